@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/globalpayments/pay-by-link-go/internal/accounting"
+)
+
+// newAccountingExportCommand creates the accounting-export subcommand, which writes the
+// links settled in a period in a format bookkeeping software imports
+func newAccountingExportCommand() *cobra.Command {
+	var (
+		format, fromDate, toDate, output string
+		opts                             accounting.Options
+	)
+
+	cmd := &cobra.Command{
+		Use:   "accounting-export",
+		Short: "Export settled payment links for Xero or QuickBooks",
+		Long: `Export settled payment links for Xero or QuickBooks.
+
+Links paid in the period whose payments have been captured are written as Xero sales
+invoices (--format xero), or as QuickBooks Desktop invoices with their payments in IIF
+(--format quickbooks). Amounts are split into the net sale and its tax. Without --from
+and --to, the previous calendar month in UTC is exported, so the command can run from
+cron at the start of each month.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format = strings.ToLower(format)
+			if !slices.Contains(accounting.Formats, format) {
+				return fmt.Errorf("--format must be one of %s", strings.Join(accounting.Formats, ", "))
+			}
+			from, to, err := accountingPeriod(fromDate, toDate, time.Now())
+			if err != nil {
+				return err
+			}
+
+			cfg, err := setupCommand()
+			if err != nil {
+				return err
+			}
+			links, err := openLinkStore(cfg.Store)
+			if err != nil {
+				return fmt.Errorf("error opening link store: %w", err)
+			}
+			defer links.Close()
+
+			var w io.Writer = cmd.OutOrStdout()
+			var file *os.File
+			if output != "" {
+				if file, err = os.Create(output); err != nil {
+					return fmt.Errorf("error creating %s: %w", output, err)
+				}
+				w = file
+			}
+
+			exported, err := accounting.Export(cmd.Context(), links, format, w, from, to, opts)
+			if file != nil {
+				// A partial file would import only some of the period's sales
+				err = errors.Join(err, file.Close())
+				if err != nil {
+					os.Remove(output)
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("error exporting settled links: %w", err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d settled links paid from %s to %s\n",
+				exported, from.Format(time.DateOnly), to.Format(time.DateOnly))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "", "export format: xero or quickbooks")
+	cmd.Flags().StringVar(&fromDate, "from", "", "first day of the period, as YYYY-MM-DD (default the first day of last month)")
+	cmd.Flags().StringVar(&toDate, "to", "", "last day of the period, as YYYY-MM-DD (default the last day of last month)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write the export to this file rather than standard output")
+	cmd.Flags().StringVar(&opts.Contact, "contact", "", `contact for links with no customer (default "Pay by Link Customer")`)
+	cmd.Flags().StringVar(&opts.IncomeAccount, "income-account", "", "account the net sales are posted to (default 200 for Xero, Sales for QuickBooks)")
+	cmd.Flags().StringVar(&opts.TaxType, "tax-type", "", "Xero tax rate of the sales (default the income account's rate)")
+	cmd.Flags().StringVar(&opts.TaxAccount, "tax-account", "", `QuickBooks account tax is owed to (default "Sales Tax Payable")`)
+	cmd.Flags().StringVar(&opts.DepositAccount, "deposit-account", "", `QuickBooks account payments are deposited to (default "Undeposited Funds")`)
+	cmd.Flags().BoolVar(&opts.USDates, "us-dates", false, "write Xero dates as MM/DD/YYYY rather than DD/MM/YYYY")
+	cmd.MarkFlagRequired("format")
+	return cmd
+}
+
+// accountingPeriod returns the start of the day from and the end of the day to, in UTC.
+// Either defaults to the bounds of the calendar month before now.
+func accountingPeriod(from, to string, now time.Time) (time.Time, time.Time, error) {
+	thisMonth := time.Date(now.UTC().Year(), now.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+	start := thisMonth.AddDate(0, -1, 0)
+	end := thisMonth.Add(-time.Nanosecond)
+
+	if from != "" {
+		parsed, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return start, end, fmt.Errorf("--from must be a date as YYYY-MM-DD")
+		}
+		start = parsed
+	}
+	if to != "" {
+		parsed, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return start, end, fmt.Errorf("--to must be a date as YYYY-MM-DD")
+		}
+		end = parsed.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	if end.Before(start) {
+		return start, end, fmt.Errorf("--to must not be before --from")
+	}
+	return start, end, nil
+}
@@ -0,0 +1,888 @@
+// Package client is a Go client for the Pay by Link sample server's link endpoints, so
+// other Go services can create, look up, list, and cancel payment links without
+// hand-writing HTTP calls.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// userAgent identifies this client in requests to the server
+const userAgent = "PayByLink-Go-Client/1.0"
+
+// maxErrorBodyBytes bounds how much of a non-JSON error response is kept
+const maxErrorBodyBytes = 4 << 10
+
+// Link statuses reported by the server
+const (
+	StatusActive   = "ACTIVE"
+	StatusInactive = "INACTIVE"
+	StatusExpired  = "EXPIRED"
+	StatusPaid     = "PAID"
+)
+
+// Client calls a Pay by Link server with an API key
+type Client struct {
+	baseURL string
+	apiKey  string
+
+	// HTTPClient sends the requests. It may be replaced before the Client is first used.
+	HTTPClient *http.Client
+}
+
+// New creates a Client for the server at baseURL, e.g. http://localhost:8000.
+// apiKey is sent as X-API-Key and may be empty when the server has no API_KEYS set.
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// CreateLinkRequest describes a payment link to create. Amounts are decimal strings in
+// major units, e.g. "10.00"; optional fields left empty use the server's defaults.
+type CreateLinkRequest struct {
+	Amount         string `json:"amount"`
+	Currency       string `json:"currency"`
+	Reference      string `json:"reference"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	UsageMode      string `json:"usageMode,omitempty"`
+	UsageLimit     string `json:"usageLimit,omitempty"`
+	ExpirationDays string `json:"expirationDays,omitempty"`
+	ExpirationDate string `json:"expirationDate,omitempty"`
+	ReturnURL      string `json:"returnUrl,omitempty"`
+	StatusURL      string `json:"statusUrl,omitempty"`
+	CancelURL      string `json:"cancelUrl,omitempty"`
+	CustomerPhone  string `json:"customerPhone,omitempty"`
+	PaymentMethods string `json:"paymentMethods,omitempty"`
+	DigitalWallets string `json:"digitalWallets,omitempty"`
+	// Shippable overrides the server's GP_API_SHIPPABLE default when set
+	Shippable      *bool  `json:"shippable,omitempty"`
+	ShippingAmount string `json:"shippingAmount,omitempty"`
+	Country        string `json:"country,omitempty"`
+	// CaptureMode is AUTO or LATER; LATER payments are captured with CaptureTransaction
+	CaptureMode string `json:"captureMode,omitempty"`
+	// Reminders set to false opts the link out of payment reminders
+	Reminders *bool `json:"reminders,omitempty"`
+	// CustomerID associates the link with a customer created with CreateCustomer
+	CustomerID string `json:"customerId,omitempty"`
+	// TemplateID names a link template whose presets fill in the fields left empty
+	TemplateID string `json:"templateId,omitempty"`
+	// Items prices the link from the server's product catalog, setting Amount and
+	// appending the itemization to Description
+	Items []LineItem `json:"items,omitempty"`
+	// PromoCode applies one of the server's configured discounts to the amount
+	PromoCode string `json:"promoCode,omitempty"`
+	// TaxRegion selects a regional tax rate within the link's country, such as CA for US-CA
+	TaxRegion string `json:"taxRegion,omitempty"`
+	// MinAmount and MaxAmount, in major units, make an open-amount link on which the payer
+	// chooses the amount; leave Amount empty when either is set
+	MinAmount string `json:"minAmount,omitempty"`
+	MaxAmount string `json:"maxAmount,omitempty"`
+	// Metadata attaches key-value pairs, such as an order ID, that are stored with the link
+	// and sent in its webhook events
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// MerchantID and AccountName create the link for another merchant or account the server
+	// allows in MERCHANT_ACCOUNTS, for partner credentials
+	MerchantID  string `json:"merchantId,omitempty"`
+	AccountName string `json:"accountName,omitempty"`
+	// DynamicDescriptor, up to 22 characters, is shown on the payer's card statement in
+	// place of the merchant name
+	DynamicDescriptor string `json:"dynamicDescriptor,omitempty"`
+	// PayerEmail, BillingCountry, and PayerIP are sent to GP for fraud screening, and links
+	// for payers on the server's blocklists are refused with RISK_DECLINED
+	PayerEmail     string `json:"payerEmail,omitempty"`
+	BillingCountry string `json:"billingCountry,omitempty"`
+	PayerIP        string `json:"payerIp,omitempty"`
+	// Language is the BCP 47 tag, such as es or fr-CA, of the language GP's hosted payment
+	// page is shown in
+	Language string `json:"language,omitempty"`
+	// BrandDisplayName, BrandLogoURL, and BrandColor brand GP's hosted payment page for the
+	// link in place of the server's defaults. BrandLogoURL must use https and BrandColor is
+	// a hex color such as #1A73E8.
+	BrandDisplayName string `json:"brandDisplayName,omitempty"`
+	BrandLogoURL     string `json:"brandLogoUrl,omitempty"`
+	BrandColor       string `json:"brandColor,omitempty"`
+	// Images are up to 10 HTTPS URLs of pictures of what the payer is paying for, shown on
+	// GP's hosted payment page
+	Images []string `json:"images,omitempty"`
+}
+
+// LineItem is one line of a link priced from the product catalog. UnitPrice is optional;
+// when set, the server rejects the link if it does not match the catalog price.
+type LineItem struct {
+	SKU       string `json:"sku"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice string `json:"unitPrice,omitempty"`
+}
+
+// CreateRecurringLinksRequest schedules a series of single-use installment links. The link
+// fields apply to every installment; UsageMode, UsageLimit, and ExpirationDate must be empty.
+type CreateRecurringLinksRequest struct {
+	CreateLinkRequest
+	// Cadence is WEEKLY or MONTHLY
+	Cadence string `json:"cadence"`
+	// Occurrences is the number of installments, from 2 to 52
+	Occurrences string `json:"occurrences"`
+}
+
+// RecurringLinks is a recurring link series. Amounts are in minor units.
+type RecurringLinks struct {
+	SeriesID      string        `json:"seriesId"`
+	Reference     string        `json:"reference"`
+	Cadence       string        `json:"cadence"`
+	Occurrences   int           `json:"occurrences"`
+	Amount        int64         `json:"amount"`
+	DisplayAmount string        `json:"displayAmount"`
+	Currency      string        `json:"currency"`
+	PaidCount     int           `json:"paidCount"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	Installments  []Installment `json:"installments"`
+}
+
+// Installment is one payment of a recurring link series. Status is SCHEDULED until its
+// link is created, then the link's status, or FAILED if the link could not be created.
+type Installment struct {
+	Number      int       `json:"number"`
+	Reference   string    `json:"reference"`
+	DueAt       time.Time `json:"dueAt"`
+	Status      string    `json:"status"`
+	LinkID      string    `json:"linkId,omitempty"`
+	PaymentLink string    `json:"paymentLink,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Bool returns a pointer to v, for optional fields such as CreateLinkRequest.Shippable
+func Bool(v bool) *bool {
+	return &v
+}
+
+// CreatedLink is a newly created payment link. Amounts are in minor units.
+type CreatedLink struct {
+	PaymentLink    string    `json:"paymentLink"`
+	ShortLink      string    `json:"shortLink,omitempty"`
+	LinkID         string    `json:"linkId"`
+	Reference      string    `json:"reference"`
+	Amount         int       `json:"amount"`
+	DisplayAmount  string    `json:"displayAmount"`
+	Currency       string    `json:"currency"`
+	UsageMode      string    `json:"usageMode"`
+	UsageLimit     int       `json:"usageLimit"`
+	ExpiresAt      string    `json:"expiresAt"`
+	PaymentMethods []string  `json:"paymentMethods"`
+	DigitalWallets []string  `json:"digitalWallets,omitempty"`
+	Shippable      bool      `json:"shippable"`
+	ShippingAmount int64     `json:"shippingAmount"`
+	Country        string    `json:"country"`
+	CaptureMode    string    `json:"captureMode"`
+	Reminders      bool      `json:"reminders"`
+	CustomerID     string    `json:"customerId,omitempty"`
+	PromoCode      string    `json:"promoCode,omitempty"`
+	DiscountAmount int64     `json:"discountAmount,omitempty"`
+	NetAmount      int64     `json:"netAmount,omitempty"`
+	TaxAmount      int64     `json:"taxAmount,omitempty"`
+	TaxRate        string    `json:"taxRate,omitempty"`
+	MinAmount      int64     `json:"minAmount,omitempty"`
+	MaxAmount      int64     `json:"maxAmount,omitempty"`
+	SMSDelivery    *Delivery `json:"smsDelivery,omitempty"`
+
+	// Metadata holds the key-value pairs the link was created with
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// MerchantID and AccountName are set when the request selected a merchant account
+	MerchantID  string `json:"merchantId,omitempty"`
+	AccountName string `json:"accountName,omitempty"`
+	// DynamicDescriptor is the statement descriptor the link was created with
+	DynamicDescriptor string `json:"dynamicDescriptor,omitempty"`
+	// Language is the hosted payment page language the link was created with
+	Language string `json:"language,omitempty"`
+	// Branding is the hosted payment page branding the link was created with, including
+	// the server's defaults
+	Branding *LinkBranding `json:"branding,omitempty"`
+	// Images are the product image URLs the link was created with
+	Images []string `json:"images,omitempty"`
+}
+
+// LinkBranding is how GP's hosted payment page presents the merchant
+type LinkBranding struct {
+	DisplayName string `json:"displayName,omitempty"`
+	LogoURL     string `json:"logoUrl,omitempty"`
+	Color       string `json:"color,omitempty"`
+}
+
+// Delivery is an attempt to send a payment link to a customer, such as by SMS
+type Delivery struct {
+	ID         int64     `json:"id"`
+	LinkID     string    `json:"linkId"`
+	Channel    string    `json:"channel"`
+	Recipient  string    `json:"recipient"`
+	ProviderID string    `json:"providerId,omitempty"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// LinkDetail is a payment link as currently reported by GP API, with its transactions
+type LinkDetail struct {
+	LinkID         string        `json:"linkId"`
+	PaymentLink    string        `json:"paymentLink"`
+	Status         string        `json:"status"`
+	Paid           bool          `json:"paid"`
+	Reference      string        `json:"reference"`
+	Name           string        `json:"name"`
+	Amount         int64         `json:"amount"`
+	Currency       string        `json:"currency"`
+	UsageMode      string        `json:"usageMode"`
+	UsageLimit     int64         `json:"usageLimit"`
+	UsageCount     int64         `json:"usageCount"`
+	ViewedCount    int64         `json:"viewedCount"`
+	ExpirationDate string        `json:"expirationDate"`
+	Transactions   []Transaction `json:"transactions"`
+}
+
+// Transaction is a payment attempt made through a link
+type Transaction struct {
+	ID          string `json:"id"`
+	Type        string `json:"type,omitempty"`
+	Status      string `json:"status"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Reference   string `json:"reference,omitempty"`
+	TimeCreated string `json:"timeCreated"`
+}
+
+// LinkTransactions lists the payments taken through a link, as reported by GP API.
+// PaidCount and PaidAmount cover captured and preauthorized payments, in minor units.
+type LinkTransactions struct {
+	LinkID       string        `json:"linkId"`
+	Status       string        `json:"status"`
+	UsageMode    string        `json:"usageMode"`
+	UsageLimit   int64         `json:"usageLimit"`
+	UsageCount   int64         `json:"usageCount"`
+	PaidCount    int           `json:"paidCount"`
+	PaidAmount   int64         `json:"paidAmount"`
+	Currency     string        `json:"currency"`
+	Transactions []Transaction `json:"transactions"`
+}
+
+// Refund is a refund of a link payment. Amounts are in minor units.
+type Refund struct {
+	RefundID      string `json:"refundId"`
+	TransactionID string `json:"transactionId"`
+	Status        string `json:"status"`
+	Amount        int64  `json:"amount"`
+	DisplayAmount string `json:"displayAmount"`
+	Currency      string `json:"currency"`
+	TimeCreated   string `json:"timeCreated"`
+}
+
+// Link is a payment link as recorded by the server
+type Link struct {
+	ID                string     `json:"linkId"`
+	URL               string     `json:"paymentLink"`
+	Reference         string     `json:"reference"`
+	Amount            int64      `json:"amount"`
+	Currency          string     `json:"currency"`
+	Status            string     `json:"status"`
+	TransactionID     string     `json:"transactionId,omitempty"`
+	TransactionStatus string     `json:"transactionStatus,omitempty"`
+	CustomerPhone     string     `json:"customerPhone,omitempty"`
+	CustomerID        string     `json:"customerId,omitempty"`
+	ExpiresAt         time.Time  `json:"expiresAt"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+	RemindersOptOut   bool       `json:"remindersOptOut,omitempty"`
+	RemindersSent     int        `json:"remindersSent,omitempty"`
+	LastReminderAt    *time.Time `json:"lastReminderAt,omitempty"`
+	PromoCode         string     `json:"promoCode,omitempty"`
+	DiscountAmount    int64      `json:"discountAmount,omitempty"`
+	TaxAmount         int64      `json:"taxAmount,omitempty"`
+	TaxRate           string     `json:"taxRate,omitempty"`
+	MinAmount         int64      `json:"minAmount,omitempty"`
+	MaxAmount         int64      `json:"maxAmount,omitempty"`
+	ShortCode         string     `json:"shortCode,omitempty"`
+
+	// Metadata holds the key-value pairs the link was created with
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// CreateCustomerRequest adds a customer to the server's directory. Only Name is required;
+// Phone must be in E.164 format.
+type CreateCustomerRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// Customer is a customer in the server's directory
+type Customer struct {
+	ID        string    `json:"customerId"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	Phone     string    `json:"phone,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// LinkTemplateRequest saves a named preset for link creation. Only Name is required; an
+// Amount needs a Currency.
+type LinkTemplateRequest struct {
+	Name           string `json:"name"`
+	Amount         string `json:"amount,omitempty"`
+	Currency       string `json:"currency,omitempty"`
+	Description    string `json:"description,omitempty"`
+	UsageMode      string `json:"usageMode,omitempty"`
+	UsageLimit     string `json:"usageLimit,omitempty"`
+	ExpirationDays string `json:"expirationDays,omitempty"`
+}
+
+// LinkTemplate is a saved preset that CreateLinkRequest.TemplateID refers to
+type LinkTemplate struct {
+	ID             string    `json:"templateId"`
+	Name           string    `json:"name"`
+	Amount         string    `json:"amount,omitempty"`
+	Currency       string    `json:"currency,omitempty"`
+	Description    string    `json:"description,omitempty"`
+	UsageMode      string    `json:"usageMode,omitempty"`
+	UsageLimit     string    `json:"usageLimit,omitempty"`
+	ExpirationDays string    `json:"expirationDays,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// ProductRequest adds or replaces a product in the server's catalog. UnitPrice is in
+// major units, like CreateLinkRequest.Amount.
+type ProductRequest struct {
+	Name      string `json:"name"`
+	UnitPrice string `json:"unitPrice"`
+	Currency  string `json:"currency"`
+}
+
+// Product is a product in the server's catalog. UnitPrice is in minor units.
+type Product struct {
+	SKU       string    `json:"sku"`
+	Name      string    `json:"name"`
+	UnitPrice int64     `json:"unitPrice"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ListLinksParams filters a link listing. Zero values are left out of the query.
+type ListLinksParams struct {
+	Reference string
+	Status    string
+	Currency  string
+	// From and To limit links to those created in the range, inclusive
+	From time.Time
+	To   time.Time
+	// Limit is the page size, 1 to 100; the server defaults to 20
+	Limit int
+	// Cursor continues a listing from LinkPage.NextCursor
+	Cursor string
+	// Refresh updates stored statuses from GP API before listing
+	Refresh bool
+	// Metadata selects links that have every one of these metadata keys and values
+	Metadata map[string]string
+}
+
+// LinkPage is one page of a link listing, newest first
+type LinkPage struct {
+	Links      []Link
+	HasMore    bool
+	NextCursor string
+}
+
+// ListTransactionsParams filters GP API's transaction report. Zero values are left out of the query.
+type ListTransactionsParams struct {
+	// From and To limit transactions to those created in the range, inclusive.
+	// The server defaults to the 30 days up to now.
+	From      time.Time
+	To        time.Time
+	Status    string
+	Reference string
+	// Limit is the page size, 1 to 100; the server defaults to 20
+	Limit int
+	// Cursor continues a listing from TransactionPage.NextCursor
+	Cursor string
+}
+
+// TransactionPage is one page of GP API's transaction report, newest first
+type TransactionPage struct {
+	Transactions []Transaction
+	HasMore      bool
+	NextCursor   string
+}
+
+// CancelledLink is the result of cancelling a link
+type CancelledLink struct {
+	LinkID string `json:"linkId"`
+	Status string `json:"status"`
+}
+
+// FieldError describes one invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error is a failed response from the server
+type Error struct {
+	StatusCode int
+	Message    string
+	// Code is the machine-readable error code, such as VALIDATION_ERROR or RATE_LIMITED
+	Code    string
+	Details string
+	// ResponseCode and GPRequestID identify a failed GP API call behind the error
+	ResponseCode int
+	GPRequestID  string
+	Fields       []FieldError
+	// Allowed lists the accepted values when a value is not supported, such as the
+	// supported currencies with CURRENCY_NOT_SUPPORTED
+	Allowed []string
+	// GPErrorCode and GPDetailedErrorCode are GP's own codes for the failed GP API call
+	GPErrorCode         string
+	GPDetailedErrorCode string
+	// RequestID matches the server's X-Request-Id header and logs
+	RequestID string
+	// RetryAfter is how long to wait before retrying a rate limited request
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	message := fmt.Sprintf("pay by link: status %d", e.StatusCode)
+	if e.Code != "" {
+		message += " " + e.Code
+	}
+	if e.Details != "" {
+		message += ": " + e.Details
+	} else if e.Message != "" {
+		message += ": " + e.Message
+	}
+	return message
+}
+
+// ErrorCode returns the server's error code for err, or "" if err is not an *Error
+func ErrorCode(err error) string {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+// envelope is the response body shared by every endpoint
+type envelope struct {
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Data       json.RawMessage `json:"data"`
+	Pagination *struct {
+		HasMore    bool   `json:"hasMore"`
+		NextCursor string `json:"nextCursor"`
+	} `json:"pagination"`
+	Error *struct {
+		Code         string       `json:"code"`
+		Details      string       `json:"details"`
+		ResponseCode int          `json:"responseCode"`
+		GPRequestID  string       `json:"gpRequestId"`
+		Fields       []FieldError `json:"fields"`
+		Allowed      []string     `json:"allowed"`
+		// GP's own codes for a failed GP API call
+		GPErrorCode         string `json:"gpErrorCode"`
+		GPDetailedErrorCode string `json:"gpDetailedErrorCode"`
+	} `json:"error"`
+	RequestID string `json:"requestId"`
+}
+
+// CreateLink creates a payment link
+func (c *Client) CreateLink(ctx context.Context, req CreateLinkRequest) (*CreatedLink, error) {
+	var link CreatedLink
+	if _, err := c.do(ctx, http.MethodPost, "/create-payment-link", req, &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// GetLink retrieves a payment link and its transactions from GP API through the server
+func (c *Client) GetLink(ctx context.Context, id string) (*LinkDetail, error) {
+	var detail LinkDetail
+	if _, err := c.do(ctx, http.MethodGet, "/payment-link/"+url.PathEscape(id), nil, &detail); err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
+
+// ListLinks returns one page of the links recorded by the server
+func (c *Client) ListLinks(ctx context.Context, params ListLinksParams) (*LinkPage, error) {
+	query := url.Values{}
+	setQuery(query, "reference", params.Reference)
+	setQuery(query, "status", params.Status)
+	setQuery(query, "currency", params.Currency)
+	setQuery(query, "cursor", params.Cursor)
+	setTagQuery(query, params.Metadata)
+	if !params.From.IsZero() {
+		query.Set("from", params.From.Format(time.RFC3339))
+	}
+	if !params.To.IsZero() {
+		query.Set("to", params.To.Format(time.RFC3339))
+	}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Refresh {
+		query.Set("refresh", "true")
+	}
+
+	return c.listLinks(ctx, "/payment-links", query)
+}
+
+// ListCustomerLinks lists the links associated with a customer, newest first, one page at
+// a time. Only the Status, Limit, and Cursor fields of params apply.
+func (c *Client) ListCustomerLinks(ctx context.Context, customerID string, params ListLinksParams) (*LinkPage, error) {
+	query := url.Values{}
+	setQuery(query, "status", params.Status)
+	setQuery(query, "cursor", params.Cursor)
+	setTagQuery(query, params.Metadata)
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	return c.listLinks(ctx, "/customers/"+url.PathEscape(customerID)+"/payment-links", query)
+}
+
+// listLinks fetches one page of stored links from path
+func (c *Client) listLinks(ctx context.Context, path string, query url.Values) (*LinkPage, error) {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	page := &LinkPage{}
+	response, err := c.do(ctx, http.MethodGet, path, nil, &page.Links)
+	if err != nil {
+		return nil, err
+	}
+	if response.Pagination != nil {
+		page.HasMore = response.Pagination.HasMore
+		page.NextCursor = response.Pagination.NextCursor
+	}
+	return page, nil
+}
+
+// CreateCustomer adds a customer to the server's directory, so links can be associated with it
+func (c *Client) CreateCustomer(ctx context.Context, req CreateCustomerRequest) (*Customer, error) {
+	var customer Customer
+	if _, err := c.do(ctx, http.MethodPost, "/customers", req, &customer); err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+// GetCustomer retrieves a customer from the server's directory
+func (c *Client) GetCustomer(ctx context.Context, id string) (*Customer, error) {
+	var customer Customer
+	if _, err := c.do(ctx, http.MethodGet, "/customers/"+url.PathEscape(id), nil, &customer); err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+// CreateLinkTemplate saves a link template
+func (c *Client) CreateLinkTemplate(ctx context.Context, req LinkTemplateRequest) (*LinkTemplate, error) {
+	var template LinkTemplate
+	if _, err := c.do(ctx, http.MethodPost, "/link-templates", req, &template); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// ListLinkTemplates returns every saved link template, ordered by name
+func (c *Client) ListLinkTemplates(ctx context.Context) ([]LinkTemplate, error) {
+	var templates []LinkTemplate
+	if _, err := c.do(ctx, http.MethodGet, "/link-templates", nil, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// GetLinkTemplate retrieves a saved link template
+func (c *Client) GetLinkTemplate(ctx context.Context, id string) (*LinkTemplate, error) {
+	var template LinkTemplate
+	if _, err := c.do(ctx, http.MethodGet, "/link-templates/"+url.PathEscape(id), nil, &template); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// UpdateLinkTemplate replaces the presets of a saved link template
+func (c *Client) UpdateLinkTemplate(ctx context.Context, id string, req LinkTemplateRequest) (*LinkTemplate, error) {
+	var template LinkTemplate
+	if _, err := c.do(ctx, http.MethodPut, "/link-templates/"+url.PathEscape(id), req, &template); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// DeleteLinkTemplate deletes a saved link template. Links already created from it are unaffected.
+func (c *Client) DeleteLinkTemplate(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/link-templates/"+url.PathEscape(id), nil, nil)
+	return err
+}
+
+// SaveProduct adds a product to the server's catalog or replaces the product with the same SKU
+func (c *Client) SaveProduct(ctx context.Context, sku string, req ProductRequest) (*Product, error) {
+	var product Product
+	if _, err := c.do(ctx, http.MethodPut, "/products/"+url.PathEscape(sku), req, &product); err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// ListProducts returns the server's product catalog, ordered by SKU
+func (c *Client) ListProducts(ctx context.Context) ([]Product, error) {
+	var products []Product
+	if _, err := c.do(ctx, http.MethodGet, "/products", nil, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetProduct retrieves a product from the server's catalog
+func (c *Client) GetProduct(ctx context.Context, sku string) (*Product, error) {
+	var product Product
+	if _, err := c.do(ctx, http.MethodGet, "/products/"+url.PathEscape(sku), nil, &product); err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// DeleteProduct removes a product from the server's catalog
+func (c *Client) DeleteProduct(ctx context.Context, sku string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/products/"+url.PathEscape(sku), nil, nil)
+	return err
+}
+
+// CancelLink deactivates a payment link so it can no longer be paid
+func (c *Client) CancelLink(ctx context.Context, id string) (*CancelledLink, error) {
+	var cancelled CancelledLink
+	if _, err := c.do(ctx, http.MethodPost, "/payment-link/"+url.PathEscape(id)+"/cancel", nil, &cancelled); err != nil {
+		return nil, err
+	}
+	return &cancelled, nil
+}
+
+// SetReminders turns payment reminders for a stored link off or back on, returning the updated link
+func (c *Client) SetReminders(ctx context.Context, id string, enabled bool) (*Link, error) {
+	var link Link
+	body := map[string]bool{"enabled": enabled}
+	if _, err := c.do(ctx, http.MethodPost, "/payment-link/"+url.PathEscape(id)+"/reminders", body, &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// CreateRecurringLinks schedules a series of installment links. The first installment's
+// link is created at once and the rest as they fall due.
+func (c *Client) CreateRecurringLinks(ctx context.Context, req CreateRecurringLinksRequest) (*RecurringLinks, error) {
+	var series RecurringLinks
+	if _, err := c.do(ctx, http.MethodPost, "/recurring-links", req, &series); err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// GetRecurringLinks retrieves a recurring link series and the state of its installments
+func (c *Client) GetRecurringLinks(ctx context.Context, seriesID string) (*RecurringLinks, error) {
+	var series RecurringLinks
+	if _, err := c.do(ctx, http.MethodGet, "/recurring-links/"+url.PathEscape(seriesID), nil, &series); err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// ListLinkTransactions returns the payments taken through a link
+func (c *Client) ListLinkTransactions(ctx context.Context, linkID string) (*LinkTransactions, error) {
+	var transactions LinkTransactions
+	if _, err := c.do(ctx, http.MethodGet, "/payment-link/"+url.PathEscape(linkID)+"/transactions", nil, &transactions); err != nil {
+		return nil, err
+	}
+	return &transactions, nil
+}
+
+// ListTransactions returns one page of GP API's transaction report for the merchant account
+func (c *Client) ListTransactions(ctx context.Context, params ListTransactionsParams) (*TransactionPage, error) {
+	query := url.Values{}
+	setQuery(query, "status", params.Status)
+	setQuery(query, "reference", params.Reference)
+	setQuery(query, "cursor", params.Cursor)
+	if !params.From.IsZero() {
+		query.Set("from", params.From.Format(time.RFC3339))
+	}
+	if !params.To.IsZero() {
+		query.Set("to", params.To.Format(time.RFC3339))
+	}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+
+	path := "/transactions"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	page := &TransactionPage{}
+	response, err := c.do(ctx, http.MethodGet, path, nil, &page.Transactions)
+	if err != nil {
+		return nil, err
+	}
+	if response.Pagination != nil {
+		page.HasMore = response.Pagination.HasMore
+		page.NextCursor = response.Pagination.NextCursor
+	}
+	return page, nil
+}
+
+// CaptureTransaction captures a payment authorized through a LATER capture mode link
+func (c *Client) CaptureTransaction(ctx context.Context, id string) (*Transaction, error) {
+	var transaction Transaction
+	if _, err := c.do(ctx, http.MethodPost, "/transactions/"+url.PathEscape(id)+"/capture", nil, &transaction); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// RefundTransaction refunds amount, in major units such as "5.00", of a captured payment.
+// An empty amount refunds the whole payment.
+func (c *Client) RefundTransaction(ctx context.Context, id, amount string) (*Refund, error) {
+	var body interface{}
+	if amount != "" {
+		body = map[string]string{"amount": amount}
+	}
+	var refund Refund
+	if _, err := c.do(ctx, http.MethodPost, "/transactions/"+url.PathEscape(id)+"/refund", body, &refund); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// do sends a request with an optional JSON body and decodes the data of a successful
+// response into out. Failed responses are returned as *Error.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) (*envelope, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var response envelope
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			// Some failures, such as 405 Method Not Allowed, are plain text
+			return nil, newError(resp, &response, respBody)
+		}
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || !response.Success {
+		return nil, newError(resp, &response, nil)
+	}
+
+	if out != nil && len(response.Data) > 0 {
+		if err := json.Unmarshal(response.Data, out); err != nil {
+			return nil, fmt.Errorf("failed to decode response data: %w", err)
+		}
+	}
+	return &response, nil
+}
+
+// newError builds an *Error from a failed response. rawBody is used as the details
+// when the response was not a JSON envelope.
+func newError(resp *http.Response, response *envelope, rawBody []byte) *Error {
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		Message:    response.Message,
+		RequestID:  response.RequestID,
+	}
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = resp.Header.Get("X-Request-Id")
+	}
+	if response.Error != nil {
+		apiErr.Code = response.Error.Code
+		apiErr.Details = response.Error.Details
+		apiErr.ResponseCode = response.Error.ResponseCode
+		apiErr.GPRequestID = response.Error.GPRequestID
+		apiErr.Fields = response.Error.Fields
+		apiErr.Allowed = response.Error.Allowed
+		apiErr.GPErrorCode = response.Error.GPErrorCode
+		apiErr.GPDetailedErrorCode = response.Error.GPDetailedErrorCode
+	}
+	if rawBody != nil {
+		if len(rawBody) > maxErrorBodyBytes {
+			rawBody = rawBody[:maxErrorBodyBytes]
+		}
+		apiErr.Details = strings.TrimSpace(string(rawBody))
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
+
+// setQuery adds key to query when value is not empty
+func setQuery(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+// setTagQuery adds a tag=key:value parameter for each metadata filter, in key order
+func setTagQuery(query url.Values, metadata map[string]string) {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		query.Add("tag", key+":"+metadata[key])
+	}
+}
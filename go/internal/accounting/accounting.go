@@ -0,0 +1,186 @@
+// Package accounting exports settled payment links for import into accounting software:
+// as sales invoices in Xero's CSV import template, or as invoices with their payments in
+// QuickBooks Desktop's IIF format.
+package accounting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// Export formats
+const (
+	FormatXero       = "xero"
+	FormatQuickBooks = "quickbooks"
+)
+
+// Formats lists the supported export formats
+var Formats = []string{FormatXero, FormatQuickBooks}
+
+// pageSize is how many links are read from the store at a time
+const pageSize = 500
+
+// defaultContact names the customer of a link with no associated customer
+const defaultContact = "Pay by Link Customer"
+
+// settledStatuses are the transaction statuses of payments that have been taken, rather
+// than only authorized
+var settledStatuses = map[string]bool{"CAPTURED": true, "BATCH_CLOSED": true, "FUNDED": true}
+
+// Options name the accounts and contact the rows are posted to. Empty fields take the
+// format's defaults.
+type Options struct {
+	// Contact names the customer of links with no customer in the directory
+	Contact string
+	// IncomeAccount receives the net sale: a Xero account code, 200 by default, or a
+	// QuickBooks account, Sales by default
+	IncomeAccount string
+	// TaxType is the Xero tax rate of the sale. When empty, Xero uses the income
+	// account's default rate.
+	TaxType string
+	// TaxAccount is the QuickBooks liability account tax is owed to, Sales Tax Payable by default
+	TaxAccount string
+	// DepositAccount is the QuickBooks account payments are deposited to, Undeposited
+	// Funds by default
+	DepositAccount string
+	// USDates writes Xero dates as MM/DD/YYYY rather than DD/MM/YYYY, for organisations
+	// in the United States. IIF dates are always MM/DD/YYYY.
+	USDates bool
+}
+
+// sale is a settled link with its customer, if it has one
+type sale struct {
+	link     *store.Link
+	customer *store.Customer
+	// paidAt is when the link was paid, taken from its last update
+	paidAt time.Time
+}
+
+// contact returns the name the sale is recorded against
+func (s sale) contact(opts Options) string {
+	if s.customer != nil && s.customer.Name != "" {
+		return s.customer.Name
+	}
+	return opts.Contact
+}
+
+// description describes the sale, with its tax rate and promo code
+func (s sale) description() string {
+	description := "Payment link " + s.link.ID
+	if s.link.Reference != "" {
+		description += " (" + s.link.Reference + ")"
+	}
+	if s.link.TaxRate != "" {
+		description += ", including tax at " + s.link.TaxRate + "%"
+	}
+	if s.link.PromoCode != "" {
+		description += ", promo code " + s.link.PromoCode
+	}
+	return description
+}
+
+// net returns the sale's amount before tax, in minor units
+func (s sale) net() int64 {
+	return s.link.Amount - s.link.TaxAmount
+}
+
+// writer writes sales in one format
+type writer interface {
+	write(s sale) error
+	// close writes anything buffered; it does not close the underlying writer
+	close() error
+}
+
+// Export writes the links paid from from to to, inclusive, whose payments have been
+// captured, in format to w. It returns how many links were exported.
+func Export(ctx context.Context, links store.LinkStore, format string, w io.Writer, from, to time.Time, opts Options) (int, error) {
+	if opts.Contact == "" {
+		opts.Contact = defaultContact
+	}
+	var out writer
+	switch format {
+	case FormatXero:
+		if opts.IncomeAccount == "" {
+			opts.IncomeAccount = "200"
+		}
+		out = newXeroWriter(w, opts)
+	case FormatQuickBooks:
+		if opts.IncomeAccount == "" {
+			opts.IncomeAccount = "Sales"
+		}
+		if opts.TaxAccount == "" {
+			opts.TaxAccount = "Sales Tax Payable"
+		}
+		if opts.DepositAccount == "" {
+			opts.DepositAccount = "Undeposited Funds"
+		}
+		out = newIIFWriter(w, opts)
+	default:
+		return 0, fmt.Errorf("unsupported format %q, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+
+	// A link paid in the period was created before its end. Its last update is when it
+	// was paid, or when its payment was captured.
+	customers := make(map[string]*store.Customer)
+	exported := 0
+	filter := store.LinkFilter{Status: store.LinkStatusPaid, CreatedTo: to, Limit: pageSize}
+	for {
+		page, err := links.ListLinks(ctx, filter)
+		if err != nil {
+			return exported, fmt.Errorf("failed to list paid links: %w", err)
+		}
+		for _, link := range page {
+			if !settledStatuses[strings.ToUpper(link.TransactionStatus)] ||
+				link.UpdatedAt.Before(from) || link.UpdatedAt.After(to) {
+				continue
+			}
+			customer, err := lookupCustomer(ctx, links, customers, link.CustomerID)
+			if err != nil {
+				return exported, err
+			}
+			if err := out.write(sale{link: link, customer: customer, paidAt: link.UpdatedAt}); err != nil {
+				return exported, fmt.Errorf("failed to write link %s: %w", link.ID, err)
+			}
+			exported++
+		}
+		if len(page) < filter.Limit {
+			break
+		}
+		last := page[len(page)-1]
+		filter.After = &store.LinkCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	if err := out.close(); err != nil {
+		return exported, fmt.Errorf("failed to write the export: %w", err)
+	}
+	return exported, nil
+}
+
+// lookupCustomer returns the customer with the given ID, or nil for none or a customer
+// who has since been deleted. Customers are cached, as many links may share one.
+func lookupCustomer(ctx context.Context, links store.LinkStore, cache map[string]*store.Customer, id string) (*store.Customer, error) {
+	if id == "" {
+		return nil, nil
+	}
+	if customer, ok := cache[id]; ok {
+		return customer, nil
+	}
+	customer, err := links.GetCustomer(ctx, id)
+	if err != nil && !errors.Is(err, store.ErrCustomerNotFound) {
+		return nil, fmt.Errorf("failed to read customer %s: %w", id, err)
+	}
+	cache[id] = customer
+	return customer, nil
+}
+
+// amount formats minor units in major units, as accounting software expects
+func amount(minor int64, currency string) string {
+	return money.FormatMinorUnits(minor, currency)
+}
@@ -0,0 +1,82 @@
+package accounting
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/spreadsheet"
+)
+
+// iifReceivable is the QuickBooks account invoices are posted to and payments taken from
+const iifReceivable = "Accounts Receivable"
+
+// iifDateLayout is the date format QuickBooks Desktop reads from IIF files
+const iifDateLayout = "01/02/2006"
+
+// iifHeader declares the columns of the transaction and split lines that follow
+const iifHeader = "!TRNS\tTRNSTYPE\tDATE\tACCNT\tNAME\tAMOUNT\tDOCNUM\tMEMO\n" +
+	"!SPL\tTRNSTYPE\tDATE\tACCNT\tNAME\tAMOUNT\tDOCNUM\tMEMO\n" +
+	"!ENDTRNS\n"
+
+// iifField strips the tabs, line breaks, and double quotes that would break an IIF line
+var iifField = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ", `"`, "")
+
+// iifWriter writes each sale as an invoice, split between the income and tax accounts,
+// followed by its payment into the deposit account
+type iifWriter struct {
+	w      *bufio.Writer
+	opts   Options
+	header bool
+}
+
+func newIIFWriter(w io.Writer, opts Options) *iifWriter {
+	return &iifWriter{w: bufio.NewWriter(w), opts: opts}
+}
+
+// line writes one transaction or split line
+func (q *iifWriter) line(kind, trnsType, date, account, name, amount, docNum, memo string) {
+	fields := []string{kind, trnsType, date, account, name, amount, docNum, memo}
+	for i, field := range fields {
+		fields[i] = iifField.Replace(field)
+	}
+	q.w.WriteString(strings.Join(fields, "\t") + "\n")
+}
+
+func (q *iifWriter) write(s sale) error {
+	if !q.header {
+		q.w.WriteString(iifHeader)
+		q.header = true
+	}
+
+	link := s.link
+	// IIF files are tab-separated and often opened in a spreadsheet, so the names and
+	// memos chosen by whoever created the link are guarded against being run as formulas
+	name := spreadsheet.Text(s.contact(q.opts))
+	memo := spreadsheet.Text(s.description())
+	invoiced := link.CreatedAt.UTC().Format(iifDateLayout)
+	paid := s.paidAt.UTC().Format(iifDateLayout)
+	gross := amount(link.Amount, link.Currency)
+
+	// The invoice debits receivables with the gross amount, crediting the net sale to
+	// income and the tax to the tax liability
+	q.line("TRNS", "INVOICE", invoiced, iifReceivable, name, gross, link.ID, memo)
+	q.line("SPL", "INVOICE", invoiced, q.opts.IncomeAccount, name, amount(-s.net(), link.Currency), link.ID, memo)
+	if link.TaxAmount != 0 {
+		q.line("SPL", "INVOICE", invoiced, q.opts.TaxAccount, name, amount(-link.TaxAmount, link.Currency), link.ID, memo)
+	}
+	q.w.WriteString("ENDTRNS\n")
+
+	// The payment moves the gross amount from receivables to the deposit account
+	q.line("TRNS", "PAYMENT", paid, q.opts.DepositAccount, name, gross, link.TransactionID, memo)
+	q.line("SPL", "PAYMENT", paid, iifReceivable, name, amount(-link.Amount, link.Currency), link.TransactionID, memo)
+	_, err := q.w.WriteString("ENDTRNS\n")
+	return err
+}
+
+func (q *iifWriter) close() error {
+	if !q.header {
+		q.w.WriteString(iifHeader)
+	}
+	return q.w.Flush()
+}
@@ -0,0 +1,84 @@
+package accounting
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/globalpayments/pay-by-link-go/internal/spreadsheet"
+)
+
+// xeroColumns is the header row of Xero's sales invoice import template. Xero requires
+// the columns marked with an asterisk.
+var xeroColumns = []string{
+	"*ContactName", "EmailAddress", "POAddressLine1", "POAddressLine2", "POAddressLine3", "POAddressLine4",
+	"POCity", "PORegion", "POPostalCode", "POCountry", "*InvoiceNumber", "Reference", "*InvoiceDate",
+	"*DueDate", "Total", "InventoryItemCode", "*Description", "*Quantity", "*UnitAmount", "Discount",
+	"*AccountCode", "*TaxType", "TaxAmount", "TrackingName1", "TrackingOption1", "TrackingName2",
+	"TrackingOption2", "Currency", "BrandingTheme",
+}
+
+// xeroWriter writes each sale as a single-line sales invoice, with tax-exclusive amounts.
+// The invoice is dated when the link was created and due when it was paid.
+type xeroWriter struct {
+	csv        *csv.Writer
+	opts       Options
+	dateLayout string
+	header     bool
+}
+
+func newXeroWriter(w io.Writer, opts Options) *xeroWriter {
+	layout := "02/01/2006"
+	if opts.USDates {
+		layout = "01/02/2006"
+	}
+	return &xeroWriter{csv: csv.NewWriter(w), opts: opts, dateLayout: layout}
+}
+
+func (x *xeroWriter) write(s sale) error {
+	if !x.header {
+		if err := x.csv.Write(xeroColumns); err != nil {
+			return err
+		}
+		x.header = true
+	}
+
+	row := make([]string, len(xeroColumns))
+	set := func(column, value string) {
+		for i, name := range xeroColumns {
+			if name == column {
+				row[i] = value
+				return
+			}
+		}
+	}
+	// Names and references are chosen by whoever created the link or customer, so they
+	// are guarded against being run as formulas
+	link := s.link
+	set("*ContactName", spreadsheet.Text(s.contact(x.opts)))
+	if s.customer != nil {
+		set("EmailAddress", spreadsheet.Text(s.customer.Email))
+	}
+	set("*InvoiceNumber", link.ID)
+	set("Reference", spreadsheet.Text(link.Reference))
+	set("*InvoiceDate", link.CreatedAt.UTC().Format(x.dateLayout))
+	set("*DueDate", s.paidAt.UTC().Format(x.dateLayout))
+	set("*Description", spreadsheet.Text(s.description()))
+	set("*Quantity", "1")
+	set("*UnitAmount", amount(s.net(), link.Currency))
+	set("*AccountCode", x.opts.IncomeAccount)
+	set("*TaxType", x.opts.TaxType)
+	set("TaxAmount", amount(link.TaxAmount, link.Currency))
+	set("Currency", link.Currency)
+	return x.csv.Write(row)
+}
+
+func (x *xeroWriter) close() error {
+	// An export with no sales is still a valid template
+	if !x.header {
+		if err := x.csv.Write(xeroColumns); err != nil {
+			return err
+		}
+	}
+	x.csv.Flush()
+	return x.csv.Error()
+}
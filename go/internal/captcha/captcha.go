@@ -0,0 +1,103 @@
+// Package captcha verifies the tokens Google reCAPTCHA and Cloudflare Turnstile widgets give
+// the browser, so link creation on a public form can be kept from bots.
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+)
+
+// verifyTimeout bounds each call to the provider's verification endpoint
+const verifyTimeout = 10 * time.Second
+
+// ErrRejected is returned when the provider does not accept a token, or its reCAPTCHA v3
+// score is too low
+var ErrRejected = errors.New("CAPTCHA token rejected")
+
+// verifyResponse is the answer of both providers' siteverify endpoints
+type verifyResponse struct {
+	Success    bool     `json:"success"`
+	Score      *float64 `json:"score"`
+	Hostname   string   `json:"hostname"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Verifier checks CAPTCHA tokens with one provider
+type Verifier struct {
+	provider  string
+	siteKey   string
+	secretKey string
+	verifyURL string
+	minScore  float64
+	http      *http.Client
+}
+
+// NewVerifier creates a Verifier for the provider in cfg, calling it with client
+func NewVerifier(cfg config.Captcha, client *http.Client) *Verifier {
+	return &Verifier{
+		provider:  cfg.Provider,
+		siteKey:   cfg.SiteKey,
+		secretKey: cfg.SecretKey,
+		verifyURL: cfg.VerifyURL,
+		minScore:  cfg.MinScore,
+		http:      client,
+	}
+}
+
+// Provider names the provider, recaptcha or turnstile
+func (v *Verifier) Provider() string {
+	return v.provider
+}
+
+// SiteKey is the public key the browser renders the widget with
+func (v *Verifier) SiteKey() string {
+	return v.siteKey
+}
+
+// Verify checks token, passing remoteIP to the provider when it is known. It returns an
+// error wrapping ErrRejected when the token is not accepted, and any other error when the
+// provider could not be asked.
+func (v *Verifier) Verify(ctx context.Context, token, remoteIP string) error {
+	form := url.Values{"secret": {v.secretKey}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, verifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create %s verification request: %w", v.provider, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", v.provider, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with status %d", v.provider, resp.StatusCode)
+	}
+
+	var result verifyResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", v.provider, err)
+	}
+	if !result.Success {
+		return fmt.Errorf("%w: %s", ErrRejected, strings.Join(result.ErrorCodes, ", "))
+	}
+	if result.Score != nil && *result.Score < v.minScore {
+		return fmt.Errorf("%w: score %.1f is below %.1f", ErrRejected, *result.Score, v.minScore)
+	}
+	return nil
+}
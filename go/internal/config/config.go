@@ -0,0 +1,2578 @@
+// Package config loads the server configuration from environment variables, which a YAML
+// config file and command-line overrides can also supply.
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/globalpayments/pay-by-link-go/internal/country"
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/mockgp"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/wallet"
+)
+
+// Defaults used when the corresponding environment variables are unset
+const (
+	defaultPort            = "8000"
+	defaultMockAddr        = "127.0.0.1:0"
+	defaultMockAppID       = "mock-app-id"
+	defaultMockAppKey      = "mock-app-key"
+	defaultStoreDriver     = "sqlite"
+	defaultSQLitePath      = "paybylink.db"
+	defaultShutdownTimeout = 30 * time.Second
+	defaultCapabilitiesTTL = 15 * time.Minute
+
+	defaultNotificationMaxSkew = 15 * time.Minute
+	defaultIdempotencyTTL      = 24 * time.Hour
+
+	defaultDatabaseMaxOpenConns    = 10
+	defaultDatabaseMaxIdleConns    = 5
+	defaultDatabaseConnMaxLifetime = 30 * time.Minute
+
+	defaultMaxBodyBytes        = 64 << 10
+	defaultMaxBatchBodyBytes   = 10 << 20
+	defaultRequestTimeout      = 30 * time.Second
+	defaultBatchRequestTimeout = 110 * time.Second
+
+	defaultPaymentMethods = "CARD"
+	defaultShippable      = "true"
+	defaultShippingAmount = "0"
+	defaultCountry        = "GB"
+	defaultChannel        = gpapi.ChannelCardNotPresent
+	defaultCaptureMode    = gpapi.CaptureModeAuto
+
+	defaultReferenceFormat = "PBL-{date}-{seq}"
+
+	maxMerchantNameLength = 100
+
+	defaultReturnURL = "https://www.example.com/returnUrl"
+	defaultStatusURL = "https://www.example.com/statusUrl"
+	defaultCancelURL = "https://www.example.com/returnUrl"
+
+	defaultAPIKeyRatePerMinute = 60
+	defaultAPIKeyBurst         = 10
+
+	defaultIPRatePerMinute = 30
+	defaultIPBurst         = 5
+
+	defaultCORSMethods = "GET, POST, OPTIONS"
+	defaultCORSHeaders = "Content-Type, Authorization, X-API-Key, X-Captcha-Token, Idempotency-Key"
+	defaultCORSMaxAge  = 600
+
+	defaultServiceName = "pay-by-link-go"
+
+	defaultWebhookMaxAttempts  = 5
+	defaultWebhookRetryBackoff = 5 * time.Second
+
+	defaultEventSource = "/pay-by-link"
+	defaultKafkaTopic  = "pay-by-link.events"
+	defaultNATSURL     = "nats://127.0.0.1:4222"
+	defaultNATSSubject = "paybylink.events"
+
+	defaultCaptchaMinScore = 0.5
+
+	defaultHSTSMaxAge       = 365 * 24 * time.Hour
+	defaultFrameAncestors   = "'none'"
+	defaultAutocertCacheDir = "autocert-cache"
+
+	defaultReconcileInterval = 5 * time.Minute
+	defaultExpiryInterval    = time.Minute
+	defaultRecurringInterval = time.Minute
+	defaultRetentionInterval = time.Hour
+
+	defaultReminderInterval    = 15 * time.Minute
+	defaultReminderHoursBefore = 24
+	defaultReminderRepeatHours = 12
+	defaultReminderMaxCount    = 1
+
+	defaultTaxLabel = "Tax"
+
+	defaultHTTPClientTimeout             = 30 * time.Second
+	defaultHTTPClientDialTimeout         = 10 * time.Second
+	defaultHTTPClientTLSHandshakeTimeout = 10 * time.Second
+	defaultHTTPClientIdleConnTimeout     = 90 * time.Second
+	defaultHTTPClientMaxIdleConns        = 100
+	defaultHTTPClientMaxIdleConnsPerHost = 10
+
+	defaultOIDCScopes        = "openid,profile,email"
+	defaultOIDCRolesClaim    = "roles"
+	defaultOIDCAdminRoles    = "admin"
+	defaultOIDCOperatorRoles = "operator"
+	defaultOIDCViewerRoles   = "viewer"
+	defaultOIDCSessionTTL    = 8 * time.Hour
+)
+
+// Config holds all settings read from the environment at startup
+type Config struct {
+	GP              GPConfig
+	Port            string
+	ShutdownTimeout time.Duration
+	// CapabilitiesTTL is how long the merchant account capabilities served by /config are cached
+	CapabilitiesTTL time.Duration
+	Store           Store
+	RedisURL        string
+	Limits          Limits
+	LogLevel        slog.Level
+	LogRedaction    logging.RedactionPolicy
+	Links           LinkDefaults
+	Notifications   NotificationURLs
+	APIKeys         APIKeys
+	RateLimit       RateLimit
+	CORS            CORS
+	SMS             SMS
+	Tracing         Tracing
+	Webhooks        Webhooks
+	Reconcile       Reconcile
+	Expiry          Expiry
+	Retention       Retention
+	Recurring       Recurring
+	Reminders       Reminders
+	// PromoCodes are the discounts link requests may apply, keyed by upper-case code
+	PromoCodes map[string]PromoCode
+	Tax        Tax
+	// AmountLimits bound link amounts, keyed by upper-case currency code
+	AmountLimits map[string]AmountLimit
+	// VelocityLimits cap the links created for one reference, customer, or API key in a window
+	VelocityLimits []VelocityLimit
+	// StaticDir serves the browser client from this directory instead of the copy embedded
+	// in the binary, so edits show on reload during development. It is empty by default.
+	StaticDir string
+	// HTTPClient configures the client shared by calls to GP API, Twilio, webhook endpoints, and Slack
+	HTTPClient HTTPClient
+	// Settings are the raw settings the configuration was loaded from and where each came
+	// from, with secrets redacted, for the effective configuration admin endpoint
+	Settings []Setting
+	// OIDC signs people in to the admin dashboard through an OpenID Connect provider
+	OIDC OIDC
+	// Slack posts link events to a Slack channel for the merchant's team
+	Slack Slack
+	// EventPublisher publishes link lifecycle events to a Kafka or NATS broker
+	EventPublisher EventPublisher
+	// NotificationMaxSkew is how far the time_created of a GP status notification may be from
+	// the server's clock before the notification is rejected as a replay
+	NotificationMaxSkew time.Duration
+	// IdempotencyTTL is how long the response to a link or refund request made with an
+	// Idempotency-Key is replayed to retries of it
+	IdempotencyTTL time.Duration
+	// MerchantAccounts are the merchants and accounts link requests may select, for partner
+	// credentials that act for several merchants. Requests must use the token's own account
+	// when it is empty.
+	MerchantAccounts []MerchantAccount
+	// Risk refuses link requests whose payer details are on a blocklist before GP is called
+	Risk Risk
+	// Captcha verifies that link requests made without an API key come from a person
+	Captcha Captcha
+	// CSRFProtection requires form posts and other requests a cross-site page could send,
+	// unless they carry an API key, to echo the CSRF token issued by /config
+	CSRFProtection bool
+	// SecurityHeaders are sent with every response
+	SecurityHeaders SecurityHeaders
+	// TLS serves HTTPS directly when enabled
+	TLS TLS
+	// IPAccess restricts the admin and public endpoints to client IP ranges
+	IPAccess IPAccess
+	// Wallet signs the Apple Wallet and Google Wallet passes of payment links
+	Wallet Wallet
+}
+
+// GPConfig holds the GP API credentials and the environment to call
+type GPConfig struct {
+	AppID       string
+	AppKey      string
+	Environment string
+	BaseURL     string
+	// Version is the GP API version sent in the X-GP-Version header
+	Version string
+	Mock    MockGP
+}
+
+// MockGP configures the in-process fake GP API used instead of a real environment
+type MockGP struct {
+	Enabled   bool
+	Addr      string
+	Failures  []string
+	Latency   time.Duration
+	NotifyURL string
+}
+
+// Store selects the database the link store is kept in
+type Store struct {
+	// Driver is sqlite (default) or postgres
+	Driver     string
+	SQLitePath string
+	// DatabaseURL is the PostgreSQL connection URL used by the postgres driver
+	DatabaseURL     string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	Encryption      Encryption
+}
+
+// encryptionKeySize is the length of the AES-256 keys personal data is encrypted with
+const encryptionKeySize = 32
+
+// Encryption holds the keys personal data in the link store is encrypted with. It is
+// disabled when there are no keys.
+type Encryption struct {
+	// Keys maps each key version to its AES-256 key
+	Keys map[int][]byte
+	// Version is the version of the key new data is encrypted with; older keys are kept
+	// to read data encrypted before a rotation
+	Version int
+}
+
+// Limits bounds the size of request bodies and how long a handler may run, so slow
+// or oversized requests cannot tie up memory and connections while GP API is called
+type Limits struct {
+	MaxBodyBytes        int64
+	MaxBatchBodyBytes   int64
+	RequestTimeout      time.Duration
+	BatchRequestTimeout time.Duration
+}
+
+// HTTPClient configures the HTTP client shared by every outbound call. Proxies are taken
+// from HTTP_PROXY, HTTPS_PROXY, and NO_PROXY, and connections require TLS 1.2 or later.
+type HTTPClient struct {
+	// Timeout bounds a whole request, from dialing to reading the last byte of the response
+	Timeout             time.Duration
+	DialTimeout         time.Duration
+	TLSHandshakeTimeout time.Duration
+	// IdleConnTimeout is how long an unused pooled connection is kept open
+	IdleConnTimeout     time.Duration
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	// CABundle is a PEM file of certificate authorities trusted in addition to the system
+	// ones, such as a corporate proxy's, or empty for the system ones alone
+	CABundle string
+}
+
+// OIDC configures dashboard sign-in through an OpenID Connect provider. People are given
+// the operator or viewer role from the groups or roles their ID token carries; anyone
+// holding neither is turned away.
+type OIDC struct {
+	// IssuerURL is the provider's issuer; sign-in is disabled when it is empty
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is this server's /admin/oidc/callback URL as registered with the provider
+	RedirectURL string
+	Scopes      []string
+	// RolesClaim names the ID token claim holding the person's roles, with dots separating
+	// nested objects such as realm_access.roles
+	RolesClaim string
+	// AdminRoles are granted every permission, OperatorRoles every permission but admin,
+	// and ViewerRoles only read
+	AdminRoles    []string
+	OperatorRoles []string
+	ViewerRoles   []string
+	// SessionSecret signs the dashboard session cookie
+	SessionSecret string
+	SessionTTL    time.Duration
+}
+
+// Enabled reports whether dashboard sign-in through OpenID Connect is configured
+func (o OIDC) Enabled() bool {
+	return o.IssuerURL != ""
+}
+
+// CAPTCHA providers
+const (
+	CaptchaRecaptcha = "recaptcha"
+	CaptchaTurnstile = "turnstile"
+)
+
+// CaptchaVerifyURLs are the verification endpoints of each CAPTCHA provider
+var CaptchaVerifyURLs = map[string]string{
+	CaptchaRecaptcha: "https://www.google.com/recaptcha/api/siteverify",
+	CaptchaTurnstile: "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+// Captcha configures the CAPTCHA link requests without an API key must pass, for
+// deployments where the browser client is public. It is disabled when Provider is empty.
+type Captcha struct {
+	// Provider is recaptcha or turnstile
+	Provider string
+	// SiteKey is public and given to the browser client to render the widget
+	SiteKey   string
+	SecretKey string
+	// VerifyURL is the provider's verification endpoint, overridable for testing or for
+	// reCAPTCHA through www.recaptcha.net
+	VerifyURL string
+	// MinScore is the least reCAPTCHA v3 score accepted; it is ignored for answers without one
+	MinScore float64
+}
+
+// DefaultContentSecurityPolicy allows what the bundled pages load: the browser client's
+// stylesheet, fonts, and CAPTCHA widgets, and Swagger UI for /docs. Inline scripts and
+// styles are allowed because those pages use them. frame-ancestors is added from
+// FRAME_ANCESTORS.
+const DefaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' 'unsafe-inline' https://unpkg.com https://www.google.com/recaptcha/ https://www.gstatic.com/recaptcha/ https://challenges.cloudflare.com; " +
+	"style-src 'self' 'unsafe-inline' https://fonts.googleapis.com https://globalpayments-samples.github.io https://unpkg.com; " +
+	"font-src 'self' https://fonts.gstatic.com; " +
+	"img-src 'self' data: https://globalpayments-samples.github.io; " +
+	"frame-src https://www.google.com/recaptcha/ https://challenges.cloudflare.com; " +
+	"connect-src 'self'; object-src 'none'; base-uri 'self'; form-action 'self'"
+
+// SecurityHeaders configures the headers every response is sent with to limit what a
+// browser lets other sites and injected content do with it
+type SecurityHeaders struct {
+	Enabled bool
+	// HSTSMaxAge is how long browsers should only use HTTPS for the host, sent on HTTPS
+	// responses. Zero sends no Strict-Transport-Security header.
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	// ContentSecurityPolicy includes the frame-ancestors directive
+	ContentSecurityPolicy string
+	// FrameAncestors are the sources allowed to embed the pages in a frame, such as 'none',
+	// 'self', or a merchant's site. It is empty when ContentSecurityPolicy came with its own.
+	FrameAncestors []string
+}
+
+// TLS configures serving HTTPS without a proxy in front, with a certificate and key from
+// files or obtained from an ACME certificate authority such as Let's Encrypt. Plain HTTP
+// is served when neither is set.
+type TLS struct {
+	CertFile string
+	KeyFile  string
+	// AutocertDomains are the host names certificates are obtained for automatically
+	AutocertDomains []string
+	// AutocertCacheDir keeps obtained certificates and the ACME account key across restarts
+	AutocertCacheDir     string
+	AutocertEmail        string
+	AutocertDirectoryURL string
+	// HTTPPort, when set, serves plain HTTP that redirects to HTTPS and answers ACME HTTP-01
+	// challenges
+	HTTPPort string
+	// ClientCAFile, when set, is a PEM bundle of the certificate authorities that issue
+	// client certificates. Connections without a client certificate they issued are refused.
+	ClientCAFile string
+}
+
+// Enabled reports whether the server serves HTTPS
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || len(t.AutocertDomains) > 0
+}
+
+// Event publisher drivers
+const (
+	EventPublisherKafka = "kafka"
+	EventPublisherNATS  = "nats"
+)
+
+// Kafka SASL mechanisms
+const (
+	KafkaSASLPlain       = "plain"
+	KafkaSASLSCRAMSHA256 = "scram-sha-256"
+	KafkaSASLSCRAMSHA512 = "scram-sha-512"
+)
+
+// EventPublisher configures the link lifecycle events published as CloudEvents to a
+// message broker. It is disabled when Driver is empty.
+type EventPublisher struct {
+	// Driver is EventPublisherKafka or EventPublisherNATS
+	Driver string
+	// Source is the CloudEvents source attribute of every event
+	Source string
+	Kafka  KafkaPublisher
+	NATS   NATSPublisher
+}
+
+// KafkaPublisher configures the Kafka topic events are written to
+type KafkaPublisher struct {
+	Brokers []string
+	Topic   string
+	TLS     bool
+	// SASLMechanism is a KafkaSASL mechanism, or empty when the brokers need no SASL
+	SASLMechanism string
+	SASLUsername  string
+	SASLPassword  string
+}
+
+// NATSPublisher configures the NATS subjects events are published on
+type NATSPublisher struct {
+	URL string
+	// Subject prefixes each event's type, such as paybylink.events.link.paid
+	Subject string
+	// CredsFile is a NATS user credentials file, or empty when the URL carries any credentials
+	CredsFile string
+}
+
+// SlackEvents are the link events that can be posted to Slack
+var SlackEvents = []string{"link.created", "link.paid"}
+
+// Slack configures the messages posted to a Slack channel through an incoming webhook
+// when links are created or paid. It is disabled when WebhookURL is empty.
+type Slack struct {
+	// WebhookURL is the incoming webhook, which posts to the channel chosen when it was created
+	WebhookURL string
+	// Events are the link events posted, from SlackEvents
+	Events []string
+}
+
+// LinkDefaults holds the settings applied to every link unless a request overrides them
+type LinkDefaults struct {
+	// PaymentMethods are the payment methods links accept; requests may narrow them
+	PaymentMethods []gpapi.PaymentMethodName
+	// DigitalWallets are the wallets offered on links that accept DIGITAL_WALLET
+	DigitalWallets DigitalWallets
+	// Shippable makes the hosted payment page collect a shipping address
+	Shippable bool
+	// ShippingAmount is the shipping charge in major units, added to shippable links
+	ShippingAmount string
+	// Country is the ISO 3166-1 alpha-2 country of the merchant; requests may override it
+	Country string
+	// Channel is the GP API channel of link transactions
+	Channel gpapi.Channel
+	// CaptureMode is AUTO to capture payments at once or LATER to only authorize them; requests may override it
+	CaptureMode gpapi.CaptureMode
+	// ReferenceFormat generates the reference of links requested without one, such as
+	// PBL-{date}-{seq}. It is empty when requests must send a reference.
+	ReferenceFormat string
+	// AllowedScripts are the Unicode scripts, such as Latin or Cyrillic, that letters in link
+	// names, descriptions, and references may be written in. It is empty when any script is allowed.
+	AllowedScripts []string
+	// SupportedCurrencies are the ISO 4217 currencies links may be created in. It is empty
+	// when any ISO 4217 currency is allowed.
+	SupportedCurrencies []string
+	// ShortLinkBaseURL is this server's public URL, without a trailing slash, that link
+	// shortlinks such as https://pay.example.com/l/{code} are built on. Responses include no
+	// shortlink when it is empty.
+	ShortLinkBaseURL string
+	// MerchantName is the business name shown on payment receipts, or empty to leave it off
+	MerchantName string
+	// Branding is how GP's hosted payment page presents the merchant; requests may override
+	// each part of it
+	Branding Branding
+}
+
+// Branding holds the hosted payment page branding options of the Links API. Each part is
+// empty when GP's own default is used.
+type Branding struct {
+	// DisplayName is the merchant name shown at the top of the page
+	DisplayName string
+	// LogoURL is the HTTPS address of the logo shown beside the display name
+	LogoURL string
+	// Color is the brand color of the page's buttons and header, as #RRGGBB
+	Color string
+}
+
+// DigitalWallets holds the Apple Pay and Google Pay settings of links that accept the
+// DIGITAL_WALLET payment method
+type DigitalWallets struct {
+	// Providers are the wallets offered; requests may narrow them. It is empty when
+	// DIGITAL_WALLET is not enabled.
+	Providers []gpapi.DigitalWalletProvider
+	// ApplePayMerchantID is the Apple Pay merchant identifier, such as merchant.com.example
+	ApplePayMerchantID string
+	// GooglePayMerchantID is the merchant ID from the Google Pay & Wallet Console
+	GooglePayMerchantID string
+}
+
+// MerchantID returns the merchant's identifier with provider
+func (w DigitalWallets) MerchantID(provider gpapi.DigitalWalletProvider) string {
+	switch provider {
+	case gpapi.DigitalWalletApplePay:
+		return w.ApplePayMerchantID
+	case gpapi.DigitalWalletGooglePay:
+		return w.GooglePayMerchantID
+	}
+	return ""
+}
+
+// googlePayMerchantIDPattern matches a Google Pay merchant ID
+var googlePayMerchantIDPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// MaxBrandDisplayNameLength is the longest display name, in characters, the hosted payment
+// page shows
+const MaxBrandDisplayNameLength = 50
+
+// brandColorPattern matches a hex color with or without its leading #, such as #1A73E8 or 1a73e8
+var brandColorPattern = regexp.MustCompile(`^#?([0-9A-Fa-f]{6}|[0-9A-Fa-f]{3})$`)
+
+// ParseBrandColor parses a hex color such as #1A73E8, #FFF, or 1a73e8, returning it in the
+// #RRGGBB form GP expects. The # is optional, as it starts a comment when left unquoted in
+// a YAML config file.
+func ParseBrandColor(value string) (string, error) {
+	match := brandColorPattern.FindStringSubmatch(value)
+	if match == nil {
+		return "", errors.New("must be a hex color such as #1A73E8")
+	}
+	hex := strings.ToUpper(match[1])
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	return "#" + hex, nil
+}
+
+// NotificationURLs holds the default notification URLs sent with each link
+// and the hosts that per-request overrides may point at
+type NotificationURLs struct {
+	ReturnURL    string
+	StatusURL    string
+	CancelURL    string
+	AllowedHosts map[string]bool
+}
+
+// APIKey is a configured client credential and its rate limit
+type APIKey struct {
+	Name          string
+	Key           string
+	RatePerMinute int
+	// Permissions are the actions the key may take; keys not named in API_KEY_PERMISSIONS
+	// are granted every permission
+	Permissions []Permission
+}
+
+// Permission names an action an API key or dashboard user may be granted
+type Permission string
+
+// Permissions, each covering a group of endpoints
+const (
+	// PermissionCreate creates, edits, and sends links, and creates customers
+	PermissionCreate Permission = "create"
+	// PermissionRead looks up and lists links, customers, transactions, and events
+	PermissionRead Permission = "read"
+	// PermissionCancel cancels links
+	PermissionCancel Permission = "cancel"
+	// PermissionCapture captures payments authorized through LATER capture mode links
+	PermissionCapture Permission = "capture"
+	// PermissionRefund refunds transactions
+	PermissionRefund Permission = "refund"
+	// PermissionAdmin manages link templates and products, and reads and reloads the
+	// configuration
+	PermissionAdmin Permission = "admin"
+)
+
+// AllPermissions lists every permission
+var AllPermissions = []Permission{PermissionCreate, PermissionRead, PermissionCancel, PermissionCapture, PermissionRefund, PermissionAdmin}
+
+// ParsePermissions parses a list of permissions joined by +, such as read+cancel
+func ParsePermissions(value string) ([]Permission, error) {
+	var permissions []Permission
+	for _, name := range strings.Split(value, "+") {
+		permission := Permission(strings.ToLower(strings.TrimSpace(name)))
+		if !slices.Contains(AllPermissions, permission) {
+			return nil, fmt.Errorf("unknown permission %q: must be create, read, cancel, capture, refund, or admin", name)
+		}
+		if !slices.Contains(permissions, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions, nil
+}
+
+// APIKeys configures API key authentication. It is disabled when Keys is empty, and then
+// only link creation is served unless AllowUnauthenticated opens every endpoint.
+type APIKeys struct {
+	Keys                 []APIKey
+	Burst                int
+	PublicConfig         bool
+	AllowUnauthenticated bool
+}
+
+// RateLimit configures per-IP rate limiting of link creation. It is disabled when PerMinute is 0.
+type RateLimit struct {
+	PerMinute      int
+	Burst          int
+	TrustedProxies int
+}
+
+// CORS controls which browser origins may call the API
+type CORS struct {
+	AllowedOrigins []string
+	AllowedMethods string
+	AllowedHeaders string
+	MaxAge         int
+}
+
+// SMS configures delivery of payment links by text message. It is disabled when Provider is empty.
+type SMS struct {
+	Provider                string
+	TwilioAccountSID        string
+	TwilioAuthToken         string
+	TwilioFromNumber        string
+	TwilioStatusCallbackURL string
+}
+
+// Webhooks configures the signed link events posted to merchant endpoints. It is disabled when URLs is empty.
+type Webhooks struct {
+	URLs   []string
+	Secret string
+	// MaxAttempts is how many times an event is sent to an endpoint before it is dead-lettered
+	MaxAttempts int
+	// RetryBackoff is the wait before the first retry; it doubles after each failed attempt
+	RetryBackoff time.Duration
+}
+
+// Reconcile configures the background job that brings active links up to date with GP API,
+// covering status notifications that never arrived. It is disabled when Interval is 0.
+type Reconcile struct {
+	Interval time.Duration
+}
+
+// Expiry configures the background job that marks links past their expiry date as expired.
+// It is disabled when Interval is 0.
+type Expiry struct {
+	Interval time.Duration
+	// DeactivateAtGP also deactivates expired links in GP API, in case GP still accepts payment
+	DeactivateAtGP bool
+}
+
+// Retention policy tables: the stored records a RetentionPolicy applies to
+const (
+	RetentionLinks     = "links"
+	RetentionCustomers = "customers"
+	RetentionWebhooks  = "webhooks"
+)
+
+// Retention policy actions: what is done with records older than a policy's age
+const (
+	RetentionDelete    = "delete"
+	RetentionAnonymize = "anonymize"
+)
+
+// RetentionPolicy deletes or anonymizes the records of one table once they are older than MaxAge.
+// Links age from their last update once they stop being active, customers from their last
+// update or that of any of their links, and webhook dead letters from when they were recorded.
+type RetentionPolicy struct {
+	Table  string
+	Action string
+	MaxAge time.Duration
+}
+
+// Retention configures the background job that applies data retention policies. It is
+// disabled when Interval is 0 or there are no policies.
+type Retention struct {
+	Interval time.Duration
+	Policies []RetentionPolicy
+}
+
+// Recurring configures the background job that creates the links of recurring series
+// as their installments fall due. It is disabled when Interval is 0.
+type Recurring struct {
+	Interval time.Duration
+}
+
+// Reminders configures the background job that re-sends unpaid links to customers by SMS
+// as their expiry date approaches. It is disabled when Interval is 0.
+type Reminders struct {
+	Interval time.Duration
+	// Before is how long before a link expires the first reminder is sent
+	Before time.Duration
+	// Repeat is the wait between reminders when MaxCount allows more than one
+	Repeat time.Duration
+	// MaxCount caps the number of reminders sent for each link
+	MaxCount int
+}
+
+// PromoCode is a discount that link requests apply with their promoCode field. It takes
+// either Percent off the amount or the fixed AmountOff, which only applies in Currency.
+type PromoCode struct {
+	Code    string
+	Percent int
+	// AmountOff is in the minor units of Currency
+	AmountOff int64
+	Currency  string
+	// ExpiresAt is the end of the code's last valid day; zero means it does not expire
+	ExpiresAt time.Time
+	// MaxUses caps how many links the code may be applied to; 0 means no limit
+	MaxUses int
+}
+
+// Risk is the local pre-screen of link requests against the payer details they carry
+type Risk struct {
+	// BlockedCountries are the ISO 3166-1 alpha-2 billing countries links are refused for
+	BlockedCountries map[string]bool
+	// BlockedEmails are lower-case payer email addresses, and domains written as @example.com,
+	// links are refused for
+	BlockedEmails map[string]bool
+}
+
+// IPAccessList restricts a group of endpoints to client IPs. Denied ranges are refused;
+// when Allow is not empty, so is every address outside it.
+type IPAccessList struct {
+	Allow []netip.Prefix
+	Deny  []netip.Prefix
+}
+
+// Permits reports whether the list lets addr through
+func (l IPAccessList) Permits(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range l.Deny {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	if len(l.Allow) == 0 {
+		return true
+	}
+	for _, prefix := range l.Allow {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Empty reports whether the list lets every address through
+func (l IPAccessList) Empty() bool {
+	return len(l.Allow) == 0 && len(l.Deny) == 0
+}
+
+// IPAccess holds the client IP lists of each group of endpoints
+type IPAccess struct {
+	// Admin covers the dashboard and the /admin API
+	Admin IPAccessList
+	// Public covers the link API and the browser client, but not the pages and callbacks
+	// payers, GP, and Twilio reach, nor the health checks
+	Public IPAccessList
+}
+
+// Wallet holds the credentials wallet passes are signed with. Each wallet is offered only
+// when its settings are set.
+type Wallet struct {
+	// ApplePassTypeID and AppleTeamID identify the Pass Type ID, such as
+	// pass.com.example.paybylink, that AppleCertFile and AppleKeyFile were issued for.
+	// AppleWWDRFile is Apple's WWDR intermediate certificate, which issued it.
+	ApplePassTypeID string
+	AppleTeamID     string
+	AppleCertFile   string
+	AppleKeyFile    string
+	AppleWWDRFile   string
+	// GoogleIssuerID is the Google Wallet issuer passes are created under, and
+	// GoogleServiceAccountFile the JSON key of a service account allowed to act for it
+	GoogleIssuerID           string
+	GoogleServiceAccountFile string
+}
+
+// AppleEnabled reports whether Apple Wallet passes are offered
+func (w Wallet) AppleEnabled() bool {
+	return w.ApplePassTypeID != ""
+}
+
+// GoogleEnabled reports whether Google Wallet passes are offered
+func (w Wallet) GoogleEnabled() bool {
+	return w.GoogleIssuerID != ""
+}
+
+// MerchantAccount is a merchant, and optionally one of its transaction processing accounts,
+// that link requests may create links for with their merchantId and accountName fields
+type MerchantAccount struct {
+	MerchantID string
+	// AccountName is empty when links for the merchant use the token's account name
+	AccountName string
+}
+
+// AmountLimit bounds the amounts links may be created for in one currency, in minor units.
+// A zero Min or Max leaves that side unbounded.
+type AmountLimit struct {
+	Min int64
+	Max int64
+}
+
+// Velocity limit scopes: what the links counted towards a VelocityLimit have in common
+const (
+	VelocityScopeReference = "reference"
+	VelocityScopeCustomer  = "customer"
+	VelocityScopeAPIKey    = "apikey"
+)
+
+// VelocityLimit caps the links created for the same reference, customer email, or API key
+// within a sliding window
+type VelocityLimit struct {
+	Scope  string
+	Window time.Duration
+	// MaxLinks caps how many links may be created in the window; 0 means no cap
+	MaxLinks int
+	// MaxAmount caps the total of the amounts of the links created in the window in
+	// Currency, in minor units; 0 means no cap
+	MaxAmount int64
+	Currency  string
+}
+
+// Tax configures the tax added to link amounts, which are then taken as net of tax.
+// It is disabled when Rates is empty and DefaultRate is 0.
+type Tax struct {
+	// Rates are percentages in basis points keyed by country (GB) or country and region (US-CA)
+	Rates map[string]int
+	// DefaultRate applies, in basis points, to countries without a rate of their own
+	DefaultRate int
+	// Label names the tax in link descriptions, such as VAT
+	Label string
+}
+
+// Enabled reports whether any tax rate is configured
+func (t Tax) Enabled() bool {
+	return len(t.Rates) > 0 || t.DefaultRate > 0
+}
+
+// Tracing configures OpenTelemetry trace export. It is disabled unless an OTLP endpoint is set;
+// the endpoint, headers, and sampler themselves are read by the OpenTelemetry SDK.
+type Tracing struct {
+	Enabled     bool
+	ServiceName string
+}
+
+// Load reads the configuration from the environment, after ApplyFile and ApplyOverrides
+// have added any settings from a config file and the command line. Every missing or
+// invalid setting is reported, as an Errors.
+func Load() (*Config, error) {
+	cfg := &Config{
+		Port:      envOrDefault("PORT", defaultPort),
+		RedisURL:  strings.TrimSpace(os.Getenv("REDIS_URL")),
+		StaticDir: strings.TrimSpace(os.Getenv("STATIC_DIR")),
+	}
+
+	var err error
+	var problems Errors
+	if cfg.LogLevel, err = logging.ParseLevel(os.Getenv("LOG_LEVEL")); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.LogRedaction, err = loadRedactionPolicy(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.GP, err = loadGPConfig(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.ShutdownTimeout, err = positiveDurationEnv("SHUTDOWN_TIMEOUT", defaultShutdownTimeout); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.CapabilitiesTTL, err = positiveDurationEnv("CAPABILITIES_CACHE_TTL", defaultCapabilitiesTTL); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.NotificationMaxSkew, err = positiveDurationEnv("STATUS_NOTIFICATION_MAX_SKEW", defaultNotificationMaxSkew); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.IdempotencyTTL, err = positiveDurationEnv("IDEMPOTENCY_TTL", defaultIdempotencyTTL); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.StaticDir != "" {
+		if info, err := os.Stat(cfg.StaticDir); err != nil || !info.IsDir() {
+			problems = append(problems, fmt.Errorf("invalid STATIC_DIR %q: must be a directory", cfg.StaticDir))
+		}
+	}
+	if cfg.Store, err = loadStore(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Limits, err = loadLimits(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Links, err = loadLinkDefaults(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Notifications, err = loadNotificationURLs(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.APIKeys, err = loadAPIKeys(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.RateLimit, err = loadRateLimit(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.CORS, err = loadCORS(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.SMS, err = loadSMS(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Tracing, err = loadTracing(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Webhooks, err = loadWebhooks(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Reconcile.Interval, err = nonNegativeDurationEnv("RECONCILE_INTERVAL", defaultReconcileInterval); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Expiry, err = loadExpiry(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Retention, err = loadRetention(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Recurring.Interval, err = nonNegativeDurationEnv("RECURRING_INTERVAL", defaultRecurringInterval); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Reminders, err = loadReminders(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.PromoCodes, err = loadPromoCodes(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Tax, err = loadTax(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.AmountLimits, err = loadAmountLimits(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.VelocityLimits, err = loadVelocityLimits(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.HTTPClient, err = loadHTTPClient(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.OIDC, err = loadOIDC(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Slack, err = loadSlack(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.EventPublisher, err = loadEventPublisher(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.MerchantAccounts, err = loadMerchantAccounts(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Risk, err = loadRisk(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Captcha, err = loadCaptcha(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.CSRFProtection, err = strconv.ParseBool(envOrDefault("CSRF_PROTECTION", "true")); err != nil {
+		problems = append(problems, fmt.Errorf("invalid CSRF_PROTECTION %q: must be true or false", os.Getenv("CSRF_PROTECTION")))
+	}
+	if cfg.SecurityHeaders, err = loadSecurityHeaders(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.TLS, err = loadTLS(cfg.Port); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.IPAccess, err = loadIPAccess(); err != nil {
+		problems = append(problems, err)
+	}
+	if cfg.Wallet, err = loadWallet(); err != nil {
+		problems = append(problems, err)
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	cfg.Settings = effectiveSettings()
+	return cfg, nil
+}
+
+// loadGPConfig reads the GP API credentials and environment. GP_API_ENVIRONMENT
+// selects sandbox (default) or production, and GP_API_BASE_URL optionally
+// overrides the base URL for that environment. GP_API_VERSION overrides the
+// API version requested, a date such as 2021-03-22.
+func loadGPConfig() (GPConfig, error) {
+	gp := GPConfig{
+		AppID:       os.Getenv("GP_API_APP_ID"),
+		AppKey:      os.Getenv("GP_API_APP_KEY"),
+		Environment: strings.ToLower(strings.TrimSpace(os.Getenv("GP_API_ENVIRONMENT"))),
+		Version:     strings.TrimSpace(envOrDefault("GP_API_VERSION", gpapi.DefaultVersion)),
+	}
+	if _, err := time.Parse("2006-01-02", gp.Version); err != nil {
+		return GPConfig{}, fmt.Errorf("invalid GP_API_VERSION %q: must be a date such as %s", gp.Version, gpapi.DefaultVersion)
+	}
+
+	mock, err := loadMockGP()
+	if err != nil {
+		return GPConfig{}, err
+	}
+	if mock.Enabled {
+		// The mock accepts any credentials, so none are needed to try the server locally
+		gp.AppID = envOrDefault("GP_API_APP_ID", defaultMockAppID)
+		gp.AppKey = envOrDefault("GP_API_APP_KEY", defaultMockAppKey)
+		gp.Environment = "mock"
+		gp.Mock = mock
+		return gp, nil
+	}
+
+	if gp.AppID == "" || gp.AppKey == "" {
+		return GPConfig{}, errors.New("GP_API_APP_ID and GP_API_APP_KEY must be set")
+	}
+
+	if gp.Environment == "" {
+		gp.Environment = "sandbox"
+	}
+	switch gp.Environment {
+	case "sandbox":
+		gp.BaseURL = gpapi.SandboxBaseURL
+	case "production":
+		gp.BaseURL = gpapi.ProductionBaseURL
+	default:
+		return GPConfig{}, fmt.Errorf("invalid GP_API_ENVIRONMENT %q: must be sandbox or production", gp.Environment)
+	}
+
+	if override := strings.TrimSpace(os.Getenv("GP_API_BASE_URL")); override != "" {
+		gp.BaseURL = strings.TrimRight(override, "/")
+	}
+	return gp, nil
+}
+
+// loadMockGP reads GP_API_MOCK, GP_API_MOCK_ADDR, GP_API_MOCK_FAILURES,
+// GP_API_MOCK_LATENCY, and GP_API_MOCK_NOTIFY_URL
+func loadMockGP() (MockGP, error) {
+	enabled, err := strconv.ParseBool(envOrDefault("GP_API_MOCK", "false"))
+	if err != nil {
+		return MockGP{}, fmt.Errorf("invalid GP_API_MOCK %q: must be true or false", os.Getenv("GP_API_MOCK"))
+	}
+	if !enabled {
+		return MockGP{}, nil
+	}
+
+	mock := MockGP{
+		Enabled:   true,
+		Addr:      envOrDefault("GP_API_MOCK_ADDR", defaultMockAddr),
+		NotifyURL: strings.TrimSpace(os.Getenv("GP_API_MOCK_NOTIFY_URL")),
+	}
+	for _, failure := range strings.Split(os.Getenv("GP_API_MOCK_FAILURES"), ",") {
+		failure = strings.ToLower(strings.TrimSpace(failure))
+		if failure == "" {
+			continue
+		}
+		if !slices.Contains(mockgp.Scenarios, failure) {
+			return MockGP{}, fmt.Errorf("invalid GP_API_MOCK_FAILURES entry %q: must be one of %s", failure, strings.Join(mockgp.Scenarios, ", "))
+		}
+		mock.Failures = append(mock.Failures, failure)
+	}
+	if value := os.Getenv("GP_API_MOCK_LATENCY"); value != "" {
+		mock.Latency, err = time.ParseDuration(value)
+		if err != nil || mock.Latency < 0 {
+			return MockGP{}, fmt.Errorf("invalid GP_API_MOCK_LATENCY %q: must be a duration such as 200ms", value)
+		}
+	}
+	return mock, nil
+}
+
+// loadRedactionPolicy reads LOG_REDACTION and LOG_REDACT_FIELDS
+func loadRedactionPolicy() (logging.RedactionPolicy, error) {
+	fields := logging.DefaultRedactedFields
+	if value := os.Getenv("LOG_REDACT_FIELDS"); value != "" {
+		fields = strings.Split(value, ",")
+	}
+	return logging.NewRedactionPolicy(envOrDefault("LOG_REDACTION", logging.RedactionMask), fields)
+}
+
+// loadStore reads STORE_DRIVER, SQLITE_PATH, DATABASE_URL, DATABASE_MAX_OPEN_CONNS,
+// DATABASE_MAX_IDLE_CONNS, and DATABASE_CONN_MAX_LIFETIME, and the encryption keys
+func loadStore() (Store, error) {
+	store := Store{
+		Driver:     strings.ToLower(envOrDefault("STORE_DRIVER", defaultStoreDriver)),
+		SQLitePath: envOrDefault("SQLITE_PATH", defaultSQLitePath),
+	}
+	var err error
+	if store.Encryption, err = loadEncryption(); err != nil {
+		return Store{}, err
+	}
+	switch store.Driver {
+	case "sqlite":
+		return store, nil
+	case "postgres":
+	default:
+		return Store{}, fmt.Errorf("invalid STORE_DRIVER %q: must be sqlite or postgres", store.Driver)
+	}
+
+	store.DatabaseURL = strings.TrimSpace(os.Getenv("DATABASE_URL"))
+	if store.DatabaseURL == "" {
+		return Store{}, errors.New("DATABASE_URL must be set when STORE_DRIVER is postgres")
+	}
+
+	if store.MaxOpenConns, err = positiveIntEnv("DATABASE_MAX_OPEN_CONNS", defaultDatabaseMaxOpenConns); err != nil {
+		return Store{}, err
+	}
+	if store.MaxIdleConns, err = nonNegativeIntEnv("DATABASE_MAX_IDLE_CONNS", defaultDatabaseMaxIdleConns); err != nil {
+		return Store{}, err
+	}
+	if store.ConnMaxLifetime, err = positiveDurationEnv("DATABASE_CONN_MAX_LIFETIME", defaultDatabaseConnMaxLifetime); err != nil {
+		return Store{}, err
+	}
+	return store, nil
+}
+
+// loadEncryption reads ENCRYPTION_KEYS, or the file named by ENCRYPTION_KEYS_FILE, and
+// ENCRYPTION_KEY_VERSION. Keys are listed as version:key, separated by commas or newlines,
+// with each key 32 bytes in base64; new data is encrypted with the highest version unless
+// ENCRYPTION_KEY_VERSION names another.
+func loadEncryption() (Encryption, error) {
+	value := os.Getenv("ENCRYPTION_KEYS")
+	source := "ENCRYPTION_KEYS"
+	if path := strings.TrimSpace(os.Getenv("ENCRYPTION_KEYS_FILE")); path != "" {
+		if strings.TrimSpace(value) != "" {
+			return Encryption{}, errors.New("only one of ENCRYPTION_KEYS and ENCRYPTION_KEYS_FILE can be set")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Encryption{}, fmt.Errorf("invalid ENCRYPTION_KEYS_FILE: %w", err)
+		}
+		value, source = string(data), "ENCRYPTION_KEYS_FILE"
+	}
+
+	entries := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' })
+	encryption := Encryption{Keys: make(map[int][]byte)}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		version, key, ok := strings.Cut(entry, ":")
+		number, err := strconv.Atoi(version)
+		if !ok || err != nil || number < 1 {
+			return Encryption{}, fmt.Errorf("invalid %s entry %q: expected version:key with a positive version", source, logging.MaskSecret(entry))
+		}
+		if _, dup := encryption.Keys[number]; dup {
+			return Encryption{}, fmt.Errorf("invalid %s: key version %d is listed twice", source, number)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil || len(decoded) != encryptionKeySize {
+			return Encryption{}, fmt.Errorf("invalid %s key version %d: must be %d bytes in base64", source, number, encryptionKeySize)
+		}
+		encryption.Keys[number] = decoded
+		encryption.Version = max(encryption.Version, number)
+	}
+
+	if value := os.Getenv("ENCRYPTION_KEY_VERSION"); value != "" {
+		version, err := strconv.Atoi(value)
+		if err != nil || encryption.Keys[version] == nil {
+			return Encryption{}, fmt.Errorf("invalid ENCRYPTION_KEY_VERSION %q: must be the version of a key in %s", value, source)
+		}
+		encryption.Version = version
+	}
+	if len(encryption.Keys) == 0 {
+		return Encryption{}, nil
+	}
+	return encryption, nil
+}
+
+// loadLimits reads MAX_REQUEST_BODY_BYTES, MAX_BATCH_BODY_BYTES, REQUEST_TIMEOUT, and BATCH_REQUEST_TIMEOUT
+func loadLimits() (Limits, error) {
+	maxBody, err := positiveIntEnv("MAX_REQUEST_BODY_BYTES", defaultMaxBodyBytes)
+	if err != nil {
+		return Limits{}, err
+	}
+	maxBatchBody, err := positiveIntEnv("MAX_BATCH_BODY_BYTES", defaultMaxBatchBodyBytes)
+	if err != nil {
+		return Limits{}, err
+	}
+	requestTimeout, err := positiveDurationEnv("REQUEST_TIMEOUT", defaultRequestTimeout)
+	if err != nil {
+		return Limits{}, err
+	}
+	batchTimeout, err := positiveDurationEnv("BATCH_REQUEST_TIMEOUT", defaultBatchRequestTimeout)
+	if err != nil {
+		return Limits{}, err
+	}
+	return Limits{
+		MaxBodyBytes:        int64(maxBody),
+		MaxBatchBodyBytes:   int64(maxBatchBody),
+		RequestTimeout:      requestTimeout,
+		BatchRequestTimeout: batchTimeout,
+	}, nil
+}
+
+// loadHTTPClient reads HTTP_CLIENT_TIMEOUT, HTTP_CLIENT_DIAL_TIMEOUT,
+// HTTP_CLIENT_TLS_HANDSHAKE_TIMEOUT, HTTP_CLIENT_IDLE_CONN_TIMEOUT, HTTP_CLIENT_MAX_IDLE_CONNS,
+// HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST, and HTTP_CLIENT_CA_BUNDLE
+func loadHTTPClient() (HTTPClient, error) {
+	client := HTTPClient{CABundle: strings.TrimSpace(os.Getenv("HTTP_CLIENT_CA_BUNDLE"))}
+	var err error
+	if client.Timeout, err = positiveDurationEnv("HTTP_CLIENT_TIMEOUT", defaultHTTPClientTimeout); err != nil {
+		return HTTPClient{}, err
+	}
+	if client.DialTimeout, err = positiveDurationEnv("HTTP_CLIENT_DIAL_TIMEOUT", defaultHTTPClientDialTimeout); err != nil {
+		return HTTPClient{}, err
+	}
+	if client.TLSHandshakeTimeout, err = positiveDurationEnv("HTTP_CLIENT_TLS_HANDSHAKE_TIMEOUT", defaultHTTPClientTLSHandshakeTimeout); err != nil {
+		return HTTPClient{}, err
+	}
+	if client.IdleConnTimeout, err = positiveDurationEnv("HTTP_CLIENT_IDLE_CONN_TIMEOUT", defaultHTTPClientIdleConnTimeout); err != nil {
+		return HTTPClient{}, err
+	}
+	if client.MaxIdleConns, err = positiveIntEnv("HTTP_CLIENT_MAX_IDLE_CONNS", defaultHTTPClientMaxIdleConns); err != nil {
+		return HTTPClient{}, err
+	}
+	if client.MaxIdleConnsPerHost, err = positiveIntEnv("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", defaultHTTPClientMaxIdleConnsPerHost); err != nil {
+		return HTTPClient{}, err
+	}
+	if client.CABundle != "" {
+		if info, err := os.Stat(client.CABundle); err != nil || info.IsDir() {
+			return HTTPClient{}, fmt.Errorf("invalid HTTP_CLIENT_CA_BUNDLE %q: must be a PEM file", client.CABundle)
+		}
+	}
+	return client, nil
+}
+
+// decimalPattern matches a non-negative decimal amount such as 4 or 4.99
+var decimalPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// loadLinkDefaults reads GP_API_PAYMENT_METHODS, the digital wallet settings, GP_API_SHIPPABLE, GP_API_SHIPPING_AMOUNT,
+// GP_API_COUNTRY, GP_API_CHANNEL, GP_API_CAPTURE_MODE, REFERENCE_FORMAT, ALLOWED_SCRIPTS,
+// SUPPORTED_CURRENCIES, SHORTLINK_BASE_URL, MERCHANT_NAME, and the BRAND_ settings
+func loadLinkDefaults() (LinkDefaults, error) {
+	methods, err := gpapi.ParsePaymentMethods(envOrDefault("GP_API_PAYMENT_METHODS", defaultPaymentMethods))
+	if err != nil {
+		return LinkDefaults{}, fmt.Errorf("invalid GP_API_PAYMENT_METHODS: %w", err)
+	}
+	if len(methods) == 0 {
+		return LinkDefaults{}, errors.New("invalid GP_API_PAYMENT_METHODS: at least one payment method is required")
+	}
+
+	digitalWallets, err := loadDigitalWallets(methods)
+	if err != nil {
+		return LinkDefaults{}, err
+	}
+
+	shippable, err := strconv.ParseBool(envOrDefault("GP_API_SHIPPABLE", defaultShippable))
+	if err != nil {
+		return LinkDefaults{}, fmt.Errorf("invalid GP_API_SHIPPABLE %q: must be true or false", os.Getenv("GP_API_SHIPPABLE"))
+	}
+
+	// The amount is converted to minor units per link, once the currency is known
+	shippingAmount := envOrDefault("GP_API_SHIPPING_AMOUNT", defaultShippingAmount)
+	if !decimalPattern.MatchString(shippingAmount) {
+		return LinkDefaults{}, fmt.Errorf("invalid GP_API_SHIPPING_AMOUNT %q: must be a non-negative amount such as 4.99", shippingAmount)
+	}
+
+	countryCode, err := country.Parse(envOrDefault("GP_API_COUNTRY", defaultCountry))
+	if err != nil {
+		return LinkDefaults{}, fmt.Errorf("invalid GP_API_COUNTRY: %w", err)
+	}
+
+	channel := gpapi.Channel(strings.ToUpper(envOrDefault("GP_API_CHANNEL", string(defaultChannel))))
+	if channel != gpapi.ChannelCardNotPresent && channel != gpapi.ChannelCardPresent {
+		return LinkDefaults{}, fmt.Errorf("invalid GP_API_CHANNEL %q: must be CNP or CP", channel)
+	}
+
+	captureMode := gpapi.CaptureMode(strings.ToUpper(envOrDefault("GP_API_CAPTURE_MODE", string(defaultCaptureMode))))
+	if captureMode != gpapi.CaptureModeAuto && captureMode != gpapi.CaptureModeLater {
+		return LinkDefaults{}, fmt.Errorf("invalid GP_API_CAPTURE_MODE %q: must be AUTO or LATER", captureMode)
+	}
+
+	referenceFormat, err := loadReferenceFormat()
+	if err != nil {
+		return LinkDefaults{}, err
+	}
+
+	allowedScripts, err := loadAllowedScripts()
+	if err != nil {
+		return LinkDefaults{}, err
+	}
+
+	supportedCurrencies, err := loadSupportedCurrencies()
+	if err != nil {
+		return LinkDefaults{}, err
+	}
+
+	shortLinkBaseURL, err := loadShortLinkBaseURL()
+	if err != nil {
+		return LinkDefaults{}, err
+	}
+
+	merchantName := strings.TrimSpace(os.Getenv("MERCHANT_NAME"))
+	if utf8.RuneCountInString(merchantName) > maxMerchantNameLength {
+		return LinkDefaults{}, fmt.Errorf("invalid MERCHANT_NAME: must be at most %d characters", maxMerchantNameLength)
+	}
+
+	branding, err := loadBranding()
+	if err != nil {
+		return LinkDefaults{}, err
+	}
+
+	return LinkDefaults{
+		PaymentMethods:  methods,
+		DigitalWallets:  digitalWallets,
+		Shippable:       shippable,
+		ShippingAmount:  shippingAmount,
+		Country:         countryCode,
+		Channel:         channel,
+		CaptureMode:     captureMode,
+		ReferenceFormat: referenceFormat,
+		AllowedScripts:  allowedScripts,
+
+		SupportedCurrencies: supportedCurrencies,
+		ShortLinkBaseURL:    shortLinkBaseURL,
+		MerchantName:        merchantName,
+		Branding:            branding,
+	}, nil
+}
+
+// loadDigitalWallets reads GP_API_DIGITAL_WALLETS, APPLE_PAY_MERCHANT_ID, and
+// GOOGLE_PAY_MERCHANT_ID. Wallets are only offered when methods includes DIGITAL_WALLET, and
+// each needs the merchant's identifier with its provider.
+func loadDigitalWallets(methods []gpapi.PaymentMethodName) (DigitalWallets, error) {
+	providers, err := gpapi.ParseDigitalWallets(os.Getenv("GP_API_DIGITAL_WALLETS"))
+	if err != nil {
+		return DigitalWallets{}, fmt.Errorf("invalid GP_API_DIGITAL_WALLETS: %w", err)
+	}
+	if !slices.Contains(methods, gpapi.PaymentMethodDigitalWallet) {
+		if len(providers) > 0 {
+			return DigitalWallets{}, errors.New("invalid GP_API_DIGITAL_WALLETS: GP_API_PAYMENT_METHODS must include DIGITAL_WALLET")
+		}
+		return DigitalWallets{}, nil
+	}
+	if len(providers) == 0 {
+		return DigitalWallets{}, errors.New("GP_API_DIGITAL_WALLETS must name APPLE_PAY, GOOGLE_PAY, or both when GP_API_PAYMENT_METHODS includes DIGITAL_WALLET")
+	}
+
+	wallets := DigitalWallets{
+		Providers:           providers,
+		ApplePayMerchantID:  strings.TrimSpace(os.Getenv("APPLE_PAY_MERCHANT_ID")),
+		GooglePayMerchantID: strings.TrimSpace(os.Getenv("GOOGLE_PAY_MERCHANT_ID")),
+	}
+	if slices.Contains(providers, gpapi.DigitalWalletApplePay) {
+		if wallets.ApplePayMerchantID == "" {
+			return DigitalWallets{}, errors.New("APPLE_PAY_MERCHANT_ID is required when GP_API_DIGITAL_WALLETS includes APPLE_PAY")
+		}
+		if !strings.HasPrefix(wallets.ApplePayMerchantID, "merchant.") {
+			return DigitalWallets{}, fmt.Errorf("invalid APPLE_PAY_MERCHANT_ID %q: must be a merchant identifier such as merchant.com.example", wallets.ApplePayMerchantID)
+		}
+	}
+	if slices.Contains(providers, gpapi.DigitalWalletGooglePay) {
+		if wallets.GooglePayMerchantID == "" {
+			return DigitalWallets{}, errors.New("GOOGLE_PAY_MERCHANT_ID is required when GP_API_DIGITAL_WALLETS includes GOOGLE_PAY")
+		}
+		if !googlePayMerchantIDPattern.MatchString(wallets.GooglePayMerchantID) {
+			return DigitalWallets{}, fmt.Errorf("invalid GOOGLE_PAY_MERCHANT_ID %q: must contain only letters and digits", wallets.GooglePayMerchantID)
+		}
+	}
+	return wallets, nil
+}
+
+// loadBranding reads BRAND_DISPLAY_NAME, BRAND_LOGO_URL, and BRAND_COLOR, the hosted payment
+// page branding sent with links whose requests do not set their own
+func loadBranding() (Branding, error) {
+	branding := Branding{
+		DisplayName: strings.TrimSpace(os.Getenv("BRAND_DISPLAY_NAME")),
+		LogoURL:     strings.TrimSpace(os.Getenv("BRAND_LOGO_URL")),
+	}
+	if utf8.RuneCountInString(branding.DisplayName) > MaxBrandDisplayNameLength {
+		return Branding{}, fmt.Errorf("invalid BRAND_DISPLAY_NAME: must be at most %d characters", MaxBrandDisplayNameLength)
+	}
+	if strings.ContainsFunc(branding.DisplayName, unicode.IsControl) {
+		return Branding{}, errors.New("invalid BRAND_DISPLAY_NAME: must not contain control characters")
+	}
+	if branding.LogoURL != "" {
+		if _, err := ParseHTTPSURL(branding.LogoURL); err != nil {
+			return Branding{}, fmt.Errorf("invalid BRAND_LOGO_URL %q: %w", branding.LogoURL, err)
+		}
+	}
+	if color := strings.TrimSpace(os.Getenv("BRAND_COLOR")); color != "" {
+		parsed, err := ParseBrandColor(color)
+		if err != nil {
+			return Branding{}, fmt.Errorf("invalid BRAND_COLOR %q: %w", color, err)
+		}
+		branding.Color = parsed
+	}
+	return branding, nil
+}
+
+// loadShortLinkBaseURL reads SHORTLINK_BASE_URL, the public URL shortlinks are served on.
+// Plain HTTP is accepted so shortlinks can be tried against a local server.
+func loadShortLinkBaseURL() (string, error) {
+	value := strings.TrimSpace(os.Getenv("SHORTLINK_BASE_URL"))
+	if value == "" {
+		return "", nil
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return "", fmt.Errorf("invalid SHORTLINK_BASE_URL %q: must be an absolute http or https URL", value)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", fmt.Errorf("invalid SHORTLINK_BASE_URL %q: must not have a query or fragment", value)
+	}
+	return strings.TrimRight(value, "/"), nil
+}
+
+// referenceFormatTokens maps the placeholders of REFERENCE_FORMAT to the longest text they
+// are replaced with: a YYYYMMDD date, a sequence number, or a ULID
+var referenceFormatTokens = map[string]int{"{date}": 8, "{seq}": 10, "{ulid}": 26}
+
+// referenceLiteralPattern matches the text allowed around REFERENCE_FORMAT placeholders
+var referenceLiteralPattern = regexp.MustCompile(`^[A-Za-z0-9_\-#]*$`)
+
+// maxGeneratedReferenceLength leaves room within the 100 character reference limit for
+// the installment number added to the references of recurring series
+const maxGeneratedReferenceLength = 64
+
+// loadReferenceFormat reads REFERENCE_FORMAT, the pattern references are generated from
+// when a request omits one. It must contain {seq} or {ulid} so each reference differs,
+// and off turns generation off.
+func loadReferenceFormat() (string, error) {
+	format := envOrDefault("REFERENCE_FORMAT", defaultReferenceFormat)
+	if strings.EqualFold(format, "off") {
+		return "", nil
+	}
+	if !strings.Contains(format, "{seq}") && !strings.Contains(format, "{ulid}") {
+		return "", fmt.Errorf("invalid REFERENCE_FORMAT %q: must contain {seq} or {ulid}", format)
+	}
+
+	literal, length := format, 0
+	for token, tokenLength := range referenceFormatTokens {
+		length += strings.Count(literal, token) * tokenLength
+		literal = strings.ReplaceAll(literal, token, "")
+	}
+	if !referenceLiteralPattern.MatchString(literal) {
+		return "", fmt.Errorf("invalid REFERENCE_FORMAT %q: only letters, digits, _, -, # and the {date}, {seq}, and {ulid} placeholders are allowed", format)
+	}
+	if length += len(literal); length > maxGeneratedReferenceLength {
+		return "", fmt.Errorf("invalid REFERENCE_FORMAT %q: generated references could be %d characters, more than %d", format, length, maxGeneratedReferenceLength)
+	}
+	return format, nil
+}
+
+// loadSupportedCurrencies reads SUPPORTED_CURRENCIES, a comma-separated list of ISO 4217
+// currency codes such as EUR,GBP,USD
+func loadSupportedCurrencies() ([]string, error) {
+	var currencies []string
+	for _, currency := range strings.Split(os.Getenv("SUPPORTED_CURRENCIES"), ",") {
+		if currency = strings.ToUpper(strings.TrimSpace(currency)); currency == "" {
+			continue
+		}
+		if !money.IsCurrency(currency) {
+			return nil, fmt.Errorf("invalid SUPPORTED_CURRENCIES: %s is not an ISO 4217 currency code", currency)
+		}
+		if !slices.Contains(currencies, currency) {
+			currencies = append(currencies, currency)
+		}
+	}
+	return currencies, nil
+}
+
+// loadAllowedScripts reads ALLOWED_SCRIPTS, a comma-separated list of Unicode script names
+// such as Latin,Greek,Cyrillic. Names are matched case-insensitively and returned as Go's
+// unicode package spells them.
+func loadAllowedScripts() ([]string, error) {
+	var scripts []string
+	for _, name := range strings.Split(os.Getenv("ALLOWED_SCRIPTS"), ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		script := ""
+		for known := range unicode.Scripts {
+			if strings.EqualFold(known, name) {
+				script = known
+				break
+			}
+		}
+		if script == "" {
+			return nil, fmt.Errorf("invalid ALLOWED_SCRIPTS: unknown script %q", name)
+		}
+		if !slices.Contains(scripts, script) {
+			scripts = append(scripts, script)
+		}
+	}
+	return scripts, nil
+}
+
+// loadNotificationURLs reads RETURN_URL, STATUS_URL, CANCEL_URL, and
+// NOTIFICATION_ALLOWED_HOSTS. The hosts of the configured URLs are always allowed.
+func loadNotificationURLs() (NotificationURLs, error) {
+	config := NotificationURLs{
+		ReturnURL:    envOrDefault("RETURN_URL", defaultReturnURL),
+		StatusURL:    envOrDefault("STATUS_URL", defaultStatusURL),
+		CancelURL:    envOrDefault("CANCEL_URL", defaultCancelURL),
+		AllowedHosts: make(map[string]bool),
+	}
+
+	for _, host := range strings.Split(os.Getenv("NOTIFICATION_ALLOWED_HOSTS"), ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			config.AllowedHosts[host] = true
+		}
+	}
+
+	for name, value := range map[string]string{
+		"RETURN_URL": config.ReturnURL,
+		"STATUS_URL": config.StatusURL,
+		"CANCEL_URL": config.CancelURL,
+	} {
+		parsed, err := ParseHTTPSURL(value)
+		if err != nil {
+			return NotificationURLs{}, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		config.AllowedHosts[strings.ToLower(parsed.Hostname())] = true
+	}
+
+	return config, nil
+}
+
+// ParseHTTPSURL parses an absolute HTTPS URL
+func ParseHTTPSURL(value string) (*url.URL, error) {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("must be an absolute URL")
+	}
+	if parsed.Scheme != "https" {
+		return nil, fmt.Errorf("must use https")
+	}
+	return parsed, nil
+}
+
+// loadAPIKeys reads API_KEYS, API_KEY_RATE_LIMIT, API_KEY_RATE_BURST, API_PUBLIC_CONFIG,
+// API_KEY_PERMISSIONS, and ALLOW_UNAUTHENTICATED.
+//
+// API_KEYS is a comma-separated list of name:key or name:key:requestsPerMinute entries.
+// API_KEY_PERMISSIONS is a comma-separated list of name:permission+permission entries, such
+// as support:read+cancel, limiting what the named keys may do.
+func loadAPIKeys() (APIKeys, error) {
+	value := strings.TrimSpace(os.Getenv("API_KEYS"))
+	if value == "" {
+		allow, err := strconv.ParseBool(envOrDefault("ALLOW_UNAUTHENTICATED", "false"))
+		if err != nil {
+			return APIKeys{}, fmt.Errorf("invalid ALLOW_UNAUTHENTICATED %q: must be true or false", os.Getenv("ALLOW_UNAUTHENTICATED"))
+		}
+		return APIKeys{AllowUnauthenticated: allow}, nil
+	}
+
+	defaultRate, err := positiveIntEnv("API_KEY_RATE_LIMIT", defaultAPIKeyRatePerMinute)
+	if err != nil {
+		return APIKeys{}, err
+	}
+	burst, err := positiveIntEnv("API_KEY_RATE_BURST", defaultAPIKeyBurst)
+	if err != nil {
+		return APIKeys{}, err
+	}
+	publicConfig, err := strconv.ParseBool(envOrDefault("API_PUBLIC_CONFIG", "true"))
+	if err != nil {
+		return APIKeys{}, fmt.Errorf("invalid API_PUBLIC_CONFIG %q: must be true or false", os.Getenv("API_PUBLIC_CONFIG"))
+	}
+
+	keys := APIKeys{Burst: burst, PublicConfig: publicConfig}
+	seen := make(map[[sha256.Size]byte]bool)
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return APIKeys{}, fmt.Errorf("invalid API_KEYS entry %q: expected name:key or name:key:requestsPerMinute", logging.MaskSecret(entry))
+		}
+
+		perMinute := defaultRate
+		if len(parts) == 3 {
+			perMinute, err = strconv.Atoi(parts[2])
+			if err != nil || perMinute <= 0 {
+				return APIKeys{}, fmt.Errorf("invalid rate limit for API key %q: must be a positive integer", parts[0])
+			}
+		}
+
+		digest := sha256.Sum256([]byte(parts[1]))
+		if seen[digest] {
+			return APIKeys{}, fmt.Errorf("duplicate API key for %q", parts[0])
+		}
+		seen[digest] = true
+		keys.Keys = append(keys.Keys, APIKey{Name: parts[0], Key: parts[1], RatePerMinute: perMinute, Permissions: AllPermissions})
+	}
+
+	for _, entry := range strings.Split(os.Getenv("API_KEY_PERMISSIONS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		name, list, ok := strings.Cut(entry, ":")
+		if !ok {
+			return APIKeys{}, fmt.Errorf("invalid API_KEY_PERMISSIONS entry %q: expected name:permission+permission", entry)
+		}
+		if !slices.ContainsFunc(keys.Keys, func(key APIKey) bool { return key.Name == name }) {
+			return APIKeys{}, fmt.Errorf("invalid API_KEY_PERMISSIONS entry %q: no API key is named %q", entry, name)
+		}
+		permissions, err := ParsePermissions(list)
+		if err != nil {
+			return APIKeys{}, fmt.Errorf("invalid API_KEY_PERMISSIONS entry %q: %w", entry, err)
+		}
+		// Every key sharing the name, such as an old and a new key during rotation, gets them
+		for i := range keys.Keys {
+			if keys.Keys[i].Name == name {
+				keys.Keys[i].Permissions = permissions
+			}
+		}
+	}
+	return keys, nil
+}
+
+// loadRateLimit reads RATE_LIMIT_PER_MINUTE, RATE_LIMIT_BURST, and TRUSTED_PROXY_COUNT
+func loadRateLimit() (RateLimit, error) {
+	// The client IP is also used by the IP lists and CAPTCHA checks, so the proxies are
+	// trusted whether or not the limit is enabled
+	trustedProxies, err := nonNegativeIntEnv("TRUSTED_PROXY_COUNT", 0)
+	if err != nil {
+		return RateLimit{}, err
+	}
+	perMinute, err := nonNegativeIntEnv("RATE_LIMIT_PER_MINUTE", defaultIPRatePerMinute)
+	if err != nil {
+		return RateLimit{}, err
+	}
+	if perMinute == 0 {
+		return RateLimit{TrustedProxies: trustedProxies}, nil
+	}
+	burst, err := positiveIntEnv("RATE_LIMIT_BURST", defaultIPBurst)
+	if err != nil {
+		return RateLimit{}, err
+	}
+	return RateLimit{PerMinute: perMinute, Burst: burst, TrustedProxies: trustedProxies}, nil
+}
+
+// loadCORS reads CORS_ALLOWED_ORIGINS, CORS_ALLOWED_METHODS, CORS_ALLOWED_HEADERS,
+// and CORS_MAX_AGE. With no allowed origins, only same-origin requests are possible.
+func loadCORS() (CORS, error) {
+	config := CORS{
+		AllowedMethods: envOrDefault("CORS_ALLOWED_METHODS", defaultCORSMethods),
+		AllowedHeaders: envOrDefault("CORS_ALLOWED_HEADERS", defaultCORSHeaders),
+		MaxAge:         defaultCORSMaxAge,
+	}
+
+	for _, origin := range strings.Split(os.Getenv("CORS_ALLOWED_ORIGINS"), ",") {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin == "" {
+			continue
+		}
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return CORS{}, fmt.Errorf("invalid CORS origin %q: must be * or start with http:// or https://", origin)
+		}
+		config.AllowedOrigins = append(config.AllowedOrigins, origin)
+	}
+
+	maxAge, err := nonNegativeIntEnv("CORS_MAX_AGE", defaultCORSMaxAge)
+	if err != nil {
+		return CORS{}, err
+	}
+	config.MaxAge = maxAge
+	return config, nil
+}
+
+// loadSMS reads SMS_PROVIDER and the settings for the selected provider
+func loadSMS() (SMS, error) {
+	sms := SMS{Provider: strings.ToLower(strings.TrimSpace(os.Getenv("SMS_PROVIDER")))}
+	switch sms.Provider {
+	case "":
+		return SMS{}, nil
+	case "twilio":
+		sms.TwilioAccountSID = os.Getenv("TWILIO_ACCOUNT_SID")
+		sms.TwilioAuthToken = os.Getenv("TWILIO_AUTH_TOKEN")
+		sms.TwilioFromNumber = os.Getenv("TWILIO_FROM_NUMBER")
+		sms.TwilioStatusCallbackURL = os.Getenv("TWILIO_STATUS_CALLBACK_URL")
+		return sms, nil
+	default:
+		return SMS{}, fmt.Errorf("unsupported SMS_PROVIDER %q", sms.Provider)
+	}
+}
+
+// loadWebhooks reads WEBHOOK_URLS, WEBHOOK_SECRET, WEBHOOK_MAX_ATTEMPTS, and WEBHOOK_RETRY_BACKOFF.
+// Endpoints must use HTTPS, except on localhost for local development.
+func loadWebhooks() (Webhooks, error) {
+	var webhooks Webhooks
+	for _, value := range strings.Split(os.Getenv("WEBHOOK_URLS"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		parsed, err := url.Parse(value)
+		if err != nil || parsed.Host == "" {
+			return Webhooks{}, fmt.Errorf("invalid WEBHOOK_URLS entry %q: must be an absolute URL", value)
+		}
+		local := parsed.Hostname() == "localhost" || parsed.Hostname() == "127.0.0.1"
+		if parsed.Scheme != "https" && !(parsed.Scheme == "http" && local) {
+			return Webhooks{}, fmt.Errorf("invalid WEBHOOK_URLS entry %q: must use https", value)
+		}
+		webhooks.URLs = append(webhooks.URLs, parsed.String())
+	}
+	if len(webhooks.URLs) == 0 {
+		return Webhooks{}, nil
+	}
+
+	webhooks.Secret = os.Getenv("WEBHOOK_SECRET")
+	if len(webhooks.Secret) < 16 {
+		return Webhooks{}, errors.New("WEBHOOK_SECRET must be set to at least 16 characters when WEBHOOK_URLS is set")
+	}
+
+	var err error
+	if webhooks.MaxAttempts, err = positiveIntEnv("WEBHOOK_MAX_ATTEMPTS", defaultWebhookMaxAttempts); err != nil {
+		return Webhooks{}, err
+	}
+	if webhooks.RetryBackoff, err = positiveDurationEnv("WEBHOOK_RETRY_BACKOFF", defaultWebhookRetryBackoff); err != nil {
+		return Webhooks{}, err
+	}
+	return webhooks, nil
+}
+
+// loadSlack reads SLACK_WEBHOOK_URL and SLACK_EVENTS. The webhook must use HTTPS, except
+// on localhost for local development.
+func loadSlack() (Slack, error) {
+	webhookURL := strings.TrimSpace(os.Getenv("SLACK_WEBHOOK_URL"))
+	if webhookURL == "" {
+		return Slack{}, nil
+	}
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || parsed.Host == "" {
+		return Slack{}, errors.New("invalid SLACK_WEBHOOK_URL: must be an absolute URL")
+	}
+	local := parsed.Hostname() == "localhost" || parsed.Hostname() == "127.0.0.1"
+	if parsed.Scheme != "https" && !(parsed.Scheme == "http" && local) {
+		return Slack{}, errors.New("invalid SLACK_WEBHOOK_URL: must use https")
+	}
+
+	slack := Slack{WebhookURL: webhookURL}
+	for _, event := range listEnv("SLACK_EVENTS", strings.Join(SlackEvents, ",")) {
+		event = strings.ToLower(event)
+		if !slices.Contains(SlackEvents, event) {
+			return Slack{}, fmt.Errorf("invalid SLACK_EVENTS entry %q: expected one of %s", event, strings.Join(SlackEvents, ", "))
+		}
+		if !slices.Contains(slack.Events, event) {
+			slack.Events = append(slack.Events, event)
+		}
+	}
+	return slack, nil
+}
+
+// loadCaptcha reads CAPTCHA_PROVIDER and the keys and verification settings it needs
+func loadCaptcha() (Captcha, error) {
+	provider := strings.ToLower(strings.TrimSpace(os.Getenv("CAPTCHA_PROVIDER")))
+	if provider == "" {
+		return Captcha{}, nil
+	}
+	if _, ok := CaptchaVerifyURLs[provider]; !ok {
+		return Captcha{}, fmt.Errorf("unsupported CAPTCHA_PROVIDER %q: must be %s or %s", provider, CaptchaRecaptcha, CaptchaTurnstile)
+	}
+
+	captcha := Captcha{
+		Provider:  provider,
+		SiteKey:   strings.TrimSpace(os.Getenv("CAPTCHA_SITE_KEY")),
+		SecretKey: strings.TrimSpace(os.Getenv("CAPTCHA_SECRET_KEY")),
+		VerifyURL: envOrDefault("CAPTCHA_VERIFY_URL", CaptchaVerifyURLs[provider]),
+		MinScore:  defaultCaptchaMinScore,
+	}
+	if captcha.SiteKey == "" || captcha.SecretKey == "" {
+		return Captcha{}, errors.New("CAPTCHA_SITE_KEY and CAPTCHA_SECRET_KEY must be set with CAPTCHA_PROVIDER")
+	}
+	if parsed, err := url.Parse(captcha.VerifyURL); err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return Captcha{}, fmt.Errorf("invalid CAPTCHA_VERIFY_URL %q: must be an absolute URL", captcha.VerifyURL)
+	}
+	if value := strings.TrimSpace(os.Getenv("CAPTCHA_MIN_SCORE")); value != "" {
+		score, err := strconv.ParseFloat(value, 64)
+		if err != nil || score < 0 || score > 1 {
+			return Captcha{}, fmt.Errorf("invalid CAPTCHA_MIN_SCORE %q: must be between 0 and 1", value)
+		}
+		captcha.MinScore = score
+	}
+	return captcha, nil
+}
+
+// frameAncestorPattern matches a frame-ancestors source other than 'none': 'self', a
+// scheme such as https:, or an origin, optionally with a wildcard subdomain
+var frameAncestorPattern = regexp.MustCompile(`^('self'|https?:|https?://(\*\.)?[A-Za-z0-9.\-]+(:[0-9]+)?)$`)
+
+// loadSecurityHeaders reads SECURITY_HEADERS, HSTS_MAX_AGE, HSTS_INCLUDE_SUBDOMAINS,
+// CONTENT_SECURITY_POLICY, and FRAME_ANCESTORS
+func loadSecurityHeaders() (SecurityHeaders, error) {
+	enabled, err := strconv.ParseBool(envOrDefault("SECURITY_HEADERS", "true"))
+	if err != nil {
+		return SecurityHeaders{}, fmt.Errorf("invalid SECURITY_HEADERS %q: must be true or false", os.Getenv("SECURITY_HEADERS"))
+	}
+	if !enabled {
+		return SecurityHeaders{}, nil
+	}
+	headers := SecurityHeaders{Enabled: true}
+	if headers.HSTSMaxAge, err = nonNegativeDurationEnv("HSTS_MAX_AGE", defaultHSTSMaxAge); err != nil {
+		return SecurityHeaders{}, err
+	}
+	if headers.HSTSIncludeSubdomains, err = strconv.ParseBool(envOrDefault("HSTS_INCLUDE_SUBDOMAINS", "false")); err != nil {
+		return SecurityHeaders{}, fmt.Errorf("invalid HSTS_INCLUDE_SUBDOMAINS %q: must be true or false", os.Getenv("HSTS_INCLUDE_SUBDOMAINS"))
+	}
+
+	headers.FrameAncestors = strings.Fields(strings.ReplaceAll(envOrDefault("FRAME_ANCESTORS", defaultFrameAncestors), ",", " "))
+	for _, source := range headers.FrameAncestors {
+		if source == "'none'" && len(headers.FrameAncestors) > 1 {
+			return SecurityHeaders{}, errors.New("invalid FRAME_ANCESTORS: 'none' cannot be combined with other sources")
+		}
+		if source != "'none'" && !frameAncestorPattern.MatchString(source) {
+			return SecurityHeaders{}, fmt.Errorf("invalid FRAME_ANCESTORS source %q: must be 'none', 'self', or an origin such as https://shop.example.com", source)
+		}
+	}
+
+	policy := strings.TrimSuffix(envOrDefault("CONTENT_SECURITY_POLICY", DefaultContentSecurityPolicy), ";")
+	if strings.ContainsAny(policy, "\r\n") {
+		return SecurityHeaders{}, errors.New("invalid CONTENT_SECURITY_POLICY: must be a single line")
+	}
+	// A frame-ancestors directive in the policy itself takes precedence over FRAME_ANCESTORS
+	if strings.Contains(policy, "frame-ancestors") {
+		headers.FrameAncestors = nil
+	} else {
+		policy += "; frame-ancestors " + strings.Join(headers.FrameAncestors, " ")
+	}
+	headers.ContentSecurityPolicy = policy
+	return headers, nil
+}
+
+// autocertDomainPattern matches a host name a certificate can be obtained for
+var autocertDomainPattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9\-]{0,61}[A-Za-z0-9])?\.)+[A-Za-z]{2,63}$`)
+
+// loadTLS reads TLS_CERT_FILE and TLS_KEY_FILE, or the TLS_AUTOCERT_* settings, and
+// TLS_HTTP_PORT and TLS_CLIENT_CA_FILE. The certificate, key, and client CAs are loaded
+// here, so bad files stop startup.
+func loadTLS(port string) (TLS, error) {
+	config := TLS{
+		CertFile:             strings.TrimSpace(os.Getenv("TLS_CERT_FILE")),
+		KeyFile:              strings.TrimSpace(os.Getenv("TLS_KEY_FILE")),
+		AutocertDomains:      listEnv("TLS_AUTOCERT_DOMAINS", ""),
+		AutocertCacheDir:     envOrDefault("TLS_AUTOCERT_CACHE_DIR", defaultAutocertCacheDir),
+		AutocertEmail:        strings.TrimSpace(os.Getenv("TLS_AUTOCERT_EMAIL")),
+		AutocertDirectoryURL: strings.TrimSpace(os.Getenv("TLS_AUTOCERT_DIRECTORY_URL")),
+		HTTPPort:             strings.TrimSpace(os.Getenv("TLS_HTTP_PORT")),
+		ClientCAFile:         strings.TrimSpace(os.Getenv("TLS_CLIENT_CA_FILE")),
+	}
+	// ACME certificate authorities cannot present a client certificate to validate a domain
+	if config.ClientCAFile != "" && config.CertFile == "" {
+		return TLS{}, errors.New("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+
+	switch {
+	case (config.CertFile == "") != (config.KeyFile == ""):
+		return TLS{}, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	case config.CertFile != "" && len(config.AutocertDomains) > 0:
+		return TLS{}, errors.New("TLS_CERT_FILE and TLS_AUTOCERT_DOMAINS cannot both be set")
+	case config.CertFile != "":
+		if _, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile); err != nil {
+			return TLS{}, fmt.Errorf("invalid TLS_CERT_FILE or TLS_KEY_FILE: %w", err)
+		}
+		if config.ClientCAFile != "" {
+			pem, err := os.ReadFile(config.ClientCAFile)
+			if err != nil || !x509.NewCertPool().AppendCertsFromPEM(pem) {
+				return TLS{}, fmt.Errorf("invalid TLS_CLIENT_CA_FILE %q: must be a PEM file of CA certificates", config.ClientCAFile)
+			}
+		}
+	case len(config.AutocertDomains) > 0:
+		for _, domain := range config.AutocertDomains {
+			if !autocertDomainPattern.MatchString(domain) {
+				return TLS{}, fmt.Errorf("invalid TLS_AUTOCERT_DOMAINS entry %q: must be a host name such as pay.example.com", domain)
+			}
+		}
+		if config.AutocertDirectoryURL != "" {
+			if parsed, err := url.Parse(config.AutocertDirectoryURL); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+				return TLS{}, fmt.Errorf("invalid TLS_AUTOCERT_DIRECTORY_URL %q: must be an https URL", config.AutocertDirectoryURL)
+			}
+		}
+	default:
+		if config.HTTPPort != "" {
+			return TLS{}, errors.New("TLS_HTTP_PORT requires TLS_CERT_FILE or TLS_AUTOCERT_DOMAINS")
+		}
+		return TLS{}, nil
+	}
+
+	if config.HTTPPort != "" {
+		if n, err := strconv.Atoi(config.HTTPPort); err != nil || n <= 0 || n > 65535 {
+			return TLS{}, fmt.Errorf("invalid TLS_HTTP_PORT %q: must be a port number", config.HTTPPort)
+		}
+		if config.HTTPPort == port {
+			return TLS{}, fmt.Errorf("invalid TLS_HTTP_PORT %q: must differ from PORT", config.HTTPPort)
+		}
+	}
+	return config, nil
+}
+
+// loadEventPublisher reads EVENT_PUBLISHER, EVENT_SOURCE, and the KAFKA_* or NATS_*
+// settings of the chosen driver
+func loadEventPublisher() (EventPublisher, error) {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("EVENT_PUBLISHER")))
+	publisher := EventPublisher{Driver: driver, Source: envOrDefault("EVENT_SOURCE", defaultEventSource)}
+	switch driver {
+	case "":
+		return EventPublisher{}, nil
+	case EventPublisherKafka:
+		kafka := KafkaPublisher{
+			Brokers:       listEnv("KAFKA_BROKERS", ""),
+			Topic:         envOrDefault("KAFKA_TOPIC", defaultKafkaTopic),
+			SASLMechanism: strings.ToLower(strings.TrimSpace(os.Getenv("KAFKA_SASL_MECHANISM"))),
+			SASLUsername:  os.Getenv("KAFKA_SASL_USERNAME"),
+			SASLPassword:  os.Getenv("KAFKA_SASL_PASSWORD"),
+		}
+		if len(kafka.Brokers) == 0 {
+			return EventPublisher{}, errors.New("KAFKA_BROKERS is required when EVENT_PUBLISHER is kafka")
+		}
+		tls, err := strconv.ParseBool(envOrDefault("KAFKA_TLS", "false"))
+		if err != nil {
+			return EventPublisher{}, fmt.Errorf("invalid KAFKA_TLS %q: must be true or false", os.Getenv("KAFKA_TLS"))
+		}
+		kafka.TLS = tls
+		switch kafka.SASLMechanism {
+		case "":
+		case KafkaSASLPlain, KafkaSASLSCRAMSHA256, KafkaSASLSCRAMSHA512:
+			if kafka.SASLUsername == "" || kafka.SASLPassword == "" {
+				return EventPublisher{}, errors.New("KAFKA_SASL_USERNAME and KAFKA_SASL_PASSWORD are required with KAFKA_SASL_MECHANISM")
+			}
+		default:
+			return EventPublisher{}, fmt.Errorf("invalid KAFKA_SASL_MECHANISM %q: expected %s, %s, or %s",
+				kafka.SASLMechanism, KafkaSASLPlain, KafkaSASLSCRAMSHA256, KafkaSASLSCRAMSHA512)
+		}
+		publisher.Kafka = kafka
+	case EventPublisherNATS:
+		nats := NATSPublisher{
+			URL:       envOrDefault("NATS_URL", defaultNATSURL),
+			Subject:   envOrDefault("NATS_SUBJECT", defaultNATSSubject),
+			CredsFile: strings.TrimSpace(os.Getenv("NATS_CREDS_FILE")),
+		}
+		if strings.ContainsAny(nats.Subject, " *>") || strings.HasPrefix(nats.Subject, ".") || strings.HasSuffix(nats.Subject, ".") {
+			return EventPublisher{}, fmt.Errorf("invalid NATS_SUBJECT %q: must be a subject without wildcards", nats.Subject)
+		}
+		if nats.CredsFile != "" {
+			if _, err := os.Stat(nats.CredsFile); err != nil {
+				return EventPublisher{}, fmt.Errorf("invalid NATS_CREDS_FILE: %w", err)
+			}
+		}
+		publisher.NATS = nats
+	default:
+		return EventPublisher{}, fmt.Errorf("invalid EVENT_PUBLISHER %q: expected %s or %s", driver, EventPublisherKafka, EventPublisherNATS)
+	}
+	return publisher, nil
+}
+
+// loadOIDC reads OIDC_ISSUER_URL and, when it is set, the rest of the OIDC_* settings
+func loadOIDC() (OIDC, error) {
+	issuer := strings.TrimSpace(os.Getenv("OIDC_ISSUER_URL"))
+	if issuer == "" {
+		return OIDC{}, nil
+	}
+	parsed, err := url.Parse(issuer)
+	if err != nil || parsed.Host == "" {
+		return OIDC{}, fmt.Errorf("invalid OIDC_ISSUER_URL %q: must be an absolute URL", issuer)
+	}
+	local := parsed.Hostname() == "localhost" || parsed.Hostname() == "127.0.0.1"
+	if parsed.Scheme != "https" && !(parsed.Scheme == "http" && local) {
+		return OIDC{}, fmt.Errorf("invalid OIDC_ISSUER_URL %q: must use https", issuer)
+	}
+
+	oidc := OIDC{
+		IssuerURL:     issuer,
+		ClientID:      strings.TrimSpace(os.Getenv("OIDC_CLIENT_ID")),
+		ClientSecret:  os.Getenv("OIDC_CLIENT_SECRET"),
+		Scopes:        listEnv("OIDC_SCOPES", defaultOIDCScopes),
+		RolesClaim:    envOrDefault("OIDC_ROLES_CLAIM", defaultOIDCRolesClaim),
+		AdminRoles:    listEnv("OIDC_ADMIN_ROLES", defaultOIDCAdminRoles),
+		OperatorRoles: listEnv("OIDC_OPERATOR_ROLES", defaultOIDCOperatorRoles),
+		ViewerRoles:   listEnv("OIDC_VIEWER_ROLES", defaultOIDCViewerRoles),
+		SessionSecret: os.Getenv("OIDC_SESSION_SECRET"),
+	}
+	if oidc.ClientID == "" {
+		return OIDC{}, errors.New("OIDC_CLIENT_ID must be set when OIDC_ISSUER_URL is set")
+	}
+	redirect, err := url.Parse(strings.TrimSpace(os.Getenv("OIDC_REDIRECT_URL")))
+	if err != nil || redirect.Host == "" || (redirect.Scheme != "https" && redirect.Scheme != "http") {
+		return OIDC{}, errors.New("OIDC_REDIRECT_URL must be set to this server's absolute /admin/oidc/callback URL when OIDC_ISSUER_URL is set")
+	}
+	oidc.RedirectURL = redirect.String()
+	if !slices.Contains(oidc.Scopes, "openid") {
+		oidc.Scopes = append([]string{"openid"}, oidc.Scopes...)
+	}
+	if len(oidc.SessionSecret) < 32 {
+		return OIDC{}, errors.New("OIDC_SESSION_SECRET must be set to at least 32 characters when OIDC_ISSUER_URL is set")
+	}
+	if oidc.SessionTTL, err = positiveDurationEnv("OIDC_SESSION_TTL", defaultOIDCSessionTTL); err != nil {
+		return OIDC{}, err
+	}
+	return oidc, nil
+}
+
+// loadExpiry reads EXPIRY_INTERVAL and EXPIRY_DEACTIVATE_AT_GP
+func loadExpiry() (Expiry, error) {
+	interval, err := nonNegativeDurationEnv("EXPIRY_INTERVAL", defaultExpiryInterval)
+	if err != nil {
+		return Expiry{}, err
+	}
+	deactivate, err := strconv.ParseBool(envOrDefault("EXPIRY_DEACTIVATE_AT_GP", "false"))
+	if err != nil {
+		return Expiry{}, fmt.Errorf("invalid EXPIRY_DEACTIVATE_AT_GP %q: must be true or false", os.Getenv("EXPIRY_DEACTIVATE_AT_GP"))
+	}
+	return Expiry{
+		Interval:       interval,
+		DeactivateAtGP: deactivate,
+	}, nil
+}
+
+// loadRetention reads RETENTION_INTERVAL and RETENTION_POLICIES, a comma-separated list of
+// table:action:age entries such as links:anonymize:395d. The table is links, customers, or
+// webhooks, the action delete or anonymize, and the age a number of days such as 395d or a
+// duration such as 72h. LINK_RETENTION_DAYS, from before policies could be configured, is
+// taken as a links:delete policy.
+func loadRetention() (Retention, error) {
+	interval, err := nonNegativeDurationEnv("RETENTION_INTERVAL", defaultRetentionInterval)
+	if err != nil {
+		return Retention{}, err
+	}
+	retention := Retention{Interval: interval}
+
+	if value := strings.TrimSpace(os.Getenv("RETENTION_POLICIES")); value != "" {
+		for _, entry := range strings.Split(value, ",") {
+			parts := strings.Split(strings.TrimSpace(entry), ":")
+			if len(parts) != 3 {
+				return Retention{}, fmt.Errorf("invalid RETENTION_POLICIES entry %q: expected table:action:age", entry)
+			}
+
+			policy := RetentionPolicy{Table: strings.ToLower(parts[0]), Action: strings.ToLower(parts[1])}
+			switch policy.Table {
+			case RetentionLinks, RetentionCustomers, RetentionWebhooks:
+			default:
+				return Retention{}, fmt.Errorf("invalid RETENTION_POLICIES entry %q: table must be links, customers, or webhooks", entry)
+			}
+			if slices.ContainsFunc(retention.Policies, func(p RetentionPolicy) bool { return p.Table == policy.Table }) {
+				return Retention{}, fmt.Errorf("invalid RETENTION_POLICIES entry %q: %s already has a policy", entry, policy.Table)
+			}
+			if policy.Action != RetentionDelete && policy.Action != RetentionAnonymize {
+				return Retention{}, fmt.Errorf("invalid RETENTION_POLICIES entry %q: action must be delete or anonymize", entry)
+			}
+			if policy.MaxAge, err = parseAge(parts[2]); err != nil {
+				return Retention{}, fmt.Errorf("invalid RETENTION_POLICIES entry %q: %w", entry, err)
+			}
+			retention.Policies = append(retention.Policies, policy)
+		}
+	}
+
+	retentionDays, err := nonNegativeIntEnv("LINK_RETENTION_DAYS", 0)
+	if err != nil {
+		return Retention{}, err
+	}
+	if retentionDays > 0 {
+		if slices.ContainsFunc(retention.Policies, func(p RetentionPolicy) bool { return p.Table == RetentionLinks }) {
+			return Retention{}, errors.New("LINK_RETENTION_DAYS cannot be set with a links policy in RETENTION_POLICIES")
+		}
+		retention.Policies = append(retention.Policies, RetentionPolicy{
+			Table:  RetentionLinks,
+			Action: RetentionDelete,
+			MaxAge: time.Duration(retentionDays) * 24 * time.Hour,
+		})
+	}
+	return retention, nil
+}
+
+// parseAge parses a retention age given as a number of days such as 395d, or as a duration such as 72h
+func parseAge(value string) (time.Duration, error) {
+	var age time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, errors.New("age must be a number of days such as 395d or a duration such as 72h")
+		}
+		age = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if age, err = time.ParseDuration(value); err != nil {
+			return 0, errors.New("age must be a number of days such as 395d or a duration such as 72h")
+		}
+	}
+	if age <= 0 {
+		return 0, errors.New("age must be positive")
+	}
+	return age, nil
+}
+
+// loadReminders reads REMINDER_INTERVAL, REMINDER_HOURS_BEFORE, REMINDER_REPEAT_HOURS, and REMINDER_MAX_COUNT
+func loadReminders() (Reminders, error) {
+	interval, err := nonNegativeDurationEnv("REMINDER_INTERVAL", defaultReminderInterval)
+	if err != nil {
+		return Reminders{}, err
+	}
+	hoursBefore, err := positiveIntEnv("REMINDER_HOURS_BEFORE", defaultReminderHoursBefore)
+	if err != nil {
+		return Reminders{}, err
+	}
+	repeatHours, err := positiveIntEnv("REMINDER_REPEAT_HOURS", defaultReminderRepeatHours)
+	if err != nil {
+		return Reminders{}, err
+	}
+	maxCount, err := positiveIntEnv("REMINDER_MAX_COUNT", defaultReminderMaxCount)
+	if err != nil {
+		return Reminders{}, err
+	}
+	return Reminders{
+		Interval: interval,
+		Before:   time.Duration(hoursBefore) * time.Hour,
+		Repeat:   time.Duration(repeatHours) * time.Hour,
+		MaxCount: maxCount,
+	}, nil
+}
+
+// promoCodePattern matches the promo codes that may be configured
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{1,32}$`)
+
+// fixedDiscountPattern matches an amount with its currency such as 5.00EUR, as given for
+// fixed promo code discounts and velocity limits
+var fixedDiscountPattern = regexp.MustCompile(`^([0-9.]+)([A-Z]{3})$`)
+
+// loadPromoCodes reads PROMO_CODES, a comma-separated list of code:discount[:expiry[:maxUses]]
+// entries. The discount is a percentage such as 10% or a fixed amount with its currency
+// such as 5.00EUR, and the expiry is the last valid day as YYYY-MM-DD (UTC).
+func loadPromoCodes() (map[string]PromoCode, error) {
+	value := strings.TrimSpace(os.Getenv("PROMO_CODES"))
+	if value == "" {
+		return nil, nil
+	}
+
+	codes := make(map[string]PromoCode)
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) < 2 || len(parts) > 4 {
+			return nil, fmt.Errorf("invalid PROMO_CODES entry %q: expected code:discount[:expiry[:maxUses]]", entry)
+		}
+
+		promo := PromoCode{Code: strings.ToUpper(parts[0])}
+		if !promoCodePattern.MatchString(promo.Code) {
+			return nil, fmt.Errorf("invalid PROMO_CODES code %q: must be 1-32 letters, digits, '_', or '-'", parts[0])
+		}
+		if _, ok := codes[promo.Code]; ok {
+			return nil, fmt.Errorf("duplicate PROMO_CODES code %q", promo.Code)
+		}
+
+		discount := strings.ToUpper(parts[1])
+		if percent, ok := strings.CutSuffix(discount, "%"); ok {
+			n, err := strconv.Atoi(percent)
+			if err != nil || n < 1 || n > 99 {
+				return nil, fmt.Errorf("invalid discount for promo code %q: percentage must be between 1%% and 99%%", promo.Code)
+			}
+			promo.Percent = n
+		} else {
+			match := fixedDiscountPattern.FindStringSubmatch(discount)
+			if match == nil {
+				return nil, fmt.Errorf("invalid discount for promo code %q: expected a percentage such as 10%% or an amount such as 5.00EUR", promo.Code)
+			}
+			amount, currency := match[1], match[2]
+			minor, err := money.ToMinorUnits(amount, currency)
+			if err != nil {
+				return nil, fmt.Errorf("invalid discount for promo code %q: %w", promo.Code, err)
+			}
+			promo.AmountOff = minor
+			promo.Currency = currency
+		}
+
+		if len(parts) > 2 && parts[2] != "" {
+			day, err := time.Parse(time.DateOnly, parts[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid expiry for promo code %q: must be a date such as 2025-12-31", promo.Code)
+			}
+			promo.ExpiresAt = day.Add(24*time.Hour - time.Nanosecond)
+		}
+		if len(parts) > 3 && parts[3] != "" {
+			n, err := strconv.Atoi(parts[3])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid max uses for promo code %q: must be a non-negative integer", promo.Code)
+			}
+			promo.MaxUses = n
+		}
+		codes[promo.Code] = promo
+	}
+	return codes, nil
+}
+
+// merchantAccountPattern matches GP merchant IDs and account names, such as MER_abc123 or
+// transaction_processing
+var merchantAccountPattern = regexp.MustCompile(`^[A-Za-z0-9_\-]{1,64}$`)
+
+// loadMerchantAccounts reads MERCHANT_ACCOUNTS, a comma-separated list of
+// merchantId[:accountName] entries in the order requests are matched against them
+func loadMerchantAccounts() ([]MerchantAccount, error) {
+	value := strings.TrimSpace(os.Getenv("MERCHANT_ACCOUNTS"))
+	if value == "" {
+		return nil, nil
+	}
+
+	var accounts []MerchantAccount
+	seen := make(map[MerchantAccount]bool)
+	for _, entry := range strings.Split(value, ",") {
+		merchantID, accountName, _ := strings.Cut(strings.TrimSpace(entry), ":")
+		account := MerchantAccount{MerchantID: strings.TrimSpace(merchantID), AccountName: strings.TrimSpace(accountName)}
+		if !merchantAccountPattern.MatchString(account.MerchantID) {
+			return nil, fmt.Errorf("invalid MERCHANT_ACCOUNTS entry %q: expected merchantId[:accountName] of letters, digits, '_', or '-'", entry)
+		}
+		if strings.Contains(entry, ":") && !merchantAccountPattern.MatchString(account.AccountName) {
+			return nil, fmt.Errorf("invalid MERCHANT_ACCOUNTS entry %q: account name must be 1-64 letters, digits, '_', or '-'", entry)
+		}
+		if seen[account] {
+			return nil, fmt.Errorf("duplicate MERCHANT_ACCOUNTS entry %q", entry)
+		}
+		seen[account] = true
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// loadRisk reads the payer blocklists: RISK_BLOCKED_COUNTRIES, comma-separated country
+// codes, and RISK_BLOCKED_EMAILS, comma-separated addresses and @domain entries
+func loadRisk() (Risk, error) {
+	var risk Risk
+	for _, entry := range listEnv("RISK_BLOCKED_COUNTRIES", "") {
+		code, err := country.Parse(entry)
+		if err != nil {
+			return Risk{}, fmt.Errorf("invalid RISK_BLOCKED_COUNTRIES entry: %w", err)
+		}
+		if risk.BlockedCountries == nil {
+			risk.BlockedCountries = make(map[string]bool)
+		}
+		risk.BlockedCountries[code] = true
+	}
+	for _, entry := range listEnv("RISK_BLOCKED_EMAILS", "") {
+		entry = strings.ToLower(entry)
+		local, domain, ok := strings.Cut(entry, "@")
+		if !ok || domain == "" || strings.ContainsAny(domain, "@ ") || strings.Contains(local, " ") {
+			return Risk{}, fmt.Errorf("invalid RISK_BLOCKED_EMAILS entry %q: expected an address such as name@example.com or a domain such as @example.com", entry)
+		}
+		if risk.BlockedEmails == nil {
+			risk.BlockedEmails = make(map[string]bool)
+		}
+		risk.BlockedEmails[entry] = true
+	}
+	return risk, nil
+}
+
+// loadIPAccess reads IP_ALLOWLIST_ADMIN, IP_DENYLIST_ADMIN, IP_ALLOWLIST_PUBLIC, and
+// IP_DENYLIST_PUBLIC, each a comma-separated list of CIDR ranges and single addresses
+func loadIPAccess() (IPAccess, error) {
+	var access IPAccess
+	for _, list := range []struct {
+		name   string
+		target *[]netip.Prefix
+	}{
+		{"IP_ALLOWLIST_ADMIN", &access.Admin.Allow},
+		{"IP_DENYLIST_ADMIN", &access.Admin.Deny},
+		{"IP_ALLOWLIST_PUBLIC", &access.Public.Allow},
+		{"IP_DENYLIST_PUBLIC", &access.Public.Deny},
+	} {
+		for _, entry := range listEnv(list.name, "") {
+			prefix, err := parseIPRange(entry)
+			if err != nil {
+				return IPAccess{}, fmt.Errorf("invalid %s entry %q: expected a CIDR range such as 203.0.113.0/24 or an address", list.name, entry)
+			}
+			*list.target = append(*list.target, prefix)
+		}
+	}
+	return access, nil
+}
+
+// appleTeamIDPattern matches an Apple Developer team ID
+var appleTeamIDPattern = regexp.MustCompile(`^[A-Z0-9]{10}$`)
+
+// loadWallet reads the APPLE_WALLET_* and GOOGLE_WALLET_* settings. The certificates and
+// keys are loaded here, so bad files stop startup.
+func loadWallet() (Wallet, error) {
+	config := Wallet{
+		ApplePassTypeID:          strings.TrimSpace(os.Getenv("APPLE_WALLET_PASS_TYPE_ID")),
+		AppleTeamID:              strings.TrimSpace(os.Getenv("APPLE_WALLET_TEAM_ID")),
+		AppleCertFile:            strings.TrimSpace(os.Getenv("APPLE_WALLET_CERT_FILE")),
+		AppleKeyFile:             strings.TrimSpace(os.Getenv("APPLE_WALLET_KEY_FILE")),
+		AppleWWDRFile:            strings.TrimSpace(os.Getenv("APPLE_WALLET_WWDR_FILE")),
+		GoogleIssuerID:           strings.TrimSpace(os.Getenv("GOOGLE_WALLET_ISSUER_ID")),
+		GoogleServiceAccountFile: strings.TrimSpace(os.Getenv("GOOGLE_WALLET_SERVICE_ACCOUNT_FILE")),
+	}
+
+	apple := []string{config.ApplePassTypeID, config.AppleTeamID, config.AppleCertFile, config.AppleKeyFile, config.AppleWWDRFile}
+	switch {
+	case !slices.Contains(apple, ""):
+		if !strings.HasPrefix(config.ApplePassTypeID, "pass.") {
+			return Wallet{}, fmt.Errorf("invalid APPLE_WALLET_PASS_TYPE_ID %q: must be a Pass Type ID such as pass.com.example.paybylink", config.ApplePassTypeID)
+		}
+		if !appleTeamIDPattern.MatchString(config.AppleTeamID) {
+			return Wallet{}, fmt.Errorf("invalid APPLE_WALLET_TEAM_ID %q: must be a 10-character team ID such as A1B2C3D4E5", config.AppleTeamID)
+		}
+		if _, err := wallet.LoadAppleSigner(config.ApplePassTypeID, config.AppleTeamID, config.AppleCertFile, config.AppleKeyFile, config.AppleWWDRFile); err != nil {
+			return Wallet{}, fmt.Errorf("invalid Apple Wallet settings: %w", err)
+		}
+	case slices.ContainsFunc(apple, func(value string) bool { return value != "" }):
+		return Wallet{}, errors.New("APPLE_WALLET_PASS_TYPE_ID, APPLE_WALLET_TEAM_ID, APPLE_WALLET_CERT_FILE, APPLE_WALLET_KEY_FILE, and APPLE_WALLET_WWDR_FILE must be set together")
+	}
+
+	switch {
+	case (config.GoogleIssuerID == "") != (config.GoogleServiceAccountFile == ""):
+		return Wallet{}, errors.New("GOOGLE_WALLET_ISSUER_ID and GOOGLE_WALLET_SERVICE_ACCOUNT_FILE must be set together")
+	case config.GoogleIssuerID != "":
+		if _, err := wallet.LoadGoogleIssuer(config.GoogleIssuerID, config.GoogleServiceAccountFile); err != nil {
+			return Wallet{}, fmt.Errorf("invalid Google Wallet settings: %w", err)
+		}
+	}
+	return config, nil
+}
+
+// parseIPRange parses a CIDR range, or a single address as a range of one
+func parseIPRange(entry string) (netip.Prefix, error) {
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		if prefix.Addr().Is4In6() {
+			return netip.Prefix{}, errors.New("IPv4-mapped ranges are not supported")
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// loadAmountLimits reads the MIN_AMOUNT_<currency> and MAX_AMOUNT_<currency> variables,
+// such as MIN_AMOUNT_EUR=100 and MAX_AMOUNT_EUR=500000, as whole numbers of minor units
+func loadAmountLimits() (map[string]AmountLimit, error) {
+	limits := make(map[string]AmountLimit)
+	// Sorted so the same configuration always reports the same error first
+	environ := os.Environ()
+	slices.Sort(environ)
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		currency, isMin := strings.CutPrefix(name, "MIN_AMOUNT_")
+		if !isMin {
+			var isMax bool
+			if currency, isMax = strings.CutPrefix(name, "MAX_AMOUNT_"); !isMax {
+				continue
+			}
+		}
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		if !money.IsCurrency(currency) || currency != strings.ToUpper(currency) {
+			return nil, fmt.Errorf("invalid %s: %s is not an upper-case ISO 4217 currency code", name, currency)
+		}
+		amount, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || amount <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive whole number of minor units", name, value)
+		}
+
+		limit := limits[currency]
+		if isMin {
+			limit.Min = amount
+		} else {
+			limit.Max = amount
+		}
+		limits[currency] = limit
+	}
+
+	for currency, limit := range limits {
+		if limit.Max > 0 && limit.Min > limit.Max {
+			return nil, fmt.Errorf("invalid MIN_AMOUNT_%s: %d is greater than MAX_AMOUNT_%s %d", currency, limit.Min, currency, limit.Max)
+		}
+	}
+	return limits, nil
+}
+
+// loadVelocityLimits reads VELOCITY_LIMITS, a comma-separated list of
+// scope:window:maxLinks[:maxAmount] entries such as reference:24h:3 or customer:24h:0:2000.00EUR.
+// The scope is reference, customer, or apikey, and a maxLinks of 0 leaves only the amount capped.
+func loadVelocityLimits() ([]VelocityLimit, error) {
+	value := strings.TrimSpace(os.Getenv("VELOCITY_LIMITS"))
+	if value == "" {
+		return nil, nil
+	}
+
+	var limits []VelocityLimit
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) < 3 || len(parts) > 4 {
+			return nil, fmt.Errorf("invalid VELOCITY_LIMITS entry %q: expected scope:window:maxLinks[:maxAmount]", entry)
+		}
+
+		limit := VelocityLimit{Scope: strings.ToLower(parts[0])}
+		switch limit.Scope {
+		case VelocityScopeReference, VelocityScopeCustomer, VelocityScopeAPIKey:
+		default:
+			return nil, fmt.Errorf("invalid VELOCITY_LIMITS entry %q: scope must be reference, customer, or apikey", entry)
+		}
+
+		window, err := time.ParseDuration(parts[1])
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid VELOCITY_LIMITS entry %q: window must be a positive duration such as 1h", entry)
+		}
+		limit.Window = window
+
+		if limit.MaxLinks, err = strconv.Atoi(parts[2]); err != nil || limit.MaxLinks < 0 {
+			return nil, fmt.Errorf("invalid VELOCITY_LIMITS entry %q: maxLinks must be a non-negative integer", entry)
+		}
+
+		if len(parts) > 3 {
+			match := fixedDiscountPattern.FindStringSubmatch(strings.ToUpper(parts[3]))
+			if match == nil {
+				return nil, fmt.Errorf("invalid VELOCITY_LIMITS entry %q: maxAmount must be an amount with its currency such as 2000.00EUR", entry)
+			}
+			if limit.MaxAmount, err = money.ToMinorUnits(match[1], match[2]); err != nil {
+				return nil, fmt.Errorf("invalid VELOCITY_LIMITS entry %q: %w", entry, err)
+			}
+			limit.Currency = match[2]
+		}
+		if limit.MaxLinks == 0 && limit.MaxAmount == 0 {
+			return nil, fmt.Errorf("invalid VELOCITY_LIMITS entry %q: set maxLinks, maxAmount, or both", entry)
+		}
+		limits = append(limits, limit)
+	}
+	return limits, nil
+}
+
+// taxRegionPattern matches the region part of an ISO 3166-2 subdivision code, such as CA in US-CA
+var taxRegionPattern = regexp.MustCompile(`^[A-Z0-9]{1,3}$`)
+
+// loadTax reads TAX_RATES, TAX_DEFAULT_RATE, and TAX_LABEL. TAX_RATES is a comma-separated
+// list of country:percent or country-region:percent entries, such as GB:20,US-CA:7.25.
+func loadTax() (Tax, error) {
+	tax := Tax{Label: envOrDefault("TAX_LABEL", defaultTaxLabel)}
+
+	if value := strings.TrimSpace(os.Getenv("TAX_DEFAULT_RATE")); value != "" {
+		rate, err := parseBasisPoints(value)
+		if err != nil {
+			return Tax{}, fmt.Errorf("invalid TAX_DEFAULT_RATE %q: %w", value, err)
+		}
+		tax.DefaultRate = rate
+	}
+
+	value := strings.TrimSpace(os.Getenv("TAX_RATES"))
+	if value == "" {
+		return tax, nil
+	}
+	tax.Rates = make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		key, percent, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return Tax{}, fmt.Errorf("invalid TAX_RATES entry %q: expected country:percent or country-region:percent", entry)
+		}
+
+		countryPart, region, hasRegion := strings.Cut(strings.ToUpper(key), "-")
+		countryCode, err := country.Parse(countryPart)
+		if err != nil {
+			return Tax{}, fmt.Errorf("invalid TAX_RATES entry %q: %w", entry, err)
+		}
+		key = countryCode
+		if hasRegion {
+			if !taxRegionPattern.MatchString(region) {
+				return Tax{}, fmt.Errorf("invalid TAX_RATES entry %q: region must be 1-3 letters or digits", entry)
+			}
+			key += "-" + region
+		}
+		if _, ok := tax.Rates[key]; ok {
+			return Tax{}, fmt.Errorf("duplicate TAX_RATES entry for %s", key)
+		}
+
+		rate, err := parseBasisPoints(percent)
+		if err != nil {
+			return Tax{}, fmt.Errorf("invalid TAX_RATES entry %q: %w", entry, err)
+		}
+		tax.Rates[key] = rate
+	}
+	return tax, nil
+}
+
+// parseBasisPoints parses a percentage with up to two decimal places, such as 7.25, into basis points
+func parseBasisPoints(percent string) (int, error) {
+	whole, fraction, _ := strings.Cut(strings.TrimSpace(percent), ".")
+	if whole == "" || len(fraction) > 2 || !decimalPattern.MatchString(percent) {
+		return 0, errors.New("must be a percentage such as 20 or 7.25")
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+	rate, err := strconv.Atoi(whole + fraction)
+	if err != nil || rate > 10000 {
+		return 0, errors.New("must be a percentage from 0 to 100")
+	}
+	return rate, nil
+}
+
+// loadTracing reads the standard OpenTelemetry variables that decide whether spans are exported:
+// OTEL_SDK_DISABLED, OTEL_TRACES_EXPORTER, OTEL_EXPORTER_OTLP_ENDPOINT (or its traces-only
+// variant), OTEL_EXPORTER_OTLP_PROTOCOL, and OTEL_SERVICE_NAME
+func loadTracing() (Tracing, error) {
+	tracing := Tracing{ServiceName: envOrDefault("OTEL_SERVICE_NAME", defaultServiceName)}
+
+	disabled, err := strconv.ParseBool(envOrDefault("OTEL_SDK_DISABLED", "false"))
+	if err != nil {
+		return Tracing{}, fmt.Errorf("invalid OTEL_SDK_DISABLED %q: must be true or false", os.Getenv("OTEL_SDK_DISABLED"))
+	}
+	if disabled {
+		return tracing, nil
+	}
+
+	endpoint := envOrDefault("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	switch exporter := strings.ToLower(envOrDefault("OTEL_TRACES_EXPORTER", "otlp")); exporter {
+	case "none":
+		return tracing, nil
+	case "otlp":
+		tracing.Enabled = strings.TrimSpace(endpoint) != ""
+	default:
+		return Tracing{}, fmt.Errorf("unsupported OTEL_TRACES_EXPORTER %q: must be otlp or none", exporter)
+	}
+
+	// Only the OTLP/HTTP exporter is built in
+	for _, name := range []string{"OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"} {
+		if protocol := strings.TrimSpace(os.Getenv(name)); protocol != "" && protocol != "http/protobuf" {
+			return Tracing{}, fmt.Errorf("unsupported %s %q: only http/protobuf is supported", name, protocol)
+		}
+	}
+	return tracing, nil
+}
+
+// listEnv splits the named comma-separated environment variable, or fallback when it is
+// unset or empty, dropping blank entries
+func listEnv(name, fallback string) []string {
+	var values []string
+	for _, value := range strings.Split(envOrDefault(name, fallback), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// envOrDefault returns the named environment variable, or fallback when it is unset or empty
+func envOrDefault(name, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// positiveIntEnv reads a positive integer from the environment, returning fallback when unset
+func positiveIntEnv(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive integer", name, value)
+	}
+	return n, nil
+}
+
+// nonNegativeIntEnv reads a non-negative integer from the environment, returning fallback when unset
+func nonNegativeIntEnv(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative integer", name, value)
+	}
+	return n, nil
+}
+
+// positiveDurationEnv reads a positive Go duration such as "45s" from the environment, returning fallback when unset
+func positiveDurationEnv(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive duration such as 30s", name, value)
+	}
+	return d, nil
+}
+
+// nonNegativeDurationEnv reads a Go duration such as "5m" or "0" from the environment, returning fallback when unset
+func nonNegativeDurationEnv(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a duration such as 5m, or 0", name, value)
+	}
+	return d, nil
+}
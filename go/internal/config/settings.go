@@ -0,0 +1,441 @@
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// Where a setting's value came from. Flags override environment variables, which
+// override the config file.
+const (
+	SourceFile = "file"
+	SourceEnv  = "env"
+	SourceFlag = "flag"
+)
+
+// settingKind controls how a setting's value is shown in the effective configuration
+type settingKind int
+
+const (
+	plainSetting settingKind = iota
+	// secretSetting values are never shown
+	secretSetting
+	// maskedSetting values are identifiers shown with all but a few characters masked
+	maskedSetting
+	// urlSetting values are URLs shown with any password removed
+	urlSetting
+)
+
+// settings lists every setting Load reads, so that a config file or override naming
+// anything else is reported as a mistake rather than silently ignored. Add new settings
+// here as well as to Load.
+var settings = map[string]settingKind{
+	"ALLOWED_SCRIPTS":                     plainSetting,
+	"ALLOW_UNAUTHENTICATED":               plainSetting,
+	"API_KEYS":                            secretSetting,
+	"API_KEY_PERMISSIONS":                 plainSetting,
+	"API_KEY_RATE_BURST":                  plainSetting,
+	"API_KEY_RATE_LIMIT":                  plainSetting,
+	"API_PUBLIC_CONFIG":                   plainSetting,
+	"APPLE_PAY_MERCHANT_ID":               plainSetting,
+	"APPLE_WALLET_CERT_FILE":              plainSetting,
+	"APPLE_WALLET_KEY_FILE":               plainSetting,
+	"APPLE_WALLET_PASS_TYPE_ID":           plainSetting,
+	"APPLE_WALLET_TEAM_ID":                plainSetting,
+	"APPLE_WALLET_WWDR_FILE":              plainSetting,
+	"BATCH_REQUEST_TIMEOUT":               plainSetting,
+	"BRAND_COLOR":                         plainSetting,
+	"BRAND_DISPLAY_NAME":                  plainSetting,
+	"BRAND_LOGO_URL":                      plainSetting,
+	"CANCEL_URL":                          plainSetting,
+	"CAPTCHA_MIN_SCORE":                   plainSetting,
+	"CAPTCHA_PROVIDER":                    plainSetting,
+	"CAPTCHA_SECRET_KEY":                  secretSetting,
+	"CAPTCHA_SITE_KEY":                    plainSetting,
+	"CAPTCHA_VERIFY_URL":                  plainSetting,
+	"CAPABILITIES_CACHE_TTL":              plainSetting,
+	"CONTENT_SECURITY_POLICY":             plainSetting,
+	"CORS_ALLOWED_HEADERS":                plainSetting,
+	"CORS_ALLOWED_METHODS":                plainSetting,
+	"CORS_ALLOWED_ORIGINS":                plainSetting,
+	"CORS_MAX_AGE":                        plainSetting,
+	"CSRF_PROTECTION":                     plainSetting,
+	"DATABASE_CONN_MAX_LIFETIME":          plainSetting,
+	"DATABASE_MAX_IDLE_CONNS":             plainSetting,
+	"DATABASE_MAX_OPEN_CONNS":             plainSetting,
+	"DATABASE_URL":                        urlSetting,
+	"ENCRYPTION_KEYS":                     secretSetting,
+	"ENCRYPTION_KEYS_FILE":                plainSetting,
+	"ENCRYPTION_KEY_VERSION":              plainSetting,
+	"EVENT_PUBLISHER":                     plainSetting,
+	"EVENT_SOURCE":                        plainSetting,
+	"EXPIRY_DEACTIVATE_AT_GP":             plainSetting,
+	"EXPIRY_INTERVAL":                     plainSetting,
+	"FRAME_ANCESTORS":                     plainSetting,
+	"GOOGLE_PAY_MERCHANT_ID":              plainSetting,
+	"GOOGLE_WALLET_ISSUER_ID":             plainSetting,
+	"GOOGLE_WALLET_SERVICE_ACCOUNT_FILE":  plainSetting,
+	"GP_API_APP_ID":                       maskedSetting,
+	"GP_API_APP_KEY":                      secretSetting,
+	"GP_API_BASE_URL":                     plainSetting,
+	"GP_API_CAPTURE_MODE":                 plainSetting,
+	"GP_API_CHANNEL":                      plainSetting,
+	"GP_API_COUNTRY":                      plainSetting,
+	"GP_API_DIGITAL_WALLETS":              plainSetting,
+	"GP_API_ENVIRONMENT":                  plainSetting,
+	"GP_API_MOCK":                         plainSetting,
+	"GP_API_MOCK_ADDR":                    plainSetting,
+	"GP_API_MOCK_FAILURES":                plainSetting,
+	"GP_API_MOCK_LATENCY":                 plainSetting,
+	"GP_API_MOCK_NOTIFY_URL":              plainSetting,
+	"GP_API_PAYMENT_METHODS":              plainSetting,
+	"GP_API_SHIPPABLE":                    plainSetting,
+	"GP_API_SHIPPING_AMOUNT":              plainSetting,
+	"GP_API_VERSION":                      plainSetting,
+	"HSTS_INCLUDE_SUBDOMAINS":             plainSetting,
+	"HSTS_MAX_AGE":                        plainSetting,
+	"HTTP_CLIENT_CA_BUNDLE":               plainSetting,
+	"HTTP_CLIENT_DIAL_TIMEOUT":            plainSetting,
+	"HTTP_CLIENT_IDLE_CONN_TIMEOUT":       plainSetting,
+	"HTTP_CLIENT_MAX_IDLE_CONNS":          plainSetting,
+	"HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST": plainSetting,
+	"HTTP_CLIENT_TIMEOUT":                 plainSetting,
+	"HTTP_CLIENT_TLS_HANDSHAKE_TIMEOUT":   plainSetting,
+	"IDEMPOTENCY_TTL":                     plainSetting,
+	"IP_ALLOWLIST_ADMIN":                  plainSetting,
+	"IP_ALLOWLIST_PUBLIC":                 plainSetting,
+	"IP_DENYLIST_ADMIN":                   plainSetting,
+	"IP_DENYLIST_PUBLIC":                  plainSetting,
+	"KAFKA_BROKERS":                       plainSetting,
+	"KAFKA_SASL_MECHANISM":                plainSetting,
+	"KAFKA_SASL_PASSWORD":                 secretSetting,
+	"KAFKA_SASL_USERNAME":                 plainSetting,
+	"KAFKA_TLS":                           plainSetting,
+	"KAFKA_TOPIC":                         plainSetting,
+	"LINK_RETENTION_DAYS":                 plainSetting,
+	"LOG_LEVEL":                           plainSetting,
+	"LOG_REDACTION":                       plainSetting,
+	"LOG_REDACT_FIELDS":                   plainSetting,
+	"MAX_BATCH_BODY_BYTES":                plainSetting,
+	"MAX_REQUEST_BODY_BYTES":              plainSetting,
+	"MERCHANT_ACCOUNTS":                   plainSetting,
+	"MERCHANT_NAME":                       plainSetting,
+	"NATS_CREDS_FILE":                     plainSetting,
+	"NATS_SUBJECT":                        plainSetting,
+	"NATS_URL":                            urlSetting,
+	"NOTIFICATION_ALLOWED_HOSTS":          plainSetting,
+	"OIDC_ADMIN_ROLES":                    plainSetting,
+	"OIDC_CLIENT_ID":                      plainSetting,
+	"OIDC_CLIENT_SECRET":                  secretSetting,
+	"OIDC_ISSUER_URL":                     plainSetting,
+	"OIDC_OPERATOR_ROLES":                 plainSetting,
+	"OIDC_REDIRECT_URL":                   plainSetting,
+	"OIDC_ROLES_CLAIM":                    plainSetting,
+	"OIDC_SCOPES":                         plainSetting,
+	"OIDC_SESSION_SECRET":                 secretSetting,
+	"OIDC_SESSION_TTL":                    plainSetting,
+	"OIDC_VIEWER_ROLES":                   plainSetting,
+	"PORT":                                plainSetting,
+	"PROMO_CODES":                         plainSetting,
+	"RATE_LIMIT_BURST":                    plainSetting,
+	"RATE_LIMIT_PER_MINUTE":               plainSetting,
+	"RECONCILE_INTERVAL":                  plainSetting,
+	"RECURRING_INTERVAL":                  plainSetting,
+	"RETENTION_INTERVAL":                  plainSetting,
+	"RETENTION_POLICIES":                  plainSetting,
+	"REDIS_URL":                           urlSetting,
+	"REFERENCE_FORMAT":                    plainSetting,
+	"REMINDER_HOURS_BEFORE":               plainSetting,
+	"REMINDER_INTERVAL":                   plainSetting,
+	"REMINDER_MAX_COUNT":                  plainSetting,
+	"REMINDER_REPEAT_HOURS":               plainSetting,
+	"REQUEST_TIMEOUT":                     plainSetting,
+	"RETURN_URL":                          plainSetting,
+	"RISK_BLOCKED_COUNTRIES":              plainSetting,
+	"RISK_BLOCKED_EMAILS":                 plainSetting,
+	"SECURITY_HEADERS":                    plainSetting,
+	"SHORTLINK_BASE_URL":                  plainSetting,
+	"SHUTDOWN_TIMEOUT":                    plainSetting,
+	"SLACK_EVENTS":                        plainSetting,
+	"SLACK_WEBHOOK_URL":                   secretSetting,
+	"SMS_PROVIDER":                        plainSetting,
+	"SQLITE_PATH":                         plainSetting,
+	"STATIC_DIR":                          plainSetting,
+	"STATUS_NOTIFICATION_MAX_SKEW":        plainSetting,
+	"STATUS_URL":                          plainSetting,
+	"STORE_DRIVER":                        plainSetting,
+	"SUPPORTED_CURRENCIES":                plainSetting,
+	"TAX_DEFAULT_RATE":                    plainSetting,
+	"TAX_LABEL":                           plainSetting,
+	"TAX_RATES":                           plainSetting,
+	"TLS_AUTOCERT_CACHE_DIR":              plainSetting,
+	"TLS_AUTOCERT_DIRECTORY_URL":          plainSetting,
+	"TLS_AUTOCERT_DOMAINS":                plainSetting,
+	"TLS_AUTOCERT_EMAIL":                  plainSetting,
+	"TLS_CERT_FILE":                       plainSetting,
+	"TLS_CLIENT_CA_FILE":                  plainSetting,
+	"TLS_HTTP_PORT":                       plainSetting,
+	"TLS_KEY_FILE":                        plainSetting,
+	"TRUSTED_PROXY_COUNT":                 plainSetting,
+	"TWILIO_ACCOUNT_SID":                  maskedSetting,
+	"TWILIO_AUTH_TOKEN":                   secretSetting,
+	"TWILIO_FROM_NUMBER":                  plainSetting,
+	"TWILIO_STATUS_CALLBACK_URL":          plainSetting,
+	"VELOCITY_LIMITS":                     plainSetting,
+	"WEBHOOK_MAX_ATTEMPTS":                plainSetting,
+	"WEBHOOK_RETRY_BACKOFF":               plainSetting,
+	"WEBHOOK_SECRET":                      secretSetting,
+	"WEBHOOK_URLS":                        plainSetting,
+}
+
+// settingPrefixes are the prefixes of settings named after a value, such as MIN_AMOUNT_EUR,
+// and of the OpenTelemetry SDK's own settings. OTLP headers often carry credentials, so
+// they are kept secret. The first matching prefix applies.
+var settingPrefixes = []struct {
+	prefix string
+	kind   settingKind
+}{
+	{"MIN_AMOUNT_", plainSetting},
+	{"MAX_AMOUNT_", plainSetting},
+	{"OTEL_EXPORTER_OTLP_HEADERS", secretSetting},
+	{"OTEL_EXPORTER_OTLP_TRACES_HEADERS", secretSetting},
+	{"OTEL_", plainSetting},
+}
+
+// sources records the settings that came from the config file or a flag. Any other
+// setting that is set came from the environment.
+var sources = map[string]string{}
+
+// baseEnvironment is the process environment before any settings were added to it
+var baseEnvironment []string
+
+// Setting is one setting the configuration was loaded from
+type Setting struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	// digest identifies the unredacted value, so changes to secrets can be detected
+	digest [sha256.Size]byte
+}
+
+// Errors lists every problem found with the configuration
+type Errors []error
+
+// Error implements the error interface, listing each problem
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	if len(e) == 1 {
+		return messages[0]
+	}
+	return fmt.Sprintf("%d problems: %s", len(e), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the individual problems
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// lookupSetting returns how the named setting is shown, and whether it is a known setting
+func lookupSetting(name string) (settingKind, bool) {
+	if kind, ok := settings[name]; ok {
+		return kind, true
+	}
+	for _, p := range settingPrefixes {
+		if strings.HasPrefix(name, p.prefix) {
+			return p.kind, true
+		}
+	}
+	return plainSetting, false
+}
+
+// ApplyFile reads settings from the YAML file at path into the environment, where Load
+// reads them. Keys are setting names in any case, and nested keys are joined with
+// underscores, so gp_api: {environment: production} sets GP_API_ENVIRONMENT. Lists are
+// joined with commas. Settings already in the environment are left as they are.
+func ApplyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	values := map[string]string{}
+	var problems Errors
+	flattenYAML(doc.Content[0], "", values, &problems)
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		if _, known := lookupSetting(name); !known {
+			problems = append(problems, fmt.Errorf("unknown setting %s in config file %s", name, path))
+			continue
+		}
+		if _, set := os.LookupEnv(name); set {
+			continue
+		}
+		os.Setenv(name, values[name])
+		sources[name] = SourceFile
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// flattenYAML adds the settings in node to values, naming nested keys after their parents
+func flattenYAML(node *yaml.Node, prefix string, values map[string]string, problems *Errors) {
+	if node.Kind != yaml.MappingNode {
+		*problems = append(*problems, fmt.Errorf("config file line %d: expected setting names and values", node.Line))
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		name := strings.ToUpper(key.Value)
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+		switch value.Kind {
+		case yaml.ScalarNode:
+			values[name] = value.Value
+		case yaml.SequenceNode:
+			items := make([]string, 0, len(value.Content))
+			for _, item := range value.Content {
+				if item.Kind != yaml.ScalarNode {
+					*problems = append(*problems, fmt.Errorf("config file line %d: %s must be a list of values", item.Line, name))
+					break
+				}
+				items = append(items, item.Value)
+			}
+			values[name] = strings.Join(items, ",")
+		case yaml.MappingNode:
+			flattenYAML(value, name, values, problems)
+		default:
+			*problems = append(*problems, fmt.Errorf("config file line %d: unsupported value for %s", value.Line, name))
+		}
+	}
+}
+
+// ApplyOverrides sets each NAME=VALUE override in the environment, replacing any value from
+// the environment or the config file
+func ApplyOverrides(overrides []string) error {
+	var problems Errors
+	for _, override := range overrides {
+		name, value, ok := strings.Cut(override, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if !ok || name == "" {
+			problems = append(problems, fmt.Errorf("invalid override %q: must be NAME=VALUE", override))
+			continue
+		}
+		if _, known := lookupSetting(name); !known {
+			problems = append(problems, fmt.Errorf("unknown setting %s in override", name))
+			continue
+		}
+		os.Setenv(name, value)
+		sources[name] = SourceFlag
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// SaveEnvironment records the process environment before .env, ApplyFile, or ApplyOverrides
+// add settings to it, for RestoreEnvironment
+func SaveEnvironment() {
+	baseEnvironment = os.Environ()
+}
+
+// RestoreEnvironment undoes the settings added since SaveEnvironment, so that they can be
+// applied again when the configuration is reloaded. Variables that are unchanged are left
+// alone, so they never appear unset to code reading the environment meanwhile.
+func RestoreEnvironment() {
+	base := map[string]string{}
+	for _, entry := range baseEnvironment {
+		name, value, _ := strings.Cut(entry, "=")
+		base[name] = value
+	}
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		if original, ok := base[name]; !ok {
+			os.Unsetenv(name)
+		} else if value != original {
+			os.Setenv(name, original)
+		}
+	}
+	clear(sources)
+}
+
+// effectiveSettings lists every known setting that is set, sorted by name, with where it
+// came from and its value redacted as its kind requires. Unlisted settings take their defaults.
+func effectiveSettings() []Setting {
+	var effective []Setting
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		kind, known := lookupSetting(name)
+		if !known {
+			continue
+		}
+		source := sources[name]
+		if source == "" {
+			source = SourceEnv
+		}
+		effective = append(effective, Setting{Name: name, Value: redactSetting(kind, value), Source: source, digest: sha256.Sum256([]byte(value))})
+	}
+	slices.SortFunc(effective, func(a, b Setting) int { return strings.Compare(a.Name, b.Name) })
+	return effective
+}
+
+// ChangedSettings returns the names of the settings whose values differ between two
+// effective configurations, including settings only one of them sets
+func ChangedSettings(before, after []Setting) []string {
+	digests := map[string][sha256.Size]byte{}
+	for _, setting := range before {
+		digests[setting.Name] = setting.digest
+	}
+	var changed []string
+	for _, setting := range after {
+		if digest, ok := digests[setting.Name]; !ok || digest != setting.digest {
+			changed = append(changed, setting.Name)
+		}
+		delete(digests, setting.Name)
+	}
+	changed = append(changed, slices.Collect(maps.Keys(digests))...)
+	slices.Sort(changed)
+	return changed
+}
+
+// redactSetting returns value as it may be shown for a setting of the given kind
+func redactSetting(kind settingKind, value string) string {
+	switch kind {
+	case secretSetting:
+		if value == "" {
+			return ""
+		}
+		return "[REDACTED]"
+	case maskedSetting:
+		return logging.MaskSecret(value)
+	case urlSetting:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" {
+			// Key=value connection strings may hold a password anywhere
+			return "[REDACTED]"
+		}
+		return u.Redacted()
+	}
+	return value
+}
@@ -0,0 +1,224 @@
+// Package eventbus publishes link lifecycle events as CloudEvents to a message broker,
+// Kafka or NATS, for merchants piping payment events into their data platform.
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/webhooks"
+)
+
+// EventTypePrefix is prepended to a link event's type, such as link.paid, to form its
+// CloudEvents type
+const EventTypePrefix = "com.globalpayments.paybylink."
+
+// ContentType is the media type of a CloudEvent in the structured JSON mode
+const ContentType = "application/cloudevents+json"
+
+// Publishing limits
+const (
+	// queueSize is how many events can wait to be published before new ones are dropped
+	queueSize = 1000
+	// publishTimeout bounds each publish, including the broker's acknowledgement
+	publishTimeout = 10 * time.Second
+)
+
+// ErrPublisherNotConfigured is returned when redelivering an event to a broker other than
+// the one configured
+var ErrPublisherNotConfigured = errors.New("event publisher is not configured")
+
+// CloudEvent is a link event in the CloudEvents 1.0 JSON format
+type CloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	// LinkEvent is the link event type without the prefix, used to route the event
+	LinkEvent string      `json:"-"`
+	Data      *store.Link `json:"data"`
+}
+
+// queuedEvent is an event waiting to be published, with the callback reporting whether it was
+type queuedEvent struct {
+	event CloudEvent
+	done  func(published bool)
+}
+
+// EventPublisher sends events to a message broker
+type EventPublisher interface {
+	// Driver names the broker, e.g. "kafka"
+	Driver() string
+	// Publish sends one event, returning once the broker or its client has accepted it
+	Publish(ctx context.Context, event CloudEvent) error
+	// Close flushes any buffered events and disconnects
+	Close() error
+}
+
+// Open connects to the broker configured in cfg
+func Open(cfg config.EventPublisher) (EventPublisher, error) {
+	switch cfg.Driver {
+	case config.EventPublisherKafka:
+		return NewKafkaPublisher(cfg.Kafka)
+	case config.EventPublisherNATS:
+		return NewNATSPublisher(cfg.NATS)
+	default:
+		return nil, fmt.Errorf("unsupported event publisher %q", cfg.Driver)
+	}
+}
+
+// Bus publishes events in the background, one at a time and in the order they happened,
+// so a slow broker never delays the API
+type Bus struct {
+	publisher   EventPublisher
+	source      string
+	deadLetters webhooks.DeadLetterStore
+	queue       chan queuedEvent
+	done        chan struct{}
+	// mu guards closed, so no event is queued once the queue is closed
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewBus starts publishing events with publisher, naming source as their origin. Events
+// the broker rejects are recorded in deadLetters under the publisher's driver name.
+func NewBus(publisher EventPublisher, source string, deadLetters webhooks.DeadLetterStore) *Bus {
+	b := &Bus{
+		publisher:   publisher,
+		source:      source,
+		deadLetters: deadLetters,
+		queue:       make(chan queuedEvent, queueSize),
+		done:        make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Publish queues event without blocking the caller, dropping it when the queue is full.
+// done is called, unless it is nil, with whether the broker accepted the event, so the
+// caller can keep it to send again. A nil Bus calls done with true straight away.
+func (b *Bus) Publish(event webhooks.Event, done func(published bool)) {
+	if done == nil {
+		done = func(bool) {}
+	}
+	if b == nil {
+		done(true)
+		return
+	}
+	cloudEvent := CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              event.ID,
+		Source:          b.source,
+		Type:            EventTypePrefix + event.Type,
+		Time:            event.CreatedAt,
+		DataContentType: "application/json",
+		LinkEvent:       event.Type,
+		Data:            event.Data,
+	}
+	if event.Data != nil {
+		cloudEvent.Subject = event.Data.ID
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		slog.Warn("Event published after shutdown, dropping it", "event_id", event.ID, "event_type", event.Type)
+		done(false)
+		return
+	}
+	select {
+	case b.queue <- queuedEvent{event: cloudEvent, done: done}:
+	default:
+		slog.Error("Event publisher queue full, dropping event", "driver", b.publisher.Driver(),
+			"event_id", event.ID, "event_type", event.Type)
+		done(false)
+	}
+}
+
+// Close publishes the events still queued, waiting up to ctx's deadline, and disconnects
+// from the broker. It is a no-op on a nil Bus.
+func (b *Bus) Close(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return b.publisher.Close()
+	case <-ctx.Done():
+		return fmt.Errorf("%d events still waiting to be published: %w", len(b.queue), ctx.Err())
+	}
+}
+
+// Redeliver publishes a dead-lettered event again, so an operator can resend it once the
+// broker is back. Only events dead-lettered by the configured driver are published.
+func (b *Bus) Redeliver(ctx context.Context, letter *store.WebhookDeadLetter) error {
+	if b == nil || letter.URL != b.publisher.Driver() {
+		return ErrPublisherNotConfigured
+	}
+	var event CloudEvent
+	if err := json.Unmarshal([]byte(letter.Payload), &event); err != nil {
+		return fmt.Errorf("failed to decode dead-lettered event: %w", err)
+	}
+	event.LinkEvent = strings.TrimPrefix(event.Type, EventTypePrefix)
+
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	return b.publisher.Publish(ctx, event)
+}
+
+// run publishes queued events until the queue is closed and drained. Brokers are not
+// retried here beyond their client's own retries; events they reject are dead-lettered.
+func (b *Bus) run() {
+	defer close(b.done)
+	for queued := range b.queue {
+		event := queued.event
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		err := b.publisher.Publish(ctx, event)
+		cancel()
+		if err != nil {
+			queued.done(b.deadLetter(event, err))
+			continue
+		}
+		queued.done(true)
+	}
+}
+
+// deadLetter records an event the broker rejected with err, returning whether it was
+// recorded
+func (b *Bus) deadLetter(event CloudEvent, err error) bool {
+	logger := slog.With("driver", b.publisher.Driver(), "event_id", event.ID, "event_type", event.LinkEvent, "link_id", event.Subject)
+	// CloudEvents hold only plain values and a stored link, which always encode
+	payload, _ := json.Marshal(event)
+	letter := &store.WebhookDeadLetter{
+		EventID:   event.ID,
+		EventType: event.LinkEvent,
+		URL:       b.publisher.Driver(),
+		Payload:   string(payload),
+		Attempts:  1,
+		LastError: err.Error(),
+	}
+	if recordErr := b.deadLetters.CreateWebhookDeadLetter(context.Background(), letter); recordErr != nil {
+		logger.Error("Error recording event dead letter", "error", recordErr, "publish_error", err)
+		return false
+	}
+	logger.Error("Event dead-lettered", "dead_letter_id", letter.ID, "error", err)
+	return true
+}
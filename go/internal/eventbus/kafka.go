@@ -0,0 +1,92 @@
+package eventbus
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+)
+
+// kafkaBatchTimeout is how long the writer waits to fill a batch. Events are written one
+// at a time, so it is kept short.
+const kafkaBatchTimeout = 10 * time.Millisecond
+
+// KafkaPublisher writes events to a Kafka topic, keyed by link ID so each link's events
+// stay in order on one partition
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher creates a KafkaPublisher for the brokers and topic in cfg. Brokers are
+// not contacted until the first event is written.
+func NewKafkaPublisher(cfg config.KafkaPublisher) (*KafkaPublisher, error) {
+	transport := &kafka.Transport{ClientID: "pay-by-link-go"}
+	if cfg.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if cfg.SASLMechanism != "" {
+		mechanism, err := kafkaSASL(cfg)
+		if err != nil {
+			return nil, err
+		}
+		transport.SASL = mechanism
+	}
+
+	return &KafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: kafkaBatchTimeout,
+		Transport:    transport,
+	}}, nil
+}
+
+// kafkaSASL returns the SASL mechanism configured in cfg
+func kafkaSASL(cfg config.KafkaPublisher) (sasl.Mechanism, error) {
+	switch cfg.SASLMechanism {
+	case config.KafkaSASLPlain:
+		return plain.Mechanism{Username: cfg.SASLUsername, Password: cfg.SASLPassword}, nil
+	case config.KafkaSASLSCRAMSHA256:
+		return scram.Mechanism(scram.SHA256, cfg.SASLUsername, cfg.SASLPassword)
+	case config.KafkaSASLSCRAMSHA512:
+		return scram.Mechanism(scram.SHA512, cfg.SASLUsername, cfg.SASLPassword)
+	default:
+		return nil, fmt.Errorf("unsupported Kafka SASL mechanism %q", cfg.SASLMechanism)
+	}
+}
+
+// Driver implements EventPublisher
+func (k *KafkaPublisher) Driver() string {
+	return config.EventPublisherKafka
+}
+
+// Publish implements EventPublisher, returning once every in-sync replica has the event
+func (k *KafkaPublisher) Publish(ctx context.Context, event CloudEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	message := kafka.Message{
+		Key:     []byte(event.Subject),
+		Value:   value,
+		Headers: []kafka.Header{{Key: "content-type", Value: []byte(ContentType)}},
+	}
+	if err := k.writer.WriteMessages(ctx, message); err != nil {
+		return fmt.Errorf("failed to write to Kafka topic %s: %w", k.writer.Topic, err)
+	}
+	return nil
+}
+
+// Close implements EventPublisher
+func (k *KafkaPublisher) Close() error {
+	return k.writer.Close()
+}
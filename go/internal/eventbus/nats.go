@@ -0,0 +1,75 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+)
+
+// NATSPublisher publishes events on a subject per event type, such as
+// paybylink.events.link.paid, so subscribers can pick the events they want
+type NATSPublisher struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// NewNATSPublisher connects to the NATS server in cfg. A server that is down at startup
+// is retried in the background, with events buffered until it is reached.
+func NewNATSPublisher(cfg config.NATSPublisher) (*NATSPublisher, error) {
+	options := []nats.Option{
+		nats.Name("pay-by-link-go"),
+		nats.MaxReconnects(-1),
+		nats.RetryOnFailedConnect(true),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				slog.Warn("Disconnected from NATS", "error", err)
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			slog.Info("Reconnected to NATS", "url", conn.ConnectedUrlRedacted())
+		}),
+	}
+	if cfg.CredsFile != "" {
+		options = append(options, nats.UserCredentials(cfg.CredsFile))
+	}
+	conn, err := nats.Connect(cfg.URL, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	return &NATSPublisher{conn: conn, subject: cfg.Subject}, nil
+}
+
+// Driver implements EventPublisher
+func (n *NATSPublisher) Driver() string {
+	return config.EventPublisherNATS
+}
+
+// Publish implements EventPublisher. Core NATS does not acknowledge messages, so it
+// returns once the client has accepted the event.
+func (n *NATSPublisher) Publish(ctx context.Context, event CloudEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	msg := &nats.Msg{Subject: n.subject + "." + event.LinkEvent, Data: data, Header: nats.Header{}}
+	msg.Header.Set("Content-Type", ContentType)
+	msg.Header.Set(nats.MsgIdHdr, event.ID)
+	if err := n.conn.PublishMsg(msg); err != nil {
+		return fmt.Errorf("failed to publish to NATS subject %s: %w", msg.Subject, err)
+	}
+	return nil
+}
+
+// Close implements EventPublisher, sending buffered events before disconnecting
+func (n *NATSPublisher) Close() error {
+	defer n.conn.Close()
+	if !n.conn.IsConnected() {
+		return nil
+	}
+	return n.conn.Flush()
+}
@@ -0,0 +1,395 @@
+// Package gpapi is a minimal client for the Global Payments GP API payment link endpoints.
+package gpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// userAgent identifies this client in requests to GP API
+const userAgent = "PayByLink-Go/1.0"
+
+// LinksClient is the set of GP API operations used to manage payment links.
+// *Client implements it; tests and other programs can substitute their own.
+type LinksClient interface {
+	// Token returns a valid access token, reusing a cached token when possible
+	Token(ctx context.Context) (*TokenResponse, error)
+	// CreateLink creates a payment link
+	CreateLink(ctx context.Context, data LinkData) (*LinkResponse, error)
+	// GetLink retrieves a payment link and its transactions
+	GetLink(ctx context.Context, id string) (*LinkDetail, error)
+	// UpdateLink applies a partial update, such as LinkUpdate or LinkStatusUpdate, to a payment link
+	UpdateLink(ctx context.Context, id string, patch interface{}) (*LinkDetail, error)
+	// SearchLinks returns up to pageSize links created between from and to, newest first
+	SearchLinks(ctx context.Context, from, to time.Time, pageSize int) ([]LinkDetail, error)
+	// GetAccount retrieves a merchant account and the currencies, countries, and payment methods it supports
+	GetAccount(ctx context.Context, id string) (*Account, error)
+	// CaptureTransaction captures the full amount of a transaction authorized by a LATER capture mode link
+	CaptureTransaction(ctx context.Context, id string) (*Transaction, error)
+	// GetTransaction retrieves a transaction
+	GetTransaction(ctx context.Context, id string) (*Transaction, error)
+	// SearchTransactions returns one page of the transaction report, newest first
+	SearchTransactions(ctx context.Context, search TransactionSearch) (*TransactionList, error)
+	// RefundTransaction refunds amount, in minor units, of a captured transaction and returns the refund transaction
+	RefundTransaction(ctx context.Context, id string, amount int64) (*Transaction, error)
+}
+
+// Client calls GP API with app credentials, caching the access token between requests
+type Client struct {
+	baseURL string
+	host    string
+	appID   string
+	appKey  string
+	http    *http.Client
+	tokens  *TokenManager
+	version string
+}
+
+// NewClient creates a Client for the GP API environment at baseURL,
+// e.g. SandboxBaseURL or ProductionBaseURL
+func NewClient(baseURL, appID, appKey string) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		host:    hostOf(baseURL),
+		appID:   appID,
+		appKey:  appKey,
+		http:    &http.Client{Timeout: 30 * time.Second},
+		version: DefaultVersion,
+	}
+	c.tokens = NewTokenManager(c.fetchToken, DefaultTokenRefreshMargin)
+	return c
+}
+
+// SetTokenStore shares access tokens with other instances through store.
+// It must be called before the client is first used.
+func (c *Client) SetTokenStore(store TokenStore) {
+	c.tokens.SetStore(store)
+}
+
+// SetHTTPClient sends requests with client in place of the default client with a 30-second
+// timeout, such as to share a connection pool and proxy settings with other outbound calls.
+// It must be called before the client is first used.
+func (c *Client) SetHTTPClient(client *http.Client) {
+	c.http = client
+}
+
+// SetVersion sends version in the X-GP-Version header of every request, in place of
+// DefaultVersion. It must be called before the client is first used.
+func (c *Client) SetVersion(version string) {
+	c.version = version
+}
+
+// versionKey is the context key holding the GP API version for a single call
+type versionKey struct{}
+
+// WithVersion returns a copy of ctx that makes GP API calls send version in the
+// X-GP-Version header, so one call can use fields from a newer API version without
+// changing the version of every other call. Access token requests keep the client's
+// version, as the token is shared between calls.
+func WithVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, versionKey{}, version)
+}
+
+// versionFor returns the GP API version to send for a call made with ctx
+func (c *Client) versionFor(ctx context.Context) string {
+	if version, ok := ctx.Value(versionKey{}).(string); ok && version != "" {
+		return version
+	}
+	return c.version
+}
+
+// Token returns a valid access token, fetching a new one when the cached token is missing or expired
+func (c *Client) Token(ctx context.Context) (*TokenResponse, error) {
+	return c.tokens.Token(ctx)
+}
+
+// generateSecret generates a secret hash using SHA512 for GP API authentication.
+// The secret is created as SHA512(NONCE + APP-KEY).
+func generateSecret(nonce, appKey string) string {
+	data := nonce + appKey
+	hash := sha512.Sum512([]byte(data))
+	return strings.ToLower(hex.EncodeToString(hash[:]))
+}
+
+// fetchToken requests a new access token from GP API using the app credentials
+func (c *Client) fetchToken(ctx context.Context) (_ *TokenResponse, err error) {
+	ctx, span := tracer().Start(ctx, "GP API access token", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String("POST"),
+			semconv.URLPath("/accesstoken"),
+			semconv.ServerAddress(c.host),
+		))
+	defer func() { endSpan(span, err) }()
+
+	// Generate nonce using the same format as .NET SDK
+	nonce := time.Now().Format("01/02/2006 03:04:05.000 PM")
+
+	tokenRequest := TokenRequest{
+		AppID:     c.appID,
+		Nonce:     nonce,
+		GrantType: "client_credentials",
+		Secret:    generateSecret(nonce, c.appKey),
+	}
+
+	requestBody, err := json.Marshal(tokenRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal token request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/accesstoken", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GP-Api-Key", c.appKey)
+	req.Header.Set("X-GP-Version", c.version)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode), attribute.String("gp.request_id", resp.Header.Get(RequestIDHeader)))
+	logging.FromContext(ctx).Debug("GP API call", "action", "token request", "status", resp.StatusCode, "gp_request_id", resp.Header.Get(RequestIDHeader))
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request failed with %w", parseAPIError(resp, body))
+	}
+
+	var tokenResponse TokenResponse
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token response: %w", err)
+	}
+
+	return &tokenResponse, nil
+}
+
+// parseAPIError extracts the most useful error message, GP's error codes, and GP's request
+// ID from a failed GP API response
+func parseAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: string(body), RequestID: resp.Header.Get(RequestIDHeader)}
+	// Try to parse error response for better error details
+	var errorResponse map[string]interface{}
+	if err := json.Unmarshal(body, &errorResponse); err != nil {
+		return apiErr
+	}
+	if desc, ok := errorResponse["detailed_error_description"]; ok {
+		apiErr.Message = fmt.Sprintf("%v", desc)
+	} else if desc, ok := errorResponse["error_description"]; ok {
+		apiErr.Message = fmt.Sprintf("%v", desc)
+	} else if msg, ok := errorResponse["message"]; ok {
+		apiErr.Message = fmt.Sprintf("%v", msg)
+	}
+	if code, ok := errorResponse["error_code"]; ok {
+		apiErr.ErrorCode = fmt.Sprintf("%v", code)
+	}
+	// GP sends the detailed code as a string, but accept a number too
+	if code, ok := errorResponse["detailed_error_code"]; ok {
+		apiErr.DetailedErrorCode = fmt.Sprintf("%v", code)
+	}
+	return apiErr
+}
+
+// do sends an authenticated request to path and decodes a successful response into out.
+// action names the operation in error messages, e.g. "payment link lookup".
+func (c *Client) do(ctx context.Context, method, path string, payload, out interface{}, action string, okStatuses ...int) (err error) {
+	ctx, span := tracer().Start(ctx, "GP API "+action, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(method),
+			semconv.URLPath(strings.SplitN(path, "?", 2)[0]),
+			semconv.ServerAddress(c.host),
+		))
+	defer func() { endSpan(span, err) }()
+
+	version := c.versionFor(ctx)
+	span.SetAttributes(attribute.String("gp.version", version))
+
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return &TokenError{Err: err}
+	}
+
+	var body io.Reader
+	if payload != nil {
+		requestBody, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", action, err)
+		}
+		body = bytes.NewBuffer(requestBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", action, err)
+	}
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	req.Header.Set("X-GP-Version", version)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute %s request: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", action, err)
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode), attribute.String("gp.request_id", resp.Header.Get(RequestIDHeader)))
+	logging.FromContext(ctx).Debug("GP API call", "action", action, "status", resp.StatusCode, "gp_version", version, "gp_request_id", resp.Header.Get(RequestIDHeader))
+
+	ok := false
+	for _, status := range okStatuses {
+		if resp.StatusCode == status {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("%s failed with %w", action, parseAPIError(resp, respBody))
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to unmarshal %s response: %w", action, err)
+	}
+	return nil
+}
+
+// CreateLink creates a payment link
+func (c *Client) CreateLink(ctx context.Context, data LinkData) (*LinkResponse, error) {
+	var linkResponse LinkResponse
+	if err := c.do(ctx, "POST", "/links", data, &linkResponse, "payment link creation", http.StatusCreated, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &linkResponse, nil
+}
+
+// GetLink retrieves a payment link and its transactions
+func (c *Client) GetLink(ctx context.Context, id string) (*LinkDetail, error) {
+	var linkDetail LinkDetail
+	if err := c.do(ctx, "GET", "/links/"+url.PathEscape(id), nil, &linkDetail, "payment link lookup", http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &linkDetail, nil
+}
+
+// UpdateLink applies a partial update to a payment link
+func (c *Client) UpdateLink(ctx context.Context, id string, patch interface{}) (*LinkDetail, error) {
+	var linkDetail LinkDetail
+	if err := c.do(ctx, "PATCH", "/links/"+url.PathEscape(id), patch, &linkDetail, "payment link update", http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &linkDetail, nil
+}
+
+// SearchLinks retrieves up to one page of payment links created in the given window
+func (c *Client) SearchLinks(ctx context.Context, from, to time.Time, pageSize int) ([]LinkDetail, error) {
+	query := url.Values{}
+	query.Set("page", "1")
+	query.Set("page_size", strconv.Itoa(pageSize))
+	query.Set("order", "DESC")
+	query.Set("order_by", "TIME_CREATED")
+	query.Set("from_time_created", from.UTC().Format("2006-01-02"))
+	query.Set("to_time_created", to.UTC().Format("2006-01-02"))
+
+	var linkList LinkList
+	if err := c.do(ctx, "GET", "/links?"+query.Encode(), nil, &linkList, "payment link search", http.StatusOK); err != nil {
+		return nil, err
+	}
+	return linkList.Links, nil
+}
+
+// GetAccount retrieves a merchant account and its capabilities
+func (c *Client) GetAccount(ctx context.Context, id string) (*Account, error) {
+	var account Account
+	if err := c.do(ctx, "GET", "/accounts/"+url.PathEscape(id), nil, &account, "account lookup", http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// CaptureTransaction captures an authorized transaction for its full amount
+func (c *Client) CaptureTransaction(ctx context.Context, id string) (*Transaction, error) {
+	var transaction Transaction
+	if err := c.do(ctx, "POST", "/transactions/"+url.PathEscape(id)+"/capture", TransactionCapture{}, &transaction, "transaction capture", http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// GetTransaction retrieves a transaction
+func (c *Client) GetTransaction(ctx context.Context, id string) (*Transaction, error) {
+	var transaction Transaction
+	if err := c.do(ctx, "GET", "/transactions/"+url.PathEscape(id), nil, &transaction, "transaction lookup", http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// SearchTransactions retrieves one page of transactions from the reporting API
+func (c *Client) SearchTransactions(ctx context.Context, search TransactionSearch) (*TransactionList, error) {
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(search.Page))
+	query.Set("page_size", strconv.Itoa(search.PageSize))
+	query.Set("order", "DESC")
+	query.Set("order_by", "TIME_CREATED")
+	query.Set("from_time_created", search.From.UTC().Format("2006-01-02"))
+	query.Set("to_time_created", search.To.UTC().Format("2006-01-02"))
+	if search.Status != "" {
+		query.Set("status", search.Status)
+	}
+	if search.Reference != "" {
+		query.Set("reference", search.Reference)
+	}
+
+	var transactionList TransactionList
+	if err := c.do(ctx, "GET", "/transactions?"+query.Encode(), nil, &transactionList, "transaction search", http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &transactionList, nil
+}
+
+// RefundTransaction refunds amount, in minor units, of a captured transaction
+func (c *Client) RefundTransaction(ctx context.Context, id string, amount int64) (*Transaction, error) {
+	var refund Transaction
+	if err := c.do(ctx, "POST", "/transactions/"+url.PathEscape(id)+"/refund", TransactionRefund{Amount: amount}, &refund, "transaction refund", http.StatusOK, http.StatusCreated); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
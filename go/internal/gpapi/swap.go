@@ -0,0 +1,81 @@
+package gpapi
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// SwappableClient is a LinksClient that passes each call to a client that can be replaced
+// at any time, so credentials can be rotated without restarting. Calls already in flight
+// finish on the client they started with.
+type SwappableClient struct {
+	current atomic.Pointer[LinksClient]
+}
+
+// NewSwappableClient creates a SwappableClient passing calls to client
+func NewSwappableClient(client LinksClient) *SwappableClient {
+	s := &SwappableClient{}
+	s.Swap(client)
+	return s
+}
+
+// Swap passes later calls to client
+func (s *SwappableClient) Swap(client LinksClient) {
+	s.current.Store(&client)
+}
+
+// client returns the client calls are currently passed to
+func (s *SwappableClient) client() LinksClient {
+	return *s.current.Load()
+}
+
+// Token returns a valid access token from the current client
+func (s *SwappableClient) Token(ctx context.Context) (*TokenResponse, error) {
+	return s.client().Token(ctx)
+}
+
+// CreateLink creates a payment link with the current client
+func (s *SwappableClient) CreateLink(ctx context.Context, data LinkData) (*LinkResponse, error) {
+	return s.client().CreateLink(ctx, data)
+}
+
+// GetLink retrieves a payment link with the current client
+func (s *SwappableClient) GetLink(ctx context.Context, id string) (*LinkDetail, error) {
+	return s.client().GetLink(ctx, id)
+}
+
+// UpdateLink updates a payment link with the current client
+func (s *SwappableClient) UpdateLink(ctx context.Context, id string, patch interface{}) (*LinkDetail, error) {
+	return s.client().UpdateLink(ctx, id, patch)
+}
+
+// SearchLinks searches payment links with the current client
+func (s *SwappableClient) SearchLinks(ctx context.Context, from, to time.Time, pageSize int) ([]LinkDetail, error) {
+	return s.client().SearchLinks(ctx, from, to, pageSize)
+}
+
+// GetAccount retrieves a merchant account with the current client
+func (s *SwappableClient) GetAccount(ctx context.Context, id string) (*Account, error) {
+	return s.client().GetAccount(ctx, id)
+}
+
+// CaptureTransaction captures a transaction with the current client
+func (s *SwappableClient) CaptureTransaction(ctx context.Context, id string) (*Transaction, error) {
+	return s.client().CaptureTransaction(ctx, id)
+}
+
+// GetTransaction retrieves a transaction with the current client
+func (s *SwappableClient) GetTransaction(ctx context.Context, id string) (*Transaction, error) {
+	return s.client().GetTransaction(ctx, id)
+}
+
+// SearchTransactions searches the transaction report with the current client
+func (s *SwappableClient) SearchTransactions(ctx context.Context, search TransactionSearch) (*TransactionList, error) {
+	return s.client().SearchTransactions(ctx, search)
+}
+
+// RefundTransaction refunds a transaction with the current client
+func (s *SwappableClient) RefundTransaction(ctx context.Context, id string, amount int64) (*Transaction, error) {
+	return s.client().RefundTransaction(ctx, id, amount)
+}
@@ -0,0 +1,183 @@
+package gpapi
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultTokenRefreshMargin is how long before expiry a cached token is refreshed
+const DefaultTokenRefreshMargin = 5 * time.Minute
+
+// Shared token refresh timing. An instance that loses the refresh lock polls the store for
+// the winner's token for up to tokenLockWait before fetching a token itself.
+const (
+	tokenLockTTL      = 30 * time.Second
+	tokenLockWait     = 5 * time.Second
+	tokenPollInterval = 200 * time.Millisecond
+)
+
+// TokenStore shares access tokens between server instances, so replicas reuse one token
+// instead of each requesting their own. Implementations must be safe for concurrent use.
+type TokenStore interface {
+	// Load returns the stored token and when it expires, or a nil token when none is stored
+	Load(ctx context.Context) (*TokenResponse, time.Time, error)
+	// Save stores token until expiresAt
+	Save(ctx context.Context, token *TokenResponse, expiresAt time.Time) error
+	// TryLock claims the right to fetch a new token for up to ttl. It reports false when
+	// another instance holds the claim; release gives the claim up early.
+	TryLock(ctx context.Context, ttl time.Duration) (release func(), ok bool, err error)
+}
+
+// TokenManager caches the GP API access token in memory and refreshes it before it expires.
+// Concurrent callers share a single in-flight token request. With a TokenStore, the token
+// is also shared with other instances and at most one instance requests a new one at a time.
+type TokenManager struct {
+	fetch         func(context.Context) (*TokenResponse, error)
+	refreshMargin time.Duration
+	store         TokenStore
+
+	mu        sync.RWMutex
+	token     *TokenResponse
+	refreshAt time.Time
+	expiresAt time.Time
+
+	group singleflight.Group
+}
+
+// NewTokenManager creates a TokenManager that obtains tokens using fetch.
+// Tokens are refreshed refreshMargin before their reported expiry.
+func NewTokenManager(fetch func(context.Context) (*TokenResponse, error), refreshMargin time.Duration) *TokenManager {
+	return &TokenManager{
+		fetch:         fetch,
+		refreshMargin: refreshMargin,
+	}
+}
+
+// SetStore shares tokens through store. It must be called before the first Token call.
+func (m *TokenManager) SetStore(store TokenStore) {
+	m.store = store
+}
+
+// Token returns a valid access token, fetching a new one if the cache is empty or expired.
+// When the cached token is close to expiry it is still returned while a refresh runs in the background.
+// The token request is traced as part of ctx but is not cancelled with it, since other callers may share it.
+func (m *TokenManager) Token(ctx context.Context) (*TokenResponse, error) {
+	ctx = context.WithoutCancel(ctx)
+	now := time.Now()
+
+	m.mu.RLock()
+	token, refreshAt, expiresAt := m.token, m.refreshAt, m.expiresAt
+	m.mu.RUnlock()
+
+	if token != nil && now.Before(refreshAt) {
+		return token, nil
+	}
+
+	if token != nil && now.Before(expiresAt) {
+		// Token is still usable; refresh proactively without blocking the caller
+		go func() {
+			if _, err := m.refresh(ctx); err != nil {
+				slog.Warn("Background token refresh failed", "error", err)
+			}
+		}()
+		return token, nil
+	}
+
+	return m.refresh(ctx)
+}
+
+// refresh fetches a new token, collapsing concurrent calls into a single request
+func (m *TokenManager) refresh(ctx context.Context) (*TokenResponse, error) {
+	result, err, _ := m.group.Do("token", func() (interface{}, error) {
+		if m.store != nil {
+			return m.refreshShared(ctx)
+		}
+
+		token, err := m.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.cache(token, time.Now().Add(time.Duration(token.SecondsToExpire)*time.Second))
+		return token, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*TokenResponse), nil
+}
+
+// refreshShared reuses a token another instance has stored, or claims the refresh lock and
+// fetches a new one. A store that cannot be reached only costs the sharing: the token is
+// then fetched directly, so an outage of the store does not stop links being created.
+func (m *TokenManager) refreshShared(ctx context.Context) (*TokenResponse, error) {
+	if token := m.loadShared(ctx); token != nil {
+		return token, nil
+	}
+
+	release, ok, err := m.store.TryLock(ctx, tokenLockTTL)
+	if err != nil {
+		slog.Warn("Error locking shared token store, fetching a token directly", "error", err)
+	}
+	if err == nil && !ok {
+		// Another instance is fetching; wait for its token to appear
+		deadline := time.Now().Add(tokenLockWait)
+		for time.Now().Before(deadline) {
+			time.Sleep(tokenPollInterval)
+			if token := m.loadShared(ctx); token != nil {
+				return token, nil
+			}
+		}
+		slog.Warn("Timed out waiting for another instance to refresh the token, fetching a token directly")
+	}
+	if ok {
+		defer release()
+	}
+
+	token, err := m.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(time.Duration(token.SecondsToExpire) * time.Second)
+	m.cache(token, expiresAt)
+	if err := m.store.Save(ctx, token, expiresAt); err != nil {
+		slog.Warn("Error saving token to shared store", "error", err)
+	}
+	return token, nil
+}
+
+// loadShared returns the stored token and caches it locally when it is not yet due for
+// refresh, or nil when there is none or it cannot be read
+func (m *TokenManager) loadShared(ctx context.Context) *TokenResponse {
+	token, expiresAt, err := m.store.Load(ctx)
+	if err != nil {
+		slog.Warn("Error reading shared token store", "error", err)
+		return nil
+	}
+	if token == nil || !time.Now().Before(expiresAt.Add(-m.margin(token))) {
+		return nil
+	}
+	m.cache(token, expiresAt)
+	return token
+}
+
+// margin returns how long before expiry token is refreshed, at most half its lifetime
+func (m *TokenManager) margin(token *TokenResponse) time.Duration {
+	lifetime := time.Duration(token.SecondsToExpire) * time.Second
+	if m.refreshMargin > lifetime/2 {
+		return lifetime / 2
+	}
+	return m.refreshMargin
+}
+
+// cache keeps token in memory until it is due for refresh
+func (m *TokenManager) cache(token *TokenResponse, expiresAt time.Time) {
+	m.mu.Lock()
+	m.token = token
+	m.refreshAt = expiresAt.Add(-m.margin(token))
+	m.expiresAt = expiresAt
+	m.mu.Unlock()
+}
@@ -0,0 +1,35 @@
+package gpapi
+
+import (
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans created by this package
+const instrumentationName = "github.com/globalpayments/pay-by-link-go/internal/gpapi"
+
+// tracer returns the tracer for GP API calls, a no-op until a tracer provider is installed
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// endSpan records err on span, if any, and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// hostOf returns the host name of baseURL for span attributes
+func hostOf(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
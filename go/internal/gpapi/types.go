@@ -0,0 +1,393 @@
+package gpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// GP API base URLs for each supported environment
+const (
+	SandboxBaseURL    = "https://apis.sandbox.globalpay.com/ucp"
+	ProductionBaseURL = "https://apis.globalpay.com/ucp"
+)
+
+// DefaultVersion is the GP API version sent in the X-GP-Version header unless the client
+// or the call asks for another
+const DefaultVersion = "2021-03-22"
+
+// DateTimeLayout is the date-time format GP API expects for expiration dates
+const DateTimeLayout = "2006-01-02 15:04:05"
+
+// PayByLinkType identifies the kind of payment link, matching the SDK's PayByLinkType enum
+type PayByLinkType string
+
+// Supported PayByLinkType values
+const (
+	PayByLinkTypePayment           PayByLinkType = "PAYMENT"
+	PayByLinkTypeHostedPaymentPage PayByLinkType = "HOSTED_PAYMENT_PAGE"
+	PayByLinkTypeThirdPartyPage    PayByLinkType = "THIRD_PARTY_PAGE"
+)
+
+// PaymentMethodUsageMode controls how many times a link can be paid, matching the SDK's PaymentMethodUsageMode enum
+type PaymentMethodUsageMode string
+
+// Supported PaymentMethodUsageMode values
+const (
+	UsageModeSingle   PaymentMethodUsageMode = "SINGLE"
+	UsageModeMultiple PaymentMethodUsageMode = "MULTIPLE"
+)
+
+// Channel identifies whether the card is present for a link's transactions
+type Channel string
+
+// Supported Channel values
+const (
+	ChannelCardNotPresent Channel = "CNP"
+	ChannelCardPresent    Channel = "CP"
+)
+
+// CaptureMode controls whether a link's payments are captured at once or only authorized
+type CaptureMode string
+
+// Supported CaptureMode values
+const (
+	CaptureModeAuto  CaptureMode = "AUTO"  // capture the payment when it is authorized
+	CaptureModeLater CaptureMode = "LATER" // authorize only; capture with a separate request
+)
+
+// PaymentMethodName identifies a payment method a link accepts, matching the SDK's PaymentMethodName enum
+type PaymentMethodName string
+
+// Supported PaymentMethodName values
+const (
+	PaymentMethodCard          PaymentMethodName = "CARD"
+	PaymentMethodBankPayment   PaymentMethodName = "BANK_PAYMENT"
+	PaymentMethodAPM           PaymentMethodName = "APM"
+	PaymentMethodDigitalWallet PaymentMethodName = "DIGITAL_WALLET"
+)
+
+// PaymentMethodNames lists the payment methods GP API accepts on a link
+var PaymentMethodNames = []PaymentMethodName{
+	PaymentMethodCard,
+	PaymentMethodBankPayment,
+	PaymentMethodAPM,
+	PaymentMethodDigitalWallet,
+}
+
+// ParsePaymentMethods parses a comma-separated list of payment method names,
+// ignoring case and duplicates
+func ParsePaymentMethods(value string) ([]PaymentMethodName, error) {
+	var methods []PaymentMethodName
+	for _, name := range strings.Split(value, ",") {
+		method := PaymentMethodName(strings.ToUpper(strings.TrimSpace(name)))
+		if method == "" || slices.Contains(methods, method) {
+			continue
+		}
+		if !slices.Contains(PaymentMethodNames, method) {
+			return nil, fmt.Errorf("unsupported payment method %q", name)
+		}
+		methods = append(methods, method)
+	}
+	return methods, nil
+}
+
+// DigitalWalletProvider identifies a wallet offered on a link that accepts the DIGITAL_WALLET
+// payment method
+type DigitalWalletProvider string
+
+// Supported DigitalWalletProvider values
+const (
+	DigitalWalletApplePay  DigitalWalletProvider = "APPLE_PAY"
+	DigitalWalletGooglePay DigitalWalletProvider = "GOOGLE_PAY"
+)
+
+// DigitalWalletProviders lists the digital wallets GP API can offer on a link
+var DigitalWalletProviders = []DigitalWalletProvider{
+	DigitalWalletApplePay,
+	DigitalWalletGooglePay,
+}
+
+// ParseDigitalWallets parses a comma-separated list of digital wallet providers,
+// ignoring case and duplicates
+func ParseDigitalWallets(value string) ([]DigitalWalletProvider, error) {
+	var providers []DigitalWalletProvider
+	for _, name := range strings.Split(value, ",") {
+		provider := DigitalWalletProvider(strings.ToUpper(strings.TrimSpace(name)))
+		if provider == "" || slices.Contains(providers, provider) {
+			continue
+		}
+		if !slices.Contains(DigitalWalletProviders, provider) {
+			return nil, fmt.Errorf("unsupported digital wallet %q", name)
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// YesNo converts a boolean to the "YES" or "NO" flags used by GP API
+func YesNo(value bool) string {
+	if value {
+		return "YES"
+	}
+	return "NO"
+}
+
+// LinkData represents the data structure for creating payment links via GP API
+type LinkData struct {
+	AccountName    string                 `json:"account_name"`
+	Type           PayByLinkType          `json:"type"`
+	UsageMode      PaymentMethodUsageMode `json:"usage_mode"`
+	UsageLimit     int                    `json:"usage_limit"`
+	Reference      string                 `json:"reference"`
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description"`
+	Shippable      string                 `json:"shippable"`
+	ShippingAmount int                    `json:"shipping_amount"`
+	ExpirationDate string                 `json:"expiration_date"`
+	// Language is the BCP 47 tag of the language the hosted payment page is shown in
+	Language string `json:"language,omitempty"`
+	// Branding is how the hosted payment page presents the merchant, or nil for GP's default
+	Branding *LinkBranding `json:"branding,omitempty"`
+	// Images are the URLs of product pictures the hosted payment page shows
+	Images        []string          `json:"images,omitempty"`
+	Transactions  LinkTransactions  `json:"transactions"`
+	Notifications LinkNotifications `json:"notifications"`
+	MerchantID    string            `json:"merchant_id,omitempty"`
+}
+
+// LinkBranding holds the hosted payment page branding options of a link
+type LinkBranding struct {
+	MerchantDisplayName string `json:"merchant_display_name,omitempty"`
+	LogoURL             string `json:"logo_url,omitempty"`
+	// BrandColor is a hex color such as #1A73E8
+	BrandColor string `json:"brand_color,omitempty"`
+}
+
+// LinkTransactions represents transaction configuration for payment links
+type LinkTransactions struct {
+	AllowedPaymentMethods []PaymentMethodName `json:"allowed_payment_methods"`
+	Channel               Channel             `json:"channel"`
+	Country               string              `json:"country"`
+	Amount                int                 `json:"amount,omitempty"`
+	Currency              string              `json:"currency"`
+	CaptureMode           CaptureMode         `json:"capture_mode,omitempty"`
+	// MinAmount and MaxAmount bound the amount the payer enters on a HOSTED_PAYMENT_PAGE
+	// link, which is sent without a fixed amount
+	MinAmount int `json:"min_amount,omitempty"`
+	MaxAmount int `json:"max_amount,omitempty"`
+	// DynamicDescriptor replaces the merchant name on the payer's card statement
+	DynamicDescriptor string `json:"dynamic_descriptor,omitempty"`
+	// Payer carries what the merchant knows of the payer, for GP's fraud screening
+	Payer *LinkPayer `json:"payer,omitempty"`
+	// DigitalWallets are the wallets offered when AllowedPaymentMethods includes DIGITAL_WALLET
+	DigitalWallets []LinkDigitalWallet `json:"digital_wallets,omitempty"`
+}
+
+// LinkDigitalWallet enables one digital wallet on a link's hosted payment page
+type LinkDigitalWallet struct {
+	Provider DigitalWalletProvider `json:"provider"`
+	// MerchantID is the merchant's identifier with the wallet provider, such as an Apple Pay
+	// merchant identifier
+	MerchantID string `json:"merchant_id"`
+}
+
+// LinkPayer holds the payer details GP's risk checks take with a link's transactions
+type LinkPayer struct {
+	Email          string       `json:"email,omitempty"`
+	IPAddress      string       `json:"ip_address,omitempty"`
+	BillingAddress *LinkAddress `json:"billing_address,omitempty"`
+}
+
+// LinkAddress is a postal address; only the country is sent
+type LinkAddress struct {
+	Country string `json:"country"`
+}
+
+// LinkNotifications represents notification URLs for payment links
+type LinkNotifications struct {
+	ReturnURL string `json:"return_url"`
+	StatusURL string `json:"status_url"`
+	CancelURL string `json:"cancel_url"`
+}
+
+// TokenRequest represents the GP API token request
+type TokenRequest struct {
+	AppID     string `json:"app_id"`
+	Nonce     string `json:"nonce"`
+	GrantType string `json:"grant_type"`
+	Secret    string `json:"secret"`
+}
+
+// TokenResponse represents the GP API token response
+type TokenResponse struct {
+	Token                            string `json:"token"`
+	Type                             string `json:"type"`
+	AppID                            string `json:"app_id"`
+	AppName                          string `json:"app_name"`
+	TimeCreated                      string `json:"time_created"`
+	SecondsToExpire                  int    `json:"seconds_to_expire"`
+	Email                            string `json:"email"`
+	MerchantID                       string `json:"merchant_id"`
+	MerchantName                     string `json:"merchant_name"`
+	TransactionProcessingAccountID   string `json:"transaction_processing_account_id"`
+	TransactionProcessingAccountName string `json:"transaction_processing_account_name"`
+}
+
+// LinkResponse represents the GP API payment link creation response
+type LinkResponse struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// LinkDetail represents the GP API payment link retrieval response
+type LinkDetail struct {
+	ID             string                 `json:"id"`
+	URL            string                 `json:"url"`
+	Status         string                 `json:"status"`
+	Type           string                 `json:"type"`
+	UsageMode      string                 `json:"usage_mode"`
+	UsageLimit     json.Number            `json:"usage_limit"`
+	UsageCount     json.Number            `json:"usage_count"`
+	ViewedCount    json.Number            `json:"viewed_count"`
+	Reference      string                 `json:"reference"`
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description"`
+	ExpirationDate string                 `json:"expiration_date"`
+	Transactions   LinkDetailTransactions `json:"transactions"`
+}
+
+// LinkDetailTransactions represents the transaction section of a GP API link detail
+type LinkDetailTransactions struct {
+	Amount          json.Number   `json:"amount"`
+	Currency        string        `json:"currency"`
+	TransactionList []Transaction `json:"transaction_list"`
+}
+
+// LinkStatusUpdate represents a GP API request to change a payment link's status
+type LinkStatusUpdate struct {
+	Status string `json:"status"`
+}
+
+// LinkUpdate represents the fields GP API allows to be modified on an active payment link
+type LinkUpdate struct {
+	Name           string                  `json:"name,omitempty"`
+	Description    string                  `json:"description,omitempty"`
+	ExpirationDate string                  `json:"expiration_date,omitempty"`
+	Transactions   *LinkUpdateTransactions `json:"transactions,omitempty"`
+}
+
+// LinkUpdateTransactions represents the transaction fields of a payment link update
+type LinkUpdateTransactions struct {
+	Amount int `json:"amount"`
+}
+
+// TransactionCapture represents a GP API request to capture an authorized transaction
+type TransactionCapture struct {
+	Amount int64 `json:"amount,omitempty"`
+}
+
+// TransactionRefund represents a GP API request to refund a captured transaction
+type TransactionRefund struct {
+	Amount int64 `json:"amount"`
+}
+
+// LinkList represents the GP API payment link search response
+type LinkList struct {
+	Links []LinkDetail `json:"links"`
+}
+
+// Account represents the GP API merchant account details that describe what the account can process
+type Account struct {
+	ID             string              `json:"id"`
+	Name           string              `json:"name"`
+	Status         string              `json:"status"`
+	Countries      []string            `json:"countries"`
+	Currencies     []string            `json:"currencies"`
+	PaymentMethods []PaymentMethodName `json:"payment_methods"`
+	// DigitalWallets are the wallets configured for the account's DIGITAL_WALLET payments
+	DigitalWallets []DigitalWalletProvider `json:"digital_wallets"`
+}
+
+// Transaction represents a transaction summary returned by GP API
+type Transaction struct {
+	ID          string      `json:"id"`
+	TimeCreated string      `json:"time_created"`
+	Status      string      `json:"status"`
+	Type        string      `json:"type"`
+	Amount      json.Number `json:"amount"`
+	Currency    string      `json:"currency"`
+	Reference   string      `json:"reference"`
+}
+
+// TransactionStatuses lists the transaction statuses GP API reports
+var TransactionStatuses = []string{
+	"INITIATED", "PENDING", "PREAUTHORIZED", "CAPTURED", "BATCH_CLOSED",
+	"DECLINED", "REVERSED", "FUNDED", "REJECTED",
+}
+
+// TransactionSearch selects transactions from GP API's transaction report. Zero-valued
+// Status and Reference are not filtered on.
+type TransactionSearch struct {
+	// From and To bound the creation date, inclusive of both days
+	From, To  time.Time
+	Status    string
+	Reference string
+	// Page is the 1-based page to return, of PageSize transactions each
+	Page     int
+	PageSize int
+}
+
+// TransactionList represents the GP API transaction report response
+type TransactionList struct {
+	Transactions     []Transaction `json:"transactions"`
+	TotalRecordCount int           `json:"total_record_count"`
+}
+
+// RequestIDHeader is the response header in which GP API returns its identifier for a request.
+// Quote it in support tickets to Global Payments.
+const RequestIDHeader = "X-GP-Request-Id"
+
+// APIError represents an unsuccessful response from GP API
+type APIError struct {
+	StatusCode int
+	Message    string
+	// RequestID is GP's identifier for the failed request, if it returned one
+	RequestID string
+	// ErrorCode is GP's error category, such as INVALID_REQUEST_DATA, and DetailedErrorCode
+	// its numeric code for the specific error, such as 40213
+	ErrorCode         string
+	DetailedErrorCode string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+}
+
+// RequestIDOf returns GP's request ID for the API call that caused err, if any
+func RequestIDOf(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RequestID
+	}
+	return ""
+}
+
+// TokenError reports that an access token could not be obtained for a request
+type TokenError struct {
+	Err error
+}
+
+// Error implements the error interface
+func (e *TokenError) Error() string {
+	return "access token unavailable: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying token request error
+func (e *TokenError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,58 @@
+// Package httpclient builds the HTTP client shared by the server's outbound calls to GP API,
+// Twilio, and merchant webhook endpoints.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+)
+
+// New creates an http.Client from cfg. Its transport pools connections, sends requests
+// through the proxies named by HTTP_PROXY, HTTPS_PROXY, and NO_PROXY, and refuses TLS
+// versions before 1.2. A CA bundle, when configured, is trusted alongside the system roots.
+func New(cfg config.HTTPClient) (*http.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CABundle != "" {
+		roots, err := loadRoots(cfg.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     true,
+	}
+	return &http.Client{Timeout: cfg.Timeout, Transport: transport}, nil
+}
+
+// loadRoots returns the system certificate pool with the PEM certificates in path added
+func loadRoots(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA bundle: %w", err)
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", path)
+	}
+	return roots, nil
+}
@@ -0,0 +1,171 @@
+package locale
+
+// german translates API messages into German
+var german = map[string]string{
+	// Response messages
+	"Payment link creation failed":                   "Der Zahlungslink konnte nicht erstellt werden",
+	"Payment link created successfully! Link ID: %s": "Zahlungslink erfolgreich erstellt! Link-ID: %s",
+	"Batch link creation failed":                     "Der Link-Stapel konnte nicht erstellt werden",
+	"Created %d of %d payment links":                 "%d von %d Zahlungslinks erstellt",
+	"Recurring link creation failed":                 "Die wiederkehrenden Links konnten nicht erstellt werden",
+	"Recurring links scheduled! Series ID: %s":       "Wiederkehrende Links geplant! Serien-ID: %s",
+	"Recurring link lookup failed":                   "Die wiederkehrenden Links konnten nicht abgerufen werden",
+	"Payment link lookup failed":                     "Der Zahlungslink konnte nicht abgerufen werden",
+	"Payment link listing failed":                    "Die Zahlungslinks konnten nicht aufgelistet werden",
+	"Payment link export failed":                     "Die Zahlungslinks konnten nicht exportiert werden",
+	"Payment link update failed":                     "Der Zahlungslink konnte nicht aktualisiert werden",
+	"Payment link %s updated":                        "Zahlungslink %s aktualisiert",
+	"Payment link cancellation failed":               "Der Zahlungslink konnte nicht storniert werden",
+	"Payment link %s cancelled":                      "Zahlungslink %s storniert",
+	"Payment link sent by SMS":                       "Zahlungslink per SMS gesendet",
+	"SMS delivery failed":                            "Die SMS konnte nicht zugestellt werden",
+	"Reminder update failed":                         "Die Erinnerungen konnten nicht aktualisiert werden",
+	"Delivery lookup failed":                         "Die Zustellungen konnten nicht abgerufen werden",
+	"Link view lookup failed":                        "Die Aufrufe des Links konnten nicht abgerufen werden",
+	"Found %d views":                                 "%d Aufrufe gefunden",
+	"Wallet pass creation failed":                    "Der Wallet-Pass konnte nicht erstellt werden",
+	"Google Wallet pass created":                     "Google Wallet-Pass erstellt",
+	"Receipt lookup failed":                          "Der Beleg konnte nicht abgerufen werden",
+	"Transaction listing failed":                     "Die Transaktionen konnten nicht aufgelistet werden",
+	"Transaction capture failed":                     "Die Transaktion konnte nicht erfasst werden",
+	"Transaction %s captured":                        "Transaktion %s erfasst",
+	"Refund failed":                                  "Die Erstattung ist fehlgeschlagen",
+	"Refunded %s %s of transaction %s":               "%s %s der Transaktion %s erstattet",
+	"Customer created":                               "Kunde angelegt",
+	"Customer creation failed":                       "Der Kunde konnte nicht angelegt werden",
+	"Customer link listing failed":                   "Die Links des Kunden konnten nicht aufgelistet werden",
+	"Customer export failed":                         "Der Kunde konnte nicht exportiert werden",
+	"Exported customer %s with %d payment links":     "Kunde %s mit %d Zahlungslinks exportiert",
+	"Customer erasure failed":                        "Die Kundendaten konnten nicht gelöscht werden",
+	"Erased customer %s":                             "Daten des Kunden %s gelöscht",
+	"Link template created":                          "Link-Vorlage erstellt",
+	"Link template creation failed":                  "Die Link-Vorlage konnte nicht erstellt werden",
+	"Link template updated":                          "Link-Vorlage aktualisiert",
+	"Link template update failed":                    "Die Link-Vorlage konnte nicht aktualisiert werden",
+	"Link template deleted":                          "Link-Vorlage gelöscht",
+	"Link template listing failed":                   "Die Link-Vorlagen konnten nicht aufgelistet werden",
+	"Product saved":                                  "Produkt gespeichert",
+	"Product deleted":                                "Produkt gelöscht",
+	"Product listing failed":                         "Die Produkte konnten nicht aufgelistet werden",
+	"Product update failed":                          "Das Produkt konnte nicht aktualisiert werden",
+	"GraphQL request failed":                         "Die GraphQL-Anfrage ist fehlgeschlagen",
+	"Authentication required":                        "Authentifizierung erforderlich",
+	"Forbidden":                                      "Verboten",
+	"Access denied":                                  "Zugriff verweigert",
+	"Request rejected":                               "Anfrage abgelehnt",
+	"Rate limit exceeded":                            "Anfragelimit überschritten",
+	"Request timed out":                              "Zeitüberschreitung der Anfrage",
+	"Internal server error":                          "Interner Serverfehler",
+	"Service not ready":                              "Dienst nicht bereit",
+	"Event stream unavailable":                       "Ereignisstream nicht verfügbar",
+
+	// Error details
+	"A valid API key must be sent in the X-API-Key or Authorization: Bearer header": "Ein gültiger API-Schlüssel muss im Header X-API-Key oder Authorization: Bearer gesendet werden",
+	"The API key has not been granted the %s permission":                            "Dem API-Schlüssel wurde die Berechtigung %s nicht erteilt",
+	"Too many requests from this address":                                           "Zu viele Anfragen von dieser Adresse",
+	"Too many requests for API key %s":                                              "Zu viele Anfragen für den API-Schlüssel %s",
+	"Requests from this network are not allowed":                                    "Anfragen aus diesem Netzwerk sind nicht erlaubt",
+	"Form posts must include the CSRF token from /config in the X-CSRF-Token header or the csrfToken field; send JSON or an API key instead": "Formularsendungen müssen das CSRF-Token von /config im Header X-CSRF-Token oder im Feld csrfToken enthalten; senden Sie andernfalls JSON oder einen API-Schlüssel",
+	"Complete the CAPTCHA and send its token in the X-Captcha-Token header":                                                                  "Lösen Sie das CAPTCHA und senden Sie sein Token im Header X-Captcha-Token",
+	"The CAPTCHA was not solved or has expired; please try again":                                                                            "Das CAPTCHA wurde nicht gelöst oder ist abgelaufen; bitte versuchen Sie es erneut",
+	"The CAPTCHA could not be verified; please try again later":                                                                              "Das CAPTCHA konnte nicht überprüft werden; bitte versuchen Sie es später erneut",
+	"An unexpected error occurred. Quote the request ID when contacting support.":                                                            "Ein unerwarteter Fehler ist aufgetreten. Geben Sie die Anfrage-ID an, wenn Sie den Support kontaktieren.",
+	"Idempotency-Key must be 1 to 255 printable ASCII characters":                                                                            "Idempotency-Key muss aus 1 bis 255 druckbaren ASCII-Zeichen bestehen",
+	"Error reading request body":                                                           "Fehler beim Lesen des Anfragekörpers",
+	"Idempotency-Key could not be checked; retry the request":                              "Idempotency-Key konnte nicht geprüft werden; wiederholen Sie die Anfrage",
+	"A request with this Idempotency-Key is still in progress":                             "Eine Anfrage mit diesem Idempotency-Key wird noch verarbeitet",
+	"The response recorded for this Idempotency-Key could not be read":                     "Die für diesen Idempotency-Key gespeicherte Antwort konnte nicht gelesen werden",
+	"Idempotency-Key was already used for a different request":                             "Idempotency-Key wurde bereits für eine andere Anfrage verwendet",
+	"The request did not complete within %s":                                               "Die Anfrage wurde nicht innerhalb von %s abgeschlossen",
+	"Request body must not exceed %d bytes":                                                "Der Anfragetext darf %d Bytes nicht überschreiten",
+	"The server is shutting down":                                                          "Der Server wird heruntergefahren",
+	"One or more readiness checks failed":                                                  "Eine oder mehrere Bereitschaftsprüfungen sind fehlgeschlagen",
+	"Error parsing JSON request body":                                                      "Fehler beim Verarbeiten des JSON-Anfragetexts",
+	"Error parsing form data":                                                              "Fehler beim Verarbeiten der Formulardaten",
+	"%d field(s) failed validation":                                                        "%d Feld(er) haben die Prüfung nicht bestanden",
+	"Currency %s is not supported; use one of %s":                                          "Die Währung %s wird nicht unterstützt; verwenden Sie eine von %s",
+	"Payment link not found":                                                               "Zahlungslink nicht gefunden",
+	"Invalid payment link ID":                                                              "Ungültige Zahlungslink-ID",
+	"Customer not found":                                                                   "Kunde nicht gefunden",
+	"Customer %s not found":                                                                "Kunde %s nicht gefunden",
+	"Invalid customer ID":                                                                  "Ungültige Kunden-ID",
+	"Product not found":                                                                    "Produkt nicht gefunden",
+	"Link template not found":                                                              "Link-Vorlage nicht gefunden",
+	"Link template %s not found":                                                           "Link-Vorlage %s nicht gefunden",
+	"Invalid link template ID":                                                             "Ungültige Link-Vorlagen-ID",
+	"Invalid transaction ID":                                                               "Ungültige Transaktions-ID",
+	"Recurring link series not found":                                                      "Serie wiederkehrender Links nicht gefunden",
+	"Invalid recurring link series ID":                                                     "Ungültige Serien-ID wiederkehrender Links",
+	"Unknown promo code":                                                                   "Unbekannter Aktionscode",
+	"Promo code %s has expired":                                                            "Der Aktionscode %s ist abgelaufen",
+	"Promo code %s has reached its maximum uses":                                           "Der Aktionscode %s hat die maximale Anzahl an Verwendungen erreicht",
+	"Promo code %s only applies to %s amounts":                                             "Der Aktionscode %s gilt nur für Beträge in %s",
+	"Promo code %s would reduce the amount %s to nothing":                                  "Der Aktionscode %s würde den Betrag %s auf null reduzieren",
+	"Promo codes cannot be applied to open-amount links":                                   "Aktionscodes können nicht auf Links mit freiem Betrag angewendet werden",
+	"Tax cannot be added to open-amount links":                                             "Steuern können Links mit freiem Betrag nicht hinzugefügt werden",
+	"taxRegion was provided but no tax rates are configured":                               "taxRegion wurde angegeben, aber es sind keine Steuersätze konfiguriert",
+	"customerPhone was provided but SMS delivery is not configured":                        "customerPhone wurde angegeben, aber der SMS-Versand ist nicht konfiguriert",
+	"SMS delivery is not configured on this server":                                        "Der SMS-Versand ist auf diesem Server nicht konfiguriert",
+	"No customerPhone provided or stored for this link":                                    "Für diesen Link wurde keine customerPhone angegeben oder gespeichert",
+	"Payments from billing country %s are not accepted":                                    "Zahlungen aus dem Rechnungsland %s werden nicht akzeptiert",
+	"Payments from this email address are not accepted":                                    "Zahlungen von dieser E-Mail-Adresse werden nicht akzeptiert",
+	"Only active links can be edited; link status is %s":                                   "Nur aktive Links können bearbeitet werden; der Linkstatus ist %s",
+	"Amount cannot be changed after the link has been used":                                "Der Betrag kann nicht mehr geändert werden, nachdem der Link verwendet wurde",
+	"Amount cannot be changed on links priced from line items or with a promo code or tax": "Der Betrag kann bei Links, die aus Artikeln berechnet wurden oder einen Aktionscode oder Steuern enthalten, nicht geändert werden",
+	"Open-amount links have no fixed amount to change":                                     "Links mit freiem Betrag haben keinen festen Betrag, der geändert werden kann",
+	"Provide at least one of amount, name, description, expirationDays, or expirationDate": "Geben Sie mindestens eines der Felder amount, name, description, expirationDays oder expirationDate an",
+	"Payment link is %s; receipts are only available once it is paid":                      "Der Zahlungslink ist %s; Belege sind erst nach der Zahlung verfügbar",
+	"A batch may contain at most %d links, got %d":                                         "Ein Stapel darf höchstens %d Links enthalten, erhalten: %d",
+	"No payment link requests provided":                                                    "Es wurden keine Zahlungslink-Anfragen übermittelt",
+	"Payment link is %s; wallet passes are only available while it can be paid":            "Der Zahlungslink ist %s; Wallet-Pässe sind nur verfügbar, solange er bezahlt werden kann",
+	"Wallet passes are not configured on this server":                                      "Wallet-Pässe sind auf diesem Server nicht konfiguriert",
+	"%s Wallet passes are not configured on this server":                                   "%s Wallet-Pässe sind auf diesem Server nicht konfiguriert",
+	"wallet must be apple or google":                                                       "wallet muss apple oder google sein",
+	"Error signing the wallet pass":                                                        "Fehler beim Signieren des Wallet-Passes",
+	"limit must be between 1 and %d":                                                       "limit muss zwischen 1 und %d liegen",
+
+	// Field messages
+	"%s is required":                                          "%s ist erforderlich",
+	"amount is required":                                      "amount ist erforderlich",
+	"%s must be at most %d characters":                        "%s darf höchstens %d Zeichen lang sein",
+	"%s must not contain control characters":                  "%s darf keine Steuerzeichen enthalten",
+	"%s may only contain letters from the %s scripts":         "%s darf nur Buchstaben der Schriften %s enthalten",
+	"%s must be a %s":                                         "%s muss vom Typ %s sein",
+	"%s is not a recognized field":                            "%s ist kein bekanntes Feld",
+	"%s must not be empty":                                    "%s darf nicht leer sein",
+	"currency is required when amount is preset":              "currency ist erforderlich, wenn amount vorgegeben ist",
+	"currency must be an ISO 4217 currency code, such as EUR": "currency muss ein ISO-4217-Währungscode sein, z. B. EUR",
+	"reference may only contain letters, digits, spaces, underscores, hyphens, and #":       "reference darf nur Buchstaben, Ziffern, Leerzeichen, Unterstriche, Bindestriche und # enthalten",
+	"reference must be at most %d characters for %d occurrences":                            "reference darf höchstens %d Zeichen lang sein, bei %d Raten",
+	"description must be at most %d characters including the tax breakdown":                 "description darf einschließlich der Steueraufschlüsselung höchstens %d Zeichen lang sein",
+	"email must be a plain address such as name@example.com":                                "email muss eine einfache Adresse sein, z. B. name@example.com",
+	"payerEmail must be a plain address such as name@example.com":                           "payerEmail muss eine einfache Adresse sein, z. B. name@example.com",
+	"phone must be in E.164 format, e.g. +447700900123":                                     "phone muss im Format E.164 sein, z. B. +447700900123",
+	"billingCountry must be an ISO 3166-1 alpha-2 country code, such as IE":                 "billingCountry muss ein Ländercode nach ISO 3166-1 Alpha-2 sein, z. B. IE",
+	"payerIp must be an IPv4 or IPv6 address":                                               "payerIp muss eine IPv4- oder IPv6-Adresse sein",
+	"language must be a language tag such as es or fr-CA":                                   "language muss ein Sprach-Tag sein, z. B. es oder fr-CA",
+	"%s must be an absolute https URL":                                                      "%s muss eine absolute https-URL sein",
+	"images may contain at most %d URLs":                                                    "images darf höchstens %d URLs enthalten",
+	"brandColor must be a hex color such as #1A73E8":                                        "brandColor muss eine Hex-Farbe sein, z. B. #1A73E8",
+	"amount cannot be combined with minAmount or maxAmount":                                 "amount kann nicht mit minAmount oder maxAmount kombiniert werden",
+	"minAmount must not be greater than maxAmount":                                          "minAmount darf nicht größer als maxAmount sein",
+	"usageMode must be SINGLE or MULTIPLE":                                                  "usageMode muss SINGLE oder MULTIPLE sein",
+	"usageLimit must be 1 when usageMode is SINGLE":                                         "usageLimit muss 1 sein, wenn usageMode SINGLE ist",
+	"usageLimit must be a whole number between 1 and %d":                                    "usageLimit muss eine ganze Zahl zwischen 1 und %d sein",
+	"expirationDays must be a whole number of at least 1":                                   "expirationDays muss eine ganze Zahl von mindestens 1 sein",
+	"expiration must be within %d days":                                                     "der Ablauf muss innerhalb von %d Tagen liegen",
+	"expirationDate must be an RFC3339 timestamp, e.g. 2025-01-31T23:59:00Z":                "expirationDate muss ein RFC3339-Zeitstempel sein, z. B. 2025-01-31T23:59:00Z",
+	"expirationDate must be in the future":                                                  "expirationDate muss in der Zukunft liegen",
+	"shippable must be true or false":                                                       "shippable muss true oder false sein",
+	"captureMode must be AUTO or LATER":                                                     "captureMode muss AUTO oder LATER sein",
+	"dynamicDescriptor must contain a letter":                                               "dynamicDescriptor muss einen Buchstaben enthalten",
+	"dynamicDescriptor may only contain ASCII letters, digits, spaces, and & * , - . / # '": "dynamicDescriptor darf nur ASCII-Buchstaben, Ziffern, Leerzeichen und & * , - . / # ' enthalten",
+	"metadata may have at most %d keys":                                                     "metadata darf höchstens %d Schlüssel haben",
+	"metadata keys and values must total at most %d bytes":                                  "Schlüssel und Werte von metadata dürfen zusammen höchstens %d Bytes umfassen",
+	"metadata keys must be 1-40 letters, digits, '.', '_', or '-'":                          "Schlüssel von metadata müssen aus 1-40 Buchstaben, Ziffern, '.', '_' oder '-' bestehen",
+	"quantity must be between 1 and %d":                                                     "quantity muss zwischen 1 und %d liegen",
+	"occurrences must be a whole number from %d to %d":                                      "occurrences muss eine ganze Zahl von %d bis %d sein",
+	"currency must be an ISO 4217 currency code":                                            "currency muss ein ISO-4217-Währungscode sein",
+	"Request body must be a JSON array of payment link requests":                            "Der Anfragetext muss ein JSON-Array von Zahlungslink-Anfragen sein",
+	"at most %d items are allowed":                                                          "höchstens %d Positionen sind erlaubt",
+}
@@ -0,0 +1,171 @@
+package locale
+
+// spanish translates API messages into Spanish
+var spanish = map[string]string{
+	// Response messages
+	"Payment link creation failed":                   "No se pudo crear el enlace de pago",
+	"Payment link created successfully! Link ID: %s": "¡Enlace de pago creado correctamente! ID del enlace: %s",
+	"Batch link creation failed":                     "No se pudo crear el lote de enlaces",
+	"Created %d of %d payment links":                 "Se crearon %d de %d enlaces de pago",
+	"Recurring link creation failed":                 "No se pudieron crear los enlaces periódicos",
+	"Recurring links scheduled! Series ID: %s":       "¡Enlaces periódicos programados! ID de la serie: %s",
+	"Recurring link lookup failed":                   "No se pudieron consultar los enlaces periódicos",
+	"Payment link lookup failed":                     "No se pudo consultar el enlace de pago",
+	"Payment link listing failed":                    "No se pudieron listar los enlaces de pago",
+	"Payment link export failed":                     "No se pudieron exportar los enlaces de pago",
+	"Payment link update failed":                     "No se pudo actualizar el enlace de pago",
+	"Payment link %s updated":                        "Enlace de pago %s actualizado",
+	"Payment link cancellation failed":               "No se pudo cancelar el enlace de pago",
+	"Payment link %s cancelled":                      "Enlace de pago %s cancelado",
+	"Payment link sent by SMS":                       "Enlace de pago enviado por SMS",
+	"SMS delivery failed":                            "No se pudo enviar el SMS",
+	"Reminder update failed":                         "No se pudieron actualizar los recordatorios",
+	"Delivery lookup failed":                         "No se pudieron consultar los envíos",
+	"Link view lookup failed":                        "No se pudieron consultar las visitas del enlace",
+	"Found %d views":                                 "Se encontraron %d visitas",
+	"Wallet pass creation failed":                    "No se pudo crear el pase de Wallet",
+	"Google Wallet pass created":                     "Pase de Google Wallet creado",
+	"Receipt lookup failed":                          "No se pudo consultar el recibo",
+	"Transaction listing failed":                     "No se pudieron listar las transacciones",
+	"Transaction capture failed":                     "No se pudo capturar la transacción",
+	"Transaction %s captured":                        "Transacción %s capturada",
+	"Refund failed":                                  "No se pudo realizar el reembolso",
+	"Refunded %s %s of transaction %s":               "Reembolsados %s %s de la transacción %s",
+	"Customer created":                               "Cliente creado",
+	"Customer creation failed":                       "No se pudo crear el cliente",
+	"Customer link listing failed":                   "No se pudieron listar los enlaces del cliente",
+	"Customer export failed":                         "No se pudo exportar el cliente",
+	"Exported customer %s with %d payment links":     "Cliente %s exportado con %d enlaces de pago",
+	"Customer erasure failed":                        "No se pudieron borrar los datos del cliente",
+	"Erased customer %s":                             "Datos del cliente %s borrados",
+	"Link template created":                          "Plantilla de enlace creada",
+	"Link template creation failed":                  "No se pudo crear la plantilla de enlace",
+	"Link template updated":                          "Plantilla de enlace actualizada",
+	"Link template update failed":                    "No se pudo actualizar la plantilla de enlace",
+	"Link template deleted":                          "Plantilla de enlace eliminada",
+	"Link template listing failed":                   "No se pudieron listar las plantillas de enlace",
+	"Product saved":                                  "Producto guardado",
+	"Product deleted":                                "Producto eliminado",
+	"Product listing failed":                         "No se pudieron listar los productos",
+	"Product update failed":                          "No se pudo actualizar el producto",
+	"GraphQL request failed":                         "La solicitud GraphQL ha fallado",
+	"Authentication required":                        "Se requiere autenticación",
+	"Forbidden":                                      "Prohibido",
+	"Access denied":                                  "Acceso denegado",
+	"Request rejected":                               "Solicitud rechazada",
+	"Rate limit exceeded":                            "Límite de solicitudes superado",
+	"Request timed out":                              "La solicitud ha excedido el tiempo de espera",
+	"Internal server error":                          "Error interno del servidor",
+	"Service not ready":                              "El servicio no está listo",
+	"Event stream unavailable":                       "El flujo de eventos no está disponible",
+
+	// Error details
+	"A valid API key must be sent in the X-API-Key or Authorization: Bearer header": "Debe enviarse una clave de API válida en la cabecera X-API-Key o Authorization: Bearer",
+	"The API key has not been granted the %s permission":                            "La clave de API no tiene el permiso %s",
+	"Too many requests from this address":                                           "Demasiadas solicitudes desde esta dirección",
+	"Too many requests for API key %s":                                              "Demasiadas solicitudes para la clave de API %s",
+	"Requests from this network are not allowed":                                    "No se permiten solicitudes desde esta red",
+	"Form posts must include the CSRF token from /config in the X-CSRF-Token header or the csrfToken field; send JSON or an API key instead": "Los envíos de formularios deben incluir el token CSRF de /config en la cabecera X-CSRF-Token o en el campo csrfToken; también puede enviar JSON o una clave de API",
+	"Complete the CAPTCHA and send its token in the X-Captcha-Token header":                                                                  "Complete el CAPTCHA y envíe su token en la cabecera X-Captcha-Token",
+	"The CAPTCHA was not solved or has expired; please try again":                                                                            "El CAPTCHA no se resolvió o ha caducado; inténtelo de nuevo",
+	"The CAPTCHA could not be verified; please try again later":                                                                              "No se pudo verificar el CAPTCHA; inténtelo de nuevo más tarde",
+	"An unexpected error occurred. Quote the request ID when contacting support.":                                                            "Se ha producido un error inesperado. Indique el ID de la solicitud al contactar con soporte.",
+	"Idempotency-Key must be 1 to 255 printable ASCII characters":                                                                            "Idempotency-Key debe tener de 1 a 255 caracteres ASCII imprimibles",
+	"Error reading request body":                                                           "Error al leer el cuerpo de la solicitud",
+	"Idempotency-Key could not be checked; retry the request":                              "No se pudo comprobar Idempotency-Key; vuelva a intentar la solicitud",
+	"A request with this Idempotency-Key is still in progress":                             "Todavía hay una solicitud en curso con esta Idempotency-Key",
+	"The response recorded for this Idempotency-Key could not be read":                     "No se pudo leer la respuesta registrada para esta Idempotency-Key",
+	"Idempotency-Key was already used for a different request":                             "Idempotency-Key ya se usó para otra solicitud",
+	"The request did not complete within %s":                                               "La solicitud no se completó en %s",
+	"Request body must not exceed %d bytes":                                                "El cuerpo de la solicitud no debe superar los %d bytes",
+	"The server is shutting down":                                                          "El servidor se está deteniendo",
+	"One or more readiness checks failed":                                                  "Una o más comprobaciones de disponibilidad han fallado",
+	"Error parsing JSON request body":                                                      "Error al analizar el cuerpo JSON de la solicitud",
+	"Error parsing form data":                                                              "Error al analizar los datos del formulario",
+	"%d field(s) failed validation":                                                        "%d campo(s) no superaron la validación",
+	"Currency %s is not supported; use one of %s":                                          "La moneda %s no está admitida; use una de %s",
+	"Payment link not found":                                                               "Enlace de pago no encontrado",
+	"Invalid payment link ID":                                                              "ID de enlace de pago no válido",
+	"Customer not found":                                                                   "Cliente no encontrado",
+	"Customer %s not found":                                                                "Cliente %s no encontrado",
+	"Invalid customer ID":                                                                  "ID de cliente no válido",
+	"Product not found":                                                                    "Producto no encontrado",
+	"Link template not found":                                                              "Plantilla de enlace no encontrada",
+	"Link template %s not found":                                                           "Plantilla de enlace %s no encontrada",
+	"Invalid link template ID":                                                             "ID de plantilla de enlace no válido",
+	"Invalid transaction ID":                                                               "ID de transacción no válido",
+	"Recurring link series not found":                                                      "Serie de enlaces periódicos no encontrada",
+	"Invalid recurring link series ID":                                                     "ID de serie de enlaces periódicos no válido",
+	"Unknown promo code":                                                                   "Código promocional desconocido",
+	"Promo code %s has expired":                                                            "El código promocional %s ha caducado",
+	"Promo code %s has reached its maximum uses":                                           "El código promocional %s ha alcanzado su número máximo de usos",
+	"Promo code %s only applies to %s amounts":                                             "El código promocional %s solo se aplica a importes en %s",
+	"Promo code %s would reduce the amount %s to nothing":                                  "El código promocional %s reduciría el importe %s a cero",
+	"Promo codes cannot be applied to open-amount links":                                   "Los códigos promocionales no se pueden aplicar a enlaces de importe libre",
+	"Tax cannot be added to open-amount links":                                             "No se pueden añadir impuestos a enlaces de importe libre",
+	"taxRegion was provided but no tax rates are configured":                               "Se indicó taxRegion, pero no hay tipos impositivos configurados",
+	"customerPhone was provided but SMS delivery is not configured":                        "Se indicó customerPhone, pero el envío de SMS no está configurado",
+	"SMS delivery is not configured on this server":                                        "El envío de SMS no está configurado en este servidor",
+	"No customerPhone provided or stored for this link":                                    "No se indicó ni se guardó ningún customerPhone para este enlace",
+	"Payments from billing country %s are not accepted":                                    "No se aceptan pagos desde el país de facturación %s",
+	"Payments from this email address are not accepted":                                    "No se aceptan pagos desde esta dirección de correo electrónico",
+	"Only active links can be edited; link status is %s":                                   "Solo se pueden editar los enlaces activos; el estado del enlace es %s",
+	"Amount cannot be changed after the link has been used":                                "El importe no se puede cambiar después de que se haya usado el enlace",
+	"Amount cannot be changed on links priced from line items or with a promo code or tax": "El importe no se puede cambiar en los enlaces con precio de artículos del catálogo, código promocional o impuestos",
+	"Open-amount links have no fixed amount to change":                                     "Los enlaces de importe libre no tienen un importe fijo que cambiar",
+	"Provide at least one of amount, name, description, expirationDays, or expirationDate": "Indique al menos uno de amount, name, description, expirationDays o expirationDate",
+	"Payment link is %s; receipts are only available once it is paid":                      "El enlace de pago está %s; los recibos solo están disponibles una vez pagado",
+	"A batch may contain at most %d links, got %d":                                         "Un lote puede contener como máximo %d enlaces; se recibieron %d",
+	"No payment link requests provided":                                                    "No se recibió ninguna solicitud de enlace de pago",
+	"Payment link is %s; wallet passes are only available while it can be paid":            "El enlace de pago está %s; los pases de Wallet solo están disponibles mientras se pueda pagar",
+	"Wallet passes are not configured on this server":                                      "Los pases de Wallet no están configurados en este servidor",
+	"%s Wallet passes are not configured on this server":                                   "Los pases de %s Wallet no están configurados en este servidor",
+	"wallet must be apple or google":                                                       "wallet debe ser apple o google",
+	"Error signing the wallet pass":                                                        "Error al firmar el pase de Wallet",
+	"limit must be between 1 and %d":                                                       "limit debe estar entre 1 y %d",
+
+	// Field messages
+	"%s is required":                                          "%s es obligatorio",
+	"amount is required":                                      "amount es obligatorio",
+	"%s must be at most %d characters":                        "%s debe tener como máximo %d caracteres",
+	"%s must not contain control characters":                  "%s no debe contener caracteres de control",
+	"%s may only contain letters from the %s scripts":         "%s solo puede contener letras de los alfabetos %s",
+	"%s must be a %s":                                         "%s debe ser de tipo %s",
+	"%s is not a recognized field":                            "%s no es un campo reconocido",
+	"%s must not be empty":                                    "%s no debe estar vacío",
+	"currency is required when amount is preset":              "currency es obligatorio cuando se fija amount",
+	"currency must be an ISO 4217 currency code, such as EUR": "currency debe ser un código de moneda ISO 4217, como EUR",
+	"reference may only contain letters, digits, spaces, underscores, hyphens, and #":       "reference solo puede contener letras, dígitos, espacios, guiones bajos, guiones y #",
+	"reference must be at most %d characters for %d occurrences":                            "reference debe tener como máximo %d caracteres para %d cuotas",
+	"description must be at most %d characters including the tax breakdown":                 "description debe tener como máximo %d caracteres, incluido el desglose de impuestos",
+	"email must be a plain address such as name@example.com":                                "email debe ser una dirección simple, como nombre@example.com",
+	"payerEmail must be a plain address such as name@example.com":                           "payerEmail debe ser una dirección simple, como nombre@example.com",
+	"phone must be in E.164 format, e.g. +447700900123":                                     "phone debe estar en formato E.164, p. ej. +447700900123",
+	"billingCountry must be an ISO 3166-1 alpha-2 country code, such as IE":                 "billingCountry debe ser un código de país ISO 3166-1 alfa-2, como IE",
+	"payerIp must be an IPv4 or IPv6 address":                                               "payerIp debe ser una dirección IPv4 o IPv6",
+	"language must be a language tag such as es or fr-CA":                                   "language debe ser una etiqueta de idioma, como es o fr-CA",
+	"%s must be an absolute https URL":                                                      "%s debe ser una URL https absoluta",
+	"images may contain at most %d URLs":                                                    "images puede contener como máximo %d URL",
+	"brandColor must be a hex color such as #1A73E8":                                        "brandColor debe ser un color hexadecimal, como #1A73E8",
+	"amount cannot be combined with minAmount or maxAmount":                                 "amount no se puede combinar con minAmount ni maxAmount",
+	"minAmount must not be greater than maxAmount":                                          "minAmount no debe ser mayor que maxAmount",
+	"usageMode must be SINGLE or MULTIPLE":                                                  "usageMode debe ser SINGLE o MULTIPLE",
+	"usageLimit must be 1 when usageMode is SINGLE":                                         "usageLimit debe ser 1 cuando usageMode es SINGLE",
+	"usageLimit must be a whole number between 1 and %d":                                    "usageLimit debe ser un número entero entre 1 y %d",
+	"expirationDays must be a whole number of at least 1":                                   "expirationDays debe ser un número entero igual o mayor que 1",
+	"expiration must be within %d days":                                                     "la caducidad debe estar dentro de %d días",
+	"expirationDate must be an RFC3339 timestamp, e.g. 2025-01-31T23:59:00Z":                "expirationDate debe ser una marca de tiempo RFC3339, p. ej. 2025-01-31T23:59:00Z",
+	"expirationDate must be in the future":                                                  "expirationDate debe estar en el futuro",
+	"shippable must be true or false":                                                       "shippable debe ser true o false",
+	"captureMode must be AUTO or LATER":                                                     "captureMode debe ser AUTO o LATER",
+	"dynamicDescriptor must contain a letter":                                               "dynamicDescriptor debe contener una letra",
+	"dynamicDescriptor may only contain ASCII letters, digits, spaces, and & * , - . / # '": "dynamicDescriptor solo puede contener letras ASCII, dígitos, espacios y & * , - . / # '",
+	"metadata may have at most %d keys":                                                     "metadata puede tener como máximo %d claves",
+	"metadata keys and values must total at most %d bytes":                                  "las claves y valores de metadata deben sumar como máximo %d bytes",
+	"metadata keys must be 1-40 letters, digits, '.', '_', or '-'":                          "las claves de metadata deben tener de 1 a 40 letras, dígitos, '.', '_' o '-'",
+	"quantity must be between 1 and %d":                                                     "quantity debe estar entre 1 y %d",
+	"occurrences must be a whole number from %d to %d":                                      "occurrences debe ser un número entero de %d a %d",
+	"currency must be an ISO 4217 currency code":                                            "currency debe ser un código de moneda ISO 4217",
+	"Request body must be a JSON array of payment link requests":                            "El cuerpo de la solicitud debe ser un array JSON de solicitudes de enlaces de pago",
+	"at most %d items are allowed":                                                          "se permiten como máximo %d artículos",
+}
@@ -0,0 +1,171 @@
+package locale
+
+// french translates API messages into French
+var french = map[string]string{
+	// Response messages
+	"Payment link creation failed":                   "La création du lien de paiement a échoué",
+	"Payment link created successfully! Link ID: %s": "Lien de paiement créé avec succès ! ID du lien : %s",
+	"Batch link creation failed":                     "La création du lot de liens a échoué",
+	"Created %d of %d payment links":                 "%d liens de paiement créés sur %d",
+	"Recurring link creation failed":                 "La création des liens récurrents a échoué",
+	"Recurring links scheduled! Series ID: %s":       "Liens récurrents programmés ! ID de la série : %s",
+	"Recurring link lookup failed":                   "La consultation des liens récurrents a échoué",
+	"Payment link lookup failed":                     "La consultation du lien de paiement a échoué",
+	"Payment link listing failed":                    "La liste des liens de paiement n'a pas pu être obtenue",
+	"Payment link export failed":                     "L'export des liens de paiement a échoué",
+	"Payment link update failed":                     "La mise à jour du lien de paiement a échoué",
+	"Payment link %s updated":                        "Lien de paiement %s mis à jour",
+	"Payment link cancellation failed":               "L'annulation du lien de paiement a échoué",
+	"Payment link %s cancelled":                      "Lien de paiement %s annulé",
+	"Payment link sent by SMS":                       "Lien de paiement envoyé par SMS",
+	"SMS delivery failed":                            "L'envoi du SMS a échoué",
+	"Reminder update failed":                         "La mise à jour des rappels a échoué",
+	"Delivery lookup failed":                         "La consultation des envois a échoué",
+	"Link view lookup failed":                        "La consultation des visites du lien a échoué",
+	"Found %d views":                                 "%d visites trouvées",
+	"Wallet pass creation failed":                    "La création du passe Wallet a échoué",
+	"Google Wallet pass created":                     "Passe Google Wallet créé",
+	"Receipt lookup failed":                          "La consultation du reçu a échoué",
+	"Transaction listing failed":                     "La liste des transactions n'a pas pu être obtenue",
+	"Transaction capture failed":                     "La capture de la transaction a échoué",
+	"Transaction %s captured":                        "Transaction %s capturée",
+	"Refund failed":                                  "Le remboursement a échoué",
+	"Refunded %s %s of transaction %s":               "%s %s remboursés sur la transaction %s",
+	"Customer created":                               "Client créé",
+	"Customer creation failed":                       "La création du client a échoué",
+	"Customer link listing failed":                   "La liste des liens du client n'a pas pu être obtenue",
+	"Customer export failed":                         "L'export du client a échoué",
+	"Exported customer %s with %d payment links":     "Client %s exporté avec %d liens de paiement",
+	"Customer erasure failed":                        "L'effacement des données du client a échoué",
+	"Erased customer %s":                             "Données du client %s effacées",
+	"Link template created":                          "Modèle de lien créé",
+	"Link template creation failed":                  "La création du modèle de lien a échoué",
+	"Link template updated":                          "Modèle de lien mis à jour",
+	"Link template update failed":                    "La mise à jour du modèle de lien a échoué",
+	"Link template deleted":                          "Modèle de lien supprimé",
+	"Link template listing failed":                   "La liste des modèles de lien n'a pas pu être obtenue",
+	"Product saved":                                  "Produit enregistré",
+	"Product deleted":                                "Produit supprimé",
+	"Product listing failed":                         "La liste des produits n'a pas pu être obtenue",
+	"Product update failed":                          "La mise à jour du produit a échoué",
+	"GraphQL request failed":                         "La requête GraphQL a échoué",
+	"Authentication required":                        "Authentification requise",
+	"Forbidden":                                      "Interdit",
+	"Access denied":                                  "Accès refusé",
+	"Request rejected":                               "Requête refusée",
+	"Rate limit exceeded":                            "Limite de requêtes dépassée",
+	"Request timed out":                              "Le délai de la requête a expiré",
+	"Internal server error":                          "Erreur interne du serveur",
+	"Service not ready":                              "Le service n'est pas prêt",
+	"Event stream unavailable":                       "Le flux d'événements est indisponible",
+
+	// Error details
+	"A valid API key must be sent in the X-API-Key or Authorization: Bearer header": "Une clé d'API valide doit être envoyée dans l'en-tête X-API-Key ou Authorization: Bearer",
+	"The API key has not been granted the %s permission":                            "La clé d'API n'a pas reçu l'autorisation %s",
+	"Too many requests from this address":                                           "Trop de requêtes depuis cette adresse",
+	"Too many requests for API key %s":                                              "Trop de requêtes pour la clé d'API %s",
+	"Requests from this network are not allowed":                                    "Les requêtes depuis ce réseau ne sont pas autorisées",
+	"Form posts must include the CSRF token from /config in the X-CSRF-Token header or the csrfToken field; send JSON or an API key instead": "Les envois de formulaire doivent inclure le jeton CSRF de /config dans l'en-tête X-CSRF-Token ou le champ csrfToken ; envoyez sinon du JSON ou une clé d'API",
+	"Complete the CAPTCHA and send its token in the X-Captcha-Token header":                                                                  "Complétez le CAPTCHA et envoyez son jeton dans l'en-tête X-Captcha-Token",
+	"The CAPTCHA was not solved or has expired; please try again":                                                                            "Le CAPTCHA n'a pas été résolu ou a expiré ; veuillez réessayer",
+	"The CAPTCHA could not be verified; please try again later":                                                                              "Le CAPTCHA n'a pas pu être vérifié ; veuillez réessayer plus tard",
+	"An unexpected error occurred. Quote the request ID when contacting support.":                                                            "Une erreur inattendue s'est produite. Indiquez l'ID de la requête en contactant le support.",
+	"Idempotency-Key must be 1 to 255 printable ASCII characters":                                                                            "Idempotency-Key doit comporter de 1 à 255 caractères ASCII imprimables",
+	"Error reading request body":                                                           "Erreur lors de la lecture du corps de la requête",
+	"Idempotency-Key could not be checked; retry the request":                              "Idempotency-Key n'a pas pu être vérifiée ; renvoyez la requête",
+	"A request with this Idempotency-Key is still in progress":                             "Une requête avec cette Idempotency-Key est encore en cours",
+	"The response recorded for this Idempotency-Key could not be read":                     "La réponse enregistrée pour cette Idempotency-Key n'a pas pu être lue",
+	"Idempotency-Key was already used for a different request":                             "Idempotency-Key a déjà été utilisée pour une autre requête",
+	"The request did not complete within %s":                                               "La requête ne s'est pas terminée en %s",
+	"Request body must not exceed %d bytes":                                                "Le corps de la requête ne doit pas dépasser %d octets",
+	"The server is shutting down":                                                          "Le serveur est en cours d'arrêt",
+	"One or more readiness checks failed":                                                  "Une ou plusieurs vérifications de disponibilité ont échoué",
+	"Error parsing JSON request body":                                                      "Erreur lors de l'analyse du corps JSON de la requête",
+	"Error parsing form data":                                                              "Erreur lors de l'analyse des données du formulaire",
+	"%d field(s) failed validation":                                                        "%d champ(s) n'ont pas passé la validation",
+	"Currency %s is not supported; use one of %s":                                          "La devise %s n'est pas prise en charge ; utilisez l'une de %s",
+	"Payment link not found":                                                               "Lien de paiement introuvable",
+	"Invalid payment link ID":                                                              "ID de lien de paiement non valide",
+	"Customer not found":                                                                   "Client introuvable",
+	"Customer %s not found":                                                                "Client %s introuvable",
+	"Invalid customer ID":                                                                  "ID de client non valide",
+	"Product not found":                                                                    "Produit introuvable",
+	"Link template not found":                                                              "Modèle de lien introuvable",
+	"Link template %s not found":                                                           "Modèle de lien %s introuvable",
+	"Invalid link template ID":                                                             "ID de modèle de lien non valide",
+	"Invalid transaction ID":                                                               "ID de transaction non valide",
+	"Recurring link series not found":                                                      "Série de liens récurrents introuvable",
+	"Invalid recurring link series ID":                                                     "ID de série de liens récurrents non valide",
+	"Unknown promo code":                                                                   "Code promo inconnu",
+	"Promo code %s has expired":                                                            "Le code promo %s a expiré",
+	"Promo code %s has reached its maximum uses":                                           "Le code promo %s a atteint son nombre maximal d'utilisations",
+	"Promo code %s only applies to %s amounts":                                             "Le code promo %s ne s'applique qu'aux montants en %s",
+	"Promo code %s would reduce the amount %s to nothing":                                  "Le code promo %s réduirait le montant %s à zéro",
+	"Promo codes cannot be applied to open-amount links":                                   "Les codes promo ne peuvent pas s'appliquer aux liens à montant libre",
+	"Tax cannot be added to open-amount links":                                             "Les taxes ne peuvent pas être ajoutées aux liens à montant libre",
+	"taxRegion was provided but no tax rates are configured":                               "taxRegion a été fourni mais aucun taux de taxe n'est configuré",
+	"customerPhone was provided but SMS delivery is not configured":                        "customerPhone a été fourni mais l'envoi de SMS n'est pas configuré",
+	"SMS delivery is not configured on this server":                                        "L'envoi de SMS n'est pas configuré sur ce serveur",
+	"No customerPhone provided or stored for this link":                                    "Aucun customerPhone n'a été fourni ou enregistré pour ce lien",
+	"Payments from billing country %s are not accepted":                                    "Les paiements depuis le pays de facturation %s ne sont pas acceptés",
+	"Payments from this email address are not accepted":                                    "Les paiements depuis cette adresse e-mail ne sont pas acceptés",
+	"Only active links can be edited; link status is %s":                                   "Seuls les liens actifs peuvent être modifiés ; le statut du lien est %s",
+	"Amount cannot be changed after the link has been used":                                "Le montant ne peut plus être modifié une fois le lien utilisé",
+	"Amount cannot be changed on links priced from line items or with a promo code or tax": "Le montant ne peut pas être modifié sur les liens tarifés à partir d'articles, avec un code promo ou une taxe",
+	"Open-amount links have no fixed amount to change":                                     "Les liens à montant libre n'ont pas de montant fixe à modifier",
+	"Provide at least one of amount, name, description, expirationDays, or expirationDate": "Indiquez au moins l'un des champs amount, name, description, expirationDays ou expirationDate",
+	"Payment link is %s; receipts are only available once it is paid":                      "Le lien de paiement est %s ; les reçus ne sont disponibles qu'une fois qu'il est payé",
+	"A batch may contain at most %d links, got %d":                                         "Un lot peut contenir au plus %d liens ; %d reçus",
+	"No payment link requests provided":                                                    "Aucune demande de lien de paiement n'a été fournie",
+	"Payment link is %s; wallet passes are only available while it can be paid":            "Le lien de paiement est %s ; les passes Wallet ne sont disponibles que tant qu'il peut être payé",
+	"Wallet passes are not configured on this server":                                      "Les passes Wallet ne sont pas configurés sur ce serveur",
+	"%s Wallet passes are not configured on this server":                                   "Les passes %s Wallet ne sont pas configurés sur ce serveur",
+	"wallet must be apple or google":                                                       "wallet doit être apple ou google",
+	"Error signing the wallet pass":                                                        "Erreur lors de la signature du passe Wallet",
+	"limit must be between 1 and %d":                                                       "limit doit être compris entre 1 et %d",
+
+	// Field messages
+	"%s is required":                                          "%s est obligatoire",
+	"amount is required":                                      "amount est obligatoire",
+	"%s must be at most %d characters":                        "%s doit comporter au plus %d caractères",
+	"%s must not contain control characters":                  "%s ne doit pas contenir de caractères de contrôle",
+	"%s may only contain letters from the %s scripts":         "%s ne peut contenir que des lettres des écritures %s",
+	"%s must be a %s":                                         "%s doit être de type %s",
+	"%s is not a recognized field":                            "%s n'est pas un champ reconnu",
+	"%s must not be empty":                                    "%s ne doit pas être vide",
+	"currency is required when amount is preset":              "currency est obligatoire lorsque amount est prédéfini",
+	"currency must be an ISO 4217 currency code, such as EUR": "currency doit être un code de devise ISO 4217, comme EUR",
+	"reference may only contain letters, digits, spaces, underscores, hyphens, and #":       "reference ne peut contenir que des lettres, chiffres, espaces, tirets bas, tirets et #",
+	"reference must be at most %d characters for %d occurrences":                            "reference doit comporter au plus %d caractères pour %d échéances",
+	"description must be at most %d characters including the tax breakdown":                 "description doit comporter au plus %d caractères, détail des taxes compris",
+	"email must be a plain address such as name@example.com":                                "email doit être une adresse simple, comme nom@example.com",
+	"payerEmail must be a plain address such as name@example.com":                           "payerEmail doit être une adresse simple, comme nom@example.com",
+	"phone must be in E.164 format, e.g. +447700900123":                                     "phone doit être au format E.164, par ex. +447700900123",
+	"billingCountry must be an ISO 3166-1 alpha-2 country code, such as IE":                 "billingCountry doit être un code pays ISO 3166-1 alpha-2, comme IE",
+	"payerIp must be an IPv4 or IPv6 address":                                               "payerIp doit être une adresse IPv4 ou IPv6",
+	"language must be a language tag such as es or fr-CA":                                   "language doit être une balise de langue, comme es ou fr-CA",
+	"%s must be an absolute https URL":                                                      "%s doit être une URL https absolue",
+	"images may contain at most %d URLs":                                                    "images peut contenir au plus %d URL",
+	"brandColor must be a hex color such as #1A73E8":                                        "brandColor doit être une couleur hexadécimale, comme #1A73E8",
+	"amount cannot be combined with minAmount or maxAmount":                                 "amount ne peut pas être combiné avec minAmount ou maxAmount",
+	"minAmount must not be greater than maxAmount":                                          "minAmount ne doit pas être supérieur à maxAmount",
+	"usageMode must be SINGLE or MULTIPLE":                                                  "usageMode doit être SINGLE ou MULTIPLE",
+	"usageLimit must be 1 when usageMode is SINGLE":                                         "usageLimit doit valoir 1 lorsque usageMode est SINGLE",
+	"usageLimit must be a whole number between 1 and %d":                                    "usageLimit doit être un nombre entier entre 1 et %d",
+	"expirationDays must be a whole number of at least 1":                                   "expirationDays doit être un nombre entier d'au moins 1",
+	"expiration must be within %d days":                                                     "l'expiration doit intervenir dans les %d jours",
+	"expirationDate must be an RFC3339 timestamp, e.g. 2025-01-31T23:59:00Z":                "expirationDate doit être un horodatage RFC3339, par ex. 2025-01-31T23:59:00Z",
+	"expirationDate must be in the future":                                                  "expirationDate doit être dans le futur",
+	"shippable must be true or false":                                                       "shippable doit valoir true ou false",
+	"captureMode must be AUTO or LATER":                                                     "captureMode doit valoir AUTO ou LATER",
+	"dynamicDescriptor must contain a letter":                                               "dynamicDescriptor doit contenir une lettre",
+	"dynamicDescriptor may only contain ASCII letters, digits, spaces, and & * , - . / # '": "dynamicDescriptor ne peut contenir que des lettres ASCII, chiffres, espaces et & * , - . / # '",
+	"metadata may have at most %d keys":                                                     "metadata peut comporter au plus %d clés",
+	"metadata keys and values must total at most %d bytes":                                  "les clés et valeurs de metadata ne doivent pas dépasser %d octets au total",
+	"metadata keys must be 1-40 letters, digits, '.', '_', or '-'":                          "les clés de metadata doivent comporter de 1 à 40 lettres, chiffres, '.', '_' ou '-'",
+	"quantity must be between 1 and %d":                                                     "quantity doit être compris entre 1 et %d",
+	"occurrences must be a whole number from %d to %d":                                      "occurrences doit être un nombre entier de %d à %d",
+	"currency must be an ISO 4217 currency code":                                            "currency doit être un code de devise ISO 4217",
+	"Request body must be a JSON array of payment link requests":                            "Le corps de la requête doit être un tableau JSON de demandes de liens de paiement",
+	"at most %d items are allowed":                                                          "%d articles au plus sont autorisés",
+}
@@ -0,0 +1,113 @@
+// Package locale picks the language of API messages from a request's Accept-Language
+// header and translates the messages, which are written in English, into it. Messages
+// are translated from their catalog key and the values formatted into it, never by
+// parsing the English text.
+package locale
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+// Default is the language messages are written in, used when a request accepts none of
+// the others
+const Default = "en"
+
+// Supported lists the languages messages can be translated into, Default first
+var Supported = []string{Default, "es", "fr", "de"}
+
+// catalogs holds the translations of each language other than Default, keyed by the
+// English message. Keys may contain %s and %d verbs, which a Message carries the values
+// of; the values are formatted, in order, into the verbs of the translation.
+var catalogs = map[string]map[string]string{
+	"es": spanish,
+	"fr": french,
+	"de": german,
+}
+
+var matcher = language.NewMatcher(func() []language.Tag {
+	tags := make([]language.Tag, len(Supported))
+	for i, name := range Supported {
+		tags[i] = language.MustParse(name)
+	}
+	return tags
+}())
+
+// Negotiate returns the supported language that best matches an Accept-Language header,
+// or Default when it matches none
+func Negotiate(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return Default
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	return Supported[index]
+}
+
+// Message is an API message: its catalog key, the English text with %s and %d verbs where
+// values go, such as "Payment link %s cancelled", and the values of the verbs
+type Message struct {
+	Key  string
+	Args []interface{}
+}
+
+// Messagef returns the message with the given catalog key and verb values
+func Messagef(key string, args ...interface{}) Message {
+	return Message{Key: key, Args: args}
+}
+
+// String returns the message in English
+func (m Message) String() string {
+	return format(m.Key, m.Args)
+}
+
+// Translate returns m in lang, or in English when lang is Default or has no translation
+// of m's key
+func Translate(lang string, m Message) string {
+	if translation, ok := catalogs[lang][m.Key]; ok {
+		return format(translation, m.Args)
+	}
+	return m.String()
+}
+
+// format fills the verbs of a catalog key or translation with args. Text without args is
+// returned as it is, so a message that was not formatted may contain %.
+func format(text string, args []interface{}) string {
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// Error is an error whose text is a catalog message, so that it can be reported in the
+// language of the request
+type Error struct {
+	Message
+}
+
+// Error implements the error interface, returning the message in English
+func (e *Error) Error() string {
+	return e.String()
+}
+
+// Errorf returns an error with the given catalog key and verb values
+func Errorf(key string, args ...interface{}) error {
+	return &Error{Messagef(key, args...)}
+}
+
+// MessageOf returns the message of err when it is an *Error, and otherwise err's text,
+// which translates when it is a catalog key without verbs. Wrapped errors are not
+// unwrapped, as their text is more than the message.
+func MessageOf(err error) Message {
+	if e, ok := err.(*Error); ok {
+		return e.Message
+	}
+	return Messagef(err.Error())
+}
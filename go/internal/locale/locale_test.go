@@ -0,0 +1,86 @@
+package locale
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name    string
+		lang    string
+		message Message
+		want    string
+	}{
+		{name: "values formatted into the translation", lang: "es", message: Messagef("Payment link %s cancelled", "PAY_1"),
+			want: "Enlace de pago PAY_1 cancelado"},
+		{name: "number verb", lang: "de", message: Messagef("limit must be between 1 and %d", 100),
+			want: "limit muss zwischen 1 und 100 liegen"},
+		{name: "key without values", lang: "fr", message: Messagef("Payment link not found"),
+			want: "Lien de paiement introuvable"},
+		{name: "value that reads like another message", lang: "fr", message: Messagef("Customer %s not found", "Payment link"),
+			want: "Client Payment link introuvable"},
+		{name: "value with a percent sign", lang: "de", message: Messagef("Customer %s not found", "100%"),
+			want: "Kunde 100% nicht gefunden"},
+		{name: "default language", lang: Default, message: Messagef("%s is required", "name"),
+			want: "name is required"},
+		{name: "no language", lang: "", message: Messagef("%s is required", "name"),
+			want: "name is required"},
+		{name: "unsupported language", lang: "it", message: Messagef("%s is required", "name"),
+			want: "name is required"},
+		{name: "key without a translation", lang: "es", message: Messagef("Found %d widgets", 3),
+			want: "Found 3 widgets"},
+		{name: "English text is not matched against keys", lang: "es", message: Messagef("name is required"),
+			want: "name is required"},
+		{name: "text without values is not formatted", lang: "es", message: Messagef("100% off"),
+			want: "100% off"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Translate(tt.lang, tt.message); got != tt.want {
+				t.Errorf("Translate(%q, %v) = %q, want %q", tt.lang, tt.message, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "catalog error", err: Errorf("limit must be between 1 and %d", 50), want: "limit debe estar entre 1 y 50"},
+		{name: "plain error with a catalog key", err: errors.New("Payment link not found"), want: "Enlace de pago no encontrado"},
+		{name: "wrapped catalog error", err: fmt.Errorf("listing: %w", Errorf("limit must be between 1 and %d", 50)),
+			want: "listing: limit must be between 1 and 50"},
+		{name: "plain error", err: errors.New("connection refused"), want: "connection refused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Translate("es", MessageOf(tt.err)); got != tt.want {
+				t.Errorf("Translate(es, MessageOf(%v)) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: Default},
+		{accept: "es-MX,es;q=0.9", want: "es"},
+		{accept: "fr-CA", want: "fr"},
+		{accept: "it, de;q=0.5", want: "de"},
+		{accept: "ja", want: Default},
+		{accept: "not a header;;", want: Default},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.accept); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
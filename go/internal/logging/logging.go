@@ -0,0 +1,130 @@
+// Package logging provides the structured JSON logger used by the server,
+// with PII redaction and request-scoped loggers.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Redaction modes for PII values in log output
+const (
+	RedactionMask = "mask" // replace the value with a fixed placeholder
+	RedactionHash = "hash" // replace the value with a short hash so entries can be correlated
+	RedactionNone = "none" // log values as-is (local development only)
+)
+
+// DefaultRedactedFields lists the log attribute keys treated as PII unless LOG_REDACT_FIELDS is set
+var DefaultRedactedFields = []string{"name", "description", "reference", "email", "phone"}
+
+// RedactionPolicy controls how PII attributes are written to logs
+type RedactionPolicy struct {
+	Mode   string
+	Fields map[string]bool
+}
+
+// NewRedactionPolicy creates a RedactionPolicy for mode that redacts the given attribute keys
+func NewRedactionPolicy(mode string, fields []string) (RedactionPolicy, error) {
+	policy := RedactionPolicy{
+		Mode:   strings.ToLower(mode),
+		Fields: make(map[string]bool),
+	}
+	switch policy.Mode {
+	case RedactionMask, RedactionHash, RedactionNone:
+	default:
+		return RedactionPolicy{}, fmt.Errorf("invalid LOG_REDACTION %q: must be mask, hash, or none", policy.Mode)
+	}
+
+	for _, field := range fields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			policy.Fields[field] = true
+		}
+	}
+	return policy, nil
+}
+
+// replaceAttr redacts attributes whose key is listed in the policy
+func (p RedactionPolicy) replaceAttr(groups []string, attr slog.Attr) slog.Attr {
+	if p.Mode == RedactionNone || !p.Fields[strings.ToLower(attr.Key)] {
+		return attr
+	}
+	value := attr.Value.String()
+	if value == "" {
+		return attr
+	}
+	if p.Mode == RedactionHash {
+		hash := sha256.Sum256([]byte(value))
+		return slog.String(attr.Key, "sha256:"+hex.EncodeToString(hash[:6]))
+	}
+	return slog.String(attr.Key, "[REDACTED]")
+}
+
+// New creates a JSON logger that applies the redaction policy
+func New(w io.Writer, level slog.Level, policy RedactionPolicy) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: policy.replaceAttr,
+	}))
+}
+
+// ParseLevel converts LOG_LEVEL values (debug, info, warn, error) to a slog.Level
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if value == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return slog.LevelInfo, fmt.Errorf("invalid LOG_LEVEL %q", value)
+	}
+	return level, nil
+}
+
+// MaskSecret shows only the first few characters of an identifier
+func MaskSecret(value string) string {
+	if len(value) <= 4 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + strings.Repeat("*", len(value)-4)
+}
+
+// requestIDKey is the context key under which the request ID is stored
+type requestIDKey struct{}
+
+// NewRequestID generates a random identifier for an inbound request
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns the request ID stored in ctx, if any
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// FromContext returns the default logger annotated with the request ID in ctx and,
+// when the request is traced, its trace ID so log entries can be matched to spans
+func FromContext(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	if id := RequestIDFrom(ctx); id != "" {
+		logger = logger.With("request_id", id)
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		logger = logger.With("trace_id", span.TraceID().String())
+	}
+	return logger
+}
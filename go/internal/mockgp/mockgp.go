@@ -0,0 +1,753 @@
+// Package mockgp is an in-process fake of the GP API payment link endpoints for
+// local development and CI. It issues access tokens, stores links in memory,
+// serves a simple hosted payment page, and can be told to fail in specific ways.
+package mockgp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+)
+
+// Failure scenarios that can be enabled with Options.Failures
+const (
+	FailToken    = "token"     // token requests are rejected with 401
+	FailCreate   = "create"    // link creation is rejected with 400
+	FailNotFound = "not_found" // link lookups and updates return 404
+	FailServer   = "server"    // every link endpoint returns 502
+)
+
+// Scenarios lists the supported failure scenarios
+var Scenarios = []string{FailToken, FailCreate, FailNotFound, FailServer}
+
+// FailReference is a link reference that always makes creation fail, so
+// individual requests (for example rows in a batch) can exercise error handling
+const FailReference = "MOCK-FAIL"
+
+// tokenLifetime is the seconds_to_expire reported for issued tokens
+const tokenLifetime = 3600
+
+// accountID identifies the single transaction processing account of the fake merchant
+const accountID = "TRA_mock"
+
+// Options configures the fake
+type Options struct {
+	// AppKey signs status notifications, as GP does with the merchant's app key
+	AppKey string
+	// Failures enables failure scenarios by name
+	Failures []string
+	// Latency is added to every API response
+	Latency time.Duration
+	// NotifyURL, when set, receives status notifications instead of each link's status_url
+	NotifyURL string
+}
+
+// Server is the fake GP API. It implements http.Handler.
+type Server struct {
+	opts     Options
+	failures map[string]bool
+	mux      *http.ServeMux
+	client   *http.Client
+
+	mu      sync.Mutex
+	baseURL string
+	appKey  string
+	tokens  map[string]bool
+	links   map[string]*link
+	nextID  int
+	// refunded is the amount refunded so far of each transaction, in minor units
+	refunded map[string]int64
+	// refunds are the refund transactions issued, which belong to no link
+	refunds []gpapi.Transaction
+}
+
+// link is a payment link held by the fake
+type link struct {
+	ID            string
+	Status        string
+	CreatedAt     time.Time
+	Data          gpapi.LinkData
+	UsageCount    int
+	ViewedCount   int
+	Transactions  []gpapi.Transaction
+	notifications gpapi.LinkNotifications
+}
+
+// New creates a fake GP API server
+func New(opts Options) *Server {
+	s := &Server{
+		opts:     opts,
+		appKey:   opts.AppKey,
+		failures: make(map[string]bool),
+		client:   &http.Client{Timeout: 10 * time.Second},
+		tokens:   make(map[string]bool),
+		links:    make(map[string]*link),
+		refunded: make(map[string]int64),
+	}
+	for _, failure := range opts.Failures {
+		s.failures[failure] = true
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("POST /ucp/accesstoken", s.handleAccessToken)
+	s.mux.HandleFunc("POST /ucp/links", s.api(s.handleCreateLink))
+	s.mux.HandleFunc("GET /ucp/links", s.api(s.handleSearchLinks))
+	s.mux.HandleFunc("GET /ucp/links/{id}", s.api(s.handleGetLink))
+	s.mux.HandleFunc("PATCH /ucp/links/{id}", s.api(s.handleUpdateLink))
+	s.mux.HandleFunc("GET /ucp/accounts/{id}", s.api(s.handleGetAccount))
+	s.mux.HandleFunc("GET /ucp/transactions", s.api(s.handleSearchTransactions))
+	s.mux.HandleFunc("GET /ucp/transactions/{id}", s.api(s.handleGetTransaction))
+	s.mux.HandleFunc("POST /ucp/transactions/{id}/capture", s.api(s.handleCaptureTransaction))
+	s.mux.HandleFunc("POST /ucp/transactions/{id}/refund", s.api(s.handleRefundTransaction))
+	s.mux.HandleFunc("GET /pay/{id}", s.handlePayPage)
+	s.mux.HandleFunc("POST /pay/{id}", s.handlePay)
+	return s
+}
+
+// ServeHTTP implements http.Handler. API responses carry a request ID, as GP's do.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/ucp/") {
+		w.Header().Set(gpapi.RequestIDHeader, randomID("RQ_"))
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// Start listens on addr (use "127.0.0.1:0" for a free port) and serves in the
+// background. It returns the GP API base URL to configure the client with.
+func (s *Server) Start(addr string) (string, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to start mock GP API: %w", err)
+	}
+
+	s.mu.Lock()
+	s.baseURL = "http://" + listener.Addr().String()
+	s.mu.Unlock()
+
+	server := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			slog.Error("Mock GP API stopped", "error", err)
+		}
+	}()
+	return s.baseURL + "/ucp", nil
+}
+
+// SetAppKey rotates the app key, as a merchant does in the GP developer portal. Later token
+// requests must be signed with the new key; tokens already issued remain valid.
+func (s *Server) SetAppKey(appKey string) {
+	s.mu.Lock()
+	s.appKey = appKey
+	s.mu.Unlock()
+}
+
+// currentAppKey returns the app key tokens are issued for and notifications signed with
+func (s *Server) currentAppKey() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.appKey
+}
+
+// writeJSON writes body as JSON with the given status code
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeAPIError writes an error in GP API's format
+func writeAPIError(w http.ResponseWriter, status int, code, detailedCode, description string) {
+	writeJSON(w, status, map[string]string{
+		"error_code":                 code,
+		"detailed_error_code":        detailedCode,
+		"detailed_error_description": description,
+	})
+}
+
+// randomID generates an identifier with GP's prefix convention, e.g. LNK_...
+func randomID(prefix string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
+
+// handleAccessToken issues a token for any app ID whose secret matches SHA512(nonce + app key)
+func (s *Server) handleAccessToken(w http.ResponseWriter, r *http.Request) {
+	time.Sleep(s.opts.Latency)
+
+	if s.failures[FailToken] {
+		writeAPIError(w, http.StatusUnauthorized, "ACTION_NOT_AUTHORIZED", "40004", "Credentials not recognized to create access token.")
+		return
+	}
+
+	var req gpapi.TokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AppID == "" || req.Nonce == "" {
+		writeAPIError(w, http.StatusBadRequest, "MANDATORY_DATA_MISSING", "40005", "Request expects the following fields app_id, nonce, secret")
+		return
+	}
+	hash := sha512.Sum512([]byte(req.Nonce + s.currentAppKey()))
+	if req.Secret != hex.EncodeToString(hash[:]) {
+		writeAPIError(w, http.StatusForbidden, "ACTION_NOT_AUTHORIZED", "40004", "Credentials not recognized to create access token.")
+		return
+	}
+
+	token := randomID("MOCK_")
+	s.mu.Lock()
+	s.tokens[token] = true
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, gpapi.TokenResponse{
+		Token:                            token,
+		Type:                             "Bearer",
+		AppID:                            req.AppID,
+		AppName:                          "Mock Pay by Link",
+		TimeCreated:                      time.Now().UTC().Format(time.RFC3339),
+		SecondsToExpire:                  tokenLifetime,
+		Email:                            "merchant@example.com",
+		MerchantID:                       "MER_mock",
+		MerchantName:                     "Mock Merchant",
+		TransactionProcessingAccountID:   accountID,
+		TransactionProcessingAccountName: "paylink",
+	})
+}
+
+// handleGetAccount returns the capabilities of the fake merchant's account
+func (s *Server) handleGetAccount(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("id") != accountID {
+		writeAPIError(w, http.StatusNotFound, "RESOURCE_NOT_FOUND", "40118", fmt.Sprintf("Accounts %s not found at this location.", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, gpapi.Account{
+		ID:             accountID,
+		Name:           "paylink",
+		Status:         "ACTIVE",
+		Countries:      []string{"GB", "IE"},
+		Currencies:     []string{"GBP", "EUR", "USD"},
+		PaymentMethods: gpapi.PaymentMethodNames,
+		DigitalWallets: gpapi.DigitalWalletProviders,
+	})
+}
+
+// api wraps a link endpoint with latency, bearer token checks, and the server failure scenario
+func (s *Server) api(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(s.opts.Latency)
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		valid := s.tokens[token]
+		s.mu.Unlock()
+		if !valid {
+			writeAPIError(w, http.StatusUnauthorized, "NOT_AUTHENTICATED", "40001", "Invalid access token")
+			return
+		}
+
+		if s.failures[FailServer] {
+			writeAPIError(w, http.StatusBadGateway, "SYSTEM_ERROR_DOWNSTREAM", "50002", "Mock upstream failure")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleCreateLink validates and stores a new link
+func (s *Server) handleCreateLink(w http.ResponseWriter, r *http.Request) {
+	var data gpapi.LinkData
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "INVALID_REQUEST_DATA", "40213", "Request body is not valid JSON")
+		return
+	}
+
+	switch {
+	case s.failures[FailCreate] || data.Reference == FailReference:
+		writeAPIError(w, http.StatusBadRequest, "INVALID_REQUEST_DATA", "40213", "Mock link creation failure")
+		return
+	case (data.Transactions.Amount <= 0 && !openAmount(data)) || data.Transactions.Currency == "":
+		writeAPIError(w, http.StatusBadRequest, "MANDATORY_DATA_MISSING", "40005", "Request expects the following fields transactions.amount, transactions.currency")
+		return
+	case openAmount(data) && data.Transactions.MaxAmount > 0 && data.Transactions.MinAmount > data.Transactions.MaxAmount:
+		writeAPIError(w, http.StatusBadRequest, "INVALID_REQUEST_DATA", "40213", "transactions.min_amount must not exceed transactions.max_amount")
+		return
+	case data.Type == "" || data.UsageMode == "":
+		writeAPIError(w, http.StatusBadRequest, "MANDATORY_DATA_MISSING", "40005", "Request expects the following fields type, usage_mode")
+		return
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	l := &link{
+		ID:            fmt.Sprintf("LNK_mock%06d", s.nextID),
+		Status:        "ACTIVE",
+		CreatedAt:     time.Now().UTC(),
+		Data:          data,
+		notifications: data.Notifications,
+	}
+	s.links[l.ID] = l
+	response := gpapi.LinkResponse{ID: l.ID, URL: s.baseURL + "/pay/" + l.ID}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, response)
+}
+
+// openAmount reports whether a link lets the payer choose the amount
+func openAmount(data gpapi.LinkData) bool {
+	return data.Type == gpapi.PayByLinkTypeHostedPaymentPage && data.Transactions.Amount == 0
+}
+
+// detail renders a link in GP's link detail format. The caller must hold s.mu.
+func (s *Server) detail(l *link) map[string]interface{} {
+	transactions := l.Transactions
+	if transactions == nil {
+		transactions = []gpapi.Transaction{}
+	}
+	return map[string]interface{}{
+		"id":              l.ID,
+		"url":             s.baseURL + "/pay/" + l.ID,
+		"status":          l.Status,
+		"type":            l.Data.Type,
+		"usage_mode":      l.Data.UsageMode,
+		"usage_limit":     strconv.Itoa(l.Data.UsageLimit),
+		"usage_count":     strconv.Itoa(l.UsageCount),
+		"viewed_count":    strconv.Itoa(l.ViewedCount),
+		"reference":       l.Data.Reference,
+		"name":            l.Data.Name,
+		"description":     l.Data.Description,
+		"expiration_date": l.Data.ExpirationDate,
+		"time_created":    l.CreatedAt.Format(time.RFC3339),
+		"transactions": map[string]interface{}{
+			"amount":           strconv.Itoa(l.Data.Transactions.Amount),
+			"currency":         l.Data.Transactions.Currency,
+			"transaction_list": transactions,
+		},
+	}
+}
+
+// findLink returns the link named in the request path, writing a 404 if it does not exist.
+// The caller must hold s.mu.
+func (s *Server) findLink(w http.ResponseWriter, r *http.Request) *link {
+	l, ok := s.links[r.PathValue("id")]
+	if !ok || s.failures[FailNotFound] {
+		writeAPIError(w, http.StatusNotFound, "RESOURCE_NOT_FOUND", "40118", fmt.Sprintf("Links %s not found at this location.", r.PathValue("id")))
+		return nil
+	}
+	return l
+}
+
+// handleGetLink returns a single link
+func (s *Server) handleGetLink(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l := s.findLink(w, r); l != nil {
+		writeJSON(w, http.StatusOK, s.detail(l))
+	}
+}
+
+// handleUpdateLink applies a partial update to a link
+func (s *Server) handleUpdateLink(w http.ResponseWriter, r *http.Request) {
+	var patch struct {
+		gpapi.LinkUpdate
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "INVALID_REQUEST_DATA", "40213", "Request body is not valid JSON")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.findLink(w, r)
+	if l == nil {
+		return
+	}
+	if patch.Status != "" {
+		l.Status = strings.ToUpper(patch.Status)
+	}
+	if patch.Name != "" {
+		l.Data.Name = patch.Name
+	}
+	if patch.Description != "" {
+		l.Data.Description = patch.Description
+	}
+	if patch.ExpirationDate != "" {
+		l.Data.ExpirationDate = patch.ExpirationDate
+	}
+	if patch.Transactions != nil {
+		l.Data.Transactions.Amount = patch.Transactions.Amount
+	}
+	writeJSON(w, http.StatusOK, s.detail(l))
+}
+
+// handleSearchLinks lists links created within from_time_created and to_time_created, newest first
+func (s *Server) handleSearchLinks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pageSize, err := strconv.Atoi(query.Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10
+	}
+	from, _ := time.Parse("2006-01-02", query.Get("from_time_created"))
+	to, err := time.Parse("2006-01-02", query.Get("to_time_created"))
+	if err != nil {
+		to = time.Now()
+	}
+	to = to.Add(24 * time.Hour)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matches := make([]*link, 0, len(s.links))
+	for _, l := range s.links {
+		if !l.CreatedAt.Before(from) && l.CreatedAt.Before(to) {
+			matches = append(matches, l)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].CreatedAt.After(matches[j].CreatedAt) })
+	if len(matches) > pageSize {
+		matches = matches[:pageSize]
+	}
+
+	links := make([]interface{}, 0, len(matches))
+	for _, l := range matches {
+		links = append(links, s.detail(l))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"links":              links,
+		"current_page_size":  strconv.Itoa(len(links)),
+		"total_record_count": len(s.links),
+	})
+}
+
+// payPage is the fake hosted payment page shown for a link's URL
+var payPage = template.Must(template.New("pay").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Mock payment - {{.Name}}</title></head>
+<body>
+  <h1>{{.Name}}</h1>
+  <p>{{.Description}}</p>
+  <p>Reference: {{.Reference}}</p>
+  {{if not .Open}}<p>Amount: {{.Amount}} {{.Currency}} (minor units)</p>{{end}}
+  {{if .Active}}
+  <form method="post">
+    {{if .Open}}<p><label>Amount in {{.Currency}} minor units
+      <input name="amount" type="number" required{{if .MinAmount}} min="{{.MinAmount}}"{{end}}{{if .MaxAmount}} max="{{.MaxAmount}}"{{end}}></label></p>{{end}}
+    <button name="result" value="CAPTURED">Pay</button>
+    <button name="result" value="DECLINED">Decline</button>
+  </form>
+  {{else}}
+  <p>This link is {{.Status}}.</p>
+  {{end}}
+  <p><small>Served by the mock GP API. No real payment is taken.</small></p>
+</body>
+</html>
+`))
+
+// findTransaction returns the transaction named in the request path, writing a 404 if it
+// does not exist. The caller must hold s.mu.
+func (s *Server) findTransaction(w http.ResponseWriter, r *http.Request) *gpapi.Transaction {
+	id := r.PathValue("id")
+	for _, l := range s.links {
+		for i := range l.Transactions {
+			if l.Transactions[i].ID == id {
+				return &l.Transactions[i]
+			}
+		}
+	}
+	for i := range s.refunds {
+		if s.refunds[i].ID == id {
+			return &s.refunds[i]
+		}
+	}
+	writeAPIError(w, http.StatusNotFound, "RESOURCE_NOT_FOUND", "40118", fmt.Sprintf("Transactions %s not found at this location.", id))
+	return nil
+}
+
+// handleGetTransaction returns a single transaction
+func (s *Server) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if transaction := s.findTransaction(w, r); transaction != nil {
+		writeJSON(w, http.StatusOK, transaction)
+	}
+}
+
+// handleCaptureTransaction captures a transaction authorized on a LATER capture mode link
+func (s *Server) handleCaptureTransaction(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction := s.findTransaction(w, r)
+	if transaction == nil {
+		return
+	}
+	if transaction.Status != "PREAUTHORIZED" {
+		writeAPIError(w, http.StatusBadRequest, "INVALID_REQUEST_DATA", "40213", fmt.Sprintf("Transaction %s cannot be captured in status %s", transaction.ID, transaction.Status))
+		return
+	}
+	transaction.Status = "CAPTURED"
+	writeJSON(w, http.StatusOK, transaction)
+}
+
+// handleRefundTransaction refunds part or all of a captured transaction, rejecting
+// refunds that would exceed the amount captured
+func (s *Server) handleRefundTransaction(w http.ResponseWriter, r *http.Request) {
+	var refund gpapi.TransactionRefund
+	if err := json.NewDecoder(r.Body).Decode(&refund); err != nil || refund.Amount <= 0 {
+		writeAPIError(w, http.StatusBadRequest, "MANDATORY_DATA_MISSING", "40005", "Request expects the following fields amount")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction := s.findTransaction(w, r)
+	if transaction == nil {
+		return
+	}
+	if transaction.Status != "CAPTURED" {
+		writeAPIError(w, http.StatusBadRequest, "INVALID_REQUEST_DATA", "40213", fmt.Sprintf("Transaction %s cannot be refunded in status %s", transaction.ID, transaction.Status))
+		return
+	}
+	captured, _ := transaction.Amount.Int64()
+	if s.refunded[transaction.ID]+refund.Amount > captured {
+		writeAPIError(w, http.StatusBadRequest, "INVALID_REQUEST_DATA", "40213", fmt.Sprintf("Refund amount exceeds the %d remaining on transaction %s", captured-s.refunded[transaction.ID], transaction.ID))
+		return
+	}
+	s.refunded[transaction.ID] += refund.Amount
+
+	refundTransaction := gpapi.Transaction{
+		ID:          randomID("TRN_"),
+		TimeCreated: time.Now().UTC().Format(time.RFC3339),
+		Status:      "CAPTURED",
+		Type:        "REFUND",
+		Amount:      json.Number(strconv.FormatInt(refund.Amount, 10)),
+		Currency:    transaction.Currency,
+		Reference:   transaction.Reference,
+	}
+	s.refunds = append(s.refunds, refundTransaction)
+	writeJSON(w, http.StatusOK, refundTransaction)
+}
+
+// handleSearchTransactions returns a page of link payments and refunds, newest first,
+// filtered by creation date, status, and reference
+func (s *Server) handleSearchTransactions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(query.Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10
+	}
+	from, _ := time.Parse("2006-01-02", query.Get("from_time_created"))
+	to, err := time.Parse("2006-01-02", query.Get("to_time_created"))
+	if err != nil {
+		to = time.Now()
+	}
+	to = to.Add(24 * time.Hour)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all := append([]gpapi.Transaction{}, s.refunds...)
+	for _, l := range s.links {
+		all = append(all, l.Transactions...)
+	}
+
+	matches := []gpapi.Transaction{}
+	for _, transaction := range all {
+		created, _ := time.Parse(time.RFC3339, transaction.TimeCreated)
+		switch {
+		case created.Before(from) || !created.Before(to):
+		case query.Get("status") != "" && transaction.Status != query.Get("status"):
+		case query.Get("reference") != "" && transaction.Reference != query.Get("reference"):
+		default:
+			matches = append(matches, transaction)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].TimeCreated > matches[j].TimeCreated })
+
+	total := len(matches)
+	start := min((page-1)*pageSize, total)
+	matches = matches[start:min(start+pageSize, total)]
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"transactions":       matches,
+		"current_page_size":  strconv.Itoa(len(matches)),
+		"total_record_count": total,
+	})
+}
+
+// handlePayPage renders the fake hosted payment page
+func (s *Server) handlePayPage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	l := s.findLink(w, r)
+	if l == nil {
+		s.mu.Unlock()
+		return
+	}
+	l.ViewedCount++
+	page := map[string]interface{}{
+		"Name":        l.Data.Name,
+		"Description": l.Data.Description,
+		"Reference":   l.Data.Reference,
+		"Amount":      l.Data.Transactions.Amount,
+		"Currency":    l.Data.Transactions.Currency,
+		"Open":        openAmount(l.Data),
+		"MinAmount":   l.Data.Transactions.MinAmount,
+		"MaxAmount":   l.Data.Transactions.MaxAmount,
+		"Status":      l.Status,
+		"Active":      l.Status == "ACTIVE",
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	payPage.Execute(w, page)
+}
+
+// handlePay records a payment attempt, sends a status notification, and redirects to the return URL.
+// Payments on LATER capture mode links are only authorized.
+func (s *Server) handlePay(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	l := s.findLink(w, r)
+	if l == nil {
+		s.mu.Unlock()
+		return
+	}
+	if l.Status != "ACTIVE" {
+		s.mu.Unlock()
+		http.Error(w, "Link is "+l.Status, http.StatusConflict)
+		return
+	}
+
+	// Open-amount links are paid whatever the payer enters within the link's bounds
+	amount := l.Data.Transactions.Amount
+	if openAmount(l.Data) {
+		var err error
+		amount, err = strconv.Atoi(r.FormValue("amount"))
+		bounds := l.Data.Transactions
+		if err != nil || amount <= 0 || amount < bounds.MinAmount || (bounds.MaxAmount > 0 && amount > bounds.MaxAmount) {
+			s.mu.Unlock()
+			http.Error(w, "Enter an amount within the link's bounds", http.StatusBadRequest)
+			return
+		}
+	}
+
+	result := "CAPTURED"
+	if r.FormValue("result") == "DECLINED" {
+		result = "DECLINED"
+	} else if l.Data.Transactions.CaptureMode == gpapi.CaptureModeLater {
+		result = "PREAUTHORIZED"
+	}
+
+	transaction := gpapi.Transaction{
+		ID:          randomID("TRN_"),
+		TimeCreated: time.Now().UTC().Format(time.RFC3339),
+		Status:      result,
+		Type:        "SALE",
+		Amount:      json.Number(strconv.Itoa(amount)),
+		Currency:    l.Data.Transactions.Currency,
+		Reference:   l.Data.Reference,
+	}
+	l.Transactions = append(l.Transactions, transaction)
+	if result != "DECLINED" {
+		l.UsageCount++
+		if l.UsageCount >= l.Data.UsageLimit {
+			l.Status = "PAID"
+		}
+	}
+	notifications := l.notifications
+	linkID, reference := l.ID, l.Data.Reference
+	s.mu.Unlock()
+
+	go s.notify(notifications.StatusURL, linkID, reference, transaction)
+
+	redirect := notifications.ReturnURL
+	if result == "DECLINED" && notifications.CancelURL != "" {
+		redirect = notifications.CancelURL
+	}
+	http.Redirect(w, r, withResultParams(redirect, linkID, transaction.ID), http.StatusSeeOther)
+}
+
+// withResultParams adds the link and transaction IDs to a return or cancel URL, as GP
+// does when it sends the customer back to the merchant
+func withResultParams(redirect, linkID, transactionID string) string {
+	parsed, err := url.Parse(redirect)
+	if err != nil {
+		return redirect
+	}
+	query := parsed.Query()
+	query.Set("link_id", linkID)
+	query.Set("transaction_id", transactionID)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+// notify posts a signed transaction status notification, as GP does to a link's status_url
+func (s *Server) notify(statusURL, linkID, reference string, transaction gpapi.Transaction) {
+	if s.opts.NotifyURL != "" {
+		statusURL = s.opts.NotifyURL
+	}
+	if statusURL == "" {
+		return
+	}
+
+	result, message := "00", "[ test system ] AUTHORISED"
+	if transaction.Status == "DECLINED" {
+		result, message = "05", "[ test system ] DECLINED"
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":           transaction.ID,
+		"time_created": transaction.TimeCreated,
+		"type":         transaction.Type,
+		"status":       transaction.Status,
+		"channel":      "CNP",
+		"amount":       transaction.Amount.String(),
+		"currency":     transaction.Currency,
+		"reference":    transaction.Reference,
+		"payment_method": map[string]interface{}{
+			"result":  result,
+			"message": message,
+			"card":    map[string]string{"brand": "VISA", "masked_number_last4": "XXXXXXXXXXXX1111"},
+		},
+		"link_data": map[string]string{"id": linkID, "reference": reference},
+	})
+	if err != nil {
+		slog.Error("Mock GP API could not encode notification", "error", err)
+		return
+	}
+
+	hash := sha512.Sum512(append(append([]byte{}, body...), s.currentAppKey()...))
+	req, err := http.NewRequest("POST", statusURL, bytes.NewReader(body))
+	if err != nil {
+		slog.Warn("Mock GP API could not send notification", "status_url", statusURL, "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GP-Signature", hex.EncodeToString(hash[:]))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		slog.Warn("Mock GP API could not send notification", "status_url", statusURL, "error", err)
+		return
+	}
+	resp.Body.Close()
+	slog.Info("Mock GP API sent status notification", "status_url", statusURL, "link_id", linkID, "status", resp.StatusCode)
+}
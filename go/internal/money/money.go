@@ -0,0 +1,118 @@
+// Package money converts decimal amounts in major currency units to the
+// integer minor units expected by GP API, using ISO 4217 currency exponents.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxMinorUnits caps converted amounts well below the int64 range.
+const maxMinorUnits = 999_999_999_999
+
+// Errors returned by ToMinorUnits. Returned errors wrap one of these, so
+// callers can use errors.Is to distinguish them.
+var (
+	ErrEmpty           = errors.New("amount is required")
+	ErrMalformed       = errors.New("amount is not a valid decimal number")
+	ErrTooManyDecimals = errors.New("amount has too many decimal places")
+	ErrNotPositive     = errors.New("amount must be greater than zero")
+	ErrTooLarge        = errors.New("amount is too large")
+	ErrInvalidCurrency = errors.New("currency must be an ISO 4217 currency code")
+)
+
+// exponents lists ISO 4217 currencies whose minor unit is not 1/100.
+var exponents = map[string]int{
+	// Zero-decimal currencies
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	// Three-decimal currencies
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	// Four-decimal currencies
+	"CLF": 4, "UYW": 4,
+}
+
+// Exponent returns the number of minor-unit decimal places for an ISO 4217
+// currency code. Currencies not listed as exceptions use two decimal places.
+func Exponent(currency string) int {
+	if exponent, ok := exponents[strings.ToUpper(currency)]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// ToMinorUnits parses a decimal amount expressed in major units (for example
+// "10.99") and converts it to minor units for the given currency (1099 for
+// EUR, or an error for JPY, which has no minor unit).
+func ToMinorUnits(amount, currency string) (int64, error) {
+	if !IsCurrency(currency) {
+		return 0, ErrInvalidCurrency
+	}
+
+	amount = strings.TrimSpace(amount)
+	if amount == "" {
+		return 0, ErrEmpty
+	}
+
+	whole, fraction, hasPoint := strings.Cut(amount, ".")
+	if whole == "" || (hasPoint && fraction == "") || !isDigits(whole) || !isDigits(fraction) {
+		return 0, fmt.Errorf("%w: %q", ErrMalformed, amount)
+	}
+
+	exponent := Exponent(currency)
+	if len(fraction) > exponent {
+		if exponent == 0 {
+			return 0, fmt.Errorf("%w: %s does not allow decimal places", ErrTooManyDecimals, strings.ToUpper(currency))
+		}
+		return 0, fmt.Errorf("%w: %s allows at most %d", ErrTooManyDecimals, strings.ToUpper(currency), exponent)
+	}
+
+	// Pad the fraction to the currency exponent and treat the digits as one integer
+	digits := strings.TrimLeft(whole+fraction+strings.Repeat("0", exponent-len(fraction)), "0")
+	if digits == "" {
+		return 0, ErrNotPositive
+	}
+	if len(digits) > len(strconv.Itoa(maxMinorUnits)) {
+		return 0, ErrTooLarge
+	}
+
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || minor > maxMinorUnits {
+		return 0, ErrTooLarge
+	}
+
+	return minor, nil
+}
+
+// FormatMinorUnits renders an amount in minor units as a decimal string in
+// major units, for example 1099 EUR as "10.99".
+func FormatMinorUnits(minor int64, currency string) string {
+	exponent := Exponent(currency)
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+
+	digits := strconv.FormatInt(minor, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+// isDigits reports whether s consists only of ASCII digits
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestToMinorUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     int64
+		wantErr  error
+	}{
+		{name: "two decimals", amount: "10.99", currency: "EUR", want: 1099},
+		{name: "whole amount", amount: "10", currency: "EUR", want: 1000},
+		{name: "one decimal is padded", amount: "0.5", currency: "EUR", want: 50},
+		{name: "smallest unit", amount: "0.01", currency: "USD", want: 1},
+		{name: "surrounding space", amount: " 5.00 ", currency: "GBP", want: 500},
+		{name: "lower case currency", amount: "1.25", currency: "eur", want: 125},
+		{name: "leading zeros", amount: "007.10", currency: "EUR", want: 710},
+		{name: "zero-decimal currency", amount: "1500", currency: "JPY", want: 1500},
+		{name: "three-decimal currency", amount: "1.234", currency: "KWD", want: 1234},
+		{name: "four-decimal currency", amount: "0.0001", currency: "CLF", want: 1},
+		{name: "largest amount", amount: "9999999999.99", currency: "EUR", want: 999_999_999_999},
+
+		{name: "third decimal is not rounded", amount: "10.999", currency: "EUR", wantErr: ErrTooManyDecimals},
+		{name: "half a minor unit is not rounded", amount: "0.005", currency: "EUR", wantErr: ErrTooManyDecimals},
+		{name: "decimals on zero-decimal currency", amount: "1.5", currency: "JPY", wantErr: ErrTooManyDecimals},
+		{name: "fourth decimal on three-decimal currency", amount: "1.2345", currency: "BHD", wantErr: ErrTooManyDecimals},
+		{name: "empty", amount: "  ", currency: "EUR", wantErr: ErrEmpty},
+		{name: "zero", amount: "0.00", currency: "EUR", wantErr: ErrNotPositive},
+		{name: "negative", amount: "-1.00", currency: "EUR", wantErr: ErrMalformed},
+		{name: "explicit plus sign", amount: "+1.00", currency: "EUR", wantErr: ErrMalformed},
+		{name: "comma decimal separator", amount: "1,50", currency: "EUR", wantErr: ErrMalformed},
+		{name: "exponent", amount: "1e3", currency: "EUR", wantErr: ErrMalformed},
+		{name: "missing whole part", amount: ".50", currency: "EUR", wantErr: ErrMalformed},
+		{name: "trailing point", amount: "1.", currency: "EUR", wantErr: ErrMalformed},
+		{name: "too large", amount: "10000000000.00", currency: "EUR", wantErr: ErrTooLarge},
+		{name: "too many digits", amount: "99999999999999999999999", currency: "JPY", wantErr: ErrTooLarge},
+		{name: "unknown currency", amount: "1.00", currency: "EURO", wantErr: ErrInvalidCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToMinorUnits(tt.amount, tt.currency)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ToMinorUnits(%q, %q) error = %v, want %v", tt.amount, tt.currency, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ToMinorUnits(%q, %q) error = %v", tt.amount, tt.currency, err)
+			}
+			if got != tt.want {
+				t.Errorf("ToMinorUnits(%q, %q) = %d, want %d", tt.amount, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatMinorUnits(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		currency string
+		want     string
+	}{
+		{1099, "EUR", "10.99"},
+		{1000, "EUR", "10.00"},
+		{5, "EUR", "0.05"},
+		{50, "EUR", "0.50"},
+		{0, "EUR", "0.00"},
+		{-250, "EUR", "-2.50"},
+		{-5, "EUR", "-0.05"},
+		{1500, "JPY", "1500"},
+		{-1500, "JPY", "-1500"},
+		{1234, "KWD", "1.234"},
+		{7, "KWD", "0.007"},
+		{1, "CLF", "0.0001"},
+		{999_999_999_999, "EUR", "9999999999.99"},
+	}
+	for _, tt := range tests {
+		if got := FormatMinorUnits(tt.minor, tt.currency); got != tt.want {
+			t.Errorf("FormatMinorUnits(%d, %q) = %q, want %q", tt.minor, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMinorUnitsRoundTrip(t *testing.T) {
+	for _, currency := range []string{"EUR", "JPY", "KWD", "CLF"} {
+		for _, minor := range []int64{1, 9, 10, 99, 100, 101, 12345, 999_999_999_999} {
+			formatted := FormatMinorUnits(minor, currency)
+			got, err := ToMinorUnits(formatted, currency)
+			if err != nil || got != minor {
+				t.Errorf("ToMinorUnits(FormatMinorUnits(%d, %s) = %q) = %d, %v", minor, currency, formatted, got, err)
+			}
+		}
+	}
+}
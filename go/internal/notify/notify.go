@@ -0,0 +1,148 @@
+// Package notify sends payment links to customers through messaging providers.
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Delivery statuses recorded before a provider reports back
+const (
+	DeliveryStatusQueued = "queued"
+	DeliveryStatusFailed = "failed"
+)
+
+// Notifier delivers payment links to customers through a messaging provider
+type Notifier interface {
+	// Channel names the delivery channel, e.g. "sms"
+	Channel() string
+	// Send delivers body to the recipient and returns the provider's message ID and initial status
+	Send(ctx context.Context, to, body string) (providerID, status string, err error)
+}
+
+// TwilioNotifier sends SMS messages through the Twilio Messages API
+type TwilioNotifier struct {
+	accountSID        string
+	authToken         string
+	from              string
+	statusCallbackURL string
+	baseURL           string
+	client            *http.Client
+}
+
+// twilioMessageResponse represents the fields used from a Twilio message resource
+type twilioMessageResponse struct {
+	SID          string `json:"sid"`
+	Status       string `json:"status"`
+	ErrorCode    *int   `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+	Message      string `json:"message"`
+}
+
+// NewTwilioNotifier creates a TwilioNotifier that calls Twilio with client. from may be a
+// phone number or a Messaging Service SID; statusCallbackURL is optional.
+func NewTwilioNotifier(accountSID, authToken, from, statusCallbackURL string, client *http.Client) (*TwilioNotifier, error) {
+	if accountSID == "" || authToken == "" || from == "" {
+		return nil, fmt.Errorf("TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN, and TWILIO_FROM_NUMBER are required for SMS")
+	}
+	return &TwilioNotifier{
+		accountSID:        accountSID,
+		authToken:         authToken,
+		from:              from,
+		statusCallbackURL: statusCallbackURL,
+		baseURL:           "https://api.twilio.com/2010-04-01",
+		client:            client,
+	}, nil
+}
+
+// Channel implements Notifier
+func (t *TwilioNotifier) Channel() string {
+	return "sms"
+}
+
+// Send implements Notifier
+func (t *TwilioNotifier) Send(ctx context.Context, to, body string) (string, string, error) {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("Body", body)
+	if strings.HasPrefix(t.from, "MG") {
+		form.Set("MessagingServiceSid", t.from)
+	} else {
+		form.Set("From", t.from)
+	}
+	if t.statusCallbackURL != "" {
+		form.Set("StatusCallback", t.statusCallbackURL)
+	}
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", t.baseURL, url.PathEscape(t.accountSID))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create Twilio request: %w", err)
+	}
+	req.SetBasicAuth(t.accountSID, t.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to execute Twilio request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read Twilio response: %w", err)
+	}
+
+	var message twilioMessageResponse
+	if err := json.Unmarshal(respBody, &message); err != nil {
+		return "", "", fmt.Errorf("failed to unmarshal Twilio response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("twilio request failed with status %d: %s", resp.StatusCode, message.Message)
+	}
+	if message.ErrorCode != nil {
+		return message.SID, DeliveryStatusFailed, fmt.Errorf("twilio error %d: %s", *message.ErrorCode, message.ErrorMessage)
+	}
+
+	return message.SID, message.Status, nil
+}
+
+// VerifySignature checks the X-Twilio-Signature header of a status callback.
+// Twilio signs the full callback URL followed by each POST parameter name and
+// value, sorted by name, using HMAC-SHA1 with the auth token.
+func (t *TwilioNotifier) VerifySignature(signature string, params url.Values) bool {
+	if t.statusCallbackURL == "" {
+		return false
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var data strings.Builder
+	data.WriteString(t.statusCallbackURL)
+	for _, key := range keys {
+		for _, value := range params[key] {
+			data.WriteString(key)
+			data.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(t.authToken))
+	mac.Write([]byte(data.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,110 @@
+// Package pdf writes single-page PDF documents of plain text, such as receipts, using the
+// standard Helvetica fonts every PDF reader has, so no fonts are embedded.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page size of A4 in points
+const (
+	PageWidth  = 595
+	PageHeight = 842
+)
+
+// ContentType is the media type of a PDF document
+const ContentType = "application/pdf"
+
+// text is a run of text placed on the page
+type text struct {
+	x, y, size float64
+	bold       bool
+	value      string
+}
+
+// Document is a single page of text. Coordinates are in points from the bottom left
+// corner of the page.
+type Document struct {
+	texts []text
+}
+
+// Text places value on the page with its baseline starting at x, y
+func (d *Document) Text(x, y, size float64, bold bool, value string) {
+	d.texts = append(d.texts, text{x: x, y: y, size: size, bold: bold, value: value})
+}
+
+// WriteTo writes the document to w
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var content bytes.Buffer
+	for _, t := range d.texts {
+		font := "F1"
+		if t.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n", font, number(t.size), number(t.x), number(t.y), encode(t.value))
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> /Contents 4 0 R >>",
+			PageWidth, PageHeight),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	var out bytes.Buffer
+	// The comment of high bytes marks the file as binary to transfer programs
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.WriteTo(w)
+}
+
+// number formats a coordinate or size without needless decimals
+func number(f float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", f), "0"), ".")
+}
+
+// winAnsi maps the characters of Windows-1252 outside Latin-1 to their codes
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b,
+	'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// encode converts value to a PDF string in WinAnsiEncoding, escaping the characters
+// that delimit strings. Characters the standard fonts cannot show become "?".
+func encode(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case winAnsi[r] != 0:
+			fmt.Fprintf(&b, "\\%03o", winAnsi[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
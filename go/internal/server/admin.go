@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// EffectiveConfig lists the settings the server was started with, with secrets redacted
+type EffectiveConfig struct {
+	Settings []config.Setting `json:"settings"`
+}
+
+// handleAdminConfig handles the /admin/config endpoint, showing where each setting came
+// from so operators can tell which of the config file, environment, and flags took effect
+func (s *Server) handleAdminConfig(w http.ResponseWriter, r *http.Request) {
+	settings := s.reloadable.Load().settings
+	if settings == nil {
+		settings = []config.Setting{}
+	}
+	writeJSON(w, http.StatusOK, Response{Success: true, Data: EffectiveConfig{Settings: settings}})
+}
+
+// handleAdminReload handles the /admin/reload endpoint, reloading the configuration as
+// SIGHUP does and responding with the settings now in effect
+func (s *Server) handleAdminReload(w http.ResponseWriter, r *http.Request) {
+	if err := s.Reload(r.Context()); err != nil {
+		logging.FromContext(r.Context()).Error("Error reloading configuration, keeping the current one", "error", err)
+		writeError(w, http.StatusUnprocessableEntity, "Configuration not reloaded", "RELOAD_FAILED", err.Error())
+		return
+	}
+	s.handleAdminConfig(w, r)
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// configMaxAge is how long a browser may reuse a /config response before revalidating it
+const configMaxAge = time.Minute
+
+// immutableCacheControl lets browsers and CDNs keep a fingerprinted asset for a year
+// without revalidating it, as its content can never change under the same name
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// staticAsset is a file of the embedded browser client, held in memory
+type staticAsset struct {
+	name    string
+	content []byte
+	etag    string
+}
+
+// staticAssets serves the embedded browser client. Each file other than a page is also
+// served under a fingerprinted name with its content hash, such as app.3f2a9c1b7d4e.js,
+// which the pages reference, so browsers can cache it indefinitely and still pick up a
+// new version as soon as the pages do. The pages and the plain names are revalidated on
+// each load with their ETag.
+type staticAssets struct {
+	byPath        map[string]*staticAsset
+	byFingerprint map[string]*staticAsset
+}
+
+// newStaticAssets reads the files in fsys, rewriting the src and href attributes of its
+// pages to the fingerprinted names
+func newStaticAssets(fsys fs.FS) (*staticAssets, error) {
+	assets := &staticAssets{byPath: make(map[string]*staticAsset), byFingerprint: make(map[string]*staticAsset)}
+	var pages []string
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if path.Ext(name) == ".html" {
+			pages = append(pages, name)
+			assets.byPath[name] = &staticAsset{name: name, content: content}
+			return nil
+		}
+		asset := &staticAsset{name: name, content: content, etag: contentETag(content)}
+		assets.byPath[name] = asset
+		assets.byFingerprint[fingerprintedName(name, content)] = asset
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var references []string
+	for fingerprinted, asset := range assets.byFingerprint {
+		for _, attribute := range []string{"src", "href"} {
+			references = append(references, attribute+`="`+asset.name+`"`, attribute+`="`+fingerprinted+`"`)
+		}
+	}
+	rewrite := strings.NewReplacer(references...)
+	for _, name := range pages {
+		page := assets.byPath[name]
+		page.content = []byte(rewrite.Replace(string(page.content)))
+		page.etag = contentETag(page.content)
+	}
+	return assets, nil
+}
+
+// contentETag returns an ETag for content. It is weak because the response it describes
+// may be compressed.
+func contentETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// fingerprintedName inserts the hash of content before the extension of name
+func fingerprintedName(name string, content []byte) string {
+	sum := sha256.Sum256(content)
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:6]) + ext
+}
+
+// ServeHTTP serves the file at the request's path, answering If-None-Match with 304 when
+// the browser already has it
+func (a *staticAssets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if name == "" || strings.HasSuffix(name, "/") {
+		name += "index.html"
+	}
+	asset, ok := a.byFingerprint[name]
+	if ok {
+		w.Header().Set("Cache-Control", immutableCacheControl)
+	} else if asset, ok = a.byPath[name]; ok {
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("ETag", asset.etag)
+	http.ServeContent(w, r, asset.name, time.Time{}, bytes.NewReader(asset.content))
+}
+
+// jsonETag returns an ETag for the JSON encoding of v
+func jsonETag(v interface{}) string {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return contentETag(encoded)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, comparing weakly
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
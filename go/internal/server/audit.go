@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// Audited actions
+const (
+	auditLinkCreate         = "link.create"
+	auditLinkUpdate         = "link.update"
+	auditLinkCancel         = "link.cancel"
+	auditLinkReminders      = "link.reminders"
+	auditLinkSMS            = "link.sms"
+	auditTransactionCapture = "transaction.capture"
+	auditTransactionRefund  = "transaction.refund"
+	auditCustomerCreate     = "customer.create"
+	auditCustomerErase      = "customer.erase"
+	auditTemplateCreate     = "template.create"
+	auditTemplateUpdate     = "template.update"
+	auditTemplateDelete     = "template.delete"
+	auditProductSave        = "product.save"
+	auditProductDelete      = "product.delete"
+	auditConfigReload       = "config.reload"
+	auditRetentionPurge     = "retention.purge"
+	auditDeadLetterRetry    = "deadletter.retry"
+)
+
+// auditRedacted stands in for free text in recorded changes, which may name the customer
+// and could not be erased from the audit log later
+const auditRedacted = "[REDACTED]"
+
+// auditVerifyPageSize is how many entries are read at a time while verifying the chain
+const auditVerifyPageSize = 500
+
+// auditChange records a field's value before and after a change
+type auditChange struct {
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to"`
+}
+
+// AuditVerification reports whether the audit log's hash chain is intact
+type AuditVerification struct {
+	Valid   bool  `json:"valid"`
+	Entries int64 `json:"entries"`
+	// HeadID and HeadHash identify the newest entry. Recording them elsewhere lets a later
+	// check detect entries removed from the end of the log, which the chain cannot.
+	HeadID   int64  `json:"headId,omitempty"`
+	HeadHash string `json:"headHash,omitempty"`
+	// BrokenAt is the earliest entry at which the chain is broken, and Problem says how
+	BrokenAt int64  `json:"brokenAt,omitempty"`
+	Problem  string `json:"problem,omitempty"`
+}
+
+// auditActor names who is making the change in ctx: a person signed in to the dashboard
+// through single sign-on, an API key, anyone when authentication is disabled, or the
+// server's own background jobs, which run outside any request
+func auditActor(ctx context.Context) string {
+	if identity := adminIdentityFrom(ctx); identity.Role != "" {
+		return "user:" + identity.Name
+	}
+	if name := apiKeyNameFrom(ctx); name != "" {
+		return "apikey:" + name
+	}
+	if logging.RequestIDFrom(ctx) != "" {
+		return "anonymous"
+	}
+	return "system"
+}
+
+// audit appends entry to the audit log with changes, attributed to the caller in ctx.
+// Failures are logged rather than returned, as the change has already been made.
+func (s *Server) audit(ctx context.Context, entry store.AuditEntry, changes map[string]interface{}) {
+	entry.Actor = auditActor(ctx)
+	entry.RequestID = logging.RequestIDFrom(ctx)
+	if len(changes) > 0 {
+		// Changes are built from plain values and stored records, which always encode
+		entry.Changes, _ = json.Marshal(changes)
+	}
+	if err := s.links.AppendAudit(ctx, &entry); err != nil {
+		logging.FromContext(ctx).Error("Error appending to the audit log", "action", entry.Action, "resource", entry.Resource, "error", err)
+	}
+}
+
+// handleAdminAudit handles GET requests to /admin/audit, listing audit entries newest first
+func (s *Server) handleAdminAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := store.AuditFilter{
+		Action:        strings.TrimSpace(query.Get("action")),
+		Actor:         strings.TrimSpace(query.Get("actor")),
+		Resource:      strings.TrimSpace(query.Get("resource")),
+		LinkID:        strings.TrimSpace(query.Get("linkId")),
+		TransactionID: strings.TrimSpace(query.Get("transactionId")),
+		Limit:         defaultListLimit,
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			writeErrorf(w, http.StatusBadRequest, "Audit log listing failed", "INVALID_LIMIT",
+				"limit must be between 1 and %d", maxListLimit)
+			return
+		}
+		filter.Limit = limit
+	}
+	if value := query.Get("from"); value != "" {
+		from, err := parseDateParam(value, false)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Audit log listing failed", "INVALID_DATE", "from must be RFC3339 or YYYY-MM-DD")
+			return
+		}
+		filter.CreatedFrom = from
+	}
+	if value := query.Get("to"); value != "" {
+		to, err := parseDateParam(value, true)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Audit log listing failed", "INVALID_DATE", "to must be RFC3339 or YYYY-MM-DD")
+			return
+		}
+		filter.CreatedTo = to
+	}
+	if value := query.Get("cursor"); value != "" {
+		before, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || before < 1 {
+			writeError(w, http.StatusBadRequest, "Audit log listing failed", "INVALID_CURSOR", "invalid cursor")
+			return
+		}
+		filter.BeforeID = before
+	}
+
+	// Fetch one extra entry to find out whether another page exists
+	pageFilter := filter
+	pageFilter.Limit = filter.Limit + 1
+	entries, err := s.links.ListAudit(r.Context(), pageFilter)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing audit entries", "error", err)
+		writeError(w, http.StatusInternalServerError, "Audit log listing failed", "STORE_ERROR", "Error reading the audit log")
+		return
+	}
+	pagination := &Pagination{Limit: filter.Limit}
+	if len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+		pagination.HasMore = true
+		pagination.NextCursor = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success:    true,
+		Message:    fmt.Sprintf("Found %d audit entries", len(entries)),
+		Data:       entries,
+		Pagination: pagination,
+	})
+}
+
+// handleAdminAuditVerify handles GET requests to /admin/audit/verify, checking every
+// entry's hash and its link to the entry before it
+func (s *Server) handleAdminAuditVerify(w http.ResponseWriter, r *http.Request) {
+	verification, err := s.verifyAudit(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error verifying the audit log", "error", err)
+		writeError(w, http.StatusInternalServerError, "Audit log verification failed", "STORE_ERROR", "Error reading the audit log")
+		return
+	}
+
+	message := "The audit log is intact"
+	if !verification.Valid {
+		message = "The audit log has been tampered with"
+		logging.FromContext(r.Context()).Error("Audit log hash chain is broken", "entry", verification.BrokenAt, "problem", verification.Problem)
+	}
+	writeJSON(w, http.StatusOK, Response{Success: true, Message: message, Data: verification})
+}
+
+// verifyAudit walks the audit log from newest to oldest, checking the hash chain. When it
+// is broken in several places, the earliest is reported.
+func (s *Server) verifyAudit(ctx context.Context) (*AuditVerification, error) {
+	verification := &AuditVerification{Valid: true}
+	broken := func(id int64, problem string) {
+		verification.Valid = false
+		verification.BrokenAt = id
+		verification.Problem = problem
+	}
+
+	// newer is the entry checked just before, which should link to the current one
+	var newer *store.AuditEntry
+	filter := store.AuditFilter{Limit: auditVerifyPageSize}
+	for {
+		entries, err := s.links.ListAudit(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if newer == nil {
+				verification.HeadID, verification.HeadHash = entry.ID, entry.Hash
+			} else if newer.ID != entry.ID+1 {
+				broken(newer.ID, missingEntries(entry.ID+1, newer.ID-1))
+			} else if newer.PrevHash != entry.Hash {
+				broken(newer.ID, "the previous hash does not match the entry before it")
+			}
+			if entry.ComputeHash() != entry.Hash {
+				broken(entry.ID, "the hash does not match the entry's contents")
+			}
+			newer = entry
+			verification.Entries++
+		}
+		if len(entries) < auditVerifyPageSize {
+			break
+		}
+		filter.BeforeID = entries[len(entries)-1].ID
+	}
+
+	if newer != nil && newer.ID != 1 {
+		broken(newer.ID, missingEntries(1, newer.ID-1))
+	} else if newer != nil && newer.PrevHash != store.AuditGenesisHash {
+		broken(newer.ID, "the first entry does not start the chain")
+	}
+	return verification, nil
+}
+
+// missingEntries describes a gap in the audit log from entry first to entry last
+func missingEntries(first, last int64) string {
+	if first == last {
+		return fmt.Sprintf("entry %d is missing", first)
+	}
+	return fmt.Sprintf("entries %d to %d are missing", first, last)
+}
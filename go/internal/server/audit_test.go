@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// auditLog is a link store holding only an audit log, listed as the real stores list it
+type auditLog struct {
+	store.LinkStore
+	// entries are oldest first
+	entries []*store.AuditEntry
+}
+
+// ListAudit returns the entries before filter.BeforeID, newest first
+func (l *auditLog) ListAudit(_ context.Context, filter store.AuditFilter) ([]*store.AuditEntry, error) {
+	var page []*store.AuditEntry
+	for i := len(l.entries) - 1; i >= 0 && len(page) < filter.Limit; i-- {
+		if entry := l.entries[i]; filter.BeforeID == 0 || entry.ID < filter.BeforeID {
+			page = append(page, entry)
+		}
+	}
+	return page, nil
+}
+
+// auditChain returns n correctly chained audit entries, oldest first
+func auditChain(n int) []*store.AuditEntry {
+	entries := make([]*store.AuditEntry, 0, n)
+	prevHash := store.AuditGenesisHash
+	created := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	for id := int64(1); id <= int64(n); id++ {
+		entry := &store.AuditEntry{ID: id, Action: auditLinkCreate, Actor: "apikey:shop", Resource: fmt.Sprintf("LNK_%d", id),
+			Changes: json.RawMessage(`{"amount":"10.00"}`), CreatedAt: created.Add(time.Duration(id) * time.Second), PrevHash: prevHash}
+		entry.Hash = entry.ComputeHash()
+		prevHash = entry.Hash
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// rehash recomputes the hash of an edited entry, as someone covering their tracks would
+func rehash(entry *store.AuditEntry) {
+	entry.Hash = entry.ComputeHash()
+}
+
+// without returns entries less those with the given IDs
+func without(entries []*store.AuditEntry, ids ...int64) []*store.AuditEntry {
+	var kept []*store.AuditEntry
+	for _, entry := range entries {
+		removed := false
+		for _, id := range ids {
+			removed = removed || entry.ID == id
+		}
+		if !removed {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+func TestVerifyAudit(t *testing.T) {
+	tests := []struct {
+		name        string
+		entries     func() []*store.AuditEntry
+		wantValid   bool
+		wantEntries int64
+		wantBroken  int64
+		wantProblem string
+	}{
+		{name: "empty", entries: func() []*store.AuditEntry { return nil }, wantValid: true},
+		{name: "intact", entries: func() []*store.AuditEntry { return auditChain(5) }, wantValid: true, wantEntries: 5},
+		{name: "intact across pages", entries: func() []*store.AuditEntry { return auditChain(auditVerifyPageSize + 3) },
+			wantValid: true, wantEntries: auditVerifyPageSize + 3},
+		{
+			name: "edited entry",
+			entries: func() []*store.AuditEntry {
+				entries := auditChain(5)
+				entries[2].Actor = "apikey:someone-else"
+				return entries
+			},
+			wantEntries: 5, wantBroken: 3, wantProblem: "the hash does not match the entry's contents",
+		},
+		{
+			name: "edited changes",
+			entries: func() []*store.AuditEntry {
+				entries := auditChain(5)
+				entries[1].Changes = json.RawMessage(`{"amount":"1.00"}`)
+				return entries
+			},
+			wantEntries: 5, wantBroken: 2, wantProblem: "the hash does not match the entry's contents",
+		},
+		{
+			name: "edited and rehashed entry",
+			entries: func() []*store.AuditEntry {
+				entries := auditChain(5)
+				entries[2].Actor = "apikey:someone-else"
+				rehash(entries[2])
+				return entries
+			},
+			wantEntries: 5, wantBroken: 4, wantProblem: "the previous hash does not match the entry before it",
+		},
+		{
+			name:        "deleted entry",
+			entries:     func() []*store.AuditEntry { return without(auditChain(5), 3) },
+			wantEntries: 4, wantBroken: 4, wantProblem: "entry 3 is missing",
+		},
+		{
+			name:        "deleted entries",
+			entries:     func() []*store.AuditEntry { return without(auditChain(5), 2, 3) },
+			wantEntries: 3, wantBroken: 4, wantProblem: "entries 2 to 3 are missing",
+		},
+		{
+			name:        "deleted first entry",
+			entries:     func() []*store.AuditEntry { return without(auditChain(5), 1) },
+			wantEntries: 4, wantBroken: 2, wantProblem: "entry 1 is missing",
+		},
+		{
+			name: "first entry does not start the chain",
+			entries: func() []*store.AuditEntry {
+				entries := auditChain(3)
+				entries[0].PrevHash = entries[2].Hash
+				rehash(entries[0])
+				return entries
+			},
+			wantEntries: 3, wantBroken: 1, wantProblem: "the first entry does not start the chain",
+		},
+		{
+			name: "earliest of several breaks",
+			entries: func() []*store.AuditEntry {
+				entries := auditChain(6)
+				entries[1].Resource = "LNK_other"
+				entries[4].Resource = "LNK_other"
+				return entries
+			},
+			wantEntries: 6, wantBroken: 2, wantProblem: "the hash does not match the entry's contents",
+		},
+		{
+			name: "break on an earlier page",
+			entries: func() []*store.AuditEntry {
+				entries := auditChain(auditVerifyPageSize + 3)
+				entries[1].Actor = "apikey:someone-else"
+				return entries
+			},
+			wantEntries: auditVerifyPageSize + 3, wantBroken: 2, wantProblem: "the hash does not match the entry's contents",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := tt.entries()
+			s := &Server{links: &auditLog{entries: entries}}
+			got, err := s.verifyAudit(context.Background())
+			if err != nil {
+				t.Fatalf("verifyAudit: %v", err)
+			}
+			if got.Valid != tt.wantValid || got.Entries != tt.wantEntries || got.BrokenAt != tt.wantBroken || got.Problem != tt.wantProblem {
+				t.Errorf("verifyAudit() = valid %v, %d entries, broken at %d (%q); want valid %v, %d entries, broken at %d (%q)",
+					got.Valid, got.Entries, got.BrokenAt, got.Problem, tt.wantValid, tt.wantEntries, tt.wantBroken, tt.wantProblem)
+			}
+			if len(entries) > 0 {
+				head := entries[len(entries)-1]
+				if got.HeadID != head.ID || got.HeadHash != head.Hash {
+					t.Errorf("head = %d %s, want %d %s", got.HeadID, got.HeadHash, head.ID, head.Hash)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyAuditAppended(t *testing.T) {
+	// Entries the store chains verify as read back, with the times it stored
+	links := newTestStore(t)
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		entry := &store.AuditEntry{Action: auditLinkCreate, Actor: "apikey:shop", Resource: fmt.Sprintf("LNK_%d", i),
+			Changes: json.RawMessage(`{"amount":"10.00"}`)}
+		if err := links.AppendAudit(ctx, entry); err != nil {
+			t.Fatalf("AppendAudit: %v", err)
+		}
+	}
+
+	s := &Server{links: links}
+	got, err := s.verifyAudit(ctx)
+	if err != nil {
+		t.Fatalf("verifyAudit: %v", err)
+	}
+	if !got.Valid || got.Entries != 3 || got.HeadID != 3 {
+		t.Errorf("verifyAudit() = %+v, want a valid chain of 3 entries", got)
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/time/rate"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/locale"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// APIKey is a configured client credential with its own rate limiter and permissions
+type APIKey struct {
+	Name        string
+	Permissions []config.Permission
+	limiter     *rate.Limiter
+}
+
+// APIKeyAuth authenticates requests against a set of configured API keys
+type APIKeyAuth struct {
+	keys         map[[sha256.Size]byte]*APIKey
+	publicConfig bool
+}
+
+// apiKeyNameKey is the context key under which the authenticated key name is stored
+type apiKeyNameKey struct{}
+
+// permissionsKey is the context key under which the caller's permissions are stored
+type permissionsKey struct{}
+
+// NewAPIKeyAuth creates an APIKeyAuth for the configured keys. It returns nil,
+// disabling authentication, when no keys are configured.
+func NewAPIKeyAuth(cfg config.APIKeys) *APIKeyAuth {
+	if len(cfg.Keys) == 0 {
+		return nil
+	}
+
+	auth := &APIKeyAuth{
+		keys:         make(map[[sha256.Size]byte]*APIKey),
+		publicConfig: cfg.PublicConfig,
+	}
+	for _, key := range cfg.Keys {
+		auth.keys[sha256.Sum256([]byte(key.Key))] = &APIKey{
+			Name:        key.Name,
+			Permissions: key.Permissions,
+			limiter:     rate.NewLimiter(rate.Limit(float64(key.RatePerMinute)/60), cfg.Burst),
+		}
+	}
+	return auth
+}
+
+// apiKeyFromRequest extracts the key from the X-API-Key header or an Authorization: Bearer header
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// lookup returns the configured key matching key, or nil if there is none
+func (a *APIKeyAuth) lookup(key string) *APIKey {
+	return a.keys[sha256.Sum256([]byte(key))]
+}
+
+// apiKeyHash returns the hex SHA-256 of key, which identifies it without revealing it
+func apiKeyHash(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// lookupHash returns the configured key whose apiKeyHash is hash, or nil if there is none
+func (a *APIKeyAuth) lookupHash(hash string) *APIKey {
+	decoded, err := hex.DecodeString(hash)
+	if err != nil || len(decoded) != sha256.Size {
+		return nil
+	}
+	return a.keys[[sha256.Size]byte(decoded)]
+}
+
+// apiKeyNameFrom returns the name of the API key that authenticated the request in ctx, if any
+func apiKeyNameFrom(ctx context.Context) string {
+	name, _ := ctx.Value(apiKeyNameKey{}).(string)
+	return name
+}
+
+// Require wraps next so that it is only served to requests presenting a valid API key
+// within that key's rate limit. When a is nil, next is returned unchanged.
+func (a *APIKeyAuth) Require(next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := a.lookup(apiKeyFromRequest(r))
+		if key == nil {
+			logging.FromContext(r.Context()).Warn("Rejected request without a valid API key", "path", r.URL.Path, "remote_addr", r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="pay-by-link"`)
+			writeError(w, http.StatusUnauthorized, "Authentication required", "UNAUTHORIZED", "A valid API key must be sent in the X-API-Key or Authorization: Bearer header")
+			return
+		}
+
+		reservation := key.limiter.Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			reservation.Cancel()
+			logging.FromContext(r.Context()).Warn("API key rate limit exceeded", "api_key", key.Name, "path", r.URL.Path)
+			writeRateLimited(w, delay, locale.Messagef("Too many requests for API key %s", strconv.Quote(key.Name)))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), apiKeyNameKey{}, key.Name)
+		ctx = context.WithValue(ctx, permissionsKey{}, key.Permissions)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// permitted reports whether the caller in ctx has been granted permission. Callers are
+// granted everything when neither API keys nor a dashboard sign-in identified them, as
+// happens when authentication is disabled.
+func permitted(ctx context.Context, permission config.Permission) bool {
+	permissions, ok := ctx.Value(permissionsKey{}).([]config.Permission)
+	return !ok || slices.Contains(permissions, permission)
+}
+
+// requirePermission returns middleware, run after Require, that refuses callers without
+// permission with 403 FORBIDDEN
+func requirePermission(permission config.Permission) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !permitted(r.Context(), permission) {
+				logging.FromContext(r.Context()).Warn("Refused request without permission", "api_key", apiKeyNameFrom(r.Context()), "permission", permission, "path", r.URL.Path)
+				writeErrorf(w, http.StatusForbidden, "Forbidden", "FORBIDDEN", "The API key has not been granted the %s permission", permission)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireConfig wraps the /config handler, which stays public unless API_PUBLIC_CONFIG=false
+func (a *APIKeyAuth) RequireConfig(next http.Handler) http.Handler {
+	if a == nil || a.publicConfig {
+		return next
+	}
+	return a.Require(next)
+}
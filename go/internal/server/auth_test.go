@@ -0,0 +1,218 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+)
+
+// okHandler answers 200 with the name of the API key that authenticated the request
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(apiKeyNameFrom(r.Context())))
+})
+
+func TestAPIKeyAuthRequire(t *testing.T) {
+	auth := NewAPIKeyAuth(config.APIKeys{Burst: 10, Keys: []config.APIKey{
+		{Name: "shop", Key: "shop-secret", RatePerMinute: 600, Permissions: config.AllPermissions},
+		{Name: "reports", Key: "reports-secret", RatePerMinute: 600, Permissions: []config.Permission{config.PermissionRead}},
+	}})
+
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		wantStatus int
+		wantKey    string
+	}{
+		{name: "X-API-Key", header: "X-API-Key", value: "shop-secret", wantStatus: http.StatusOK, wantKey: "shop"},
+		{name: "bearer token", header: "Authorization", value: "Bearer reports-secret", wantStatus: http.StatusOK, wantKey: "reports"},
+		{name: "bearer scheme is case-insensitive", header: "Authorization", value: "bearer shop-secret", wantStatus: http.StatusOK, wantKey: "shop"},
+		{name: "no key", wantStatus: http.StatusUnauthorized},
+		{name: "unknown key", header: "X-API-Key", value: "guess", wantStatus: http.StatusUnauthorized},
+		{name: "key differs in case", header: "X-API-Key", value: "SHOP-SECRET", wantStatus: http.StatusUnauthorized},
+		{name: "basic auth", header: "Authorization", value: "Basic c2hvcC1zZWNyZXQ=", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/payment-links", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			auth.Require(okHandler).ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				if got := rec.Body.String(); got != tt.wantKey {
+					t.Errorf("authenticated key = %q, want %q", got, tt.wantKey)
+				}
+				return
+			}
+			if code := errorCode(decodeResponse(t, rec)); code != "UNAUTHORIZED" {
+				t.Errorf("error code = %q, want UNAUTHORIZED", code)
+			}
+			if rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("missing WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthRateLimit(t *testing.T) {
+	auth := NewAPIKeyAuth(config.APIKeys{Burst: 2, Keys: []config.APIKey{
+		{Name: "shop", Key: "shop-secret", RatePerMinute: 1, Permissions: config.AllPermissions},
+		{Name: "other", Key: "other-secret", RatePerMinute: 1, Permissions: config.AllPermissions},
+	}})
+	send := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/payment-links", nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		auth.Require(okHandler).ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if rec := send("shop-secret"); rec.Code != want {
+			t.Fatalf("request %d: status = %d, want %d", i+1, rec.Code, want)
+		}
+	}
+	// Each key has a limiter of its own
+	if rec := send("other-secret"); rec.Code != http.StatusOK {
+		t.Errorf("other key: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestAPIKeyAuthDisabled(t *testing.T) {
+	auth := NewAPIKeyAuth(config.APIKeys{})
+	if auth != nil {
+		t.Fatal("NewAPIKeyAuth without keys should disable authentication")
+	}
+	rec := httptest.NewRecorder()
+	auth.Require(okHandler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/payment-links", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	auth := NewAPIKeyAuth(config.APIKeys{Burst: 10, Keys: []config.APIKey{
+		{Name: "admin", Key: "admin-secret", RatePerMinute: 600, Permissions: config.AllPermissions},
+		{Name: "reports", Key: "reports-secret", RatePerMinute: 600, Permissions: []config.Permission{config.PermissionRead}},
+		{Name: "support", Key: "support-secret", RatePerMinute: 600, Permissions: []config.Permission{config.PermissionRead, config.PermissionCancel}},
+	}})
+
+	tests := []struct {
+		key        string
+		permission config.Permission
+		wantStatus int
+	}{
+		{"admin-secret", config.PermissionCreate, http.StatusOK},
+		{"admin-secret", config.PermissionAdmin, http.StatusOK},
+		{"reports-secret", config.PermissionRead, http.StatusOK},
+		{"reports-secret", config.PermissionCreate, http.StatusForbidden},
+		{"reports-secret", config.PermissionCancel, http.StatusForbidden},
+		{"reports-secret", config.PermissionAdmin, http.StatusForbidden},
+		{"support-secret", config.PermissionCancel, http.StatusOK},
+		{"support-secret", config.PermissionCapture, http.StatusForbidden},
+		{"support-secret", config.PermissionRefund, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"/"+string(tt.permission), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/payment-link/LNK_1/cancel", nil)
+			req.Header.Set("X-API-Key", tt.key)
+			rec := httptest.NewRecorder()
+			chain(okHandler, auth.Require, requirePermission(tt.permission)).ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusForbidden {
+				if code := errorCode(decodeResponse(t, rec)); code != "FORBIDDEN" {
+					t.Errorf("error code = %q, want FORBIDDEN", code)
+				}
+			}
+		})
+	}
+}
+
+func TestRequirePermissionWithoutAuth(t *testing.T) {
+	// Without API keys nobody is identified, and every permission is granted
+	rec := httptest.NewRecorder()
+	requirePermission(config.PermissionAdmin)(okHandler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+}
+
+func TestHandlerWithoutAPIKeys(t *testing.T) {
+	keys := config.APIKeys{Burst: 10, PublicConfig: true, Keys: []config.APIKey{{Name: "shop", Key: "shop-secret", RatePerMinute: 600, Permissions: config.AllPermissions}}}
+	noKeys := New(newTestConfig(config.APIKeys{}), nil, newTestStore(t), nil, http.DefaultClient).Handler()
+	allowed := New(newTestConfig(config.APIKeys{AllowUnauthenticated: true}), nil, newTestStore(t), nil, http.DefaultClient).Handler()
+	withKeys := New(newTestConfig(keys), nil, newTestStore(t), nil, http.DefaultClient).Handler()
+
+	// How a server answers a request without an API key
+	const (
+		served       = "served"
+		unrouted     = "unrouted"
+		unauthorized = "unauthorized"
+	)
+	answer := func(handler http.Handler, method, path string) string {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		// Handlers reached without a GP client fail, but only the routing matters here
+		handler.ServeHTTP(rec, req)
+		switch rec.Code {
+		case http.StatusNotFound, http.StatusMethodNotAllowed:
+			if strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+				return served
+			}
+			return unrouted
+		case http.StatusUnauthorized:
+			return unauthorized
+		default:
+			return served
+		}
+	}
+
+	tests := []struct {
+		method   string
+		path     string
+		noKeys   string
+		allowed  string
+		withKeys string
+	}{
+		{http.MethodGet, "/healthz", served, served, served},
+		{http.MethodGet, "/config", served, served, served},
+		{http.MethodPost, "/create-payment-link", served, served, unauthorized},
+		{http.MethodPost, "/create-payment-links", served, served, unauthorized},
+		{http.MethodGet, "/payment-links", unrouted, served, unauthorized},
+		{http.MethodGet, "/payment-links/export", unrouted, served, unauthorized},
+		{http.MethodGet, "/payment-link/LNK_1", unrouted, served, unauthorized},
+		{http.MethodPatch, "/payment-link/LNK_1", unrouted, served, unauthorized},
+		{http.MethodPost, "/payment-link/LNK_1/cancel", unrouted, served, unauthorized},
+		{http.MethodPost, "/graphql", unrouted, served, unauthorized},
+		{http.MethodGet, "/customers/cus_1/export", unrouted, served, unauthorized},
+		{http.MethodDelete, "/customers/cus_1/data", unrouted, served, unauthorized},
+		{http.MethodPost, "/transactions/TRN_1/capture", unrouted, served, unauthorized},
+		{http.MethodPost, "/transactions/TRN_1/refund", unrouted, served, unauthorized},
+		{http.MethodGet, "/admin", unrouted, served, served},
+		{http.MethodGet, "/admin/audit", unrouted, unrouted, unauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			if got := answer(noKeys, tt.method, tt.path); got != tt.noKeys {
+				t.Errorf("without API keys: %s, want %s", got, tt.noKeys)
+			}
+			if got := answer(allowed, tt.method, tt.path); got != tt.allowed {
+				t.Errorf("with ALLOW_UNAUTHENTICATED: %s, want %s", got, tt.allowed)
+			}
+			if got := answer(withKeys, tt.method, tt.path); got != tt.withKeys {
+				t.Errorf("with API keys: %s, want %s", got, tt.withKeys)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/globalpayments/pay-by-link-go/internal/locale"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// Batch creation limits
+const (
+	maxBatchSize = 500
+	batchWorkers = 8
+)
+
+// BatchLinkResult reports the outcome of a single row in a batch request
+type BatchLinkResult struct {
+	Row     int                  `json:"row"`
+	Success bool                 `json:"success"`
+	Data    *PaymentLinkResponse `json:"data,omitempty"`
+	Error   *ErrorInfo           `json:"error,omitempty"`
+}
+
+// BatchLinkResponse summarises a batch creation request
+type BatchLinkResponse struct {
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchLinkResult `json:"results"`
+}
+
+// handleCreatePaymentLinks handles the /create-payment-links batch endpoint.
+// It accepts a JSON array of link requests or a multipart CSV upload in the "file" field.
+func (s *Server) handleCreatePaymentLinks(w http.ResponseWriter, r *http.Request) {
+	var requests []PaymentLinkRequest
+	contentType := r.Header.Get("Content-Type")
+	switch {
+	case strings.Contains(contentType, "application/json"):
+		if err := json.NewDecoder(r.Body).Decode(&requests); err != nil {
+			if writeBodyTooLarge(w, "Batch link creation failed", err) {
+				return
+			}
+			writeError(w, http.StatusBadRequest, "Batch link creation failed", "INVALID_JSON", "Request body must be a JSON array of payment link requests")
+			return
+		}
+	case strings.Contains(contentType, "multipart/form-data"):
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			if writeBodyTooLarge(w, "Batch link creation failed", err) {
+				return
+			}
+			writeError(w, http.StatusBadRequest, "Batch link creation failed", "FORM_PARSE_ERROR", "Expected a CSV upload in the \"file\" field")
+			return
+		}
+		defer file.Close()
+
+		requests, err = parseBatchCSV(file)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Batch link creation failed", "INVALID_CSV", err.Error())
+			return
+		}
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, "Batch link creation failed", "UNSUPPORTED_CONTENT_TYPE", "Use application/json or multipart/form-data")
+		return
+	}
+
+	if len(requests) == 0 {
+		writeError(w, http.StatusBadRequest, "Batch link creation failed", "EMPTY_BATCH", "No payment link requests provided")
+		return
+	}
+	if len(requests) > maxBatchSize {
+		writeErrorf(w, http.StatusBadRequest, "Batch link creation failed", "BATCH_TOO_LARGE",
+			"A batch may contain at most %d links, got %d", maxBatchSize, len(requests))
+		return
+	}
+
+	results := s.createLinksConcurrently(r.Context(), requests)
+
+	batch := BatchLinkResponse{Total: len(results), Results: results}
+	for _, result := range results {
+		if result.Success {
+			batch.Succeeded++
+		} else {
+			batch.Failed++
+		}
+	}
+
+	logging.FromContext(r.Context()).Info("Batch payment links processed",
+		"total", batch.Total,
+		"succeeded", batch.Succeeded,
+		"failed", batch.Failed,
+	)
+
+	writeJSON(w, http.StatusOK, Response{
+		Success:        batch.Failed == 0,
+		catalogMessage: locale.Messagef("Created %d of %d payment links", batch.Succeeded, batch.Total),
+		Data:           batch,
+	})
+}
+
+// createLinksConcurrently creates each link using a bounded pool of workers.
+// Results are returned in the same order as requests.
+func (s *Server) createLinksConcurrently(ctx context.Context, requests []PaymentLinkRequest) []BatchLinkResult {
+	results := make([]BatchLinkResult, len(requests))
+	rows := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(batchWorkers, len(requests)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range rows {
+				result := BatchLinkResult{Row: row + 1}
+				response, linkErr := s.createLinkFromRequest(ctx, requests[row])
+				if linkErr != nil {
+					result.Error = linkErr.Info()
+				} else {
+					result.Success = true
+					result.Data = response
+				}
+				results[row] = result
+			}
+		}()
+	}
+
+	for i := range requests {
+		rows <- i
+	}
+	close(rows)
+	wg.Wait()
+
+	return results
+}
+
+// parseBatchCSV reads link requests from a CSV file whose header row names the
+// request fields, e.g. amount,currency,reference,name,description
+func parseBatchCSV(file multipart.File) ([]PaymentLinkRequest, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSV header: %w", err)
+	}
+
+	fields := paymentLinkRequestFields()
+	columns := make([]int, len(header))
+	for i, name := range header {
+		index, ok := fields[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		columns[i] = index
+	}
+
+	var requests []PaymentLinkRequest
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading CSV: %w", err)
+		}
+		if len(requests) == maxBatchSize {
+			return nil, fmt.Errorf("a batch may contain at most %d links", maxBatchSize)
+		}
+
+		var req PaymentLinkRequest
+		value := reflect.ValueOf(&req).Elem()
+		for i, cell := range record {
+			value.Field(columns[i]).SetString(strings.TrimSpace(cell))
+		}
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
+
+// paymentLinkRequestFields maps lower-cased PaymentLinkRequest form names to struct field indexes
+func paymentLinkRequestFields() map[string]int {
+	fields := make(map[string]int)
+	requestType := reflect.TypeOf(PaymentLinkRequest{})
+	for i := 0; i < requestType.NumField(); i++ {
+		if name := requestType.Field(i).Tag.Get("form"); name != "" {
+			fields[strings.ToLower(name)] = i
+		}
+	}
+	return fields
+}
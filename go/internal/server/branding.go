@@ -0,0 +1,66 @@
+package server
+
+import (
+	"unicode/utf8"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+)
+
+// validateBranding checks the optional hosted payment page branding of a link request,
+// returning a field error for each malformed part
+func validateBranding(req PaymentLinkRequest) []FieldError {
+	var fields []FieldError
+	if name := req.BrandDisplayName; name != "" {
+		if utf8.RuneCountInString(name) > config.MaxBrandDisplayNameLength {
+			fields = append(fields, fieldError("brandDisplayName", FieldTooLong,
+				"%s must be at most %d characters", "brandDisplayName", config.MaxBrandDisplayNameLength))
+		} else if hasControlCharacters(name, false) {
+			fields = append(fields, fieldError("brandDisplayName", FieldInvalidCharacters,
+				"%s must not contain control characters", "brandDisplayName"))
+		}
+	}
+	if logoURL := req.BrandLogoURL; logoURL != "" {
+		if _, err := config.ParseHTTPSURL(logoURL); err != nil {
+			fields = append(fields, fieldError("brandLogoUrl", FieldInvalidFormat, "%s must be an absolute https URL", "brandLogoUrl"))
+		}
+	}
+	if color := req.BrandColor; color != "" {
+		if _, err := config.ParseBrandColor(color); err != nil {
+			fields = append(fields, FieldError{Field: "brandColor", Code: FieldInvalidFormat, Message: "brandColor must be a hex color such as #1A73E8"})
+		}
+	}
+	return fields
+}
+
+// linkBranding returns the hosted payment page branding of a validated request to send to
+// GP, taking each part the request leaves empty from the configured defaults, or nil when
+// neither sets any
+func linkBranding(req PaymentLinkRequest, defaults config.Branding) *gpapi.LinkBranding {
+	branding := &gpapi.LinkBranding{
+		MerchantDisplayName: req.BrandDisplayName,
+		LogoURL:             req.BrandLogoURL,
+		BrandColor:          defaults.Color,
+	}
+	if branding.MerchantDisplayName == "" {
+		branding.MerchantDisplayName = defaults.DisplayName
+	}
+	if branding.LogoURL == "" {
+		branding.LogoURL = defaults.LogoURL
+	}
+	if color, err := config.ParseBrandColor(req.BrandColor); err == nil {
+		branding.BrandColor = color
+	}
+	if *branding == (gpapi.LinkBranding{}) {
+		return nil
+	}
+	return branding
+}
+
+// brandingResponse reports the branding sent to GP, or nil when none was
+func brandingResponse(branding *gpapi.LinkBranding) *LinkBrandingResponse {
+	if branding == nil {
+		return nil
+	}
+	return &LinkBrandingResponse{DisplayName: branding.MerchantDisplayName, LogoURL: branding.LogoURL, Color: branding.BrandColor}
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// defaultCurrencies are served by /config when GP API does not report the account's currencies
+// and SUPPORTED_CURRENCIES is not set
+var defaultCurrencies = []string{"EUR", "USD", "GBP"}
+
+// unsupportedCurrency returns a CURRENCY_NOT_SUPPORTED error listing the supported currencies
+// when they are configured and do not include currency
+func unsupportedCurrency(currency string, supported []string) *LinkRequestError {
+	if len(supported) == 0 || slices.Contains(supported, currency) {
+		return nil
+	}
+	linkErr := newLinkRequestError(http.StatusBadRequest, "CURRENCY_NOT_SUPPORTED",
+		"Currency %s is not supported; use one of %s", currency, strings.Join(supported, ", "))
+	linkErr.Allowed = supported
+	return linkErr
+}
+
+// capabilitiesRetryInterval is how long a failed capabilities lookup is remembered before
+// GP API is asked again, so an outage does not turn every /config request into an API call
+const capabilitiesRetryInterval = time.Minute
+
+// capabilities describes what the merchant account can process
+type capabilities struct {
+	Currencies     []string
+	PaymentMethods []gpapi.PaymentMethodName
+	// DigitalWallets are the wallets links can offer; it is empty unless PaymentMethods
+	// includes DIGITAL_WALLET
+	DigitalWallets []gpapi.DigitalWalletProvider
+	Country        string
+}
+
+// capabilitiesCache looks up the merchant account's capabilities through GP API and caches them
+type capabilitiesCache struct {
+	gp             gpapi.LinksClient
+	ttl            time.Duration
+	paymentMethods []gpapi.PaymentMethodName
+	digitalWallets []gpapi.DigitalWalletProvider
+	country        string
+	currencies     []string
+
+	mu        sync.Mutex
+	current   *capabilities
+	expiresAt time.Time
+}
+
+// newCapabilitiesCache creates a cache that keeps lookups for ttl. Only the configured
+// payment methods, digital wallets, and supported currencies are reported, since links cannot
+// be created with any others, and the configured country is reported until the account names one.
+func newCapabilitiesCache(gp gpapi.LinksClient, ttl time.Duration, defaults config.LinkDefaults) *capabilitiesCache {
+	return &capabilitiesCache{gp: gp, ttl: ttl, paymentMethods: defaults.PaymentMethods, digitalWallets: defaults.DigitalWallets.Providers,
+		country: defaults.Country, currencies: defaults.SupportedCurrencies}
+}
+
+// Get returns the cached capabilities, looking them up again once the cache has expired.
+// When the lookup fails, the last known capabilities (or the defaults) are returned.
+func (c *capabilitiesCache) Get(ctx context.Context) capabilities {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.current != nil && now.Before(c.expiresAt) {
+		return *c.current
+	}
+
+	// The result is shared by every caller, so it must not fail because one client went away
+	current, err := c.fetch(context.WithoutCancel(ctx))
+	if err != nil {
+		logging.FromContext(ctx).Warn("Error looking up account capabilities, serving the last known values", "error", err)
+		if c.current == nil {
+			c.current = c.defaults()
+		}
+		c.expiresAt = now.Add(capabilitiesRetryInterval)
+		return *c.current
+	}
+
+	c.current = current
+	c.expiresAt = now.Add(c.ttl)
+	return *c.current
+}
+
+// invalidate makes the next Get look the capabilities up again, such as after the GP API
+// credentials change. The cached capabilities are still served if that lookup fails.
+func (c *capabilitiesCache) invalidate() {
+	c.mu.Lock()
+	c.expiresAt = time.Time{}
+	c.mu.Unlock()
+}
+
+// defaults returns the capabilities assumed when GP API has not reported any
+func (c *capabilitiesCache) defaults() *capabilities {
+	currencies := defaultCurrencies
+	if len(c.currencies) > 0 {
+		currencies = c.currencies
+	}
+	return &capabilities{
+		Currencies:     currencies,
+		PaymentMethods: c.paymentMethods,
+		DigitalWallets: c.digitalWallets,
+		Country:        c.country,
+	}
+}
+
+// fetch looks up the transaction processing account named in the access token
+func (c *capabilitiesCache) fetch(ctx context.Context) (*capabilities, error) {
+	token, err := c.gp.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token.TransactionProcessingAccountID == "" {
+		return nil, errors.New("access token does not name a transaction processing account")
+	}
+
+	account, err := c.gp.GetAccount(ctx, token.TransactionProcessingAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := c.defaults()
+	if len(account.Currencies) > 0 {
+		result.Currencies = make([]string, 0, len(account.Currencies))
+		for _, currency := range account.Currencies {
+			currency = strings.ToUpper(currency)
+			if len(c.currencies) == 0 || slices.Contains(c.currencies, currency) {
+				result.Currencies = append(result.Currencies, currency)
+			}
+		}
+	}
+	if len(account.PaymentMethods) > 0 {
+		result.PaymentMethods = slices.DeleteFunc(slices.Clone(c.paymentMethods), func(method gpapi.PaymentMethodName) bool {
+			return !slices.Contains(account.PaymentMethods, method)
+		})
+	}
+	// Wallets the account has not been set up for are dropped, and DIGITAL_WALLET with them
+	// when none remain
+	if len(account.DigitalWallets) > 0 {
+		result.DigitalWallets = slices.DeleteFunc(slices.Clone(c.digitalWallets), func(provider gpapi.DigitalWalletProvider) bool {
+			return !slices.Contains(account.DigitalWallets, provider)
+		})
+	}
+	if !slices.Contains(result.PaymentMethods, gpapi.PaymentMethodDigitalWallet) {
+		result.DigitalWallets = nil
+	} else if len(result.DigitalWallets) == 0 {
+		result.PaymentMethods = slices.DeleteFunc(slices.Clone(result.PaymentMethods), func(method gpapi.PaymentMethodName) bool {
+			return method == gpapi.PaymentMethodDigitalWallet
+		})
+	}
+	if len(account.Countries) > 0 {
+		result.Country = strings.ToUpper(account.Countries[0])
+	}
+	return result, nil
+}
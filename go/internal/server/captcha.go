@@ -0,0 +1,84 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/captcha"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// captchaTokenHeader carries the token the CAPTCHA widget gave the browser
+const captchaTokenHeader = "X-Captcha-Token"
+
+// captchaFormFields are the form fields the reCAPTCHA and Turnstile widgets add the token to,
+// for pages that post the form without script
+var captchaFormFields = []string{"g-recaptcha-response", "cf-turnstile-response"}
+
+// CaptchaConfig tells the browser client which CAPTCHA widget to render
+type CaptchaConfig struct {
+	Provider string `json:"provider"`
+	SiteKey  string `json:"siteKey"`
+}
+
+// captchaConfig returns the CAPTCHA the browser client must show, or nil when none is
+// configured
+func (s *Server) captchaConfig() *CaptchaConfig {
+	if s.captcha == nil {
+		return nil
+	}
+	return &CaptchaConfig{Provider: s.captcha.Provider(), SiteKey: s.captcha.SiteKey()}
+}
+
+// checkCaptcha verifies the CAPTCHA token sent with a link creation request. Requests made
+// with an API key come from the merchant's own systems and are not checked, nor are any
+// when no CAPTCHA is configured. A provider that cannot be reached fails the request, so
+// an outage never lets bots through.
+func (s *Server) checkCaptcha(r *http.Request) *LinkRequestError {
+	if s.captcha == nil || apiKeyNameFrom(r.Context()) != "" {
+		return nil
+	}
+
+	token := strings.TrimSpace(r.Header.Get(captchaTokenHeader))
+	if token == "" && strings.Contains(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		// The form is parsed again by the handler from the same cached values
+		if err := r.ParseForm(); err == nil {
+			for _, field := range captchaFormFields {
+				if token = strings.TrimSpace(r.PostForm.Get(field)); token != "" {
+					break
+				}
+			}
+		}
+	}
+	if token == "" {
+		return &LinkRequestError{Status: http.StatusBadRequest, Code: "CAPTCHA_REQUIRED", Details: "Complete the CAPTCHA and send its token in the " + captchaTokenHeader + " header"}
+	}
+
+	logger := logging.FromContext(r.Context())
+	err := s.captcha.Verify(r.Context(), token, s.clientIP(r))
+	switch {
+	case errors.Is(err, captcha.ErrRejected):
+		logger.Warn("CAPTCHA verification failed", "provider", s.captcha.Provider(), "error", err)
+		return &LinkRequestError{Status: http.StatusForbidden, Code: "CAPTCHA_FAILED", Details: "The CAPTCHA was not solved or has expired; please try again"}
+	case err != nil:
+		logger.Error("Error verifying CAPTCHA", "provider", s.captcha.Provider(), "error", err)
+		return &LinkRequestError{Status: http.StatusServiceUnavailable, Code: "CAPTCHA_UNAVAILABLE", Details: "The CAPTCHA could not be verified; please try again later"}
+	}
+	return nil
+}
+
+// requireCaptcha wraps next so that link creation without an API key is only served to
+// requests with a valid CAPTCHA token. It must run after auth.
+func (s *Server) requireCaptcha(next http.Handler) http.Handler {
+	if s.captcha == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if linkErr := s.checkCaptcha(r); linkErr != nil {
+			writeJSON(w, linkErr.Status, Response{Success: false, Message: "Payment link creation failed", Error: linkErr.Info()})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+)
+
+// corsExposedHeaders lists response headers browsers may read from cross-origin responses
+const corsExposedHeaders = "X-Request-Id, Retry-After, Idempotent-Replayed"
+
+// allowOrigin returns the Access-Control-Allow-Origin value for origin, or "" if it is not allowed
+func allowOrigin(c config.CORS, origin string) string {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// withCORS wraps next with CORS headers for origins allowed by c and answers
+// preflight requests directly, before authentication and rate limiting are applied
+func withCORS(c config.CORS, next http.Handler) http.Handler {
+	if len(c.AllowedOrigins) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		allowed := ""
+		if origin != "" {
+			allowed = allowOrigin(c, origin)
+		}
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if preflight {
+			if allowed != "" {
+				w.Header().Set("Access-Control-Allow-Origin", allowed)
+				w.Header().Set("Access-Control-Allow-Methods", c.AllowedMethods)
+				w.Header().Set("Access-Control-Allow-Headers", c.AllowedHeaders)
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(c.MaxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if allowed != "" {
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+			w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsPreflight answers CORS preflight requests to a route registered for other methods.
+// An OPTIONS request that is not a preflight is refused, as no route serves OPTIONS itself.
+func corsPreflight(c config.CORS) http.Handler {
+	return withCORS(c, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}))
+}
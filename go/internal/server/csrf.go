@@ -0,0 +1,89 @@
+package server
+
+import (
+	"crypto/subtle"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// CSRF tokens are issued by /config in a cookie and in the response, and must be echoed in
+// the X-CSRF-Token header or the csrfToken form field. A page on another site can neither
+// read the response nor make the browser send the cookie, as it is SameSite=Strict.
+const (
+	csrfCookie    = "pbl_csrf"
+	csrfHeader    = "X-CSRF-Token"
+	csrfFormField = "csrfToken"
+)
+
+// csrfToken returns the CSRF token of the browser making r, setting a new one in a cookie
+// when it has none, or an empty string when CSRF protection is off
+func (s *Server) csrfToken(w http.ResponseWriter, r *http.Request) string {
+	if !s.csrfProtection {
+		return ""
+	}
+	if cookie, err := r.Cookie(csrfCookie); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	token := randomToken()
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   secureRequest(r),
+		SameSite: http.SameSiteStrictMode,
+	})
+	return token
+}
+
+// crossSiteSendable reports whether a request with r's method and body could have been sent
+// by a page on another site without a CORS preflight: an unsafe method with a form, text,
+// or no body. JSON bodies always need a preflight, which CORS only grants to the allowed
+// origins.
+func crossSiteSendable(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return true
+	}
+	return mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")
+}
+
+// requireCSRFToken wraps next so that requests a page on another site could send are only
+// served when they echo the browser's CSRF token. Requests with an API key are not checked,
+// as a browser only sends the key when a script of an allowed origin adds it. It must run
+// after auth.
+func (s *Server) requireCSRFToken(next http.Handler) http.Handler {
+	if !s.csrfProtection {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !crossSiteSendable(r) || apiKeyNameFrom(r.Context()) != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := r.Header.Get(csrfHeader)
+		if token == "" && strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+			// The form is parsed again by the handler from the same cached values
+			if err := r.ParseForm(); err == nil {
+				token = r.PostForm.Get(csrfFormField)
+			}
+		}
+		cookie, err := r.Cookie(csrfCookie)
+		if err != nil || token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) != 1 {
+			logging.FromContext(r.Context()).Warn("Rejected request without a valid CSRF token", "path", r.URL.Path,
+				"origin", r.Header.Get("Origin"), "has_cookie", err == nil)
+			writeError(w, http.StatusForbidden, "Request rejected", "CSRF_TOKEN_INVALID",
+				"Form posts must include the CSRF token from /config in the "+csrfHeader+" header or the "+csrfFormField+" field; send JSON or an API key instead")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
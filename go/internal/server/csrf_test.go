@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequireCSRFToken(t *testing.T) {
+	const token = "browser-token"
+	const form = "application/x-www-form-urlencoded"
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		cookie      string
+		header      string
+		apiKey      string
+		wantStatus  int
+	}{
+		{name: "GET is not checked", method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "JSON needs a preflight", method: http.MethodPost, contentType: "application/json", body: "{}", wantStatus: http.StatusOK},
+		{name: "JSON with charset", method: http.MethodPost, contentType: "application/json; charset=utf-8", body: "{}", wantStatus: http.StatusOK},
+		{name: "JSON suffix type", method: http.MethodPost, contentType: "application/merge-patch+json", body: "{}", wantStatus: http.StatusOK},
+		{name: "form with header token", method: http.MethodPost, contentType: form, body: "amount=10", cookie: token, header: token, wantStatus: http.StatusOK},
+		{name: "form with field token", method: http.MethodPost, contentType: form, body: "amount=10&csrfToken=" + token, cookie: token, wantStatus: http.StatusOK},
+		{name: "form with API key", method: http.MethodPost, contentType: form, body: "amount=10", apiKey: "shop", wantStatus: http.StatusOK},
+
+		{name: "form without token", method: http.MethodPost, contentType: form, body: "amount=10", cookie: token, wantStatus: http.StatusForbidden},
+		{name: "form without cookie", method: http.MethodPost, contentType: form, body: "amount=10", header: token, wantStatus: http.StatusForbidden},
+		{name: "form with wrong token", method: http.MethodPost, contentType: form, body: "amount=10", cookie: token, header: "other-token", wantStatus: http.StatusForbidden},
+		{name: "form with wrong field token", method: http.MethodPost, contentType: form, body: "csrfToken=other-token", cookie: token, wantStatus: http.StatusForbidden},
+		{name: "multipart form", method: http.MethodPost, contentType: "multipart/form-data; boundary=x", body: "--x--", cookie: token, wantStatus: http.StatusForbidden},
+		{name: "plain text", method: http.MethodPost, contentType: "text/plain", body: "{}", cookie: token, wantStatus: http.StatusForbidden},
+		{name: "no content type", method: http.MethodPost, body: "{}", cookie: token, wantStatus: http.StatusForbidden},
+		{name: "DELETE without body", method: http.MethodDelete, cookie: token, wantStatus: http.StatusForbidden},
+	}
+	s := &Server{csrfProtection: true}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/create-payment-link", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: csrfCookie, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set(csrfHeader, tt.header)
+			}
+			if tt.apiKey != "" {
+				req = req.WithContext(context.WithValue(req.Context(), apiKeyNameKey{}, tt.apiKey))
+			}
+			rec := httptest.NewRecorder()
+			s.requireCSRFToken(okHandler).ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusForbidden {
+				if code := errorCode(decodeResponse(t, rec)); code != "CSRF_TOKEN_INVALID" {
+					t.Errorf("error code = %q, want CSRF_TOKEN_INVALID", code)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireCSRFTokenDisabled(t *testing.T) {
+	s := &Server{csrfProtection: false}
+	req := httptest.NewRequest(http.MethodPost, "/create-payment-link", strings.NewReader("amount=10"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	s.requireCSRFToken(okHandler).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+}
+
+func TestCSRFTokenCookie(t *testing.T) {
+	s := &Server{csrfProtection: true}
+
+	// A browser without a token is given one in a strict, HTTP-only cookie
+	rec := httptest.NewRecorder()
+	token := s.csrfToken(rec, httptest.NewRequest(http.MethodGet, "/config", nil))
+	cookies := rec.Result().Cookies()
+	if token == "" || len(cookies) != 1 {
+		t.Fatalf("csrfToken() = %q with %d cookies, want a token in one cookie", token, len(cookies))
+	}
+	cookie := cookies[0]
+	if cookie.Name != csrfCookie || cookie.Value != token || !cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode {
+		t.Errorf("cookie = %+v, want %s=%s, HttpOnly, SameSite=Strict", cookie, csrfCookie, token)
+	}
+
+	// A browser with a token keeps it
+	req := httptest.NewRequest(http.MethodGet, "/config", nil)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	if again := s.csrfToken(rec, req); again != token {
+		t.Errorf("csrfToken() with cookie = %q, want %q", again, token)
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("csrfToken() replaced an existing cookie")
+	}
+}
@@ -0,0 +1,294 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/globalpayments/pay-by-link-go/internal/locale"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// maxEmailLength is the longest email address accepted for a customer
+const maxEmailLength = 254
+
+// customerIDPattern matches the identifiers assigned to customers
+var customerIDPattern = regexp.MustCompile(`^CUS_[0-9a-f]{24}$`)
+
+// CustomerRequest represents the expected customer creation request payload
+type CustomerRequest struct {
+	Name  string `json:"name" form:"name"`
+	Email string `json:"email" form:"email"`
+	Phone string `json:"phone" form:"phone"`
+}
+
+// newCustomerID generates a random customer identifier
+func newCustomerID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "CUS_" + hex.EncodeToString(b)
+}
+
+// validateCustomerRequest checks a customer creation request and returns the customer to
+// store, or every field error found
+func validateCustomerRequest(req CustomerRequest) (*store.Customer, []FieldError) {
+	var fields []FieldError
+	customer := &store.Customer{
+		Name:  normalizeText(req.Name),
+		Email: strings.TrimSpace(req.Email),
+	}
+
+	switch {
+	case customer.Name == "":
+		fields = append(fields, fieldError("name", FieldRequired, "%s is required", "name"))
+	case utf8.RuneCountInString(customer.Name) > maxNameLength:
+		fields = append(fields, fieldError("name", FieldTooLong, "%s must be at most %d characters", "name", maxNameLength))
+	case hasControlCharacters(customer.Name, false):
+		fields = append(fields, fieldError("name", FieldInvalidCharacters, "%s must not contain control characters", "name"))
+	}
+
+	if customer.Email != "" {
+		if len(customer.Email) > maxEmailLength {
+			fields = append(fields, fieldError("email", FieldTooLong, "%s must be at most %d characters", "email", maxEmailLength))
+		} else if address, err := mail.ParseAddress(customer.Email); err != nil || address.Address != customer.Email {
+			fields = append(fields, FieldError{Field: "email", Code: FieldInvalidFormat, Message: "email must be a plain address such as name@example.com"})
+		}
+	}
+
+	if strings.TrimSpace(req.Phone) != "" {
+		phone, err := validatePhone(req.Phone)
+		if err != nil {
+			fields = append(fields, FieldError{Field: "phone", Code: FieldInvalidFormat, Message: "phone must be in E.164 format, e.g. +447700900123"})
+		}
+		customer.Phone = phone
+	}
+
+	return customer, fields
+}
+
+// handleCreateCustomer handles the /customers endpoint, which adds a customer to the directory
+func (s *Server) handleCreateCustomer(w http.ResponseWriter, r *http.Request) {
+	var req CustomerRequest
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		if jsonErr := decodeStrictJSON(r.Body, &req); jsonErr != nil {
+			writeJSON(w, jsonErr.Status, Response{Success: false, Message: "Customer creation failed", Error: jsonErr.Info()})
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			if writeBodyTooLarge(w, "Customer creation failed", err) {
+				return
+			}
+			writeError(w, http.StatusBadRequest, "Customer creation failed", "FORM_PARSE_ERROR", "Error parsing form data")
+			return
+		}
+		req = CustomerRequest{
+			Name:  r.Form.Get("name"),
+			Email: r.Form.Get("email"),
+			Phone: r.Form.Get("phone"),
+		}
+	}
+
+	customer, fields := validateCustomerRequest(req)
+	if len(fields) > 0 {
+		linkErr := validationError(fields)
+		writeJSON(w, linkErr.Status, Response{Success: false, Message: "Customer creation failed", Error: linkErr.Info()})
+		return
+	}
+
+	customer.ID = newCustomerID()
+	if err := s.links.CreateCustomer(r.Context(), customer); err != nil {
+		logging.FromContext(r.Context()).Error("Error storing customer", "error", err)
+		writeError(w, http.StatusInternalServerError, "Customer creation failed", "STORE_ERROR", "Error storing customer")
+		return
+	}
+
+	// Only the ID is recorded, as the audit log cannot be edited to remove personal data later
+	s.audit(r.Context(), store.AuditEntry{Action: auditCustomerCreate, Resource: customer.ID}, nil)
+	logging.FromContext(r.Context()).Info("Customer created", "customer_id", customer.ID, "api_key", apiKeyNameFrom(r.Context()))
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Customer created",
+		Data:    customer,
+	})
+}
+
+// lookupCustomer reads the customer named in the request path, writing an error response
+// and returning nil when the ID is invalid or unknown
+func (s *Server) lookupCustomer(w http.ResponseWriter, r *http.Request, failure string) *store.Customer {
+	customerID := r.PathValue("id")
+	if !customerIDPattern.MatchString(customerID) {
+		writeError(w, http.StatusBadRequest, failure, "INVALID_CUSTOMER_ID", "Invalid customer ID")
+		return nil
+	}
+
+	customer, err := s.links.GetCustomer(r.Context(), customerID)
+	if errors.Is(err, store.ErrCustomerNotFound) {
+		writeError(w, http.StatusNotFound, failure, "CUSTOMER_NOT_FOUND", "Customer not found")
+		return nil
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error reading customer", "customer_id", customerID, "error", err)
+		writeError(w, http.StatusInternalServerError, failure, "STORE_ERROR", "Error reading stored customer")
+		return nil
+	}
+	return customer
+}
+
+// handleGetCustomer handles the /customers/{id} endpoint
+func (s *Server) handleGetCustomer(w http.ResponseWriter, r *http.Request) {
+	customer := s.lookupCustomer(w, r, "Customer lookup failed")
+	if customer == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    customer,
+	})
+}
+
+// handleListCustomerLinks handles the /customers/{id}/payment-links endpoint, listing the
+// stored links associated with a customer, newest first, with the same paging as /payment-links
+func (s *Server) handleListCustomerLinks(w http.ResponseWriter, r *http.Request) {
+	customer := s.lookupCustomer(w, r, "Customer link listing failed")
+	if customer == nil {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := store.LinkFilter{
+		CustomerID: customer.ID,
+		Status:     strings.ToUpper(strings.TrimSpace(query.Get("status"))),
+		Limit:      defaultListLimit,
+	}
+
+	metadata, err := parseTagFilters(query["tag"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Customer link listing failed", "INVALID_TAG", err.Error())
+		return
+	}
+	filter.Metadata = metadata
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			writeErrorf(w, http.StatusBadRequest, "Customer link listing failed", "INVALID_LIMIT",
+				"limit must be between 1 and %d", maxListLimit)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := store.DecodeLinkCursor(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Customer link listing failed", "INVALID_CURSOR", err.Error())
+			return
+		}
+		filter.After = cursor
+	}
+
+	links, pagination, err := s.listLinkPage(r.Context(), filter)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing customer payment links", "customer_id", customer.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Customer link listing failed", "STORE_ERROR", "Error reading stored payment links")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success:    true,
+		Data:       links,
+		Pagination: pagination,
+	})
+}
+
+// CustomerExport holds everything stored locally about a customer, for data subject access requests
+type CustomerExport struct {
+	Customer   *store.Customer   `json:"customer"`
+	Links      []*store.Link     `json:"links"`
+	Deliveries []*store.Delivery `json:"deliveries"`
+	ExportedAt time.Time         `json:"exportedAt"`
+}
+
+// handleExportCustomer handles GET requests to the /customers/{id}/export endpoint,
+// returning the customer with all of their stored links and the deliveries of those links
+func (s *Server) handleExportCustomer(w http.ResponseWriter, r *http.Request) {
+	customer := s.lookupCustomer(w, r, "Customer export failed")
+	if customer == nil {
+		return
+	}
+
+	export := CustomerExport{Customer: customer, Links: []*store.Link{}, Deliveries: []*store.Delivery{}, ExportedAt: time.Now().UTC()}
+	filter := store.LinkFilter{CustomerID: customer.ID, Limit: maxListLimit}
+	for {
+		links, err := s.links.ListLinks(r.Context(), filter)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Error listing customer payment links", "customer_id", customer.ID, "error", err)
+			writeError(w, http.StatusInternalServerError, "Customer export failed", "STORE_ERROR", "Error reading stored payment links")
+			return
+		}
+		for _, link := range links {
+			deliveries, err := s.links.ListDeliveries(r.Context(), link.ID)
+			if err != nil {
+				logging.FromContext(r.Context()).Error("Error listing deliveries", "link_id", link.ID, "error", err)
+				writeError(w, http.StatusInternalServerError, "Customer export failed", "STORE_ERROR", "Error reading stored deliveries")
+				return
+			}
+			export.Deliveries = append(export.Deliveries, deliveries...)
+		}
+		export.Links = append(export.Links, links...)
+		if len(links) < filter.Limit {
+			break
+		}
+		last := links[len(links)-1]
+		filter.After = &store.LinkCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	logging.FromContext(r.Context()).Info("Customer data exported", "customer_id", customer.ID, "links", len(export.Links), "api_key", apiKeyNameFrom(r.Context()))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, customer.ID))
+	writeJSON(w, http.StatusOK, Response{
+		Success:        true,
+		catalogMessage: locale.Messagef("Exported customer %s with %d payment links", customer.ID, len(export.Links)),
+		Data:           export,
+	})
+}
+
+// handleEraseCustomer handles DELETE requests to the /customers/{id}/data endpoint, deleting
+// the customer and the personal data stored with their links. The links themselves are kept,
+// without anything identifying the customer, as they record payments the merchant must account for.
+func (s *Server) handleEraseCustomer(w http.ResponseWriter, r *http.Request) {
+	customerID := r.PathValue("id")
+	if !customerIDPattern.MatchString(customerID) {
+		writeError(w, http.StatusBadRequest, "Customer erasure failed", "INVALID_CUSTOMER_ID", "Invalid customer ID")
+		return
+	}
+
+	erasure, err := s.links.EraseCustomer(r.Context(), customerID)
+	if errors.Is(err, store.ErrCustomerNotFound) {
+		writeError(w, http.StatusNotFound, "Customer erasure failed", "CUSTOMER_NOT_FOUND", "Customer not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error erasing customer", "customer_id", customerID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Customer erasure failed", "STORE_ERROR", "Error erasing stored customer data")
+		return
+	}
+
+	s.audit(r.Context(), store.AuditEntry{Action: auditCustomerErase, Resource: customerID}, map[string]interface{}{"erased": erasure})
+	logging.FromContext(r.Context()).Info("Customer data erased", "customer_id", customerID, "links", erasure.Links, "api_key", apiKeyNameFrom(r.Context()))
+	writeJSON(w, http.StatusOK, Response{
+		Success:        true,
+		catalogMessage: locale.Messagef("Erased customer %s", customerID),
+		Data:           erasure,
+	})
+}
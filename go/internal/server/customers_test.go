@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+func TestCustomerExportAndErasure(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(config.APIKeys{AllowUnauthenticated: true})
+	cfg.VelocityLimits = []config.VelocityLimit{{Scope: config.VelocityScopeCustomer, Window: 24 * time.Hour, MaxLinks: 10}}
+	links := newTestStore(t)
+	s := New(cfg, &fakeGP{}, links, nil, http.DefaultClient)
+	handler := s.Handler()
+
+	customer := &store.Customer{ID: newCustomerID(), Name: "Ada Lovelace", Email: "ada@example.com", Phone: "+447700900123"}
+	if err := links.CreateCustomer(ctx, customer); err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	link, err := s.CreateLink(ctx, PaymentLinkRequest{Amount: "10.00", Currency: "USD", Reference: "INV-ADA", Name: "Invoice",
+		Description: "Invoice for Ada", CustomerID: customer.ID, Metadata: map[string]string{"orderId": "1001"}})
+	if err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+	if err := links.CreateDelivery(ctx, &store.Delivery{LinkID: link.LinkID, Channel: "sms", Recipient: customer.Phone, Status: "queued"}); err != nil {
+		t.Fatalf("CreateDelivery: %v", err)
+	}
+
+	serve := func(method, target string) (int, Response) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec.Code, decodeResponse(t, rec)
+	}
+
+	status, response := serve(http.MethodGet, "/customers/"+customer.ID+"/export")
+	if status != http.StatusOK {
+		t.Fatalf("export: status = %d, want 200: %+v", status, response.Error)
+	}
+	var export CustomerExport
+	data, _ := json.Marshal(response.Data)
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatalf("decoding export: %v", err)
+	}
+	if export.Customer.Email != customer.Email || len(export.Links) != 1 || export.Links[0].Reference != "INV-ADA" ||
+		len(export.Deliveries) != 1 || export.Deliveries[0].Recipient != customer.Phone {
+		t.Errorf("export = %s, want the customer with their link and its delivery", data)
+	}
+
+	status, response = serve(http.MethodDelete, "/customers/"+customer.ID+"/data")
+	if status != http.StatusOK {
+		t.Fatalf("erasure: status = %d, want 200: %+v", status, response.Error)
+	}
+	var erasure store.CustomerErasure
+	data, _ = json.Marshal(response.Data)
+	json.Unmarshal(data, &erasure)
+	if erasure.Links != 1 || erasure.Deliveries != 1 || erasure.Velocity != 1 {
+		t.Errorf("erasure = %+v, want 1 link, 1 delivery, and 1 velocity entry", erasure)
+	}
+
+	// The link is kept for the merchant's records without anything identifying the customer
+	stored, err := links.GetLink(ctx, link.LinkID)
+	if err != nil {
+		t.Fatalf("GetLink: %v", err)
+	}
+	if stored.Reference != "" || stored.CustomerID != "" || len(stored.Metadata) != 0 || stored.Amount != 1000 {
+		t.Errorf("erased link = %+v, want its amount without reference, customer, or metadata", stored)
+	}
+	deliveries, err := links.ListDeliveries(ctx, link.LinkID)
+	if err != nil || len(deliveries) != 1 || deliveries[0].Recipient != "" {
+		t.Errorf("deliveries = %+v (%v), want one without a recipient", deliveries, err)
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		target := "/customers/" + customer.ID + "/export"
+		if method == http.MethodDelete {
+			target = "/customers/" + customer.ID + "/data"
+		}
+		if status, response := serve(method, target); status != http.StatusNotFound || errorCode(response) != "CUSTOMER_NOT_FOUND" {
+			t.Errorf("%s %s after erasure: got %d %q, want 404 CUSTOMER_NOT_FOUND", method, target, status, errorCode(response))
+		}
+	}
+}
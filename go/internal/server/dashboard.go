@@ -0,0 +1,476 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// adminSessionCookie holds the random token of a browser's dashboard session. The session,
+// and the API key it was signed in with, are looked up in the link store by the token's hash.
+const adminSessionCookie = "pbl_admin_sid"
+
+// adminSessionLifetime is how long a dashboard sign-in lasts
+const adminSessionLifetime = 12 * time.Hour
+
+// adminNotices are the messages dashboard actions report after redirecting back to a page.
+// Only these fixed messages are shown, so a crafted link cannot put text on the page.
+var adminNotices = map[string]string{
+	"cancelled":     "The link was cancelled.",
+	"cancel_failed": "The link could not be cancelled. The server log has the details.",
+	"sent":          "The link was sent to the customer by SMS.",
+	"send_failed":   "The link could not be sent by SMS. The server log has the details.",
+}
+
+//go:embed templates/admin.html
+var adminTemplateFS embed.FS
+
+// adminTemplates renders the dashboard pages
+var adminTemplates = template.Must(template.New("admin").Funcs(template.FuncMap{
+	"linkAmount": linkAmountText,
+	"money": func(amount int64, currency string) string {
+		return money.FormatMinorUnits(amount, currency) + " " + currency
+	},
+	"when": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format("2 Jan 2006 15:04 MST")
+	},
+}).ParseFS(adminTemplateFS, "templates/admin.html"))
+
+// AdminPage is the data every dashboard page renders
+type AdminPage struct {
+	Title string
+	// User names the API key or person signed in; empty when the dashboard is open
+	User string
+	// Role is the dashboard role of a person signed in through single sign-on
+	Role   string
+	Notice string
+	Error  string
+}
+
+// AdminLinksPage is the data rendered on the dashboard's link list
+type AdminLinksPage struct {
+	AdminPage
+	Statuses  []string
+	Status    string
+	Reference string
+	Currency  string
+	Tag       string
+	From      string
+	To        string
+	Links     []*store.Link
+	// NextPage is the URL of the next page of links, if there is one
+	NextPage string
+	// Paged reports whether this is a later page than the first
+	Paged bool
+}
+
+// AdminLinkPage is the data rendered on the dashboard's link detail view. Link is nil for
+// links created outside this server, and GPError is set when GP API could not be reached.
+type AdminLinkPage struct {
+	AdminPage
+	LinkID       string
+	Link         *store.Link
+	GPStatus     string
+	Name         string
+	UsageMode    string
+	UsageCount   int64
+	UsageLimit   int64
+	Transactions []TransactionSummary
+	Deliveries   []*store.Delivery
+	ShortLink    string
+	Views        []*store.LinkView
+	GPError      string
+	CanCancel    bool
+	CanResend    bool
+}
+
+// AdminLoginPage is the data rendered on the dashboard's sign-in page
+type AdminLoginPage struct {
+	AdminPage
+	Next string
+	// APIKeys and SSO report which ways of signing in are offered
+	APIKeys bool
+	SSO     bool
+}
+
+// renderAdmin writes the named dashboard page with the given status code
+func renderAdmin(w http.ResponseWriter, r *http.Request, status int, name string, page interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(status)
+	if err := adminTemplates.ExecuteTemplate(w, name, page); err != nil {
+		logging.FromContext(r.Context()).Error("Error rendering dashboard page", "page", name, "error", err)
+	}
+}
+
+// newAdminPage returns the common page data for a request, with the notice named by its
+// notice parameter
+func newAdminPage(r *http.Request, title string) AdminPage {
+	identity := adminIdentityFrom(r.Context())
+	return AdminPage{
+		Title:  title,
+		User:   identity.Name,
+		Role:   identity.Role,
+		Notice: adminNotices[r.URL.Query().Get("notice")],
+	}
+}
+
+// requireAdminSession wraps a dashboard page so it is only served to browsers signed in
+// with a valid API key or through the OpenID Connect provider, sending others to the
+// sign-in page, and refusing those without the read permission. When neither API keys nor
+// single sign-on are configured the dashboard is open, as the API is; Handler only serves
+// it then when ALLOW_UNAUTHENTICATED is set.
+func (s *Server) requireAdminSession(next http.Handler) http.Handler {
+	if s.auth == nil && s.oidc == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		identity, ok := adminIdentity{}, false
+		if cookie, err := r.Cookie(adminSessionCookie); err == nil && s.auth != nil {
+			if key := s.adminSessionKey(ctx, cookie.Value); key != nil {
+				identity, ok = adminIdentity{Name: key.Name, Permissions: key.Permissions}, true
+				ctx = context.WithValue(ctx, apiKeyNameKey{}, key.Name)
+			}
+		}
+		if !ok && s.oidc != nil {
+			identity, ok = s.oidc.session(r)
+		}
+		if !ok {
+			login := "/admin/login"
+			if r.Method == http.MethodGet {
+				login += "?next=" + url.QueryEscape(r.URL.RequestURI())
+			}
+			http.Redirect(w, r, login, http.StatusSeeOther)
+			return
+		}
+		if !slices.Contains(identity.Permissions, config.PermissionRead) {
+			s.renderLogin(w, r, http.StatusForbidden, "You have not been granted the read permission the dashboard needs.", "")
+			return
+		}
+		ctx = context.WithValue(ctx, adminIdentityKey{}, identity)
+		ctx = context.WithValue(ctx, permissionsKey{}, identity.Permissions)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// adminSessionKey returns the configured API key the dashboard session with token was
+// signed in with, or nil when the session has expired or been revoked, or its key is no
+// longer configured
+func (s *Server) adminSessionKey(ctx context.Context, token string) *APIKey {
+	session, err := s.links.GetAdminSession(ctx, adminSessionHash(token))
+	if err != nil {
+		if !errors.Is(err, store.ErrAdminSessionNotFound) {
+			logging.FromContext(ctx).Error("Error reading dashboard session", "error", err)
+		}
+		return nil
+	}
+	return s.auth.lookupHash(session.APIKeyHash)
+}
+
+// adminSessionHash returns the hash a dashboard session is stored under, so the tokens
+// that sign browsers in are not kept
+func adminSessionHash(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// allowAdminAction reports whether a dashboard form may take an action on linkID: the ID is
+// valid, the form was posted from the dashboard, and the user has been granted permission
+func allowAdminAction(r *http.Request, linkID string, permission config.Permission) bool {
+	if !linkIDPattern.MatchString(linkID) || !sameOrigin(r) {
+		return false
+	}
+	if !permitted(r.Context(), permission) {
+		logging.FromContext(r.Context()).Warn("Refused dashboard action without permission", "user", adminIdentityFrom(r.Context()).Name, "permission", permission, "path", r.URL.Path)
+		return false
+	}
+	return true
+}
+
+// sameOrigin reports whether a form was posted from this server's own pages. The session
+// cookie is SameSite=Strict as well; this also covers browsers that ignore that.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		return r.Header.Get("Sec-Fetch-Site") != "cross-site"
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// adminRedirectTarget returns next when it is a dashboard page, or the link list otherwise,
+// so the sign-in page cannot be used to redirect elsewhere
+func adminRedirectTarget(next string) string {
+	if next == "/admin" || strings.HasPrefix(next, "/admin/links/") || strings.HasPrefix(next, "/admin?") {
+		return next
+	}
+	return "/admin"
+}
+
+// renderLogin writes the sign-in page with an error message, offering the configured ways
+// of signing in
+func (s *Server) renderLogin(w http.ResponseWriter, r *http.Request, status int, message, next string) {
+	renderAdmin(w, r, status, "login", AdminLoginPage{
+		AdminPage: AdminPage{Title: "Sign in", Error: message},
+		Next:      adminRedirectTarget(next),
+		APIKeys:   s.auth != nil,
+		SSO:       s.oidc != nil,
+	})
+}
+
+// handleAdminLoginPage handles GET requests to /admin/login
+func (s *Server) handleAdminLoginPage(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil && s.oidc == nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	s.renderLogin(w, r, http.StatusOK, "", r.URL.Query().Get("next"))
+}
+
+// handleAdminLogin handles POST requests to /admin/login, signing the browser in when the
+// form carries a valid API key. The browser is given a random session token rather than
+// the key, and the session is recorded in the link store.
+func (s *Server) handleAdminLogin(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		http.Redirect(w, r, "/admin", http.StatusSeeOther)
+		return
+	}
+	next := adminRedirectTarget(r.FormValue("next"))
+	apiKey := strings.TrimSpace(r.FormValue("apiKey"))
+	key := s.auth.lookup(apiKey)
+	if key == nil || !sameOrigin(r) {
+		logging.FromContext(r.Context()).Warn("Rejected dashboard sign-in", "remote_addr", r.RemoteAddr)
+		s.renderLogin(w, r, http.StatusUnauthorized, "That API key is not valid.", next)
+		return
+	}
+
+	token := randomToken()
+	session := &store.AdminSession{
+		TokenHash:  adminSessionHash(token),
+		APIKeyHash: apiKeyHash(apiKey),
+		ExpiresAt:  time.Now().Add(adminSessionLifetime),
+	}
+	if err := s.links.CreateAdminSession(r.Context(), session); err != nil {
+		logging.FromContext(r.Context()).Error("Error recording dashboard session", "error", err)
+		s.renderLogin(w, r, http.StatusInternalServerError, "You could not be signed in. The server log has the details.", next)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     adminSessionCookie,
+		Value:    token,
+		Path:     "/admin",
+		MaxAge:   int(adminSessionLifetime.Seconds()),
+		HttpOnly: true,
+		Secure:   secureRequest(r),
+		SameSite: http.SameSiteStrictMode,
+	})
+	logging.FromContext(r.Context()).Info("Signed in to the dashboard", "api_key", key.Name)
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// handleAdminLogout handles POST requests to /admin/logout, revoking the browser's
+// dashboard session so its token no longer signs anyone in
+func (s *Server) handleAdminLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(adminSessionCookie); err == nil {
+		if err := s.links.DeleteAdminSession(r.Context(), adminSessionHash(cookie.Value)); err != nil {
+			logging.FromContext(r.Context()).Error("Error revoking dashboard session", "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: adminSessionCookie, Path: "/admin", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteStrictMode})
+	http.SetCookie(w, &http.Cookie{Name: oidcSessionCookie, Path: "/admin", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteLaxMode})
+	http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+}
+
+// handleAdminLinks handles the /admin dashboard page, listing stored links newest first
+// with the same filters as /payment-links
+func (s *Server) handleAdminLinks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page := AdminLinksPage{
+		AdminPage: newAdminPage(r, "Payment links"),
+		Statuses:  []string{store.LinkStatusActive, store.LinkStatusPaid, store.LinkStatusInactive, store.LinkStatusExpired},
+		Status:    strings.ToUpper(strings.TrimSpace(query.Get("status"))),
+		Reference: strings.TrimSpace(query.Get("reference")),
+		Currency:  strings.ToUpper(strings.TrimSpace(query.Get("currency"))),
+		Tag:       strings.TrimSpace(query.Get("tag")),
+		From:      query.Get("from"),
+		To:        query.Get("to"),
+	}
+	filter := store.LinkFilter{Reference: page.Reference, Status: page.Status, Currency: page.Currency, Limit: defaultListLimit}
+
+	var problems []string
+	if page.Tag != "" {
+		metadata, err := parseTagFilters([]string{page.Tag})
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		filter.Metadata = metadata
+	}
+	if page.From != "" {
+		from, err := parseDateParam(page.From, false)
+		if err != nil {
+			problems = append(problems, "From must be a date such as 2025-01-31.")
+		}
+		filter.CreatedFrom = from
+	}
+	if page.To != "" {
+		to, err := parseDateParam(page.To, true)
+		if err != nil {
+			problems = append(problems, "To must be a date such as 2025-01-31.")
+		}
+		filter.CreatedTo = to
+	}
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := store.DecodeLinkCursor(value)
+		if err != nil {
+			problems = append(problems, "The page requested is not valid; showing the first page.")
+		}
+		filter.After = cursor
+		page.Paged = cursor != nil
+	}
+	if len(problems) > 0 {
+		page.Error = strings.Join(problems, " ")
+		renderAdmin(w, r, http.StatusBadRequest, "links", page)
+		return
+	}
+
+	links, pagination, err := s.listLinkPage(r.Context(), filter)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing payment links", "error", err)
+		page.Error = "Stored payment links could not be read."
+		renderAdmin(w, r, http.StatusInternalServerError, "links", page)
+		return
+	}
+	page.Links = links
+	if pagination.HasMore {
+		next := url.Values{}
+		for name, values := range query {
+			if name != "cursor" && name != "notice" {
+				next[name] = values
+			}
+		}
+		next.Set("cursor", pagination.NextCursor)
+		page.NextPage = "/admin?" + next.Encode()
+	}
+	renderAdmin(w, r, http.StatusOK, "links", page)
+}
+
+// handleAdminLink handles the /admin/links/{id} dashboard page, showing a stored link with
+// its transactions from GP API and its SMS deliveries
+func (s *Server) handleAdminLink(w http.ResponseWriter, r *http.Request) {
+	linkID := r.PathValue("id")
+	page := AdminLinkPage{AdminPage: newAdminPage(r, "Payment link "+linkID), LinkID: linkID}
+	if !linkIDPattern.MatchString(linkID) {
+		page.Error = "That is not a valid payment link ID."
+		renderAdmin(w, r, http.StatusBadRequest, "link", page)
+		return
+	}
+
+	link, err := s.links.GetLink(r.Context(), linkID)
+	if err != nil && !errors.Is(err, store.ErrLinkNotFound) {
+		logging.FromContext(r.Context()).Error("Error reading payment link", "link_id", linkID, "error", err)
+		page.Error = "The stored payment link could not be read."
+		renderAdmin(w, r, http.StatusInternalServerError, "link", page)
+		return
+	}
+	page.Link = link
+
+	detail, err := s.gp.GetLink(r.Context(), linkID)
+	if err != nil {
+		status, info := gpErrorInfo(err)
+		if link == nil && status == http.StatusNotFound {
+			page.Error = "No payment link has that ID."
+			renderAdmin(w, r, http.StatusNotFound, "link", page)
+			return
+		}
+		logging.FromContext(r.Context()).Warn("Error looking up payment link for the dashboard", "link_id", linkID, "error", err)
+		page.GPError = info.Details
+	} else {
+		page.GPStatus = detail.Status
+		page.Name = detail.Name
+		page.UsageMode = detail.UsageMode
+		page.UsageCount, _ = detail.UsageCount.Int64()
+		page.UsageLimit, _ = detail.UsageLimit.Int64()
+		for _, transaction := range detail.Transactions.TransactionList {
+			page.Transactions = append(page.Transactions, newTransactionSummary(transaction))
+		}
+	}
+
+	if link != nil {
+		if page.Deliveries, err = s.links.ListDeliveries(r.Context(), linkID); err != nil {
+			logging.FromContext(r.Context()).Error("Error listing deliveries", "link_id", linkID, "error", err)
+		}
+		if page.Views, err = s.links.ListLinkViews(r.Context(), linkID, defaultListLimit); err != nil {
+			logging.FromContext(r.Context()).Error("Error listing link views", "link_id", linkID, "error", err)
+		}
+		page.ShortLink = s.shortLinkURL(link.ShortCode)
+		page.CanResend = s.sms != nil && link.CustomerPhone != "" && link.Status == store.LinkStatusActive
+	}
+	page.CanCancel = (link != nil && link.Status == store.LinkStatusActive) || strings.EqualFold(page.GPStatus, store.LinkStatusActive)
+	page.CanResend = page.CanResend && permitted(r.Context(), config.PermissionCreate)
+	page.CanCancel = page.CanCancel && permitted(r.Context(), config.PermissionCancel)
+	renderAdmin(w, r, http.StatusOK, "link", page)
+}
+
+// handleAdminCancelLink handles POST requests to /admin/links/{id}/cancel
+func (s *Server) handleAdminCancelLink(w http.ResponseWriter, r *http.Request) {
+	linkID := r.PathValue("id")
+	if !allowAdminAction(r, linkID, config.PermissionCancel) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	notice := "cancelled"
+	if _, err := s.CancelLink(r.Context(), linkID); err != nil {
+		logging.FromContext(r.Context()).Warn("Error cancelling payment link from the dashboard", "link_id", linkID, "error", err)
+		notice = "cancel_failed"
+	} else {
+		logging.FromContext(r.Context()).Info("Payment link cancelled from the dashboard", "link_id", linkID, "user", adminIdentityFrom(r.Context()).Name)
+	}
+	http.Redirect(w, r, "/admin/links/"+linkID+"?notice="+notice, http.StatusSeeOther)
+}
+
+// handleAdminResendLink handles POST requests to /admin/links/{id}/resend, sending the
+// link again by SMS to the customer phone stored with it
+func (s *Server) handleAdminResendLink(w http.ResponseWriter, r *http.Request) {
+	linkID := r.PathValue("id")
+	if !allowAdminAction(r, linkID, config.PermissionCreate) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	notice := "sent"
+	link, err := s.links.GetLink(r.Context(), linkID)
+	switch {
+	case err != nil:
+		logging.FromContext(r.Context()).Warn("Error reading payment link to resend", "link_id", linkID, "error", err)
+		notice = "send_failed"
+	case s.sms == nil || link.CustomerPhone == "":
+		notice = "send_failed"
+	default:
+		if _, err := s.sendLinkSMS(r.Context(), link, link.CustomerPhone, linkSMSBody(link)); err != nil {
+			logging.FromContext(r.Context()).Warn("Error resending payment link from the dashboard", "link_id", linkID, "error", err)
+			notice = "send_failed"
+		}
+	}
+	http.Redirect(w, r, "/admin/links/"+linkID+"?notice="+notice, http.StatusSeeOther)
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+)
+
+func TestAdminSession(t *testing.T) {
+	keys := config.APIKeys{Burst: 10, Keys: []config.APIKey{
+		{Name: "shop", Key: "shop-secret", RatePerMinute: 600, Permissions: config.AllPermissions},
+		{Name: "billing", Key: "billing-secret", RatePerMinute: 600, Permissions: []config.Permission{config.PermissionCreate}},
+	}}
+	handler := New(newTestConfig(keys), &fakeGP{}, newTestStore(t), nil, http.DefaultClient).Handler()
+
+	serve := func(req *http.Request, cookie *http.Cookie) *httptest.ResponseRecorder {
+		t.Helper()
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	login := func(apiKey, origin string) *httptest.ResponseRecorder {
+		t.Helper()
+		form := url.Values{"apiKey": {apiKey}, "next": {"/admin/links/LNK_1"}}
+		req := httptest.NewRequest(http.MethodPost, "/admin/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Origin", origin)
+		return serve(req, nil)
+	}
+	sessionCookie := func(rec *httptest.ResponseRecorder) *http.Cookie {
+		t.Helper()
+		for _, cookie := range rec.Result().Cookies() {
+			if cookie.Name == adminSessionCookie {
+				return cookie
+			}
+		}
+		t.Fatalf("no %s cookie in %v", adminSessionCookie, rec.Result().Cookies())
+		return nil
+	}
+
+	rec := serve(httptest.NewRequest(http.MethodGet, "/admin?status=ACTIVE", nil), nil)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/admin/login?next=%2Fadmin%3Fstatus%3DACTIVE" {
+		t.Errorf("signed out: got %d to %q, want 303 to the sign-in page", rec.Code, rec.Header().Get("Location"))
+	}
+
+	for _, tt := range []struct{ name, apiKey, origin string }{
+		{name: "unknown key", apiKey: "guess", origin: "http://example.com"},
+		{name: "cross-site form", apiKey: "shop-secret", origin: "https://attacker.example"},
+	} {
+		if rec := login(tt.apiKey, tt.origin); rec.Code != http.StatusUnauthorized || len(rec.Result().Cookies()) != 0 {
+			t.Errorf("%s: got %d with cookies %v, want 401 without a session", tt.name, rec.Code, rec.Result().Cookies())
+		}
+	}
+
+	rec = login("shop-secret", "http://example.com")
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/admin/links/LNK_1" {
+		t.Fatalf("sign-in: got %d to %q, want 303 to the requested page", rec.Code, rec.Header().Get("Location"))
+	}
+	cookie := sessionCookie(rec)
+	if cookie.Value == "shop-secret" || !cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode {
+		t.Errorf("session cookie = %+v, want an HttpOnly, SameSite=Strict random token", cookie)
+	}
+	if rec := serve(httptest.NewRequest(http.MethodGet, "/admin", nil), cookie); rec.Code != http.StatusOK {
+		t.Errorf("signed in: status = %d, want 200", rec.Code)
+	}
+
+	// A key without the read permission signs in, but cannot see the dashboard
+	billing := sessionCookie(login("billing-secret", "http://example.com"))
+	if rec := serve(httptest.NewRequest(http.MethodGet, "/admin", nil), billing); rec.Code != http.StatusForbidden {
+		t.Errorf("without read permission: status = %d, want 403", rec.Code)
+	}
+
+	// Signing out revokes the session, so its token no longer signs anyone in
+	if rec := serve(httptest.NewRequest(http.MethodPost, "/admin/logout", nil), cookie); rec.Code != http.StatusSeeOther {
+		t.Fatalf("sign-out: status = %d, want 303", rec.Code)
+	}
+	if rec := serve(httptest.NewRequest(http.MethodGet, "/admin", nil), cookie); rec.Code != http.StatusSeeOther {
+		t.Errorf("after sign-out: status = %d, want 303 to the sign-in page", rec.Code)
+	}
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/eventbus"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/webhooks"
+)
+
+// DeadLetter is an event that could not be delivered to a webhook endpoint, or published
+// to the event publisher, with the payload that was sent
+type DeadLetter struct {
+	ID        int64  `json:"id"`
+	EventID   string `json:"eventId"`
+	EventType string `json:"eventType"`
+	// URL is the webhook endpoint, or "kafka" or "nats" for the event publisher
+	URL       string          `json:"url"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"lastError"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// newDeadLetter describes a stored dead letter, showing its payload as JSON
+func newDeadLetter(letter *store.WebhookDeadLetter) DeadLetter {
+	payload := json.RawMessage(letter.Payload)
+	if !json.Valid(payload) {
+		// Payloads are always written as JSON, but one edited by hand is shown as a string
+		payload, _ = json.Marshal(letter.Payload)
+	}
+	return DeadLetter{
+		ID:        letter.ID,
+		EventID:   letter.EventID,
+		EventType: letter.EventType,
+		URL:       letter.URL,
+		Payload:   payload,
+		Attempts:  letter.Attempts,
+		LastError: letter.LastError,
+		CreatedAt: letter.CreatedAt,
+	}
+}
+
+// handleAdminDeadLetters handles GET requests to /admin/deadletters, listing the events
+// that could not be delivered, newest first
+func (s *Server) handleAdminDeadLetters(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := store.DeadLetterFilter{
+		EventType: strings.TrimSpace(query.Get("eventType")),
+		URL:       strings.TrimSpace(query.Get("url")),
+		Limit:     defaultListLimit,
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			writeErrorf(w, http.StatusBadRequest, "Dead letter listing failed", "INVALID_LIMIT",
+				"limit must be between 1 and %d", maxListLimit)
+			return
+		}
+		filter.Limit = limit
+	}
+	if value := query.Get("cursor"); value != "" {
+		before, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || before < 1 {
+			writeError(w, http.StatusBadRequest, "Dead letter listing failed", "INVALID_CURSOR", "invalid cursor")
+			return
+		}
+		filter.BeforeID = before
+	}
+
+	// Fetch one extra dead letter to find out whether another page exists
+	pageFilter := filter
+	pageFilter.Limit = filter.Limit + 1
+	letters, err := s.links.ListWebhookDeadLetters(r.Context(), pageFilter)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing webhook dead letters", "error", err)
+		writeError(w, http.StatusInternalServerError, "Dead letter listing failed", "STORE_ERROR", "Error reading dead letters")
+		return
+	}
+	pagination := &Pagination{Limit: filter.Limit}
+	if len(letters) > filter.Limit {
+		letters = letters[:filter.Limit]
+		pagination.HasMore = true
+		pagination.NextCursor = strconv.FormatInt(letters[len(letters)-1].ID, 10)
+	}
+
+	data := make([]DeadLetter, len(letters))
+	for i, letter := range letters {
+		data[i] = newDeadLetter(letter)
+	}
+	writeJSON(w, http.StatusOK, Response{
+		Success:    true,
+		Message:    fmt.Sprintf("Found %d dead letters", len(data)),
+		Data:       data,
+		Pagination: pagination,
+	})
+}
+
+// handleAdminRetryDeadLetter handles POST requests to /admin/deadletters/{id}/retry,
+// sending a dead letter's payload once more to where it failed, under its original event
+// ID. A delivered dead letter is deleted; one that fails again is kept with the new error.
+func (s *Server) handleAdminRetryDeadLetter(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		writeError(w, http.StatusBadRequest, "Redelivery failed", "INVALID_DEAD_LETTER_ID", "Invalid dead letter ID")
+		return
+	}
+
+	letter, err := s.links.GetWebhookDeadLetter(ctx, id)
+	if errors.Is(err, store.ErrDeadLetterNotFound) {
+		writeError(w, http.StatusNotFound, "Redelivery failed", "DEAD_LETTER_NOT_FOUND", "Dead letter not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error reading webhook dead letter", "dead_letter_id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "Redelivery failed", "STORE_ERROR", "Error reading dead letter")
+		return
+	}
+
+	// Events the publisher rejected are dead-lettered under its driver name
+	var deliveryErr error
+	switch letter.URL {
+	case config.EventPublisherKafka, config.EventPublisherNATS:
+		deliveryErr = s.bus.Redeliver(ctx, letter)
+	default:
+		deliveryErr = s.events.Redeliver(letter)
+	}
+	logger := logging.FromContext(ctx).With("dead_letter_id", id, "event_id", letter.EventID, "url", letter.URL)
+	if errors.Is(deliveryErr, webhooks.ErrEndpointNotConfigured) || errors.Is(deliveryErr, eventbus.ErrPublisherNotConfigured) {
+		writeError(w, http.StatusConflict, "Redelivery failed", "DESTINATION_NOT_CONFIGURED",
+			fmt.Sprintf("%s is not a configured webhook endpoint or event publisher", letter.URL))
+		return
+	}
+
+	letter.Attempts++
+	s.audit(ctx, store.AuditEntry{Action: auditDeadLetterRetry, Resource: strconv.FormatInt(id, 10)}, map[string]interface{}{
+		"eventId":   letter.EventID,
+		"url":       letter.URL,
+		"delivered": deliveryErr == nil,
+	})
+	if deliveryErr != nil {
+		letter.LastError = deliveryErr.Error()
+		if err := s.links.RecordWebhookDeadLetterRetry(ctx, id, letter.LastError); err != nil {
+			logger.Error("Error recording webhook dead letter retry", "error", err)
+		}
+		logger.Warn("Dead letter redelivery failed", "error", deliveryErr)
+		writeError(w, http.StatusBadGateway, "Redelivery failed", "REDELIVERY_FAILED", letter.LastError)
+		return
+	}
+
+	if err := s.links.DeleteWebhookDeadLetter(ctx, id); err != nil && !errors.Is(err, store.ErrDeadLetterNotFound) {
+		// The event was delivered, so report success and surface the local failure in logs
+		logger.Error("Error deleting redelivered webhook dead letter", "error", err)
+	}
+	logger.Info("Dead letter redelivered")
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Dead letter redelivered",
+		Data:    newDeadLetter(letter),
+	})
+}
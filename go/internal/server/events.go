@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/globalpayments/pay-by-link-go/internal/eventbus"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/webhooks"
+)
+
+// eventForStatus returns the event announcing that a link moved to status,
+// or "" when the status change is not announced
+func eventForStatus(status string) string {
+	switch status {
+	case store.LinkStatusPaid:
+		return webhooks.EventLinkPaid
+	case store.LinkStatusExpired:
+		return webhooks.EventLinkExpired
+	case store.LinkStatusInactive:
+		return webhooks.EventLinkCancelled
+	default:
+		return ""
+	}
+}
+
+// SetEventPublisher publishes link events, and the views of shortlinks, to a message broker
+// with publisher, naming source as their origin. It must be called before the server starts.
+func (s *Server) SetEventPublisher(publisher eventbus.EventPublisher, source string) {
+	s.bus = eventbus.NewBus(publisher, source, s.links)
+}
+
+// emitLinkEvent sends one event to every /events client, the merchant's webhook endpoints,
+// and the event publisher, under the same event ID, and posts it to Slack. done is called
+// with true once the webhook endpoints and the event publisher have recorded the event,
+// or with false when either could not.
+func (s *Server) emitLinkEvent(event webhooks.Event, done func(recorded bool)) {
+	s.stream.publish(event)
+	s.notifySlack(event.Type, event.Data)
+
+	var mu sync.Mutex
+	pending, recorded := 2, true
+	finish := func(ok bool) {
+		mu.Lock()
+		pending--
+		recorded = recorded && ok
+		last := pending == 0
+		mu.Unlock()
+		if last {
+			done(recorded)
+		}
+	}
+	s.events.Publish(event, finish)
+	s.bus.Publish(event, finish)
+}
+
+// notifySlack posts a created or paid link to Slack when it is configured. Open-amount
+// links are announced as paid with the amount the customer chose, from their receipt.
+func (s *Server) notifySlack(eventType string, link *store.Link) {
+	if !s.slack.Wants(eventType) {
+		return
+	}
+	if eventType == webhooks.EventLinkPaid && link.Amount == 0 {
+		receipt, err := s.links.GetReceipt(context.Background(), link.ID)
+		if err == nil && receipt.Amount > 0 {
+			paid := *link
+			paid.Amount, paid.Currency = receipt.Amount, receipt.Currency
+			link = &paid
+		}
+	}
+	s.slack.Publish(eventType, link)
+}
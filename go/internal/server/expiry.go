@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/webhooks"
+)
+
+// expireLinks marks links still stored as active after their expiry date as expired, optionally
+// deactivating them in GP API first
+func (s *Server) expireLinks(ctx context.Context) {
+	now := time.Now()
+	expired := 0
+
+	filter := store.LinkFilter{Status: store.LinkStatusActive, ExpiresBefore: now, Limit: maxListLimit}
+	for {
+		links, err := s.links.ListLinks(ctx, filter)
+		if err != nil {
+			slog.Error("Error listing expired links", "error", err)
+			return
+		}
+
+		for _, link := range links {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := s.expireLink(ctx, link); err != nil {
+				slog.Warn("Error expiring link", "link_id", link.ID, "error", err)
+				continue
+			}
+			expired++
+		}
+
+		if len(links) < filter.Limit {
+			break
+		}
+		last := links[len(links)-1]
+		filter.After = &store.LinkCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	if expired > 0 {
+		slog.Info("Expired links", "count", expired)
+	}
+}
+
+// expireLink marks one link as expired and announces it. When GP API cannot be reached to
+// deactivate the link it is left active, so it is tried again on the next run; a link GP
+// refuses to deactivate, such as one it already expired, is expired locally regardless.
+func (s *Server) expireLink(ctx context.Context, link *store.Link) error {
+	if s.expiry.DeactivateAtGP {
+		gpCtx, cancel := context.WithTimeout(ctx, reconcileLinkTimeout)
+		_, err := s.gp.UpdateLink(gpCtx, link.ID, gpapi.LinkStatusUpdate{Status: store.LinkStatusInactive})
+		cancel()
+		var apiErr *gpapi.APIError
+		if err != nil && !(errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError) {
+			return err
+		}
+		if err != nil {
+			slog.Warn("GP API did not deactivate expired link", "link_id", link.ID, "error", err)
+		}
+	}
+
+	if err := s.links.UpdateStatus(ctx, link.ID, store.LinkStatusExpired, newOutboxEvent(webhooks.EventLinkExpired)); err != nil {
+		return err
+	}
+	s.wakeOutboxRelay()
+	return nil
+}
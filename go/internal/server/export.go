@@ -0,0 +1,264 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/spreadsheet"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/xlsx"
+)
+
+// Link export formats
+const (
+	exportCSV  = "csv"
+	exportXLSX = "xlsx"
+)
+
+// exportPageSize is how many links are read from the store, and written to the client,
+// at a time during an export
+const exportPageSize = 500
+
+// linkExportColumns names the columns of a link export, in the order linkExportRow fills them
+var linkExportColumns = []string{
+	"Link ID", "Reference", "Status", "Amount", "Currency", "Discount", "Tax", "Promo Code",
+	"Transaction ID", "Transaction Status", "Customer ID", "Customer Name", "Customer Email",
+	"Customer Phone", "Payment Link", "Short Link", "Created", "Expires", "Updated", "Metadata",
+}
+
+// linkExporter writes the rows of a link export in one file format. Cells are strings,
+// xlsx.Numbers, time.Times, or nil.
+type linkExporter interface {
+	writeHeader(columns []string) error
+	writeRow(cells []interface{}) error
+	// flush sends the rows written so far on to the response
+	flush() error
+	// close finishes the file
+	close() error
+}
+
+// newLinkExporter returns an exporter for format writing to w
+func newLinkExporter(format string, w io.Writer) (linkExporter, error) {
+	if format == exportXLSX {
+		workbook, err := xlsx.NewWriter(w, "Payment links")
+		if err != nil {
+			return nil, err
+		}
+		return xlsxExporter{workbook}, nil
+	}
+	return csvExporter{csv.NewWriter(w)}, nil
+}
+
+// csvExporter writes a link export as CSV, with times in RFC3339
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func (e csvExporter) writeHeader(columns []string) error {
+	return e.w.Write(columns)
+}
+
+func (e csvExporter) writeRow(cells []interface{}) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		switch v := cell.(type) {
+		case string:
+			record[i] = spreadsheet.Text(v)
+		case xlsx.Number:
+			record[i] = string(v)
+		case time.Time:
+			if !v.IsZero() {
+				record[i] = v.UTC().Format(time.RFC3339)
+			}
+		}
+	}
+	return e.w.Write(record)
+}
+
+func (e csvExporter) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e csvExporter) close() error {
+	return e.flush()
+}
+
+// xlsxExporter writes a link export as an Excel workbook
+type xlsxExporter struct {
+	w *xlsx.Writer
+}
+
+func (e xlsxExporter) writeHeader(columns []string) error {
+	return e.w.WriteHeader(columns...)
+}
+
+func (e xlsxExporter) writeRow(cells []interface{}) error {
+	return e.w.WriteRow(cells...)
+}
+
+func (e xlsxExporter) flush() error {
+	return e.w.Flush()
+}
+
+func (e xlsxExporter) close() error {
+	return e.w.Close()
+}
+
+// exportAmount returns an amount in minor units as a number in major units, or nil for
+// an optional amount that is zero
+func exportAmount(minor int64, currency string, optional bool) interface{} {
+	if optional && minor == 0 {
+		return nil
+	}
+	return xlsx.Number(money.FormatMinorUnits(minor, currency))
+}
+
+// linkExportRow returns a link's cells in the order of linkExportColumns. The customer
+// is the link's associated customer, or nil when it has none.
+func (s *Server) linkExportRow(link *store.Link, customer *store.Customer) []interface{} {
+	// Open-amount links have no amount until the customer chooses one
+	openAmount := link.MinAmount > 0 || link.MaxAmount > 0
+	var name, email string
+	phone := link.CustomerPhone
+	if customer != nil {
+		name, email = customer.Name, customer.Email
+		if phone == "" {
+			phone = customer.Phone
+		}
+	}
+	var metadata string
+	if len(link.Metadata) > 0 {
+		// Metadata is a map of strings, which always encodes
+		encoded, _ := json.Marshal(link.Metadata)
+		metadata = string(encoded)
+	}
+
+	return []interface{}{
+		link.ID, link.Reference, link.Status,
+		exportAmount(link.Amount, link.Currency, openAmount), link.Currency,
+		exportAmount(link.DiscountAmount, link.Currency, true),
+		exportAmount(link.TaxAmount, link.Currency, true),
+		link.PromoCode, link.TransactionID, link.TransactionStatus,
+		link.CustomerID, name, email, phone,
+		link.URL, s.shortLinkURL(link.ShortCode),
+		link.CreatedAt, link.ExpiresAt, link.UpdatedAt, metadata,
+	}
+}
+
+// exportCustomer returns the customer with the given ID for a link export, or nil when
+// there is none. Customers are cached for the export, as many links may share one.
+func (s *Server) exportCustomer(ctx context.Context, cache map[string]*store.Customer, id string) *store.Customer {
+	if id == "" {
+		return nil
+	}
+	if customer, ok := cache[id]; ok {
+		return customer
+	}
+	customer, err := s.links.GetCustomer(ctx, id)
+	if err != nil && !errors.Is(err, store.ErrCustomerNotFound) {
+		logging.FromContext(ctx).Warn("Error reading customer for export", "customer_id", id, "error", err)
+	}
+	cache[id] = customer
+	return customer
+}
+
+// handleExportPaymentLinks handles GET requests to /payment-links/export, sending the links
+// matching the listing's filters as a CSV or Excel download. Links are read and written a
+// page at a time, so an export of any size is never held in memory.
+func (s *Server) handleExportPaymentLinks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	format := strings.ToLower(strings.TrimSpace(query.Get("format")))
+	if format == "" {
+		format = exportCSV
+	}
+	if format != exportCSV && format != exportXLSX {
+		writeError(w, http.StatusBadRequest, "Payment link export failed", "INVALID_FORMAT", "format must be csv or xlsx")
+		return
+	}
+	filter, invalid := linkFilterFromQuery(query)
+	if invalid != nil {
+		writeError(w, http.StatusBadRequest, "Payment link export failed", invalid.Code, invalid.Details)
+		return
+	}
+	filter.Limit = exportPageSize
+
+	// The first page is read before anything is sent, so a failure can still be reported
+	links, err := s.links.ListLinks(ctx, filter)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error listing payment links for export", "error", err)
+		writeError(w, http.StatusInternalServerError, "Payment link export failed", "STORE_ERROR", "Error reading stored payment links")
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == exportXLSX {
+		contentType = xlsx.ContentType
+	}
+	filename := fmt.Sprintf("payment-links-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	// Once the download has started its status cannot change, so a failure part way
+	// through aborts the response and the client sees an incomplete download
+	abort := func(message string, err error) {
+		if ctx.Err() == nil {
+			logging.FromContext(ctx).Error(message, "format", format, "error", err)
+		}
+		panic(http.ErrAbortHandler)
+	}
+	exporter, err := newLinkExporter(format, w)
+	if err != nil {
+		abort("Error starting payment link export", err)
+	}
+	if err := exporter.writeHeader(linkExportColumns); err != nil {
+		abort("Error writing payment link export", err)
+	}
+
+	controller := http.NewResponseController(w)
+	customers := map[string]*store.Customer{}
+	rows := 0
+	for {
+		// The export may outlast the server's write timeout, so each page gets its own
+		// and only a client that stops reading is cut off
+		if err := controller.SetWriteDeadline(time.Now().Add(s.limits.RequestTimeout)); err != nil && rows == 0 {
+			logging.FromContext(ctx).Warn("Payment link export cannot extend the write deadline", "error", err)
+		}
+		for _, link := range links {
+			if err := exporter.writeRow(s.linkExportRow(link, s.exportCustomer(ctx, customers, link.CustomerID))); err != nil {
+				abort("Error writing payment link export", err)
+			}
+		}
+		rows += len(links)
+		if err := exporter.flush(); err != nil {
+			abort("Error writing payment link export", err)
+		}
+		controller.Flush()
+
+		if len(links) < filter.Limit {
+			break
+		}
+		last := links[len(links)-1]
+		filter.After = &store.LinkCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		if links, err = s.links.ListLinks(ctx, filter); err != nil {
+			abort("Error listing payment links for export", err)
+		}
+	}
+	if err := exporter.close(); err != nil {
+		abort("Error finishing payment link export", err)
+	}
+
+	logging.FromContext(ctx).Info("Payment links exported", "format", format, "links", rows, "api_key", apiKeyNameFrom(ctx))
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/xlsx"
+)
+
+func TestExportPaymentLinks(t *testing.T) {
+	ctx := context.Background()
+	links := newTestStore(t)
+	handler := New(newTestConfig(config.APIKeys{AllowUnauthenticated: true}), &fakeGP{}, links, nil, http.DefaultClient).Handler()
+
+	customer := &store.Customer{ID: newCustomerID(), Name: `=HYPERLINK("https://attacker.example")`, Email: "ada@example.com"}
+	if err := links.CreateCustomer(ctx, customer); err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	// More links than fit on a page, in pairs created at the same time, so the export has
+	// to page by ID as well as creation time
+	created := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	for i := 0; i <= exportPageSize; i++ {
+		link := &store.Link{ID: fmt.Sprintf("LNK_%04d", i), URL: "https://pay.example/" + fmt.Sprint(i), Reference: fmt.Sprintf("INV-%d", i),
+			Amount: 1050, Currency: "USD", Status: store.LinkStatusActive,
+			CreatedAt: created.Add(time.Duration(i/2) * time.Second), ExpiresAt: created.Add(24 * time.Hour)}
+		if i == 0 {
+			link.Status = store.LinkStatusPaid
+			link.CustomerID = customer.ID
+			link.Metadata = map[string]string{"orderId": "1001"}
+		}
+		if err := links.CreateLink(ctx, link, nil); err != nil {
+			t.Fatalf("CreateLink: %v", err)
+		}
+	}
+
+	export := func(query string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/payment-links/export"+query, nil))
+		return rec
+	}
+
+	rec := export("")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv; charset=utf-8" ||
+		!strings.HasPrefix(rec.Header().Get("Content-Disposition"), `attachment; filename="payment-links-`) {
+		t.Fatalf("csv: got %d %v, want a CSV download", rec.Code, rec.Header())
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("reading csv: %v", err)
+	}
+	if !slices.Equal(records[0], linkExportColumns) {
+		t.Errorf("header = %v, want %v", records[0], linkExportColumns)
+	}
+	column := func(name string) int { return slices.Index(linkExportColumns, name) }
+	ids := map[string]bool{}
+	for _, record := range records[1:] {
+		ids[record[column("Link ID")]] = true
+		if record[column("Link ID")] != "LNK_0000" {
+			continue
+		}
+		want := map[string]string{
+			"Status": store.LinkStatusPaid, "Amount": "10.50", "Discount": "", "Customer Email": "ada@example.com",
+			// Text a spreadsheet would run as a formula is exported as text
+			"Customer Name": `'=HYPERLINK("https://attacker.example")`, "Metadata": `{"orderId":"1001"}`,
+		}
+		for name, value := range want {
+			if got := record[column(name)]; got != value {
+				t.Errorf("LNK_0000 %s = %q, want %q", name, got, value)
+			}
+		}
+	}
+	if len(records) != exportPageSize+2 || len(ids) != exportPageSize+1 {
+		t.Errorf("csv has %d rows of %d links, want %d of each", len(records)-1, len(ids), exportPageSize+1)
+	}
+
+	rec = export("?format=xlsx&status=paid")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != xlsx.ContentType {
+		t.Fatalf("xlsx: got %d %v, want an Excel download", rec.Code, rec.Header())
+	}
+	workbook, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("opening workbook: %v", err)
+	}
+	sheet, err := workbook.Open("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatalf("opening worksheet: %v", err)
+	}
+	defer sheet.Close()
+	data, err := io.ReadAll(sheet)
+	if err != nil {
+		t.Fatalf("reading worksheet: %v", err)
+	}
+	if !strings.Contains(string(data), "LNK_0000") || strings.Contains(string(data), "LNK_0001") {
+		t.Errorf("worksheet = %s, want only the paid link", data)
+	}
+
+	if rec := export("?format=pdf"); rec.Code != http.StatusBadRequest || errorCode(decodeResponse(t, rec)) != "INVALID_FORMAT" {
+		t.Errorf("unknown format: got %d %s, want 400 INVALID_FORMAT", rec.Code, rec.Body.String())
+	}
+}
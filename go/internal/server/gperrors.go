@@ -0,0 +1,93 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+)
+
+// gpErrorRule maps GP API errors to the error code and HTTP status this server reports for
+// them. A rule matches when GP's error code is one of ErrorCodes, if any are listed, and
+// GP's description mentions one of Mentions, if any are listed.
+type gpErrorRule struct {
+	ErrorCodes []string
+	Mentions   []string
+	Status     int
+	Code       string
+}
+
+// gpErrorTaxonomy lists the rules for GP API errors in the order they are tried. GP reports
+// most rejected values as INVALID_REQUEST_DATA and names the offending field in its
+// description, so those are told apart by the field mentioned.
+var gpErrorTaxonomy = []gpErrorRule{
+	{ErrorCodes: []string{"NOT_AUTHENTICATED", "ACTION_NOT_AUTHORIZED"}, Status: http.StatusBadGateway, Code: "GP_AUTHENTICATION_FAILED"},
+	{ErrorCodes: []string{"DUPLICATE_ACTION"}, Status: http.StatusConflict, Code: "DUPLICATE_REFERENCE"},
+	{ErrorCodes: []string{"INVALID_REQUEST_DATA"}, Mentions: []string{"already exists", "already been used", "duplicate"}, Status: http.StatusConflict, Code: "DUPLICATE_REFERENCE"},
+	{ErrorCodes: []string{"INVALID_REQUEST_DATA", "MANDATORY_DATA_MISSING", "RESOURCE_NOT_FOUND"},
+		Mentions: []string{"account", "merchant"}, Status: http.StatusUnprocessableEntity, Code: "INVALID_ACCOUNT"},
+	{ErrorCodes: []string{"INVALID_REQUEST_DATA"}, Mentions: []string{"expiration_date", "expiry"}, Status: http.StatusBadRequest, Code: "EXPIRY_INVALID"},
+	{ErrorCodes: []string{"INVALID_REQUEST_DATA"}, Mentions: []string{"currency"}, Status: http.StatusBadRequest, Code: "CURRENCY_NOT_SUPPORTED"},
+	{ErrorCodes: []string{"INVALID_REQUEST_DATA"}, Mentions: []string{"amount"}, Status: http.StatusBadRequest, Code: "AMOUNT_INVALID"},
+	{ErrorCodes: []string{"INVALID_REQUEST_DATA"}, Mentions: []string{"payment_method", "allowed_payment_methods"}, Status: http.StatusBadRequest, Code: "PAYMENT_METHOD_NOT_SUPPORTED"},
+	{ErrorCodes: []string{"RESOURCE_NOT_FOUND"}, Status: http.StatusNotFound, Code: "RESOURCE_NOT_FOUND"},
+	{ErrorCodes: []string{"INVALID_REQUEST_DATA", "MANDATORY_DATA_MISSING"}, Status: http.StatusBadRequest, Code: "GP_REQUEST_REJECTED"},
+	{ErrorCodes: []string{"SYSTEM_ERROR", "SYSTEM_ERROR_DOWNSTREAM"}, Status: http.StatusBadGateway, Code: "GP_UNAVAILABLE"},
+}
+
+// matches reports whether the rule applies to apiErr
+func (rule gpErrorRule) matches(apiErr *gpapi.APIError) bool {
+	if len(rule.ErrorCodes) > 0 && !containsFold(rule.ErrorCodes, apiErr.ErrorCode) {
+		return false
+	}
+	if len(rule.Mentions) == 0 {
+		return true
+	}
+	description := strings.ToLower(apiErr.Message)
+	for _, mention := range rule.Mentions {
+		if strings.Contains(description, mention) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyGPError maps a failed GP API call to a stable error code and HTTP status using
+// gpErrorTaxonomy. GP's own error codes and request ID are reported alongside for support.
+// Errors GP did not describe, and failed token requests, get generic codes.
+func classifyGPError(err error) (int, *ErrorInfo) {
+	var tokenErr *gpapi.TokenError
+	if errors.As(err, &tokenErr) {
+		return http.StatusInternalServerError, &ErrorInfo{Code: "TOKEN_GENERATION_ERROR", Details: tokenErr.Err.Error()}
+	}
+
+	var apiErr *gpapi.APIError
+	if !errors.As(err, &apiErr) {
+		return http.StatusBadGateway, &ErrorInfo{Code: "API_ERROR", Details: err.Error()}
+	}
+	status, info := http.StatusBadGateway, &ErrorInfo{Code: "API_ERROR", Details: apiErr.Message}
+	for _, rule := range gpErrorTaxonomy {
+		if rule.matches(apiErr) {
+			status, info.Code = rule.Status, rule.Code
+			break
+		}
+	}
+	if info.Code == "API_ERROR" && apiErr.StatusCode >= http.StatusInternalServerError {
+		info.Code = "GP_UNAVAILABLE"
+	}
+	info.GPRequestID = apiErr.RequestID
+	info.GPErrorCode = apiErr.ErrorCode
+	info.GPDetailedErrorCode = apiErr.DetailedErrorCode
+	return status, info
+}
@@ -0,0 +1,599 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/locale"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// graphQLRequest is the body of a POST /graphql request
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphQLError is a resolver error reported with the same code and details as the REST endpoints
+type graphQLError struct {
+	info       *ErrorInfo
+	retryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *graphQLError) Error() string {
+	return e.info.Details
+}
+
+// Extensions adds the error code, any field errors, and any allowed values to the GraphQL error
+func (e *graphQLError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.info.Code}
+	if e.info.GPRequestID != "" {
+		extensions["gpRequestId"] = e.info.GPRequestID
+	}
+	if e.info.GPErrorCode != "" {
+		extensions["gpErrorCode"] = e.info.GPErrorCode
+		extensions["gpDetailedErrorCode"] = e.info.GPDetailedErrorCode
+	}
+	if len(e.info.Fields) > 0 {
+		extensions["fields"] = e.info.Fields
+	}
+	if len(e.info.Allowed) > 0 {
+		extensions["allowed"] = e.info.Allowed
+	}
+	if e.retryAfter > 0 {
+		extensions["retryAfter"] = int(math.Ceil(e.retryAfter.Seconds()))
+	}
+	return extensions
+}
+
+// newGraphQLError creates a resolver error with the given code and details
+func newGraphQLError(code, details string) error {
+	return &graphQLError{info: &ErrorInfo{Code: code, Details: details}}
+}
+
+// newGraphQLErrorf creates a resolver error whose details are the catalog message with the
+// given key and values
+func newGraphQLErrorf(code, key string, args ...interface{}) error {
+	details := locale.Messagef(key, args...)
+	return &graphQLError{info: &ErrorInfo{Code: code, Details: details.String(), catalogDetails: details}}
+}
+
+// graphQLErrorInfo returns the error info of a resolver error in a GraphQL result, or nil
+// for errors raised by graphql-go itself, such as a malformed query
+func graphQLErrorInfo(gqlErr gqlerrors.FormattedError) *ErrorInfo {
+	located, ok := gqlErr.OriginalError().(*gqlerrors.Error)
+	if !ok {
+		return nil
+	}
+	resolverErr, ok := located.OriginalError.(*graphQLError)
+	if !ok {
+		return nil
+	}
+	return resolverErr.info
+}
+
+// linkConnection is one page of a paymentLinks query
+type linkConnection struct {
+	Nodes    []*store.Link `json:"nodes"`
+	PageInfo pageInfo      `json:"pageInfo"`
+}
+
+// pageInfo describes where a page of links ends
+type pageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor"`
+}
+
+// cancelledLink is the result of the cancelPaymentLink mutation
+type cancelledLink struct {
+	LinkID string `json:"linkId"`
+	Status string `json:"status"`
+}
+
+// metadataEntry is one key-value pair of a link's metadata; GraphQL has no map type
+type metadataEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// resolveMetadata lists the metadata of a stored or newly created link, ordered by key
+func resolveMetadata(p graphql.ResolveParams) (interface{}, error) {
+	var metadata map[string]string
+	switch link := p.Source.(type) {
+	case *store.Link:
+		metadata = link.Metadata
+	case *PaymentLinkResponse:
+		metadata = link.Metadata
+	}
+	entries := make([]metadataEntry, 0, len(metadata))
+	for _, key := range slices.Sorted(maps.Keys(metadata)) {
+		entries = append(entries, metadataEntry{Key: key, Value: metadata[key]})
+	}
+	return entries, nil
+}
+
+// nonNull wraps t as a non-null type
+func nonNull(t graphql.Output) graphql.Output {
+	return graphql.NewNonNull(t)
+}
+
+// newGraphQLSchema builds the /graphql schema. Resolvers call the same Server methods as the
+// REST endpoints, so both apply identical validation, storage, and events.
+func (s *Server) newGraphQLSchema() graphql.Schema {
+	metadataEntryType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "MetadataEntry",
+		Description: "A key-value pair of a link's metadata",
+		Fields: graphql.Fields{
+			"key":   &graphql.Field{Type: nonNull(graphql.String)},
+			"value": &graphql.Field{Type: nonNull(graphql.String)},
+		},
+	})
+
+	paymentLinkType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "PaymentLink",
+		Description: "A payment link recorded by this server",
+		Fields: graphql.Fields{
+			"linkId":      &graphql.Field{Type: nonNull(graphql.ID)},
+			"paymentLink": &graphql.Field{Type: nonNull(graphql.String), Description: "URL of GP's hosted payment page"},
+			"reference":   &graphql.Field{Type: nonNull(graphql.String)},
+			"amount":      &graphql.Field{Type: nonNull(graphql.Int), Description: "Amount in minor units"},
+			"displayAmount": &graphql.Field{
+				Type:        nonNull(graphql.String),
+				Description: "Amount in major units, e.g. 10.99",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					link := p.Source.(*store.Link)
+					return money.FormatMinorUnits(link.Amount, link.Currency), nil
+				},
+			},
+			"shortLink": &graphql.Field{
+				Type:        graphql.String,
+				Description: "Shortlink that counts each visit before redirecting to the payment page",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return s.shortLinkURL(p.Source.(*store.Link).ShortCode), nil
+				},
+			},
+			"currency":          &graphql.Field{Type: nonNull(graphql.String)},
+			"status":            &graphql.Field{Type: nonNull(graphql.String), Description: "ACTIVE, PAID, INACTIVE, or EXPIRED"},
+			"transactionId":     &graphql.Field{Type: graphql.String},
+			"transactionStatus": &graphql.Field{Type: graphql.String},
+			"customerPhone":     &graphql.Field{Type: graphql.String},
+			"customerId":        &graphql.Field{Type: graphql.ID},
+			"remindersOptOut":   &graphql.Field{Type: nonNull(graphql.Boolean)},
+			"remindersSent":     &graphql.Field{Type: nonNull(graphql.Int)},
+			"promoCode":         &graphql.Field{Type: graphql.String},
+			"discountAmount":    &graphql.Field{Type: nonNull(graphql.Int), Description: "Promo code discount in minor units"},
+			"taxAmount":         &graphql.Field{Type: nonNull(graphql.Int), Description: "Tax included in the amount, in minor units"},
+			"taxRate":           &graphql.Field{Type: graphql.String, Description: "Tax rate in percent"},
+			"minAmount":         &graphql.Field{Type: nonNull(graphql.Int), Description: "Least the payer may enter on an open-amount link, in minor units; 0 for no minimum"},
+			"maxAmount":         &graphql.Field{Type: nonNull(graphql.Int), Description: "Most the payer may enter on an open-amount link, in minor units; 0 for no maximum"},
+			"metadata":          &graphql.Field{Type: nonNull(graphql.NewList(nonNull(metadataEntryType))), Resolve: resolveMetadata},
+			"expiresAt":         &graphql.Field{Type: nonNull(graphql.DateTime)},
+			"createdAt":         &graphql.Field{Type: nonNull(graphql.DateTime)},
+			"updatedAt":         &graphql.Field{Type: nonNull(graphql.DateTime)},
+		},
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: nonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String, Description: "Pass as after to fetch the next page"},
+		},
+	})
+
+	connectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PaymentLinkConnection",
+		Fields: graphql.Fields{
+			"nodes":    &graphql.Field{Type: nonNull(graphql.NewList(nonNull(paymentLinkType)))},
+			"pageInfo": &graphql.Field{Type: nonNull(pageInfoType)},
+		},
+	})
+
+	deliveryType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Delivery",
+		Description: "An attempt to send a payment link to a customer",
+		Fields: graphql.Fields{
+			"id":         &graphql.Field{Type: nonNull(graphql.Int)},
+			"channel":    &graphql.Field{Type: nonNull(graphql.String)},
+			"recipient":  &graphql.Field{Type: nonNull(graphql.String)},
+			"providerId": &graphql.Field{Type: graphql.String},
+			"status":     &graphql.Field{Type: nonNull(graphql.String)},
+			"error":      &graphql.Field{Type: graphql.String},
+			"createdAt":  &graphql.Field{Type: nonNull(graphql.DateTime)},
+			"updatedAt":  &graphql.Field{Type: nonNull(graphql.DateTime)},
+		},
+	})
+
+	brandingType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "LinkBranding",
+		Description: "How GP's hosted payment page presents the merchant",
+		Fields: graphql.Fields{
+			"displayName": &graphql.Field{Type: graphql.String},
+			"logoUrl":     &graphql.Field{Type: graphql.String},
+			"color":       &graphql.Field{Type: graphql.String, Description: "Hex color such as #1A73E8"},
+		},
+	})
+
+	createdLinkType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "CreatedPaymentLink",
+		Description: "A newly created payment link and the settings it was created with",
+		Fields: graphql.Fields{
+			"linkId":            &graphql.Field{Type: nonNull(graphql.ID)},
+			"paymentLink":       &graphql.Field{Type: nonNull(graphql.String)},
+			"shortLink":         &graphql.Field{Type: graphql.String},
+			"reference":         &graphql.Field{Type: nonNull(graphql.String)},
+			"amount":            &graphql.Field{Type: nonNull(graphql.Int), Description: "Amount in minor units"},
+			"displayAmount":     &graphql.Field{Type: nonNull(graphql.String)},
+			"currency":          &graphql.Field{Type: nonNull(graphql.String)},
+			"usageMode":         &graphql.Field{Type: nonNull(graphql.String)},
+			"usageLimit":        &graphql.Field{Type: nonNull(graphql.Int)},
+			"expiresAt":         &graphql.Field{Type: nonNull(graphql.String), Description: "RFC3339 expiry time"},
+			"paymentMethods":    &graphql.Field{Type: nonNull(graphql.NewList(nonNull(graphql.String)))},
+			"digitalWallets":    &graphql.Field{Type: graphql.NewList(nonNull(graphql.String)), Description: "Wallets offered under DIGITAL_WALLET"},
+			"shippable":         &graphql.Field{Type: nonNull(graphql.Boolean)},
+			"shippingAmount":    &graphql.Field{Type: nonNull(graphql.Int), Description: "Shipping charge in minor units"},
+			"country":           &graphql.Field{Type: nonNull(graphql.String)},
+			"captureMode":       &graphql.Field{Type: nonNull(graphql.String)},
+			"reminders":         &graphql.Field{Type: nonNull(graphql.Boolean)},
+			"customerId":        &graphql.Field{Type: graphql.ID},
+			"promoCode":         &graphql.Field{Type: graphql.String},
+			"discountAmount":    &graphql.Field{Type: nonNull(graphql.Int), Description: "Promo code discount in minor units"},
+			"netAmount":         &graphql.Field{Type: nonNull(graphql.Int), Description: "Amount before tax in minor units, when tax applies"},
+			"taxAmount":         &graphql.Field{Type: nonNull(graphql.Int), Description: "Tax included in the amount, in minor units"},
+			"taxRate":           &graphql.Field{Type: graphql.String, Description: "Tax rate in percent"},
+			"minAmount":         &graphql.Field{Type: nonNull(graphql.Int), Description: "Least the payer may enter on an open-amount link, in minor units"},
+			"maxAmount":         &graphql.Field{Type: nonNull(graphql.Int), Description: "Most the payer may enter on an open-amount link, in minor units"},
+			"metadata":          &graphql.Field{Type: nonNull(graphql.NewList(nonNull(metadataEntryType))), Resolve: resolveMetadata},
+			"smsDelivery":       &graphql.Field{Type: deliveryType},
+			"merchantId":        &graphql.Field{Type: graphql.String, Description: "Merchant the link was created for, when the request selected one"},
+			"accountName":       &graphql.Field{Type: graphql.String, Description: "Account the link was created under, when the request selected a merchant account"},
+			"dynamicDescriptor": &graphql.Field{Type: graphql.String, Description: "Descriptor shown on the payer's card statement"},
+			"language":          &graphql.Field{Type: graphql.String, Description: "Language tag the hosted payment page is shown in"},
+			"branding":          &graphql.Field{Type: brandingType, Description: "Hosted payment page branding, including the configured defaults"},
+			"images":            &graphql.Field{Type: graphql.NewList(nonNull(graphql.String)), Description: "Product image URLs shown on the hosted payment page"},
+		},
+	})
+
+	cancelledLinkType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "CancelledPaymentLink",
+		Fields: graphql.Fields{
+			"linkId": &graphql.Field{Type: nonNull(graphql.ID)},
+			"status": &graphql.Field{Type: nonNull(graphql.String)},
+		},
+	})
+
+	lineItemInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "LineItemInput",
+		Description: "A line of a payment link priced from the product catalog",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"sku":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"quantity":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+			"unitPrice": &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Expected unit price in major units; must match the catalog"},
+		},
+	})
+
+	metadataEntryInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "MetadataEntryInput",
+		Description: "A key-value pair to attach to a link, such as an order or campaign identifier",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"key":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"value": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	createInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "CreatePaymentLinkInput",
+		Description: "The fields accepted by POST /create-payment-link",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"amount":            &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Amount in major units, e.g. 10.99. Required unless preset by templateId, computed from items, or replaced by minAmount and maxAmount"},
+			"currency":          &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Required unless preset by templateId"},
+			"reference":         &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Generated from the server's reference format when omitted"},
+			"name":              &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Required unless preset by templateId"},
+			"description":       &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Required unless preset by templateId"},
+			"usageMode":         &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"usageLimit":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"expirationDays":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"expirationDate":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"returnUrl":         &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"statusUrl":         &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"cancelUrl":         &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"customerPhone":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"paymentMethods":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"digitalWallets":    &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Comma-separated wallets offered under DIGITAL_WALLET, such as APPLE_PAY,GOOGLE_PAY"},
+			"shippable":         &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+			"shippingAmount":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"country":           &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"captureMode":       &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "AUTO or LATER"},
+			"reminders":         &graphql.InputObjectFieldConfig{Type: graphql.Boolean, Description: "false to opt out of payment reminders"},
+			"customerId":        &graphql.InputObjectFieldConfig{Type: graphql.ID, Description: "Customer from POST /customers to associate the link with"},
+			"templateId":        &graphql.InputObjectFieldConfig{Type: graphql.ID, Description: "Link template whose presets fill in the fields left empty"},
+			"promoCode":         &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Configured promo code to discount the amount by"},
+			"taxRegion":         &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Region within the country whose tax rate applies, such as CA"},
+			"minAmount":         &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Least the payer may enter, in major units, making an open-amount link"},
+			"maxAmount":         &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Most the payer may enter, in major units, making an open-amount link"},
+			"metadata":          &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(metadataEntryInputType)), Description: "Key-value pairs stored with the link and sent in its webhook events"},
+			"items":             &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(lineItemInputType)), Description: "Line items that set the amount from the product catalog"},
+			"merchantId":        &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Merchant allowed by MERCHANT_ACCOUNTS to create the link for"},
+			"accountName":       &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Account allowed by MERCHANT_ACCOUNTS to create the link under"},
+			"dynamicDescriptor": &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Up to 22 characters shown on the payer's card statement in place of the merchant name"},
+			"payerEmail":        &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Payer email sent to GP for fraud screening and checked against RISK_BLOCKED_EMAILS"},
+			"billingCountry":    &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Payer billing country sent to GP for fraud screening and checked against RISK_BLOCKED_COUNTRIES"},
+			"payerIp":           &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Payer IP address sent to GP for fraud screening"},
+			"language":          &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Language tag, such as es or fr-CA, the hosted payment page is shown in"},
+			"brandDisplayName":  &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Merchant name shown on the hosted payment page in place of BRAND_DISPLAY_NAME"},
+			"brandLogoUrl":      &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "HTTPS URL of the logo shown on the hosted payment page in place of BRAND_LOGO_URL"},
+			"brandColor":        &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Hex color, such as #1A73E8, of the hosted payment page in place of BRAND_COLOR"},
+			"images":            &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "Up to 10 HTTPS URLs of product images shown on the hosted payment page"},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"paymentLink": &graphql.Field{
+				Type:        paymentLinkType,
+				Description: "A stored payment link by ID, or null if it is not recorded",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: s.resolvePaymentLink,
+			},
+			"paymentLinkByReference": &graphql.Field{
+				Type:        paymentLinkType,
+				Description: "The most recently created stored link with the given reference, or null",
+				Args: graphql.FieldConfigArgument{
+					"reference": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: s.resolvePaymentLinkByReference,
+			},
+			"paymentLinks": &graphql.Field{
+				Type:        nonNull(connectionType),
+				Description: "Stored payment links, newest first",
+				Args: graphql.FieldConfigArgument{
+					"first":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultListLimit, Description: "Page size, at most 100"},
+					"after":     &graphql.ArgumentConfig{Type: graphql.String, Description: "endCursor of the previous page"},
+					"reference": &graphql.ArgumentConfig{Type: graphql.String},
+					"status":    &graphql.ArgumentConfig{Type: graphql.String},
+					"currency":  &graphql.ArgumentConfig{Type: graphql.String},
+					"tags":      &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "key:value metadata pairs the links must all have"},
+				},
+				Resolve: s.resolvePaymentLinks,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createPaymentLink": &graphql.Field{
+				Type: nonNull(createdLinkType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createInputType)},
+				},
+				Resolve: s.resolveCreatePaymentLink,
+			},
+			"cancelPaymentLink": &graphql.Field{
+				Type: nonNull(cancelledLinkType),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: s.resolveCancelPaymentLink,
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+	if err != nil {
+		// The schema is static, so this only fails when the definitions above are wrong
+		panic("invalid GraphQL schema: " + err.Error())
+	}
+	return schema
+}
+
+// handleGraphQL handles POST requests to the /graphql endpoint. Responses follow the GraphQL
+// convention of a data and errors object rather than the usual Response envelope.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if writeBodyTooLarge(w, "GraphQL request failed", err) {
+			return
+		}
+		writeError(w, http.StatusBadRequest, "GraphQL request failed", "INVALID_JSON", "Error parsing JSON request body")
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         s.graphql,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        r.Context(),
+		RootObject:     map[string]interface{}{"request": r},
+	})
+
+	if lang := w.Header().Get(contentLanguageHeader); lang != "" {
+		for i, gqlErr := range result.Errors {
+			var message locale.Message
+			if info := graphQLErrorInfo(gqlErr); info != nil {
+				message = info.catalogDetails
+			}
+			result.Errors[i].Message = localize(lang, message, gqlErr.Message)
+			if fields, ok := gqlErr.Extensions["fields"].([]FieldError); ok {
+				gqlErr.Extensions["fields"] = localizeFields(lang, fields)
+			}
+		}
+	}
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// resolvePaymentLink resolves the paymentLink query
+func (s *Server) resolvePaymentLink(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+	if !linkIDPattern.MatchString(id) {
+		return nil, newGraphQLError("INVALID_LINK_ID", "Invalid payment link ID")
+	}
+
+	link, err := s.links.GetLink(p.Context, id)
+	if errors.Is(err, store.ErrLinkNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		logging.FromContext(p.Context).Error("Error reading payment link", "link_id", id, "error", err)
+		return nil, newGraphQLError("STORE_ERROR", "Error reading stored payment links")
+	}
+	return link, nil
+}
+
+// resolvePaymentLinkByReference resolves the paymentLinkByReference query
+func (s *Server) resolvePaymentLinkByReference(p graphql.ResolveParams) (interface{}, error) {
+	reference, _ := p.Args["reference"].(string)
+	links, err := s.links.ListLinks(p.Context, store.LinkFilter{Reference: strings.TrimSpace(reference), Limit: 1})
+	if err != nil {
+		logging.FromContext(p.Context).Error("Error listing payment links", "error", err)
+		return nil, newGraphQLError("STORE_ERROR", "Error reading stored payment links")
+	}
+	if len(links) == 0 {
+		return nil, nil
+	}
+	return links[0], nil
+}
+
+// resolvePaymentLinks resolves the paymentLinks query, applying the same filters and
+// limits as GET /payment-links
+func (s *Server) resolvePaymentLinks(p graphql.ResolveParams) (interface{}, error) {
+	reference, _ := p.Args["reference"].(string)
+	status, _ := p.Args["status"].(string)
+	currency, _ := p.Args["currency"].(string)
+	filter := store.LinkFilter{
+		Reference: strings.TrimSpace(reference),
+		Status:    strings.ToUpper(strings.TrimSpace(status)),
+		Currency:  strings.ToUpper(strings.TrimSpace(currency)),
+		Limit:     defaultListLimit,
+	}
+
+	var tags []string
+	if values, ok := p.Args["tags"].([]interface{}); ok {
+		for _, value := range values {
+			tag, _ := value.(string)
+			tags = append(tags, tag)
+		}
+	}
+	metadata, err := parseTagFilters(tags)
+	if err != nil {
+		return nil, newGraphQLError("INVALID_TAG", err.Error())
+	}
+	filter.Metadata = metadata
+
+	if first, ok := p.Args["first"].(int); ok {
+		if first < 1 || first > maxListLimit {
+			return nil, newGraphQLError("INVALID_LIMIT", fmt.Sprintf("first must be between 1 and %d", maxListLimit))
+		}
+		filter.Limit = first
+	}
+	if after, _ := p.Args["after"].(string); after != "" {
+		cursor, err := store.DecodeLinkCursor(after)
+		if err != nil {
+			return nil, newGraphQLError("INVALID_CURSOR", err.Error())
+		}
+		filter.After = cursor
+	}
+
+	links, pagination, err := s.listLinkPage(p.Context, filter)
+	if err != nil {
+		logging.FromContext(p.Context).Error("Error listing payment links", "error", err)
+		return nil, newGraphQLError("STORE_ERROR", "Error reading stored payment links")
+	}
+
+	connection := linkConnection{Nodes: links, PageInfo: pageInfo{HasNextPage: pagination.HasMore}}
+	if pagination.HasMore {
+		connection.PageInfo.EndCursor = &pagination.NextCursor
+	}
+	return connection, nil
+}
+
+// resolveCreatePaymentLink resolves the createPaymentLink mutation. Each link created is
+// held to the same per-IP rate limit, and CAPTCHA, as POST /create-payment-link.
+func (s *Server) resolveCreatePaymentLink(p graphql.ResolveParams) (interface{}, error) {
+	if !permitted(p.Context, config.PermissionCreate) {
+		return nil, newGraphQLErrorf("FORBIDDEN", "The API key has not been granted the %s permission", config.PermissionCreate)
+	}
+	if r, ok := p.Info.RootValue.(map[string]interface{})["request"].(*http.Request); ok {
+		if delay := s.ipLimiter.delayFor(r); delay > 0 {
+			logging.FromContext(p.Context).Warn("Client IP rate limit exceeded", "client_ip", s.clientIP(r), "path", r.URL.Path)
+			return nil, &graphQLError{
+				info:       &ErrorInfo{Code: "RATE_LIMITED", Details: "Too many requests from this address"},
+				retryAfter: delay,
+			}
+		}
+		if linkErr := s.checkCaptcha(r); linkErr != nil {
+			return nil, &graphQLError{info: linkErr.Info()}
+		}
+	}
+
+	// The input fields match the JSON accepted by POST /create-payment-link, apart from
+	// metadata, which is a list of entries in place of a JSON object
+	args, _ := p.Args["input"].(map[string]interface{})
+	if entries, ok := args["metadata"].([]interface{}); ok {
+		metadata := make(map[string]interface{}, len(entries))
+		for _, entry := range entries {
+			if fields, ok := entry.(map[string]interface{}); ok {
+				key, _ := fields["key"].(string)
+				metadata[key] = fields["value"]
+			}
+		}
+		args["metadata"] = metadata
+	}
+	input, err := json.Marshal(args)
+	if err != nil {
+		return nil, newGraphQLError("INVALID_INPUT", "Error reading input")
+	}
+	var req PaymentLinkRequest
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, newGraphQLError("INVALID_INPUT", "Error reading input")
+	}
+
+	link, err := s.CreateLink(p.Context, req)
+	if err != nil {
+		var linkErr *LinkRequestError
+		if errors.As(err, &linkErr) {
+			return nil, &graphQLError{info: linkErr.Info()}
+		}
+		return nil, err
+	}
+	return link, nil
+}
+
+// resolveCancelPaymentLink resolves the cancelPaymentLink mutation
+func (s *Server) resolveCancelPaymentLink(p graphql.ResolveParams) (interface{}, error) {
+	if !permitted(p.Context, config.PermissionCancel) {
+		return nil, newGraphQLErrorf("FORBIDDEN", "The API key has not been granted the %s permission", config.PermissionCancel)
+	}
+	id, _ := p.Args["id"].(string)
+	if !linkIDPattern.MatchString(id) {
+		return nil, newGraphQLError("INVALID_LINK_ID", "Invalid payment link ID")
+	}
+
+	status, err := s.CancelLink(p.Context, id)
+	if err != nil {
+		_, info := gpErrorInfo(err)
+		return nil, &graphQLError{info: info}
+	}
+	return cancelledLink{LinkID: id, Status: status}, nil
+}
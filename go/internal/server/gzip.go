@@ -0,0 +1,116 @@
+package server
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// compressibleTypes lists the response media types worth compressing. Images and
+// other binary formats are already compressed.
+var compressibleTypes = map[string]bool{
+	"application/json":       true,
+	"application/javascript": true,
+	"text/css":               true,
+	"text/csv":               true,
+	"text/html":              true,
+	"text/javascript":        true,
+	"text/plain":             true,
+}
+
+// gzipWriters reuses gzip writers across responses, as each holds sizeable buffers
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// gzipResponseWriter compresses the response body once the handler's headers show it
+// is a compressible type that is not already encoded
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+// WriteHeader decides whether to compress the response before writing the headers
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if g.wroteHeader {
+		return
+	}
+	g.wroteHeader = true
+
+	header := g.ResponseWriter.Header()
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if compressibleTypes[mediaType] && header.Get("Content-Encoding") == "" && header.Get("Content-Range") == "" &&
+		status != http.StatusNoContent && status != http.StatusNotModified {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		g.gz = gzipWriters.Get().(*gzip.Writer)
+		g.gz.Reset(g.ResponseWriter)
+	}
+	g.ResponseWriter.WriteHeader(status)
+}
+
+// Write compresses p when the response is being compressed
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !g.wroteHeader {
+		if g.ResponseWriter.Header().Get("Content-Type") == "" {
+			g.ResponseWriter.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.gz == nil {
+		return g.ResponseWriter.Write(p)
+	}
+	return g.gz.Write(p)
+}
+
+// Flush sends any compressed data buffered so far to the client
+func (g *gzipResponseWriter) Flush() {
+	if g.gz != nil {
+		g.gz.Flush()
+	}
+	http.NewResponseController(g.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter, so http.ResponseController can reach it
+func (g *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+// close finishes the compressed stream and returns its writer to the pool
+func (g *gzipResponseWriter) close() {
+	if g.gz == nil {
+		return
+	}
+	g.gz.Close()
+	gzipWriters.Put(g.gz)
+	g.gz = nil
+}
+
+// withGzip compresses responses for clients that accept gzip. Event streams and
+// WebSockets are not routed through it, as they must reach the client unbuffered.
+func withGzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r) || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding header allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
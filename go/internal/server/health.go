@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// readinessCheckTimeout bounds how long the store check in /readyz may take
+const readinessCheckTimeout = 5 * time.Second
+
+// ReadinessReport describes the result of each readiness check
+type ReadinessReport struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// handleHealthz handles the /healthz liveness endpoint
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    map[string]string{"status": "ok"},
+	})
+}
+
+// handleReadyz handles the /readyz readiness endpoint. It verifies that a GP API
+// access token can be obtained (reusing the cached token when valid) and that
+// the link store is reachable.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	report := ReadinessReport{Status: "ready", Checks: make(map[string]string)}
+
+	if _, err := s.gp.Token(r.Context()); err != nil {
+		logging.FromContext(r.Context()).Warn("Readiness check failed", "check", "gp_api_token", "error", err)
+		report.Checks["gp_api_token"] = "failed: " + err.Error()
+	} else {
+		report.Checks["gp_api_token"] = "ok"
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessCheckTimeout)
+	defer cancel()
+	if err := s.links.Ping(ctx); err != nil {
+		logging.FromContext(r.Context()).Warn("Readiness check failed", "check", "link_store", "error", err)
+		report.Checks["link_store"] = "failed: " + err.Error()
+	} else {
+		report.Checks["link_store"] = "ok"
+	}
+
+	for _, result := range report.Checks {
+		if result != "ok" {
+			report.Status = "not ready"
+			writeJSON(w, http.StatusServiceUnavailable, Response{
+				Success: false,
+				Message: "Service not ready",
+				Data:    report,
+				Error:   &ErrorInfo{Code: "NOT_READY", Details: "One or more readiness checks failed"},
+			})
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    report,
+	})
+}
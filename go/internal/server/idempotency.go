@@ -0,0 +1,244 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// Idempotency-Key handling
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	// idempotencyLease is how long a key stays reserved by a request that has not finished,
+	// so one held by an instance that stopped mid-request can be used again. It outlasts
+	// the server's write timeout.
+	idempotencyLease = 2*serverWriteTimeout + time.Minute
+)
+
+// IdempotencyStore records the responses to requests made with an Idempotency-Key, so a
+// retried request is answered with the first response instead of creating a second link or refund
+type IdempotencyStore interface {
+	// Reserve claims key for lease. When it was already claimed, Reserve reports false
+	// with the response recorded for it, which is nil while the first request is in progress.
+	Reserve(ctx context.Context, key string, lease time.Duration) ([]byte, bool, error)
+	// Complete records response for a reserved key and keeps it for ttl
+	Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error
+	// Release gives up a reserved key without a response, so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// SetIdempotencyStore records idempotent responses in store, such as one in Redis that
+// other instances share, in place of the server's own memory
+func (s *Server) SetIdempotencyStore(store IdempotencyStore) {
+	s.idempotency = store
+}
+
+// idempotentResponse is the response recorded for an idempotency key
+type idempotentResponse struct {
+	// Fingerprint is a hash of the request the response was for
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+// responseCapture copies the response a handler writes, so it can be recorded
+type responseCapture struct {
+	statusRecorder
+	body bytes.Buffer
+}
+
+// Write copies p before writing it
+func (c *responseCapture) Write(p []byte) (int, error) {
+	c.body.Write(p)
+	return c.statusRecorder.Write(p)
+}
+
+// withIdempotency returns middleware that answers a request carrying an Idempotency-Key
+// that was already used with the response to its first use, so a client retrying after a
+// timeout does not create a second link or refund a payment twice. Keys are scoped to the
+// API key, last for IDEMPOTENCY_TTL, and only successful responses are recorded; a failed
+// request can be retried with the same key. Errors are reported with message. It runs
+// after auth.
+func (s *Server) withIdempotency(message string) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength || !printableASCII(key) {
+				writeError(w, http.StatusBadRequest, message, "INVALID_IDEMPOTENCY_KEY",
+					"Idempotency-Key must be 1 to 255 printable ASCII characters")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				if writeBodyTooLarge(w, message, err) {
+					return
+				}
+				writeError(w, http.StatusBadRequest, message, "FORM_PARSE_ERROR", "Error reading request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			logger := logging.FromContext(ctx)
+			storeKey := idempotencyStoreKey(apiKeyNameFrom(ctx), key)
+			fingerprint := requestFingerprint(r, body)
+
+			recorded, reserved, err := s.idempotency.Reserve(ctx, storeKey, idempotencyLease)
+			if err != nil {
+				logger.Error("Failed to reserve idempotency key", "error", err)
+				writeError(w, http.StatusServiceUnavailable, message, "IDEMPOTENCY_UNAVAILABLE",
+					"Idempotency-Key could not be checked; retry the request")
+				return
+			}
+			if !reserved {
+				s.replayIdempotentResponse(w, message, recorded, fingerprint)
+				return
+			}
+
+			capture := &responseCapture{statusRecorder: statusRecorder{ResponseWriter: w, status: http.StatusOK}}
+			next.ServeHTTP(capture, r)
+
+			// The request may have been cancelled, but its outcome must still be recorded
+			ctx = context.WithoutCancel(ctx)
+			if capture.status < 200 || capture.status > 299 {
+				if err := s.idempotency.Release(ctx, storeKey); err != nil {
+					logger.Warn("Failed to release idempotency key", "error", err)
+				}
+				return
+			}
+			// Encoding a struct of strings, numbers, and bytes cannot fail
+			response, _ := json.Marshal(idempotentResponse{
+				Fingerprint: fingerprint,
+				Status:      capture.status,
+				ContentType: capture.Header().Get("Content-Type"),
+				Body:        capture.body.Bytes(),
+			})
+			if err := s.idempotency.Complete(ctx, storeKey, response, s.idempotencyTTL); err != nil {
+				logger.Warn("Failed to record idempotent response", "error", err)
+			}
+		})
+	}
+}
+
+// replayIdempotentResponse answers a request whose idempotency key was already used with
+// the recorded response, or a conflict while the first request is in progress or when the
+// key was used for a different request
+func (s *Server) replayIdempotentResponse(w http.ResponseWriter, message string, recorded []byte, fingerprint string) {
+	if recorded == nil {
+		writeError(w, http.StatusConflict, message, "IDEMPOTENCY_KEY_IN_USE",
+			"A request with this Idempotency-Key is still in progress")
+		return
+	}
+	var response idempotentResponse
+	if err := json.Unmarshal(recorded, &response); err != nil {
+		writeError(w, http.StatusInternalServerError, message, "IDEMPOTENCY_UNAVAILABLE",
+			"The response recorded for this Idempotency-Key could not be read")
+		return
+	}
+	if response.Fingerprint != fingerprint {
+		writeError(w, http.StatusUnprocessableEntity, message, "IDEMPOTENCY_KEY_REUSED",
+			"Idempotency-Key was already used for a different request")
+		return
+	}
+	if response.ContentType != "" {
+		w.Header().Set("Content-Type", response.ContentType)
+	}
+	w.Header().Set(idempotentReplayedHeader, "true")
+	w.WriteHeader(response.Status)
+	w.Write(response.Body)
+}
+
+// idempotencyStoreKey returns the key a request's idempotency key is recorded under, scoped
+// to the API key it was made with. Only a hash is stored.
+func idempotencyStoreKey(apiKeyName, key string) string {
+	hash := sha256.Sum256([]byte(apiKeyName + "\x00" + key))
+	return hex.EncodeToString(hash[:])
+}
+
+// requestFingerprint returns a hash identifying a request by its path, content type, and body
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.URL.Path + "\x00" + r.Header.Get("Content-Type") + "\x00"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// printableASCII reports whether value only holds printable ASCII characters
+func printableASCII(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] < 0x20 || value[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// memoryIdempotency is an IdempotencyStore in the server's memory, used when no shared
+// store is configured. Records are lost on restart and not seen by other instances.
+type memoryIdempotency struct {
+	mu      sync.Mutex
+	records map[string]memoryIdempotencyRecord
+}
+
+// memoryIdempotencyRecord is a key's recorded response, nil while it is reserved
+type memoryIdempotencyRecord struct {
+	response  []byte
+	expiresAt time.Time
+}
+
+// newMemoryIdempotency returns an empty memoryIdempotency
+func newMemoryIdempotency() *memoryIdempotency {
+	return &memoryIdempotency{records: make(map[string]memoryIdempotencyRecord)}
+}
+
+// Reserve implements IdempotencyStore
+func (m *memoryIdempotency) Reserve(_ context.Context, key string, lease time.Duration) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for k, record := range m.records {
+		if now.After(record.expiresAt) {
+			delete(m.records, k)
+		}
+	}
+	if record, ok := m.records[key]; ok {
+		return record.response, false, nil
+	}
+	m.records[key] = memoryIdempotencyRecord{expiresAt: now.Add(lease)}
+	return nil, true, nil
+}
+
+// Complete implements IdempotencyStore
+func (m *memoryIdempotency) Complete(_ context.Context, key string, response []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[key] = memoryIdempotencyRecord{response: response, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// Release implements IdempotencyStore
+func (m *memoryIdempotency) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if record, ok := m.records[key]; ok && record.response == nil {
+		delete(m.records, key)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingIdempotency is an IdempotencyStore that cannot be reached
+type failingIdempotency struct{}
+
+func (failingIdempotency) Reserve(context.Context, string, time.Duration) ([]byte, bool, error) {
+	return nil, false, errors.New("connection refused")
+}
+
+func (failingIdempotency) Complete(context.Context, string, []byte, time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func (failingIdempotency) Release(context.Context, string) error {
+	return errors.New("connection refused")
+}
+
+func TestWithIdempotency(t *testing.T) {
+	s := &Server{idempotency: newMemoryIdempotency(), idempotencyTTL: time.Hour}
+	calls := 0
+	status := http.StatusOK
+	handler := s.withIdempotency("Payment link creation failed")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		writeJSON(w, status, Response{Success: status == http.StatusOK})
+	}))
+
+	send := func(key, apiKey, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/create-payment-link", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(idempotencyKeyHeader, key)
+		if apiKey != "" {
+			req = req.WithContext(context.WithValue(req.Context(), apiKeyNameKey{}, apiKey))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	steps := []struct {
+		name         string
+		key          string
+		apiKey       string
+		body         string
+		status       int
+		wantStatus   int
+		wantCode     string
+		wantReplayed bool
+		wantCalls    int
+	}{
+		{name: "first request", key: "key-1", body: `{"amount":"1.00"}`, wantStatus: http.StatusOK, wantCalls: 1},
+		{name: "retry", key: "key-1", body: `{"amount":"1.00"}`, wantStatus: http.StatusOK, wantReplayed: true, wantCalls: 1},
+		{name: "different request", key: "key-1", body: `{"amount":"2.00"}`,
+			wantStatus: http.StatusUnprocessableEntity, wantCode: "IDEMPOTENCY_KEY_REUSED", wantCalls: 1},
+		{name: "same key for another API key", key: "key-1", apiKey: "billing", body: `{"amount":"1.00"}`,
+			wantStatus: http.StatusOK, wantCalls: 2},
+		{name: "failed request", key: "key-2", body: `{}`, status: http.StatusBadGateway,
+			wantStatus: http.StatusBadGateway, wantCalls: 3},
+		{name: "retry of failed request", key: "key-2", body: `{}`, wantStatus: http.StatusOK, wantCalls: 4},
+		{name: "key too long", key: strings.Repeat("k", maxIdempotencyKeyLength+1), body: `{}`,
+			wantStatus: http.StatusBadRequest, wantCode: "INVALID_IDEMPOTENCY_KEY", wantCalls: 4},
+	}
+	for _, step := range steps {
+		status = http.StatusOK
+		if step.status != 0 {
+			status = step.status
+		}
+		rec := send(step.key, step.apiKey, step.body)
+		if rec.Code != step.wantStatus || errorCode(decodeResponse(t, rec)) != step.wantCode {
+			t.Fatalf("%s: got %d %s, want %d %q", step.name, rec.Code, rec.Body.String(), step.wantStatus, step.wantCode)
+		}
+		if replayed := rec.Header().Get(idempotentReplayedHeader) == "true"; replayed != step.wantReplayed {
+			t.Errorf("%s: replayed = %v, want %v", step.name, replayed, step.wantReplayed)
+		}
+		if calls != step.wantCalls {
+			t.Errorf("%s: handler calls = %d, want %d", step.name, calls, step.wantCalls)
+		}
+	}
+
+	// A key held by a request that has not finished is refused
+	if _, _, err := s.idempotency.Reserve(context.Background(), idempotencyStoreKey("", "key-3"), time.Minute); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if rec := send("key-3", "", `{}`); rec.Code != http.StatusConflict || errorCode(decodeResponse(t, rec)) != "IDEMPOTENCY_KEY_IN_USE" {
+		t.Errorf("key in use: got %d %s, want 409 IDEMPOTENCY_KEY_IN_USE", rec.Code, rec.Body.String())
+	}
+
+	// Without the store, requests with a key are refused rather than risk running twice
+	s.idempotency = failingIdempotency{}
+	if rec := send("key-4", "", `{}`); rec.Code != http.StatusServiceUnavailable || errorCode(decodeResponse(t, rec)) != "IDEMPOTENCY_UNAVAILABLE" {
+		t.Errorf("store unavailable: got %d %s, want 503 IDEMPOTENCY_UNAVAILABLE", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// ipAccessExempt are the paths no IP list applies to: the health checks, the pages payers
+// open from anywhere, and the callbacks of GP and Twilio
+var ipAccessExempt = []string{"/healthz", "/readyz", "/payment-result", "/l/", "/webhooks/"}
+
+// ipAccessGroup returns the name and IP list of the endpoint group path belongs to, or false
+// when it is exempt
+func ipAccessGroup(access config.IPAccess, path string) (string, config.IPAccessList, bool) {
+	for _, exempt := range ipAccessExempt {
+		if path == exempt || strings.HasSuffix(exempt, "/") && strings.HasPrefix(path, exempt) {
+			return "", config.IPAccessList{}, false
+		}
+	}
+	if path == "/admin" || strings.HasPrefix(path, "/admin/") {
+		return "admin", access.Admin, true
+	}
+	return "public", access.Public, true
+}
+
+// clientIP returns the address of the client that sent r, read from X-Forwarded-For behind
+// trusted proxies whether or not the rate limit is enabled
+func (s *Server) clientIP(r *http.Request) string {
+	return clientIP(r, s.trustedProxies)
+}
+
+// withIPAccess wraps next so that the admin and public endpoints are only served to the
+// client IPs their lists permit. The client IP is taken from X-Forwarded-For behind
+// TRUSTED_PROXY_COUNT proxies. The lists are read on each request, as they change on
+// reload.
+func (s *Server) withIPAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		group, list, ok := ipAccessGroup(s.reloadable.Load().ipAccess, r.URL.Path)
+		if !ok || list.Empty() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip := s.clientIP(r)
+		// An address that cannot be parsed cannot be shown to be in an allowed range
+		if addr, err := netip.ParseAddr(ip); err != nil || !list.Permits(addr) {
+			logging.FromContext(r.Context()).Warn("Rejected request from a client IP that is not allowed",
+				"group", group, "path", r.URL.Path, "client_ip", ip)
+			writeError(w, http.StatusForbidden, "Access denied", "IP_NOT_ALLOWED",
+				"Requests from this network are not allowed")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
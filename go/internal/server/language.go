@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+
+	"golang.org/x/text/language"
+
+	"github.com/globalpayments/pay-by-link-go/internal/locale"
+)
+
+// contentLanguageHeader names the language of a response's messages. withLanguage sets it
+// before the handler runs, and writeJSON reads it back, as it does the request ID.
+const contentLanguageHeader = "Content-Language"
+
+// withLanguage picks the language of API messages from the request's Accept-Language
+// header. Requests without one get messages in English and no Content-Language.
+func withLanguage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if accept := r.Header.Get("Accept-Language"); accept != "" {
+			w.Header().Set(contentLanguageHeader, locale.Negotiate(accept))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// localizeResponse renders the message and error details of response in lang, from their
+// catalog messages when set and otherwise by looking up their text as a catalog key.
+// Responses without a language are rendered in English.
+func localizeResponse(lang string, response Response) Response {
+	response.Message = localize(lang, response.catalogMessage, response.Message)
+	if response.Error != nil {
+		// The error info may be shared, as with a LinkRequestError's fields
+		info := *response.Error
+		info.Details = localize(lang, info.catalogDetails, info.Details)
+		info.Fields = localizeFields(lang, info.Fields)
+		response.Error = &info
+	}
+	return response
+}
+
+// localizeFields returns a copy of fields with their messages rendered in lang
+func localizeFields(lang string, fields []FieldError) []FieldError {
+	if len(fields) == 0 {
+		return fields
+	}
+	localized := make([]FieldError, len(fields))
+	for i, field := range fields {
+		field.Message = localize(lang, field.catalogMessage, field.Message)
+		localized[i] = field
+	}
+	return localized
+}
+
+// localize renders message in lang, or text when message is unset. Text is translated
+// only when it is a catalog key as it stands, such as "Payment link not found".
+func localize(lang string, message locale.Message, text string) string {
+	if message.Key == "" {
+		message = locale.Messagef(text)
+	}
+	return locale.Translate(lang, message)
+}
+
+// hostedPageLanguage returns the canonical form of a validated language tag from a link
+// request, such as fr-CA for fr_ca, or an empty string when the request has none
+func hostedPageLanguage(value string) string {
+	tag, err := language.Parse(value)
+	if err != nil {
+		return ""
+	}
+	return tag.String()
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+)
+
+func TestLocalizedValidationError(t *testing.T) {
+	handler := New(newTestConfig(config.APIKeys{}), &fakeGP{}, newTestStore(t), nil, http.DefaultClient).Handler()
+
+	tests := []struct {
+		acceptLanguage string
+		wantMessage    string
+		wantDetails    string
+		wantField      string
+	}{
+		{acceptLanguage: "", wantMessage: "Payment link creation failed",
+			wantDetails: "1 field(s) failed validation", wantField: "name is required"},
+		{acceptLanguage: "es-ES", wantMessage: "No se pudo crear el enlace de pago",
+			wantDetails: "1 campo(s) no superaron la validación", wantField: "name es obligatorio"},
+		{acceptLanguage: "de", wantMessage: "Der Zahlungslink konnte nicht erstellt werden",
+			wantDetails: "1 Feld(er) haben die Prüfung nicht bestanden", wantField: "name ist erforderlich"},
+	}
+	for _, tt := range tests {
+		body := `{"amount":"10.00","currency":"USD","reference":"INV-1","description":"Invoice 1"}`
+		req := httptest.NewRequest(http.MethodPost, "/create-payment-link", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.acceptLanguage != "" {
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		response := decodeResponse(t, rec)
+		if rec.Code != http.StatusBadRequest || errorCode(response) != "VALIDATION_ERROR" {
+			t.Fatalf("%q: got %d %q, want 400 VALIDATION_ERROR", tt.acceptLanguage, rec.Code, errorCode(response))
+		}
+		if response.Message != tt.wantMessage || response.Error.Details != tt.wantDetails {
+			t.Errorf("%q: message %q, details %q, want %q, %q", tt.acceptLanguage,
+				response.Message, response.Error.Details, tt.wantMessage, tt.wantDetails)
+		}
+		if len(response.Error.Fields) != 1 || response.Error.Fields[0].Message != tt.wantField {
+			t.Errorf("%q: fields = %+v, want %q", tt.acceptLanguage, response.Error.Fields, tt.wantField)
+		}
+	}
+}
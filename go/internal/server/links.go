@@ -0,0 +1,1557 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/country"
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+	"github.com/globalpayments/pay-by-link-go/internal/locale"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/webhooks"
+)
+
+// ClientConfig represents the configuration response sent to the client
+type ClientConfig struct {
+	Environment             string   `json:"environment"`
+	SupportedCurrencies     []string `json:"supportedCurrencies"`
+	SupportedPaymentMethods []string `json:"supportedPaymentMethods"`
+	SupportedDigitalWallets []string `json:"supportedDigitalWallets"`
+	Country                 string   `json:"country"`
+	// Captcha is the widget to render on the link form, when link creation requires one
+	Captcha *CaptchaConfig `json:"captcha,omitempty"`
+	// CSRFToken must be sent with form posts, unless CSRF protection is off
+	CSRFToken string `json:"csrfToken,omitempty"`
+}
+
+// PaymentLinkRequest represents the expected payment link creation request payload
+type PaymentLinkRequest struct {
+	Amount         string   `json:"amount" form:"amount"`
+	Currency       string   `json:"currency" form:"currency"`
+	Reference      string   `json:"reference" form:"reference"`
+	Name           string   `json:"name" form:"name"`
+	Description    string   `json:"description" form:"description"`
+	UsageMode      string   `json:"usageMode" form:"usageMode"`
+	UsageLimit     string   `json:"usageLimit" form:"usageLimit"`
+	ExpirationDays string   `json:"expirationDays" form:"expirationDays"`
+	ExpirationDate string   `json:"expirationDate" form:"expirationDate"`
+	ReturnURL      string   `json:"returnUrl" form:"returnUrl"`
+	StatusURL      string   `json:"statusUrl" form:"statusUrl"`
+	CancelURL      string   `json:"cancelUrl" form:"cancelUrl"`
+	CustomerPhone  string   `json:"customerPhone" form:"customerPhone"`
+	PaymentMethods string   `json:"paymentMethods" form:"paymentMethods"`
+	DigitalWallets string   `json:"digitalWallets" form:"digitalWallets"`
+	Shippable      flexBool `json:"shippable" form:"shippable"`
+	ShippingAmount string   `json:"shippingAmount" form:"shippingAmount"`
+	Country        string   `json:"country" form:"country"`
+	CaptureMode    string   `json:"captureMode" form:"captureMode"`
+	Reminders      flexBool `json:"reminders" form:"reminders"`
+	CustomerID     string   `json:"customerId" form:"customerId"`
+	// TemplateID names a link template that fills in the fields left empty
+	TemplateID string `json:"templateId" form:"templateId"`
+	// Items prices the link from the product catalog; JSON requests only
+	Items []LineItemRequest `json:"items"`
+	// itemized is set once Items have priced the amount, and recorded on the stored link
+	itemized bool
+	// PromoCode applies a configured discount to the amount
+	PromoCode string `json:"promoCode" form:"promoCode"`
+	// TaxRegion selects a regional tax rate within the link's country, such as CA for US-CA
+	TaxRegion string `json:"taxRegion" form:"taxRegion"`
+	// MinAmount and MaxAmount make an open-amount link, on which the payer chooses the amount
+	// within these bounds, in place of a fixed amount
+	MinAmount string `json:"minAmount" form:"minAmount"`
+	MaxAmount string `json:"maxAmount" form:"maxAmount"`
+	// Metadata holds the merchant's own key-value pairs, such as an order ID; JSON requests only
+	Metadata map[string]string `json:"metadata"`
+	// Images are HTTPS URLs of pictures of what the payer is paying for, shown on GP's
+	// hosted payment page; JSON requests only
+	Images []string `json:"images"`
+	// MerchantID and AccountName create the link for another merchant or account allowed by
+	// MERCHANT_ACCOUNTS, instead of those of the access token
+	MerchantID  string `json:"merchantId" form:"merchantId"`
+	AccountName string `json:"accountName" form:"accountName"`
+	// DynamicDescriptor is shown on the payer's card statement in place of the merchant name
+	DynamicDescriptor string `json:"dynamicDescriptor" form:"dynamicDescriptor"`
+	// PayerEmail, BillingCountry, and PayerIP are what the merchant knows of the payer, sent
+	// to GP for fraud screening and checked against the local blocklists
+	PayerEmail     string `json:"payerEmail" form:"payerEmail"`
+	BillingCountry string `json:"billingCountry" form:"billingCountry"`
+	PayerIP        string `json:"payerIp" form:"payerIp"`
+	// Language is the BCP 47 tag of the language GP's hosted payment page is shown in
+	Language string `json:"language" form:"language"`
+	// BrandDisplayName, BrandLogoURL, and BrandColor brand GP's hosted payment page for this
+	// link, each replacing the configured BRAND_ default
+	BrandDisplayName string `json:"brandDisplayName" form:"brandDisplayName"`
+	BrandLogoURL     string `json:"brandLogoUrl" form:"brandLogoUrl"`
+	BrandColor       string `json:"brandColor" form:"brandColor"`
+}
+
+// openAmount reports whether the request is for an open-amount link
+func (req PaymentLinkRequest) openAmount() bool {
+	return strings.TrimSpace(req.MinAmount) != "" || strings.TrimSpace(req.MaxAmount) != ""
+}
+
+// flexBool is a boolean request field held as text, so it can be set from a JSON
+// boolean as well as from form and CSV values such as "true" or "false"
+type flexBool string
+
+// UnmarshalJSON accepts a JSON boolean or string
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var value bool
+	if err := json.Unmarshal(data, &value); err == nil {
+		*b = flexBool(strconv.FormatBool(value))
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return &json.UnmarshalTypeError{Value: string(data), Type: reflect.TypeOf(true)}
+	}
+	*b = flexBool(text)
+	return nil
+}
+
+// PaymentLinkUpdateRequest represents the expected payment link update request payload
+type PaymentLinkUpdateRequest struct {
+	Amount         string `json:"amount"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	ExpirationDays string `json:"expirationDays"`
+	ExpirationDate string `json:"expirationDate"`
+}
+
+// PaymentLinkResponse represents the response data for successful payment link creation
+type PaymentLinkResponse struct {
+	PaymentLink    string          `json:"paymentLink"`
+	ShortLink      string          `json:"shortLink,omitempty"`
+	LinkID         string          `json:"linkId"`
+	Reference      string          `json:"reference"`
+	Amount         int             `json:"amount"`
+	DisplayAmount  string          `json:"displayAmount"`
+	Currency       string          `json:"currency"`
+	UsageMode      string          `json:"usageMode"`
+	UsageLimit     int             `json:"usageLimit"`
+	ExpiresAt      string          `json:"expiresAt"`
+	PaymentMethods []string        `json:"paymentMethods"`
+	DigitalWallets []string        `json:"digitalWallets,omitempty"`
+	Shippable      bool            `json:"shippable"`
+	ShippingAmount int64           `json:"shippingAmount"`
+	Country        string          `json:"country"`
+	CaptureMode    string          `json:"captureMode"`
+	Reminders      bool            `json:"reminders"`
+	CustomerID     string          `json:"customerId,omitempty"`
+	PromoCode      string          `json:"promoCode,omitempty"`
+	DiscountAmount int64           `json:"discountAmount,omitempty"`
+	NetAmount      int64           `json:"netAmount,omitempty"`
+	TaxAmount      int64           `json:"taxAmount,omitempty"`
+	TaxRate        string          `json:"taxRate,omitempty"`
+	MinAmount      int64           `json:"minAmount,omitempty"`
+	MaxAmount      int64           `json:"maxAmount,omitempty"`
+	SMSDelivery    *store.Delivery `json:"smsDelivery,omitempty"`
+
+	// Metadata echoes the merchant's key-value pairs from the request
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// MerchantID and AccountName are set when the request selected a merchant account
+	MerchantID  string `json:"merchantId,omitempty"`
+	AccountName string `json:"accountName,omitempty"`
+	// DynamicDescriptor echoes the statement descriptor from the request
+	DynamicDescriptor string `json:"dynamicDescriptor,omitempty"`
+	// Language is the hosted payment page language sent to GP, in canonical form
+	Language string `json:"language,omitempty"`
+	// Branding is the hosted payment page branding sent to GP, defaults included
+	Branding *LinkBrandingResponse `json:"branding,omitempty"`
+	// Images echoes the product image URLs from the request
+	Images []string `json:"images,omitempty"`
+}
+
+// LinkBrandingResponse reports the hosted payment page branding of a created link
+type LinkBrandingResponse struct {
+	DisplayName string `json:"displayName,omitempty"`
+	LogoURL     string `json:"logoUrl,omitempty"`
+	Color       string `json:"color,omitempty"`
+}
+
+// PaymentLinkDetailResponse represents the response data for a payment link lookup
+type PaymentLinkDetailResponse struct {
+	LinkID         string               `json:"linkId"`
+	PaymentLink    string               `json:"paymentLink"`
+	Status         string               `json:"status"`
+	Paid           bool                 `json:"paid"`
+	Reference      string               `json:"reference"`
+	Name           string               `json:"name"`
+	Amount         int64                `json:"amount"`
+	Currency       string               `json:"currency"`
+	UsageMode      string               `json:"usageMode"`
+	UsageLimit     int64                `json:"usageLimit"`
+	UsageCount     int64                `json:"usageCount"`
+	ViewedCount    int64                `json:"viewedCount"`
+	ExpirationDate string               `json:"expirationDate"`
+	Transactions   []TransactionSummary `json:"transactions"`
+}
+
+// TransactionSummary represents a transaction made through a payment link
+type TransactionSummary struct {
+	ID          string `json:"id"`
+	Type        string `json:"type,omitempty"`
+	Status      string `json:"status"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Reference   string `json:"reference,omitempty"`
+	TimeCreated string `json:"timeCreated"`
+}
+
+// maxUsageLimit is the largest number of payments accepted on a MULTIPLE usage link
+const maxUsageLimit = 100
+
+// Store writes of a link GP has created are retried this many times, this far apart,
+// before the link is given up on
+const (
+	storeLinkAttempts   = 3
+	storeLinkRetryDelay = 200 * time.Millisecond
+)
+
+// Page size limits for link listings
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// Link expiration defaults and limits
+const (
+	defaultExpirationDays = 10
+	maxExpirationDays     = 365
+)
+
+// parseUsage validates the requested usage mode and limit, applying defaults.
+// Usage mode defaults to SINGLE and usage limit defaults to 1.
+func parseUsage(mode, limit string) (gpapi.PaymentMethodUsageMode, int, error) {
+	usageMode := gpapi.PaymentMethodUsageMode(strings.ToUpper(strings.TrimSpace(mode)))
+	if usageMode == "" {
+		usageMode = gpapi.UsageModeSingle
+	}
+	if usageMode != gpapi.UsageModeSingle && usageMode != gpapi.UsageModeMultiple {
+		return "", 0, locale.Errorf("usageMode must be SINGLE or MULTIPLE")
+	}
+
+	usageLimit := 1
+	if limit = strings.TrimSpace(limit); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 || parsed > maxUsageLimit {
+			return "", 0, locale.Errorf("usageLimit must be a whole number between 1 and %d", maxUsageLimit)
+		}
+		usageLimit = parsed
+	}
+
+	if usageMode == gpapi.UsageModeSingle && usageLimit != 1 {
+		return "", 0, locale.Errorf("usageLimit must be 1 when usageMode is SINGLE")
+	}
+
+	return usageMode, usageLimit, nil
+}
+
+// parseCaptureMode validates the requested capture mode, defaulting to the configured mode
+func parseCaptureMode(mode string, defaultMode gpapi.CaptureMode) (gpapi.CaptureMode, error) {
+	captureMode := gpapi.CaptureMode(strings.ToUpper(strings.TrimSpace(mode)))
+	if captureMode == "" {
+		return defaultMode, nil
+	}
+	if captureMode != gpapi.CaptureModeAuto && captureMode != gpapi.CaptureModeLater {
+		return "", fmt.Errorf("captureMode must be AUTO or LATER")
+	}
+	return captureMode, nil
+}
+
+// parseExpiration determines when a link expires. Callers may supply either a number
+// of days from now or an absolute RFC3339 timestamp; without either the link expires
+// after defaultExpirationDays. The result must fall within GP's allowed window.
+func parseExpiration(days, date string, now time.Time) (time.Time, error) {
+	days = strings.TrimSpace(days)
+	date = strings.TrimSpace(date)
+
+	if days != "" && date != "" {
+		return time.Time{}, locale.Errorf("provide either expirationDays or expirationDate, not both")
+	}
+
+	expiresAt := now.Add(defaultExpirationDays * 24 * time.Hour)
+	switch {
+	case days != "":
+		parsed, err := strconv.Atoi(days)
+		if err != nil || parsed < 1 {
+			return time.Time{}, locale.Errorf("expirationDays must be a whole number of at least 1")
+		}
+		expiresAt = now.Add(time.Duration(parsed) * 24 * time.Hour)
+	case date != "":
+		parsed, err := time.Parse(time.RFC3339, date)
+		if err != nil {
+			return time.Time{}, locale.Errorf("expirationDate must be an RFC3339 timestamp, e.g. 2025-01-31T23:59:00Z")
+		}
+		if !parsed.After(now) {
+			return time.Time{}, locale.Errorf("expirationDate must be in the future")
+		}
+		expiresAt = parsed
+	}
+
+	if expiresAt.After(now.Add(maxExpirationDays * 24 * time.Hour)) {
+		return time.Time{}, locale.Errorf("expiration must be within %d days", maxExpirationDays)
+	}
+
+	return expiresAt, nil
+}
+
+// parseShipping resolves whether the link collects a shipping address and the shipping
+// charge in minor units of currency. Unset values fall back to the configured defaults;
+// a shipping charge is only allowed on shippable links.
+func parseShipping(shippableValue flexBool, amountValue string, defaults config.LinkDefaults, currency string) (bool, int64, error) {
+	shippable := defaults.Shippable
+	if value := strings.TrimSpace(string(shippableValue)); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, 0, fmt.Errorf("shippable must be true or false")
+		}
+		shippable = parsed
+	}
+
+	amountValue = strings.TrimSpace(amountValue)
+	if amountValue == "" {
+		if !shippable {
+			return false, 0, nil
+		}
+		amountValue = defaults.ShippingAmount
+	}
+
+	amount, err := money.ToMinorUnits(amountValue, currency)
+	if errors.Is(err, money.ErrNotPositive) {
+		// A zero shipping charge is valid
+		return shippable, 0, nil
+	}
+	if err != nil {
+		return false, 0, fmt.Errorf("invalid shippingAmount: %w", err)
+	}
+	if !shippable {
+		return false, 0, fmt.Errorf("shippingAmount requires a shippable link")
+	}
+	return shippable, amount, nil
+}
+
+// parseAmountRange parses the bounds of an open-amount link from major units into minor
+// units. Either bound may be empty, which leaves it at zero for no bound.
+func parseAmountRange(minValue, maxValue, currency string) (int64, int64, error) {
+	var minAmount, maxAmount int64
+	var err error
+	if strings.TrimSpace(minValue) != "" {
+		if minAmount, err = money.ToMinorUnits(minValue, currency); err != nil {
+			return 0, 0, fmt.Errorf("invalid minAmount: %w", err)
+		}
+	}
+	if strings.TrimSpace(maxValue) != "" {
+		if maxAmount, err = money.ToMinorUnits(maxValue, currency); err != nil {
+			return 0, 0, fmt.Errorf("invalid maxAmount: %w", err)
+		}
+	}
+	if maxAmount > 0 && minAmount > maxAmount {
+		return 0, 0, fmt.Errorf("minAmount must not be greater than maxAmount")
+	}
+	return minAmount, maxAmount, nil
+}
+
+// amountOutOfRange returns an AMOUNT_OUT_OF_RANGE error when an amount in minor units falls
+// outside the limits configured for its currency. field names the amount in the error.
+func (s *Server) amountOutOfRange(field string, amount int64, currency string) *LinkRequestError {
+	limit := s.amountLimits[currency]
+	var details string
+	switch {
+	case limit.Min > 0 && amount < limit.Min:
+		details = fmt.Sprintf("%s %s %s is below the minimum of %s %s", field,
+			money.FormatMinorUnits(amount, currency), currency, money.FormatMinorUnits(limit.Min, currency), currency)
+	case limit.Max > 0 && amount > limit.Max:
+		details = fmt.Sprintf("%s %s %s is above the maximum of %s %s", field,
+			money.FormatMinorUnits(amount, currency), currency, money.FormatMinorUnits(limit.Max, currency), currency)
+	default:
+		return nil
+	}
+	return &LinkRequestError{Status: http.StatusBadRequest, Code: "AMOUNT_OUT_OF_RANGE", Details: details}
+}
+
+// resolvePaymentMethods returns the payment methods requested in value, which must all be
+// among the configured methods. An empty value selects every configured method.
+func resolvePaymentMethods(configured []gpapi.PaymentMethodName, value string) ([]gpapi.PaymentMethodName, error) {
+	methods, err := gpapi.ParsePaymentMethods(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(methods) == 0 {
+		return configured, nil
+	}
+	for _, method := range methods {
+		if !slices.Contains(configured, method) {
+			return nil, fmt.Errorf("payment method %s is not enabled on this server", method)
+		}
+	}
+	return methods, nil
+}
+
+// resolveDigitalWallets returns the digital wallets requested in value, which must all be
+// available: enabled on this server and supported by the merchant account. An empty value
+// selects every available wallet.
+func resolveDigitalWallets(available []gpapi.DigitalWalletProvider, value string) ([]gpapi.DigitalWalletProvider, error) {
+	wallets, err := gpapi.ParseDigitalWallets(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(available) == 0 {
+		return nil, errors.New("DIGITAL_WALLET is not available: the merchant account supports none of the digital wallets enabled on this server")
+	}
+	if len(wallets) == 0 {
+		return available, nil
+	}
+	for _, wallet := range wallets {
+		if !slices.Contains(available, wallet) {
+			return nil, fmt.Errorf("digital wallet %s is not enabled on this server or not supported by the merchant account", wallet)
+		}
+	}
+	return wallets, nil
+}
+
+// paymentMethodStrings converts payment method names to plain strings for JSON responses
+func paymentMethodStrings(methods []gpapi.PaymentMethodName) []string {
+	names := make([]string, len(methods))
+	for i, method := range methods {
+		names[i] = string(method)
+	}
+	return names
+}
+
+// digitalWalletStrings converts digital wallet providers to plain strings for JSON responses
+func digitalWalletStrings(providers []gpapi.DigitalWalletProvider) []string {
+	names := make([]string, len(providers))
+	for i, provider := range providers {
+		names[i] = string(provider)
+	}
+	return names
+}
+
+// handleConfig handles the /config endpoint
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	caps := s.capabilities.Get(r.Context())
+	response := Response{
+		Success: true,
+		Data: ClientConfig{
+			Environment:             s.reloadable.Load().environment,
+			SupportedCurrencies:     caps.Currencies,
+			SupportedPaymentMethods: paymentMethodStrings(caps.PaymentMethods),
+			SupportedDigitalWallets: digitalWalletStrings(caps.DigitalWallets),
+			Country:                 caps.Country,
+			Captcha:                 s.captchaConfig(),
+			CSRFToken:               s.csrfToken(w, r),
+		},
+	}
+	// The frontend polls /config, so browsers may reuse it briefly and then revalidate it.
+	// The ETag covers the data, not the request ID, which differs on every response.
+	etag := jsonETag(response.Data)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(configMaxAge.Seconds())))
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleCreatePaymentLink handles the /create-payment-link endpoint
+func (s *Server) handleCreatePaymentLink(w http.ResponseWriter, r *http.Request) {
+	// Parse and validate the form data or JSON
+	var req PaymentLinkRequest
+
+	// Check Content-Type and parse accordingly
+	contentType := r.Header.Get("Content-Type")
+	if strings.Contains(contentType, "application/json") {
+		// Parse JSON request, rejecting fields this endpoint does not accept
+		if jsonErr := decodeStrictJSON(r.Body, &req); jsonErr != nil {
+			errorResponse := Response{
+				Success: false,
+				Message: "Payment link creation failed",
+				Error:   jsonErr.Info(),
+			}
+			writeJSON(w, jsonErr.Status, errorResponse)
+			return
+		}
+	} else {
+		// Parse form data
+		if err := r.ParseForm(); err != nil {
+			if writeBodyTooLarge(w, "Payment link creation failed", err) {
+				return
+			}
+			errorResponse := Response{
+				Success: false,
+				Message: "Payment link creation failed",
+				Error: &ErrorInfo{
+					Code:    "FORM_PARSE_ERROR",
+					Details: "Error parsing form data",
+				},
+			}
+			writeJSON(w, http.StatusBadRequest, errorResponse)
+			return
+		}
+
+		// Extract form values
+		req = paymentLinkRequestFromForm(r.Form)
+	}
+
+	response, linkErr := s.createLinkFromRequest(r.Context(), req)
+	if linkErr != nil {
+		writeJSON(w, linkErr.Status, Response{
+			Success: false,
+			Message: "Payment link creation failed",
+			Error:   linkErr.Info(),
+		})
+		return
+	}
+
+	// Return success response
+	writeJSON(w, http.StatusOK, Response{
+		Success:        true,
+		catalogMessage: locale.Messagef("Payment link created successfully! Link ID: %s", response.LinkID),
+		Data:           response,
+	})
+}
+
+// paymentLinkRequestFromForm reads a link creation request from form values
+func paymentLinkRequestFromForm(form url.Values) PaymentLinkRequest {
+	return PaymentLinkRequest{
+		Amount:         form.Get("amount"),
+		Currency:       form.Get("currency"),
+		Reference:      form.Get("reference"),
+		Name:           form.Get("name"),
+		Description:    form.Get("description"),
+		UsageMode:      form.Get("usageMode"),
+		UsageLimit:     form.Get("usageLimit"),
+		ExpirationDays: form.Get("expirationDays"),
+		ExpirationDate: form.Get("expirationDate"),
+		ReturnURL:      form.Get("returnUrl"),
+		StatusURL:      form.Get("statusUrl"),
+		CancelURL:      form.Get("cancelUrl"),
+		CustomerPhone:  form.Get("customerPhone"),
+		PaymentMethods: form.Get("paymentMethods"),
+		DigitalWallets: form.Get("digitalWallets"),
+		Shippable:      flexBool(form.Get("shippable")),
+		ShippingAmount: form.Get("shippingAmount"),
+		Country:        form.Get("country"),
+		CaptureMode:    form.Get("captureMode"),
+		Reminders:      flexBool(form.Get("reminders")),
+		CustomerID:     form.Get("customerId"),
+		TemplateID:     form.Get("templateId"),
+		PromoCode:      form.Get("promoCode"),
+		TaxRegion:      form.Get("taxRegion"),
+		MinAmount:      form.Get("minAmount"),
+		MaxAmount:      form.Get("maxAmount"),
+		MerchantID:     form.Get("merchantId"),
+		AccountName:    form.Get("accountName"),
+		// Sent to GP as given, unlike the text fields normalized before validation
+		DynamicDescriptor: form.Get("dynamicDescriptor"),
+		PayerEmail:        form.Get("payerEmail"),
+		BillingCountry:    form.Get("billingCountry"),
+		PayerIP:           form.Get("payerIp"),
+		Language:          form.Get("language"),
+		BrandDisplayName:  form.Get("brandDisplayName"),
+		BrandLogoURL:      form.Get("brandLogoUrl"),
+		BrandColor:        form.Get("brandColor"),
+	}
+}
+
+// LinkRequestError describes why a payment link request could not be fulfilled
+type LinkRequestError struct {
+	Status  int
+	Code    string
+	Details string
+	// GPRequestID is GP's identifier for the failed API call, when GP API caused the error
+	GPRequestID string
+	// Fields lists the invalid fields when Code is VALIDATION_ERROR or INVALID_JSON
+	Fields []FieldError
+	// Allowed lists the supported values when Code is CURRENCY_NOT_SUPPORTED
+	Allowed []string
+	// GPErrorCode and GPDetailedErrorCode are GP's own codes, when GP API caused the error
+	GPErrorCode         string
+	GPDetailedErrorCode string
+	// catalogDetails, when set, is the catalog message Details was formatted from
+	catalogDetails locale.Message
+}
+
+// newLinkRequestError returns a LinkRequestError whose details are the catalog message with
+// the given key and values
+func newLinkRequestError(status int, code, key string, args ...interface{}) *LinkRequestError {
+	details := locale.Messagef(key, args...)
+	return &LinkRequestError{Status: status, Code: code, Details: details.String(), catalogDetails: details}
+}
+
+// linkRequestErrorOf returns a LinkRequestError whose details are the message of err, such
+// as a *locale.Error from parsing a request field
+func linkRequestErrorOf(status int, code string, err error) *LinkRequestError {
+	message := locale.MessageOf(err)
+	return newLinkRequestError(status, code, message.Key, message.Args...)
+}
+
+// Error implements the error interface
+func (e *LinkRequestError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Details)
+}
+
+// Info returns the error details to include in a response
+func (e *LinkRequestError) Info() *ErrorInfo {
+	return &ErrorInfo{Code: e.Code, Details: e.Details, GPRequestID: e.GPRequestID, Fields: e.Fields, Allowed: e.Allowed,
+		GPErrorCode: e.GPErrorCode, GPDetailedErrorCode: e.GPDetailedErrorCode, catalogDetails: e.catalogDetails}
+}
+
+// CreateLink validates req and creates a payment link the same way POST /create-payment-link
+// does, for callers such as the command line that do not go through HTTP. A failed request
+// is returned as a *LinkRequestError.
+func (s *Server) CreateLink(ctx context.Context, req PaymentLinkRequest) (*PaymentLinkResponse, error) {
+	response, linkErr := s.createLinkFromRequest(ctx, req)
+	if linkErr != nil {
+		return nil, linkErr
+	}
+	return response, nil
+}
+
+// createLinkFromRequest validates a payment link request, creates the link via GP API,
+// stores it locally, and sends it by SMS when a customer phone is given
+func (s *Server) createLinkFromRequest(ctx context.Context, req PaymentLinkRequest) (_ *PaymentLinkResponse, linkErr *LinkRequestError) {
+	ctx, span := tracer().Start(ctx, "create payment link")
+	defer func() {
+		if linkErr != nil {
+			span.SetAttributes(attribute.String("error.code", linkErr.Code))
+			span.SetStatus(codes.Error, linkErr.Error())
+		}
+		span.End()
+	}()
+
+	// Start from the link template the request names, if any
+	req, linkErr = s.resolveLinkTemplate(ctx, req)
+	if linkErr != nil {
+		return nil, linkErr
+	}
+
+	// Compute the amount and itemized description from the catalog when line items are given
+	req, linkErr = s.resolveLineItems(ctx, req)
+	if linkErr != nil {
+		return nil, linkErr
+	}
+
+	// Validate field presence, length, and characters, reporting every violation at once
+	// A reference is generated later when the request omits one and a format is configured
+	req.Reference = normalizeText(req.Reference)
+	req.Name = normalizeText(req.Name)
+	req.Description = normalizeText(req.Description)
+	req.DynamicDescriptor = strings.TrimSpace(req.DynamicDescriptor)
+	req.Language = strings.TrimSpace(req.Language)
+	req.BrandDisplayName = strings.TrimSpace(req.BrandDisplayName)
+	req.BrandLogoURL = strings.TrimSpace(req.BrandLogoURL)
+	req.BrandColor = strings.TrimSpace(req.BrandColor)
+	for i, image := range req.Images {
+		req.Images[i] = strings.TrimSpace(image)
+	}
+	if fields := validateLinkRequest(req, s.linkDefaults); len(fields) > 0 {
+		return nil, validationError(fields)
+	}
+
+	// Parse amount in major units and convert to the currency's minor units. Open-amount links
+	// have no fixed amount, only the bounds of what the payer may enter.
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if linkErr = unsupportedCurrency(currency, s.linkDefaults.SupportedCurrencies); linkErr != nil {
+		return nil, linkErr
+	}
+	var minorAmount, minAmount, maxAmount int64
+	var err error
+	openAmount := req.openAmount()
+	if openAmount {
+		minAmount, maxAmount, err = parseAmountRange(req.MinAmount, req.MaxAmount, currency)
+		if err != nil {
+			return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_AMOUNT_RANGE", Details: err.Error()}
+		}
+	} else {
+		minorAmount, err = money.ToMinorUnits(req.Amount, currency)
+		if err != nil {
+			return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_AMOUNT", Details: err.Error()}
+		}
+	}
+
+	// Take the promo code discount, if any, off the amount
+	var promo config.PromoCode
+	var discount int64
+	if strings.TrimSpace(req.PromoCode) != "" {
+		if openAmount {
+			return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "PROMO_CODE_NOT_APPLICABLE", Details: "Promo codes cannot be applied to open-amount links"}
+		}
+		promo, discount, linkErr = s.promoDiscount(req.PromoCode, currency, minorAmount, time.Now())
+		if linkErr != nil {
+			return nil, linkErr
+		}
+		minorAmount -= discount
+	}
+	amount := int(minorAmount)
+
+	// Parse and validate usage mode and limit
+	usageMode, usageLimit, err := parseUsage(req.UsageMode, req.UsageLimit)
+	if err != nil {
+		return nil, linkRequestErrorOf(http.StatusBadRequest, "INVALID_USAGE", err)
+	}
+
+	// Parse and validate expiration
+	expiresAt, err := parseExpiration(req.ExpirationDays, req.ExpirationDate, time.Now())
+	if err != nil {
+		return nil, linkRequestErrorOf(http.StatusBadRequest, "INVALID_EXPIRATION", err)
+	}
+
+	// Resolve notification URLs, validating any per-request overrides
+	notifications, err := resolveNotificationURLs(s.notifications, req.ReturnURL, req.StatusURL, req.CancelURL)
+	if err != nil {
+		return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_NOTIFICATION_URL", Details: err.Error()}
+	}
+
+	// Resolve the allowed payment methods, restricting the configured set per request
+	paymentMethods, err := resolvePaymentMethods(s.linkDefaults.PaymentMethods, req.PaymentMethods)
+	if err != nil {
+		return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_PAYMENT_METHODS", Details: err.Error()}
+	}
+
+	// Offer the digital wallets that are enabled and that the merchant account supports on
+	// links accepting DIGITAL_WALLET. A link defaulting to the configured methods drops
+	// DIGITAL_WALLET, rather than failing, while the account supports no wallet.
+	var digitalWallets []gpapi.LinkDigitalWallet
+	var walletProviders []gpapi.DigitalWalletProvider
+	if slices.Contains(paymentMethods, gpapi.PaymentMethodDigitalWallet) {
+		available := s.capabilities.Get(ctx).DigitalWallets
+		if len(available) == 0 && len(paymentMethods) > 1 && strings.TrimSpace(req.PaymentMethods) == "" && strings.TrimSpace(req.DigitalWallets) == "" {
+			paymentMethods = slices.DeleteFunc(slices.Clone(paymentMethods), func(method gpapi.PaymentMethodName) bool {
+				return method == gpapi.PaymentMethodDigitalWallet
+			})
+		} else {
+			walletProviders, err = resolveDigitalWallets(available, req.DigitalWallets)
+			if err != nil {
+				return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_DIGITAL_WALLETS", Details: err.Error()}
+			}
+			for _, provider := range walletProviders {
+				digitalWallets = append(digitalWallets, gpapi.LinkDigitalWallet{Provider: provider, MerchantID: s.linkDefaults.DigitalWallets.MerchantID(provider)})
+			}
+		}
+	} else if strings.TrimSpace(req.DigitalWallets) != "" {
+		return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_DIGITAL_WALLETS", Details: "digitalWallets requires the DIGITAL_WALLET payment method"}
+	}
+
+	// Resolve shipping, defaulting to the configured settings
+	shippable, shippingAmount, err := parseShipping(req.Shippable, req.ShippingAmount, s.linkDefaults, currency)
+	if err != nil {
+		return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_SHIPPING", Details: err.Error()}
+	}
+
+	// Resolve whether payments are captured at once or only authorized
+	captureMode, err := parseCaptureMode(req.CaptureMode, s.linkDefaults.CaptureMode)
+	if err != nil {
+		return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_CAPTURE_MODE", Details: err.Error()}
+	}
+
+	// Resolve the merchant country, defaulting to the configured country
+	countryCode := s.linkDefaults.Country
+	if strings.TrimSpace(req.Country) != "" {
+		countryCode, err = country.Parse(req.Country)
+		if err != nil {
+			return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_COUNTRY", Details: err.Error()}
+		}
+	}
+
+	// Add tax to the net amount when a rate is configured for the country or region. The
+	// amount the payer enters on an open-amount link is taken as the gross amount.
+	var netAmount, taxAmount int64
+	var taxRate string
+	taxRegion := strings.ToUpper(strings.TrimSpace(req.TaxRegion))
+	if taxRegion != "" {
+		if openAmount {
+			return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_TAX_REGION", Details: "Tax cannot be added to open-amount links"}
+		}
+		if !s.tax.Enabled() {
+			return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "TAX_NOT_CONFIGURED", Details: "taxRegion was provided but no tax rates are configured"}
+		}
+		if !taxRegionPattern.MatchString(taxRegion) {
+			return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_TAX_REGION", Details: "taxRegion must be 1-3 letters or digits, such as CA"}
+		}
+	}
+	if rate, ok := s.taxRate(countryCode, taxRegion); ok && !openAmount {
+		netAmount = minorAmount
+		taxAmount = taxOn(netAmount, rate)
+		taxRate = formatTaxRate(rate)
+		minorAmount += taxAmount
+		amount = int(minorAmount)
+	}
+
+	// Keep the amount charged within the limits configured for the currency. Open-amount
+	// links take the limits as their bounds when the request leaves them out.
+	if openAmount {
+		limit := s.amountLimits[currency]
+		if minAmount == 0 {
+			minAmount = limit.Min
+		}
+		if maxAmount == 0 {
+			maxAmount = limit.Max
+		}
+		if minAmount > 0 {
+			if linkErr = s.amountOutOfRange("minAmount", minAmount, currency); linkErr != nil {
+				return nil, linkErr
+			}
+		}
+		if maxAmount > 0 {
+			if linkErr = s.amountOutOfRange("maxAmount", maxAmount, currency); linkErr != nil {
+				return nil, linkErr
+			}
+		}
+	} else if linkErr = s.amountOutOfRange("amount", minorAmount, currency); linkErr != nil {
+		return nil, linkErr
+	}
+
+	// Validate the customer phone number when the link should be sent by SMS
+	var customerPhone string
+	if req.CustomerPhone != "" {
+		if s.sms == nil {
+			return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "SMS_NOT_CONFIGURED", Details: "customerPhone was provided but SMS delivery is not configured"}
+		}
+		customerPhone, err = validatePhone(req.CustomerPhone)
+		if err != nil {
+			return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_PHONE", Details: err.Error()}
+		}
+	}
+
+	// Payment reminders are sent before the link expires unless the request opts out
+	reminders := true
+	if value := strings.TrimSpace(string(req.Reminders)); value != "" {
+		reminders, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_REMINDERS", Details: "reminders must be true or false"}
+		}
+	}
+
+	// Associate the link with a customer from the local directory
+	var customer *store.Customer
+	customerID := strings.TrimSpace(req.CustomerID)
+	if customerID != "" {
+		if !customerIDPattern.MatchString(customerID) {
+			return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_CUSTOMER_ID", Details: "Invalid customer ID"}
+		}
+		if customer, err = s.links.GetCustomer(ctx, customerID); errors.Is(err, store.ErrCustomerNotFound) {
+			return nil, newLinkRequestError(http.StatusBadRequest, "CUSTOMER_NOT_FOUND", "Customer %s not found", customerID)
+		} else if err != nil {
+			logging.FromContext(ctx).Error("Error reading customer", "customer_id", customerID, "error", err)
+			return nil, &LinkRequestError{Status: http.StatusInternalServerError, Code: "STORE_ERROR", Details: "Error reading stored customer"}
+		}
+	}
+
+	// Screen the payer against the blocklists, falling back to the customer's email
+	payerEmail := req.PayerEmail
+	if strings.TrimSpace(payerEmail) == "" && customer != nil {
+		payerEmail = customer.Email
+	}
+	if linkErr = s.screenPayer(ctx, payerEmail, req.BillingCountry); linkErr != nil {
+		return nil, linkErr
+	}
+
+	// Resolve the merchant account selected by the request, if any
+	merchantAccount, merchantSelected, linkErr := s.selectMerchantAccount(req.MerchantID, req.AccountName)
+	if linkErr != nil {
+		return nil, linkErr
+	}
+
+	// Prepare data; the text fields are normalized and validation has already checked lengths and characters
+	reference := req.Reference
+	name := req.Name
+	description := req.Description
+	if taxRate != "" {
+		description += "\n" + s.taxBreakdown(netAmount, taxAmount, taxRate, currency)
+		if utf8.RuneCountInString(description) > maxDescriptionLength {
+			return nil, validationError([]FieldError{fieldError("description", FieldTooLong,
+				"description must be at most %d characters including the tax breakdown", maxDescriptionLength)})
+		}
+	}
+
+	// Generate the reference when the request has none
+	if reference == "" {
+		if reference, linkErr = s.generateReference(ctx); linkErr != nil {
+			return nil, linkErr
+		}
+	}
+
+	// Enforce the velocity limits on links for the same reference, customer, and API key,
+	// counting this link now and giving the count back if the link is not created
+	now := time.Now()
+	reservation, linkErr := s.reserveVelocity(ctx, velocityKeys(ctx, reference, customer), minorAmount, currency, now)
+	if linkErr != nil {
+		return nil, linkErr
+	}
+	if reservation != "" {
+		defer func() {
+			if linkErr == nil {
+				return
+			}
+			if err := s.links.ReleaseVelocity(context.WithoutCancel(ctx), reservation); err != nil {
+				logging.FromContext(ctx).Error("Error releasing link velocity", "error", err)
+			}
+		}()
+	}
+
+	// Count a use of the promo code, giving it back if the link is not created
+	if promo.Code != "" {
+		if err := s.links.ClaimPromoCode(ctx, promo.Code, promo.MaxUses); errors.Is(err, store.ErrPromoCodeUsedUp) {
+			return nil, newLinkRequestError(http.StatusBadRequest, "PROMO_CODE_USED_UP", "Promo code %s has reached its maximum uses", promo.Code)
+		} else if err != nil {
+			logging.FromContext(ctx).Error("Error claiming promo code", "promo_code", promo.Code, "error", err)
+			return nil, &LinkRequestError{Status: http.StatusInternalServerError, Code: "STORE_ERROR", Details: "Error recording promo code use"}
+		}
+		defer func() {
+			if linkErr == nil {
+				return
+			}
+			if err := s.links.ReleasePromoCode(context.WithoutCancel(ctx), promo.Code); err != nil {
+				logging.FromContext(ctx).Error("Error releasing promo code", "promo_code", promo.Code, "error", err)
+			}
+		}()
+	}
+
+	// Get access token (cached between requests)
+	tokenResponse, err := s.gp.Token(ctx)
+	if err != nil {
+		return nil, &LinkRequestError{Status: http.StatusInternalServerError, Code: "TOKEN_GENERATION_ERROR", Details: err.Error(), GPRequestID: gpapi.RequestIDOf(err)}
+	}
+
+	// Set account name from token response or default to "paylink"
+	accountName := "paylink"
+	if tokenResponse.TransactionProcessingAccountName != "" {
+		accountName = tokenResponse.TransactionProcessingAccountName
+	}
+
+	// Create PayByLink data object
+	expirationDate := expiresAt.UTC().Format(gpapi.DateTimeLayout)
+
+	linkType := gpapi.PayByLinkTypePayment
+	if openAmount {
+		linkType = gpapi.PayByLinkTypeHostedPaymentPage
+	}
+
+	payByLinkData := gpapi.LinkData{
+		AccountName:    accountName,
+		Type:           linkType,   // HOSTED_PAYMENT_PAGE lets the payer enter the amount
+		UsageMode:      usageMode,  // SINGLE or MULTIPLE
+		UsageLimit:     usageLimit, // 1 for SINGLE, up to maxUsageLimit for MULTIPLE
+		Reference:      reference,
+		Name:           name,
+		Description:    description,
+		Shippable:      gpapi.YesNo(shippable),
+		ShippingAmount: int(shippingAmount), // Shipping charge in minor units
+		ExpirationDate: expirationDate,
+		Language:       hostedPageLanguage(req.Language),
+		Branding:       linkBranding(req, s.linkDefaults.Branding),
+		Images:         req.Images,
+		Transactions: gpapi.LinkTransactions{
+			AllowedPaymentMethods: paymentMethods,
+			Channel:               s.linkDefaults.Channel, // CNP (Card Not Present) unless configured
+			Country:               countryCode,
+			Amount:                amount, // Amount in minor units, omitted on open-amount links
+			Currency:              currency,
+			CaptureMode:           captureMode, // LATER links are only authorized until captured
+			MinAmount:             int(minAmount),
+			MaxAmount:             int(maxAmount),
+			DynamicDescriptor:     req.DynamicDescriptor,
+			Payer:                 payerHints(req),
+			DigitalWallets:        digitalWallets, // Apple Pay and Google Pay under DIGITAL_WALLET
+		},
+		Notifications: notifications,
+	}
+
+	// Add merchant_id if available
+	if tokenResponse.MerchantID != "" {
+		payByLinkData.MerchantID = tokenResponse.MerchantID
+	}
+
+	// A selected merchant account replaces the token's, keeping the token's account name
+	// when the entry names none; the response reports the account name used
+	if merchantSelected {
+		payByLinkData.MerchantID = merchantAccount.MerchantID
+		if merchantAccount.AccountName != "" {
+			payByLinkData.AccountName = merchantAccount.AccountName
+		}
+		merchantAccount.AccountName = payByLinkData.AccountName
+	}
+
+	// Create payment link via GP API
+	linkResponse, err := s.gp.CreateLink(ctx, payByLinkData)
+	if err != nil {
+		status, info := classifyGPError(err)
+		return nil, &LinkRequestError{Status: status, Code: info.Code, Details: info.Details, GPRequestID: info.GPRequestID,
+			GPErrorCode: info.GPErrorCode, GPDetailedErrorCode: info.GPDetailedErrorCode}
+	}
+
+	// Validate payment link URL
+	if linkResponse.URL == "" {
+		return nil, &LinkRequestError{Status: http.StatusInternalServerError, Code: "INVALID_RESPONSE", Details: "No payment link URL in response"}
+	}
+
+	// Store the link so status notifications and lookups can update it
+	storedLink := &store.Link{
+		ID:              linkResponse.ID,
+		URL:             linkResponse.URL,
+		Reference:       reference,
+		Amount:          minorAmount,
+		Currency:        currency,
+		Status:          store.LinkStatusActive,
+		CustomerPhone:   customerPhone,
+		ExpiresAt:       expiresAt,
+		RemindersOptOut: !reminders,
+		CustomerID:      customerID,
+		PromoCode:       promo.Code,
+		DiscountAmount:  discount,
+		TaxAmount:       taxAmount,
+		TaxRate:         taxRate,
+		MinAmount:       minAmount,
+		MaxAmount:       maxAmount,
+		Metadata:        req.Metadata,
+		ShortCode:       newShortCode(),
+		Itemized:        req.itemized,
+	}
+	if err := s.storeCreatedLink(ctx, storedLink); err != nil {
+		return nil, s.abandonUnstoredLink(ctx, linkResponse.ID, err)
+	}
+	s.wakeOutboxRelay()
+	shortLink := s.shortLinkURL(storedLink.ShortCode)
+	s.audit(ctx, store.AuditEntry{Action: auditLinkCreate, Resource: linkResponse.ID, LinkID: linkResponse.ID}, map[string]interface{}{
+		"amount":     money.FormatMinorUnits(minorAmount, currency),
+		"currency":   currency,
+		"customerId": customerID,
+		"promoCode":  promo.Code,
+		"expiresAt":  expiresAt,
+		"merchantId": merchantAccount.MerchantID,
+	})
+	s.pruneVelocity(ctx, now)
+
+	// Send the link to the customer; a failed SMS is reported in the delivery, not as a failed creation
+	var smsDelivery *store.Delivery
+	if customerPhone != "" {
+		smsDelivery, _ = s.sendLinkSMS(ctx, storedLink, customerPhone, linkSMSBody(storedLink))
+	}
+
+	span.SetAttributes(
+		attribute.String("payment_link.id", linkResponse.ID),
+		attribute.Int("payment_link.amount", amount),
+		attribute.String("payment_link.currency", currency),
+	)
+	logging.FromContext(ctx).Info("Payment link created",
+		"link_id", linkResponse.ID,
+		"reference", reference,
+		"name", name,
+		"amount", amount,
+		"currency", currency,
+		"api_key", apiKeyNameFrom(ctx),
+		"merchant_id", payByLinkData.MerchantID,
+	)
+
+	return &PaymentLinkResponse{
+		PaymentLink:    linkResponse.URL,
+		ShortLink:      shortLink,
+		LinkID:         linkResponse.ID,
+		Reference:      reference,
+		Amount:         amount,
+		DisplayAmount:  money.FormatMinorUnits(minorAmount, currency),
+		Currency:       currency,
+		UsageMode:      string(usageMode),
+		UsageLimit:     usageLimit,
+		ExpiresAt:      expiresAt.UTC().Format(time.RFC3339),
+		PaymentMethods: paymentMethodStrings(paymentMethods),
+		DigitalWallets: digitalWalletStrings(walletProviders),
+		Shippable:      shippable,
+		ShippingAmount: shippingAmount,
+		Country:        countryCode,
+		CaptureMode:    string(captureMode),
+		Reminders:      reminders,
+		CustomerID:     customerID,
+		PromoCode:      promo.Code,
+		DiscountAmount: discount,
+		NetAmount:      netAmount,
+		TaxAmount:      taxAmount,
+		TaxRate:        taxRate,
+		MinAmount:      minAmount,
+		MaxAmount:      maxAmount,
+		Metadata:       req.Metadata,
+		SMSDelivery:    smsDelivery,
+		MerchantID:     merchantAccount.MerchantID,
+		AccountName:    merchantAccount.AccountName,
+		// Echoed so callers can check what the payer's statement will show
+		DynamicDescriptor: req.DynamicDescriptor,
+		Language:          payByLinkData.Language,
+		Branding:          brandingResponse(payByLinkData.Branding),
+		Images:            req.Images,
+	}, nil
+}
+
+// linkIDPattern matches the format of GP API link identifiers
+var linkIDPattern = regexp.MustCompile(`^[A-Za-z0-9_\-]{1,64}$`)
+
+// handleGetPaymentLink handles GET requests to the /payment-link/{id} endpoint
+func (s *Server) handleGetPaymentLink(w http.ResponseWriter, r *http.Request) {
+	linkID := r.PathValue("id")
+	if !linkIDPattern.MatchString(linkID) {
+		writeError(w, http.StatusBadRequest, "Payment link lookup failed", "INVALID_LINK_ID", "Invalid payment link ID")
+		return
+	}
+
+	linkDetail, err := s.gp.GetLink(r.Context(), linkID)
+	if err != nil {
+		writeGPError(w, "Payment link lookup failed", err)
+		return
+	}
+
+	detail := PaymentLinkDetailResponse{
+		LinkID:         linkDetail.ID,
+		PaymentLink:    linkDetail.URL,
+		Status:         linkDetail.Status,
+		Reference:      linkDetail.Reference,
+		Name:           linkDetail.Name,
+		Currency:       linkDetail.Transactions.Currency,
+		UsageMode:      linkDetail.UsageMode,
+		ExpirationDate: linkDetail.ExpirationDate,
+		Transactions:   []TransactionSummary{},
+	}
+	detail.Amount, _ = linkDetail.Transactions.Amount.Int64()
+	detail.UsageLimit, _ = linkDetail.UsageLimit.Int64()
+	detail.UsageCount, _ = linkDetail.UsageCount.Int64()
+	detail.ViewedCount, _ = linkDetail.ViewedCount.Int64()
+
+	for _, transaction := range linkDetail.Transactions.TransactionList {
+		detail.Transactions = append(detail.Transactions, newTransactionSummary(transaction))
+		if transactionPaid(transaction.Status) {
+			detail.Paid = true
+		}
+	}
+
+	// A status notification may have arrived before GP's reporting caught up
+	if stored, err := s.links.GetLink(r.Context(), linkDetail.ID); err == nil && stored.Status == store.LinkStatusPaid {
+		detail.Paid = true
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    detail,
+	})
+}
+
+// handleUpdatePaymentLink handles PATCH requests to the /payment-link/{id} endpoint.
+// The link is fetched first so only changes GP accepts on an active link are forwarded.
+func (s *Server) handleUpdatePaymentLink(w http.ResponseWriter, r *http.Request) {
+	linkID := r.PathValue("id")
+	if !linkIDPattern.MatchString(linkID) {
+		writeError(w, http.StatusBadRequest, "Payment link update failed", "INVALID_LINK_ID", "Invalid payment link ID")
+		return
+	}
+
+	// Unknown fields are rejected as on creation, so a mistyped field is not silently ignored
+	var req PaymentLinkUpdateRequest
+	if jsonErr := decodeStrictJSON(r.Body, &req); jsonErr != nil {
+		writeJSON(w, jsonErr.Status, Response{Success: false, Message: "Payment link update failed", Error: jsonErr.Info()})
+		return
+	}
+
+	if req.Amount == "" && req.Name == "" && req.Description == "" && req.ExpirationDays == "" && req.ExpirationDate == "" {
+		writeError(w, http.StatusBadRequest, "Payment link update failed", "NO_CHANGES",
+			"Provide at least one of amount, name, description, expirationDays, or expirationDate")
+		return
+	}
+
+	current, err := s.gp.GetLink(r.Context(), linkID)
+	if err != nil {
+		writeGPError(w, "Payment link update failed", err)
+		return
+	}
+
+	if !strings.EqualFold(current.Status, store.LinkStatusActive) {
+		writeErrorf(w, http.StatusConflict, "Payment link update failed", "LINK_NOT_EDITABLE",
+			"Only active links can be edited; link status is %s", current.Status)
+		return
+	}
+
+	var patch gpapi.LinkUpdate
+	var storeUpdate store.LinkUpdate
+
+	if req.Amount != "" {
+		if current.Type == string(gpapi.PayByLinkTypeHostedPaymentPage) {
+			writeError(w, http.StatusConflict, "Payment link update failed", "LINK_NOT_EDITABLE",
+				"Open-amount links have no fixed amount to change")
+			return
+		}
+		if usageCount, _ := current.UsageCount.Int64(); usageCount > 0 {
+			writeError(w, http.StatusConflict, "Payment link update failed", "LINK_NOT_EDITABLE",
+				"Amount cannot be changed after the link has been used")
+			return
+		}
+		// A discount, tax breakdown, or itemized description was worked out from the amount
+		// the link was created with, and would no longer match a new one
+		stored, err := s.links.GetLink(r.Context(), linkID)
+		if err != nil && !errors.Is(err, store.ErrLinkNotFound) {
+			logging.FromContext(r.Context()).Error("Error reading stored link", "link_id", linkID, "error", err)
+			writeError(w, http.StatusInternalServerError, "Payment link update failed", "STORE_ERROR", "Error reading stored link")
+			return
+		}
+		if stored != nil && (stored.Itemized || stored.DiscountAmount > 0 || stored.TaxRate != "") {
+			writeError(w, http.StatusConflict, "Payment link update failed", "LINK_NOT_EDITABLE",
+				"Amount cannot be changed on links priced from line items or with a promo code or tax")
+			return
+		}
+		amount, err := money.ToMinorUnits(req.Amount, current.Transactions.Currency)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Payment link update failed", "INVALID_AMOUNT", err.Error())
+			return
+		}
+		if linkErr := s.amountOutOfRange("amount", amount, current.Transactions.Currency); linkErr != nil {
+			writeError(w, linkErr.Status, "Payment link update failed", linkErr.Code, linkErr.Details)
+			return
+		}
+		patch.Transactions = &gpapi.LinkUpdateTransactions{Amount: int(amount)}
+		storeUpdate.Amount = &amount
+	}
+
+	// Names and descriptions are validated as on creation, reporting every violation at once
+	var fields []FieldError
+	validateText := func(field, value string, limit int, allowNewlines bool) {
+		switch {
+		case utf8.RuneCountInString(value) > limit:
+			fields = append(fields, fieldError(field, FieldTooLong, "%s must be at most %d characters", field, limit))
+		case hasControlCharacters(value, allowNewlines):
+			fields = append(fields, fieldError(field, FieldInvalidCharacters, "%s must not contain control characters", field))
+		case !inAllowedScripts(value, s.linkDefaults.AllowedScripts):
+			fields = append(fields, fieldError(field, FieldInvalidCharacters,
+				"%s may only contain letters from the %s scripts", field, strings.Join(s.linkDefaults.AllowedScripts, ", ")))
+		}
+	}
+	if name := normalizeText(req.Name); name != "" {
+		validateText("name", name, maxNameLength, false)
+		patch.Name = name
+	}
+	if description := normalizeText(req.Description); description != "" {
+		validateText("description", description, maxDescriptionLength, true)
+		patch.Description = description
+	}
+	if len(fields) > 0 {
+		linkErr := validationError(fields)
+		writeJSON(w, linkErr.Status, Response{Success: false, Message: "Payment link update failed", Error: linkErr.Info()})
+		return
+	}
+
+	if req.ExpirationDays != "" || req.ExpirationDate != "" {
+		expiresAt, err := parseExpiration(req.ExpirationDays, req.ExpirationDate, time.Now())
+		if err != nil {
+			message := locale.MessageOf(err)
+			writeErrorf(w, http.StatusBadRequest, "Payment link update failed", "INVALID_EXPIRATION", message.Key, message.Args...)
+			return
+		}
+		patch.ExpirationDate = expiresAt.UTC().Format(gpapi.DateTimeLayout)
+		storeUpdate.ExpiresAt = &expiresAt
+	}
+
+	updated, err := s.gp.UpdateLink(r.Context(), linkID, patch)
+	if err != nil {
+		writeGPError(w, "Payment link update failed", err)
+		return
+	}
+
+	if storeUpdate.Amount != nil || storeUpdate.ExpiresAt != nil {
+		if err := s.links.UpdateLink(r.Context(), linkID, storeUpdate); err != nil && !errors.Is(err, store.ErrLinkNotFound) {
+			logging.FromContext(r.Context()).Error("Error updating stored link details", "link_id", linkID, "error", err)
+		}
+	}
+
+	changes := map[string]interface{}{}
+	if patch.Transactions != nil {
+		from, _ := current.Transactions.Amount.Int64()
+		changes["amount"] = auditChange{
+			From: money.FormatMinorUnits(from, current.Transactions.Currency),
+			To:   money.FormatMinorUnits(*storeUpdate.Amount, current.Transactions.Currency),
+		}
+	}
+	if patch.Name != "" {
+		changes["name"] = auditRedacted
+	}
+	if patch.Description != "" {
+		changes["description"] = auditRedacted
+	}
+	if patch.ExpirationDate != "" {
+		changes["expirationDate"] = auditChange{From: current.ExpirationDate, To: patch.ExpirationDate}
+	}
+	s.audit(r.Context(), store.AuditEntry{Action: auditLinkUpdate, Resource: linkID, LinkID: linkID}, changes)
+
+	writeJSON(w, http.StatusOK, Response{
+		Success:        true,
+		catalogMessage: locale.Messagef("Payment link %s updated", linkID),
+		Data: map[string]string{
+			"linkId": updated.ID,
+			"status": updated.Status,
+		},
+	})
+}
+
+// handleCancelPaymentLink handles the /payment-link/{id}/cancel endpoint
+func (s *Server) handleCancelPaymentLink(w http.ResponseWriter, r *http.Request) {
+	linkID := r.PathValue("id")
+	if !linkIDPattern.MatchString(linkID) {
+		writeError(w, http.StatusBadRequest, "Payment link cancellation failed", "INVALID_LINK_ID", "Invalid payment link ID")
+		return
+	}
+
+	status, err := s.CancelLink(r.Context(), linkID)
+	if err != nil {
+		writeGPError(w, "Payment link cancellation failed", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success:        true,
+		catalogMessage: locale.Messagef("Payment link %s cancelled", linkID),
+		Data: map[string]string{
+			"linkId": linkID,
+			"status": status,
+		},
+	})
+}
+
+// listLinkPage returns up to filter.Limit stored links matching filter, newest first,
+// with the cursor for the next page when there is one
+func (s *Server) listLinkPage(ctx context.Context, filter store.LinkFilter) ([]*store.Link, *Pagination, error) {
+	// Fetch one extra link to find out whether another page exists
+	pageFilter := filter
+	pageFilter.Limit = filter.Limit + 1
+	links, err := s.links.ListLinks(ctx, pageFilter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pagination := &Pagination{Limit: filter.Limit}
+	if len(links) > filter.Limit {
+		links = links[:filter.Limit]
+		last := links[len(links)-1]
+		pagination.HasMore = true
+		pagination.NextCursor = store.LinkCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	return links, pagination, nil
+}
+
+// storeCreatedLink stores a link GP has created, retrying failed writes. The link's
+// status notifications and lookups depend on it, so a momentary store error should not lose it.
+func (s *Server) storeCreatedLink(ctx context.Context, link *store.Link) error {
+	var err error
+	for attempt := 1; attempt <= storeLinkAttempts; attempt++ {
+		if err = s.links.CreateLink(ctx, link, newOutboxEvent(webhooks.EventLinkCreated)); err == nil {
+			return nil
+		}
+		logging.FromContext(ctx).Warn("Error storing payment link", "link_id", link.ID, "attempt", attempt, "error", err)
+		if attempt == storeLinkAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(storeLinkRetryDelay):
+		}
+	}
+	return err
+}
+
+// abandonUnstoredLink deactivates a link GP created but this server could not store, so
+// it cannot take payments nobody records, and returns the error failing its creation.
+// The failure releases the velocity and promo code use reserved for the link.
+func (s *Server) abandonUnstoredLink(ctx context.Context, linkID string, storeErr error) *LinkRequestError {
+	logging.FromContext(ctx).Error("Error storing payment link, deactivating it at GP", "link_id", linkID, "error", storeErr)
+	// Deactivate even if the client has gone, as the link would otherwise stay payable
+	if _, err := s.gp.UpdateLink(context.WithoutCancel(ctx), linkID, gpapi.LinkStatusUpdate{Status: store.LinkStatusInactive}); err != nil {
+		logging.FromContext(ctx).Error("Error deactivating unstored payment link; cancel it at GP by hand", "link_id", linkID, "error", err)
+		return &LinkRequestError{Status: http.StatusInternalServerError, Code: "STORE_ERROR",
+			Details: fmt.Sprintf("Error storing payment link %s, which could not be deactivated at GP", linkID)}
+	}
+	return &LinkRequestError{Status: http.StatusInternalServerError, Code: "STORE_ERROR",
+		Details: "Error storing payment link; it was deactivated at GP"}
+}
+
+// CancelLink deactivates a payment link at GP and records it as inactive, returning the
+// status GP reports. GP API failures are returned unchanged for gpErrorInfo to classify.
+func (s *Server) CancelLink(ctx context.Context, linkID string) (string, error) {
+	linkDetail, err := s.gp.UpdateLink(ctx, linkID, gpapi.LinkStatusUpdate{Status: store.LinkStatusInactive})
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.links.UpdateStatus(ctx, linkID, store.LinkStatusInactive, newOutboxEvent(webhooks.EventLinkCancelled)); err == nil {
+		s.wakeOutboxRelay()
+	} else if !errors.Is(err, store.ErrLinkNotFound) {
+		// GP has already deactivated the link, so report success and surface the local failure in logs
+		logging.FromContext(ctx).Error("Error updating stored status for cancelled link", "link_id", linkID, "error", err)
+	}
+	s.audit(ctx, store.AuditEntry{Action: auditLinkCancel, Resource: linkID, LinkID: linkID}, map[string]interface{}{
+		"status": auditChange{To: store.LinkStatusInactive},
+	})
+
+	if linkDetail.Status == "" {
+		return store.LinkStatusInactive, nil
+	}
+	return linkDetail.Status, nil
+}
+
+// parseDateParam parses a date filter given as RFC3339 or YYYY-MM-DD.
+// Plain dates used as an upper bound include the whole day.
+func parseDateParam(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// linkStatusFromGP maps a GP API link status to a local link status
+func linkStatusFromGP(status string) (string, bool) {
+	switch strings.ToUpper(status) {
+	case store.LinkStatusActive, store.LinkStatusInactive, store.LinkStatusExpired, store.LinkStatusPaid:
+		return strings.ToUpper(status), true
+	default:
+		return "", false
+	}
+}
+
+// refreshLinkStatuses updates stored link statuses from GP's link search for the given window.
+// Links already marked as paid locally are left unchanged.
+func (s *Server) refreshLinkStatuses(ctx context.Context, from, to time.Time) error {
+	gpLinks, err := s.gp.SearchLinks(ctx, from, to, maxListLimit)
+	if err != nil {
+		return err
+	}
+
+	for _, gpLink := range gpLinks {
+		status, ok := linkStatusFromGP(gpLink.Status)
+		if !ok {
+			continue
+		}
+		stored, err := s.links.GetLink(ctx, gpLink.ID)
+		if err != nil {
+			// Links created outside this server are not tracked locally
+			continue
+		}
+		if stored.Status == status || stored.Status == store.LinkStatusPaid {
+			continue
+		}
+		if err := s.links.UpdateStatus(ctx, gpLink.ID, status, newOutboxEvent(eventForStatus(status))); err != nil {
+			return err
+		}
+		s.wakeOutboxRelay()
+	}
+	return nil
+}
+
+// linkFilterFromQuery reads the filters shared by the link listing and export: reference,
+// status, currency, tag, from, and to. An invalid filter is reported in the returned ErrorInfo.
+func linkFilterFromQuery(query url.Values) (store.LinkFilter, *ErrorInfo) {
+	filter := store.LinkFilter{
+		Reference: strings.TrimSpace(query.Get("reference")),
+		Status:    strings.ToUpper(strings.TrimSpace(query.Get("status"))),
+		Currency:  strings.ToUpper(strings.TrimSpace(query.Get("currency"))),
+	}
+
+	metadata, err := parseTagFilters(query["tag"])
+	if err != nil {
+		return filter, &ErrorInfo{Code: "INVALID_TAG", Details: err.Error()}
+	}
+	filter.Metadata = metadata
+
+	if value := query.Get("from"); value != "" {
+		from, err := parseDateParam(value, false)
+		if err != nil {
+			return filter, &ErrorInfo{Code: "INVALID_DATE", Details: "from must be RFC3339 or YYYY-MM-DD"}
+		}
+		filter.CreatedFrom = from
+	}
+	if value := query.Get("to"); value != "" {
+		to, err := parseDateParam(value, true)
+		if err != nil {
+			return filter, &ErrorInfo{Code: "INVALID_DATE", Details: "to must be RFC3339 or YYYY-MM-DD"}
+		}
+		filter.CreatedTo = to
+	}
+	return filter, nil
+}
+
+// handleListPaymentLinks handles the /payment-links endpoint
+func (s *Server) handleListPaymentLinks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter, invalid := linkFilterFromQuery(query)
+	if invalid != nil {
+		writeError(w, http.StatusBadRequest, "Payment link listing failed", invalid.Code, invalid.Details)
+		return
+	}
+	filter.Limit = defaultListLimit
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			writeErrorf(w, http.StatusBadRequest, "Payment link listing failed", "INVALID_LIMIT",
+				"limit must be between 1 and %d", maxListLimit)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := store.DecodeLinkCursor(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Payment link listing failed", "INVALID_CURSOR", err.Error())
+			return
+		}
+		filter.After = cursor
+	}
+
+	// Optionally bring local statuses up to date with GP before listing
+	if query.Get("refresh") == "true" {
+		from, to := filter.CreatedFrom, filter.CreatedTo
+		if to.IsZero() {
+			to = time.Now()
+		}
+		if from.IsZero() {
+			from = to.Add(-defaultExpirationDays * 24 * time.Hour)
+		}
+		if err := s.refreshLinkStatuses(r.Context(), from, to); err != nil {
+			logging.FromContext(r.Context()).Warn("Refreshing link statuses from GP API failed", "error", err)
+		}
+	}
+
+	links, pagination, err := s.listLinkPage(r.Context(), filter)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing payment links", "error", err)
+		writeError(w, http.StatusInternalServerError, "Payment link listing failed", "STORE_ERROR", "Error reading stored payment links")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success:    true,
+		Data:       links,
+		Pagination: pagination,
+	})
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// fakeGP creates links numbered LNK_1, LNK_2, and so on, failing with createErr when it
+// is set, and records the status updates made to them. It refunds the transactions it
+// holds, failing with refundErr when it is set. Calls to the methods it does not
+// implement panic.
+type fakeGP struct {
+	gpapi.LinksClient
+	created      int
+	createErr    error
+	updates      map[string]interface{}
+	transactions map[string]*gpapi.Transaction
+	refunds      []int64
+	refundErr    error
+}
+
+func (g *fakeGP) Token(context.Context) (*gpapi.TokenResponse, error) {
+	return &gpapi.TokenResponse{Token: "token", MerchantID: "MER_1", TransactionProcessingAccountName: "transaction_processing"}, nil
+}
+
+func (g *fakeGP) CreateLink(context.Context, gpapi.LinkData) (*gpapi.LinkResponse, error) {
+	if g.createErr != nil {
+		return nil, g.createErr
+	}
+	g.created++
+	id := fmt.Sprintf("LNK_%d", g.created)
+	return &gpapi.LinkResponse{ID: id, URL: "https://pay.example.com/" + id}, nil
+}
+
+func (g *fakeGP) UpdateLink(_ context.Context, id string, patch interface{}) (*gpapi.LinkDetail, error) {
+	if g.updates == nil {
+		g.updates = make(map[string]interface{})
+	}
+	g.updates[id] = patch
+	return &gpapi.LinkDetail{}, nil
+}
+
+func (g *fakeGP) GetTransaction(_ context.Context, id string) (*gpapi.Transaction, error) {
+	transaction, ok := g.transactions[id]
+	if !ok {
+		return nil, &gpapi.APIError{StatusCode: http.StatusNotFound, ErrorCode: "RESOURCE_NOT_FOUND"}
+	}
+	return transaction, nil
+}
+
+func (g *fakeGP) RefundTransaction(_ context.Context, id string, amount int64) (*gpapi.Transaction, error) {
+	if g.refundErr != nil {
+		return nil, g.refundErr
+	}
+	g.refunds = append(g.refunds, amount)
+	return &gpapi.Transaction{ID: "TRN_REFUND", Status: "CAPTURED", Type: "REFUND"}, nil
+}
+
+// failingCreateStore is a link store whose link writes fail
+type failingCreateStore struct {
+	*store.SQLiteLinkStore
+	attempts int
+}
+
+func (s *failingCreateStore) CreateLink(context.Context, *store.Link, *store.OutboxEvent) error {
+	s.attempts++
+	return errors.New("database is locked")
+}
+
+func TestUpdatePaymentLinkDecoding(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantField  string
+	}{
+		{name: "unknown field", body: `{"name":"Invoice","currency":"USD"}`,
+			wantStatus: http.StatusBadRequest, wantCode: "INVALID_JSON", wantField: "currency"},
+		{name: "mistyped field", body: `{"expiryDate":"2030-01-01"}`,
+			wantStatus: http.StatusBadRequest, wantCode: "INVALID_JSON", wantField: "expiryDate"},
+		{name: "wrong type", body: `{"amount":30}`,
+			wantStatus: http.StatusBadRequest, wantCode: "INVALID_JSON", wantField: "amount"},
+		{name: "malformed", body: `{"name":`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_JSON"},
+		{name: "no changes", body: `{}`, wantStatus: http.StatusBadRequest, wantCode: "NO_CHANGES"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/payment-link/LNK_1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.SetPathValue("id", "LNK_1")
+			rec := httptest.NewRecorder()
+			(&Server{}).handleUpdatePaymentLink(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			response := decodeResponse(t, rec)
+			if code := errorCode(response); code != tt.wantCode {
+				t.Errorf("error code = %q, want %q", code, tt.wantCode)
+			}
+			if tt.wantField != "" && (len(response.Error.Fields) != 1 || response.Error.Fields[0].Field != tt.wantField) {
+				t.Errorf("fields = %+v, want one for %s", response.Error.Fields, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestCreateLinkStoreFailure(t *testing.T) {
+	links := &failingCreateStore{SQLiteLinkStore: newTestStore(t)}
+	gp := &fakeGP{}
+	s := New(newTestConfig(config.APIKeys{}), gp, links, nil, http.DefaultClient)
+
+	_, err := s.CreateLink(context.Background(), PaymentLinkRequest{Amount: "10.00", Currency: "USD", Reference: "INV-1", Name: "Invoice", Description: "Invoice 1"})
+	var linkErr *LinkRequestError
+	if !errors.As(err, &linkErr) || linkErr.Status != http.StatusInternalServerError || linkErr.Code != "STORE_ERROR" {
+		t.Fatalf("error = %v, want 500 STORE_ERROR", err)
+	}
+	if links.attempts != storeLinkAttempts {
+		t.Errorf("store attempts = %d, want %d", links.attempts, storeLinkAttempts)
+	}
+	update, ok := gp.updates["LNK_1"].(gpapi.LinkStatusUpdate)
+	if !ok || update.Status != store.LinkStatusInactive {
+		t.Errorf("GP update = %+v, want the link deactivated", gp.updates["LNK_1"])
+	}
+}
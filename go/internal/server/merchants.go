@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+)
+
+// selectMerchantAccount finds the configured merchant account a link request selects with
+// its merchantId and accountName, either of which may be left empty. The first entry of
+// MERCHANT_ACCOUNTS matching the fields given is used. ok is false when the request selects
+// none, so the link is created for the merchant and account of the access token.
+func (s *Server) selectMerchantAccount(merchantID, accountName string) (account config.MerchantAccount, ok bool, linkErr *LinkRequestError) {
+	merchantID = strings.TrimSpace(merchantID)
+	accountName = strings.TrimSpace(accountName)
+	if merchantID == "" && accountName == "" {
+		return config.MerchantAccount{}, false, nil
+	}
+	if len(s.merchantAccounts) == 0 {
+		return config.MerchantAccount{}, false, &LinkRequestError{Status: http.StatusBadRequest, Code: "MERCHANT_SELECTION_DISABLED",
+			Details: "merchantId and accountName are only accepted when MERCHANT_ACCOUNTS is configured"}
+	}
+
+	for _, account := range s.merchantAccounts {
+		if merchantID != "" && account.MerchantID != merchantID {
+			continue
+		}
+		if accountName != "" && account.AccountName != accountName {
+			continue
+		}
+		return account, true, nil
+	}
+
+	details := "Merchant " + merchantID + " is not an allowed merchant account"
+	switch {
+	case merchantID == "":
+		details = "Account " + accountName + " is not an allowed merchant account"
+	case accountName != "":
+		details = "Account " + accountName + " of merchant " + merchantID + " is not an allowed merchant account"
+	}
+	return config.MerchantAccount{}, false, &LinkRequestError{Status: http.StatusBadRequest, Code: "MERCHANT_NOT_ALLOWED", Details: details}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits on the metadata attached to a link
+const (
+	maxMetadataKeys        = 20
+	maxMetadataValueLength = 500
+	// maxMetadataSize caps the combined length of every key and value, in bytes
+	maxMetadataSize = 4096
+)
+
+// metadataKeyPattern matches the keys allowed in link metadata
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]{1,40}$`)
+
+// validateMetadata checks the keys and values of a link's metadata, returning every
+// violation found
+func validateMetadata(metadata map[string]string) []FieldError {
+	var fields []FieldError
+	if len(metadata) > maxMetadataKeys {
+		return append(fields, fieldError("metadata", FieldTooLong, "metadata may have at most %d keys", maxMetadataKeys))
+	}
+
+	size := 0
+	for _, key := range slices.Sorted(maps.Keys(metadata)) {
+		value := metadata[key]
+		size += len(key) + len(value)
+		field := "metadata." + key
+		if !metadataKeyPattern.MatchString(key) {
+			fields = append(fields, FieldError{Field: field, Code: FieldInvalidFormat, Message: "metadata keys must be 1-40 letters, digits, '.', '_', or '-'"})
+			continue
+		}
+		switch {
+		case strings.TrimSpace(value) == "":
+			fields = append(fields, fieldError(field, FieldRequired, "%s must not be empty", field))
+		case utf8.RuneCountInString(value) > maxMetadataValueLength:
+			fields = append(fields, fieldError(field, FieldTooLong, "%s must be at most %d characters", field, maxMetadataValueLength))
+		case hasControlCharacters(value, false):
+			fields = append(fields, fieldError(field, FieldInvalidCharacters, "%s must not contain control characters", field))
+		}
+	}
+	if size > maxMetadataSize {
+		fields = append(fields, fieldError("metadata", FieldTooLong, "metadata keys and values must total at most %d bytes", maxMetadataSize))
+	}
+	return fields
+}
+
+// parseTagFilters reads ?tag=key:value query parameters into a metadata filter. Each tag
+// must match for a link to be listed.
+func parseTagFilters(tags []string) (map[string]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	if len(tags) > maxMetadataKeys {
+		return nil, fmt.Errorf("at most %d tag filters are allowed", maxMetadataKeys)
+	}
+	filter := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		key, value, ok := strings.Cut(tag, ":")
+		if !ok || !metadataKeyPattern.MatchString(key) || value == "" {
+			return nil, fmt.Errorf("tag must be key:value, such as campaign:spring")
+		}
+		if existing, ok := filter[key]; ok && existing != value {
+			return nil, fmt.Errorf("tag %s is given more than once with different values", key)
+		}
+		filter[key] = value
+	}
+	return filter, nil
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/locale"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// requestIDHeader is the response header carrying the ID assigned to each request
+const requestIDHeader = "X-Request-Id"
+
+// middleware wraps a handler with behavior that runs before and after it, such as
+// authentication or logging
+type middleware func(http.Handler) http.Handler
+
+// chain wraps h in each middleware in turn, so the first one listed runs first and sees
+// the request before the rest
+func chain(h http.Handler, middleware ...middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	// wroteHeader records whether the handler has started its response
+	wroteHeader bool
+}
+
+// WriteHeader records the status code before writing it
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.wroteHeader = true
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the implicit 200 status of a response written without WriteHeader
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the underlying ResponseWriter, so http.ResponseController can reach it
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Hijack hands the connection to WebSocket handlers, which need http.Hijacker directly
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	// A hijacked connection can no longer be given an error response
+	r.wroteHeader = true
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+// withRequestID assigns each request an ID, exposes it in the X-Request-Id response
+// header, and adds it to the request context so every log line for the request carries it
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := logging.NewRequestID()
+		w.Header().Set(requestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+	})
+}
+
+// requestLogger logs each request once it completes
+func requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		attrs := []interface{}{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"remote_addr", r.RemoteAddr,
+		}
+		// With TLS_CLIENT_CA_FILE set, every caller is identified by its client certificate
+		if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+			attrs = append(attrs, "client_cert", r.TLS.PeerCertificates[0].Subject.CommonName)
+		}
+		logging.FromContext(r.Context()).Info("Request completed", attrs...)
+	})
+}
+
+// recoverPanics turns a panic in a handler into a 500 INTERNAL_ERROR response, logging the
+// panic and its stack with the request ID. A response the handler had already started cannot
+// be replaced, so its connection is aborted instead, as is one aborted on purpose with
+// http.ErrAbortHandler. It runs once around the mux and again inside each route's timeout,
+// whose handler goroutine would otherwise lose the panic's stack.
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			logging.FromContext(r.Context()).Error("Handler panicked",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"panic", fmt.Sprint(recovered),
+				"stack", string(debug.Stack()),
+			)
+			if recorder.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+			writeError(w, http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR",
+				"An unexpected error occurred. Quote the request ID when contacting support.")
+		}()
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// limitBody caps request bodies at maxBytes. Reading past the limit fails with
+// *http.MaxBytesError, which handlers report with writeBodyTooLarge.
+func limitBody(maxBytes int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withTimeout fails requests that take longer than timeout with 503 REQUEST_TIMEOUT.
+// The request context is cancelled at the deadline, which aborts any GP API call in progress.
+func withTimeout(timeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The timeout body is fixed before the handler runs, so it carries this request's ID.
+		// Handlers set their own Content-Type, replacing this one when they finish in time.
+		requestID := w.Header().Get(requestIDHeader)
+		lang := w.Header().Get(contentLanguageHeader)
+		body, _ := json.Marshal(localizeResponse(lang, Response{
+			Success:   false,
+			Message:   "Request timed out",
+			Error:     &ErrorInfo{Code: "REQUEST_TIMEOUT", catalogDetails: locale.Messagef("The request did not complete within %s", timeout)},
+			RequestID: requestID,
+		}))
+		w.Header().Set("Content-Type", "application/json")
+
+		// TimeoutHandler gives the handler a fresh header map; carry the request ID and
+		// language over so writeJSON can still use them
+		inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(requestIDHeader, requestID)
+			if lang != "" {
+				w.Header().Set(contentLanguageHeader, lang)
+			}
+			next.ServeHTTP(w, r)
+		})
+		http.TimeoutHandler(inner, timeout, string(body)).ServeHTTP(w, r)
+	})
+}
+
+// writeBodyTooLarge writes a 413 REQUEST_TOO_LARGE response if err was caused by a
+// request body exceeding the limit set by limitBody, and reports whether it did
+func writeBodyTooLarge(w http.ResponseWriter, message string, err error) bool {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	writeErrorf(w, http.StatusRequestEntityTooLarge, message, "REQUEST_TOO_LARGE",
+		"Request body must not exceed %d bytes", tooLarge.Limit)
+	return true
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+)
+
+// resolveNotificationURLs returns the notification URLs for a link, applying any
+// per-request overrides after checking they are HTTPS and on an allowed host
+func resolveNotificationURLs(c config.NotificationURLs, returnURL, statusURL, cancelURL string) (gpapi.LinkNotifications, error) {
+	notifications := gpapi.LinkNotifications{
+		ReturnURL: c.ReturnURL,
+		StatusURL: c.StatusURL,
+		CancelURL: c.CancelURL,
+	}
+
+	overrides := []struct {
+		field string
+		value string
+		dest  *string
+	}{
+		{"returnUrl", returnURL, &notifications.ReturnURL},
+		{"statusUrl", statusURL, &notifications.StatusURL},
+		{"cancelUrl", cancelURL, &notifications.CancelURL},
+	}
+	for _, override := range overrides {
+		value := strings.TrimSpace(override.value)
+		if value == "" {
+			continue
+		}
+		parsed, err := config.ParseHTTPSURL(value)
+		if err != nil {
+			return gpapi.LinkNotifications{}, fmt.Errorf("%s %w", override.field, err)
+		}
+		if !c.AllowedHosts[strings.ToLower(parsed.Hostname())] {
+			return gpapi.LinkNotifications{}, fmt.Errorf("%s host %s is not allowed", override.field, parsed.Hostname())
+		}
+		*override.dest = parsed.String()
+	}
+
+	return notifications, nil
+}
@@ -0,0 +1,368 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// Dashboard roles given to people signed in through the OpenID Connect provider
+const (
+	adminRoleViewer   = "viewer"
+	adminRoleOperator = "operator"
+	adminRoleAdmin    = "admin"
+)
+
+// rolePermissions are the permissions each dashboard role is granted
+var rolePermissions = map[string][]config.Permission{
+	adminRoleViewer:   {config.PermissionRead},
+	adminRoleOperator: {config.PermissionCreate, config.PermissionRead, config.PermissionCancel, config.PermissionCapture, config.PermissionRefund},
+	adminRoleAdmin:    config.AllPermissions,
+}
+
+// oidcSessionCookie holds the signed session of someone signed in through the OpenID
+// Connect provider, and oidcStateCookie the state of a sign-in in progress
+const (
+	oidcSessionCookie = "pbl_admin_session"
+	oidcStateCookie   = "pbl_oidc_state"
+)
+
+// oidcStateLifetime is how long someone has to sign in at the provider
+const oidcStateLifetime = 10 * time.Minute
+
+// adminIdentityKey is the context key under which the signed-in dashboard user is stored
+type adminIdentityKey struct{}
+
+// adminIdentity is someone signed in to the dashboard
+type adminIdentity struct {
+	// Name is the API key name, or the provider's username or email for the person
+	Name string
+	// Role is the person's dashboard role; empty for API keys
+	Role        string
+	Permissions []config.Permission
+}
+
+// adminIdentityFrom returns the dashboard user stored in ctx by requireAdminSession. The
+// zero identity, with no role, is returned when the dashboard is open.
+func adminIdentityFrom(ctx context.Context) adminIdentity {
+	identity, _ := ctx.Value(adminIdentityKey{}).(adminIdentity)
+	return identity
+}
+
+// oidcSession is the payload of the signed session cookie
+type oidcSession struct {
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// oidcState is the payload of the signed cookie carrying a sign-in across the redirect to
+// the provider and back
+type oidcState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Next     string `json:"next"`
+	// ExpiresAt is checked as well as the cookie's Max-Age, which the browser enforces
+	ExpiresAt int64 `json:"exp"`
+}
+
+// oidcLogin signs people in to the dashboard through an OpenID Connect provider using the
+// authorization code flow with PKCE
+type oidcLogin struct {
+	cfg    config.OIDC
+	client *http.Client
+
+	// provider is discovered on first use, so the server starts while the provider is down
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+// newOIDCLogin returns the OpenID Connect sign-in for cfg, or nil when it is not configured.
+// client fetches the provider's discovery document and keys and exchanges codes.
+func newOIDCLogin(cfg config.OIDC, client *http.Client) *oidcLogin {
+	if !cfg.Enabled() {
+		return nil
+	}
+	return &oidcLogin{cfg: cfg, client: client}
+}
+
+// discover returns the provider, fetching its discovery document if that has not yet
+// succeeded
+func (l *oidcLogin) discover(ctx context.Context) (*oidc.Provider, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.provider != nil {
+		return l.provider, nil
+	}
+	// The provider keeps the context's client, not its deadline, to fetch signing keys later
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, l.client), l.cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("discovering OpenID Connect provider: %w", err)
+	}
+	l.provider = provider
+	return provider, nil
+}
+
+// oauth2Config returns the OAuth 2.0 client for provider
+func (l *oidcLogin) oauth2Config(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     l.cfg.ClientID,
+		ClientSecret: l.cfg.ClientSecret,
+		RedirectURL:  l.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       l.cfg.Scopes,
+	}
+}
+
+// role maps the roles claim in an ID token to the most powerful dashboard role the person
+// holds, or returns "" when they hold none of the configured roles
+func (l *oidcLogin) role(claims map[string]interface{}) string {
+	roles := claimStrings(claims, l.cfg.RolesClaim)
+	for _, mapping := range []struct {
+		role     string
+		provider []string
+	}{
+		{adminRoleAdmin, l.cfg.AdminRoles},
+		{adminRoleOperator, l.cfg.OperatorRoles},
+		{adminRoleViewer, l.cfg.ViewerRoles},
+	} {
+		for _, role := range roles {
+			if slices.Contains(mapping.provider, role) {
+				return mapping.role
+			}
+		}
+	}
+	return ""
+}
+
+// claimStrings returns the strings held by the claim at path, which separates the names of
+// nested objects with dots. A string claim is split on spaces and commas.
+func claimStrings(claims map[string]interface{}, path string) []string {
+	var value interface{} = claims
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+
+	switch value := value.(type) {
+	case string:
+		return strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' })
+	case []interface{}:
+		var values []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// claimName returns the name to show and log for the person an ID token identifies
+func claimName(claims map[string]interface{}, subject string) string {
+	for _, name := range []string{"preferred_username", "email", "name"} {
+		if value, ok := claims[name].(string); ok && value != "" {
+			return value
+		}
+	}
+	return subject
+}
+
+// sign returns payload encoded as JSON with an HMAC-SHA256 signature, for a cookie value
+func (l *oidcLogin) sign(payload interface{}) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(body)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(l.mac(encoded)), nil
+}
+
+// verify checks the signature on a cookie value made by sign and decodes its payload
+func (l *oidcLogin) verify(value string, payload interface{}) error {
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return errors.New("malformed cookie")
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, l.mac(encoded)) {
+		return errors.New("invalid cookie signature")
+	}
+	body, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return errors.New("malformed cookie")
+	}
+	return json.Unmarshal(body, payload)
+}
+
+// mac returns the HMAC-SHA256 of value under the session secret
+func (l *oidcLogin) mac(value string) []byte {
+	mac := hmac.New(sha256.New, []byte(l.cfg.SessionSecret))
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// session returns the person signed in by the session cookie on r, if it is valid and
+// has not expired
+func (l *oidcLogin) session(r *http.Request) (adminIdentity, bool) {
+	cookie, err := r.Cookie(oidcSessionCookie)
+	if err != nil {
+		return adminIdentity{}, false
+	}
+	var session oidcSession
+	if err := l.verify(cookie.Value, &session); err != nil || time.Now().Unix() >= session.ExpiresAt {
+		return adminIdentity{}, false
+	}
+	permissions, ok := rolePermissions[session.Role]
+	return adminIdentity{Name: session.Name, Role: session.Role, Permissions: permissions}, ok
+}
+
+// randomToken returns a random URL-safe string for a state or nonce
+func randomToken() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// secureRequest reports whether r reached the server over HTTPS, directly or through a proxy
+func secureRequest(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+// handleOIDCLogin handles GET requests to /admin/oidc/login, sending the browser to the
+// provider to sign in
+func (s *Server) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	provider, err := s.oidc.discover(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error starting single sign-on", "error", err)
+		s.renderLogin(w, r, http.StatusBadGateway, "Single sign-on is unavailable. Try again shortly.", r.URL.Query().Get("next"))
+		return
+	}
+
+	state := oidcState{
+		State:     randomToken(),
+		Nonce:     randomToken(),
+		Verifier:  oauth2.GenerateVerifier(),
+		Next:      adminRedirectTarget(r.URL.Query().Get("next")),
+		ExpiresAt: time.Now().Add(oidcStateLifetime).Unix(),
+	}
+	value, err := s.oidc.sign(state)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error signing single sign-on state", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	// The provider redirects back from another site, so the cookie must be SameSite=Lax
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/admin/oidc",
+		MaxAge:   int(oidcStateLifetime.Seconds()),
+		HttpOnly: true,
+		Secure:   secureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	target := s.oidc.oauth2Config(provider).AuthCodeURL(state.State, oidc.Nonce(state.Nonce), oauth2.S256ChallengeOption(state.Verifier))
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// handleOIDCCallback handles GET requests to /admin/oidc/callback, where the provider sends
+// the browser back after sign-in. The person is signed in to the dashboard when their ID
+// token is valid and carries an admin, operator, or viewer role.
+func (s *Server) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/admin/oidc", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteLaxMode})
+	query := r.URL.Query()
+
+	var state oidcState
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err == nil {
+		err = s.oidc.verify(cookie.Value, &state)
+	}
+	if err != nil || time.Now().Unix() >= state.ExpiresAt || !hmac.Equal([]byte(query.Get("state")), []byte(state.State)) {
+		logger.Warn("Rejected single sign-on callback with an invalid state", "remote_addr", r.RemoteAddr)
+		s.renderLogin(w, r, http.StatusBadRequest, "The sign-in expired or was not started here. Sign in again.", "")
+		return
+	}
+	if reason := query.Get("error"); reason != "" {
+		logger.Warn("Single sign-on failed at the provider", "error", reason, "description", query.Get("error_description"))
+		s.renderLogin(w, r, http.StatusUnauthorized, "The identity provider did not sign you in.", state.Next)
+		return
+	}
+
+	provider, err := s.oidc.discover(r.Context())
+	if err != nil {
+		logger.Error("Error completing single sign-on", "error", err)
+		s.renderLogin(w, r, http.StatusBadGateway, "Single sign-on is unavailable. Try again shortly.", state.Next)
+		return
+	}
+	ctx := context.WithValue(oidc.ClientContext(r.Context(), s.oidc.client), oauth2.HTTPClient, s.oidc.client)
+	token, err := s.oidc.oauth2Config(provider).Exchange(ctx, query.Get("code"), oauth2.VerifierOption(state.Verifier))
+	if err != nil {
+		logger.Warn("Error exchanging single sign-on code", "error", err)
+		s.renderLogin(w, r, http.StatusUnauthorized, "The identity provider did not sign you in.", state.Next)
+		return
+	}
+	rawIDToken, _ := token.Extra("id_token").(string)
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: s.oidc.cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err == nil && !hmac.Equal([]byte(idToken.Nonce), []byte(state.Nonce)) {
+		err = errors.New("nonce does not match")
+	}
+	var claims map[string]interface{}
+	if err == nil {
+		err = idToken.Claims(&claims)
+	}
+	if err != nil {
+		logger.Warn("Rejected single sign-on ID token", "error", err)
+		s.renderLogin(w, r, http.StatusUnauthorized, "The identity provider did not sign you in.", state.Next)
+		return
+	}
+
+	identity := adminIdentity{Name: claimName(claims, idToken.Subject), Role: s.oidc.role(claims)}
+	if identity.Role == "" {
+		logger.Warn("Refused dashboard sign-in without a dashboard role", "user", identity.Name)
+		s.renderLogin(w, r, http.StatusForbidden, "Your account has not been given access to this dashboard.", "")
+		return
+	}
+	value, err := s.oidc.sign(oidcSession{Name: identity.Name, Role: identity.Role, ExpiresAt: time.Now().Add(s.oidc.cfg.SessionTTL).Unix()})
+	if err != nil {
+		logger.Error("Error signing dashboard session", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	// SameSite=Lax rather than Strict, or the browser would withhold the cookie on the
+	// redirect below, which continues the navigation that started at the provider. Actions
+	// are POST requests that also check sameOrigin.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcSessionCookie,
+		Value:    value,
+		Path:     "/admin",
+		MaxAge:   int(s.oidc.cfg.SessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   secureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	logger.Info("Signed in to the dashboard", "user", identity.Name, "role", identity.Role)
+	http.Redirect(w, r, state.Next, http.StatusSeeOther)
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+)
+
+// fakeOIDCProvider is an OpenID Connect provider that signs in anyone, issuing ID tokens
+// with the nonce of the last authorization request and the given roles
+type fakeOIDCProvider struct {
+	*httptest.Server
+	key   *rsa.PrivateKey
+	nonce string
+	roles []interface{}
+}
+
+// newFakeOIDCProvider starts a provider for the client ID pay-by-link, stopped when the test ends
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	p := &fakeOIDCProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.URL,
+			"authorization_endpoint":                p.URL + "/authorize",
+			"token_endpoint":                        p.URL + "/token",
+			"jwks_uri":                              p.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "alg": "RS256", "use": "sig", "kid": "test",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access", "token_type": "Bearer", "expires_in": 300,
+			"id_token": p.idToken(t, map[string]interface{}{
+				"iss": p.URL, "aud": "pay-by-link", "sub": "user-1", "email": "ops@example.com",
+				"nonce": p.nonce, "iat": time.Now().Unix(), "exp": time.Now().Add(5 * time.Minute).Unix(),
+				"realm_access": map[string]interface{}{"roles": p.roles},
+			}),
+		})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// idToken returns claims as a JWT signed with RS256
+func (p *fakeOIDCProvider) idToken(t *testing.T, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": "test"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Errorf("SignPKCS1v15: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestOIDCSignIn(t *testing.T) {
+	provider := newFakeOIDCProvider(t)
+	cfg := newTestConfig(config.APIKeys{})
+	cfg.OIDC = config.OIDC{
+		IssuerURL:     provider.URL,
+		ClientID:      "pay-by-link",
+		ClientSecret:  "client-secret",
+		RedirectURL:   "http://example.com/admin/oidc/callback",
+		Scopes:        []string{"openid", "email"},
+		RolesClaim:    "realm_access.roles",
+		AdminRoles:    []string{"pbl-admin"},
+		ViewerRoles:   []string{"pbl-viewer", "support"},
+		SessionSecret: "0123456789abcdef0123456789abcdef",
+		SessionTTL:    time.Hour,
+	}
+	handler := New(cfg, &fakeGP{}, newTestStore(t), nil, http.DefaultClient).Handler()
+
+	serve := func(target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	cookie := func(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+		for _, cookie := range rec.Result().Cookies() {
+			if cookie.Name == name && cookie.MaxAge >= 0 {
+				return cookie
+			}
+		}
+		return nil
+	}
+	// signIn starts a sign-in at the dashboard, follows it to the provider, and returns the
+	// callback's response. tamper may change the callback's query.
+	signIn := func(roles []interface{}, tamper func(url.Values)) *httptest.ResponseRecorder {
+		t.Helper()
+		provider.roles = roles
+		rec := serve("/admin/oidc/login?next=%2Fadmin%2Flinks%2FLNK_1")
+		location, err := url.Parse(rec.Header().Get("Location"))
+		if rec.Code != http.StatusFound || err != nil {
+			t.Fatalf("login: got %d to %q, want 302 to the provider", rec.Code, rec.Header().Get("Location"))
+		}
+		authorize := location.Query()
+		if authorize.Get("code_challenge_method") != "S256" || authorize.Get("client_id") != "pay-by-link" {
+			t.Errorf("authorization request = %v, want PKCE for client pay-by-link", authorize)
+		}
+		provider.nonce = authorize.Get("nonce")
+		callback := url.Values{"state": {authorize.Get("state")}, "code": {"code-1"}}
+		if tamper != nil {
+			tamper(callback)
+		}
+		return serve("/admin/oidc/callback?"+callback.Encode(), cookie(rec, oidcStateCookie))
+	}
+
+	rec := signIn([]interface{}{"support", "pbl-admin"}, nil)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/admin/links/LNK_1" {
+		t.Fatalf("callback: got %d to %q, want 303 to the requested page", rec.Code, rec.Header().Get("Location"))
+	}
+	session := cookie(rec, oidcSessionCookie)
+	if session == nil {
+		t.Fatal("callback set no session cookie")
+	}
+	var signedIn oidcSession
+	if err := newOIDCLogin(cfg.OIDC, nil).verify(session.Value, &signedIn); err != nil || signedIn.Name != "ops@example.com" || signedIn.Role != adminRoleAdmin {
+		t.Errorf("session = %+v (%v), want ops@example.com as admin", signedIn, err)
+	}
+	if rec := serve("/admin", session); rec.Code != http.StatusOK {
+		t.Errorf("signed in: status = %d, want 200", rec.Code)
+	}
+
+	// A session cookie that was altered no longer signs anyone in
+	forged := *session
+	forged.Value = session.Value[:len(session.Value)-2] + "AA"
+	if rec := serve("/admin", &forged); rec.Code != http.StatusSeeOther {
+		t.Errorf("forged session: status = %d, want 303 to the sign-in page", rec.Code)
+	}
+
+	tests := []struct {
+		name       string
+		roles      []interface{}
+		tamper     func(url.Values)
+		wantStatus int
+	}{
+		{name: "no dashboard role", roles: []interface{}{"billing"}, wantStatus: http.StatusForbidden},
+		{name: "state not started here", roles: []interface{}{"pbl-admin"},
+			tamper: func(q url.Values) { q.Set("state", "forged") }, wantStatus: http.StatusBadRequest},
+		{name: "refused at the provider", roles: []interface{}{"pbl-admin"},
+			tamper: func(q url.Values) { q.Set("error", "access_denied") }, wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		rec := signIn(tt.roles, tt.tamper)
+		if rec.Code != tt.wantStatus || cookie(rec, oidcSessionCookie) != nil {
+			t.Errorf("%s: got %d with cookies %v, want %d without a session", tt.name, rec.Code, rec.Result().Cookies(), tt.wantStatus)
+		}
+	}
+
+	// A wrong nonce means the ID token was not issued for this sign-in
+	provider.roles = []interface{}{"pbl-admin"}
+	rec = serve("/admin/oidc/login")
+	location, _ := url.Parse(rec.Header().Get("Location"))
+	provider.nonce = "replayed"
+	callback := url.Values{"state": {location.Query().Get("state")}, "code": {"code-1"}}
+	if rec := serve("/admin/oidc/callback?"+callback.Encode(), cookie(rec, oidcStateCookie)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong nonce: status = %d, want 401", rec.Code)
+	}
+}
+
+func TestOIDCRole(t *testing.T) {
+	login := &oidcLogin{cfg: config.OIDC{
+		RolesClaim:    "realm_access.roles",
+		AdminRoles:    []string{"admin"},
+		OperatorRoles: []string{"operator"},
+		ViewerRoles:   []string{"viewer"},
+	}}
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		want   string
+	}{
+		{name: "most powerful role", claims: map[string]interface{}{"realm_access": map[string]interface{}{"roles": []interface{}{"viewer", "operator"}}},
+			want: adminRoleOperator},
+		{name: "roles in a string", claims: map[string]interface{}{"realm_access": map[string]interface{}{"roles": "viewer, admin"}},
+			want: adminRoleAdmin},
+		{name: "no dashboard role", claims: map[string]interface{}{"realm_access": map[string]interface{}{"roles": []interface{}{"billing"}}}},
+		{name: "claim missing", claims: map[string]interface{}{"roles": []interface{}{"admin"}}},
+		{name: "claim not an object", claims: map[string]interface{}{"realm_access": "admin"}},
+	}
+	for _, tt := range tests {
+		if got := login.role(tt.claims); got != tt.want {
+			t.Errorf("%s: role = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,495 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/pdf"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/wallet"
+	"github.com/globalpayments/pay-by-link-go/internal/xlsx"
+)
+
+// openAPIVersion is the version reported in the generated document's info block
+const openAPIVersion = "1.0.0"
+
+// apiParam describes a path or query parameter of an API operation
+type apiParam struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+}
+
+// apiOperation describes an endpoint for the generated OpenAPI document
+type apiOperation struct {
+	Method       string
+	Path         string
+	Summary      string
+	Tag          string
+	Params       []apiParam
+	Body         reflect.Type // JSON request body, if any
+	FormBody     bool         // Body is also accepted as application/x-www-form-urlencoded
+	CSVUpload    bool         // accepts a multipart upload with a CSV "file" field
+	OptionalBody bool         // the request body may be omitted
+	Data         reflect.Type // type of Response.Data on success, if any
+	Paginated    bool
+	Secured      bool
+	NoEnvelope   bool     // success response is not wrapped in Response
+	Download     []string // media types of the file the success response downloads instead
+	ErrorStatus  []int
+}
+
+// linkIDParam is the {id} path parameter shared by the single-link endpoints
+var linkIDParam = apiParam{Name: "id", In: "path", Description: "Payment link ID", Required: true}
+
+// linkFilterParams are the query parameters shared by the link listing and export
+var linkFilterParams = []apiParam{
+	{Name: "reference", In: "query", Description: "Filter by exact reference"},
+	{Name: "status", In: "query", Description: "Filter by status (ACTIVE, PAID, INACTIVE, EXPIRED)"},
+	{Name: "currency", In: "query", Description: "Filter by currency code"},
+	{Name: "tag", In: "query", Description: "Filter by metadata as key:value, e.g. campaign:spring; repeat to require several"},
+	{Name: "from", In: "query", Description: "Created on or after (RFC3339 or YYYY-MM-DD)"},
+	{Name: "to", In: "query", Description: "Created on or before (RFC3339 or YYYY-MM-DD)"},
+}
+
+// customerIDParam is the {id} path parameter of the customer endpoints
+var customerIDParam = apiParam{Name: "id", In: "path", Description: "Customer ID", Required: true}
+
+// templateIDParam is the {id} path parameter of the link template endpoints
+var templateIDParam = apiParam{Name: "id", In: "path", Description: "Link template ID", Required: true}
+
+// skuParam is the {sku} path parameter of the product catalog endpoints
+var skuParam = apiParam{Name: "sku", In: "path", Description: "Product SKU", Required: true}
+
+// transactionIDParam is the {id} path parameter of the transaction endpoints
+var transactionIDParam = apiParam{Name: "id", In: "path", Description: "GP API transaction ID", Required: true}
+
+// apiOperations lists the documented endpoints. Add new endpoints here so they appear in /openapi.json.
+var apiOperations = []apiOperation{
+	{Method: "GET", Path: "/config", Summary: "Get client configuration", Tag: "Configuration",
+		Data: reflect.TypeOf(ClientConfig{})},
+	{Method: "GET", Path: "/healthz", Summary: "Liveness probe", Tag: "Health",
+		Data: reflect.TypeOf(map[string]string{})},
+	{Method: "GET", Path: "/readyz", Summary: "Readiness probe", Tag: "Health",
+		Data: reflect.TypeOf(ReadinessReport{}), ErrorStatus: []int{503}},
+	{Method: "GET", Path: "/admin/config", Summary: "Get the effective configuration, with secrets redacted (only served when API keys are configured)", Tag: "Configuration",
+		Data: reflect.TypeOf(EffectiveConfig{}), Secured: true, ErrorStatus: []int{401, 404}},
+	{Method: "GET", Path: "/admin/audit", Summary: "List audit log entries for mutating operations, newest first (only served when API keys are configured)", Tag: "Configuration",
+		Params: []apiParam{
+			{Name: "action", In: "query", Description: "Filter by action, e.g. link.cancel"},
+			{Name: "actor", In: "query", Description: "Filter by actor, e.g. apikey:backoffice or user:alice"},
+			{Name: "resource", In: "query", Description: "Filter by the changed link, transaction, customer, or template ID, or product SKU"},
+			{Name: "linkId", In: "query", Description: "Filter by GP API link ID"},
+			{Name: "transactionId", In: "query", Description: "Filter by GP API transaction ID"},
+			{Name: "from", In: "query", Description: "Recorded on or after (RFC3339 or YYYY-MM-DD)"},
+			{Name: "to", In: "query", Description: "Recorded on or before (RFC3339 or YYYY-MM-DD)"},
+			{Name: "limit", In: "query", Description: "Page size (1-100, default 20)"},
+			{Name: "cursor", In: "query", Description: "nextCursor from the previous page"},
+		},
+		Data: reflect.TypeOf([]store.AuditEntry{}), Paginated: true, Secured: true, ErrorStatus: []int{400, 401, 404, 500}},
+	{Method: "GET", Path: "/admin/audit/verify", Summary: "Check the audit log's hash chain for entries that were changed or removed", Tag: "Configuration",
+		Data: reflect.TypeOf(AuditVerification{}), Secured: true, ErrorStatus: []int{401, 404, 500}},
+	{Method: "GET", Path: "/admin/retention", Summary: "Report the data retention policies and the rows each has purged", Tag: "Configuration",
+		Data: reflect.TypeOf(RetentionReport{}), Secured: true, ErrorStatus: []int{401, 404}},
+	{Method: "GET", Path: "/admin/deadletters", Summary: "List events that could not be delivered to a webhook endpoint or the event publisher, newest first", Tag: "Configuration",
+		Params: []apiParam{
+			{Name: "eventType", In: "query", Description: "Filter by event type, e.g. link.paid"},
+			{Name: "url", In: "query", Description: "Filter by webhook endpoint, or kafka or nats for the event publisher"},
+			{Name: "limit", In: "query", Description: "Page size (1-100, default 20)"},
+			{Name: "cursor", In: "query", Description: "nextCursor from the previous page"},
+		},
+		Data: reflect.TypeOf([]DeadLetter{}), Paginated: true, Secured: true, ErrorStatus: []int{400, 401, 404, 500}},
+	{Method: "POST", Path: "/admin/deadletters/{id}/retry", Summary: "Send a dead-lettered event again, deleting it once delivered", Tag: "Configuration",
+		Params: []apiParam{{Name: "id", In: "path", Description: "Dead letter ID", Required: true}},
+		Data:   reflect.TypeOf(DeadLetter{}), Secured: true, ErrorStatus: []int{400, 401, 404, 409, 500, 502}},
+	{Method: "POST", Path: "/admin/reload", Summary: "Reload the configuration and rotate GP API credentials without a restart, as SIGHUP does", Tag: "Configuration",
+		Data: reflect.TypeOf(EffectiveConfig{}), Secured: true, ErrorStatus: []int{401, 404, 422}},
+	{Method: "POST", Path: "/create-payment-link", Summary: "Create a payment link", Tag: "Payment Links",
+		Params: []apiParam{{Name: "Idempotency-Key", In: "header", Description: "Key that makes retries of the request return its first successful response"}},
+		Body:   reflect.TypeOf(PaymentLinkRequest{}), FormBody: true, Data: reflect.TypeOf(PaymentLinkResponse{}),
+		Secured: true, ErrorStatus: []int{400, 401, 409, 422, 429, 500, 502, 503}},
+	{Method: "POST", Path: "/create-payment-links", Summary: "Create payment links in bulk from JSON or CSV", Tag: "Payment Links",
+		Body: reflect.TypeOf([]PaymentLinkRequest{}), CSVUpload: true, Data: reflect.TypeOf(BatchLinkResponse{}),
+		Secured: true, ErrorStatus: []int{400, 401, 415, 429}},
+	{Method: "POST", Path: "/recurring-links", Summary: "Schedule a series of installment links", Tag: "Recurring Links",
+		Body: reflect.TypeOf(RecurringLinkRequest{}), FormBody: true, Data: reflect.TypeOf(RecurringLinkResponse{}),
+		Secured: true, ErrorStatus: []int{400, 401, 429, 500}},
+	{Method: "GET", Path: "/recurring-links/{id}", Summary: "Get a recurring link series and its installments", Tag: "Recurring Links",
+		Params: []apiParam{{Name: "id", In: "path", Description: "Recurring link series ID", Required: true}}, Data: reflect.TypeOf(RecurringLinkResponse{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 500}},
+	{Method: "GET", Path: "/payment-links", Summary: "List stored payment links", Tag: "Payment Links",
+		Params: append(linkFilterParams,
+			apiParam{Name: "limit", In: "query", Description: "Page size (1-100, default 20)"},
+			apiParam{Name: "cursor", In: "query", Description: "nextCursor from the previous page"},
+			apiParam{Name: "refresh", In: "query", Description: "Set to true to refresh statuses from GP API first"},
+		),
+		Data: reflect.TypeOf([]store.Link{}), Paginated: true, Secured: true, ErrorStatus: []int{400, 401, 500}},
+	{Method: "GET", Path: "/payment-links/export", Summary: "Download the stored payment links as CSV or Excel", Tag: "Payment Links",
+		Params:   append([]apiParam{{Name: "format", In: "query", Description: "csv (default) or xlsx"}}, linkFilterParams...),
+		Download: []string{"text/csv", xlsx.ContentType}, Secured: true, ErrorStatus: []int{400, 401, 500}},
+	{Method: "POST", Path: "/customers", Summary: "Add a customer to the directory", Tag: "Customers",
+		Body: reflect.TypeOf(CustomerRequest{}), FormBody: true, Data: reflect.TypeOf(store.Customer{}),
+		Secured: true, ErrorStatus: []int{400, 401, 500}},
+	{Method: "GET", Path: "/customers/{id}", Summary: "Get a customer", Tag: "Customers",
+		Params: []apiParam{customerIDParam}, Data: reflect.TypeOf(store.Customer{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 500}},
+	{Method: "GET", Path: "/customers/{id}/payment-links", Summary: "List a customer's payment links", Tag: "Customers",
+		Params: []apiParam{
+			customerIDParam,
+			{Name: "status", In: "query", Description: "Filter by status (ACTIVE, PAID, INACTIVE, EXPIRED)"},
+			{Name: "tag", In: "query", Description: "Filter by metadata as key:value, e.g. campaign:spring; repeat to require several"},
+			{Name: "limit", In: "query", Description: "Page size (1-100, default 20)"},
+			{Name: "cursor", In: "query", Description: "nextCursor from the previous page"},
+		},
+		Data: reflect.TypeOf([]store.Link{}), Paginated: true, Secured: true, ErrorStatus: []int{400, 401, 404, 500}},
+	{Method: "GET", Path: "/customers/{id}/export", Summary: "Export everything stored about a customer, for data subject access requests", Tag: "Customers",
+		Params: []apiParam{customerIDParam}, Data: reflect.TypeOf(CustomerExport{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 500}},
+	{Method: "DELETE", Path: "/customers/{id}/data", Summary: "Erase a customer and the personal data stored with their links, for data subject erasure requests", Tag: "Customers",
+		Params: []apiParam{customerIDParam}, Data: reflect.TypeOf(store.CustomerErasure{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 500}},
+	{Method: "GET", Path: "/link-templates", Summary: "List link templates", Tag: "Link Templates",
+		Data: reflect.TypeOf([]store.LinkTemplate{}), Secured: true, ErrorStatus: []int{401, 500}},
+	{Method: "POST", Path: "/link-templates", Summary: "Save a link template", Tag: "Link Templates",
+		Body: reflect.TypeOf(LinkTemplateRequest{}), FormBody: true, Data: reflect.TypeOf(store.LinkTemplate{}),
+		Secured: true, ErrorStatus: []int{400, 401, 500}},
+	{Method: "GET", Path: "/link-templates/{id}", Summary: "Get a link template", Tag: "Link Templates",
+		Params: []apiParam{templateIDParam}, Data: reflect.TypeOf(store.LinkTemplate{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 500}},
+	{Method: "PUT", Path: "/link-templates/{id}", Summary: "Replace a link template", Tag: "Link Templates",
+		Params: []apiParam{templateIDParam}, Body: reflect.TypeOf(LinkTemplateRequest{}), FormBody: true, Data: reflect.TypeOf(store.LinkTemplate{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 500}},
+	{Method: "DELETE", Path: "/link-templates/{id}", Summary: "Delete a link template", Tag: "Link Templates",
+		Params: []apiParam{templateIDParam}, Data: reflect.TypeOf(map[string]string{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 500}},
+	{Method: "GET", Path: "/products", Summary: "List the product catalog", Tag: "Products",
+		Data: reflect.TypeOf([]store.Product{}), Secured: true, ErrorStatus: []int{401, 500}},
+	{Method: "GET", Path: "/products/{sku}", Summary: "Get a catalog product", Tag: "Products",
+		Params: []apiParam{skuParam}, Data: reflect.TypeOf(store.Product{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 500}},
+	{Method: "PUT", Path: "/products/{sku}", Summary: "Add or replace a catalog product", Tag: "Products",
+		Params: []apiParam{skuParam}, Body: reflect.TypeOf(ProductRequest{}), FormBody: true, Data: reflect.TypeOf(store.Product{}),
+		Secured: true, ErrorStatus: []int{400, 401, 500}},
+	{Method: "DELETE", Path: "/products/{sku}", Summary: "Delete a catalog product", Tag: "Products",
+		Params: []apiParam{skuParam}, Data: reflect.TypeOf(map[string]string{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 500}},
+	{Method: "GET", Path: "/payment-link/{id}", Summary: "Get a payment link with its transactions", Tag: "Payment Links",
+		Params: []apiParam{linkIDParam}, Data: reflect.TypeOf(PaymentLinkDetailResponse{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 502}},
+	{Method: "PATCH", Path: "/payment-link/{id}", Summary: "Edit an active payment link", Tag: "Payment Links",
+		Params: []apiParam{linkIDParam}, Body: reflect.TypeOf(PaymentLinkUpdateRequest{}), Data: reflect.TypeOf(map[string]string{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 409, 502}},
+	{Method: "POST", Path: "/payment-link/{id}/cancel", Summary: "Deactivate a payment link", Tag: "Payment Links",
+		Params: []apiParam{linkIDParam}, Data: reflect.TypeOf(map[string]string{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 502}},
+	{Method: "POST", Path: "/payment-link/{id}/send-sms", Summary: "Send a payment link by SMS", Tag: "Delivery",
+		Params: []apiParam{linkIDParam}, Body: reflect.TypeOf(SendSMSRequest{}), FormBody: true, OptionalBody: true, Data: reflect.TypeOf(store.Delivery{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 502, 503}},
+	{Method: "POST", Path: "/payment-link/{id}/reminders", Summary: "Turn payment reminders off or on for a link", Tag: "Delivery",
+		Params: []apiParam{linkIDParam}, Body: reflect.TypeOf(RemindersRequest{}), FormBody: true, Data: reflect.TypeOf(store.Link{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 500}},
+	{Method: "GET", Path: "/payment-link/{id}/deliveries", Summary: "List SMS deliveries for a link", Tag: "Delivery",
+		Params: []apiParam{linkIDParam}, Data: reflect.TypeOf([]store.Delivery{}),
+		Secured: true, ErrorStatus: []int{400, 401, 500}},
+	{Method: "GET", Path: "/payment-link/{id}/views", Summary: "List visits to a link's shortlink, newest first", Tag: "Delivery",
+		Params: []apiParam{linkIDParam, {Name: "limit", In: "query", Description: "Page size (1-100, default 20)"}},
+		Data:   reflect.TypeOf([]store.LinkView{}), Secured: true, ErrorStatus: []int{400, 401, 500}},
+	{Method: "GET", Path: "/payment-link/{id}/receipt", Summary: "Render a paid link's receipt as HTML or PDF", Tag: "Payment Links",
+		Params:   []apiParam{linkIDParam, {Name: "format", In: "query", Description: "html (default) or pdf"}},
+		Download: []string{"text/html", pdf.ContentType}, Secured: true, ErrorStatus: []int{400, 401, 404, 409, 500}},
+	{Method: "GET", Path: "/payment-link/{id}/wallet-pass", Summary: "Get an Apple Wallet pass, or an Add to Google Wallet URL, that opens an active link", Tag: "Payment Links",
+		Params:   []apiParam{linkIDParam, {Name: "wallet", In: "query", Description: "apple or google; defaults to whichever is configured, Apple first"}},
+		Download: []string{wallet.PKPassContentType}, Data: reflect.TypeOf(WalletPassResponse{}), Secured: true, ErrorStatus: []int{400, 401, 404, 409, 500, 503}},
+	{Method: "GET", Path: "/payment-link/{id}/transactions", Summary: "List the payments taken through a link", Tag: "Transactions",
+		Params: []apiParam{linkIDParam}, Data: reflect.TypeOf(LinkTransactionsResponse{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 502}},
+	{Method: "GET", Path: "/transactions", Summary: "Search GP API's transaction report", Tag: "Transactions",
+		Params: []apiParam{
+			{Name: "from", In: "query", Description: "Created on or after (RFC3339 or YYYY-MM-DD, default 30 days before to)"},
+			{Name: "to", In: "query", Description: "Created on or before (RFC3339 or YYYY-MM-DD, default now)"},
+			{Name: "status", In: "query", Description: "Filter by GP transaction status, e.g. CAPTURED or DECLINED"},
+			{Name: "reference", In: "query", Description: "Filter by exact reference"},
+			{Name: "limit", In: "query", Description: "Page size (1-100, default 20)"},
+			{Name: "cursor", In: "query", Description: "nextCursor from the previous page"},
+		},
+		Data: reflect.TypeOf([]TransactionSummary{}), Paginated: true, Secured: true, ErrorStatus: []int{400, 401, 502}},
+	{Method: "POST", Path: "/transactions/{id}/capture", Summary: "Capture a payment authorized through a LATER capture mode link", Tag: "Transactions",
+		Params: []apiParam{transactionIDParam}, Data: reflect.TypeOf(TransactionSummary{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 502}},
+	{Method: "POST", Path: "/transactions/{id}/refund", Summary: "Refund all or part of a captured link payment", Tag: "Transactions",
+		Params: []apiParam{transactionIDParam, {Name: "Idempotency-Key", In: "header", Description: "Key that makes retries of the request return its first successful response"}},
+		Body:   reflect.TypeOf(RefundRequest{}), FormBody: true, OptionalBody: true, Data: reflect.TypeOf(RefundResponse{}),
+		Secured: true, ErrorStatus: []int{400, 401, 404, 409, 422, 500, 502, 503}},
+	{Method: "POST", Path: "/webhooks/status", Summary: "Receive a GP transaction status notification", Tag: "Webhooks",
+		Params:      []apiParam{{Name: "X-GP-Signature", In: "header", Description: "SHA512(body + app key), hex encoded", Required: true}},
+		Body:        reflect.TypeOf(GPStatusNotification{}),
+		ErrorStatus: []int{400, 401, 500}},
+	{Method: "POST", Path: "/webhooks/sms/status", Summary: "Receive a Twilio message status callback", Tag: "Webhooks",
+		Params:     []apiParam{{Name: "X-Twilio-Signature", In: "header", Description: "Twilio request signature", Required: true}},
+		NoEnvelope: true, ErrorStatus: []int{400, 401, 404}},
+}
+
+// openAPIRequiredFields lists the required properties of request types, keyed by type name
+var openAPIRequiredFields = map[string][]string{
+	"PaymentLinkRequest":   {"currency", "name", "description"},
+	"RecurringLinkRequest": {"amount", "currency", "name", "description", "cadence", "occurrences"},
+	"RemindersRequest":     {"enabled"},
+	"CustomerRequest":      {"name"},
+	"LinkTemplateRequest":  {"name"},
+	"ProductRequest":       {"name", "unitPrice", "currency"},
+	"LineItemRequest":      {"sku", "quantity"},
+}
+
+// schemaGenerator builds JSON schemas from Go types, collecting named structs as components
+type schemaGenerator struct {
+	components map[string]interface{}
+}
+
+// schema returns the JSON schema for t, registering named struct types under components
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == reflect.TypeOf(flexBool("")):
+		return map[string]interface{}{"type": "boolean"}
+	case t == reflect.TypeOf(json.RawMessage{}):
+		// Raw JSON is passed through as it is, so its shape is not known here
+		return map[string]interface{}{"type": "object"}
+	case t.Kind() == reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() == reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case t.Kind() == reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if _, ok := g.components[t.Name()]; !ok {
+			g.components[t.Name()] = map[string]interface{}{} // placeholder for recursive types
+			g.components[t.Name()] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema returns an object schema with a property for each JSON-encoded field of t
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			// Embedded structs are flattened into the parent, as encoding/json does
+			for key, value := range g.structSchema(field.Type)["properties"].(map[string]interface{}) {
+				properties[key] = value
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schema(field.Type)
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if required, ok := openAPIRequiredFields[t.Name()]; ok {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// buildOpenAPISpec generates the OpenAPI 3 document for apiOperations
+func buildOpenAPISpec() map[string]interface{} {
+	g := &schemaGenerator{components: make(map[string]interface{})}
+	responseRef := g.schema(reflect.TypeOf(Response{}))
+
+	paths := make(map[string]interface{})
+	for _, op := range apiOperations {
+		operation := map[string]interface{}{
+			"summary":     op.Summary,
+			"operationId": operationID(op),
+			"tags":        []string{op.Tag},
+		}
+
+		if len(op.Params) > 0 {
+			params := make([]interface{}, 0, len(op.Params))
+			for _, p := range op.Params {
+				params = append(params, map[string]interface{}{
+					"name":        p.Name,
+					"in":          p.In,
+					"description": p.Description,
+					"required":    p.Required,
+					"schema":      map[string]interface{}{"type": "string"},
+				})
+			}
+			operation["parameters"] = params
+		}
+
+		if op.Body != nil || op.CSVUpload {
+			content := make(map[string]interface{})
+			if op.Body != nil {
+				content["application/json"] = map[string]interface{}{"schema": g.schema(op.Body)}
+				if op.FormBody {
+					content["application/x-www-form-urlencoded"] = map[string]interface{}{"schema": g.schema(op.Body)}
+				}
+			}
+			if op.CSVUpload {
+				content["multipart/form-data"] = map[string]interface{}{"schema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"file": map[string]interface{}{
+							"type":        "string",
+							"format":      "binary",
+							"description": "CSV file whose header row names the request fields",
+						},
+					},
+					"required": []string{"file"},
+				}}
+			}
+			operation["requestBody"] = map[string]interface{}{"required": !op.OptionalBody, "content": content}
+		}
+
+		success := responseRef
+		if op.Data != nil {
+			properties := map[string]interface{}{"data": g.schema(op.Data)}
+			if op.Paginated {
+				properties["pagination"] = g.schema(reflect.TypeOf(Pagination{}))
+			}
+			success = map[string]interface{}{"allOf": []interface{}{
+				responseRef,
+				map[string]interface{}{"type": "object", "properties": properties},
+			}}
+		}
+
+		responses := make(map[string]interface{})
+		if op.NoEnvelope {
+			responses["204"] = map[string]interface{}{"description": "Processed"}
+		} else if len(op.Download) > 0 {
+			content := make(map[string]interface{})
+			for _, mediaType := range op.Download {
+				content[mediaType] = map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}}
+			}
+			// Some downloads are answered with JSON data instead, depending on the request
+			if op.Data != nil {
+				content["application/json"] = map[string]interface{}{"schema": success}
+			}
+			responses["200"] = map[string]interface{}{"description": "File download", "content": content}
+		} else {
+			responses["200"] = map[string]interface{}{
+				"description": "Success",
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": success}},
+			}
+		}
+		for _, status := range op.ErrorStatus {
+			responses[strconv.Itoa(status)] = map[string]interface{}{
+				"description": http.StatusText(status),
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": responseRef}},
+			}
+		}
+		operation["responses"] = responses
+
+		if op.Secured {
+			operation["security"] = []interface{}{
+				map[string]interface{}{"ApiKeyAuth": []string{}},
+				map[string]interface{}{"BearerAuth": []string{}},
+			}
+			// Any secured endpoint refuses keys without the permission it requires
+			responses["403"] = map[string]interface{}{
+				"description": http.StatusText(http.StatusForbidden),
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": responseRef}},
+			}
+		}
+
+		pathItem, ok := paths[op.Path].(map[string]interface{})
+		if !ok {
+			pathItem = make(map[string]interface{})
+			paths[op.Path] = pathItem
+		}
+		pathItem[strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Global Payments Pay by Link Sample API",
+			"version":     openAPIVersion,
+			"description": "Sample server for creating and managing Global Payments Pay by Link payment links. Secured endpoints require an API key when API_KEYS is configured; without it, only link creation is served unless ALLOW_UNAUTHENTICATED is set.",
+		},
+		"servers": []interface{}{map[string]interface{}{"url": "/"}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": g.components,
+			"securitySchemes": map[string]interface{}{
+				"ApiKeyAuth": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"BearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// operationID derives a stable operationId from an operation's method and path
+func operationID(op apiOperation) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(op.Method))
+	for _, part := range strings.FieldsFunc(op.Path, func(r rune) bool { return r == '/' || r == '-' || r == '{' || r == '}' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// handleOpenAPI handles the /openapi.json endpoint
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildOpenAPISpec())
+}
+
+// swaggerUIPage renders Swagger UI for /openapi.json using the swagger-ui-dist CDN build
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Pay by Link API Docs</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+// handleDocs handles the /docs endpoint
+func handleDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, swaggerUIPage)
+}
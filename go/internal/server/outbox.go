@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/webhooks"
+)
+
+// Outbox relay limits
+const (
+	// outboxBatchSize is how many events the relay claims at a time
+	outboxBatchSize = 100
+	// outboxPollInterval is how often the relay looks for events it was not woken for, such
+	// as those written by another instance or left behind by a failed delivery
+	outboxPollInterval = 5 * time.Second
+	// outboxLease is how long a claimed event is left to its relay before it may be claimed
+	// again. It outlasts a webhook's retries at the default settings, so an event is only
+	// delivered twice when its delivery could not be recorded or its relay stopped.
+	outboxLease = 15 * time.Minute
+)
+
+// outboxRelay tracks the delivery of the events in the link store's outbox
+type outboxRelay struct {
+	wake chan struct{}
+	// mu guards inFlight, the IDs of the events being delivered, which are not started again
+	mu       sync.Mutex
+	inFlight map[int64]bool
+}
+
+// newOutboxRelay creates an idle outboxRelay
+func newOutboxRelay() *outboxRelay {
+	return &outboxRelay{wake: make(chan struct{}, 1), inFlight: make(map[int64]bool)}
+}
+
+// start marks the event with id as being delivered, returning false when it already is
+func (r *outboxRelay) start(id int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.inFlight[id] {
+		return false
+	}
+	r.inFlight[id] = true
+	return true
+}
+
+// finish marks the event with id as no longer being delivered
+func (r *outboxRelay) finish(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.inFlight, id)
+}
+
+// newOutboxEvent returns an event of eventType to write to the outbox with a link change,
+// or nil when eventType is "" as the change is not announced
+func newOutboxEvent(eventType string) *store.OutboxEvent {
+	if eventType == "" {
+		return nil
+	}
+	event := webhooks.NewEvent(eventType, nil)
+	return &store.OutboxEvent{EventID: event.ID, EventType: event.Type, CreatedAt: event.CreatedAt}
+}
+
+// wakeOutboxRelay asks the relay to deliver newly written events without waiting for its
+// next poll
+func (s *Server) wakeOutboxRelay() {
+	select {
+	case s.outbox.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runOutboxRelay relays the outbox straight away, for events left by a previous run, then
+// whenever it is woken and every outboxPollInterval until ctx is cancelled
+func (s *Server) runOutboxRelay(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+	for {
+		s.RelayOutbox(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.outbox.wake:
+		}
+	}
+}
+
+// RelayOutbox claims the events waiting in the outbox and starts delivering them, deleting
+// each once the webhook endpoints and event publisher have recorded it. Events that could
+// not be recorded are left to be claimed again once their lease has passed, so every event
+// is delivered at least once; receivers tell repeats apart by the event ID.
+func (s *Server) RelayOutbox(ctx context.Context) {
+	for {
+		events, err := s.links.ClaimOutboxEvents(ctx, outboxBatchSize, outboxLease)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Error claiming outbox events", "error", err)
+			}
+			return
+		}
+		for _, event := range events {
+			if s.outbox.start(event.ID) {
+				s.relayOutboxEvent(event)
+			}
+		}
+		if len(events) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// relayOutboxEvent delivers one claimed event under the ID it was written with
+func (s *Server) relayOutboxEvent(outboxEvent *store.OutboxEvent) {
+	event := webhooks.Event{
+		ID:        outboxEvent.EventID,
+		Type:      outboxEvent.EventType,
+		CreatedAt: outboxEvent.CreatedAt,
+		Data:      outboxEvent.Link,
+	}
+	logger := slog.With("event_id", event.ID, "event_type", event.Type, "link_id", outboxEvent.LinkID)
+	if outboxEvent.Attempts > 1 {
+		logger.Info("Relaying outbox event again", "attempts", outboxEvent.Attempts)
+	}
+
+	s.emitLinkEvent(event, func(recorded bool) {
+		defer s.outbox.finish(outboxEvent.ID)
+		if !recorded {
+			logger.Warn("Outbox event not recorded, leaving it to be relayed again", "retry_after", outboxLease.String())
+			return
+		}
+		if err := s.links.DeleteOutboxEvent(context.Background(), outboxEvent.ID); err != nil {
+			logger.Error("Error deleting relayed outbox event", "error", err)
+		}
+	})
+}
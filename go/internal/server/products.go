@@ -0,0 +1,306 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+const (
+	// maxLineItems is the most line items a link request may contain
+	maxLineItems = 50
+	// maxItemQuantity is the largest quantity of a single line item
+	maxItemQuantity = 1000
+)
+
+// skuPattern matches the stock keeping units accepted in the product catalog
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// ProductRequest represents the expected catalog product payload. The SKU is taken from the path.
+type ProductRequest struct {
+	Name      string `json:"name" form:"name"`
+	UnitPrice string `json:"unitPrice" form:"unitPrice"`
+	Currency  string `json:"currency" form:"currency"`
+}
+
+// LineItemRequest is one line of a payment link request priced from the product catalog.
+// UnitPrice is optional; when given it must match the catalog price.
+type LineItemRequest struct {
+	SKU       string `json:"sku"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice string `json:"unitPrice"`
+}
+
+// validateProductRequest checks a catalog product request and returns the product to store.
+// The product must be priced in one of the supported currencies, when they are configured.
+func validateProductRequest(sku string, req ProductRequest, supportedCurrencies []string) (*store.Product, *LinkRequestError) {
+	var fields []FieldError
+	product := &store.Product{
+		SKU:      sku,
+		Name:     normalizeText(req.Name),
+		Currency: strings.ToUpper(strings.TrimSpace(req.Currency)),
+	}
+
+	switch {
+	case product.Name == "":
+		fields = append(fields, fieldError("name", FieldRequired, "%s is required", "name"))
+	case utf8.RuneCountInString(product.Name) > maxNameLength:
+		fields = append(fields, fieldError("name", FieldTooLong, "%s must be at most %d characters", "name", maxNameLength))
+	case hasControlCharacters(product.Name, false):
+		fields = append(fields, fieldError("name", FieldInvalidCharacters, "%s must not contain control characters", "name"))
+	}
+	switch {
+	case product.Currency == "":
+		fields = append(fields, fieldError("currency", FieldRequired, "%s is required", "currency"))
+	case !money.IsCurrency(product.Currency):
+		fields = append(fields, FieldError{Field: "currency", Code: FieldInvalidFormat, Message: "currency must be an ISO 4217 currency code, such as EUR"})
+	}
+	if strings.TrimSpace(req.UnitPrice) == "" {
+		fields = append(fields, fieldError("unitPrice", FieldRequired, "%s is required", "unitPrice"))
+	}
+	if len(fields) > 0 {
+		return nil, validationError(fields)
+	}
+	if linkErr := unsupportedCurrency(product.Currency, supportedCurrencies); linkErr != nil {
+		return nil, linkErr
+	}
+
+	unitPrice, err := money.ToMinorUnits(req.UnitPrice, product.Currency)
+	if err != nil {
+		return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_AMOUNT", Details: "unitPrice: " + err.Error()}
+	}
+	product.UnitPrice = unitPrice
+	return product, nil
+}
+
+// resolveLineItems prices the line items of a link request from the product catalog. The
+// total becomes the link amount and each line is appended to the description, so the amount
+// charged never comes from the client. A client amount or unit price that disagrees with
+// the catalog is rejected.
+func (s *Server) resolveLineItems(ctx context.Context, req PaymentLinkRequest) (PaymentLinkRequest, *LinkRequestError) {
+	if len(req.Items) == 0 {
+		return req, nil
+	}
+	if req.openAmount() {
+		return req, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_ITEMS", Details: "items cannot be combined with minAmount or maxAmount"}
+	}
+	if len(req.Items) > maxLineItems {
+		return req, newLinkRequestError(http.StatusBadRequest, "INVALID_ITEMS", "at most %d items are allowed", maxLineItems)
+	}
+
+	var fields []FieldError
+	for i, item := range req.Items {
+		if !skuPattern.MatchString(strings.TrimSpace(item.SKU)) {
+			fields = append(fields, FieldError{Field: fmt.Sprintf("items[%d].sku", i), Code: FieldInvalidFormat, Message: "sku must be 1-64 letters, digits, '.', '_', or '-'"})
+		}
+		if item.Quantity < 1 || item.Quantity > maxItemQuantity {
+			fields = append(fields, fieldError(fmt.Sprintf("items[%d].quantity", i), FieldInvalidFormat,
+				"quantity must be between 1 and %d", maxItemQuantity))
+		}
+	}
+	if len(fields) > 0 {
+		return req, validationError(fields)
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	var total int64
+	var lines []string
+	for i, item := range req.Items {
+		sku := strings.TrimSpace(item.SKU)
+		product, err := s.links.GetProduct(ctx, sku)
+		if errors.Is(err, store.ErrProductNotFound) {
+			return req, &LinkRequestError{Status: http.StatusBadRequest, Code: "PRODUCT_NOT_FOUND", Details: fmt.Sprintf("items[%d]: product %s not found", i, sku)}
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("Error reading product", "sku", sku, "error", err)
+			return req, &LinkRequestError{Status: http.StatusInternalServerError, Code: "STORE_ERROR", Details: "Error reading stored product"}
+		}
+
+		if currency == "" {
+			currency = product.Currency
+		}
+		if product.Currency != currency {
+			return req, &LinkRequestError{Status: http.StatusBadRequest, Code: "CURRENCY_MISMATCH",
+				Details: fmt.Sprintf("items[%d]: product %s is priced in %s, not %s", i, sku, product.Currency, currency)}
+		}
+		if strings.TrimSpace(item.UnitPrice) != "" {
+			unitPrice, err := money.ToMinorUnits(item.UnitPrice, currency)
+			if err != nil || unitPrice != product.UnitPrice {
+				return req, &LinkRequestError{Status: http.StatusBadRequest, Code: "PRICE_MISMATCH",
+					Details: fmt.Sprintf("items[%d]: unitPrice does not match the catalog price %s for %s", i, money.FormatMinorUnits(product.UnitPrice, currency), sku)}
+			}
+		}
+
+		lineTotal := product.UnitPrice * int64(item.Quantity)
+		total += lineTotal
+		lines = append(lines, fmt.Sprintf("%d x %s (%s) @ %s = %s", item.Quantity, product.Name, sku,
+			money.FormatMinorUnits(product.UnitPrice, currency), money.FormatMinorUnits(lineTotal, currency)))
+	}
+
+	amount := money.FormatMinorUnits(total, currency)
+	if strings.TrimSpace(req.Amount) != "" {
+		if requested, err := money.ToMinorUnits(req.Amount, currency); err != nil || requested != total {
+			return req, &LinkRequestError{Status: http.StatusBadRequest, Code: "AMOUNT_MISMATCH",
+				Details: fmt.Sprintf("amount does not match the item total %s %s", amount, currency)}
+		}
+	}
+
+	req.Amount = amount
+	req.Currency = currency
+	if description := strings.TrimSpace(req.Description); description != "" {
+		lines = append([]string{description}, lines...)
+	}
+	req.Description = strings.Join(lines, "\n")
+	req.Items = nil
+	req.itemized = true
+	return req, nil
+}
+
+// handleProducts handles the /products endpoint, listing the catalog ordered by SKU
+func (s *Server) handleProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := s.links.ListProducts(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing products", "error", err)
+		writeError(w, http.StatusInternalServerError, "Product listing failed", "STORE_ERROR", "Error reading stored products")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    products,
+	})
+}
+
+// skuFromPath returns the SKU in the request path, writing an error response and
+// returning false when it is malformed
+func skuFromPath(w http.ResponseWriter, r *http.Request, failure string) (string, bool) {
+	sku := r.PathValue("sku")
+	if !skuPattern.MatchString(sku) {
+		writeError(w, http.StatusBadRequest, failure, "INVALID_SKU", "SKU must be 1-64 letters, digits, '.', '_', or '-'")
+		return "", false
+	}
+	return sku, true
+}
+
+// writeProductStoreError writes the response for a failed product store operation
+func writeProductStoreError(w http.ResponseWriter, r *http.Request, failure, sku string, err error) {
+	if errors.Is(err, store.ErrProductNotFound) {
+		writeError(w, http.StatusNotFound, failure, "PRODUCT_NOT_FOUND", "Product not found")
+		return
+	}
+	logging.FromContext(r.Context()).Error("Error accessing product", "sku", sku, "error", err)
+	writeError(w, http.StatusInternalServerError, failure, "STORE_ERROR", "Error accessing stored product")
+}
+
+// handleGetProduct handles GET requests to the /products/{sku} endpoint
+func (s *Server) handleGetProduct(w http.ResponseWriter, r *http.Request) {
+	sku, ok := skuFromPath(w, r, "Product lookup failed")
+	if !ok {
+		return
+	}
+
+	product, err := s.links.GetProduct(r.Context(), sku)
+	if err != nil {
+		writeProductStoreError(w, r, "Product lookup failed", sku, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    product,
+	})
+}
+
+// handleSaveProduct handles PUT requests to the /products/{sku} endpoint, which add the
+// product to the catalog or replace it. Links already created keep the price they were created with.
+func (s *Server) handleSaveProduct(w http.ResponseWriter, r *http.Request) {
+	sku, ok := skuFromPath(w, r, "Product update failed")
+	if !ok {
+		return
+	}
+
+	var req ProductRequest
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		if jsonErr := decodeStrictJSON(r.Body, &req); jsonErr != nil {
+			writeJSON(w, jsonErr.Status, Response{Success: false, Message: "Product update failed", Error: jsonErr.Info()})
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			if writeBodyTooLarge(w, "Product update failed", err) {
+				return
+			}
+			writeError(w, http.StatusBadRequest, "Product update failed", "FORM_PARSE_ERROR", "Error parsing form data")
+			return
+		}
+		req = ProductRequest{
+			Name:      r.Form.Get("name"),
+			UnitPrice: r.Form.Get("unitPrice"),
+			Currency:  r.Form.Get("currency"),
+		}
+	}
+
+	product, linkErr := validateProductRequest(sku, req, s.linkDefaults.SupportedCurrencies)
+	if linkErr != nil {
+		writeJSON(w, linkErr.Status, Response{Success: false, Message: "Product update failed", Error: linkErr.Info()})
+		return
+	}
+
+	// Read any product being replaced so the audit log can record what it was
+	previous, err := s.links.GetProduct(r.Context(), sku)
+	if err != nil && !errors.Is(err, store.ErrProductNotFound) {
+		writeProductStoreError(w, r, "Product update failed", sku, err)
+		return
+	}
+
+	if err := s.links.SaveProduct(r.Context(), product); err != nil {
+		writeProductStoreError(w, r, "Product update failed", sku, err)
+		return
+	}
+
+	// Read the product back for its creation time
+	saved, err := s.links.GetProduct(r.Context(), sku)
+	if err != nil {
+		writeProductStoreError(w, r, "Product update failed", sku, err)
+		return
+	}
+
+	s.audit(r.Context(), store.AuditEntry{Action: auditProductSave, Resource: sku}, map[string]interface{}{
+		"product": auditChange{From: previous, To: saved},
+	})
+	logging.FromContext(r.Context()).Info("Product saved", "sku", sku, "api_key", apiKeyNameFrom(r.Context()))
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Product saved",
+		Data:    saved,
+	})
+}
+
+// handleDeleteProduct handles DELETE requests to the /products/{sku} endpoint
+func (s *Server) handleDeleteProduct(w http.ResponseWriter, r *http.Request) {
+	sku, ok := skuFromPath(w, r, "Product deletion failed")
+	if !ok {
+		return
+	}
+
+	if err := s.links.DeleteProduct(r.Context(), sku); err != nil {
+		writeProductStoreError(w, r, "Product deletion failed", sku, err)
+		return
+	}
+
+	s.audit(r.Context(), store.AuditEntry{Action: auditProductDelete, Resource: sku}, nil)
+	logging.FromContext(r.Context()).Info("Product deleted", "sku", sku, "api_key", apiKeyNameFrom(r.Context()))
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Product deleted",
+		Data:    map[string]string{"sku": sku},
+	})
+}
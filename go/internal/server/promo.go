@@ -0,0 +1,40 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+)
+
+// promoDiscount looks up a promo code and works out the discount it gives on an amount in
+// minor units. Codes are matched case-insensitively. The code's uses are not counted here;
+// they are claimed once the rest of the link request is known to be valid.
+func (s *Server) promoDiscount(code, currency string, amount int64, now time.Time) (config.PromoCode, int64, *LinkRequestError) {
+	promo, ok := s.promoCodes[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return promo, 0, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_PROMO_CODE", Details: "Unknown promo code"}
+	}
+	if !promo.ExpiresAt.IsZero() && now.After(promo.ExpiresAt) {
+		return promo, 0, newLinkRequestError(http.StatusBadRequest, "PROMO_CODE_EXPIRED", "Promo code %s has expired", promo.Code)
+	}
+
+	var discount int64
+	if promo.Percent > 0 {
+		// Round half up to the nearest minor unit
+		discount = (amount*int64(promo.Percent) + 50) / 100
+	} else {
+		if promo.Currency != currency {
+			return promo, 0, newLinkRequestError(http.StatusBadRequest, "PROMO_CODE_NOT_APPLICABLE",
+				"Promo code %s only applies to %s amounts", promo.Code, promo.Currency)
+		}
+		discount = promo.AmountOff
+	}
+	if discount >= amount {
+		return promo, 0, newLinkRequestError(http.StatusBadRequest, "PROMO_CODE_NOT_APPLICABLE",
+			"Promo code %s would reduce the amount %s to nothing", promo.Code, money.FormatMinorUnits(amount, currency))
+	}
+	return promo, discount, nil
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+)
+
+func TestPromoDiscount(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := &Server{promoCodes: map[string]config.PromoCode{
+		"TEN":     {Code: "TEN", Percent: 10},
+		"FIFTEEN": {Code: "FIFTEEN", Percent: 15},
+		"FIVEOFF": {Code: "FIVEOFF", AmountOff: 500, Currency: "EUR"},
+		"EXPIRED": {Code: "EXPIRED", Percent: 10, ExpiresAt: now.Add(-time.Second)},
+		"CURRENT": {Code: "CURRENT", Percent: 10, ExpiresAt: now.Add(time.Second)},
+	}}
+
+	tests := []struct {
+		name     string
+		code     string
+		currency string
+		amount   int64
+		want     int64
+		wantCode string
+	}{
+		{name: "percent", code: "TEN", currency: "EUR", amount: 1000, want: 100},
+		{name: "percent rounds half up", code: "TEN", currency: "EUR", amount: 5, want: 1},
+		{name: "percent rounds down under half", code: "TEN", currency: "EUR", amount: 4, want: 0},
+		{name: "percent rounds to nearest", code: "FIFTEEN", currency: "EUR", amount: 999, want: 150},
+		{name: "percent in a zero-decimal currency", code: "FIFTEEN", currency: "JPY", amount: 1234, want: 185},
+		{name: "code is case-insensitive", code: " ten ", currency: "EUR", amount: 1000, want: 100},
+		{name: "amount off", code: "FIVEOFF", currency: "EUR", amount: 1000, want: 500},
+		{name: "not yet expired", code: "CURRENT", currency: "EUR", amount: 1000, want: 100},
+
+		{name: "unknown code", code: "NOPE", currency: "EUR", amount: 1000, wantCode: "INVALID_PROMO_CODE"},
+		{name: "expired", code: "EXPIRED", currency: "EUR", amount: 1000, wantCode: "PROMO_CODE_EXPIRED"},
+		{name: "amount off in another currency", code: "FIVEOFF", currency: "USD", amount: 1000, wantCode: "PROMO_CODE_NOT_APPLICABLE"},
+		{name: "discount equal to amount", code: "FIVEOFF", currency: "EUR", amount: 500, wantCode: "PROMO_CODE_NOT_APPLICABLE"},
+		{name: "discount over amount", code: "FIVEOFF", currency: "EUR", amount: 499, wantCode: "PROMO_CODE_NOT_APPLICABLE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, discount, linkErr := s.promoDiscount(tt.code, tt.currency, tt.amount, now)
+			if tt.wantCode != "" {
+				if linkErr == nil || linkErr.Code != tt.wantCode {
+					t.Fatalf("promoDiscount(%q) error = %v, want %s", tt.code, linkErr, tt.wantCode)
+				}
+				return
+			}
+			if linkErr != nil {
+				t.Fatalf("promoDiscount(%q) error = %v", tt.code, linkErr)
+			}
+			if discount != tt.want {
+				t.Errorf("promoDiscount(%q, %d) discount = %d, want %d", tt.code, tt.amount, discount, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/locale"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// ipBucketIdleTTL is how long a client IP's bucket is kept after its last request
+const ipBucketIdleTTL = 10 * time.Minute
+
+// ipBucket is the token bucket for a single client IP
+type ipBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// IPRateLimiter applies a token-bucket rate limit per client IP
+type IPRateLimiter struct {
+	limit          rate.Limit
+	burst          int
+	trustedProxies int
+
+	mu        sync.Mutex
+	buckets   map[string]*ipBucket
+	lastSweep time.Time
+}
+
+// NewIPRateLimiter creates an IPRateLimiter allowing cfg.PerMinute requests per IP with
+// cfg.Burst, where cfg.TrustedProxies is the number of reverse proxies in front of the
+// server that append to X-Forwarded-For. It returns nil, disabling the limit, when
+// cfg.PerMinute is 0.
+func NewIPRateLimiter(cfg config.RateLimit) *IPRateLimiter {
+	if cfg.PerMinute == 0 {
+		return nil
+	}
+	return &IPRateLimiter{
+		limit:          rate.Limit(float64(cfg.PerMinute) / 60),
+		burst:          cfg.Burst,
+		trustedProxies: cfg.TrustedProxies,
+		buckets:        make(map[string]*ipBucket),
+		lastSweep:      time.Now(),
+	}
+}
+
+// clientIP returns the address of the client that sent r, as the limiter's trusted
+// proxies see it
+func (l *IPRateLimiter) clientIP(r *http.Request) string {
+	return clientIP(r, l.trustedProxies)
+}
+
+// clientIP returns the address of the client that sent r. With trusted proxies
+// configured it reads X-Forwarded-For from the right, skipping the hops added
+// by proxies in front of the server, so a client cannot spoof its address by
+// sending its own header.
+func clientIP(r *http.Request, trustedProxies int) string {
+	if trustedProxies > 0 {
+		var hops []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, hop := range strings.Split(header, ",") {
+				if hop = strings.TrimSpace(hop); hop != "" {
+					hops = append(hops, hop)
+				}
+			}
+		}
+		if len(hops) >= trustedProxies {
+			return hops[len(hops)-trustedProxies]
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// reserve takes a token from ip's bucket, returning how long the caller must wait if none is available
+func (l *IPRateLimiter) reserve(ip string) time.Duration {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop buckets for clients that have gone quiet so the map does not grow without bound
+	if now.Sub(l.lastSweep) > ipBucketIdleTTL {
+		for key, bucket := range l.buckets {
+			if now.Sub(bucket.lastSeen) > ipBucketIdleTTL {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	bucket, ok := l.buckets[ip]
+	if !ok {
+		bucket = &ipBucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[ip] = bucket
+	}
+	bucket.lastSeen = now
+
+	reservation := bucket.limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+	}
+	return delay
+}
+
+// Limit wraps next so that each client IP is held to the configured rate.
+// When l is nil, next is returned unchanged.
+func (l *IPRateLimiter) Limit(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := l.clientIP(r)
+		if delay := l.reserve(ip); delay > 0 {
+			logging.FromContext(r.Context()).Warn("Client IP rate limit exceeded", "client_ip", ip, "path", r.URL.Path)
+			writeRateLimited(w, delay, locale.Messagef("Too many requests from this address"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// delayFor takes a token from the bucket of the client that sent r, returning how long the
+// client must wait if none is available. A nil limiter never delays.
+func (l *IPRateLimiter) delayFor(r *http.Request) time.Duration {
+	if l == nil {
+		return 0
+	}
+	return l.reserve(l.clientIP(r))
+}
+
+// writeRateLimited writes a 429 RATE_LIMITED response with a Retry-After header
+func writeRateLimited(w http.ResponseWriter, delay time.Duration, details locale.Message) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+	writeErrorf(w, http.StatusTooManyRequests, "Rate limit exceeded", "RATE_LIMITED", details.Key, details.Args...)
+}
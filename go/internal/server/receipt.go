@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/pdf"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// receiptTimeLayout formats the payment time shown on receipts
+const receiptTimeLayout = "2 January 2006, 15:04 UTC"
+
+//go:embed templates/receipt.html
+var receiptTemplateFS embed.FS
+
+// receiptTemplate renders a paid link's receipt as HTML
+var receiptTemplate = template.Must(template.ParseFS(receiptTemplateFS, "templates/receipt.html"))
+
+// ReceiptPage is the data shown on a payment receipt
+type ReceiptPage struct {
+	MerchantName  string
+	LinkID        string
+	Reference     string
+	TransactionID string
+	Amount        string
+	Currency      string
+	// Tax describes the tax included in the amount, such as "1.50 EUR at 20%", when there is any
+	Tax string
+	// Card describes the card paid with, such as "VISA ending 1111", when it is known
+	Card   string
+	PaidAt string
+}
+
+// Rows returns the receipt's details as label and value pairs, in the order shown
+func (p ReceiptPage) Rows() [][2]string {
+	rows := [][2]string{}
+	add := func(label, value string) {
+		if value != "" {
+			rows = append(rows, [2]string{label, value})
+		}
+	}
+	add("Amount paid", p.Amount+" "+p.Currency)
+	add("Tax included", p.Tax)
+	add("Paid on", p.PaidAt)
+	add("Paid with", p.Card)
+	add("Reference", p.Reference)
+	add("Transaction", p.TransactionID)
+	add("Payment link", p.LinkID)
+	return rows
+}
+
+// cardLast4Pattern matches the last four digits of a masked card number such as XXXXXXXXXXXX1111
+var cardLast4Pattern = regexp.MustCompile(`[0-9]{4}$`)
+
+// saveReceipt records the payment in a notification that marked link paid, for its receipt.
+// Failures are logged rather than returned, as the payment has already been recorded.
+func (s *Server) saveReceipt(ctx context.Context, link *store.Link, notification *GPStatusNotification) {
+	receipt := &store.Receipt{
+		LinkID:        link.ID,
+		TransactionID: notification.ID,
+		Amount:        link.Amount,
+		Currency:      link.Currency,
+		CardBrand:     truncateText(strings.ToUpper(strings.TrimSpace(notification.PaymentMethod.Card.Brand)), 32),
+		CardLast4:     cardLast4Pattern.FindString(strings.TrimSpace(notification.PaymentMethod.Card.MaskedNumberLast4)),
+		PaidAt:        time.Now().UTC(),
+	}
+	// GP reports amounts in minor units; open-amount links are paid what the customer chose
+	if amount, err := strconv.ParseInt(notification.Amount, 10, 64); err == nil && amount > 0 {
+		receipt.Amount = amount
+	}
+	if notification.Currency != "" {
+		receipt.Currency = strings.ToUpper(notification.Currency)
+	}
+	if created, err := time.Parse(time.RFC3339, notification.TimeCreated); err == nil {
+		receipt.PaidAt = created.UTC()
+	}
+
+	if err := s.links.SaveReceipt(ctx, receipt); err != nil {
+		logging.FromContext(ctx).Error("Error saving receipt", "link_id", link.ID, "transaction_id", notification.ID, "error", err)
+	}
+}
+
+// receiptPage describes the receipt of a paid link. Links marked paid without a status
+// notification, such as by reconciliation, have no saved receipt, so their stored
+// transaction is shown without the card.
+func (s *Server) receiptPage(link *store.Link, receipt *store.Receipt) ReceiptPage {
+	if receipt == nil {
+		receipt = &store.Receipt{TransactionID: link.TransactionID, Amount: link.Amount, Currency: link.Currency, PaidAt: link.UpdatedAt}
+	}
+	page := ReceiptPage{
+		MerchantName:  s.linkDefaults.MerchantName,
+		LinkID:        link.ID,
+		Reference:     link.Reference,
+		TransactionID: receipt.TransactionID,
+		Amount:        money.FormatMinorUnits(receipt.Amount, receipt.Currency),
+		Currency:      receipt.Currency,
+		PaidAt:        receipt.PaidAt.UTC().Format(receiptTimeLayout),
+	}
+	if link.TaxAmount > 0 {
+		page.Tax = money.FormatMinorUnits(link.TaxAmount, link.Currency) + " " + link.Currency
+		if link.TaxRate != "" {
+			page.Tax += " at " + link.TaxRate + "%"
+		}
+	}
+	switch {
+	case receipt.CardBrand != "" && receipt.CardLast4 != "":
+		page.Card = receipt.CardBrand + " ending " + receipt.CardLast4
+	case receipt.CardLast4 != "":
+		page.Card = "Card ending " + receipt.CardLast4
+	case receipt.CardBrand != "":
+		page.Card = receipt.CardBrand
+	}
+	return page
+}
+
+// writeReceiptPDF lays the receipt out on an A4 page
+func writeReceiptPDF(w http.ResponseWriter, page ReceiptPage) {
+	var doc pdf.Document
+	const left, values = 72, 200
+	y := float64(pdf.PageHeight - 96)
+	if page.MerchantName != "" {
+		doc.Text(left, y, 14, true, page.MerchantName)
+		y -= 36
+	}
+	doc.Text(left, y, 22, true, "Payment receipt")
+	y -= 24
+	doc.Text(left, y, 11, false, "Thank you. Your payment has been received.")
+	y -= 40
+	for _, row := range page.Rows() {
+		doc.Text(left, y, 11, true, row[0])
+		doc.Text(values, y, 11, false, row[1])
+		y -= 20
+	}
+
+	w.Header().Set("Content-Type", pdf.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="receipt-%s.pdf"`, page.LinkID))
+	w.Header().Set("Cache-Control", "no-store")
+	doc.WriteTo(w)
+}
+
+// handleGetReceipt handles GET requests to /payment-link/{id}/receipt, rendering a paid
+// link's receipt as an HTML page, or as a PDF with ?format=pdf, for emailing to the payer
+func (s *Server) handleGetReceipt(w http.ResponseWriter, r *http.Request) {
+	linkID := r.PathValue("id")
+	if !linkIDPattern.MatchString(linkID) {
+		writeError(w, http.StatusBadRequest, "Receipt lookup failed", "INVALID_LINK_ID", "Invalid payment link ID")
+		return
+	}
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format != "" && format != "html" && format != "pdf" {
+		writeError(w, http.StatusBadRequest, "Receipt lookup failed", "INVALID_FORMAT", "format must be html or pdf")
+		return
+	}
+
+	link, err := s.links.GetLink(r.Context(), linkID)
+	if errors.Is(err, store.ErrLinkNotFound) {
+		writeError(w, http.StatusNotFound, "Receipt lookup failed", "LINK_NOT_FOUND", "Payment link not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error reading payment link", "link_id", linkID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Receipt lookup failed", "STORE_ERROR", "Error reading stored payment link")
+		return
+	}
+	if link.Status != store.LinkStatusPaid {
+		writeErrorf(w, http.StatusConflict, "Receipt lookup failed", "LINK_NOT_PAID",
+			"Payment link is %s; receipts are only available once it is paid", link.Status)
+		return
+	}
+
+	receipt, err := s.links.GetReceipt(r.Context(), linkID)
+	if err != nil && !errors.Is(err, store.ErrReceiptNotFound) {
+		logging.FromContext(r.Context()).Error("Error reading receipt", "link_id", linkID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Receipt lookup failed", "STORE_ERROR", "Error reading stored receipt")
+		return
+	}
+
+	page := s.receiptPage(link, receipt)
+	if format == "pdf" {
+		writeReceiptPDF(w, page)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	receiptTemplate.Execute(w, page)
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/webhooks"
+)
+
+// reconcileLinkTimeout bounds the GP API lookup and store update for one link
+const reconcileLinkTimeout = 30 * time.Second
+
+// reconcileLinks looks up every link stored as active in GP API and records payments, expiry,
+// and deactivation that the server missed, such as when a status notification never arrived.
+// Each change is announced as a link event, as if the notification had been received.
+func (s *Server) reconcileLinks(ctx context.Context) {
+	start := time.Now()
+	checked, updated := 0, 0
+
+	filter := store.LinkFilter{Status: store.LinkStatusActive, Limit: maxListLimit}
+	for {
+		links, err := s.links.ListLinks(ctx, filter)
+		if err != nil {
+			slog.Error("Error listing links to reconcile", "error", err)
+			return
+		}
+
+		for _, link := range links {
+			if ctx.Err() != nil {
+				return
+			}
+			changed, err := s.reconcileLink(ctx, link)
+			checked++
+			if err != nil {
+				slog.Warn("Error reconciling link", "link_id", link.ID, "error", err)
+				continue
+			}
+			if changed {
+				updated++
+			}
+		}
+
+		if len(links) < filter.Limit {
+			break
+		}
+		last := links[len(links)-1]
+		filter.After = &store.LinkCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	level := slog.LevelDebug
+	if updated > 0 {
+		level = slog.LevelInfo
+	}
+	slog.Log(ctx, level, "Links reconciled with GP API", "checked", checked, "updated", updated, "duration_ms", time.Since(start).Milliseconds())
+}
+
+// reconcileLink brings one active link up to date with GP API, reporting whether it changed
+func (s *Server) reconcileLink(ctx context.Context, link *store.Link) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, reconcileLinkTimeout)
+	defer cancel()
+
+	detail, err := s.gp.GetLink(ctx, link.ID)
+	if err != nil {
+		var apiErr *gpapi.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			// Links unknown to GP, such as those created against another environment, stay as they are
+			return false, nil
+		}
+		return false, err
+	}
+
+	// A captured or authorized payment takes precedence over the link's own status
+	var paid *gpapi.Transaction
+	for i := range detail.Transactions.TransactionList {
+		transaction := &detail.Transactions.TransactionList[i]
+		if transactionPaid(transaction.Status) {
+			paid = transaction
+		}
+	}
+	if paid != nil {
+		_, err := s.links.RecordTransaction(ctx, link.ID, true, paid.ID, strings.ToUpper(paid.Status),
+			newOutboxEvent(webhooks.EventLinkPaid))
+		if errors.Is(err, store.ErrLinkUnchanged) || errors.Is(err, store.ErrTransactionRecorded) {
+			// A status notification recorded the payment since the link was listed
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		slog.Info("Reconciled missed payment", "link_id", link.ID, "transaction_id", paid.ID, "transaction_status", paid.Status)
+		s.wakeOutboxRelay()
+		return true, nil
+	}
+
+	status, ok := linkStatusFromGP(detail.Status)
+	if !ok || status == link.Status {
+		return false, nil
+	}
+	if err := s.links.UpdateStatus(ctx, link.ID, status, newOutboxEvent(eventForStatus(status))); err != nil {
+		return false, err
+	}
+	slog.Info("Reconciled link status", "link_id", link.ID, "from", link.Status, "to", status)
+	s.wakeOutboxRelay()
+	return true, nil
+}
@@ -0,0 +1,369 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+	"github.com/globalpayments/pay-by-link-go/internal/locale"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// Recurring link series limits
+const (
+	minInstallments = 2
+	maxInstallments = 52
+	// maxInstallmentAttempts is how many times an installment's link is tried before it is marked FAILED
+	maxInstallmentAttempts = 5
+)
+
+// Installment statuses reported before an installment has a link
+const (
+	installmentScheduled = "SCHEDULED"
+	installmentFailed    = "FAILED"
+)
+
+// seriesIDPattern matches the identifiers assigned to recurring link series
+var seriesIDPattern = regexp.MustCompile(`^RCR_[0-9a-f]{24}$`)
+
+// RecurringLinkRequest represents a request to create a series of single-use installment links.
+// The link fields apply to every installment; each installment's reference is the series
+// reference followed by "-" and the installment number.
+type RecurringLinkRequest struct {
+	PaymentLinkRequest
+	Cadence     string `json:"cadence" form:"cadence"`
+	Occurrences string `json:"occurrences" form:"occurrences"`
+}
+
+// seriesTemplate is the link request stored with a series, recording whether its amount
+// was priced from line items, as the items themselves are not kept
+type seriesTemplate struct {
+	PaymentLinkRequest
+	Itemized bool `json:"itemized,omitempty"`
+}
+
+// RecurringLinkResponse represents a recurring link series and the state of its installments
+type RecurringLinkResponse struct {
+	SeriesID      string                `json:"seriesId"`
+	Reference     string                `json:"reference"`
+	Cadence       string                `json:"cadence"`
+	Occurrences   int                   `json:"occurrences"`
+	Amount        int64                 `json:"amount"`
+	DisplayAmount string                `json:"displayAmount"`
+	Currency      string                `json:"currency"`
+	PaidCount     int                   `json:"paidCount"`
+	CreatedAt     string                `json:"createdAt"`
+	Installments  []InstallmentResponse `json:"installments"`
+}
+
+// InstallmentResponse represents one installment of a recurring link series. Status is
+// SCHEDULED until the installment's link is created, then the link's status, or FAILED
+// if the link could not be created.
+type InstallmentResponse struct {
+	Number      int    `json:"number"`
+	Reference   string `json:"reference"`
+	DueAt       string `json:"dueAt"`
+	Status      string `json:"status"`
+	LinkID      string `json:"linkId,omitempty"`
+	PaymentLink string `json:"paymentLink,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// installmentReference derives the reference of an installment from the series reference
+func installmentReference(reference string, number int) string {
+	return reference + "-" + strconv.Itoa(number)
+}
+
+// installmentDueAt returns when the installment with the given number falls due, the
+// first being due at start
+func installmentDueAt(start time.Time, cadence string, number int) time.Time {
+	if cadence == store.CadenceWeekly {
+		return start.AddDate(0, 0, 7*(number-1))
+	}
+	return start.AddDate(0, number-1, 0)
+}
+
+// newSeriesID generates a random recurring link series identifier
+func newSeriesID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "RCR_" + hex.EncodeToString(b)
+}
+
+// handleCreateRecurringLinks handles the /recurring-links endpoint. The first installment's
+// link is created at once, which also validates the link fields; later installments are
+// created by the background job as they fall due.
+func (s *Server) handleCreateRecurringLinks(w http.ResponseWriter, r *http.Request) {
+	var req RecurringLinkRequest
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		if jsonErr := decodeStrictJSON(r.Body, &req); jsonErr != nil {
+			writeJSON(w, jsonErr.Status, Response{Success: false, Message: "Recurring link creation failed", Error: jsonErr.Info()})
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			if writeBodyTooLarge(w, "Recurring link creation failed", err) {
+				return
+			}
+			writeError(w, http.StatusBadRequest, "Recurring link creation failed", "FORM_PARSE_ERROR", "Error parsing form data")
+			return
+		}
+		req.PaymentLinkRequest = paymentLinkRequestFromForm(r.Form)
+		req.Cadence = r.Form.Get("cadence")
+		req.Occurrences = r.Form.Get("occurrences")
+	}
+
+	// Installments are created from the template and catalog prices as they are now, even if they later change
+	var linkErr *LinkRequestError
+	if req.PaymentLinkRequest, linkErr = s.resolveLinkTemplate(r.Context(), req.PaymentLinkRequest); linkErr != nil {
+		writeJSON(w, linkErr.Status, Response{Success: false, Message: "Recurring link creation failed", Error: linkErr.Info()})
+		return
+	}
+	if req.PaymentLinkRequest, linkErr = s.resolveLineItems(r.Context(), req.PaymentLinkRequest); linkErr != nil {
+		writeJSON(w, linkErr.Status, Response{Success: false, Message: "Recurring link creation failed", Error: linkErr.Info()})
+		return
+	}
+
+	cadence := strings.ToUpper(strings.TrimSpace(req.Cadence))
+	if cadence != store.CadenceWeekly && cadence != store.CadenceMonthly {
+		writeError(w, http.StatusBadRequest, "Recurring link creation failed", "INVALID_CADENCE", "cadence must be WEEKLY or MONTHLY")
+		return
+	}
+
+	occurrences, err := strconv.Atoi(strings.TrimSpace(req.Occurrences))
+	if err != nil || occurrences < minInstallments || occurrences > maxInstallments {
+		writeErrorf(w, http.StatusBadRequest, "Recurring link creation failed", "INVALID_OCCURRENCES",
+			"occurrences must be a whole number from %d to %d", minInstallments, maxInstallments)
+		return
+	}
+
+	// Each installment is paid once, and expires a number of days after it is created
+	if mode := strings.TrimSpace(req.UsageMode); (mode != "" && !strings.EqualFold(mode, string(gpapi.UsageModeSingle))) || req.UsageLimit != "" {
+		writeError(w, http.StatusBadRequest, "Recurring link creation failed", "INVALID_USAGE", "Installment links are single use; omit usageMode and usageLimit")
+		return
+	}
+	if req.ExpirationDate != "" {
+		writeError(w, http.StatusBadRequest, "Recurring link creation failed", "INVALID_EXPIRATION", "Use expirationDays to set how long each installment's link stays open")
+		return
+	}
+	if req.PromoCode != "" {
+		writeError(w, http.StatusBadRequest, "Recurring link creation failed", "INVALID_PROMO_CODE", "Promo codes cannot be applied to recurring series")
+		return
+	}
+	if req.openAmount() {
+		writeError(w, http.StatusBadRequest, "Recurring link creation failed", "INVALID_AMOUNT_RANGE", "Installments have a fixed amount; omit minAmount and maxAmount")
+		return
+	}
+
+	// The derived references must fit the reference limit
+	reference := normalizeText(req.Reference)
+	if reference == "" && s.linkDefaults.ReferenceFormat != "" {
+		if reference, linkErr = s.generateReference(r.Context()); linkErr != nil {
+			writeJSON(w, linkErr.Status, Response{Success: false, Message: "Recurring link creation failed", Error: linkErr.Info()})
+			return
+		}
+	}
+	var fields []FieldError
+	if reference == "" {
+		fields = append(fields, fieldError("reference", FieldRequired, "%s is required", "reference"))
+	} else if limit := maxReferenceLength - len(installmentReference("", occurrences)); len([]rune(reference)) > limit {
+		fields = append(fields, fieldError("reference", FieldTooLong,
+			"reference must be at most %d characters for %d occurrences", limit, occurrences))
+	}
+	if len(fields) > 0 {
+		linkErr := validationError(fields)
+		writeJSON(w, linkErr.Status, Response{Success: false, Message: "Recurring link creation failed", Error: linkErr.Info()})
+		return
+	}
+
+	template := req.PaymentLinkRequest
+	template.Reference = reference
+	template.UsageMode = ""
+	// The request holds only strings, so encoding it cannot fail
+	encoded, _ := json.Marshal(seriesTemplate{PaymentLinkRequest: template, Itemized: template.itemized})
+
+	now := time.Now().UTC()
+	series := &store.LinkSeries{
+		ID:          newSeriesID(),
+		Reference:   reference,
+		Cadence:     cadence,
+		Occurrences: occurrences,
+		Template:    string(encoded),
+	}
+	installments := make([]*store.Installment, 0, occurrences)
+	for number := 1; number <= occurrences; number++ {
+		installments = append(installments, &store.Installment{
+			Number:    number,
+			Reference: installmentReference(reference, number),
+			DueAt:     installmentDueAt(now, cadence, number),
+		})
+	}
+
+	// Create the first installment's link now, so an invalid request records nothing
+	first := template
+	first.Reference = installments[0].Reference
+	link, linkErr := s.createLinkFromRequest(r.Context(), first)
+	if linkErr != nil {
+		writeJSON(w, linkErr.Status, Response{Success: false, Message: "Recurring link creation failed", Error: linkErr.Info()})
+		return
+	}
+	installments[0].LinkID = link.LinkID
+	installments[0].Attempts = 1
+
+	if err := s.links.CreateSeries(r.Context(), series, installments); err != nil {
+		logging.FromContext(r.Context()).Error("Error storing recurring link series", "series_id", series.ID, "link_id", link.LinkID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Recurring link creation failed", "STORE_ERROR",
+			fmt.Sprintf("The first installment's link %s was created, but the series could not be recorded", link.LinkID))
+		return
+	}
+	logging.FromContext(r.Context()).Info("Recurring link series created",
+		"series_id", series.ID, "reference", reference, "cadence", cadence, "occurrences", occurrences)
+
+	response, err := s.recurringLinkResponse(r.Context(), series)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error reading recurring link series", "series_id", series.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Recurring link creation failed", "STORE_ERROR", "Error reading stored installments")
+		return
+	}
+	writeJSON(w, http.StatusOK, Response{
+		Success:        true,
+		catalogMessage: locale.Messagef("Recurring links scheduled! Series ID: %s", series.ID),
+		Data:           response,
+	})
+}
+
+// handleGetRecurringLinks handles GET requests to the /recurring-links/{id} endpoint
+func (s *Server) handleGetRecurringLinks(w http.ResponseWriter, r *http.Request) {
+	seriesID := r.PathValue("id")
+	if !seriesIDPattern.MatchString(seriesID) {
+		writeError(w, http.StatusBadRequest, "Recurring link lookup failed", "INVALID_SERIES_ID", "Invalid recurring link series ID")
+		return
+	}
+
+	series, err := s.links.GetSeries(r.Context(), seriesID)
+	if errors.Is(err, store.ErrSeriesNotFound) {
+		writeError(w, http.StatusNotFound, "Recurring link lookup failed", "SERIES_NOT_FOUND", "Recurring link series not found")
+		return
+	}
+	var response *RecurringLinkResponse
+	if err == nil {
+		response, err = s.recurringLinkResponse(r.Context(), series)
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error reading recurring link series", "series_id", seriesID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Recurring link lookup failed", "STORE_ERROR", "Error reading stored installments")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Response{Success: true, Data: response})
+}
+
+// recurringLinkResponse describes a series and its installments as currently stored
+func (s *Server) recurringLinkResponse(ctx context.Context, series *store.LinkSeries) (*RecurringLinkResponse, error) {
+	var template PaymentLinkRequest
+	if err := json.Unmarshal([]byte(series.Template), &template); err != nil {
+		return nil, fmt.Errorf("failed to decode series template: %w", err)
+	}
+	installments, err := s.links.ListInstallments(ctx, series.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(template.Currency))
+	amount, _ := money.ToMinorUnits(template.Amount, currency)
+	response := &RecurringLinkResponse{
+		SeriesID:      series.ID,
+		Reference:     series.Reference,
+		Cadence:       series.Cadence,
+		Occurrences:   series.Occurrences,
+		Amount:        amount,
+		DisplayAmount: money.FormatMinorUnits(amount, currency),
+		Currency:      currency,
+		CreatedAt:     series.CreatedAt.Format(time.RFC3339),
+		Installments:  make([]InstallmentResponse, 0, len(installments)),
+	}
+	for _, installment := range installments {
+		item := InstallmentResponse{
+			Number:      installment.Number,
+			Reference:   installment.Reference,
+			DueAt:       installment.DueAt.Format(time.RFC3339),
+			Status:      installment.LinkStatus,
+			LinkID:      installment.LinkID,
+			PaymentLink: installment.LinkURL,
+		}
+		switch {
+		case installment.LinkID != "":
+		case installment.Attempts >= maxInstallmentAttempts:
+			item.Status = installmentFailed
+			item.Error = installment.LastError
+		default:
+			item.Status = installmentScheduled
+			item.Error = installment.LastError
+		}
+		if item.Status == store.LinkStatusPaid {
+			response.PaidCount++
+		}
+		response.Installments = append(response.Installments, item)
+	}
+	return response, nil
+}
+
+// createDueInstallments creates the links of recurring series installments that have fallen
+// due. A failed installment is retried on later runs, up to maxInstallmentAttempts times.
+func (s *Server) createDueInstallments(ctx context.Context) {
+	installments, err := s.links.ListDueInstallments(ctx, time.Now(), maxInstallmentAttempts, maxListLimit)
+	if err != nil {
+		slog.Error("Error listing due installments", "error", err)
+		return
+	}
+
+	templates := make(map[string]*PaymentLinkRequest)
+	for _, installment := range installments {
+		if ctx.Err() != nil {
+			return
+		}
+
+		template, ok := templates[installment.SeriesID]
+		if !ok {
+			series, err := s.links.GetSeries(ctx, installment.SeriesID)
+			if err != nil {
+				slog.Error("Error reading recurring link series", "series_id", installment.SeriesID, "error", err)
+				continue
+			}
+			var decoded seriesTemplate
+			if err := json.Unmarshal([]byte(series.Template), &decoded); err != nil {
+				slog.Error("Error decoding recurring link series", "series_id", installment.SeriesID, "error", err)
+				continue
+			}
+			template = &decoded.PaymentLinkRequest
+			template.itemized = decoded.Itemized
+			templates[installment.SeriesID] = template
+		}
+
+		req := *template
+		req.Reference = installment.Reference
+		var linkID, attemptError string
+		if link, linkErr := s.createLinkFromRequest(ctx, req); linkErr != nil {
+			attemptError = linkErr.Error()
+			slog.Warn("Error creating installment link",
+				"series_id", installment.SeriesID, "installment", installment.Number, "attempt", installment.Attempts+1, "error", linkErr)
+		} else {
+			linkID = link.LinkID
+		}
+		if err := s.links.RecordInstallmentAttempt(ctx, installment.SeriesID, installment.Number, linkID, attemptError); err != nil {
+			slog.Error("Error recording installment", "series_id", installment.SeriesID, "installment", installment.Number, "link_id", linkID, "error", err)
+		}
+	}
+}
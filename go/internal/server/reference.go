@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// maxReferenceAttempts is how many references are generated before giving up when each is
+// found to be in use already, such as by a reference a merchant chose themselves
+const maxReferenceAttempts = 5
+
+// crockfordAlphabet is the base 32 alphabet ULIDs are written in
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID returns a ULID for the given time: 48 bits of milliseconds followed by 80
+// random bits, as 26 characters that sort in time order
+func newULID(now time.Time) string {
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[:8], uint64(now.UnixMilli())<<16)
+	rand.Read(id[6:])
+
+	// Read the 128 bits five at a time, starting with the 2 leading zero bits that pad them to 130
+	hi, lo := binary.BigEndian.Uint64(id[:8]), binary.BigEndian.Uint64(id[8:])
+	var text [26]byte
+	for i := 25; i >= 0; i-- {
+		text[i] = crockfordAlphabet[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(text[:])
+}
+
+// generateReference makes a reference from the configured format for a link requested
+// without one. Sequences are numbered per value of the format's other placeholders, so
+// with {date} they restart each day. A reference already used by a stored link is skipped.
+func (s *Server) generateReference(ctx context.Context) (string, *LinkRequestError) {
+	for attempt := 0; attempt < maxReferenceAttempts; attempt++ {
+		now := time.Now().UTC()
+		reference := strings.ReplaceAll(s.linkDefaults.ReferenceFormat, "{date}", now.Format("20060102"))
+		if strings.Contains(reference, "{seq}") {
+			seq, err := s.links.NextReferenceSequence(ctx, reference)
+			if err != nil {
+				logging.FromContext(ctx).Error("Error numbering generated reference", "error", err)
+				return "", &LinkRequestError{Status: http.StatusInternalServerError, Code: "STORE_ERROR", Details: "Error generating reference"}
+			}
+			reference = strings.ReplaceAll(reference, "{seq}", fmt.Sprintf("%06d", seq))
+		}
+		reference = strings.ReplaceAll(reference, "{ulid}", newULID(now))
+
+		existing, err := s.links.ListLinks(ctx, store.LinkFilter{Reference: reference, Limit: 1})
+		if err != nil {
+			logging.FromContext(ctx).Error("Error checking generated reference", "reference", reference, "error", err)
+			return "", &LinkRequestError{Status: http.StatusInternalServerError, Code: "STORE_ERROR", Details: "Error generating reference"}
+		}
+		if len(existing) == 0 {
+			return reference, nil
+		}
+	}
+	return "", &LinkRequestError{Status: http.StatusInternalServerError, Code: "REFERENCE_GENERATION_FAILED",
+		Details: fmt.Sprintf("No unused reference was found after %d attempts", maxReferenceAttempts)}
+}
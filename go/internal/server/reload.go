@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// Reloader re-reads the configuration and switches the GP API client passed to New over to
+// the new GP API settings, returning the configuration now in effect
+type Reloader func(ctx context.Context) (*config.Config, error)
+
+// reloadable holds the settings the server reads on each request that change when the
+// configuration is reloaded
+type reloadable struct {
+	environment string
+	// appKey verifies the signature of GP status notifications
+	appKey   string
+	settings []config.Setting
+	// ipAccess restricts the admin and public endpoints to client IP ranges
+	ipAccess config.IPAccess
+}
+
+// newReloadable returns the reloadable settings of cfg
+func newReloadable(cfg *config.Config) *reloadable {
+	return &reloadable{environment: cfg.GP.Environment, appKey: cfg.GP.AppKey, settings: cfg.Settings, ipAccess: cfg.IPAccess}
+}
+
+// SetReloader enables Reload, on SIGHUP and POST /admin/reload, using reload.
+// It must be called before the server starts.
+func (s *Server) SetReloader(reload Reloader) {
+	s.reloader = reload
+}
+
+// Reload re-reads the configuration with the reloader, so GP API credentials can be rotated
+// and client IP lists changed without downtime. Requests in flight finish with the settings they started with. When the
+// new configuration is invalid, the current one is kept and the problems are returned.
+func (s *Server) Reload(ctx context.Context) error {
+	if s.reloader == nil {
+		return errors.New("reloading the configuration is not enabled")
+	}
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	cfg, err := s.reloader(ctx)
+	if err != nil {
+		return err
+	}
+	s.reloadable.Store(newReloadable(cfg))
+	// The new credentials may belong to a different merchant account
+	s.capabilities.invalidate()
+	s.audit(ctx, store.AuditEntry{Action: auditConfigReload, Resource: "config"}, map[string]interface{}{
+		"environment": cfg.GP.Environment,
+	})
+	slog.Info("Configuration reloaded", "environment", cfg.GP.Environment)
+	return nil
+}
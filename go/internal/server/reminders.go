@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// RemindersRequest represents the expected payment link reminders request payload
+type RemindersRequest struct {
+	Enabled flexBool `json:"enabled" form:"enabled"`
+}
+
+// sendReminders re-sends active links to their customers by SMS when they are about to expire
+// unpaid. Links without a customer phone number or that opted out are skipped, and each link
+// gets at most the configured number of reminders, spaced by the configured repeat interval.
+func (s *Server) sendReminders(ctx context.Context) {
+	if s.sms == nil {
+		return
+	}
+	start := time.Now()
+	sent, failed := 0, 0
+
+	filter := store.LinkFilter{
+		Status:        store.LinkStatusActive,
+		ExpiresBefore: start.Add(s.reminders.Before),
+		Limit:         maxListLimit,
+	}
+	for {
+		links, err := s.links.ListLinks(ctx, filter)
+		if err != nil {
+			slog.Error("Error listing links to remind", "error", err)
+			return
+		}
+
+		for _, link := range links {
+			if ctx.Err() != nil {
+				return
+			}
+			if !s.reminderDue(link, start) {
+				continue
+			}
+			if err := s.sendReminder(ctx, link); err != nil {
+				slog.Warn("Error sending payment reminder", "link_id", link.ID, "error", err)
+				failed++
+				continue
+			}
+			sent++
+		}
+
+		if len(links) < filter.Limit {
+			break
+		}
+		last := links[len(links)-1]
+		filter.After = &store.LinkCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	level := slog.LevelDebug
+	if sent > 0 || failed > 0 {
+		level = slog.LevelInfo
+	}
+	slog.Log(ctx, level, "Payment reminders sent", "sent", sent, "failed", failed, "duration_ms", time.Since(start).Milliseconds())
+}
+
+// reminderDue reports whether an active link expiring within the reminder window should be reminded now
+func (s *Server) reminderDue(link *store.Link, now time.Time) bool {
+	switch {
+	case link.CustomerPhone == "", link.RemindersOptOut, !link.ExpiresAt.After(now):
+		return false
+	case link.RemindersSent >= s.reminders.MaxCount:
+		return false
+	case link.LastReminderAt != nil && now.Sub(*link.LastReminderAt) < s.reminders.Repeat:
+		return false
+	}
+	return true
+}
+
+// sendReminder sends one payment reminder for a link and counts it. A reminder whose SMS
+// could not be sent is recorded as a failed delivery and counted all the same, so a customer
+// whose number is rejected is not retried on every run.
+func (s *Server) sendReminder(ctx context.Context, link *store.Link) error {
+	_, sendErr := s.sendLinkSMS(ctx, link, link.CustomerPhone, reminderSMSBody(link))
+	if err := s.links.RecordReminder(ctx, link.ID); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// handleLinkReminders handles the /payment-link/{id}/reminders endpoint, which turns
+// payment reminders off or back on for a stored link
+func (s *Server) handleLinkReminders(w http.ResponseWriter, r *http.Request) {
+	linkID := r.PathValue("id")
+	if !linkIDPattern.MatchString(linkID) {
+		writeError(w, http.StatusBadRequest, "Reminder update failed", "INVALID_LINK_ID", "Invalid payment link ID")
+		return
+	}
+
+	var req RemindersRequest
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			if writeBodyTooLarge(w, "Reminder update failed", err) {
+				return
+			}
+			writeError(w, http.StatusBadRequest, "Reminder update failed", "INVALID_JSON", "Error parsing JSON request body")
+			return
+		}
+	} else {
+		req.Enabled = flexBool(r.FormValue("enabled"))
+	}
+
+	enabled, err := strconv.ParseBool(strings.TrimSpace(string(req.Enabled)))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Reminder update failed", "INVALID_REMINDERS", "enabled must be true or false")
+		return
+	}
+
+	link, err := s.links.SetRemindersOptOut(r.Context(), linkID, !enabled)
+	if errors.Is(err, store.ErrLinkNotFound) {
+		writeError(w, http.StatusNotFound, "Reminder update failed", "LINK_NOT_FOUND", "Payment link not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error updating payment link reminders", "link_id", linkID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Reminder update failed", "STORE_ERROR", "Error updating stored payment link")
+		return
+	}
+
+	s.audit(r.Context(), store.AuditEntry{Action: auditLinkReminders, Resource: linkID, LinkID: linkID}, map[string]interface{}{
+		"remindersEnabled": auditChange{To: enabled},
+	})
+	logging.FromContext(r.Context()).Info("Payment link reminders updated", "link_id", linkID, "enabled", enabled)
+	message := "Payment reminders turned off"
+	if enabled {
+		message = "Payment reminders turned on"
+	}
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: message,
+		Data:    link,
+	})
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+	"github.com/globalpayments/pay-by-link-go/internal/locale"
+)
+
+// Response represents a standardized API response
+type Response struct {
+	Success    bool        `json:"success"`
+	Message    string      `json:"message,omitempty"`
+	Data       interface{} `json:"data,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+	Error      *ErrorInfo  `json:"error,omitempty"`
+	// RequestID matches the X-Request-Id response header and the request_id in server logs
+	RequestID string `json:"requestId,omitempty"`
+	// catalogMessage, when set, is the catalog message writeJSON renders into Message in
+	// the language of the request
+	catalogMessage locale.Message
+}
+
+// Pagination represents cursor-based pagination metadata for list responses
+type Pagination struct {
+	Limit      int    `json:"limit"`
+	HasMore    bool   `json:"hasMore"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// ErrorInfo represents error details in the response
+type ErrorInfo struct {
+	Code         string `json:"code"`
+	Details      string `json:"details"`
+	ResponseCode int    `json:"responseCode,omitempty"`
+	// GPRequestID is GP's X-GP-Request-Id for the failed upstream call; quote it to Global Payments support
+	GPRequestID string `json:"gpRequestId,omitempty"`
+	// Fields lists each invalid request field when the request failed validation
+	Fields []FieldError `json:"fields,omitempty"`
+	// Allowed lists the values accepted in place of an unsupported one, such as the supported currencies
+	Allowed []string `json:"allowed,omitempty"`
+	// GPErrorCode and GPDetailedErrorCode are GP's own codes for a failed upstream call,
+	// such as INVALID_REQUEST_DATA and 40213
+	GPErrorCode         string `json:"gpErrorCode,omitempty"`
+	GPDetailedErrorCode string `json:"gpDetailedErrorCode,omitempty"`
+	// catalogDetails, when set, is the catalog message writeJSON renders into Details
+	catalogDetails locale.Message
+}
+
+// writeJSON writes response as JSON with the given HTTP status code.
+// The request ID set by withRequestID is copied into the response, and its messages are
+// translated into the language chosen by withLanguage.
+func writeJSON(w http.ResponseWriter, status int, response Response) {
+	if response.RequestID == "" {
+		response.RequestID = w.Header().Get(requestIDHeader)
+	}
+	response = localizeResponse(w.Header().Get(contentLanguageHeader), response)
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeError writes a failed response with the given error code and details
+func writeError(w http.ResponseWriter, status int, message, code, details string) {
+	writeJSON(w, status, Response{
+		Success: false,
+		Message: message,
+		Error: &ErrorInfo{
+			Code:    code,
+			Details: details,
+		},
+	})
+}
+
+// writeErrorf writes a failed response whose details are the catalog message with the
+// given key and values
+func writeErrorf(w http.ResponseWriter, status int, message, code, key string, args ...interface{}) {
+	writeJSON(w, status, Response{
+		Success: false,
+		Message: message,
+		Error: &ErrorInfo{
+			Code:           code,
+			catalogDetails: locale.Messagef(key, args...),
+		},
+	})
+}
+
+// writeGPError writes the failed response for an error returned by the GP API client
+func writeGPError(w http.ResponseWriter, message string, err error) {
+	status, info := gpErrorInfo(err)
+	writeJSON(w, status, Response{Success: false, Message: message, Error: info})
+}
+
+// gpErrorInfo classifies a failed GP API call about an existing link, returning the HTTP
+// status and error details to report. A resource GP could not find is the link itself.
+func gpErrorInfo(err error) (int, *ErrorInfo) {
+	status, info := classifyGPError(err)
+	var apiErr *gpapi.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		status, info.Code, info.Details = http.StatusNotFound, "LINK_NOT_FOUND", "Payment link not found"
+	}
+	return status, info
+}
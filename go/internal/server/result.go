@@ -0,0 +1,137 @@
+package server
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+)
+
+// Query parameters GP adds when it redirects the customer to the return URL
+const (
+	resultLinkIDParam        = "link_id"
+	resultTransactionIDParam = "transaction_id"
+)
+
+// Outcomes shown on the payment result page
+const (
+	resultSuccess = "success"
+	resultPending = "pending"
+	resultFailed  = "failed"
+)
+
+//go:embed templates/payment_result.html
+var templateFS embed.FS
+
+// paymentResultTemplate renders the page customers land on after paying
+var paymentResultTemplate = template.Must(template.ParseFS(templateFS, "templates/payment_result.html"))
+
+// PaymentResultPage is the data rendered on the payment result page
+type PaymentResultPage struct {
+	Outcome       string
+	Title         string
+	Message       string
+	Name          string
+	Reference     string
+	Amount        string
+	Currency      string
+	TransactionID string
+}
+
+// handlePaymentResult handles the /payment-result endpoint, used as the return URL.
+// The redirect parameters only identify the link and transaction; the outcome shown
+// is always read back from GP API, so a customer cannot fake a successful payment.
+func (s *Server) handlePaymentResult(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	linkID := query.Get(resultLinkIDParam)
+	if !linkIDPattern.MatchString(linkID) {
+		renderPaymentResult(w, http.StatusBadRequest, PaymentResultPage{
+			Outcome: resultFailed,
+			Title:   "Payment not found",
+			Message: "This page was opened without a valid payment link. Please use the link you were sent.",
+		})
+		return
+	}
+
+	detail, err := s.gp.GetLink(r.Context(), linkID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Error verifying payment result", "link_id", linkID, "error", err)
+
+		status := http.StatusBadGateway
+		var apiErr *gpapi.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			status = http.StatusNotFound
+		}
+		renderPaymentResult(w, status, PaymentResultPage{
+			Outcome: resultPending,
+			Title:   "We could not confirm your payment",
+			Message: "Your payment could not be verified right now. Please check your email for a receipt before trying again.",
+		})
+		return
+	}
+
+	page := paymentResultFor(detail, query.Get(resultTransactionIDParam))
+	logging.FromContext(r.Context()).Info("Payment result shown", "link_id", linkID, "transaction_id", page.TransactionID, "outcome", page.Outcome)
+	renderPaymentResult(w, http.StatusOK, page)
+}
+
+// paymentResultFor describes the transaction with the given ID on a link, or its latest
+// transaction when transactionID is empty
+func paymentResultFor(detail *gpapi.LinkDetail, transactionID string) PaymentResultPage {
+	var transaction *gpapi.Transaction
+	for i := range detail.Transactions.TransactionList {
+		candidate := &detail.Transactions.TransactionList[i]
+		if transactionID == "" || candidate.ID == transactionID {
+			transaction = candidate
+		}
+	}
+
+	if transaction == nil {
+		return PaymentResultPage{
+			Outcome:   resultPending,
+			Title:     "Payment not received yet",
+			Message:   "We have not received a payment for this link yet. If you have just paid, refresh this page in a moment.",
+			Name:      detail.Name,
+			Reference: detail.Reference,
+		}
+	}
+
+	page := PaymentResultPage{
+		Name:          detail.Name,
+		Reference:     detail.Reference,
+		Currency:      transaction.Currency,
+		TransactionID: transaction.ID,
+	}
+	if amount, err := transaction.Amount.Int64(); err == nil {
+		page.Amount = money.FormatMinorUnits(amount, transaction.Currency)
+	}
+
+	switch strings.ToUpper(transaction.Status) {
+	case "CAPTURED", "PREAUTHORIZED":
+		page.Outcome = resultSuccess
+		page.Title = "Payment successful"
+		page.Message = "Thank you. Your payment has been received."
+	case "PENDING", "INITIATED":
+		page.Outcome = resultPending
+		page.Title = "Payment processing"
+		page.Message = "Your payment is being processed. You will be notified once it completes."
+	default:
+		page.Outcome = resultFailed
+		page.Title = "Payment unsuccessful"
+		page.Message = "Your payment was not completed and you have not been charged. You can try again using the same link."
+	}
+	return page
+}
+
+// renderPaymentResult writes the payment result page with the given status code
+func renderPaymentResult(w http.ResponseWriter, status int, page PaymentResultPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	paymentResultTemplate.Execute(w, page)
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// retentionBatchSize is how many idle customers are looked up at a time for deletion
+const retentionBatchSize = 100
+
+// RetentionPolicyStatus reports a data retention policy and the rows it has purged since
+// the server started
+type RetentionPolicyStatus struct {
+	Table  string `json:"table"`
+	Action string `json:"action"`
+	MaxAge string `json:"maxAge"`
+	// LastRunAt is when the policy was last applied, and LastPurged how many rows it
+	// deleted or anonymized then
+	LastRunAt   *time.Time `json:"lastRunAt,omitempty"`
+	LastPurged  int64      `json:"lastPurged"`
+	TotalPurged int64      `json:"totalPurged"`
+	Failures    int64      `json:"failures"`
+	LastError   string     `json:"lastError,omitempty"`
+}
+
+// RetentionReport lists the data retention policies and what each has purged
+type RetentionReport struct {
+	// Enabled is false when there are no policies or the job's interval is 0
+	Enabled  bool                    `json:"enabled"`
+	Interval string                  `json:"interval"`
+	Policies []RetentionPolicyStatus `json:"policies"`
+}
+
+// retentionStats counts the rows purged by each retention policy, in configuration order
+type retentionStats struct {
+	mu       sync.Mutex
+	policies []RetentionPolicyStatus
+}
+
+// newRetentionStats returns empty counts for policies
+func newRetentionStats(policies []config.RetentionPolicy) *retentionStats {
+	stats := &retentionStats{policies: make([]RetentionPolicyStatus, len(policies))}
+	for i, policy := range policies {
+		stats.policies[i] = RetentionPolicyStatus{Table: policy.Table, Action: policy.Action, MaxAge: formatAge(policy.MaxAge)}
+	}
+	return stats
+}
+
+// record counts a run of the policy at index i that purged rows, failing with err if not nil
+func (r *retentionStats) record(i int, at time.Time, purged int64, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := &r.policies[i]
+	status.LastRunAt = &at
+	status.LastPurged = purged
+	status.TotalPurged += purged
+	status.LastError = ""
+	if err != nil {
+		status.Failures++
+		status.LastError = err.Error()
+	}
+}
+
+// snapshot returns a copy of the counts
+func (r *retentionStats) snapshot() []RetentionPolicyStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	policies := make([]RetentionPolicyStatus, len(r.policies))
+	copy(policies, r.policies)
+	return policies
+}
+
+// formatAge formats a retention age in days when it is a whole number of them
+func formatAge(age time.Duration) string {
+	if age%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", age/(24*time.Hour))
+	}
+	return formatWindow(age)
+}
+
+// applyRetention deletes or anonymizes the records each retention policy has aged out,
+// recording each purge in the audit log
+func (s *Server) applyRetention(ctx context.Context) {
+	now := time.Now()
+	for i, policy := range s.retention.Policies {
+		if ctx.Err() != nil {
+			return
+		}
+
+		before := now.Add(-policy.MaxAge)
+		purged, err := s.applyRetentionPolicy(ctx, policy, before)
+		s.retentionStats.record(i, now.UTC(), purged, err)
+		if err != nil {
+			slog.Error("Error applying retention policy", "table", policy.Table, "action", policy.Action, "error", err)
+		}
+		if purged > 0 {
+			slog.Info("Applied retention policy", "table", policy.Table, "action", policy.Action, "rows", purged, "max_age", formatAge(policy.MaxAge))
+			s.audit(ctx, store.AuditEntry{Action: auditRetentionPurge, Resource: policy.Table}, map[string]interface{}{
+				"action": policy.Action,
+				"rows":   purged,
+				"before": before.UTC(),
+			})
+		}
+	}
+}
+
+// applyRetentionPolicy applies one policy to the records older than before and returns how
+// many rows were deleted or anonymized, which may be some even when it fails
+func (s *Server) applyRetentionPolicy(ctx context.Context, policy config.RetentionPolicy, before time.Time) (int64, error) {
+	anonymize := policy.Action == config.RetentionAnonymize
+	switch policy.Table {
+	case config.RetentionLinks:
+		if anonymize {
+			return s.links.AnonymizeLinks(ctx, before)
+		}
+		return s.links.PruneLinks(ctx, before)
+	case config.RetentionWebhooks:
+		if anonymize {
+			return s.links.AnonymizeWebhookDeadLetters(ctx, before)
+		}
+		return s.links.PruneWebhookDeadLetters(ctx, before)
+	case config.RetentionCustomers:
+		if anonymize {
+			return s.links.AnonymizeCustomers(ctx, before)
+		}
+		return s.eraseIdleCustomers(ctx, before)
+	default:
+		return 0, fmt.Errorf("unknown retention table %q", policy.Table)
+	}
+}
+
+// eraseIdleCustomers erases every customer idle since before, as DELETE /customers/{id}/data
+// does, and returns how many were erased
+func (s *Server) eraseIdleCustomers(ctx context.Context, before time.Time) (int64, error) {
+	var erased int64
+	for {
+		ids, err := s.links.ListIdleCustomers(ctx, before, retentionBatchSize)
+		if err != nil {
+			return erased, err
+		}
+		for _, id := range ids {
+			_, err := s.links.EraseCustomer(ctx, id)
+			if errors.Is(err, store.ErrCustomerNotFound) {
+				// Erased by a request in the meantime
+				continue
+			}
+			if err != nil {
+				return erased, fmt.Errorf("failed to erase customer %s: %w", id, err)
+			}
+			erased++
+		}
+		if len(ids) < retentionBatchSize {
+			return erased, nil
+		}
+	}
+}
+
+// handleAdminRetention handles GET requests to /admin/retention, reporting the data
+// retention policies and the rows each has purged since the server started
+func (s *Server) handleAdminRetention(w http.ResponseWriter, r *http.Request) {
+	report := RetentionReport{
+		Enabled:  len(s.retention.Policies) > 0 && s.retention.Interval > 0,
+		Interval: formatWindow(s.retention.Interval),
+		Policies: s.retentionStats.snapshot(),
+	}
+	writeJSON(w, http.StatusOK, Response{Success: true, Data: report})
+}
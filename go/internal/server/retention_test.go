@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+func TestApplyRetention(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(config.APIKeys{Burst: 10, Keys: []config.APIKey{
+		{Name: "ops", Key: "ops-secret", RatePerMinute: 600, Permissions: config.AllPermissions},
+	}})
+	cfg.Retention = config.Retention{Interval: time.Hour, Policies: []config.RetentionPolicy{
+		{Table: config.RetentionLinks, Action: config.RetentionAnonymize, MaxAge: time.Millisecond},
+		{Table: config.RetentionCustomers, Action: config.RetentionDelete, MaxAge: time.Millisecond},
+		{Table: config.RetentionWebhooks, Action: config.RetentionDelete, MaxAge: 30 * 24 * time.Hour},
+	}}
+	links := newTestStore(t)
+	s := New(cfg, &fakeGP{}, links, nil, http.DefaultClient)
+
+	idle := &store.Customer{ID: newCustomerID(), Name: "Ada Lovelace", Email: "ada@example.com"}
+	busy := &store.Customer{ID: newCustomerID(), Name: "Grace Hopper", Email: "grace@example.com"}
+	for _, customer := range []*store.Customer{idle, busy} {
+		if err := links.CreateCustomer(ctx, customer); err != nil {
+			t.Fatalf("CreateCustomer: %v", err)
+		}
+	}
+	paid, err := s.CreateLink(ctx, PaymentLinkRequest{Amount: "10.00", Currency: "USD", Reference: "INV-PAID", Name: "Invoice",
+		Description: "Paid invoice", CustomerID: idle.ID})
+	if err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+	if err := links.UpdateStatus(ctx, paid.LinkID, store.LinkStatusPaid, nil); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+	open, err := s.CreateLink(ctx, PaymentLinkRequest{Amount: "20.00", Currency: "USD", Reference: "INV-OPEN", Name: "Invoice",
+		Description: "Open invoice", CustomerID: busy.ID})
+	if err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+
+	// Let everything age past the policies' millisecond
+	time.Sleep(5 * time.Millisecond)
+	s.applyRetention(ctx)
+
+	if link, err := links.GetLink(ctx, paid.LinkID); err != nil || link.Reference != "" || link.Amount != 1000 {
+		t.Errorf("paid link = %+v (%v), want it kept without its reference", link, err)
+	}
+	// Active links are never purged, and keep their customer with them
+	if link, err := links.GetLink(ctx, open.LinkID); err != nil || link.Reference != "INV-OPEN" || link.CustomerID != busy.ID {
+		t.Errorf("open link = %+v (%v), want it untouched", link, err)
+	}
+	if _, err := links.GetCustomer(ctx, idle.ID); !errors.Is(err, store.ErrCustomerNotFound) {
+		t.Errorf("idle customer: err = %v, want ErrCustomerNotFound", err)
+	}
+	if _, err := links.GetCustomer(ctx, busy.ID); err != nil {
+		t.Errorf("customer with an open link: err = %v, want it kept", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/retention", nil)
+	req.Header.Set("X-API-Key", "ops-secret")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	var report RetentionReport
+	data, _ := json.Marshal(decodeResponse(t, rec).Data)
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("decoding report: %v", err)
+	}
+	if rec.Code != http.StatusOK || !report.Enabled || report.Interval != "1h" || len(report.Policies) != 3 {
+		t.Fatalf("report = %d %s, want 200 with three enabled policies", rec.Code, data)
+	}
+	for i, want := range []struct {
+		table  string
+		maxAge string
+		purged int64
+	}{
+		{table: config.RetentionLinks, maxAge: "1ms", purged: 1},
+		{table: config.RetentionCustomers, maxAge: "1ms", purged: 1},
+		{table: config.RetentionWebhooks, maxAge: "30d", purged: 0},
+	} {
+		policy := report.Policies[i]
+		if policy.Table != want.table || policy.MaxAge != want.maxAge || policy.LastRunAt == nil ||
+			policy.LastPurged != want.purged || policy.TotalPurged != want.purged || policy.Failures != 0 {
+			t.Errorf("policy %d = %+v, want %s older than %s with %d purged", i, policy, want.table, want.maxAge, want.purged)
+		}
+	}
+}
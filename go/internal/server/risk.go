@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/mail"
+	"net/netip"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/country"
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+)
+
+// validatePayerHints checks the optional payer details of a link request, returning a
+// field error for each malformed one
+func validatePayerHints(req PaymentLinkRequest) []FieldError {
+	var fields []FieldError
+	if email := strings.TrimSpace(req.PayerEmail); email != "" {
+		if len(email) > maxEmailLength {
+			fields = append(fields, fieldError("payerEmail", FieldTooLong, "%s must be at most %d characters", "payerEmail", maxEmailLength))
+		} else if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+			fields = append(fields, FieldError{Field: "payerEmail", Code: FieldInvalidFormat, Message: "payerEmail must be a plain address such as name@example.com"})
+		}
+	}
+	if code := strings.TrimSpace(req.BillingCountry); code != "" {
+		if _, err := country.Parse(code); err != nil {
+			fields = append(fields, FieldError{Field: "billingCountry", Code: FieldInvalidFormat, Message: "billingCountry must be an ISO 3166-1 alpha-2 country code, such as IE"})
+		}
+	}
+	if ip := strings.TrimSpace(req.PayerIP); ip != "" {
+		if _, err := netip.ParseAddr(ip); err != nil {
+			fields = append(fields, FieldError{Field: "payerIp", Code: FieldInvalidFormat, Message: "payerIp must be an IPv4 or IPv6 address"})
+		}
+	}
+	return fields
+}
+
+// payerHints returns the payer details of a validated request to send to GP, or nil when
+// it has none
+func payerHints(req PaymentLinkRequest) *gpapi.LinkPayer {
+	payer := &gpapi.LinkPayer{
+		Email:     strings.TrimSpace(req.PayerEmail),
+		IPAddress: strings.TrimSpace(req.PayerIP),
+	}
+	if code, err := country.Parse(req.BillingCountry); err == nil {
+		payer.BillingAddress = &gpapi.LinkAddress{Country: code}
+	}
+	if *payer == (gpapi.LinkPayer{}) {
+		return nil
+	}
+	return payer
+}
+
+// screenPayer refuses a link whose payer email, or billing country, is on a configured
+// blocklist. email may be the payer hint or the email of the link's customer. Only the
+// rule is logged, as the address is personal data.
+func (s *Server) screenPayer(ctx context.Context, email, billingCountry string) *LinkRequestError {
+	declined := func(rule, key string, args ...interface{}) *LinkRequestError {
+		logging.FromContext(ctx).Warn("Link request declined by risk pre-screen", "rule", rule)
+		return newLinkRequestError(http.StatusUnprocessableEntity, "RISK_DECLINED", key, args...)
+	}
+
+	if code, err := country.Parse(billingCountry); err == nil && s.risk.BlockedCountries[code] {
+		return declined("blocked_country", "Payments from billing country %s are not accepted", code)
+	}
+	if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+		_, domain, _ := strings.Cut(email, "@")
+		if s.risk.BlockedEmails[email] || s.risk.BlockedEmails["@"+domain] {
+			return declined("blocked_email", "Payments from this email address are not accepted")
+		}
+	}
+	return nil
+}
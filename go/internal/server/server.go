@@ -0,0 +1,528 @@
+// Package server implements the Pay by Link HTTP API on top of a GP API client and a link store.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/globalpayments/pay-by-link-go/internal/captcha"
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/eventbus"
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+	"github.com/globalpayments/pay-by-link-go/internal/notify"
+	"github.com/globalpayments/pay-by-link-go/internal/slack"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/webhooks"
+	"github.com/globalpayments/pay-by-link-go/static"
+)
+
+// HTTP server timeouts. The write timeout leaves room for large batch requests.
+const (
+	serverReadHeaderTimeout = 10 * time.Second
+	serverReadTimeout       = 30 * time.Second
+	serverWriteTimeout      = 120 * time.Second
+	serverIdleTimeout       = 120 * time.Second
+)
+
+// Server serves the Pay by Link API
+type Server struct {
+	gp            gpapi.LinksClient
+	links         store.LinkStore
+	sms           notify.Notifier
+	notifications config.NotificationURLs
+	auth          *APIKeyAuth
+	ipLimiter     *IPRateLimiter
+	cors          config.CORS
+	linkDefaults  config.LinkDefaults
+	capabilities  *capabilitiesCache
+	limits        config.Limits
+	events        *webhooks.Dispatcher
+	slack         *slack.Notifier
+	bus           *eventbus.Bus
+	stream        *eventStream
+	outbox        *outboxRelay
+	graphql       graphql.Schema
+	reconcile     config.Reconcile
+	expiry        config.Expiry
+	recurring     config.Recurring
+	retention     config.Retention
+	reminders     config.Reminders
+	promoCodes    map[string]config.PromoCode
+	tax           config.Tax
+	amountLimits  map[string]config.AmountLimit
+	// velocityLimits cap the links created for one reference, customer, or API key
+	velocityLimits []config.VelocityLimit
+	// staticDir serves the browser client from disk when set, rather than from the binary
+	staticDir string
+	// reloadable holds the settings that change when the configuration is reloaded
+	reloadable atomic.Pointer[reloadable]
+	// reloader re-reads the configuration for Reload; reloadMu runs one reload at a time
+	reloader Reloader
+	reloadMu sync.Mutex
+	// retentionStats counts the rows each retention policy has purged
+	retentionStats *retentionStats
+	// oidc signs people in to the dashboard through an OpenID Connect provider; nil when
+	// single sign-on is not configured
+	oidc *oidcLogin
+	// notificationMaxSkew bounds the age of the GP status notifications accepted
+	notificationMaxSkew time.Duration
+	// merchantAccounts are the merchants link requests may select, in the order they are matched
+	merchantAccounts []config.MerchantAccount
+	// risk holds the payer blocklists link requests are screened against
+	risk config.Risk
+	// captcha verifies link requests made without an API key, or is nil when not configured
+	captcha *captcha.Verifier
+	// csrfProtection requires requests without an API key that another site could send to
+	// carry the CSRF token issued by /config
+	csrfProtection bool
+	// securityHeaders are sent with every response
+	securityHeaders config.SecurityHeaders
+	// tls serves HTTPS directly when enabled
+	tls config.TLS
+	// trustedProxies is the number of reverse proxies that append to X-Forwarded-For
+	trustedProxies int
+	// allowUnauthenticated serves every endpoint when no API keys are configured, for local
+	// development
+	allowUnauthenticated bool
+	// wallet signs the Apple Wallet and Google Wallet passes of links
+	wallet walletSigners
+	// idempotency records the responses to link requests made with an Idempotency-Key,
+	// for idempotencyTTL
+	idempotency    IdempotencyStore
+	idempotencyTTL time.Duration
+}
+
+// New creates a Server that creates links through gp and records them in links.
+// sms may be nil when SMS delivery is not configured. client sends link events to
+// merchant webhook endpoints and Slack.
+func New(cfg *config.Config, gp gpapi.LinksClient, links store.LinkStore, sms notify.Notifier, client *http.Client) *Server {
+	// Link events are only published when merchant webhook endpoints are configured
+	var events *webhooks.Dispatcher
+	if len(cfg.Webhooks.URLs) > 0 {
+		events = webhooks.NewDispatcher(cfg.Webhooks, links, client)
+	}
+	var slackNotifier *slack.Notifier
+	if cfg.Slack.WebhookURL != "" {
+		slackNotifier = slack.NewNotifier(cfg.Slack, cfg.Links.MerchantName, client)
+	}
+	var captchaVerifier *captcha.Verifier
+	if cfg.Captcha.Provider != "" {
+		captchaVerifier = captcha.NewVerifier(cfg.Captcha, client)
+	}
+
+	s := &Server{
+		gp:            gp,
+		links:         links,
+		sms:           sms,
+		notifications: cfg.Notifications,
+		auth:          NewAPIKeyAuth(cfg.APIKeys),
+		ipLimiter:     NewIPRateLimiter(cfg.RateLimit),
+		cors:          cfg.CORS,
+		linkDefaults:  cfg.Links,
+		capabilities:  newCapabilitiesCache(gp, cfg.CapabilitiesTTL, cfg.Links),
+		limits:        cfg.Limits,
+		events:        events,
+		slack:         slackNotifier,
+		stream:        newEventStream(),
+		outbox:        newOutboxRelay(),
+		reconcile:     cfg.Reconcile,
+		expiry:        cfg.Expiry,
+		recurring:     cfg.Recurring,
+		retention:     cfg.Retention,
+		reminders:     cfg.Reminders,
+		promoCodes:    cfg.PromoCodes,
+		tax:           cfg.Tax,
+		amountLimits:  cfg.AmountLimits,
+
+		velocityLimits: cfg.VelocityLimits,
+		staticDir:      cfg.StaticDir,
+		retentionStats: newRetentionStats(cfg.Retention.Policies),
+		oidc:           newOIDCLogin(cfg.OIDC, client),
+
+		notificationMaxSkew: cfg.NotificationMaxSkew,
+		idempotency:         newMemoryIdempotency(),
+		idempotencyTTL:      cfg.IdempotencyTTL,
+		merchantAccounts:    cfg.MerchantAccounts,
+		risk:                cfg.Risk,
+		captcha:             captchaVerifier,
+		csrfProtection:      cfg.CSRFProtection,
+		securityHeaders:     cfg.SecurityHeaders,
+		tls:                 cfg.TLS,
+		trustedProxies:      cfg.RateLimit.TrustedProxies,
+		wallet:              newWalletSigners(cfg.Wallet),
+
+		allowUnauthenticated: cfg.APIKeys.AllowUnauthenticated,
+	}
+	s.reloadable.Store(newReloadable(cfg))
+	s.graphql = s.newGraphQLSchema()
+	return s
+}
+
+// LoadCapabilities looks up the merchant account's capabilities so the first /config
+// request does not wait on GP API. Failures are logged and retried on a later request.
+func (s *Server) LoadCapabilities(ctx context.Context) {
+	caps := s.capabilities.Get(ctx)
+	slog.Info("Serving account capabilities", "currencies", caps.Currencies, "payment_methods", caps.PaymentMethods,
+		"digital_wallets", caps.DigitalWallets, "country", caps.Country)
+}
+
+// staticHandler serves the browser client embedded in the binary, with fingerprinted
+// asset names and ETags, or the files in STATIC_DIR when it is set. Files on disk are
+// marked no-cache so edits show on reload.
+func (s *Server) staticHandler() http.Handler {
+	if s.staticDir == "" {
+		assets, err := newStaticAssets(static.Files)
+		if err != nil {
+			slog.Error("Error reading the embedded browser client, serving it without fingerprints", "error", err)
+			return http.FileServer(http.FS(static.Files))
+		}
+		return assets
+	}
+	files := http.FileServer(http.Dir(s.staticDir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		files.ServeHTTP(w, r)
+	})
+}
+
+// Handler returns the HTTP handler serving all routes. Every request gets an ID, a trace
+// span, a message language, a log line, panic recovery, and a client IP check; each route
+// then adds its own
+// middleware, such as authentication, rate limiting, and CORS. Routes are registered by
+// method, so the mux answers other methods with 405 and an Allow header.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	// Middleware routes pick from, in the order they should run
+	cors := func(next http.Handler) http.Handler { return withCORS(s.cors, next) }
+	limit := s.ipLimiter.Limit
+	auth := s.auth.Require
+	configAuth := s.auth.RequireConfig
+	// Permissions each route requires of the API key, run after auth
+	create := requirePermission(config.PermissionCreate)
+	read := requirePermission(config.PermissionRead)
+	cancel := requirePermission(config.PermissionCancel)
+	capture := requirePermission(config.PermissionCapture)
+	refund := requirePermission(config.PermissionRefund)
+	admin := requirePermission(config.PermissionAdmin)
+	// CSRF checks of requests another site could send without an API key, run after auth
+	csrf := s.requireCSRFToken
+	// CAPTCHA verification of link creation without an API key, run after auth
+	humans := s.requireCaptcha
+	// Replays of link creation and refunds retried with the same Idempotency-Key, run after auth
+	idempotent := s.withIdempotency("Payment link creation failed")
+	idempotentRefund := s.withIdempotency("Refund failed")
+	// Every route has a bounded body size and run time, and compressed responses
+	bounded := func(timeout time.Duration, maxBytes int64) []middleware {
+		return []middleware{
+			withGzip,
+			func(next http.Handler) http.Handler { return withTimeout(timeout, next) },
+			recoverPanics,
+			func(next http.Handler) http.Handler { return limitBody(maxBytes, next) },
+		}
+	}
+	defaults := bounded(s.limits.RequestTimeout, s.limits.MaxBodyBytes)
+
+	// handle registers a route with the default limits followed by the route's own middleware
+	handle := func(pattern string, h http.HandlerFunc, routeMiddleware ...middleware) {
+		mux.Handle(pattern, chain(h, slices.Concat(defaults, routeMiddleware)...))
+	}
+	// preflight answers CORS preflight requests for a route registered with cors
+	preflight := func(path string) {
+		if len(s.cors.AllowedOrigins) > 0 {
+			mux.Handle("OPTIONS "+path, corsPreflight(s.cors))
+		}
+	}
+
+	// Without API keys, only the public endpoints and link creation, which the demo page
+	// uses, are served. Reading, changing, and refunding links and customer data is left
+	// unrouted unless ALLOW_UNAUTHENTICATED opens it for local development.
+	servePrivate := s.auth != nil || s.allowUnauthenticated
+	private := func(pattern string, h http.HandlerFunc, routeMiddleware ...middleware) {
+		if servePrivate {
+			handle(pattern, h, routeMiddleware...)
+		}
+	}
+
+	mux.Handle("GET /", chain(s.staticHandler(), defaults...))
+	handle("GET /config", s.handleConfig, cors, configAuth)
+	preflight("/config")
+	handle("GET /healthz", handleHealthz)
+	handle("GET /openapi.json", handleOpenAPI)
+	handle("GET /docs", handleDocs)
+	handle("GET /readyz", s.handleReadyz)
+	handle("POST /create-payment-link", s.handleCreatePaymentLink, cors, limit, auth, csrf, create, idempotent, humans)
+	preflight("/create-payment-link")
+	private("GET /payment-links", s.handleListPaymentLinks, auth, read)
+	private("POST /graphql", s.handleGraphQL, cors, auth, csrf, read)
+	if servePrivate {
+		preflight("/graphql")
+	}
+	private("GET /payment-link/{id}", s.handleGetPaymentLink, auth, read)
+	private("PATCH /payment-link/{id}", s.handleUpdatePaymentLink, auth, csrf, create)
+	private("POST /payment-link/{id}/cancel", s.handleCancelPaymentLink, auth, csrf, cancel)
+	private("POST /payment-link/{id}/send-sms", s.handleSendSMS, auth, csrf, create)
+	private("POST /payment-link/{id}/reminders", s.handleLinkReminders, auth, csrf, create)
+	private("GET /payment-link/{id}/deliveries", s.handleListDeliveries, auth, read)
+	private("GET /payment-link/{id}/views", s.handleListLinkViews, auth, read)
+	private("GET /payment-link/{id}/receipt", s.handleGetReceipt, auth, read)
+	private("GET /payment-link/{id}/wallet-pass", s.handleGetWalletPass, auth, read)
+	private("GET /payment-link/{id}/transactions", s.handleListLinkTransactions, auth, read)
+	private("POST /customers", s.handleCreateCustomer, auth, csrf, create)
+	private("GET /customers/{id}", s.handleGetCustomer, auth, read)
+	private("GET /customers/{id}/payment-links", s.handleListCustomerLinks, auth, read)
+	private("GET /customers/{id}/export", s.handleExportCustomer, auth, read)
+	private("DELETE /customers/{id}/data", s.handleEraseCustomer, auth, csrf, admin)
+	private("GET /link-templates", s.handleListLinkTemplates, auth, read)
+	private("POST /link-templates", s.handleCreateLinkTemplate, auth, csrf, admin)
+	private("GET /link-templates/{id}", s.handleGetLinkTemplate, auth, read)
+	private("PUT /link-templates/{id}", s.handleUpdateLinkTemplate, auth, csrf, admin)
+	private("DELETE /link-templates/{id}", s.handleDeleteLinkTemplate, auth, csrf, admin)
+	private("GET /products", s.handleProducts, auth, read)
+	private("GET /products/{sku}", s.handleGetProduct, auth, read)
+	private("PUT /products/{sku}", s.handleSaveProduct, auth, csrf, admin)
+	private("DELETE /products/{sku}", s.handleDeleteProduct, auth, csrf, admin)
+	handle("POST /recurring-links", s.handleCreateRecurringLinks, cors, limit, auth, csrf, create, humans)
+	preflight("/recurring-links")
+	private("GET /recurring-links/{id}", s.handleGetRecurringLinks, auth, read)
+	private("GET /transactions", s.handleListTransactions, auth, read)
+	private("POST /transactions/{id}/capture", s.handleCaptureTransaction, auth, csrf, capture)
+	private("POST /transactions/{id}/refund", s.handleRefundTransaction, auth, csrf, refund, idempotentRefund)
+	handle("GET /payment-result", s.handlePaymentResult)
+	handle("GET /l/{code}", s.handleShortLink, limit)
+	handle("POST /webhooks/status", s.handleStatusWebhook)
+	handle("POST /webhooks/sms/status", s.handleSMSStatusWebhook)
+	// The dashboard signs browsers in with an API key or through an OpenID Connect
+	// provider, held in a cookie. Actions check the user's permissions themselves.
+	// Without either, it is only served when ALLOW_UNAUTHENTICATED is set.
+	if servePrivate || s.oidc != nil {
+		session := s.requireAdminSession
+		handle("GET /admin", s.handleAdminLinks, session)
+		handle("GET /admin/links/{id}", s.handleAdminLink, session)
+		handle("POST /admin/links/{id}/cancel", s.handleAdminCancelLink, session)
+		handle("POST /admin/links/{id}/resend", s.handleAdminResendLink, session)
+		handle("GET /admin/login", s.handleAdminLoginPage)
+		handle("POST /admin/login", s.handleAdminLogin, limit)
+		handle("POST /admin/logout", s.handleAdminLogout)
+	}
+	if s.oidc != nil {
+		handle("GET /admin/oidc/login", s.handleOIDCLogin, limit)
+		handle("GET /admin/oidc/callback", s.handleOIDCCallback, limit)
+	}
+	// The admin endpoints are only served when API keys protect them
+	if s.auth != nil {
+		handle("GET /admin/config", s.handleAdminConfig, auth, admin)
+		handle("GET /admin/audit", s.handleAdminAudit, auth, admin)
+		handle("GET /admin/audit/verify", s.handleAdminAuditVerify, auth, admin)
+		handle("GET /admin/retention", s.handleAdminRetention, auth, admin)
+		handle("GET /admin/deadletters", s.handleAdminDeadLetters, auth, admin)
+		handle("POST /admin/deadletters/{id}/retry", s.handleAdminRetryDeadLetter, auth, admin)
+		if s.reloader != nil {
+			handle("POST /admin/reload", s.handleAdminReload, auth, admin)
+		}
+	}
+
+	// Batch creation has larger limits
+	batch := bounded(s.limits.BatchRequestTimeout, s.limits.MaxBatchBodyBytes)
+	mux.Handle("POST /create-payment-links", chain(http.HandlerFunc(s.handleCreatePaymentLinks), slices.Concat(batch, []middleware{limit, auth, csrf, create, humans})...))
+	// Event streams and WebSockets stay open indefinitely and must not be buffered, so they
+	// skip the default limits and compression
+	if servePrivate {
+		mux.Handle("GET /events", chain(http.HandlerFunc(s.handleEvents), cors, auth, read))
+		preflight("/events")
+		mux.Handle("GET /ws", chain(http.HandlerFunc(s.handleWebSocket), auth, read))
+		// Exports are written as they are read, so they skip the timeout, which buffers the
+		// whole response, and set their own write deadlines instead
+		mux.Handle("GET /payment-links/export", chain(http.HandlerFunc(s.handleExportPaymentLinks), withGzip, recoverPanics, auth, read))
+	}
+
+	// route names the path pattern a request matches, without the method it is registered for
+	route := func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		if _, path, ok := strings.Cut(pattern, " "); ok {
+			return path
+		}
+		return pattern
+	}
+	tracing := func(next http.Handler) http.Handler { return withTracing(route, next) }
+	security := func(next http.Handler) http.Handler { return withSecurityHeaders(s.securityHeaders, next) }
+	return chain(mux, security, tracing, withRequestID, withLanguage, requestLogger, recoverPanics, s.withIPAccess)
+}
+
+// Close waits up to ctx's deadline for link events still being delivered to merchant
+// webhooks, Slack, and the event publisher
+func (s *Server) Close(ctx context.Context) error {
+	return errors.Join(s.events.Close(ctx), s.slack.Close(ctx), s.bus.Close(ctx))
+}
+
+// runEvery calls run every interval until ctx is cancelled
+func runEvery(ctx context.Context, interval time.Duration, run func(context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run(ctx)
+		}
+	}
+}
+
+// newHTTPServer creates an http.Server with timeouts suitable for production use
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: serverReadHeaderTimeout,
+		ReadTimeout:       serverReadTimeout,
+		WriteTimeout:      serverWriteTimeout,
+		IdleTimeout:       serverIdleTimeout,
+	}
+}
+
+// ListenAndServe serves on addr until SIGINT or SIGTERM is received, then stops
+// accepting connections and waits up to shutdownTimeout for in-flight requests to finish.
+// SIGHUP reloads the configuration when a reloader is set, and the TLS certificate files.
+// With TLS enabled, addr serves HTTPS, and TLS_HTTP_PORT, when set, redirects to it.
+func (s *Server) ListenAndServe(addr string, shutdownTimeout time.Duration) error {
+	server := newHTTPServer(addr, s.Handler())
+	server.RegisterOnShutdown(s.stream.close)
+	servers := []*http.Server{server}
+	var https *httpsServing
+	if s.tls.Enabled() {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return fmt.Errorf("invalid address %q: %w", addr, err)
+		}
+		if https, err = newHTTPSServing(s.tls, port); err != nil {
+			return err
+		}
+		server.TLSConfig = https.config
+		if s.tls.HTTPPort != "" {
+			servers = append(servers, newHTTPServer(net.JoinHostPort(host, s.tls.HTTPPort), https.httpHandler))
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Run the background jobs until shutdown begins
+	retentionInterval := s.retention.Interval
+	if len(s.retention.Policies) == 0 {
+		retentionInterval = 0
+	}
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	var jobs sync.WaitGroup
+	for _, job := range []struct {
+		interval time.Duration
+		run      func(context.Context)
+	}{
+		{s.reconcile.Interval, s.reconcileLinks},
+		{s.expiry.Interval, s.expireLinks},
+		{s.recurring.Interval, s.createDueInstallments},
+		{s.reminders.Interval, s.sendReminders},
+		{retentionInterval, s.applyRetention},
+	} {
+		if job.interval <= 0 {
+			continue
+		}
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			runEvery(jobsCtx, job.interval, job.run)
+		}()
+	}
+
+	// Deliver the link events written to the outbox
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		s.runOutboxRelay(jobsCtx)
+	}()
+
+	// Reload the configuration and certificate files on SIGHUP
+	if s.reloader != nil || (https != nil && https.certificates != nil) {
+		hangups := make(chan os.Signal, 1)
+		signal.Notify(hangups, syscall.SIGHUP)
+		defer signal.Stop(hangups)
+		go func() {
+			for {
+				select {
+				case <-jobsCtx.Done():
+					return
+				case <-hangups:
+					if s.reloader != nil {
+						if err := s.Reload(jobsCtx); err != nil {
+							slog.Error("Error reloading configuration, keeping the current one", "error", err)
+						}
+					}
+					if https != nil && https.certificates != nil {
+						if err := https.certificates.load(); err != nil {
+							slog.Error("Error reloading the TLS certificate, keeping the current one", "error", err)
+						} else {
+							slog.Info("Reloaded the TLS certificate")
+						}
+					}
+				}
+			}
+		}()
+	}
+
+	serveErr := make(chan error, len(servers))
+	go func() {
+		if server.TLSConfig != nil {
+			serveErr <- server.ListenAndServeTLS("", "")
+			return
+		}
+		serveErr <- server.ListenAndServe()
+	}()
+	for _, redirect := range servers[1:] {
+		go func() {
+			serveErr <- redirect.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+
+	slog.Info("Shutting down, draining in-flight requests", "timeout", shutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("graceful shutdown failed: %w", err)
+		}
+	}
+	stopJobs()
+	jobs.Wait()
+	if err := s.Close(shutdownCtx); err != nil {
+		slog.Warn("Webhook deliveries did not finish before shutdown", "error", err)
+	}
+	for range servers {
+		if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	}
+	return nil
+}
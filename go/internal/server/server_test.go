@@ -0,0 +1,51 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// newTestStore returns an empty SQLite link store in a temporary directory, closed when
+// the test ends
+func newTestStore(t *testing.T) *store.SQLiteLinkStore {
+	t.Helper()
+	links, err := store.NewSQLiteLinkStore(filepath.Join(t.TempDir(), "links.db"), nil)
+	if err != nil {
+		t.Fatalf("NewSQLiteLinkStore: %v", err)
+	}
+	t.Cleanup(func() { links.Close() })
+	return links
+}
+
+// decodeResponse reads the Response a handler wrote to rec
+func decodeResponse(t *testing.T, rec *httptest.ResponseRecorder) Response {
+	t.Helper()
+	var response Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decoding response %q: %v", rec.Body.String(), err)
+	}
+	return response
+}
+
+// errorCode returns the error code of a response, or "" for a successful one
+func errorCode(response Response) string {
+	if response.Error == nil {
+		return ""
+	}
+	return response.Error.Code
+}
+
+// newTestConfig returns the smallest configuration New accepts, with the given API keys
+func newTestConfig(keys config.APIKeys) *config.Config {
+	return &config.Config{
+		APIKeys: keys,
+		Limits: config.Limits{MaxBodyBytes: 1 << 20, MaxBatchBodyBytes: 1 << 20,
+			RequestTimeout: 10 * time.Second, BatchRequestTimeout: 10 * time.Second},
+	}
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/locale"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/webhooks"
+)
+
+// shortCodeLength is how many Crockford base 32 characters a shortlink code has, giving
+// 60 random bits
+const shortCodeLength = 12
+
+// maxViewHeaderLength is the most characters of a visitor's user agent or referrer recorded
+// with a view
+const maxViewHeaderLength = 512
+
+// shortCodePattern matches a shortlink code once upper-cased
+var shortCodePattern = regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{12}$`)
+
+// newShortCode generates a random shortlink code
+func newShortCode() string {
+	b := make([]byte, shortCodeLength)
+	rand.Read(b)
+	for i := range b {
+		b[i] = crockfordAlphabet[b[i]&31]
+	}
+	return string(b)
+}
+
+// shortLinkURL returns the shortlink with the given code, or "" when SHORTLINK_BASE_URL is
+// not set or the link has no code
+func (s *Server) shortLinkURL(code string) string {
+	if s.linkDefaults.ShortLinkBaseURL == "" || code == "" {
+		return ""
+	}
+	return s.linkDefaults.ShortLinkBaseURL + "/l/" + code
+}
+
+// viewHeader cleans a request header recorded with a view, which the visitor controls
+func viewHeader(value string) string {
+	return truncateText(strings.ToValidUTF8(strings.TrimSpace(value), ""), maxViewHeaderLength)
+}
+
+// handleShortLink handles GET requests to /l/{code}, recording a view of the link before
+// redirecting the visitor to its payment page. Expired and paid links are redirected too,
+// so the payment page can tell the customer.
+func (s *Server) handleShortLink(w http.ResponseWriter, r *http.Request) {
+	notFound := PaymentResultPage{
+		Outcome: resultFailed,
+		Title:   "Payment link not found",
+		Message: "This payment link does not exist. Please check the link you were sent.",
+	}
+	code := strings.ToUpper(r.PathValue("code"))
+	if !shortCodePattern.MatchString(code) {
+		renderPaymentResult(w, http.StatusNotFound, notFound)
+		return
+	}
+
+	link, err := s.links.GetLinkByShortCode(r.Context(), code)
+	if errors.Is(err, store.ErrLinkNotFound) {
+		renderPaymentResult(w, http.StatusNotFound, notFound)
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error reading shortlink", "short_code", code, "error", err)
+		renderPaymentResult(w, http.StatusInternalServerError, PaymentResultPage{
+			Outcome: resultPending,
+			Title:   "We could not open this payment link",
+			Message: "The payment page is unavailable right now. Please try the link again in a few minutes.",
+		})
+		return
+	}
+
+	// A view that cannot be recorded must not stop the customer paying
+	view := &store.LinkView{LinkID: link.ID, UserAgent: viewHeader(r.UserAgent()), Referrer: viewHeader(r.Referer())}
+	if err := s.links.RecordLinkView(r.Context(), view); err != nil {
+		logging.FromContext(r.Context()).Error("Error recording link view", "link_id", link.ID, "error", err)
+	}
+	s.bus.Publish(webhooks.NewEvent(webhooks.EventLinkViewed, link), nil)
+	logging.FromContext(r.Context()).Info("Shortlink opened", "link_id", link.ID, "status", link.Status)
+
+	// Every visit should reach the server to be counted, rather than a cached redirect
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, link.URL, http.StatusFound)
+}
+
+// handleListLinkViews handles the /payment-link/{id}/views endpoint, listing the visits to
+// a link's shortlink newest first
+func (s *Server) handleListLinkViews(w http.ResponseWriter, r *http.Request) {
+	linkID := r.PathValue("id")
+	if !linkIDPattern.MatchString(linkID) {
+		writeError(w, http.StatusBadRequest, "Link view lookup failed", "INVALID_LINK_ID", "Invalid payment link ID")
+		return
+	}
+	limit := defaultListLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxListLimit {
+			writeErrorf(w, http.StatusBadRequest, "Link view lookup failed", "INVALID_LIMIT",
+				"limit must be between 1 and %d", maxListLimit)
+			return
+		}
+		limit = parsed
+	}
+
+	views, err := s.links.ListLinkViews(r.Context(), linkID, limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing link views", "link_id", linkID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Link view lookup failed", "STORE_ERROR", "Error reading stored link views")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success:        true,
+		catalogMessage: locale.Messagef("Found %d views", len(views)),
+		Data:           views,
+	})
+}
@@ -0,0 +1,234 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/notify"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// e164Pattern matches phone numbers in E.164 format, e.g. +447700900123
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// SendSMSRequest represents the expected send-sms request payload
+type SendSMSRequest struct {
+	CustomerPhone string `json:"customerPhone" form:"customerPhone"`
+}
+
+// validatePhone normalises a phone number and checks it is in E.164 format
+func validatePhone(phone string) (string, error) {
+	phone = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+	if !e164Pattern.MatchString(phone) {
+		return "", fmt.Errorf("customerPhone must be in E.164 format, e.g. +447700900123")
+	}
+	return phone, nil
+}
+
+// linkAmountText describes what a link asks the customer to pay, such as "12.00 EUR", or the
+// bounds of an open-amount link, such as "5.00-100.00 EUR"
+func linkAmountText(link *store.Link) string {
+	format := func(amount int64) string { return money.FormatMinorUnits(amount, link.Currency) }
+	switch {
+	case link.Amount > 0:
+		return fmt.Sprintf("%s %s", format(link.Amount), link.Currency)
+	case link.MinAmount > 0 && link.MaxAmount > 0:
+		return fmt.Sprintf("%s-%s %s", format(link.MinAmount), format(link.MaxAmount), link.Currency)
+	case link.MinAmount > 0:
+		return fmt.Sprintf("at least %s %s", format(link.MinAmount), link.Currency)
+	case link.MaxAmount > 0:
+		return fmt.Sprintf("up to %s %s", format(link.MaxAmount), link.Currency)
+	default:
+		return "an amount of your choice in " + link.Currency
+	}
+}
+
+// linkSMSBody builds the text message sent to a customer for a link
+func linkSMSBody(link *store.Link) string {
+	return fmt.Sprintf("Payment request for %s (ref %s): %s", linkAmountText(link), link.Reference, link.URL)
+}
+
+// reminderSMSBody builds the text message reminding a customer that a link is due to expire unpaid
+func reminderSMSBody(link *store.Link) string {
+	return fmt.Sprintf("Payment due: %s (ref %s) must be paid by %s: %s",
+		linkAmountText(link), link.Reference, link.ExpiresAt.UTC().Format("2 Jan 2006 15:04 MST"), link.URL)
+}
+
+// sendLinkSMS sends body about a link to phone through the SMS notifier and records the delivery
+// attempt. A failed send is still recorded, and returned alongside the error.
+func (s *Server) sendLinkSMS(ctx context.Context, link *store.Link, phone, body string) (*store.Delivery, error) {
+	delivery := &store.Delivery{
+		LinkID:    link.ID,
+		Channel:   s.sms.Channel(),
+		Recipient: phone,
+	}
+
+	providerID, status, sendErr := s.sms.Send(ctx, phone, body)
+	delivery.ProviderID = providerID
+	delivery.Status = status
+	if delivery.Status == "" {
+		delivery.Status = notify.DeliveryStatusQueued
+	}
+	if sendErr != nil {
+		delivery.Status = notify.DeliveryStatusFailed
+		delivery.Error = sendErr.Error()
+	}
+
+	if err := s.links.CreateDelivery(ctx, delivery); err != nil {
+		logging.FromContext(ctx).Error("Error recording delivery", "link_id", link.ID, "error", err)
+	}
+
+	// The recipient is left out, as the audit log cannot be edited to remove personal data later
+	s.audit(ctx, store.AuditEntry{Action: auditLinkSMS, Resource: link.ID, LinkID: link.ID}, map[string]interface{}{
+		"channel": delivery.Channel,
+		"status":  delivery.Status,
+	})
+	logging.FromContext(ctx).Info("Payment link SMS sent",
+		"link_id", link.ID,
+		"phone", phone,
+		"provider_id", providerID,
+		"status", delivery.Status,
+	)
+	return delivery, sendErr
+}
+
+// handleSendSMS handles the /payment-link/{id}/send-sms endpoint
+func (s *Server) handleSendSMS(w http.ResponseWriter, r *http.Request) {
+	if s.sms == nil {
+		writeError(w, http.StatusServiceUnavailable, "SMS delivery failed", "SMS_NOT_CONFIGURED", "SMS delivery is not configured on this server")
+		return
+	}
+
+	linkID := r.PathValue("id")
+	if !linkIDPattern.MatchString(linkID) {
+		writeError(w, http.StatusBadRequest, "SMS delivery failed", "INVALID_LINK_ID", "Invalid payment link ID")
+		return
+	}
+
+	var req SendSMSRequest
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			if writeBodyTooLarge(w, "SMS delivery failed", err) {
+				return
+			}
+			writeError(w, http.StatusBadRequest, "SMS delivery failed", "INVALID_JSON", "Error parsing JSON request body")
+			return
+		}
+	} else {
+		req.CustomerPhone = r.FormValue("customerPhone")
+	}
+
+	link, err := s.links.GetLink(r.Context(), linkID)
+	if errors.Is(err, store.ErrLinkNotFound) {
+		writeError(w, http.StatusNotFound, "SMS delivery failed", "LINK_NOT_FOUND", "Payment link not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error reading payment link", "link_id", linkID, "error", err)
+		writeError(w, http.StatusInternalServerError, "SMS delivery failed", "STORE_ERROR", "Error reading stored payment link")
+		return
+	}
+
+	phone := req.CustomerPhone
+	if phone == "" {
+		phone = link.CustomerPhone
+	}
+	if phone == "" {
+		writeError(w, http.StatusBadRequest, "SMS delivery failed", "MISSING_PHONE", "No customerPhone provided or stored for this link")
+		return
+	}
+	phone, err = validatePhone(phone)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "SMS delivery failed", "INVALID_PHONE", err.Error())
+		return
+	}
+
+	delivery, err := s.sendLinkSMS(r.Context(), link, phone, linkSMSBody(link))
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, Response{
+			Success: false,
+			Message: "SMS delivery failed",
+			Data:    delivery,
+			Error: &ErrorInfo{
+				Code:    "SMS_PROVIDER_ERROR",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Payment link sent by SMS",
+		Data:    delivery,
+	})
+}
+
+// handleListDeliveries handles the /payment-link/{id}/deliveries endpoint
+func (s *Server) handleListDeliveries(w http.ResponseWriter, r *http.Request) {
+	linkID := r.PathValue("id")
+	if !linkIDPattern.MatchString(linkID) {
+		writeError(w, http.StatusBadRequest, "Delivery lookup failed", "INVALID_LINK_ID", "Invalid payment link ID")
+		return
+	}
+
+	deliveries, err := s.links.ListDeliveries(r.Context(), linkID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing deliveries", "link_id", linkID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Delivery lookup failed", "STORE_ERROR", "Error reading stored deliveries")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    deliveries,
+	})
+}
+
+// handleSMSStatusWebhook handles the /webhooks/sms/status endpoint that Twilio
+// calls as a message moves through queued, sent, delivered, or failed
+func (s *Server) handleSMSStatusWebhook(w http.ResponseWriter, r *http.Request) {
+	twilio, ok := s.sms.(*notify.TwilioNotifier)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Callback rejected", "SMS_NOT_CONFIGURED", "Twilio SMS delivery is not configured")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		if writeBodyTooLarge(w, "Callback rejected", err) {
+			return
+		}
+		writeError(w, http.StatusBadRequest, "Callback rejected", "FORM_PARSE_ERROR", "Error parsing form data")
+		return
+	}
+
+	if !twilio.VerifySignature(r.Header.Get("X-Twilio-Signature"), r.PostForm) {
+		logging.FromContext(r.Context()).Warn("Rejected SMS status callback with invalid signature", "remote_addr", r.RemoteAddr)
+		writeError(w, http.StatusUnauthorized, "Callback rejected", "INVALID_SIGNATURE", "Signature verification failed")
+		return
+	}
+
+	providerID := r.PostForm.Get("MessageSid")
+	status := r.PostForm.Get("MessageStatus")
+	var deliveryError string
+	if code := r.PostForm.Get("ErrorCode"); code != "" {
+		deliveryError = "twilio error " + code
+	}
+
+	err := s.links.UpdateDeliveryStatus(r.Context(), providerID, status, deliveryError)
+	if err != nil && !errors.Is(err, store.ErrDeliveryNotFound) {
+		logging.FromContext(r.Context()).Error("Error updating delivery status", "provider_id", providerID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Callback not processed", "STORE_ERROR", "Error recording delivery status")
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("SMS status callback processed", "provider_id", providerID, "status", status)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/webhooks"
+)
+
+// Event stream limits
+const (
+	streamHistorySize       = 100              // recent events replayed to clients resuming with Last-Event-ID
+	streamClientBuffer      = 32               // events queued per client before it is dropped as too slow
+	streamHeartbeatInterval = 30 * time.Second // comment lines that keep idle connections open through proxies
+	streamRetry             = 5 * time.Second  // how long browsers wait before reconnecting
+)
+
+// eventStream fans link events out to the clients connected to /events and /ws
+type eventStream struct {
+	mu      sync.Mutex
+	clients map[chan webhooks.Event]struct{}
+	history []webhooks.Event
+	closed  bool
+}
+
+// newEventStream creates an eventStream with no clients
+func newEventStream() *eventStream {
+	return &eventStream{clients: make(map[chan webhooks.Event]struct{})}
+}
+
+// publish sends event to every connected client. A client whose queue is full is
+// disconnected rather than allowed to hold up the others; it can resume with Last-Event-ID.
+func (es *eventStream) publish(event webhooks.Event) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.closed {
+		return
+	}
+
+	es.history = append(es.history, event)
+	if len(es.history) > streamHistorySize {
+		es.history = es.history[len(es.history)-streamHistorySize:]
+	}
+
+	for client := range es.clients {
+		select {
+		case client <- event:
+		default:
+			delete(es.clients, client)
+			close(client)
+		}
+	}
+}
+
+// subscribe connects a client, returning the events published after lastEventID that it
+// missed and a channel of new events. It reports false once the stream has been closed.
+func (es *eventStream) subscribe(lastEventID string) ([]webhooks.Event, chan webhooks.Event, bool) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.closed {
+		return nil, nil, false
+	}
+
+	var missed []webhooks.Event
+	if lastEventID != "" {
+		for i, event := range es.history {
+			if event.ID == lastEventID {
+				missed = append(missed, es.history[i+1:]...)
+				break
+			}
+		}
+	}
+
+	client := make(chan webhooks.Event, streamClientBuffer)
+	es.clients[client] = struct{}{}
+	return missed, client, true
+}
+
+// unsubscribe disconnects a client
+func (es *eventStream) unsubscribe(client chan webhooks.Event) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if _, ok := es.clients[client]; ok {
+		delete(es.clients, client)
+		close(client)
+	}
+}
+
+// close disconnects every client so shutdown is not held up by open streams
+func (es *eventStream) close() {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.closed = true
+	for client := range es.clients {
+		delete(es.clients, client)
+		close(client)
+	}
+}
+
+// handleEvents handles GET requests to the /events endpoint, streaming link events as
+// server-sent events until the client disconnects or the server shuts down
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	missed, events, ok := s.stream.subscribe(r.Header.Get("Last-Event-ID"))
+	if !ok {
+		writeError(w, http.StatusServiceUnavailable, "Event stream unavailable", "SHUTTING_DOWN", "The server is shutting down")
+		return
+	}
+	defer s.stream.unsubscribe(events)
+
+	// The stream outlives the server's write timeout
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		logging.FromContext(r.Context()).Warn("Event stream cannot clear the write deadline", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	for _, event := range missed {
+		writeStreamEvent(w, event)
+	}
+	if err := controller.Flush(); err != nil {
+		logging.FromContext(r.Context()).Error("Event stream cannot be flushed", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			writeStreamEvent(w, event)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeStreamEvent writes event in server-sent events format, named by its type so
+// browsers can listen for each type separately
+func writeStreamEvent(w http.ResponseWriter, event webhooks.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
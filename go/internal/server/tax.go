@@ -0,0 +1,53 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+)
+
+// taxRegionPattern matches the region part of an ISO 3166-2 subdivision code, such as CA in US-CA
+var taxRegionPattern = regexp.MustCompile(`^[A-Z0-9]{1,3}$`)
+
+// taxRate returns the configured tax rate in basis points for a country and optional region,
+// falling back from the region to the country and then to the default rate. It reports false
+// when no rate applies.
+func (s *Server) taxRate(countryCode, region string) (int, bool) {
+	if region != "" {
+		if rate, ok := s.tax.Rates[countryCode+"-"+region]; ok {
+			return rate, true
+		}
+	}
+	if rate, ok := s.tax.Rates[countryCode]; ok {
+		return rate, true
+	}
+	if s.tax.DefaultRate > 0 {
+		return s.tax.DefaultRate, true
+	}
+	return 0, false
+}
+
+// taxOn returns the tax at rate basis points on a net amount in minor units, rounded half up
+func taxOn(net int64, rate int) int64 {
+	return (net*int64(rate) + 5000) / 10000
+}
+
+// formatTaxRate renders a rate in basis points as a percentage such as 20 or 7.25
+func formatTaxRate(rate int) string {
+	percent := strconv.Itoa(rate / 100)
+	if fraction := rate % 100; fraction != 0 {
+		percent += strings.TrimRight(fmt.Sprintf(".%02d", fraction), "0")
+	}
+	return percent
+}
+
+// taxBreakdown describes how a link's gross amount is made up, for its description,
+// such as "Net 10.00 + VAT 20% 2.00 = 12.00 EUR"
+func (s *Server) taxBreakdown(net, tax int64, rate, currency string) string {
+	return fmt.Sprintf("Net %s + %s %s%% %s = %s %s",
+		money.FormatMinorUnits(net, currency), s.tax.Label, rate,
+		money.FormatMinorUnits(tax, currency), money.FormatMinorUnits(net+tax, currency), currency)
+}
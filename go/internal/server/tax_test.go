@@ -0,0 +1,45 @@
+package server
+
+import "testing"
+
+func TestTaxOn(t *testing.T) {
+	tests := []struct {
+		name string
+		net  int64
+		rate int
+		want int64
+	}{
+		{name: "exact", net: 1000, rate: 2000, want: 200},
+		{name: "fractional rate rounds down", net: 999, rate: 725, want: 72},
+		{name: "fractional rate rounds up", net: 1010, rate: 725, want: 73},
+		{name: "half a minor unit rounds up", net: 1, rate: 5000, want: 1},
+		{name: "just under half rounds down", net: 1, rate: 4999, want: 0},
+		{name: "zero rate", net: 1000, rate: 0, want: 0},
+		{name: "large amount", net: 999_999_999_999, rate: 2000, want: 200_000_000_000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := taxOn(tt.net, tt.rate); got != tt.want {
+				t.Errorf("taxOn(%d, %d) = %d, want %d", tt.net, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTaxRate(t *testing.T) {
+	tests := []struct {
+		rate int
+		want string
+	}{
+		{2000, "20"},
+		{725, "7.25"},
+		{750, "7.5"},
+		{5, "0.05"},
+		{0, "0"},
+	}
+	for _, tt := range tests {
+		if got := formatTaxRate(tt.rate); got != tt.want {
+			t.Errorf("formatTaxRate(%d) = %q, want %q", tt.rate, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// templateIDPattern matches the identifiers assigned to link templates
+var templateIDPattern = regexp.MustCompile(`^TPL_[0-9a-f]{24}$`)
+
+// LinkTemplateRequest represents the expected link template payload. Name is required and
+// is also the name of links created from the template; the other fields are optional presets.
+type LinkTemplateRequest struct {
+	Name           string `json:"name" form:"name"`
+	Amount         string `json:"amount" form:"amount"`
+	Currency       string `json:"currency" form:"currency"`
+	Description    string `json:"description" form:"description"`
+	UsageMode      string `json:"usageMode" form:"usageMode"`
+	UsageLimit     string `json:"usageLimit" form:"usageLimit"`
+	ExpirationDays string `json:"expirationDays" form:"expirationDays"`
+}
+
+// newTemplateID generates a random link template identifier
+func newTemplateID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "TPL_" + hex.EncodeToString(b)
+}
+
+// validateLinkTemplate checks a link template request with the same rules as link creation
+// and returns the template to store with its values normalised
+func validateLinkTemplate(req LinkTemplateRequest, supportedCurrencies []string) (*store.LinkTemplate, *LinkRequestError) {
+	var fields []FieldError
+	template := &store.LinkTemplate{
+		Name:        normalizeText(req.Name),
+		Currency:    strings.ToUpper(strings.TrimSpace(req.Currency)),
+		Description: normalizeText(req.Description),
+	}
+
+	switch {
+	case template.Name == "":
+		fields = append(fields, fieldError("name", FieldRequired, "%s is required", "name"))
+	case utf8.RuneCountInString(template.Name) > maxNameLength:
+		fields = append(fields, fieldError("name", FieldTooLong, "%s must be at most %d characters", "name", maxNameLength))
+	case hasControlCharacters(template.Name, false):
+		fields = append(fields, fieldError("name", FieldInvalidCharacters, "%s must not contain control characters", "name"))
+	}
+	switch {
+	case utf8.RuneCountInString(template.Description) > maxDescriptionLength:
+		fields = append(fields, fieldError("description", FieldTooLong, "%s must be at most %d characters", "description", maxDescriptionLength))
+	case hasControlCharacters(template.Description, true):
+		fields = append(fields, fieldError("description", FieldInvalidCharacters, "%s must not contain control characters", "description"))
+	}
+	if template.Currency != "" && !money.IsCurrency(template.Currency) {
+		fields = append(fields, FieldError{Field: "currency", Code: FieldInvalidFormat, Message: "currency must be an ISO 4217 currency code, such as EUR"})
+	}
+	amount := strings.TrimSpace(req.Amount)
+	if amount != "" && template.Currency == "" {
+		fields = append(fields, FieldError{Field: "currency", Code: FieldRequired, Message: "currency is required when amount is preset"})
+	}
+	if len(fields) > 0 {
+		return nil, validationError(fields)
+	}
+	if template.Currency != "" {
+		if linkErr := unsupportedCurrency(template.Currency, supportedCurrencies); linkErr != nil {
+			return nil, linkErr
+		}
+	}
+
+	if amount != "" {
+		minorAmount, err := money.ToMinorUnits(amount, template.Currency)
+		if err != nil {
+			return nil, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_AMOUNT", Details: err.Error()}
+		}
+		template.Amount = money.FormatMinorUnits(minorAmount, template.Currency)
+	}
+
+	if strings.TrimSpace(req.UsageMode) != "" || strings.TrimSpace(req.UsageLimit) != "" {
+		usageMode, usageLimit, err := parseUsage(req.UsageMode, req.UsageLimit)
+		if err != nil {
+			return nil, linkRequestErrorOf(http.StatusBadRequest, "INVALID_USAGE", err)
+		}
+		template.UsageMode = string(usageMode)
+		template.UsageLimit = strconv.Itoa(usageLimit)
+	}
+
+	if days := strings.TrimSpace(req.ExpirationDays); days != "" {
+		if _, err := parseExpiration(days, "", time.Now()); err != nil {
+			return nil, linkRequestErrorOf(http.StatusBadRequest, "INVALID_EXPIRATION", err)
+		}
+		template.ExpirationDays = days
+	}
+
+	return template, nil
+}
+
+// applyLinkTemplate fills the fields a link request leaves empty from a template. Usage mode
+// and limit are taken together, and the template's expiry only when the request sets none.
+// An open-amount request does not take the template's amount.
+func applyLinkTemplate(req PaymentLinkRequest, template *store.LinkTemplate) PaymentLinkRequest {
+	preset := func(value *string, presetValue string) {
+		if strings.TrimSpace(*value) == "" {
+			*value = presetValue
+		}
+	}
+	preset(&req.Name, template.Name)
+	if !req.openAmount() {
+		preset(&req.Amount, template.Amount)
+	}
+	preset(&req.Currency, template.Currency)
+	preset(&req.Description, template.Description)
+	if strings.TrimSpace(req.UsageMode) == "" && strings.TrimSpace(req.UsageLimit) == "" {
+		req.UsageMode = template.UsageMode
+		req.UsageLimit = template.UsageLimit
+	}
+	if strings.TrimSpace(req.ExpirationDays) == "" && strings.TrimSpace(req.ExpirationDate) == "" {
+		req.ExpirationDays = template.ExpirationDays
+	}
+	req.TemplateID = ""
+	return req
+}
+
+// resolveLinkTemplate applies the template a link request names, if any
+func (s *Server) resolveLinkTemplate(ctx context.Context, req PaymentLinkRequest) (PaymentLinkRequest, *LinkRequestError) {
+	templateID := strings.TrimSpace(req.TemplateID)
+	if templateID == "" {
+		return req, nil
+	}
+	if !templateIDPattern.MatchString(templateID) {
+		return req, &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_TEMPLATE_ID", Details: "Invalid link template ID"}
+	}
+
+	template, err := s.links.GetTemplate(ctx, templateID)
+	if errors.Is(err, store.ErrTemplateNotFound) {
+		return req, newLinkRequestError(http.StatusBadRequest, "TEMPLATE_NOT_FOUND", "Link template %s not found", templateID)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error reading link template", "template_id", templateID, "error", err)
+		return req, &LinkRequestError{Status: http.StatusInternalServerError, Code: "STORE_ERROR", Details: "Error reading stored link template"}
+	}
+	return applyLinkTemplate(req, template), nil
+}
+
+// decodeLinkTemplateRequest reads a link template request from a JSON or form body,
+// writing an error response and returning false when it cannot be read
+func decodeLinkTemplateRequest(w http.ResponseWriter, r *http.Request, failure string) (LinkTemplateRequest, bool) {
+	var req LinkTemplateRequest
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		if jsonErr := decodeStrictJSON(r.Body, &req); jsonErr != nil {
+			writeJSON(w, jsonErr.Status, Response{Success: false, Message: failure, Error: jsonErr.Info()})
+			return req, false
+		}
+		return req, true
+	}
+
+	if err := r.ParseForm(); err != nil {
+		if writeBodyTooLarge(w, failure, err) {
+			return req, false
+		}
+		writeError(w, http.StatusBadRequest, failure, "FORM_PARSE_ERROR", "Error parsing form data")
+		return req, false
+	}
+	return LinkTemplateRequest{
+		Name:           r.Form.Get("name"),
+		Amount:         r.Form.Get("amount"),
+		Currency:       r.Form.Get("currency"),
+		Description:    r.Form.Get("description"),
+		UsageMode:      r.Form.Get("usageMode"),
+		UsageLimit:     r.Form.Get("usageLimit"),
+		ExpirationDays: r.Form.Get("expirationDays"),
+	}, true
+}
+
+// handleListLinkTemplates handles GET requests to the /link-templates endpoint
+func (s *Server) handleListLinkTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := s.links.ListTemplates(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error listing link templates", "error", err)
+		writeError(w, http.StatusInternalServerError, "Link template listing failed", "STORE_ERROR", "Error reading stored link templates")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    templates,
+	})
+}
+
+// handleCreateLinkTemplate handles POST requests to the /link-templates endpoint
+func (s *Server) handleCreateLinkTemplate(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeLinkTemplateRequest(w, r, "Link template creation failed")
+	if !ok {
+		return
+	}
+
+	template, linkErr := validateLinkTemplate(req, s.linkDefaults.SupportedCurrencies)
+	if linkErr != nil {
+		writeJSON(w, linkErr.Status, Response{Success: false, Message: "Link template creation failed", Error: linkErr.Info()})
+		return
+	}
+
+	template.ID = newTemplateID()
+	if err := s.links.CreateTemplate(r.Context(), template); err != nil {
+		logging.FromContext(r.Context()).Error("Error storing link template", "error", err)
+		writeError(w, http.StatusInternalServerError, "Link template creation failed", "STORE_ERROR", "Error storing link template")
+		return
+	}
+
+	s.audit(r.Context(), store.AuditEntry{Action: auditTemplateCreate, Resource: template.ID}, map[string]interface{}{"template": template})
+	logging.FromContext(r.Context()).Info("Link template created", "template_id", template.ID, "name", template.Name, "api_key", apiKeyNameFrom(r.Context()))
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Link template created",
+		Data:    template,
+	})
+}
+
+// templateIDFromPath returns the template ID in the request path, writing an error
+// response and returning false when it is malformed
+func templateIDFromPath(w http.ResponseWriter, r *http.Request, failure string) (string, bool) {
+	templateID := r.PathValue("id")
+	if !templateIDPattern.MatchString(templateID) {
+		writeError(w, http.StatusBadRequest, failure, "INVALID_TEMPLATE_ID", "Invalid link template ID")
+		return "", false
+	}
+	return templateID, true
+}
+
+// writeTemplateStoreError writes the response for a failed link template store operation
+func writeTemplateStoreError(w http.ResponseWriter, r *http.Request, failure, templateID string, err error) {
+	if errors.Is(err, store.ErrTemplateNotFound) {
+		writeError(w, http.StatusNotFound, failure, "TEMPLATE_NOT_FOUND", "Link template not found")
+		return
+	}
+	logging.FromContext(r.Context()).Error("Error accessing link template", "template_id", templateID, "error", err)
+	writeError(w, http.StatusInternalServerError, failure, "STORE_ERROR", "Error accessing stored link template")
+}
+
+// handleGetLinkTemplate handles GET requests to the /link-templates/{id} endpoint
+func (s *Server) handleGetLinkTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, ok := templateIDFromPath(w, r, "Link template lookup failed")
+	if !ok {
+		return
+	}
+
+	template, err := s.links.GetTemplate(r.Context(), templateID)
+	if err != nil {
+		writeTemplateStoreError(w, r, "Link template lookup failed", templateID, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    template,
+	})
+}
+
+// handleUpdateLinkTemplate handles PUT requests to the /link-templates/{id} endpoint,
+// which replace every field of the template
+func (s *Server) handleUpdateLinkTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, ok := templateIDFromPath(w, r, "Link template update failed")
+	if !ok {
+		return
+	}
+
+	req, ok := decodeLinkTemplateRequest(w, r, "Link template update failed")
+	if !ok {
+		return
+	}
+
+	template, linkErr := validateLinkTemplate(req, s.linkDefaults.SupportedCurrencies)
+	if linkErr != nil {
+		writeJSON(w, linkErr.Status, Response{Success: false, Message: "Link template update failed", Error: linkErr.Info()})
+		return
+	}
+
+	// Read the template first so the audit log can record what it was
+	previous, err := s.links.GetTemplate(r.Context(), templateID)
+	if err != nil {
+		writeTemplateStoreError(w, r, "Link template update failed", templateID, err)
+		return
+	}
+
+	template.ID = templateID
+	if err := s.links.UpdateTemplate(r.Context(), template); err != nil {
+		writeTemplateStoreError(w, r, "Link template update failed", templateID, err)
+		return
+	}
+
+	// Read the template back for its creation time
+	updated, err := s.links.GetTemplate(r.Context(), templateID)
+	if err != nil {
+		writeTemplateStoreError(w, r, "Link template update failed", templateID, err)
+		return
+	}
+
+	s.audit(r.Context(), store.AuditEntry{Action: auditTemplateUpdate, Resource: templateID}, map[string]interface{}{
+		"template": auditChange{From: previous, To: updated},
+	})
+	logging.FromContext(r.Context()).Info("Link template updated", "template_id", templateID, "api_key", apiKeyNameFrom(r.Context()))
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Link template updated",
+		Data:    updated,
+	})
+}
+
+// handleDeleteLinkTemplate handles DELETE requests to the /link-templates/{id} endpoint.
+// Links already created from the template are not affected.
+func (s *Server) handleDeleteLinkTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, ok := templateIDFromPath(w, r, "Link template deletion failed")
+	if !ok {
+		return
+	}
+
+	if err := s.links.DeleteTemplate(r.Context(), templateID); err != nil {
+		writeTemplateStoreError(w, r, "Link template deletion failed", templateID, err)
+		return
+	}
+
+	s.audit(r.Context(), store.AuditEntry{Action: auditTemplateDelete, Resource: templateID}, nil)
+	logging.FromContext(r.Context()).Info("Link template deleted", "template_id", templateID, "api_key", apiKeyNameFrom(r.Context()))
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Link template deleted",
+		Data:    map[string]string{"templateId": templateID},
+	})
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalizeText trims a free-text field and converts it to Unicode normalization form C,
+// so text typed as a letter plus a combining accent is stored and counted the same way as
+// the precomposed letter
+func normalizeText(value string) string {
+	return norm.NFC.String(strings.TrimSpace(value))
+}
+
+// truncateText shortens value to at most limit characters. It cuts between characters
+// rather than bytes, and never separates a letter from the accents that follow it.
+func truncateText(value string, limit int) string {
+	if utf8.RuneCountInString(value) <= limit {
+		return value
+	}
+	runes := []rune(value)
+	cut := limit
+	// Back off to the start of the letter the first dropped accent belongs to
+	for cut > 0 && unicode.In(runes[cut], unicode.Mn, unicode.Me) {
+		cut--
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace)
+}
+
+// inAllowedScripts reports whether every letter in value is written in one of the allowed
+// Unicode scripts. Digits, punctuation, and accents belong to no one script and are always
+// allowed, as is any letter when no scripts are configured.
+func inAllowedScripts(value string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, r := range value {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if !slices.ContainsFunc(allowed, func(script string) bool { return unicode.Is(unicode.Scripts[script], r) }) {
+			return false
+		}
+	}
+	return true
+}
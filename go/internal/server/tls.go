@@ -0,0 +1,132 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+)
+
+// certificateFiles serves the certificate and key in a pair of files, and requires client
+// certificates issued by the CAs in clientCAFile when it is set. The files are read again
+// on SIGHUP so a renewed certificate or changed CA is used without a restart.
+type certificateFiles struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	// current is the configuration each handshake uses
+	current atomic.Pointer[tls.Config]
+}
+
+// load reads the files, keeping the current configuration when any of them is not valid
+func (c *certificateFiles) load() error {
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+		// http.Server only adds HTTP/2 to its own configuration, not the ones returned for each handshake
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if c.clientCAFile != "" {
+		pem, err := os.ReadFile(c.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA bundle: %w", err)
+		}
+		// Only the listed CAs are trusted, not the system's
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("client CA bundle %s contains no PEM certificates", c.clientCAFile)
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = clientCAs
+	}
+	c.current.Store(config)
+	return nil
+}
+
+// tlsConfig returns the configuration to serve with, which hands each handshake the one
+// last loaded
+func (c *certificateFiles) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return c.current.Load(), nil
+		},
+		// Unused, as every handshake gets its certificate from GetConfigForClient, but it
+		// tells http.Server the configuration has a certificate
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &c.current.Load().Certificates[0], nil
+		},
+	}
+}
+
+// httpsServing holds what ListenAndServe needs to serve HTTPS
+type httpsServing struct {
+	config *tls.Config
+	// certificates are reloaded on SIGHUP, or nil when they come from ACME
+	certificates *certificateFiles
+	// httpHandler serves the plain HTTP port, redirecting to HTTPS and, with ACME,
+	// answering HTTP-01 challenges
+	httpHandler http.Handler
+}
+
+// newHTTPSServing prepares to serve HTTPS on httpsPort with the certificate files or ACME
+// domains in cfg
+func newHTTPSServing(cfg config.TLS, httpsPort string) (*httpsServing, error) {
+	redirect := redirectToHTTPS(httpsPort)
+	if cfg.CertFile != "" {
+		certificates := &certificateFiles{certFile: cfg.CertFile, keyFile: cfg.KeyFile, clientCAFile: cfg.ClientCAFile}
+		if err := certificates.load(); err != nil {
+			return nil, err
+		}
+		if cfg.ClientCAFile != "" {
+			slog.Info("Requiring client certificates", "client_ca_file", cfg.ClientCAFile)
+		}
+		return &httpsServing{
+			config:       certificates.tlsConfig(),
+			certificates: certificates,
+			httpHandler:  redirect,
+		}, nil
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+		Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+		Email:      cfg.AutocertEmail,
+	}
+	if cfg.AutocertDirectoryURL != "" {
+		manager.Client = &acme.Client{DirectoryURL: cfg.AutocertDirectoryURL}
+	}
+	// The manager's configuration also answers TLS-ALPN-01 challenges on the HTTPS port
+	tlsConfig := manager.TLSConfig()
+	tlsConfig.MinVersion = tls.VersionTLS12
+	slog.Info("Obtaining TLS certificates with ACME", "domains", cfg.AutocertDomains, "cache_dir", cfg.AutocertCacheDir)
+	return &httpsServing{config: tlsConfig, httpHandler: manager.HTTPHandler(redirect)}, nil
+}
+
+// redirectToHTTPS redirects every request to the same URL over HTTPS on httpsPort
+func redirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if name, _, err := net.SplitHostPort(host); err == nil {
+			host = name
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
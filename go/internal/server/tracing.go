@@ -0,0 +1,33 @@
+package server
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans created by this package
+const instrumentationName = "github.com/globalpayments/pay-by-link-go/internal/server"
+
+// tracer returns the tracer for handler spans, a no-op until a tracer provider is installed
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// withTracing starts a server span for each request, continuing any trace the caller
+// propagated in the traceparent header. Spans are named after the route the request
+// matches, such as "GET /payment-link/{id}", so traces group by endpoint rather than by link ID.
+func withTracing(route func(*http.Request) string, next http.Handler) http.Handler {
+	tagged := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace.SpanFromContext(r.Context()).SetAttributes(semconv.HTTPRoute(route(r)))
+		next.ServeHTTP(w, r)
+	})
+	return otelhttp.NewHandler(tagged, "pay-by-link",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + route(r)
+		}),
+	)
+}
@@ -0,0 +1,370 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+	"github.com/globalpayments/pay-by-link-go/internal/locale"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// transactionIDPattern matches the format of GP API transaction identifiers
+var transactionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_\-]{1,64}$`)
+
+// defaultReportDays is how far back the transaction report goes when no start date is given
+const defaultReportDays = 30
+
+// RefundRequest represents the optional refund request payload
+type RefundRequest struct {
+	// Amount is the amount to refund in major units; the full transaction amount when omitted
+	Amount string `json:"amount" form:"amount"`
+}
+
+// RefundResponse represents the response data for a successful refund
+type RefundResponse struct {
+	RefundID      string `json:"refundId"`
+	TransactionID string `json:"transactionId"`
+	Status        string `json:"status"`
+	Amount        int64  `json:"amount"`
+	DisplayAmount string `json:"displayAmount"`
+	Currency      string `json:"currency"`
+	TimeCreated   string `json:"timeCreated"`
+}
+
+// LinkTransactionsResponse represents the payments taken through a payment link
+type LinkTransactionsResponse struct {
+	LinkID     string `json:"linkId"`
+	Status     string `json:"status"`
+	UsageMode  string `json:"usageMode"`
+	UsageLimit int64  `json:"usageLimit"`
+	UsageCount int64  `json:"usageCount"`
+	// PaidCount and PaidAmount cover the captured and preauthorized payments
+	PaidCount    int                  `json:"paidCount"`
+	PaidAmount   int64                `json:"paidAmount"`
+	Currency     string               `json:"currency"`
+	Transactions []TransactionSummary `json:"transactions"`
+}
+
+// newTransactionSummary converts a GP API transaction to its summary
+func newTransactionSummary(transaction gpapi.Transaction) TransactionSummary {
+	summary := TransactionSummary{
+		ID:          transaction.ID,
+		Type:        transaction.Type,
+		Status:      transaction.Status,
+		Currency:    transaction.Currency,
+		Reference:   transaction.Reference,
+		TimeCreated: transaction.TimeCreated,
+	}
+	summary.Amount, _ = transaction.Amount.Int64()
+	return summary
+}
+
+// handleListTransactions handles GET requests to the /transactions endpoint, returning a
+// page of GP API's transaction report for reconciliation. Pages are numbered from 1 and
+// the next page number is returned as the cursor.
+func (s *Server) handleListTransactions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	search := gpapi.TransactionSearch{
+		To:        time.Now(),
+		Status:    strings.ToUpper(strings.TrimSpace(query.Get("status"))),
+		Reference: strings.TrimSpace(query.Get("reference")),
+		Page:      1,
+		PageSize:  defaultListLimit,
+	}
+
+	if search.Status != "" && !slices.Contains(gpapi.TransactionStatuses, search.Status) {
+		writeError(w, http.StatusBadRequest, "Transaction listing failed", "INVALID_STATUS",
+			"status must be one of "+strings.Join(gpapi.TransactionStatuses, ", "))
+		return
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			writeErrorf(w, http.StatusBadRequest, "Transaction listing failed", "INVALID_LIMIT",
+				"limit must be between 1 and %d", maxListLimit)
+			return
+		}
+		search.PageSize = limit
+	}
+
+	if value := query.Get("to"); value != "" {
+		to, err := parseDateParam(value, true)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Transaction listing failed", "INVALID_DATE", "to must be RFC3339 or YYYY-MM-DD")
+			return
+		}
+		search.To = to
+	}
+	search.From = search.To.AddDate(0, 0, -defaultReportDays)
+	if value := query.Get("from"); value != "" {
+		from, err := parseDateParam(value, false)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Transaction listing failed", "INVALID_DATE", "from must be RFC3339 or YYYY-MM-DD")
+			return
+		}
+		search.From = from
+	}
+	if search.From.After(search.To) {
+		writeError(w, http.StatusBadRequest, "Transaction listing failed", "INVALID_DATE", "from must not be after to")
+		return
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			writeError(w, http.StatusBadRequest, "Transaction listing failed", "INVALID_CURSOR", "invalid cursor")
+			return
+		}
+		search.Page = page
+	}
+
+	report, err := s.gp.SearchTransactions(r.Context(), search)
+	if err != nil {
+		writeGPError(w, "Transaction listing failed", err)
+		return
+	}
+
+	transactions := make([]TransactionSummary, 0, len(report.Transactions))
+	for _, transaction := range report.Transactions {
+		transactions = append(transactions, newTransactionSummary(transaction))
+	}
+
+	pagination := &Pagination{Limit: search.PageSize}
+	if search.Page*search.PageSize < report.TotalRecordCount {
+		pagination.HasMore = true
+		pagination.NextCursor = strconv.Itoa(search.Page + 1)
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success:    true,
+		Data:       transactions,
+		Pagination: pagination,
+	})
+}
+
+// handleListLinkTransactions handles GET requests to the /payment-link/{id}/transactions endpoint,
+// listing the payments GP API has recorded against a link
+func (s *Server) handleListLinkTransactions(w http.ResponseWriter, r *http.Request) {
+	linkID := r.PathValue("id")
+	if !linkIDPattern.MatchString(linkID) {
+		writeError(w, http.StatusBadRequest, "Transaction listing failed", "INVALID_LINK_ID", "Invalid payment link ID")
+		return
+	}
+
+	linkDetail, err := s.gp.GetLink(r.Context(), linkID)
+	if err != nil {
+		writeGPError(w, "Transaction listing failed", err)
+		return
+	}
+
+	response := LinkTransactionsResponse{
+		LinkID:       linkDetail.ID,
+		Status:       linkDetail.Status,
+		UsageMode:    linkDetail.UsageMode,
+		Currency:     linkDetail.Transactions.Currency,
+		Transactions: []TransactionSummary{},
+	}
+	response.UsageLimit, _ = linkDetail.UsageLimit.Int64()
+	response.UsageCount, _ = linkDetail.UsageCount.Int64()
+
+	for _, transaction := range linkDetail.Transactions.TransactionList {
+		summary := newTransactionSummary(transaction)
+		response.Transactions = append(response.Transactions, summary)
+		if transactionPaid(transaction.Status) {
+			response.PaidCount++
+			response.PaidAmount += summary.Amount
+		}
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// handleCaptureTransaction handles POST requests to the /transactions/{id}/capture endpoint,
+// capturing a payment authorized through a LATER capture mode link
+func (s *Server) handleCaptureTransaction(w http.ResponseWriter, r *http.Request) {
+	transactionID := r.PathValue("id")
+	if !transactionIDPattern.MatchString(transactionID) {
+		writeError(w, http.StatusBadRequest, "Transaction capture failed", "INVALID_TRANSACTION_ID", "Invalid transaction ID")
+		return
+	}
+
+	transaction, err := s.gp.CaptureTransaction(r.Context(), transactionID)
+	if err != nil {
+		writeTransactionGPError(w, "Transaction capture failed", err)
+		return
+	}
+	linkID := s.recordTransactionStatus(r.Context(), transaction)
+	s.audit(r.Context(), store.AuditEntry{Action: auditTransactionCapture, Resource: transaction.ID, LinkID: linkID, TransactionID: transaction.ID},
+		map[string]interface{}{"status": auditChange{To: transaction.Status}})
+
+	logging.FromContext(r.Context()).Info("Transaction captured",
+		"transaction_id", transaction.ID,
+		"status", transaction.Status,
+		"api_key", apiKeyNameFrom(r.Context()),
+	)
+
+	writeJSON(w, http.StatusOK, Response{
+		Success:        true,
+		catalogMessage: locale.Messagef("Transaction %s captured", transaction.ID),
+		Data:           newTransactionSummary(*transaction),
+	})
+}
+
+// handleRefundTransaction handles POST requests to the /transactions/{id}/refund endpoint.
+// The transaction is fetched from GP API first so the amount can be checked against it and
+// the refunds already made through this server.
+func (s *Server) handleRefundTransaction(w http.ResponseWriter, r *http.Request) {
+	transactionID := r.PathValue("id")
+	if !transactionIDPattern.MatchString(transactionID) {
+		writeError(w, http.StatusBadRequest, "Refund failed", "INVALID_TRANSACTION_ID", "Invalid transaction ID")
+		return
+	}
+
+	// The body is optional; without an amount the whole transaction is refunded
+	var req RefundRequest
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			if writeBodyTooLarge(w, "Refund failed", err) {
+				return
+			}
+			writeError(w, http.StatusBadRequest, "Refund failed", "INVALID_JSON", "Error parsing JSON request body")
+			return
+		}
+	} else {
+		req.Amount = r.FormValue("amount")
+	}
+
+	original, err := s.gp.GetTransaction(r.Context(), transactionID)
+	if err != nil {
+		writeTransactionGPError(w, "Refund failed", err)
+		return
+	}
+
+	if !strings.EqualFold(original.Type, "SALE") || !strings.EqualFold(original.Status, "CAPTURED") {
+		writeError(w, http.StatusConflict, "Refund failed", "TRANSACTION_NOT_REFUNDABLE",
+			fmt.Sprintf("Only captured sales can be refunded; transaction is a %s in status %s", original.Type, original.Status))
+		return
+	}
+
+	capturedAmount, err := original.Amount.Int64()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "Refund failed", "INVALID_RESPONSE", "Transaction amount in GP API response is not a number")
+		return
+	}
+
+	// Without an amount, what remains after earlier refunds is refunded
+	var amount int64
+	if strings.TrimSpace(req.Amount) != "" {
+		amount, err = money.ToMinorUnits(req.Amount, original.Currency)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Refund failed", "INVALID_AMOUNT", err.Error())
+			return
+		}
+	}
+
+	// The refund is recorded before GP makes it, so concurrent refunds cannot together
+	// refund more than was captured
+	reservation := &store.Refund{TransactionID: transactionID, Amount: amount, Currency: original.Currency, CreatedAt: time.Now()}
+	if err := s.links.ReserveRefund(r.Context(), reservation, capturedAmount); err != nil {
+		var limitErr *store.RefundLimitError
+		switch {
+		case errors.As(err, &limitErr) && limitErr.Refunded >= capturedAmount:
+			writeError(w, http.StatusConflict, "Refund failed", "TRANSACTION_NOT_REFUNDABLE", "Transaction has already been refunded in full")
+		case errors.As(err, &limitErr):
+			writeError(w, http.StatusBadRequest, "Refund failed", "INVALID_AMOUNT",
+				fmt.Sprintf("amount must not exceed the %s %s that remains of the transaction amount of %s %s",
+					money.FormatMinorUnits(capturedAmount-limitErr.Refunded, original.Currency), original.Currency,
+					money.FormatMinorUnits(capturedAmount, original.Currency), original.Currency))
+		default:
+			logging.FromContext(r.Context()).Error("Error reserving refund", "transaction_id", transactionID, "error", err)
+			writeError(w, http.StatusInternalServerError, "Refund failed", "STORE_ERROR", "Error recording refund")
+		}
+		return
+	}
+	amount = reservation.Amount
+
+	refund, err := s.gp.RefundTransaction(r.Context(), transactionID, amount)
+	if err != nil {
+		// GP did not make the refund, so the amount can be refunded again
+		if err := s.links.ReleaseRefund(context.WithoutCancel(r.Context()), reservation.Reservation); err != nil {
+			logging.FromContext(r.Context()).Error("Error releasing refund", "transaction_id", transactionID, "error", err)
+		}
+		writeTransactionGPError(w, "Refund failed", err)
+		return
+	}
+
+	displayAmount := money.FormatMinorUnits(amount, original.Currency)
+	s.audit(r.Context(), store.AuditEntry{Action: auditTransactionRefund, Resource: transactionID, TransactionID: transactionID}, map[string]interface{}{
+		"refundId": refund.ID,
+		"amount":   displayAmount,
+		"currency": original.Currency,
+		"status":   refund.Status,
+	})
+	logging.FromContext(r.Context()).Info("Transaction refunded",
+		"transaction_id", transactionID,
+		"refund_id", refund.ID,
+		"amount", amount,
+		"currency", original.Currency,
+		"api_key", apiKeyNameFrom(r.Context()),
+	)
+
+	writeJSON(w, http.StatusOK, Response{
+		Success:        true,
+		catalogMessage: locale.Messagef("Refunded %s %s of transaction %s", displayAmount, original.Currency, transactionID),
+		Data: RefundResponse{
+			RefundID:      refund.ID,
+			TransactionID: transactionID,
+			Status:        refund.Status,
+			Amount:        amount,
+			DisplayAmount: displayAmount,
+			Currency:      original.Currency,
+			TimeCreated:   refund.TimeCreated,
+		},
+	})
+}
+
+// writeTransactionGPError writes a failed GP API transaction call as writeGPError does,
+// reporting a missing resource as a missing transaction rather than a missing link
+func writeTransactionGPError(w http.ResponseWriter, message string, err error) {
+	status, info := gpErrorInfo(err)
+	if status == http.StatusNotFound {
+		info.Code, info.Details = "TRANSACTION_NOT_FOUND", "Transaction not found"
+	}
+	writeJSON(w, status, Response{Success: false, Message: message, Error: info})
+}
+
+// recordTransactionStatus updates the stored link that took transaction with its new status,
+// leaving the link's own status unchanged, and returns the link's ID. Transactions on unknown
+// links are ignored.
+func (s *Server) recordTransactionStatus(ctx context.Context, transaction *gpapi.Transaction) string {
+	links, err := s.links.ListLinks(ctx, store.LinkFilter{TransactionID: transaction.ID, Limit: 1})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error finding link for transaction", "transaction_id", transaction.ID, "error", err)
+		return ""
+	}
+	if len(links) == 0 {
+		return ""
+	}
+
+	link := links[0]
+	if err := s.links.UpdateTransactionStatus(ctx, link.ID, transaction.ID, transaction.Status); err != nil {
+		// GP has already applied the change, so report success and surface the local failure in logs
+		logging.FromContext(ctx).Error("Error updating stored transaction status", "link_id", link.ID, "transaction_id", transaction.ID, "error", err)
+	}
+	return link.ID
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+)
+
+func TestRefundTransaction(t *testing.T) {
+	gp := &fakeGP{transactions: map[string]*gpapi.Transaction{
+		"TRN_1": {ID: "TRN_1", Status: "CAPTURED", Type: "SALE", Amount: "1000", Currency: "USD"},
+	}}
+	cfg := newTestConfig(config.APIKeys{AllowUnauthenticated: true})
+	handler := New(cfg, gp, newTestStore(t), nil, http.DefaultClient).Handler()
+
+	refund := func(body string) (int, string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/transactions/TRN_1/refund", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code, errorCode(decodeResponse(t, rec))
+	}
+
+	steps := []struct {
+		name       string
+		body       string
+		refundErr  error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "partial refund", body: `{"amount":"4.00"}`, wantStatus: http.StatusOK},
+		{name: "more than remains", body: `{"amount":"6.01"}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_AMOUNT"},
+		{name: "refund failed at GP", body: `{"amount":"6.00"}`, refundErr: &gpapi.APIError{StatusCode: http.StatusBadGateway},
+			wantStatus: http.StatusBadGateway, wantCode: "GP_UNAVAILABLE"},
+		{name: "rest after failed refund", body: `{}`, wantStatus: http.StatusOK},
+		{name: "refunded in full", body: `{"amount":"0.01"}`, wantStatus: http.StatusConflict, wantCode: "TRANSACTION_NOT_REFUNDABLE"},
+	}
+	for _, step := range steps {
+		gp.refundErr = step.refundErr
+		status, code := refund(step.body)
+		if status != step.wantStatus || code != step.wantCode {
+			t.Fatalf("%s: got %d %q, want %d %q", step.name, status, code, step.wantStatus, step.wantCode)
+		}
+	}
+	if want := []int64{400, 600}; !slices.Equal(gp.refunds, want) {
+		t.Errorf("GP refunds = %v, want %v", gp.refunds, want)
+	}
+}
+
+func TestRefundTransactionIdempotency(t *testing.T) {
+	gp := &fakeGP{transactions: map[string]*gpapi.Transaction{
+		"TRN_1": {ID: "TRN_1", Status: "CAPTURED", Type: "SALE", Amount: "1000", Currency: "USD"},
+	}}
+	cfg := newTestConfig(config.APIKeys{AllowUnauthenticated: true})
+	cfg.IdempotencyTTL = time.Hour
+	handler := New(cfg, gp, newTestStore(t), nil, http.DefaultClient).Handler()
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/transactions/TRN_1/refund", strings.NewReader(`{"amount":"4.00"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "refund-1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("attempt %d: status = %d, want 200: %s", i+1, rec.Code, rec.Body.String())
+		}
+		if replayed := rec.Header().Get("Idempotent-Replayed") == "true"; replayed != (i == 1) {
+			t.Errorf("attempt %d: replayed = %v", i+1, replayed)
+		}
+	}
+	if want := []int64{400}; !slices.Equal(gp.refunds, want) {
+		t.Errorf("GP refunds = %v, want %v", gp.refunds, want)
+	}
+}
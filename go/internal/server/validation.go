@@ -0,0 +1,244 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/locale"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+)
+
+// Per-field validation codes reported in ErrorInfo.Fields
+const (
+	FieldRequired          = "REQUIRED"
+	FieldTooLong           = "TOO_LONG"
+	FieldInvalidCharacters = "INVALID_CHARACTERS"
+	FieldInvalidFormat     = "INVALID_FORMAT"
+	FieldInvalidType       = "INVALID_TYPE"
+	FieldUnknown           = "UNKNOWN_FIELD"
+)
+
+// Maximum lengths of the free-text link fields, in characters
+const (
+	maxReferenceLength   = 100
+	maxNameLength        = 100
+	maxDescriptionLength = 500
+)
+
+// maxDynamicDescriptorLength is the longest statement descriptor the card schemes accept
+const maxDynamicDescriptorLength = 22
+
+// Limits on the product image URLs of a link
+const (
+	maxImages         = 10
+	maxImageURLLength = 2048
+)
+
+// dynamicDescriptorPattern matches the characters the card schemes allow in a statement
+// descriptor: ASCII letters and digits, spaces, and a few punctuation marks
+var dynamicDescriptorPattern = regexp.MustCompile(`^[A-Za-z0-9 &*,\-./#']*$`)
+
+// referencePattern matches the characters allowed in a link reference: letters and digits
+// in any script, accents, underscores, spaces, hyphens, and hash symbols
+var referencePattern = regexp.MustCompile(`^[\p{L}\p{M}\p{N}_\s\-#]*$`)
+
+// FieldError describes one invalid field in a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// catalogMessage, when set, is the catalog message Message was formatted from
+	catalogMessage locale.Message
+}
+
+// fieldError returns the error of field whose message is the catalog message with the given
+// key and values
+func fieldError(field, code, key string, args ...interface{}) FieldError {
+	message := locale.Messagef(key, args...)
+	return FieldError{Field: field, Code: code, Message: message.String(), catalogMessage: message}
+}
+
+// validationError returns a LinkRequestError reporting every field error at once
+func validationError(fields []FieldError) *LinkRequestError {
+	linkErr := newLinkRequestError(http.StatusBadRequest, "VALIDATION_ERROR", "%d field(s) failed validation", len(fields))
+	linkErr.Fields = fields
+	return linkErr
+}
+
+// validateLinkRequest checks the presence, length, and character set of the fields of a
+// link creation request, returning every violation found. Values such as the amount,
+// usage, and expiration are parsed (and reported with their own codes) afterwards. An empty
+// reference is only reported when no reference format is configured to generate one. The
+// text fields are expected to have been normalized with normalizeText.
+func validateLinkRequest(req PaymentLinkRequest, defaults config.LinkDefaults) []FieldError {
+	var fields []FieldError
+	referenceRequired := defaults.ReferenceFormat == ""
+
+	required := func(field, value string) bool {
+		if strings.TrimSpace(value) == "" {
+			fields = append(fields, fieldError(field, FieldRequired, "%s is required", field))
+			return false
+		}
+		return true
+	}
+	maxLength := func(field, value string, limit int) {
+		if utf8.RuneCountInString(value) > limit {
+			fields = append(fields, fieldError(field, FieldTooLong, "%s must be at most %d characters", field, limit))
+		}
+	}
+	printable := func(field, value string, allowNewlines bool) {
+		if hasControlCharacters(value, allowNewlines) {
+			fields = append(fields, fieldError(field, FieldInvalidCharacters, "%s must not contain control characters", field))
+		}
+	}
+	scripts := func(field, value string) {
+		if !inAllowedScripts(value, defaults.AllowedScripts) {
+			fields = append(fields, fieldError(field, FieldInvalidCharacters,
+				"%s may only contain letters from the %s scripts", field, strings.Join(defaults.AllowedScripts, ", ")))
+		}
+	}
+
+	// Open-amount links take minAmount and maxAmount, which are parsed later, in place of amount
+	if !req.openAmount() {
+		required("amount", req.Amount)
+	} else if strings.TrimSpace(req.Amount) != "" {
+		fields = append(fields, FieldError{Field: "amount", Code: FieldInvalidFormat, Message: "amount cannot be combined with minAmount or maxAmount"})
+	}
+
+	if required("currency", req.Currency) && !money.IsCurrency(strings.TrimSpace(req.Currency)) {
+		fields = append(fields, FieldError{Field: "currency", Code: FieldInvalidFormat, Message: "currency must be an ISO 4217 currency code, such as EUR"})
+	}
+
+	if reference := strings.TrimSpace(req.Reference); (reference != "" || referenceRequired) && required("reference", reference) {
+		maxLength("reference", reference, maxReferenceLength)
+		if !referencePattern.MatchString(reference) {
+			fields = append(fields, FieldError{Field: "reference", Code: FieldInvalidCharacters, Message: "reference may only contain letters, digits, spaces, underscores, hyphens, and #"})
+		} else {
+			scripts("reference", reference)
+		}
+	}
+
+	if name := strings.TrimSpace(req.Name); required("name", name) {
+		maxLength("name", name, maxNameLength)
+		printable("name", name, false)
+		scripts("name", name)
+	}
+
+	if description := strings.TrimSpace(req.Description); required("description", description) {
+		maxLength("description", description, maxDescriptionLength)
+		printable("description", description, true)
+		scripts("description", description)
+	}
+
+	if descriptor := req.DynamicDescriptor; descriptor != "" {
+		maxLength("dynamicDescriptor", descriptor, maxDynamicDescriptorLength)
+		switch {
+		case !dynamicDescriptorPattern.MatchString(descriptor):
+			fields = append(fields, FieldError{Field: "dynamicDescriptor", Code: FieldInvalidCharacters,
+				Message: "dynamicDescriptor may only contain ASCII letters, digits, spaces, and & * , - . / # '"})
+		case !strings.ContainsFunc(descriptor, unicode.IsLetter):
+			// The schemes reject descriptors of digits and punctuation alone
+			fields = append(fields, FieldError{Field: "dynamicDescriptor", Code: FieldInvalidFormat, Message: "dynamicDescriptor must contain a letter"})
+		}
+	}
+
+	if req.Language != "" {
+		if _, err := language.Parse(req.Language); err != nil {
+			fields = append(fields, FieldError{Field: "language", Code: FieldInvalidFormat, Message: "language must be a language tag such as es or fr-CA"})
+		}
+	}
+
+	fields = append(fields, validateImages(req.Images)...)
+	fields = append(fields, validateBranding(req)...)
+	fields = append(fields, validatePayerHints(req)...)
+	fields = append(fields, validateMetadata(req.Metadata)...)
+
+	return fields
+}
+
+// validateImages checks the product image URLs of a link request, returning every
+// violation found
+func validateImages(images []string) []FieldError {
+	if len(images) > maxImages {
+		return []FieldError{fieldError("images", FieldTooLong, "images may contain at most %d URLs", maxImages)}
+	}
+	var fields []FieldError
+	for i, image := range images {
+		field := fmt.Sprintf("images[%d]", i)
+		if len(image) > maxImageURLLength {
+			fields = append(fields, fieldError(field, FieldTooLong, "%s must be at most %d characters", field, maxImageURLLength))
+		} else if _, err := config.ParseHTTPSURL(image); err != nil {
+			fields = append(fields, fieldError(field, FieldInvalidFormat, "%s must be an absolute https URL", field))
+		}
+	}
+	return fields
+}
+
+// hasControlCharacters reports whether value contains control characters, other than
+// line breaks and tabs when allowNewlines is set
+func hasControlCharacters(value string, allowNewlines bool) bool {
+	for _, r := range value {
+		if unicode.IsControl(r) && !(allowNewlines && (r == '\n' || r == '\r' || r == '\t')) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeStrictJSON decodes a JSON request body into v, rejecting unknown fields.
+// Decoding failures are returned as an INVALID_JSON error naming the offending field where possible.
+func decodeStrictJSON(body io.Reader, v interface{}) *LinkRequestError {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	if err == nil {
+		return nil
+	}
+
+	invalid := &LinkRequestError{Status: http.StatusBadRequest, Code: "INVALID_JSON", Details: "Error parsing JSON request body"}
+
+	var typeErr *json.UnmarshalTypeError
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		invalid.Status = http.StatusRequestEntityTooLarge
+		invalid.Code = "REQUEST_TOO_LARGE"
+		invalid.catalogDetails = locale.Messagef("Request body must not exceed %d bytes", tooLarge.Limit)
+		invalid.Details = invalid.catalogDetails.String()
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		invalid.Fields = []FieldError{fieldError(typeErr.Field, FieldInvalidType,
+			"%s must be a %s", typeErr.Field, jsonTypeName(typeErr.Type.String()))}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields, so the name is read from the message
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		invalid.Fields = []FieldError{fieldError(field, FieldUnknown, "%s is not a recognized field", field)}
+	default:
+		invalid.Details += ": " + strings.TrimPrefix(err.Error(), "json: ")
+	}
+	return invalid
+}
+
+// jsonTypeName describes a Go type by its JSON equivalent for error messages
+func jsonTypeName(goType string) string {
+	switch goType {
+	case "string":
+		return "string"
+	case "bool":
+		return "boolean"
+	case "int":
+		return "whole number"
+	default:
+		return "valid value"
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// velocityKeys returns the keys, by velocity limit scope, that a new link counts towards:
+// its reference, its customer's email, and the API key creating it. Scopes that do not
+// apply, such as the customer of a link without one, are left out.
+func velocityKeys(ctx context.Context, reference string, customer *store.Customer) map[string]string {
+	keys := map[string]string{config.VelocityScopeReference: reference}
+	if customer != nil && customer.Email != "" {
+		keys[config.VelocityScopeCustomer] = strings.ToLower(customer.Email)
+	}
+	if name := apiKeyNameFrom(ctx); name != "" {
+		keys[config.VelocityScopeAPIKey] = name
+	}
+	return keys
+}
+
+// reserveVelocity counts a link for amount minor units of currency towards its velocity
+// keys, or returns a VELOCITY_LIMIT_EXCEEDED error when that would take one over a
+// configured limit. The links counted are those created within each limit's window before
+// now. The returned reservation, "" when no limit applies, is released if the link is not created.
+func (s *Server) reserveVelocity(ctx context.Context, keys map[string]string, amount int64, currency string, now time.Time) (string, *LinkRequestError) {
+	var limits []store.VelocityLimit
+	var applied []config.VelocityLimit
+	for _, limit := range s.velocityLimits {
+		key, ok := keys[limit.Scope]
+		if !ok || (limit.MaxLinks == 0 && limit.Currency != currency) {
+			continue
+		}
+		// Amount limits only count links in their own currency
+		maxAmount := limit.MaxAmount
+		if limit.Currency != currency {
+			maxAmount = 0
+		}
+		limits = append(limits, store.VelocityLimit{Scope: limit.Scope, Key: key, Since: now.Add(-limit.Window),
+			MaxLinks: limit.MaxLinks, MaxAmount: maxAmount, Currency: limit.Currency})
+		applied = append(applied, limit)
+	}
+	if len(limits) == 0 {
+		return "", nil
+	}
+
+	// Only the scopes some limit applies to are recorded
+	var entries []store.VelocityEntry
+	for scope, key := range keys {
+		if slices.ContainsFunc(s.velocityLimits, func(limit config.VelocityLimit) bool { return limit.Scope == scope }) {
+			entries = append(entries, store.VelocityEntry{Scope: scope, Key: key, Amount: amount, Currency: currency, CreatedAt: now})
+		}
+	}
+
+	reservation, err := s.links.ReserveVelocity(ctx, entries, limits)
+	var exceeded *store.VelocityLimitError
+	if errors.As(err, &exceeded) {
+		limit := applied[exceeded.Index]
+		details := fmt.Sprintf("%d links were already created for this %s in the last %s", exceeded.Usage.Links, limit.Scope, formatWindow(limit.Window))
+		if limit.MaxLinks == 0 || exceeded.Usage.Links < limit.MaxLinks {
+			details = fmt.Sprintf("Links for this %s would total more than %s %s in %s", limit.Scope,
+				money.FormatMinorUnits(limit.MaxAmount, currency), currency, formatWindow(limit.Window))
+		}
+		logging.FromContext(ctx).Warn("Velocity limit exceeded", "scope", limit.Scope, "window", limit.Window, "links", exceeded.Usage.Links)
+		return "", &LinkRequestError{Status: http.StatusTooManyRequests, Code: "VELOCITY_LIMIT_EXCEEDED", Details: details}
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error reserving link velocity", "error", err)
+		return "", &LinkRequestError{Status: http.StatusInternalServerError, Code: "STORE_ERROR", Details: "Error checking velocity limits"}
+	}
+	return reservation, nil
+}
+
+// pruneVelocity deletes the velocity entries that have aged out of every window. Failures
+// are logged; the link has been created.
+func (s *Server) pruneVelocity(ctx context.Context, now time.Time) {
+	if len(s.velocityLimits) == 0 {
+		return
+	}
+	var longest time.Duration
+	for _, limit := range s.velocityLimits {
+		longest = max(longest, limit.Window)
+	}
+	if err := s.links.PruneVelocity(ctx, now.Add(-longest)); err != nil {
+		logging.FromContext(ctx).Error("Error pruning link velocity", "error", err)
+	}
+}
+
+// formatWindow renders a velocity window without trailing zero units, such as 24h or 1h30m
+func formatWindow(window time.Duration) string {
+	text := window.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+)
+
+func TestCreateLinkVelocity(t *testing.T) {
+	cfg := newTestConfig(config.APIKeys{})
+	cfg.VelocityLimits = []config.VelocityLimit{
+		{Scope: config.VelocityScopeReference, Window: 24 * time.Hour, MaxLinks: 2},
+		{Scope: config.VelocityScopeReference, Window: time.Hour, MaxAmount: 2500, Currency: "USD"},
+	}
+	gp := &fakeGP{}
+	s := New(cfg, gp, newTestStore(t), nil, http.DefaultClient)
+
+	steps := []struct {
+		name      string
+		reference string
+		amount    string
+		currency  string
+		createErr error
+		wantCode  string
+	}{
+		{name: "first link", reference: "INV-1", amount: "10.00", currency: "USD"},
+		{name: "over the amount limit", reference: "INV-1", amount: "20.00", currency: "USD", wantCode: "VELOCITY_LIMIT_EXCEEDED"},
+		{name: "link GP failed to create", reference: "INV-1", amount: "5.00", currency: "USD",
+			createErr: &gpapi.APIError{StatusCode: http.StatusBadGateway}, wantCode: "GP_UNAVAILABLE"},
+		{name: "up to the amount limit", reference: "INV-1", amount: "15.00", currency: "USD"},
+		{name: "over the link limit", reference: "INV-1", amount: "1.00", currency: "EUR", wantCode: "VELOCITY_LIMIT_EXCEEDED"},
+		{name: "other reference", reference: "INV-2", amount: "20.00", currency: "USD"},
+	}
+	for _, step := range steps {
+		gp.createErr = step.createErr
+		_, err := s.CreateLink(context.Background(), PaymentLinkRequest{Amount: step.amount, Currency: step.currency,
+			Reference: step.reference, Name: "Invoice", Description: "Invoice " + step.reference})
+		var code string
+		var linkErr *LinkRequestError
+		if errors.As(err, &linkErr) {
+			code = linkErr.Code
+		} else if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if code != step.wantCode {
+			t.Fatalf("%s: error code = %q, want %q", step.name, code, step.wantCode)
+		}
+	}
+	if gp.created != 3 {
+		t.Errorf("links created at GP = %d, want 3", gp.created)
+	}
+}
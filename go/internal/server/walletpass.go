@@ -0,0 +1,155 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/wallet"
+)
+
+// Wallets a pass can be requested for with ?wallet=
+const (
+	walletApple  = "apple"
+	walletGoogle = "google"
+)
+
+// WalletPassResponse holds the Add to Google Wallet URL of a link's pass
+type WalletPassResponse struct {
+	Wallet  string `json:"wallet"`
+	SaveURL string `json:"saveUrl"`
+}
+
+// walletSigners holds what wallet passes are signed with; either may be nil when its
+// wallet is not configured
+type walletSigners struct {
+	apple  *wallet.AppleSigner
+	google *wallet.GoogleIssuer
+}
+
+// newWalletSigners loads the credentials of the configured wallets. Load checks them at
+// startup, so a failure here is logged and leaves that wallet unavailable.
+func newWalletSigners(cfg config.Wallet) walletSigners {
+	var signers walletSigners
+	var err error
+	if cfg.AppleEnabled() {
+		if signers.apple, err = wallet.LoadAppleSigner(cfg.ApplePassTypeID, cfg.AppleTeamID, cfg.AppleCertFile, cfg.AppleKeyFile, cfg.AppleWWDRFile); err != nil {
+			slog.Error("Error loading Apple Wallet credentials, Apple Wallet passes are unavailable", "error", err)
+		}
+	}
+	if cfg.GoogleEnabled() {
+		if signers.google, err = wallet.LoadGoogleIssuer(cfg.GoogleIssuerID, cfg.GoogleServiceAccountFile); err != nil {
+			slog.Error("Error loading Google Wallet credentials, Google Wallet passes are unavailable", "error", err)
+		}
+	}
+	return signers
+}
+
+// walletPass describes the pass of link, branded with the configured defaults
+func (s *Server) walletPass(link *store.Link) wallet.Pass {
+	organization := s.linkDefaults.Branding.DisplayName
+	if organization == "" {
+		organization = s.linkDefaults.MerchantName
+	}
+	if organization == "" {
+		organization = "Pay by Link"
+	}
+	// The shortlink records the views of payers who open the pass
+	url := s.shortLinkURL(link.ShortCode)
+	if url == "" {
+		url = link.URL
+	}
+	return wallet.Pass{
+		SerialNumber: link.ID,
+		Organization: organization,
+		Description:  "Payment request " + link.Reference,
+		Amount:       linkAmountText(link),
+		Reference:    link.Reference,
+		ExpiresAt:    link.ExpiresAt,
+		URL:          url,
+		Color:        s.linkDefaults.Branding.Color,
+		LogoURL:      s.linkDefaults.Branding.LogoURL,
+	}
+}
+
+// handleGetWalletPass handles GET requests to /payment-link/{id}/wallet-pass, returning an
+// Apple Wallet pass to download, or with ?wallet=google the URL that adds a Google Wallet
+// pass, for an active link. Either pass shows the link's amount, reference, and expiry,
+// with a QR code of its URL.
+func (s *Server) handleGetWalletPass(w http.ResponseWriter, r *http.Request) {
+	if s.wallet.apple == nil && s.wallet.google == nil {
+		writeError(w, http.StatusServiceUnavailable, "Wallet pass creation failed", "WALLET_NOT_CONFIGURED", "Wallet passes are not configured on this server")
+		return
+	}
+	linkID := r.PathValue("id")
+	if !linkIDPattern.MatchString(linkID) {
+		writeError(w, http.StatusBadRequest, "Wallet pass creation failed", "INVALID_LINK_ID", "Invalid payment link ID")
+		return
+	}
+
+	kind := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("wallet")))
+	switch kind {
+	case "":
+		kind = walletApple
+		if s.wallet.apple == nil {
+			kind = walletGoogle
+		}
+	case walletApple, walletGoogle:
+	default:
+		writeError(w, http.StatusBadRequest, "Wallet pass creation failed", "INVALID_WALLET", "wallet must be apple or google")
+		return
+	}
+	if kind == walletApple && s.wallet.apple == nil || kind == walletGoogle && s.wallet.google == nil {
+		writeErrorf(w, http.StatusServiceUnavailable, "Wallet pass creation failed", "WALLET_NOT_CONFIGURED",
+			"%s Wallet passes are not configured on this server", strings.ToUpper(kind[:1])+kind[1:])
+		return
+	}
+
+	link, err := s.links.GetLink(r.Context(), linkID)
+	if errors.Is(err, store.ErrLinkNotFound) {
+		writeError(w, http.StatusNotFound, "Wallet pass creation failed", "LINK_NOT_FOUND", "Payment link not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error reading payment link", "link_id", linkID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Wallet pass creation failed", "STORE_ERROR", "Error reading stored payment link")
+		return
+	}
+	if link.Status != store.LinkStatusActive {
+		writeErrorf(w, http.StatusConflict, "Wallet pass creation failed", "LINK_NOT_ACTIVE",
+			"Payment link is %s; wallet passes are only available while it can be paid", link.Status)
+		return
+	}
+
+	pass := s.walletPass(link)
+	if kind == walletGoogle {
+		saveURL, err := s.wallet.google.SaveURL(pass)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Error signing Google Wallet pass", "link_id", linkID, "error", err)
+			writeError(w, http.StatusInternalServerError, "Wallet pass creation failed", "WALLET_PASS_ERROR", "Error signing the wallet pass")
+			return
+		}
+		writeJSON(w, http.StatusOK, Response{
+			Success: true,
+			Message: "Google Wallet pass created",
+			Data:    WalletPassResponse{Wallet: walletGoogle, SaveURL: saveURL},
+		})
+		return
+	}
+
+	pkpass, err := s.wallet.apple.Build(pass)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error signing Apple Wallet pass", "link_id", linkID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Wallet pass creation failed", "WALLET_PASS_ERROR", "Error signing the wallet pass")
+		return
+	}
+	w.Header().Set("Content-Type", wallet.PKPassContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pkpass"`, link.ID))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(pkpass)
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+func TestGetWalletPass(t *testing.T) {
+	ctx := context.Background()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	account, _ := json.Marshal(map[string]string{
+		"client_email": "wallet@example.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	})
+	accountFile := filepath.Join(t.TempDir(), "service-account.json")
+	if err := os.WriteFile(accountFile, account, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	links := newTestStore(t)
+	for _, link := range []*store.Link{
+		{ID: "LNK_1", URL: "https://pay.example/1", Reference: "INV-1", Amount: 1050, Currency: "USD", Status: store.LinkStatusActive},
+		{ID: "LNK_2", URL: "https://pay.example/2", Reference: "INV-2", Amount: 1050, Currency: "USD", Status: store.LinkStatusPaid},
+	} {
+		link.ExpiresAt = time.Now().Add(24 * time.Hour)
+		if err := links.CreateLink(ctx, link, nil); err != nil {
+			t.Fatalf("CreateLink: %v", err)
+		}
+	}
+	cfg := newTestConfig(config.APIKeys{AllowUnauthenticated: true})
+	cfg.Wallet = config.Wallet{GoogleIssuerID: "3388000000012345678", GoogleServiceAccountFile: accountFile}
+	handler := New(cfg, &fakeGP{}, links, nil, http.DefaultClient).Handler()
+	unconfigured := New(newTestConfig(config.APIKeys{AllowUnauthenticated: true}), &fakeGP{}, links, nil, http.DefaultClient).Handler()
+
+	serve := func(handler http.Handler, target string) (int, Response) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Code, decodeResponse(t, rec)
+	}
+
+	// Google Wallet is the default when it is the only wallet configured
+	status, response := serve(handler, "/payment-link/LNK_1/wallet-pass")
+	if status != http.StatusOK {
+		t.Fatalf("pass: status = %d, want 200: %+v", status, response.Error)
+	}
+	var pass WalletPassResponse
+	data, _ := json.Marshal(response.Data)
+	json.Unmarshal(data, &pass)
+	token, ok := strings.CutPrefix(pass.SaveURL, "https://pay.google.com/gp/v/save/")
+	parts := strings.Split(token, ".")
+	if pass.Wallet != walletGoogle || !ok || len(parts) != 3 {
+		t.Fatalf("pass = %+v, want an Add to Google Wallet URL", pass)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !strings.Contains(string(payload), `"value":"10.50 USD"`) || !strings.Contains(string(payload), `"body":"INV-1"`) ||
+		!strings.Contains(string(payload), `"uri":"https://pay.example/1"`) {
+		t.Errorf("pass payload = %s (%v), want the link's amount, reference, and URL", payload, err)
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.Handler
+		target     string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "no wallets configured", handler: unconfigured, target: "/payment-link/LNK_1/wallet-pass",
+			wantStatus: http.StatusServiceUnavailable, wantCode: "WALLET_NOT_CONFIGURED"},
+		{name: "wallet not configured", handler: handler, target: "/payment-link/LNK_1/wallet-pass?wallet=apple",
+			wantStatus: http.StatusServiceUnavailable, wantCode: "WALLET_NOT_CONFIGURED"},
+		{name: "unknown wallet", handler: handler, target: "/payment-link/LNK_1/wallet-pass?wallet=samsung",
+			wantStatus: http.StatusBadRequest, wantCode: "INVALID_WALLET"},
+		{name: "unknown link", handler: handler, target: "/payment-link/LNK_404/wallet-pass",
+			wantStatus: http.StatusNotFound, wantCode: "LINK_NOT_FOUND"},
+		{name: "paid link", handler: handler, target: "/payment-link/LNK_2/wallet-pass",
+			wantStatus: http.StatusConflict, wantCode: "LINK_NOT_ACTIVE"},
+	}
+	for _, tt := range tests {
+		if status, response := serve(tt.handler, tt.target); status != tt.wantStatus || errorCode(response) != tt.wantCode {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, status, errorCode(response), tt.wantStatus, tt.wantCode)
+		}
+	}
+}
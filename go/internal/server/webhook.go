@@ -0,0 +1,189 @@
+package server
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/webhooks"
+)
+
+// maxNotificationSize limits the size of status notification bodies read from GP
+const maxNotificationSize = 1 << 20
+
+// GPStatusNotification represents the transaction status notification GP API posts to status_url
+type GPStatusNotification struct {
+	ID            string                      `json:"id"`
+	TimeCreated   string                      `json:"time_created"`
+	Type          string                      `json:"type"`
+	Status        string                      `json:"status"`
+	Channel       string                      `json:"channel"`
+	Amount        string                      `json:"amount"`
+	Currency      string                      `json:"currency"`
+	Reference     string                      `json:"reference"`
+	PaymentMethod GPNotificationPaymentMethod `json:"payment_method"`
+	LinkData      GPNotificationLinkData      `json:"link_data"`
+}
+
+// GPNotificationPaymentMethod represents the payment method result in a status notification
+type GPNotificationPaymentMethod struct {
+	Result  string             `json:"result"`
+	Message string             `json:"message"`
+	Card    GPNotificationCard `json:"card"`
+}
+
+// GPNotificationCard represents the card details in a status notification
+type GPNotificationCard struct {
+	Brand             string `json:"brand"`
+	MaskedNumberLast4 string `json:"masked_number_last4"`
+}
+
+// GPNotificationLinkData identifies the payment link a notification belongs to
+type GPNotificationLinkData struct {
+	ID        string `json:"id"`
+	Reference string `json:"reference"`
+}
+
+// verifyNotificationSignature checks the X-GP-Signature header of a status notification.
+// GP signs notifications as SHA512(raw body + APP-KEY).
+func verifyNotificationSignature(body []byte, signature, appKey string) bool {
+	hash := sha512.Sum512(append(append([]byte{}, body...), appKey...))
+	expected := hex.EncodeToString(hash[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(signature))) == 1
+}
+
+// transactionPaid reports whether a GP transaction status means the link was paid
+func transactionPaid(transactionStatus string) bool {
+	switch strings.ToUpper(transactionStatus) {
+	case "CAPTURED", "PREAUTHORIZED":
+		return true
+	default:
+		return false
+	}
+}
+
+// transactionStatusesBefore returns the statuses a paid transaction can move on to
+// transactionStatus from, or none when a notification must not change the transaction
+func transactionStatusesBefore(transactionStatus string) []string {
+	if strings.EqualFold(transactionStatus, "CAPTURED") {
+		return []string{"PREAUTHORIZED"}
+	}
+	return nil
+}
+
+// handleStatusWebhook handles the /webhooks/status endpoint
+func (s *Server) handleStatusWebhook(w http.ResponseWriter, r *http.Request) {
+	// Bodies over the limit fail with 413 rather than being cut short, which would only show
+	// up as a signature that does not match
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxNotificationSize))
+	if err != nil {
+		if writeBodyTooLarge(w, "Notification rejected", err) {
+			return
+		}
+		writeError(w, http.StatusBadRequest, "Notification rejected", "INVALID_BODY", "Error reading notification body")
+		return
+	}
+
+	if !verifyNotificationSignature(body, r.Header.Get("X-GP-Signature"), s.reloadable.Load().appKey) {
+		logging.FromContext(r.Context()).Warn("Rejected status notification with invalid signature", "remote_addr", r.RemoteAddr)
+		writeError(w, http.StatusUnauthorized, "Notification rejected", "INVALID_SIGNATURE", "Signature verification failed")
+		return
+	}
+
+	var notification GPStatusNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		writeError(w, http.StatusBadRequest, "Notification rejected", "INVALID_JSON", "Error parsing notification body")
+		return
+	}
+
+	if notification.LinkData.ID == "" {
+		writeError(w, http.StatusBadRequest, "Notification rejected", "MISSING_LINK_ID", "Notification does not reference a payment link")
+		return
+	}
+
+	// The signature covers time_created, so a captured notification cannot be given a new
+	// timestamp and replayed once it is too old
+	created, err := time.Parse(time.RFC3339, notification.TimeCreated)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Notification rejected", "INVALID_TIMESTAMP", "Notification time_created must be an RFC 3339 timestamp")
+		return
+	}
+	if skew := time.Since(created); skew > s.notificationMaxSkew || skew < -s.notificationMaxSkew {
+		logging.FromContext(r.Context()).Warn("Rejected status notification outside the accepted clock skew",
+			"link_id", notification.LinkData.ID, "transaction_id", notification.ID, "time_created", notification.TimeCreated,
+			"remote_addr", r.RemoteAddr)
+		writeError(w, http.StatusBadRequest, "Notification rejected", "STALE_NOTIFICATION",
+			fmt.Sprintf("Notification time_created is more than %s from the server's clock", s.notificationMaxSkew))
+		return
+	}
+
+	// Only a successful transaction changes the link's status, and only from unpaid to PAID
+	paid := transactionPaid(notification.Status)
+	var event *store.OutboxEvent
+	if paid {
+		event = newOutboxEvent(webhooks.EventLinkPaid)
+	}
+	link, err := s.links.RecordTransaction(r.Context(), notification.LinkData.ID, paid, notification.ID, notification.Status, event)
+	if err == nil && paid {
+		// The receipt is saved before the relay is woken, so Slack can show the amount paid
+		s.saveReceipt(r.Context(), link, &notification)
+		s.wakeOutboxRelay()
+	}
+	if errors.Is(err, store.ErrTransactionRecorded) {
+		// A repeated notification is acknowledged without being applied again, so it neither
+		// changes the link nor announces the payment twice. The store decides, so repeats are
+		// caught whichever instance receives them and across restarts.
+		logging.FromContext(r.Context()).Warn("Ignored duplicate status notification",
+			"link_id", notification.LinkData.ID, "transaction_id", notification.ID, "transaction_status", notification.Status)
+		writeJSON(w, http.StatusOK, Response{
+			Success: true,
+			Message: "Notification already processed",
+		})
+		return
+	}
+	if errors.Is(err, store.ErrLinkUnchanged) {
+		// The link is already paid. GP's notification that its preauthorized payment was
+		// captured still updates the transaction, but only forwards, so a late preauthorization
+		// cannot take a captured payment back; any other transaction leaves it as it is.
+		if from := transactionStatusesBefore(notification.Status); len(from) > 0 {
+			if err := s.links.UpdateTransactionStatus(r.Context(), notification.LinkData.ID, notification.ID, notification.Status, from...); err != nil && !errors.Is(err, store.ErrLinkNotFound) {
+				logging.FromContext(r.Context()).Error("Error updating stored transaction status", "link_id", notification.LinkData.ID, "error", err)
+			}
+		}
+		logging.FromContext(r.Context()).Info("Status notification for paid link left it unchanged",
+			"link_id", notification.LinkData.ID, "transaction_id", notification.ID, "transaction_status", notification.Status)
+	} else if errors.Is(err, store.ErrLinkNotFound) {
+		// Acknowledge notifications for links created elsewhere so GP does not retry them
+		logging.FromContext(r.Context()).Warn("Status notification for unknown link",
+			"link_id", notification.LinkData.ID, "transaction_id", notification.ID, "transaction_status", notification.Status)
+	} else if err != nil {
+		logging.FromContext(r.Context()).Error("Error recording status notification", "link_id", notification.LinkData.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "Notification not processed", "STORE_ERROR", "Error recording notification")
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("Status notification processed",
+		"link_id", notification.LinkData.ID,
+		"transaction_id", notification.ID,
+		"transaction_status", notification.Status,
+		"result", notification.PaymentMethod.Result,
+		"amount", notification.Amount,
+		"currency", notification.Currency,
+		"reference", notification.Reference,
+		"paid", paid,
+	)
+
+	writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Notification processed",
+	})
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+const testAppKey = "test-app-key"
+
+// signNotification returns the X-GP-Signature GP would send with body
+func signNotification(body []byte, appKey string) string {
+	hash := sha512.Sum512(append(append([]byte{}, body...), appKey...))
+	return hex.EncodeToString(hash[:])
+}
+
+func TestVerifyNotificationSignature(t *testing.T) {
+	body := []byte(`{"id":"TRN_1","status":"CAPTURED"}`)
+	valid := signNotification(body, testAppKey)
+
+	tests := []struct {
+		name      string
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{name: "valid", body: body, signature: valid, want: true},
+		{name: "upper case hex", body: body, signature: strings.ToUpper(valid), want: true},
+		{name: "signed with another key", body: body, signature: signNotification(body, "other-key")},
+		{name: "body changed after signing", body: []byte(`{"id":"TRN_1","status":"DECLINED"}`), signature: valid},
+		{name: "truncated", body: body, signature: valid[:len(valid)-2]},
+		{name: "missing", body: body},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyNotificationSignature(tt.body, tt.signature, testAppKey); got != tt.want {
+				t.Errorf("verifyNotificationSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newWebhookTestServer returns a server that verifies notifications with testAppKey,
+// accepting them up to maxSkew from its clock, and a store holding an active link LNK_1
+func newWebhookTestServer(t *testing.T, maxSkew time.Duration) (*Server, *store.SQLiteLinkStore) {
+	t.Helper()
+	links := newTestStore(t)
+	link := &store.Link{ID: "LNK_1", URL: "https://pay.example/LNK_1", Reference: "ORDER-1", Amount: 1000, Currency: "EUR",
+		Status: store.LinkStatusActive, ExpiresAt: time.Now().Add(24 * time.Hour)}
+	if err := links.CreateLink(context.Background(), link, nil); err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+	s := &Server{links: links, notificationMaxSkew: maxSkew, outbox: newOutboxRelay()}
+	s.reloadable.Store(&reloadable{appKey: testAppKey})
+	return s, links
+}
+
+// postNotification sends a status notification to the server, signed with appKey
+func postNotification(s *Server, notification GPStatusNotification, appKey string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(notification)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/status", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GP-Signature", signNotification(body, appKey))
+	rec := httptest.NewRecorder()
+	s.handleStatusWebhook(rec, req)
+	return rec
+}
+
+func TestStatusWebhook(t *testing.T) {
+	const maxSkew = 15 * time.Minute
+	notification := func(status string, created time.Time) GPStatusNotification {
+		return GPStatusNotification{ID: "TRN_1", TimeCreated: created.UTC().Format(time.RFC3339), Status: status,
+			Amount: "1000", Currency: "EUR", LinkData: GPNotificationLinkData{ID: "LNK_1"}}
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		notification GPStatusNotification
+		appKey       string
+		wantStatus   int
+		wantCode     string
+		wantLink     string
+	}{
+		{name: "paid", notification: notification("CAPTURED", now), appKey: testAppKey,
+			wantStatus: http.StatusOK, wantLink: store.LinkStatusPaid},
+		{name: "declined leaves link active", notification: notification("DECLINED", now), appKey: testAppKey,
+			wantStatus: http.StatusOK, wantLink: store.LinkStatusActive},
+		{name: "within skew in the past", notification: notification("CAPTURED", now.Add(-maxSkew+time.Minute)), appKey: testAppKey,
+			wantStatus: http.StatusOK, wantLink: store.LinkStatusPaid},
+		{name: "within skew in the future", notification: notification("CAPTURED", now.Add(maxSkew-time.Minute)), appKey: testAppKey,
+			wantStatus: http.StatusOK, wantLink: store.LinkStatusPaid},
+		{name: "invalid signature", notification: notification("CAPTURED", now), appKey: "wrong-key",
+			wantStatus: http.StatusUnauthorized, wantCode: "INVALID_SIGNATURE", wantLink: store.LinkStatusActive},
+		{name: "too old", notification: notification("CAPTURED", now.Add(-maxSkew-time.Minute)), appKey: testAppKey,
+			wantStatus: http.StatusBadRequest, wantCode: "STALE_NOTIFICATION", wantLink: store.LinkStatusActive},
+		{name: "too far in the future", notification: notification("CAPTURED", now.Add(maxSkew+time.Minute)), appKey: testAppKey,
+			wantStatus: http.StatusBadRequest, wantCode: "STALE_NOTIFICATION", wantLink: store.LinkStatusActive},
+		{name: "malformed timestamp", notification: GPStatusNotification{ID: "TRN_1", TimeCreated: "yesterday", Status: "CAPTURED",
+			LinkData: GPNotificationLinkData{ID: "LNK_1"}}, appKey: testAppKey,
+			wantStatus: http.StatusBadRequest, wantCode: "INVALID_TIMESTAMP", wantLink: store.LinkStatusActive},
+		{name: "missing link", notification: GPStatusNotification{ID: "TRN_1", TimeCreated: now.UTC().Format(time.RFC3339), Status: "CAPTURED"},
+			appKey: testAppKey, wantStatus: http.StatusBadRequest, wantCode: "MISSING_LINK_ID", wantLink: store.LinkStatusActive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, links := newWebhookTestServer(t, maxSkew)
+			rec := postNotification(s, tt.notification, tt.appKey)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if code := errorCode(decodeResponse(t, rec)); code != tt.wantCode {
+				t.Errorf("error code = %q, want %q", code, tt.wantCode)
+			}
+			link, err := links.GetLink(context.Background(), "LNK_1")
+			if err != nil {
+				t.Fatalf("GetLink: %v", err)
+			}
+			if link.Status != tt.wantLink {
+				t.Errorf("link status = %s, want %s", link.Status, tt.wantLink)
+			}
+		})
+	}
+}
+
+func TestStatusWebhookDuplicate(t *testing.T) {
+	s, links := newWebhookTestServer(t, 15*time.Minute)
+	ctx := context.Background()
+	notification := GPStatusNotification{ID: "TRN_1", TimeCreated: time.Now().UTC().Format(time.RFC3339), Status: "CAPTURED",
+		Amount: "1000", Currency: "EUR", LinkData: GPNotificationLinkData{ID: "LNK_1"}}
+
+	wantMessages := []string{"Notification processed", "Notification already processed", "Notification already processed"}
+	for i, want := range wantMessages {
+		rec := postNotification(s, notification, testAppKey)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("delivery %d: status = %d: %s", i+1, rec.Code, rec.Body.String())
+		}
+		if got := decodeResponse(t, rec).Message; got != want {
+			t.Errorf("delivery %d: message = %q, want %q", i+1, got, want)
+		}
+	}
+
+	// The payment is announced once, however many times GP delivers it
+	events, err := links.ClaimOutboxEvents(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimOutboxEvents: %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("outbox events = %d, want 1", len(events))
+	}
+	link, err := links.GetLink(ctx, "LNK_1")
+	if err != nil {
+		t.Fatalf("GetLink: %v", err)
+	}
+	if link.Status != store.LinkStatusPaid || link.TransactionID != "TRN_1" {
+		t.Errorf("link = %s with transaction %q, want PAID with TRN_1", link.Status, link.TransactionID)
+	}
+}
+
+func TestStatusWebhookOrdering(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []string
+		wantMessages []string
+		wantStatus   string
+	}{
+		{name: "captured after preauthorization", statuses: []string{"PREAUTHORIZED", "CAPTURED"},
+			wantMessages: []string{"Notification processed", "Notification processed"}, wantStatus: "CAPTURED"},
+		{name: "preauthorization replayed after capture", statuses: []string{"PREAUTHORIZED", "CAPTURED", "PREAUTHORIZED"},
+			wantMessages: []string{"Notification processed", "Notification processed", "Notification already processed"}, wantStatus: "CAPTURED"},
+		{name: "preauthorization delivered after capture", statuses: []string{"CAPTURED", "PREAUTHORIZED"},
+			wantMessages: []string{"Notification processed", "Notification processed"}, wantStatus: "CAPTURED"},
+		{name: "capture replayed", statuses: []string{"PREAUTHORIZED", "CAPTURED", "CAPTURED"},
+			wantMessages: []string{"Notification processed", "Notification processed", "Notification already processed"}, wantStatus: "CAPTURED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, links := newWebhookTestServer(t, 15*time.Minute)
+			ctx := context.Background()
+			var updated time.Time
+			for i, status := range tt.statuses {
+				notification := GPStatusNotification{ID: "TRN_1", TimeCreated: time.Now().UTC().Format(time.RFC3339), Status: status,
+					Amount: "1000", Currency: "EUR", LinkData: GPNotificationLinkData{ID: "LNK_1"}}
+				rec := postNotification(s, notification, testAppKey)
+				if rec.Code != http.StatusOK {
+					t.Fatalf("delivery %d: status = %d: %s", i+1, rec.Code, rec.Body.String())
+				}
+				if got := decodeResponse(t, rec).Message; got != tt.wantMessages[i] {
+					t.Errorf("delivery %d of %s: message = %q, want %q", i+1, status, got, tt.wantMessages[i])
+				}
+				link, err := links.GetLink(ctx, "LNK_1")
+				if err != nil {
+					t.Fatalf("GetLink: %v", err)
+				}
+				// An ignored notification leaves the link exactly as it was
+				if tt.wantMessages[i] == "Notification already processed" && !link.UpdatedAt.Equal(updated) {
+					t.Errorf("delivery %d of %s: updated at %s, want %s", i+1, status, link.UpdatedAt, updated)
+				}
+				updated = link.UpdatedAt
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			link, err := links.GetLink(ctx, "LNK_1")
+			if err != nil {
+				t.Fatalf("GetLink: %v", err)
+			}
+			if link.Status != store.LinkStatusPaid || link.TransactionStatus != tt.wantStatus {
+				t.Errorf("link = %s with transaction %s, want PAID with %s", link.Status, link.TransactionStatus, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestStatusWebhookBodyTooLarge(t *testing.T) {
+	s, _ := newWebhookTestServer(t, 15*time.Minute)
+	body := []byte(`{"id":"TRN_1","reference":"` + strings.Repeat("x", maxNotificationSize) + `"}`)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/status", strings.NewReader(string(body)))
+	req.Header.Set("X-GP-Signature", signNotification(body, testAppKey))
+	rec := httptest.NewRecorder()
+	s.handleStatusWebhook(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413: %s", rec.Code, rec.Body.String())
+	}
+	if code := errorCode(decodeResponse(t, rec)); code != "REQUEST_TOO_LARGE" {
+		t.Errorf("error code = %q, want REQUEST_TOO_LARGE", code)
+	}
+}
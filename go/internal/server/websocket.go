@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+
+	"github.com/globalpayments/pay-by-link-go/internal/logging"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// WebSocket limits
+const (
+	wsMaxSubscriptions = 20               // links one connection may watch at once
+	wsReadLimit        = 4096             // bytes in one client message
+	wsPingInterval     = 30 * time.Second // pings that detect dead connections and keep proxies from closing idle ones
+	wsWriteTimeout     = 10 * time.Second // sending one message or ping
+)
+
+// WebSocket message types
+const (
+	wsSubscribe    = "subscribe"
+	wsUnsubscribe  = "unsubscribe"
+	wsSubscribed   = "subscribed"
+	wsUnsubscribed = "unsubscribed"
+	wsError        = "error"
+)
+
+// wsClientMessage is a message sent by a /ws client
+type wsClientMessage struct {
+	Type   string `json:"type"`
+	LinkID string `json:"linkId"`
+}
+
+// wsServerMessage answers a client message. Link events are sent as webhooks.Event.
+type wsServerMessage struct {
+	Type   string      `json:"type"`
+	LinkID string      `json:"linkId,omitempty"`
+	Link   *store.Link `json:"link,omitempty"`
+	Error  *ErrorInfo  `json:"error,omitempty"`
+}
+
+// handleWebSocket handles the /ws endpoint. Clients subscribe to link IDs and are sent each
+// event for those links as it happens, such as link.paid when the status webhook arrives.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	_, events, ok := s.stream.subscribe("")
+	if !ok {
+		writeError(w, http.StatusServiceUnavailable, "Event stream unavailable", "SHUTTING_DOWN", "The server is shutting down")
+		return
+	}
+	defer s.stream.unsubscribe(events)
+
+	// Hijacked connections keep the deadlines the server set for this request
+	controller := http.NewResponseController(w)
+	if err := controller.SetReadDeadline(time.Time{}); err != nil {
+		logging.FromContext(r.Context()).Warn("WebSocket cannot clear the read deadline", "error", err)
+	}
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		logging.FromContext(r.Context()).Warn("WebSocket cannot clear the write deadline", "error", err)
+	}
+
+	// Browsers on the origins allowed by CORS_ALLOWED_ORIGINS may connect, as well as same-origin pages
+	opts := &websocket.AcceptOptions{}
+	if origin := r.Header.Get("Origin"); origin != "" && allowOrigin(s.cors, origin) != "" {
+		opts.InsecureSkipVerify = true
+	}
+	conn, err := websocket.Accept(w, r, opts)
+	if err != nil {
+		// Accept has already written the error response
+		logging.FromContext(r.Context()).Warn("WebSocket upgrade failed", "error", err)
+		return
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(wsReadLimit)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	messages := make(chan wsClientMessage)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			var msg wsClientMessage
+			if err := wsjson.Read(ctx, conn, &msg); err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case messages <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	subscriptions := make(map[string]struct{})
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		var err error
+		select {
+		case <-readErr:
+			// The client closed the connection, or sent a message that is not JSON and was disconnected
+			return
+		case msg := <-messages:
+			err = s.handleWebSocketMessage(ctx, conn, subscriptions, msg)
+		case event, ok := <-events:
+			if !ok {
+				conn.Close(websocket.StatusGoingAway, "Server shutting down")
+				return
+			}
+			if _, subscribed := subscriptions[event.Data.ID]; subscribed {
+				err = writeWebSocket(ctx, conn, event)
+			}
+		case <-ping.C:
+			pingCtx, cancelPing := context.WithTimeout(ctx, wsWriteTimeout)
+			err = conn.Ping(pingCtx)
+			cancelPing()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handleWebSocketMessage applies a subscribe or unsubscribe message, replying with the
+// link's current state so a client watching an already paid link is told at once
+func (s *Server) handleWebSocketMessage(ctx context.Context, conn *websocket.Conn, subscriptions map[string]struct{}, msg wsClientMessage) error {
+	fail := func(code, details string) error {
+		return writeWebSocket(ctx, conn, wsServerMessage{Type: wsError, LinkID: msg.LinkID, Error: &ErrorInfo{Code: code, Details: details}})
+	}
+
+	if msg.Type != wsSubscribe && msg.Type != wsUnsubscribe {
+		return fail("INVALID_MESSAGE", `type must be "subscribe" or "unsubscribe"`)
+	}
+	if !linkIDPattern.MatchString(msg.LinkID) {
+		return fail("INVALID_LINK_ID", "Invalid payment link ID")
+	}
+
+	if msg.Type == wsUnsubscribe {
+		delete(subscriptions, msg.LinkID)
+		return writeWebSocket(ctx, conn, wsServerMessage{Type: wsUnsubscribed, LinkID: msg.LinkID})
+	}
+
+	if _, subscribed := subscriptions[msg.LinkID]; !subscribed && len(subscriptions) >= wsMaxSubscriptions {
+		return fail("TOO_MANY_SUBSCRIPTIONS", fmt.Sprintf("A connection may watch at most %d payment links", wsMaxSubscriptions))
+	}
+	link, err := s.links.GetLink(ctx, msg.LinkID)
+	if errors.Is(err, store.ErrLinkNotFound) {
+		return fail("LINK_NOT_FOUND", "Only payment links created by this server can be watched")
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Error reading payment link", "link_id", msg.LinkID, "error", err)
+		return fail("STORE_ERROR", "Error reading stored payment links")
+	}
+
+	subscriptions[msg.LinkID] = struct{}{}
+	return writeWebSocket(ctx, conn, wsServerMessage{Type: wsSubscribed, LinkID: msg.LinkID, Link: link})
+}
+
+// writeWebSocket sends v to the client as a JSON text message
+func writeWebSocket(ctx context.Context, conn *websocket.Conn, v interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, wsWriteTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, conn, v)
+}
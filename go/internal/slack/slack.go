@@ -0,0 +1,215 @@
+// Package slack posts messages about created and paid links to a Slack channel through an
+// incoming webhook, so a merchant's team sees payments as they happen.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+	"github.com/globalpayments/pay-by-link-go/internal/webhooks"
+)
+
+// Delivery limits. Slack asks for no more than one message a second, and answers faster
+// bursts with 429 and a Retry-After header.
+const (
+	postTimeout   = 10 * time.Second
+	maxAttempts   = 3
+	retryBackoff  = time.Second
+	maxRetryAfter = 30 * time.Second
+)
+
+// message is the body of an incoming webhook request
+type message struct {
+	Text string `json:"text"`
+	// UnfurlLinks is false so Slack does not fetch the payment page for a preview
+	UnfurlLinks bool `json:"unfurl_links"`
+}
+
+// Notifier posts link events to the channel of one incoming webhook in the background
+type Notifier struct {
+	webhookURL   string
+	events       []string
+	merchantName string
+	http         *http.Client
+
+	wg       sync.WaitGroup
+	stopping chan struct{}
+	stopOnce sync.Once
+}
+
+// NewNotifier creates a Notifier that posts the events in cfg with client, naming
+// merchantName in each message when it is set
+func NewNotifier(cfg config.Slack, merchantName string, client *http.Client) *Notifier {
+	return &Notifier{
+		webhookURL:   cfg.WebhookURL,
+		events:       cfg.Events,
+		merchantName: merchantName,
+		http:         client,
+		stopping:     make(chan struct{}),
+	}
+}
+
+// Wants reports whether events of eventType are posted. It is false on a nil Notifier.
+func (n *Notifier) Wants(eventType string) bool {
+	return n != nil && slices.Contains(n.events, eventType)
+}
+
+// Publish posts a message about link without blocking the caller. Events the Notifier
+// does not want are ignored.
+func (n *Notifier) Publish(eventType string, link *store.Link) {
+	if !n.Wants(eventType) {
+		return
+	}
+	payload, err := json.Marshal(message{Text: n.text(eventType, link)})
+	if err != nil {
+		slog.Error("Error encoding Slack message", "event_type", eventType, "link_id", link.ID, "error", err)
+		return
+	}
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.deliver(eventType, link.ID, payload)
+	}()
+}
+
+// Close stops retrying and waits up to ctx's deadline for messages being posted.
+// It is a no-op on a nil Notifier.
+func (n *Notifier) Close(ctx context.Context) error {
+	if n == nil {
+		return nil
+	}
+	n.stopOnce.Do(func() { close(n.stopping) })
+
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("Slack messages still being posted: %w", ctx.Err())
+	}
+}
+
+// text describes the event in Slack's mrkdwn, such as
+// "*Example Ltd*: payment received, *12.50 EUR* for INV-001 <https://...|LNK_123>"
+func (n *Notifier) text(eventType string, link *store.Link) string {
+	var b strings.Builder
+	if n.merchantName != "" {
+		b.WriteString("*" + escape(n.merchantName) + "*: ")
+	}
+	if eventType == webhooks.EventLinkPaid {
+		b.WriteString("payment received, ")
+	} else {
+		b.WriteString("payment link created for ")
+	}
+	b.WriteString(amount(link))
+	if link.Reference != "" {
+		b.WriteString(" for " + escape(link.Reference))
+	}
+	fmt.Fprintf(&b, " <%s|%s>", escape(link.URL), escape(link.ID))
+	return b.String()
+}
+
+// amount describes what the link is for. Open-amount links let the customer choose,
+// within any bounds set.
+func amount(link *store.Link) string {
+	format := func(minor int64) string {
+		return money.FormatMinorUnits(minor, link.Currency) + " " + link.Currency
+	}
+	switch {
+	case link.Amount > 0:
+		return "*" + format(link.Amount) + "*"
+	case link.MinAmount > 0 && link.MaxAmount > 0:
+		return "an amount from " + format(link.MinAmount) + " to " + format(link.MaxAmount)
+	case link.MinAmount > 0:
+		return "an amount of at least " + format(link.MinAmount)
+	case link.MaxAmount > 0:
+		return "an amount of up to " + format(link.MaxAmount)
+	default:
+		return "an amount in " + link.Currency + " chosen by the customer"
+	}
+}
+
+// escape replaces the characters Slack treats as markup with their HTML entities
+func escape(value string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(value)
+}
+
+// deliver posts payload, retrying failures and rate limiting with backoff. Messages that
+// fail every attempt are logged and dropped, as they only mirror events recorded elsewhere.
+func (n *Notifier) deliver(eventType, linkID string, payload []byte) {
+	logger := slog.With("event_type", eventType, "link_id", linkID)
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		wait, err := n.post(payload)
+		if err == nil {
+			logger.Info("Slack message posted", "attempts", attempt)
+			return
+		}
+		if attempt == maxAttempts {
+			logger.Error("Slack message not posted", "attempts", attempt, "error", err)
+			return
+		}
+		logger.Warn("Slack message failed", "attempt", attempt, "error", err)
+
+		if wait == 0 {
+			wait = backoff
+			backoff *= 2
+		}
+		select {
+		case <-time.After(wait):
+		case <-n.stopping:
+			logger.Error("Slack message not posted before the server shut down", "attempts", attempt, "error", err)
+			return
+		}
+	}
+}
+
+// post sends one attempt, allowing it postTimeout. On a 429 response it returns how long
+// Slack asked to wait before retrying.
+func (n *Notifier) post(payload []byte) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), postTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create Slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send Slack message: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait := retryBackoff
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			wait = min(time.Duration(seconds)*time.Second, maxRetryAfter)
+		}
+		return wait, fmt.Errorf("rate limited by Slack")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// Slack explains rejections in a short plain-text body, such as "invalid_token"
+		return 0, fmt.Errorf("Slack responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return 0, nil
+}
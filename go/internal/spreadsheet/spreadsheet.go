@@ -0,0 +1,22 @@
+// Package spreadsheet guards the text written to files that are opened in spreadsheets,
+// such as CSV exports.
+package spreadsheet
+
+import (
+	"regexp"
+	"strings"
+)
+
+// plainNumber matches text starting with a sign that a spreadsheet reads as a number,
+// such as a phone number, rather than a formula
+var plainNumber = regexp.MustCompile(`^[+-][0-9 ]+$`)
+
+// Text guards text that a spreadsheet would otherwise run as a formula when the file is
+// opened, as references, names, and metadata are chosen by whoever created the link.
+// Such text is prefixed with a single quote.
+func Text(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) && !plainNumber.MatchString(text) {
+		return "'" + text
+	}
+	return text
+}
@@ -0,0 +1,524 @@
+package store
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Columns holding encrypted personal data. Each name is also the additional data its values
+// are encrypted with, so a value copied into another column does not decrypt.
+const (
+	columnLinkPhone           = "payment_links.customer_phone"
+	columnLinkMetadata        = "payment_links.metadata"
+	columnDeliveryRecipient   = "link_deliveries.recipient"
+	columnCustomerEmail       = "customers.email"
+	columnCustomerPhone       = "customers.phone"
+	columnTemplateDescription = "link_templates.description"
+	columnOutboxPayload       = "link_outbox.payload"
+	columnSeriesTemplate      = "link_series.template"
+	columnDeadLetterPayload   = "webhook_dead_letters.payload"
+)
+
+// encryptedPrefix starts every encrypted value. It is followed by the version of the key
+// the value was encrypted with, a colon, and the nonce and ciphertext in base64.
+const encryptedPrefix = "enc:v"
+
+// metadataIndexLabel is hashed with each key to derive the key metadata index entries are made with
+const metadataIndexLabel = "pay-by-link metadata index"
+
+// reencryptBatchSize is how many rows Reencrypt, and dead letter anonymization, read at a time
+const reencryptBatchSize = 500
+
+// ErrEncryptionDisabled is returned when encrypted data is read, or Reencrypt is called,
+// without encryption keys
+var ErrEncryptionDisabled = errors.New("no encryption keys are configured")
+
+// Keyring encrypts personal data in the link store with AES-256-GCM. Values are tagged
+// with the version of the key that encrypted them, so after a rotation data encrypted with
+// older keys can still be read until Reencrypt has moved it to the current one. A nil
+// Keyring stores values as they are.
+type Keyring struct {
+	version int
+	aeads   map[int]cipher.AEAD
+	// indexKeys make the metadata index entries of each version, which let links be
+	// filtered by metadata without decrypting it
+	indexKeys map[int][]byte
+}
+
+// NewKeyring returns a Keyring encrypting with the key of the given version and decrypting
+// with any of keys, or nil when there are no keys
+func NewKeyring(keys map[int][]byte, version int) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	if keys[version] == nil {
+		return nil, fmt.Errorf("no encryption key version %d", version)
+	}
+
+	keyring := &Keyring{version: version, aeads: make(map[int]cipher.AEAD), indexKeys: make(map[int][]byte)}
+	for v, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key version %d: %w", v, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key version %d: %w", v, err)
+		}
+		keyring.aeads[v] = aead
+
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(metadataIndexLabel))
+		keyring.indexKeys[v] = mac.Sum(nil)
+	}
+	return keyring, nil
+}
+
+// Version returns the version of the key new data is encrypted with
+func (k *Keyring) Version() int {
+	return k.version
+}
+
+// tag returns the prefix of values encrypted with the current key
+func (k *Keyring) tag() string {
+	return encryptedPrefix + strconv.Itoa(k.version) + ":"
+}
+
+// encrypt returns value encrypted with the current key for column. Empty values are
+// stored as they are, as is everything when k is nil.
+func (k *Keyring) encrypt(column, value string) (string, error) {
+	if k == nil || value == "" {
+		return value, nil
+	}
+	aead := k.aeads[k.version]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(column))
+	return k.tag() + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt returns the plain text of a value read from column. Values stored before
+// encryption was turned on are returned as they are.
+func (k *Keyring) decrypt(column, value string) (string, error) {
+	version, encoded, ok := parseEncrypted(value)
+	if !ok {
+		return value, nil
+	}
+	if k == nil {
+		return "", fmt.Errorf("%s is encrypted: %w", column, ErrEncryptionDisabled)
+	}
+	aead := k.aeads[version]
+	if aead == nil {
+		return "", fmt.Errorf("%s is encrypted with unknown key version %d", column, version)
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("%s holds a malformed encrypted value", column)
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(column))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s with key version %d: %w", column, version, err)
+	}
+	return string(plain), nil
+}
+
+// parseEncrypted splits an encrypted value into its key version and encoded ciphertext,
+// reporting false for values that are not encrypted
+func parseEncrypted(value string) (version int, encoded string, ok bool) {
+	rest, found := strings.CutPrefix(value, encryptedPrefix)
+	if !found {
+		return 0, "", false
+	}
+	number, encoded, found := strings.Cut(rest, ":")
+	version, err := strconv.Atoi(number)
+	if !found || err != nil {
+		return 0, "", false
+	}
+	return version, encoded, true
+}
+
+// encryptMetadata returns the JSON stored for a link's metadata and its metadata index.
+// Encrypted metadata is stored as a JSON string, so the column still holds valid JSON.
+func (k *Keyring) encryptMetadata(metadata map[string]string) (encoded, index string, err error) {
+	encoded = encodeMetadata(metadata)
+	if k == nil || len(metadata) == 0 {
+		return encoded, "[]", nil
+	}
+	sealed, err := k.encrypt(columnLinkMetadata, encoded)
+	if err != nil {
+		return "", "", err
+	}
+
+	tokens := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		tokens = append(tokens, k.metadataToken(k.version, key, value))
+	}
+	sort.Strings(tokens)
+	// Strings always encode
+	quoted, _ := json.Marshal(sealed)
+	indexJSON, _ := json.Marshal(tokens)
+	return string(quoted), string(indexJSON), nil
+}
+
+// decryptMetadata reads a link's stored metadata, decrypting it when it is encrypted
+func (k *Keyring) decryptMetadata(stored []byte) (map[string]string, error) {
+	if len(stored) > 0 && stored[0] == '"' {
+		var sealed string
+		if err := json.Unmarshal(stored, &sealed); err != nil {
+			return nil, fmt.Errorf("failed to decode link metadata: %w", err)
+		}
+		plain, err := k.decrypt(columnLinkMetadata, sealed)
+		if err != nil {
+			return nil, err
+		}
+		stored = []byte(plain)
+	}
+	return decodeMetadata(stored)
+}
+
+// decryptOutboxPayload reads the link stored with an outbox event
+func (k *Keyring) decryptOutboxPayload(payload string) (*Link, error) {
+	plain, err := k.decrypt(columnOutboxPayload, payload)
+	if err != nil {
+		return nil, err
+	}
+	var link Link
+	if err := json.Unmarshal([]byte(plain), &link); err != nil {
+		return nil, fmt.Errorf("failed to decode outbox event: %w", err)
+	}
+	return &link, nil
+}
+
+// scrubSeriesTemplate returns a series' stored link creation request, which may be
+// encrypted, with the fields that identify the customer removed
+func (k *Keyring) scrubSeriesTemplate(stored string) (string, error) {
+	template, err := k.decrypt(columnSeriesTemplate, stored)
+	if err != nil {
+		return "", err
+	}
+	scrubbed, err := scrubSeriesTemplate(template)
+	if err != nil {
+		return "", err
+	}
+	return k.encrypt(columnSeriesTemplate, scrubbed)
+}
+
+// metadataTokens returns the metadata index entries a link with key set to value may have,
+// one for each key version so links encrypted before a rotation are still found. It
+// returns none when k is nil.
+func (k *Keyring) metadataTokens(key, value string) []string {
+	if k == nil {
+		return nil
+	}
+	tokens := make([]string, 0, len(k.indexKeys))
+	for version := range k.indexKeys {
+		tokens = append(tokens, k.metadataToken(version, key, value))
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// metadataToken returns the metadata index entry for key set to value with a key version
+func (k *Keyring) metadataToken(version int, key, value string) string {
+	mac := hmac.New(sha256.New, k.indexKeys[version])
+	mac.Write([]byte(key))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// Reencryption counts the rows Reencrypt rewrote in each table
+type Reencryption struct {
+	Links       int64 `json:"links"`
+	Deliveries  int64 `json:"deliveries"`
+	Customers   int64 `json:"customers"`
+	Templates   int64 `json:"templates"`
+	Outbox      int64 `json:"outbox"`
+	Series      int64 `json:"series"`
+	DeadLetters int64 `json:"deadLetters"`
+}
+
+// sqlDialect covers the differences between the SQL of the stores that re-encryption needs
+type sqlDialect struct {
+	// placeholder returns the nth bound parameter, counting from 1
+	placeholder func(n int) string
+	// jsonText reads a JSON column as text, and jsonValue writes text to one
+	jsonText  func(column string) string
+	jsonValue func(param string) string
+}
+
+// encryptedColumn is a column of personal data that Reencrypt rewrites
+type encryptedColumn struct {
+	name string
+	sql  string
+	// metadata columns hold JSON, with a metadata_index column alongside
+	metadata bool
+}
+
+// encryptedTable lists a table's columns of personal data, and where Reencrypt counts its rows
+type encryptedTable struct {
+	name    string
+	columns []encryptedColumn
+	count   func(*Reencryption) *int64
+}
+
+// encryptedTables lists every table with encrypted columns
+var encryptedTables = []encryptedTable{
+	{
+		name:    "payment_links",
+		columns: []encryptedColumn{{name: columnLinkPhone, sql: "customer_phone"}, {name: columnLinkMetadata, sql: "metadata", metadata: true}},
+		count:   func(r *Reencryption) *int64 { return &r.Links },
+	},
+	{
+		name:    "link_deliveries",
+		columns: []encryptedColumn{{name: columnDeliveryRecipient, sql: "recipient"}},
+		count:   func(r *Reencryption) *int64 { return &r.Deliveries },
+	},
+	{
+		name:    "customers",
+		columns: []encryptedColumn{{name: columnCustomerEmail, sql: "email"}, {name: columnCustomerPhone, sql: "phone"}},
+		count:   func(r *Reencryption) *int64 { return &r.Customers },
+	},
+	{
+		name:    "link_templates",
+		columns: []encryptedColumn{{name: columnTemplateDescription, sql: "description"}},
+		count:   func(r *Reencryption) *int64 { return &r.Templates },
+	},
+	{
+		name:    "link_outbox",
+		columns: []encryptedColumn{{name: columnOutboxPayload, sql: "payload"}},
+		count:   func(r *Reencryption) *int64 { return &r.Outbox },
+	},
+	{
+		name:    "link_series",
+		columns: []encryptedColumn{{name: columnSeriesTemplate, sql: "template"}},
+		count:   func(r *Reencryption) *int64 { return &r.Series },
+	},
+	{
+		name:    "webhook_dead_letters",
+		columns: []encryptedColumn{{name: columnDeadLetterPayload, sql: "payload"}},
+		count:   func(r *Reencryption) *int64 { return &r.DeadLetters },
+	},
+}
+
+// reencrypt rewrites every value in the encrypted columns that is still in plain text or
+// encrypted with an older key, and counts the rows changed in each table
+func (k *Keyring) reencrypt(ctx context.Context, db *sql.DB, dialect sqlDialect) (*Reencryption, error) {
+	if k == nil {
+		return nil, ErrEncryptionDisabled
+	}
+	result := &Reencryption{}
+	for _, table := range encryptedTables {
+		rewritten, err := k.reencryptTable(ctx, db, dialect, table)
+		*table.count(result) += rewritten
+		if err != nil {
+			return result, fmt.Errorf("failed to re-encrypt %s: %w", table.name, err)
+		}
+	}
+	return result, nil
+}
+
+// reencryptTable rewrites the rows of table with a value not encrypted with the current
+// key, a batch at a time, until none are left
+func (k *Keyring) reencryptTable(ctx context.Context, db *sql.DB, dialect sqlDialect, table encryptedTable) (int64, error) {
+	var args []interface{}
+	param := func(value interface{}) string {
+		args = append(args, value)
+		return dialect.placeholder(len(args))
+	}
+
+	reads := make([]string, len(table.columns))
+	stale := make([]string, len(table.columns))
+	for i, column := range table.columns {
+		if column.metadata {
+			reads[i] = dialect.jsonText(column.sql)
+			stale[i] = fmt.Sprintf(`(%s <> '{}' AND %s NOT LIKE %s)`, reads[i], reads[i], param(`"`+k.tag()+`%`))
+		} else {
+			reads[i] = column.sql
+			stale[i] = fmt.Sprintf(`(%s <> '' AND %s NOT LIKE %s)`, reads[i], reads[i], param(k.tag()+`%`))
+		}
+	}
+	query := `SELECT id, ` + strings.Join(reads, ", ") + ` FROM ` + table.name +
+		` WHERE ` + strings.Join(stale, " OR ") + ` LIMIT ` + param(reencryptBatchSize)
+
+	type staleRow struct {
+		id     interface{}
+		values []string
+	}
+	var rewritten int64
+	for {
+		// Read the whole batch before updating, as SQLite has a single connection
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return rewritten, err
+		}
+		var batch []staleRow
+		for rows.Next() {
+			row := staleRow{values: make([]string, len(table.columns))}
+			dest := []interface{}{&row.id}
+			for i := range row.values {
+				dest = append(dest, &row.values[i])
+			}
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return rewritten, err
+			}
+			// Text keys may be read as bytes, which would not match the column again
+			if id, ok := row.id.([]byte); ok {
+				row.id = string(id)
+			}
+			batch = append(batch, row)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return rewritten, err
+		}
+		if len(batch) == 0 {
+			return rewritten, nil
+		}
+
+		var changed int64
+		for _, row := range batch {
+			n, err := k.reencryptRow(ctx, db, dialect, table, reads, row.id, row.values)
+			if err != nil {
+				return rewritten, fmt.Errorf("row %v: %w", row.id, err)
+			}
+			changed += n
+		}
+		rewritten += changed
+		if changed == 0 {
+			return rewritten, errors.New("rows changed while being re-encrypted; run it again")
+		}
+	}
+}
+
+// reencryptRow rewrites one row's encrypted columns with the current key, unless they have
+// changed since they were read, such as by an erasure, and returns how many rows it changed
+func (k *Keyring) reencryptRow(ctx context.Context, db *sql.DB, dialect sqlDialect, table encryptedTable, reads []string, id interface{}, values []string) (int64, error) {
+	var args []interface{}
+	param := func(value interface{}) string {
+		args = append(args, value)
+		return dialect.placeholder(len(args))
+	}
+
+	sets := make([]string, 0, len(table.columns)+1)
+	matches := make([]string, 0, len(table.columns))
+	for i, column := range table.columns {
+		if column.metadata {
+			metadata, err := k.decryptMetadata([]byte(values[i]))
+			if err != nil {
+				return 0, err
+			}
+			encoded, index, err := k.encryptMetadata(metadata)
+			if err != nil {
+				return 0, err
+			}
+			sets = append(sets, column.sql+` = `+dialect.jsonValue(param(encoded)), `metadata_index = `+dialect.jsonValue(param(index)))
+		} else {
+			plain, err := k.decrypt(column.name, values[i])
+			if err != nil {
+				return 0, err
+			}
+			sealed, err := k.encrypt(column.name, plain)
+			if err != nil {
+				return 0, err
+			}
+			sets = append(sets, column.sql+` = `+param(sealed))
+		}
+	}
+	// Parameters are bound in the order they appear, so the matches follow every change and
+	// the row's key comes last
+	for i := range table.columns {
+		matches = append(matches, reads[i]+` = `+param(values[i]))
+	}
+
+	result, err := db.ExecContext(ctx,
+		`UPDATE `+table.name+` SET `+strings.Join(sets, ", ")+` WHERE `+strings.Join(matches, " AND ")+` AND id = `+param(id),
+		args...,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// anonymizeDeadLetters removes the link fields that identify the customer from the payloads
+// of dead letters created before cutoff, which is bound as the store binds times, and counts
+// the payloads changed. Payloads may be encrypted, so each is rewritten here rather than in SQL.
+func (k *Keyring) anonymizeDeadLetters(ctx context.Context, db *sql.DB, dialect sqlDialect, cutoff interface{}) (int64, error) {
+	query := `SELECT id, payload FROM webhook_dead_letters WHERE created_at < ` + dialect.placeholder(1) +
+		` AND NOT anonymized AND id > ` + dialect.placeholder(2) + ` ORDER BY id LIMIT ` + dialect.placeholder(3)
+	update := `UPDATE webhook_dead_letters SET payload = ` + dialect.placeholder(1) + `, customer_id = '', anonymized = TRUE
+		WHERE id = ` + dialect.placeholder(2) + ` AND payload = ` + dialect.placeholder(3)
+
+	type pendingLetter struct {
+		id      int64
+		payload string
+	}
+	var anonymized, lastID int64
+	for {
+		// Read the whole batch before updating, as SQLite has a single connection
+		rows, err := db.QueryContext(ctx, query, cutoff, lastID, reencryptBatchSize)
+		if err != nil {
+			return anonymized, err
+		}
+		var batch []pendingLetter
+		for rows.Next() {
+			var letter pendingLetter
+			if err := rows.Scan(&letter.id, &letter.payload); err != nil {
+				rows.Close()
+				return anonymized, err
+			}
+			batch = append(batch, letter)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return anonymized, err
+		}
+		if len(batch) == 0 {
+			return anonymized, nil
+		}
+
+		for _, letter := range batch {
+			lastID = letter.id
+			plain, err := k.decrypt(columnDeadLetterPayload, letter.payload)
+			if err != nil {
+				return anonymized, fmt.Errorf("dead letter %d: %w", letter.id, err)
+			}
+			scrubbed, changed, err := anonymizeDeadLetterPayload(plain)
+			if err != nil {
+				return anonymized, fmt.Errorf("dead letter %d: %w", letter.id, err)
+			}
+			sealed := letter.payload
+			if changed {
+				if sealed, err = k.encrypt(columnDeadLetterPayload, scrubbed); err != nil {
+					return anonymized, err
+				}
+			}
+			// A letter deleted or replayed since it was read is left alone
+			result, err := db.ExecContext(ctx, update, sealed, letter.id, letter.payload)
+			if err != nil {
+				return anonymized, fmt.Errorf("dead letter %d: %w", letter.id, err)
+			}
+			if n, _ := result.RowsAffected(); n > 0 && changed {
+				anonymized++
+			}
+		}
+	}
+}
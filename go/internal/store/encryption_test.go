@@ -0,0 +1,270 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testKey1 = bytes.Repeat([]byte{1}, 32)
+	testKey2 = bytes.Repeat([]byte{2}, 32)
+)
+
+// newTestKeyring returns a keyring encrypting with version of keys, failing the test on error
+func newTestKeyring(t *testing.T, keys map[int][]byte, version int) *Keyring {
+	t.Helper()
+	keyring, err := NewKeyring(keys, version)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return keyring
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    map[int][]byte
+		version int
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "no keys disables encryption", keys: nil, version: 1, wantNil: true},
+		{name: "one key", keys: map[int][]byte{1: testKey1}, version: 1},
+		{name: "rotated keys", keys: map[int][]byte{1: testKey1, 2: testKey2}, version: 2},
+		{name: "current version missing", keys: map[int][]byte{1: testKey1}, version: 2, wantErr: true},
+		{name: "key too short", keys: map[int][]byte{1: testKey1[:7]}, version: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := NewKeyring(tt.keys, tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewKeyring() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (keyring == nil) != tt.wantNil {
+				t.Errorf("NewKeyring() = %v, want nil %v", keyring, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestKeyringRoundTrip(t *testing.T) {
+	keyring := newTestKeyring(t, map[int][]byte{1: testKey1}, 1)
+	values := []string{"+447700900123", "jane@example.com", "Ünïcödé ✓ 日本語", strings.Repeat("x", 4096)}
+	for _, value := range values {
+		sealed, err := keyring.encrypt(columnCustomerEmail, value)
+		if err != nil {
+			t.Fatalf("encrypt(%q): %v", value, err)
+		}
+		if !strings.HasPrefix(sealed, "enc:v1:") || strings.Contains(sealed, value) {
+			t.Errorf("encrypt(%q) = %q, want ciphertext tagged enc:v1:", value, sealed)
+		}
+		again, _ := keyring.encrypt(columnCustomerEmail, value)
+		if again == sealed {
+			t.Errorf("encrypt(%q) twice gave the same ciphertext; nonces must be random", value)
+		}
+		plain, err := keyring.decrypt(columnCustomerEmail, sealed)
+		if err != nil || plain != value {
+			t.Errorf("decrypt(encrypt(%q)) = %q, %v", value, plain, err)
+		}
+	}
+
+	// Empty values are stored as they are
+	if sealed, err := keyring.encrypt(columnCustomerEmail, ""); err != nil || sealed != "" {
+		t.Errorf("encrypt(\"\") = %q, %v, want \"\"", sealed, err)
+	}
+}
+
+func TestKeyringDecrypt(t *testing.T) {
+	v1 := newTestKeyring(t, map[int][]byte{1: testKey1}, 1)
+	sealed, err := v1.encrypt(columnCustomerEmail, "jane@example.com")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	// Replace one character in the middle of the ciphertext so a single byte changes
+	flipped := byte('A')
+	if sealed[len(sealed)/2] == flipped {
+		flipped = 'B'
+	}
+	tampered := sealed[:len(sealed)/2] + string(flipped) + sealed[len(sealed)/2+1:]
+
+	tests := []struct {
+		name    string
+		keyring *Keyring
+		column  string
+		value   string
+		want    string
+		wantErr error
+		anyErr  bool
+	}{
+		{name: "current key", keyring: v1, column: columnCustomerEmail, value: sealed, want: "jane@example.com"},
+		{name: "older key after rotation", keyring: newTestKeyring(t, map[int][]byte{1: testKey1, 2: testKey2}, 2),
+			column: columnCustomerEmail, value: sealed, want: "jane@example.com"},
+		{name: "plain text stored before encryption", keyring: v1, column: columnCustomerEmail, value: "jane@example.com", want: "jane@example.com"},
+		{name: "plain text without keys", keyring: nil, column: columnCustomerEmail, value: "jane@example.com", want: "jane@example.com"},
+		{name: "encrypted without keys", keyring: nil, column: columnCustomerEmail, value: sealed, wantErr: ErrEncryptionDisabled},
+		{name: "copied to another column", keyring: v1, column: columnCustomerPhone, value: sealed, anyErr: true},
+		{name: "retired key", keyring: newTestKeyring(t, map[int][]byte{2: testKey2}, 2), column: columnCustomerEmail, value: sealed, anyErr: true},
+		{name: "different key with the same version", keyring: newTestKeyring(t, map[int][]byte{1: testKey2}, 1),
+			column: columnCustomerEmail, value: sealed, anyErr: true},
+		{name: "tampered ciphertext", keyring: v1, column: columnCustomerEmail, value: tampered, anyErr: true},
+		{name: "malformed base64", keyring: v1, column: columnCustomerEmail, value: encryptedPrefix + "1:!!!", anyErr: true},
+		{name: "shorter than a nonce", keyring: v1, column: columnCustomerEmail, value: encryptedPrefix + "1:AAAA", anyErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.keyring.decrypt(tt.column, tt.value)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("decrypt() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.anyErr:
+				if err == nil {
+					t.Errorf("decrypt() = %q, want an error", got)
+				}
+			case err != nil || got != tt.want:
+				t.Errorf("decrypt() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyringMetadata(t *testing.T) {
+	metadata := map[string]string{"orderId": "A-1001", "campaign": "spring"}
+
+	tests := []struct {
+		name      string
+		keyring   *Keyring
+		wantIndex bool
+	}{
+		{name: "encrypted", keyring: newTestKeyring(t, map[int][]byte{1: testKey1}, 1), wantIndex: true},
+		{name: "without keys", keyring: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, index, err := tt.keyring.encryptMetadata(metadata)
+			if err != nil {
+				t.Fatalf("encryptMetadata: %v", err)
+			}
+			if tt.wantIndex == strings.Contains(encoded, "A-1001") {
+				t.Errorf("encryptMetadata() stored %s", encoded)
+			}
+			decoded, err := tt.keyring.decryptMetadata([]byte(encoded))
+			if err != nil || len(decoded) != 2 || decoded["orderId"] != "A-1001" || decoded["campaign"] != "spring" {
+				t.Errorf("decryptMetadata(encryptMetadata()) = %v, %v, want %v", decoded, err, metadata)
+			}
+
+			// The index holds a blind token for each pair, which is what a filter looks for
+			for key, value := range metadata {
+				tokens := tt.keyring.metadataTokens(key, value)
+				if tt.wantIndex != (len(tokens) == 1 && strings.Contains(index, tokens[0])) {
+					t.Errorf("index %s, tokens for %s=%s %v", index, key, value, tokens)
+				}
+			}
+			if !tt.wantIndex && index != "[]" {
+				t.Errorf("index = %s, want []", index)
+			}
+		})
+	}
+}
+
+func TestMetadataTokens(t *testing.T) {
+	v1 := newTestKeyring(t, map[int][]byte{1: testKey1}, 1)
+	rotated := newTestKeyring(t, map[int][]byte{1: testKey1, 2: testKey2}, 2)
+	token := v1.metadataToken(1, "orderId", "A-1001")
+
+	if again := v1.metadataToken(1, "orderId", "A-1001"); again != token {
+		t.Errorf("metadataToken is not deterministic: %s then %s", token, again)
+	}
+	if strings.Contains(token, "A-1001") || len(token) != 32 {
+		t.Errorf("metadataToken() = %q, want 32 hex characters revealing nothing", token)
+	}
+
+	// Tokens differ for every change of key, value, or key version, and the key and value
+	// are separated so they cannot run into each other
+	others := map[string]string{
+		"another value":     v1.metadataToken(1, "orderId", "A-1002"),
+		"another key":       v1.metadataToken(1, "orderID", "A-1001"),
+		"shifted separator": v1.metadataToken(1, "orderIdA", "-1001"),
+		"another version":   rotated.metadataToken(2, "orderId", "A-1001"),
+	}
+	for name, other := range others {
+		if other == token {
+			t.Errorf("%s gave the same token %s", name, token)
+		}
+	}
+
+	// After a rotation, filters look for the tokens of every version, so links indexed with
+	// an older key are still found
+	tokens := rotated.metadataTokens("orderId", "A-1001")
+	if len(tokens) != 2 || (tokens[0] != token && tokens[1] != token) {
+		t.Errorf("metadataTokens() after rotation = %v, want 2 including %s", tokens, token)
+	}
+	var none *Keyring
+	if tokens := none.metadataTokens("orderId", "A-1001"); tokens != nil {
+		t.Errorf("metadataTokens() without keys = %v, want none", tokens)
+	}
+}
+
+func TestSQLiteEncryptedLink(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "links.db")
+	links, err := NewSQLiteLinkStore(path, newTestKeyring(t, map[int][]byte{1: testKey1}, 1))
+	if err != nil {
+		t.Fatalf("NewSQLiteLinkStore: %v", err)
+	}
+	link := &Link{ID: "LNK_1", URL: "https://pay.example/LNK_1", Reference: "ORDER-1", Amount: 1000, Currency: "EUR",
+		Status: LinkStatusActive, CustomerPhone: "+447700900123", ExpiresAt: time.Now().Add(time.Hour),
+		Metadata: map[string]string{"orderId": "A-1001"}}
+	if err := links.CreateLink(ctx, link, nil); err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+
+	// Personal data is encrypted at rest
+	var phone, metadata string
+	if err := links.db.QueryRowContext(ctx, `SELECT customer_phone, metadata FROM payment_links WHERE id = 'LNK_1'`).Scan(&phone, &metadata); err != nil {
+		t.Fatalf("reading stored link: %v", err)
+	}
+	if strings.Contains(phone, "7700900123") || strings.Contains(metadata, "A-1001") {
+		t.Errorf("stored phone %q and metadata %q in the clear", phone, metadata)
+	}
+	links.Close()
+
+	// After a rotation the link still reads back and is found by its metadata through the
+	// index made with the old key
+	links, err = NewSQLiteLinkStore(path, newTestKeyring(t, map[int][]byte{1: testKey1, 2: testKey2}, 2))
+	if err != nil {
+		t.Fatalf("NewSQLiteLinkStore: %v", err)
+	}
+	defer links.Close()
+	got, err := links.GetLink(ctx, "LNK_1")
+	if err != nil {
+		t.Fatalf("GetLink: %v", err)
+	}
+	if got.CustomerPhone != link.CustomerPhone || got.Metadata["orderId"] != "A-1001" {
+		t.Errorf("GetLink() = phone %q, metadata %v", got.CustomerPhone, got.Metadata)
+	}
+
+	tests := []struct {
+		metadata map[string]string
+		want     int
+	}{
+		{map[string]string{"orderId": "A-1001"}, 1},
+		{map[string]string{"orderId": "A-1002"}, 0},
+		{map[string]string{"orderId": "A-1001", "campaign": "spring"}, 0},
+	}
+	for _, tt := range tests {
+		found, err := links.ListLinks(ctx, LinkFilter{Metadata: tt.metadata, Limit: 10})
+		if err != nil {
+			t.Fatalf("ListLinks(%v): %v", tt.metadata, err)
+		}
+		if len(found) != tt.want {
+			t.Errorf("ListLinks(%v) found %d links, want %d", tt.metadata, len(found), tt.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+CREATE TABLE payment_links (
+	id                 TEXT PRIMARY KEY,
+	url                TEXT NOT NULL,
+	reference          TEXT NOT NULL,
+	amount             BIGINT NOT NULL,
+	currency           TEXT NOT NULL,
+	status             TEXT NOT NULL,
+	transaction_id     TEXT NOT NULL DEFAULT '',
+	transaction_status TEXT NOT NULL DEFAULT '',
+	customer_phone     TEXT NOT NULL DEFAULT '',
+	expires_at         TIMESTAMPTZ NOT NULL,
+	created_at         TIMESTAMPTZ NOT NULL,
+	updated_at         TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_payment_links_reference ON payment_links (reference);
+CREATE INDEX idx_payment_links_status ON payment_links (status);
+CREATE INDEX idx_payment_links_created_at ON payment_links (created_at, id);
+
+CREATE TABLE link_deliveries (
+	id          BIGSERIAL PRIMARY KEY,
+	link_id     TEXT NOT NULL,
+	channel     TEXT NOT NULL,
+	recipient   TEXT NOT NULL,
+	provider_id TEXT NOT NULL DEFAULT '',
+	status      TEXT NOT NULL,
+	error       TEXT NOT NULL DEFAULT '',
+	created_at  TIMESTAMPTZ NOT NULL,
+	updated_at  TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_link_deliveries_link_id ON link_deliveries (link_id);
+CREATE INDEX idx_link_deliveries_provider_id ON link_deliveries (provider_id);
@@ -0,0 +1,11 @@
+CREATE TABLE webhook_dead_letters (
+	id         BIGSERIAL PRIMARY KEY,
+	event_id   TEXT NOT NULL,
+	event_type TEXT NOT NULL,
+	url        TEXT NOT NULL,
+	payload    TEXT NOT NULL,
+	attempts   INTEGER NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_webhook_dead_letters_event_id ON webhook_dead_letters (event_id);
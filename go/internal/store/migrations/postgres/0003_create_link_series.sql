@@ -0,0 +1,21 @@
+CREATE TABLE link_series (
+	id          TEXT PRIMARY KEY,
+	reference   TEXT NOT NULL,
+	cadence     TEXT NOT NULL,
+	occurrences INTEGER NOT NULL,
+	template    TEXT NOT NULL,
+	created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE series_installments (
+	series_id  TEXT NOT NULL REFERENCES link_series (id),
+	number     INTEGER NOT NULL,
+	reference  TEXT NOT NULL,
+	due_at     TIMESTAMPTZ NOT NULL,
+	link_id    TEXT NOT NULL DEFAULT '',
+	attempts   INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	updated_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (series_id, number)
+);
+CREATE INDEX idx_series_installments_due_at ON series_installments (due_at);
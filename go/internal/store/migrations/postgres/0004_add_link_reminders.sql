@@ -0,0 +1,3 @@
+ALTER TABLE payment_links ADD COLUMN reminders_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE payment_links ADD COLUMN reminders_sent INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE payment_links ADD COLUMN last_reminder_at TIMESTAMPTZ;
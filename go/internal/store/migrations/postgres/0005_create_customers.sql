@@ -0,0 +1,11 @@
+CREATE TABLE customers (
+	id         TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	email      TEXT NOT NULL DEFAULT '',
+	phone      TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);
+
+ALTER TABLE payment_links ADD COLUMN customer_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX idx_payment_links_customer_id ON payment_links (customer_id, created_at, id);
@@ -0,0 +1,12 @@
+CREATE TABLE link_templates (
+	id              TEXT PRIMARY KEY,
+	name            TEXT NOT NULL,
+	amount          TEXT NOT NULL DEFAULT '',
+	currency        TEXT NOT NULL DEFAULT '',
+	description     TEXT NOT NULL DEFAULT '',
+	usage_mode      TEXT NOT NULL DEFAULT '',
+	usage_limit     TEXT NOT NULL DEFAULT '',
+	expiration_days TEXT NOT NULL DEFAULT '',
+	created_at      TIMESTAMPTZ NOT NULL,
+	updated_at      TIMESTAMPTZ NOT NULL
+);
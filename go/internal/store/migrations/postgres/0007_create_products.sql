@@ -0,0 +1,8 @@
+CREATE TABLE products (
+	sku        TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	unit_price BIGINT NOT NULL,
+	currency   TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,6 @@
+CREATE TABLE promo_code_uses (
+	code TEXT PRIMARY KEY,
+	uses INTEGER NOT NULL DEFAULT 0
+);
+ALTER TABLE payment_links ADD COLUMN promo_code TEXT NOT NULL DEFAULT '';
+ALTER TABLE payment_links ADD COLUMN discount_amount BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE payment_links ADD COLUMN tax_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE payment_links ADD COLUMN tax_rate TEXT NOT NULL DEFAULT '';
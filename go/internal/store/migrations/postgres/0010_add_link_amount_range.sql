@@ -0,0 +1,2 @@
+ALTER TABLE payment_links ADD COLUMN min_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE payment_links ADD COLUMN max_amount BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE payment_links ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+CREATE INDEX payment_links_metadata_idx ON payment_links USING GIN (metadata jsonb_path_ops);
@@ -0,0 +1,4 @@
+CREATE TABLE reference_sequences (
+	scope TEXT PRIMARY KEY,
+	value BIGINT NOT NULL
+);
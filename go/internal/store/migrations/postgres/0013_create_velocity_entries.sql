@@ -0,0 +1,9 @@
+CREATE TABLE velocity_entries (
+	scope      TEXT NOT NULL,
+	key        TEXT NOT NULL,
+	amount     BIGINT NOT NULL,
+	currency   TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_velocity_entries_key ON velocity_entries (scope, key, created_at);
+CREATE INDEX idx_velocity_entries_created_at ON velocity_entries (created_at);
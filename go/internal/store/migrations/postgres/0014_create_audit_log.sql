@@ -0,0 +1,24 @@
+CREATE TABLE audit_log (
+	id             BIGINT PRIMARY KEY,
+	action         TEXT NOT NULL,
+	actor          TEXT NOT NULL,
+	request_id     TEXT NOT NULL DEFAULT '',
+	resource       TEXT NOT NULL DEFAULT '',
+	link_id        TEXT NOT NULL DEFAULT '',
+	transaction_id TEXT NOT NULL DEFAULT '',
+	changes        TEXT NOT NULL,
+	created_at     TIMESTAMPTZ NOT NULL,
+	prev_hash      TEXT NOT NULL,
+	hash           TEXT NOT NULL
+);
+CREATE INDEX idx_audit_log_created_at ON audit_log (created_at);
+CREATE INDEX idx_audit_log_link_id ON audit_log (link_id);
+
+-- The audit log is append-only: rows can be inserted, never changed or removed
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+	FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,2 @@
+ALTER TABLE payment_links ADD COLUMN metadata_index JSONB NOT NULL DEFAULT '[]';
+CREATE INDEX payment_links_metadata_index_idx ON payment_links USING GIN (metadata_index jsonb_path_ops);
@@ -0,0 +1,11 @@
+ALTER TABLE payment_links ADD COLUMN short_code TEXT NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX idx_payment_links_short_code ON payment_links (short_code) WHERE short_code <> '';
+
+CREATE TABLE link_views (
+	id         BIGSERIAL PRIMARY KEY,
+	link_id    TEXT NOT NULL,
+	user_agent TEXT NOT NULL DEFAULT '',
+	referrer   TEXT NOT NULL DEFAULT '',
+	viewed_at  TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_link_views_link_id ON link_views (link_id, id);
@@ -0,0 +1,9 @@
+CREATE TABLE link_receipts (
+	link_id        TEXT PRIMARY KEY,
+	transaction_id TEXT NOT NULL,
+	amount         BIGINT NOT NULL,
+	currency       TEXT NOT NULL,
+	card_brand     TEXT NOT NULL DEFAULT '',
+	card_last4     TEXT NOT NULL DEFAULT '',
+	paid_at        TIMESTAMPTZ NOT NULL
+);
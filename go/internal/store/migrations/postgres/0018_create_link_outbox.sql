@@ -0,0 +1,10 @@
+CREATE TABLE link_outbox (
+	id           BIGSERIAL PRIMARY KEY,
+	event_id     TEXT NOT NULL,
+	event_type   TEXT NOT NULL,
+	link_id      TEXT NOT NULL,
+	payload      TEXT NOT NULL,
+	created_at   TIMESTAMPTZ NOT NULL,
+	attempts     INTEGER NOT NULL DEFAULT 0,
+	locked_until TIMESTAMPTZ
+);
@@ -0,0 +1,8 @@
+ALTER TABLE link_series ADD COLUMN customer_id TEXT NOT NULL DEFAULT '';
+UPDATE link_series SET customer_id = COALESCE(template::jsonb ->> 'customerId', '');
+CREATE INDEX idx_link_series_customer_id ON link_series (customer_id);
+
+ALTER TABLE webhook_dead_letters ADD COLUMN customer_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhook_dead_letters ADD COLUMN anonymized BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE webhook_dead_letters SET customer_id = COALESCE(payload::jsonb -> 'data' ->> 'customerId', '');
+CREATE INDEX idx_webhook_dead_letters_customer_id ON webhook_dead_letters (customer_id);
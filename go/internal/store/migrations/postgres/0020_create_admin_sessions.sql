@@ -0,0 +1,7 @@
+CREATE TABLE admin_sessions (
+	token_hash   TEXT PRIMARY KEY,
+	api_key_hash TEXT NOT NULL,
+	expires_at   TIMESTAMPTZ NOT NULL,
+	created_at   TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_admin_sessions_expires_at ON admin_sessions (expires_at);
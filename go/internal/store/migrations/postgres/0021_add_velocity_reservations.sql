@@ -0,0 +1,2 @@
+ALTER TABLE velocity_entries ADD COLUMN reservation TEXT NOT NULL DEFAULT '';
+CREATE INDEX idx_velocity_entries_reservation ON velocity_entries (reservation);
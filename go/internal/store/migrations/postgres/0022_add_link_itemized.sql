@@ -0,0 +1 @@
+ALTER TABLE payment_links ADD COLUMN itemized BOOLEAN NOT NULL DEFAULT FALSE;
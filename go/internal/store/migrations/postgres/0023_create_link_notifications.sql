@@ -0,0 +1,10 @@
+CREATE TABLE link_notifications (
+	transaction_id     TEXT NOT NULL,
+	transaction_status TEXT NOT NULL,
+	link_id            TEXT NOT NULL,
+	received_at        TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (transaction_id, transaction_status)
+);
+CREATE INDEX idx_link_notifications_link_id ON link_notifications (link_id);
+INSERT INTO link_notifications (transaction_id, transaction_status, link_id, received_at)
+	SELECT transaction_id, transaction_status, id, updated_at FROM payment_links WHERE transaction_id <> '';
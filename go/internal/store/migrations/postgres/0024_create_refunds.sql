@@ -0,0 +1,8 @@
+CREATE TABLE refunds (
+	reservation    TEXT PRIMARY KEY,
+	transaction_id TEXT NOT NULL,
+	amount         BIGINT NOT NULL,
+	currency       TEXT NOT NULL,
+	created_at     TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_refunds_transaction_id ON refunds (transaction_id);
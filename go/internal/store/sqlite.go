@@ -0,0 +1,1954 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteTimeLayout stores timestamps as fixed-width UTC strings so they sort correctly
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000Z"
+
+// sqliteMigrations are applied in order; PRAGMA user_version records how many have run
+var sqliteMigrations = []string{
+	`CREATE TABLE IF NOT EXISTS payment_links (
+		id                 TEXT PRIMARY KEY,
+		url                TEXT NOT NULL,
+		reference          TEXT NOT NULL,
+		amount             INTEGER NOT NULL,
+		currency           TEXT NOT NULL,
+		status             TEXT NOT NULL,
+		transaction_id     TEXT NOT NULL DEFAULT '',
+		transaction_status TEXT NOT NULL DEFAULT '',
+		expires_at         TEXT NOT NULL,
+		created_at         TEXT NOT NULL,
+		updated_at         TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_payment_links_reference ON payment_links (reference);
+	CREATE INDEX IF NOT EXISTS idx_payment_links_status ON payment_links (status);
+	CREATE INDEX IF NOT EXISTS idx_payment_links_created_at ON payment_links (created_at, id);`,
+
+	`ALTER TABLE payment_links ADD COLUMN customer_phone TEXT NOT NULL DEFAULT '';
+	CREATE TABLE link_deliveries (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		link_id     TEXT NOT NULL,
+		channel     TEXT NOT NULL,
+		recipient   TEXT NOT NULL,
+		provider_id TEXT NOT NULL DEFAULT '',
+		status      TEXT NOT NULL,
+		error       TEXT NOT NULL DEFAULT '',
+		created_at  TEXT NOT NULL,
+		updated_at  TEXT NOT NULL
+	);
+	CREATE INDEX idx_link_deliveries_link_id ON link_deliveries (link_id);
+	CREATE INDEX idx_link_deliveries_provider_id ON link_deliveries (provider_id);`,
+
+	`CREATE TABLE webhook_dead_letters (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id   TEXT NOT NULL,
+		event_type TEXT NOT NULL,
+		url        TEXT NOT NULL,
+		payload    TEXT NOT NULL,
+		attempts   INTEGER NOT NULL,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL
+	);
+	CREATE INDEX idx_webhook_dead_letters_event_id ON webhook_dead_letters (event_id);`,
+
+	`CREATE TABLE link_series (
+		id          TEXT PRIMARY KEY,
+		reference   TEXT NOT NULL,
+		cadence     TEXT NOT NULL,
+		occurrences INTEGER NOT NULL,
+		template    TEXT NOT NULL,
+		created_at  TEXT NOT NULL
+	);
+	CREATE TABLE series_installments (
+		series_id  TEXT NOT NULL REFERENCES link_series (id),
+		number     INTEGER NOT NULL,
+		reference  TEXT NOT NULL,
+		due_at     TEXT NOT NULL,
+		link_id    TEXT NOT NULL DEFAULT '',
+		attempts   INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		updated_at TEXT NOT NULL,
+		PRIMARY KEY (series_id, number)
+	);
+	CREATE INDEX idx_series_installments_due_at ON series_installments (due_at);`,
+
+	`ALTER TABLE payment_links ADD COLUMN reminders_opt_out INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE payment_links ADD COLUMN reminders_sent INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE payment_links ADD COLUMN last_reminder_at TEXT NOT NULL DEFAULT '';`,
+
+	`CREATE TABLE customers (
+		id         TEXT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		email      TEXT NOT NULL DEFAULT '',
+		phone      TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+	ALTER TABLE payment_links ADD COLUMN customer_id TEXT NOT NULL DEFAULT '';
+	CREATE INDEX idx_payment_links_customer_id ON payment_links (customer_id, created_at, id);`,
+
+	`CREATE TABLE link_templates (
+		id              TEXT PRIMARY KEY,
+		name            TEXT NOT NULL,
+		amount          TEXT NOT NULL DEFAULT '',
+		currency        TEXT NOT NULL DEFAULT '',
+		description     TEXT NOT NULL DEFAULT '',
+		usage_mode      TEXT NOT NULL DEFAULT '',
+		usage_limit     TEXT NOT NULL DEFAULT '',
+		expiration_days TEXT NOT NULL DEFAULT '',
+		created_at      TEXT NOT NULL,
+		updated_at      TEXT NOT NULL
+	);`,
+
+	`CREATE TABLE products (
+		sku        TEXT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		unit_price INTEGER NOT NULL,
+		currency   TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);`,
+
+	`CREATE TABLE promo_code_uses (
+		code TEXT PRIMARY KEY,
+		uses INTEGER NOT NULL DEFAULT 0
+	);
+	ALTER TABLE payment_links ADD COLUMN promo_code TEXT NOT NULL DEFAULT '';
+	ALTER TABLE payment_links ADD COLUMN discount_amount INTEGER NOT NULL DEFAULT 0;`,
+
+	`ALTER TABLE payment_links ADD COLUMN tax_amount INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE payment_links ADD COLUMN tax_rate TEXT NOT NULL DEFAULT '';`,
+
+	`ALTER TABLE payment_links ADD COLUMN min_amount INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE payment_links ADD COLUMN max_amount INTEGER NOT NULL DEFAULT 0;`,
+
+	`ALTER TABLE payment_links ADD COLUMN metadata TEXT NOT NULL DEFAULT '{}';`,
+
+	`CREATE TABLE reference_sequences (
+		scope TEXT PRIMARY KEY,
+		value INTEGER NOT NULL
+	);`,
+
+	`CREATE TABLE velocity_entries (
+		scope      TEXT NOT NULL,
+		key        TEXT NOT NULL,
+		amount     INTEGER NOT NULL,
+		currency   TEXT NOT NULL,
+		created_at TEXT NOT NULL
+	);
+	CREATE INDEX idx_velocity_entries_key ON velocity_entries (scope, key, created_at);
+	CREATE INDEX idx_velocity_entries_created_at ON velocity_entries (created_at);`,
+
+	`CREATE TABLE audit_log (
+		id             INTEGER PRIMARY KEY,
+		action         TEXT NOT NULL,
+		actor          TEXT NOT NULL,
+		request_id     TEXT NOT NULL DEFAULT '',
+		resource       TEXT NOT NULL DEFAULT '',
+		link_id        TEXT NOT NULL DEFAULT '',
+		transaction_id TEXT NOT NULL DEFAULT '',
+		changes        TEXT NOT NULL,
+		created_at     TEXT NOT NULL,
+		prev_hash      TEXT NOT NULL,
+		hash           TEXT NOT NULL
+	);
+	CREATE INDEX idx_audit_log_created_at ON audit_log (created_at);
+	CREATE INDEX idx_audit_log_link_id ON audit_log (link_id);
+	CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit_log is append-only');
+	END;
+	CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit_log is append-only');
+	END;`,
+
+	`ALTER TABLE payment_links ADD COLUMN metadata_index TEXT NOT NULL DEFAULT '[]';`,
+
+	`ALTER TABLE payment_links ADD COLUMN short_code TEXT NOT NULL DEFAULT '';
+	CREATE UNIQUE INDEX idx_payment_links_short_code ON payment_links (short_code) WHERE short_code <> '';
+	CREATE TABLE link_views (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		link_id    TEXT NOT NULL,
+		user_agent TEXT NOT NULL DEFAULT '',
+		referrer   TEXT NOT NULL DEFAULT '',
+		viewed_at  TEXT NOT NULL
+	);
+	CREATE INDEX idx_link_views_link_id ON link_views (link_id, id);`,
+
+	`CREATE TABLE link_receipts (
+		link_id        TEXT PRIMARY KEY,
+		transaction_id TEXT NOT NULL,
+		amount         INTEGER NOT NULL,
+		currency       TEXT NOT NULL,
+		card_brand     TEXT NOT NULL DEFAULT '',
+		card_last4     TEXT NOT NULL DEFAULT '',
+		paid_at        TEXT NOT NULL
+	);`,
+
+	`CREATE TABLE link_outbox (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id     TEXT NOT NULL,
+		event_type   TEXT NOT NULL,
+		link_id      TEXT NOT NULL,
+		payload      TEXT NOT NULL,
+		created_at   TEXT NOT NULL,
+		attempts     INTEGER NOT NULL DEFAULT 0,
+		locked_until TEXT NOT NULL DEFAULT ''
+	);`,
+
+	`ALTER TABLE link_series ADD COLUMN customer_id TEXT NOT NULL DEFAULT '';
+	UPDATE link_series SET customer_id = COALESCE(json_extract(template, '$.customerId'), '');
+	CREATE INDEX idx_link_series_customer_id ON link_series (customer_id);
+	ALTER TABLE webhook_dead_letters ADD COLUMN customer_id TEXT NOT NULL DEFAULT '';
+	ALTER TABLE webhook_dead_letters ADD COLUMN anonymized INTEGER NOT NULL DEFAULT 0;
+	UPDATE webhook_dead_letters SET customer_id = COALESCE(json_extract(payload, '$.data.customerId'), '');
+	CREATE INDEX idx_webhook_dead_letters_customer_id ON webhook_dead_letters (customer_id);`,
+
+	`CREATE TABLE admin_sessions (
+		token_hash   TEXT PRIMARY KEY,
+		api_key_hash TEXT NOT NULL,
+		expires_at   TEXT NOT NULL,
+		created_at   TEXT NOT NULL
+	);
+	CREATE INDEX idx_admin_sessions_expires_at ON admin_sessions (expires_at);`,
+
+	`ALTER TABLE velocity_entries ADD COLUMN reservation TEXT NOT NULL DEFAULT '';
+	CREATE INDEX idx_velocity_entries_reservation ON velocity_entries (reservation);`,
+
+	`ALTER TABLE payment_links ADD COLUMN itemized INTEGER NOT NULL DEFAULT 0;`,
+
+	`CREATE TABLE link_notifications (
+		transaction_id     TEXT NOT NULL,
+		transaction_status TEXT NOT NULL,
+		link_id            TEXT NOT NULL,
+		received_at        TEXT NOT NULL,
+		PRIMARY KEY (transaction_id, transaction_status)
+	);
+	CREATE INDEX idx_link_notifications_link_id ON link_notifications (link_id);
+	INSERT INTO link_notifications (transaction_id, transaction_status, link_id, received_at)
+		SELECT transaction_id, transaction_status, id, updated_at FROM payment_links WHERE transaction_id <> '';`,
+
+	`CREATE TABLE refunds (
+		reservation    TEXT PRIMARY KEY,
+		transaction_id TEXT NOT NULL,
+		amount         INTEGER NOT NULL,
+		currency       TEXT NOT NULL,
+		created_at     TEXT NOT NULL
+	);
+	CREATE INDEX idx_refunds_transaction_id ON refunds (transaction_id);`,
+}
+
+// auditColumns lists the audit_log columns in the order scanAuditEntry expects
+const auditColumns = `id, action, actor, request_id, resource, link_id, transaction_id, changes, created_at, prev_hash, hash`
+
+// linkColumns lists the payment_links columns in the order scanLink expects
+const linkColumns = `id, url, reference, amount, currency, status, transaction_id, transaction_status, customer_phone, expires_at, created_at, updated_at,
+	reminders_opt_out, reminders_sent, last_reminder_at, customer_id, promo_code, discount_amount, tax_amount, tax_rate, min_amount, max_amount, metadata, short_code, itemized`
+
+// sqliteDialect is the SQL SQLite is re-encrypted with
+var sqliteDialect = sqlDialect{
+	placeholder: func(int) string { return "?" },
+	jsonText:    func(column string) string { return column },
+	jsonValue:   func(param string) string { return param },
+}
+
+// SQLiteLinkStore is a LinkStore backed by a SQLite database file
+type SQLiteLinkStore struct {
+	db   *sql.DB
+	keys *Keyring
+}
+
+// NewSQLiteLinkStore opens (creating if needed) the SQLite database at path and applies the
+// schema. Personal data is encrypted with keys, unless it is nil.
+func NewSQLiteLinkStore(path string, keys *Keyring) (*SQLiteLinkStore, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	// SQLite allows a single writer; serialising connections avoids SQLITE_BUSY errors
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteLinkStore{db: db, keys: keys}, nil
+}
+
+// migrateSQLite applies any migrations the database has not yet run
+func migrateSQLite(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read SQLite schema version: %w", err)
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to start SQLite migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply SQLite migration %d: %w", i+1, err)
+		}
+		// PRAGMA statements cannot take bound parameters
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record SQLite migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit SQLite migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// CreateLink implements LinkStore
+func (s *SQLiteLinkStore) CreateLink(ctx context.Context, link *Link, event *OutboxEvent) error {
+	now := time.Now().UTC()
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = now
+	}
+	link.UpdatedAt = now
+
+	phone, err := s.keys.encrypt(columnLinkPhone, link.CustomerPhone)
+	if err != nil {
+		return err
+	}
+	metadata, index, err := s.keys.encryptMetadata(link.Metadata)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start inserting payment link: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO payment_links (`+linkColumns+`, metadata_index) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		link.ID, link.URL, link.Reference, link.Amount, link.Currency, link.Status,
+		link.TransactionID, link.TransactionStatus, phone,
+		formatSQLiteTime(link.ExpiresAt), formatSQLiteTime(link.CreatedAt), formatSQLiteTime(link.UpdatedAt),
+		link.RemindersOptOut, link.RemindersSent, formatOptionalSQLiteTime(link.LastReminderAt), link.CustomerID,
+		link.PromoCode, link.DiscountAmount, link.TaxAmount, link.TaxRate, link.MinAmount, link.MaxAmount,
+		metadata, link.ShortCode, link.Itemized, index,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert payment link: %w", err)
+	}
+	if err := s.insertOutboxEvent(ctx, tx, link.ID, event); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit payment link: %w", err)
+	}
+	return nil
+}
+
+// GetLink implements LinkStore
+func (s *SQLiteLinkStore) GetLink(ctx context.Context, id string) (*Link, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+linkColumns+` FROM payment_links WHERE id = ?`, id)
+	link, err := s.scanLink(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLinkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payment link: %w", err)
+	}
+	return link, nil
+}
+
+// GetLinkByShortCode implements LinkStore
+func (s *SQLiteLinkStore) GetLinkByShortCode(ctx context.Context, code string) (*Link, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+linkColumns+` FROM payment_links WHERE short_code = ? AND short_code <> ''`, code)
+	link, err := s.scanLink(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLinkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payment link: %w", err)
+	}
+	return link, nil
+}
+
+// ListLinks implements LinkStore
+func (s *SQLiteLinkStore) ListLinks(ctx context.Context, filter LinkFilter) ([]*Link, error) {
+	query := `SELECT ` + linkColumns + ` FROM payment_links WHERE 1 = 1`
+	var args []interface{}
+
+	if filter.Reference != "" {
+		query += ` AND reference = ?`
+		args = append(args, filter.Reference)
+	}
+	if filter.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, filter.Status)
+	}
+	if filter.Currency != "" {
+		query += ` AND currency = ?`
+		args = append(args, filter.Currency)
+	}
+	if filter.TransactionID != "" {
+		query += ` AND transaction_id = ?`
+		args = append(args, filter.TransactionID)
+	}
+	if filter.CustomerID != "" {
+		query += ` AND customer_id = ?`
+		args = append(args, filter.CustomerID)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query += ` AND created_at >= ?`
+		args = append(args, formatSQLiteTime(filter.CreatedFrom))
+	}
+	if !filter.CreatedTo.IsZero() {
+		query += ` AND created_at <= ?`
+		args = append(args, formatSQLiteTime(filter.CreatedTo))
+	}
+	if !filter.ExpiresBefore.IsZero() {
+		query += ` AND expires_at < ?`
+		args = append(args, formatSQLiteTime(filter.ExpiresBefore))
+	}
+	for _, key := range sortedKeys(filter.Metadata) {
+		clause := `EXISTS (SELECT 1 FROM json_each(payment_links.metadata) WHERE json_each.key = ? AND json_each.value = ?)`
+		args = append(args, key, filter.Metadata[key])
+		// Encrypted metadata is matched through its index instead
+		if tokens := s.keys.metadataTokens(key, filter.Metadata[key]); len(tokens) > 0 {
+			clause = `(` + clause + ` OR EXISTS (SELECT 1 FROM json_each(payment_links.metadata_index) WHERE json_each.value IN (?` +
+				strings.Repeat(`, ?`, len(tokens)-1) + `)))`
+			for _, token := range tokens {
+				args = append(args, token)
+			}
+		}
+		query += ` AND ` + clause
+	}
+	if filter.After != nil {
+		createdAt := formatSQLiteTime(filter.After.CreatedAt)
+		query += ` AND (created_at < ? OR (created_at = ? AND id < ?))`
+		args = append(args, createdAt, createdAt, filter.After.ID)
+	}
+
+	query += ` ORDER BY created_at DESC, id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []*Link{}
+	for rows.Next() {
+		link, err := s.scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read payment link: %w", err)
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list payment links: %w", err)
+	}
+	return links, nil
+}
+
+// UpdateStatus implements LinkStore
+func (s *SQLiteLinkStore) UpdateStatus(ctx context.Context, id, status string, event *OutboxEvent) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start updating payment link status: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE payment_links SET status = ?, updated_at = ? WHERE id = ?`,
+		status, formatSQLiteTime(time.Now()), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update payment link status: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrLinkNotFound
+	}
+	if err := s.insertOutboxEvent(ctx, tx, id, event); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit payment link status: %w", err)
+	}
+	return nil
+}
+
+// UpdateLink implements LinkStore
+func (s *SQLiteLinkStore) UpdateLink(ctx context.Context, id string, update LinkUpdate) error {
+	query := `UPDATE payment_links SET updated_at = ?`
+	args := []interface{}{formatSQLiteTime(time.Now())}
+	if update.Amount != nil {
+		query += `, amount = ?`
+		args = append(args, *update.Amount)
+	}
+	if update.ExpiresAt != nil {
+		query += `, expires_at = ?`
+		args = append(args, formatSQLiteTime(*update.ExpiresAt))
+	}
+	query += ` WHERE id = ?`
+	args = append(args, id)
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update payment link: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// RecordTransaction implements LinkStore
+func (s *SQLiteLinkStore) RecordTransaction(ctx context.Context, id string, paid bool, transactionID, transactionStatus string, event *OutboxEvent) (*Link, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start updating payment link: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO link_notifications (transaction_id, transaction_status, link_id, received_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT (transaction_id, transaction_status) DO NOTHING`,
+		transactionID, transactionStatus, id, formatSQLiteTime(time.Now()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record transaction notification: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return nil, ErrTransactionRecorded
+	}
+
+	result, err = tx.ExecContext(ctx,
+		`UPDATE payment_links SET status = CASE WHEN ? THEN ? ELSE status END, transaction_id = ?, transaction_status = ?, updated_at = ?
+		 WHERE id = ? AND status <> ?`,
+		paid, LinkStatusPaid, transactionID, transactionStatus, formatSQLiteTime(time.Now()), id, LinkStatusPaid,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update payment link: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		var exists bool
+		err := tx.QueryRowContext(ctx, `SELECT 1 FROM payment_links WHERE id = ?`, id).Scan(&exists)
+		if err := transactionNotRecorded(err); !errors.Is(err, ErrLinkUnchanged) {
+			return nil, err
+		}
+		// The notification is remembered even though the paid link keeps its transaction, so
+		// it is recognised if it is delivered again
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction notification: %w", err)
+		}
+		return nil, ErrLinkUnchanged
+	}
+	link, err := s.scanLink(tx.QueryRowContext(ctx, `SELECT `+linkColumns+` FROM payment_links WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment link: %w", err)
+	}
+	if event != nil {
+		if err := s.writeOutboxEvent(ctx, tx, link, event); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit payment link: %w", err)
+	}
+	return link, nil
+}
+
+// UpdateTransactionStatus implements LinkStore
+func (s *SQLiteLinkStore) UpdateTransactionStatus(ctx context.Context, id, transactionID, transactionStatus string, from ...string) error {
+	query := `UPDATE payment_links SET transaction_status = ?, updated_at = ? WHERE id = ? AND transaction_id = ?`
+	args := []interface{}{transactionStatus, formatSQLiteTime(time.Now()), id, transactionID}
+	if len(from) > 0 {
+		query += ` AND transaction_status IN (?` + strings.Repeat(", ?", len(from)-1) + `)`
+		for _, status := range from {
+			args = append(args, status)
+		}
+	}
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update payment link transaction: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// RecordReminder implements LinkStore
+func (s *SQLiteLinkStore) RecordReminder(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE payment_links SET reminders_sent = reminders_sent + 1, last_reminder_at = ? WHERE id = ?`,
+		formatSQLiteTime(time.Now()), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record payment reminder: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// SetRemindersOptOut implements LinkStore
+func (s *SQLiteLinkStore) SetRemindersOptOut(ctx context.Context, id string, optOut bool) (*Link, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE payment_links SET reminders_opt_out = ?, updated_at = ? WHERE id = ?`,
+		optOut, formatSQLiteTime(time.Now()), id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update payment link reminders: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return nil, ErrLinkNotFound
+	}
+	return s.GetLink(ctx, id)
+}
+
+// PruneLinks implements LinkStore
+func (s *SQLiteLinkStore) PruneLinks(ctx context.Context, updatedBefore time.Time) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start pruning payment links: %w", err)
+	}
+	defer tx.Rollback()
+
+	cutoff := formatSQLiteTime(updatedBefore)
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM link_deliveries WHERE link_id IN (
+			SELECT id FROM payment_links WHERE status <> ? AND updated_at < ?)`,
+		LinkStatusActive, cutoff,
+	); err != nil {
+		return 0, fmt.Errorf("failed to prune deliveries: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM link_views WHERE link_id IN (
+			SELECT id FROM payment_links WHERE status <> ? AND updated_at < ?)`,
+		LinkStatusActive, cutoff,
+	); err != nil {
+		return 0, fmt.Errorf("failed to prune link views: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM link_receipts WHERE link_id IN (
+			SELECT id FROM payment_links WHERE status <> ? AND updated_at < ?)`,
+		LinkStatusActive, cutoff,
+	); err != nil {
+		return 0, fmt.Errorf("failed to prune receipts: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM link_notifications WHERE link_id IN (
+			SELECT id FROM payment_links WHERE status <> ? AND updated_at < ?)`,
+		LinkStatusActive, cutoff,
+	); err != nil {
+		return 0, fmt.Errorf("failed to prune transaction notifications: %w", err)
+	}
+	result, err := tx.ExecContext(ctx,
+		`DELETE FROM payment_links WHERE status <> ? AND updated_at < ?`,
+		LinkStatusActive, cutoff,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune payment links: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit pruned payment links: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// AnonymizeLinks implements LinkStore
+func (s *SQLiteLinkStore) AnonymizeLinks(ctx context.Context, updatedBefore time.Time) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start anonymizing payment links: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The links keep their update time, so anonymizing them does not restart their retention
+	cutoff := formatSQLiteTime(updatedBefore)
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE link_deliveries SET recipient = '' WHERE recipient <> '' AND link_id IN (
+			SELECT id FROM payment_links WHERE status <> ? AND updated_at < ?)`,
+		LinkStatusActive, cutoff,
+	); err != nil {
+		return 0, fmt.Errorf("failed to anonymize deliveries: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE link_views SET user_agent = '', referrer = '' WHERE (user_agent <> '' OR referrer <> '') AND link_id IN (
+			SELECT id FROM payment_links WHERE status <> ? AND updated_at < ?)`,
+		LinkStatusActive, cutoff,
+	); err != nil {
+		return 0, fmt.Errorf("failed to anonymize link views: %w", err)
+	}
+	result, err := tx.ExecContext(ctx,
+		`UPDATE payment_links SET reference = '', customer_phone = '', metadata = '{}', metadata_index = '[]', customer_id = ''
+		 WHERE status <> ? AND updated_at < ?
+		 AND (reference <> '' OR customer_phone <> '' OR metadata <> '{}' OR customer_id <> '')`,
+		LinkStatusActive, cutoff,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to anonymize payment links: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit anonymized payment links: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// CreateDelivery implements LinkStore
+func (s *SQLiteLinkStore) CreateDelivery(ctx context.Context, delivery *Delivery) error {
+	now := time.Now().UTC()
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+
+	recipient, err := s.keys.encrypt(columnDeliveryRecipient, delivery.Recipient)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO link_deliveries (link_id, channel, recipient, provider_id, status, error, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		delivery.LinkID, delivery.Channel, recipient, delivery.ProviderID, delivery.Status, delivery.Error,
+		formatSQLiteTime(delivery.CreatedAt), formatSQLiteTime(delivery.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert delivery: %w", err)
+	}
+	delivery.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// UpdateDeliveryStatus implements LinkStore
+func (s *SQLiteLinkStore) UpdateDeliveryStatus(ctx context.Context, providerID, status, deliveryError string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE link_deliveries SET status = ?, error = ?, updated_at = ? WHERE provider_id = ?`,
+		status, deliveryError, formatSQLiteTime(time.Now()), providerID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update delivery status: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// ListDeliveries implements LinkStore
+func (s *SQLiteLinkStore) ListDeliveries(ctx context.Context, linkID string) ([]*Delivery, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, link_id, channel, recipient, provider_id, status, error, created_at, updated_at
+		 FROM link_deliveries WHERE link_id = ? ORDER BY id`,
+		linkID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*Delivery{}
+	for rows.Next() {
+		var delivery Delivery
+		var createdAt, updatedAt string
+		err := rows.Scan(&delivery.ID, &delivery.LinkID, &delivery.Channel, &delivery.Recipient,
+			&delivery.ProviderID, &delivery.Status, &delivery.Error, &createdAt, &updatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read delivery: %w", err)
+		}
+		if delivery.Recipient, err = s.keys.decrypt(columnDeliveryRecipient, delivery.Recipient); err != nil {
+			return nil, err
+		}
+		delivery.CreatedAt = parseSQLiteTime(createdAt)
+		delivery.UpdatedAt = parseSQLiteTime(updatedAt)
+		deliveries = append(deliveries, &delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RecordLinkView implements LinkStore
+func (s *SQLiteLinkStore) RecordLinkView(ctx context.Context, view *LinkView) error {
+	view.ViewedAt = time.Now().UTC()
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO link_views (link_id, user_agent, referrer, viewed_at) VALUES (?, ?, ?, ?)`,
+		view.LinkID, view.UserAgent, view.Referrer, formatSQLiteTime(view.ViewedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert link view: %w", err)
+	}
+	view.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// ListLinkViews implements LinkStore
+func (s *SQLiteLinkStore) ListLinkViews(ctx context.Context, linkID string, limit int) ([]*LinkView, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, link_id, user_agent, referrer, viewed_at FROM link_views WHERE link_id = ? ORDER BY id DESC LIMIT ?`,
+		linkID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list link views: %w", err)
+	}
+	defer rows.Close()
+
+	views := []*LinkView{}
+	for rows.Next() {
+		var view LinkView
+		var viewedAt string
+		if err := rows.Scan(&view.ID, &view.LinkID, &view.UserAgent, &view.Referrer, &viewedAt); err != nil {
+			return nil, fmt.Errorf("failed to read link view: %w", err)
+		}
+		view.ViewedAt = parseSQLiteTime(viewedAt)
+		views = append(views, &view)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list link views: %w", err)
+	}
+	return views, nil
+}
+
+// SaveReceipt implements LinkStore
+func (s *SQLiteLinkStore) SaveReceipt(ctx context.Context, receipt *Receipt) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO link_receipts (link_id, transaction_id, amount, currency, card_brand, card_last4, paid_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (link_id) DO UPDATE SET transaction_id = excluded.transaction_id, amount = excluded.amount,
+			currency = excluded.currency, card_brand = excluded.card_brand, card_last4 = excluded.card_last4, paid_at = excluded.paid_at`,
+		receipt.LinkID, receipt.TransactionID, receipt.Amount, receipt.Currency, receipt.CardBrand, receipt.CardLast4,
+		formatSQLiteTime(receipt.PaidAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save receipt: %w", err)
+	}
+	return nil
+}
+
+// GetReceipt implements LinkStore
+func (s *SQLiteLinkStore) GetReceipt(ctx context.Context, linkID string) (*Receipt, error) {
+	var receipt Receipt
+	var paidAt string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT link_id, transaction_id, amount, currency, card_brand, card_last4, paid_at FROM link_receipts WHERE link_id = ?`,
+		linkID,
+	).Scan(&receipt.LinkID, &receipt.TransactionID, &receipt.Amount, &receipt.Currency, &receipt.CardBrand, &receipt.CardLast4, &paidAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReceiptNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt: %w", err)
+	}
+	receipt.PaidAt = parseSQLiteTime(paidAt)
+	return &receipt, nil
+}
+
+// CreateAdminSession implements LinkStore
+func (s *SQLiteLinkStore) CreateAdminSession(ctx context.Context, session *AdminSession) error {
+	session.CreatedAt = time.Now().UTC()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start recording admin session: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM admin_sessions WHERE expires_at <= ?`, formatSQLiteTime(session.CreatedAt)); err != nil {
+		return fmt.Errorf("failed to delete expired admin sessions: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO admin_sessions (token_hash, api_key_hash, expires_at, created_at) VALUES (?, ?, ?, ?)`,
+		session.TokenHash, session.APIKeyHash, formatSQLiteTime(session.ExpiresAt), formatSQLiteTime(session.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert admin session: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit admin session: %w", err)
+	}
+	return nil
+}
+
+// GetAdminSession implements LinkStore
+func (s *SQLiteLinkStore) GetAdminSession(ctx context.Context, tokenHash string) (*AdminSession, error) {
+	var session AdminSession
+	var expiresAt, createdAt string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT token_hash, api_key_hash, expires_at, created_at FROM admin_sessions WHERE token_hash = ? AND expires_at > ?`,
+		tokenHash, formatSQLiteTime(time.Now().UTC()),
+	).Scan(&session.TokenHash, &session.APIKeyHash, &expiresAt, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAdminSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read admin session: %w", err)
+	}
+	session.ExpiresAt = parseSQLiteTime(expiresAt)
+	session.CreatedAt = parseSQLiteTime(createdAt)
+	return &session, nil
+}
+
+// DeleteAdminSession implements LinkStore
+func (s *SQLiteLinkStore) DeleteAdminSession(ctx context.Context, tokenHash string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM admin_sessions WHERE token_hash = ?`, tokenHash); err != nil {
+		return fmt.Errorf("failed to delete admin session: %w", err)
+	}
+	return nil
+}
+
+// CreateWebhookDeadLetter implements LinkStore
+func (s *SQLiteLinkStore) CreateWebhookDeadLetter(ctx context.Context, letter *WebhookDeadLetter) error {
+	letter.CreatedAt = time.Now().UTC()
+
+	payload, err := s.keys.encrypt(columnDeadLetterPayload, letter.Payload)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO webhook_dead_letters (event_id, event_type, url, payload, customer_id, attempts, last_error, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		letter.EventID, letter.EventType, letter.URL, payload, deadLetterCustomerID(letter.Payload),
+		letter.Attempts, letter.LastError, formatSQLiteTime(letter.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook dead letter: %w", err)
+	}
+	letter.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// deadLetterColumns lists the webhook_dead_letters columns in the order scanDeadLetter expects
+const deadLetterColumns = `id, event_id, event_type, url, payload, attempts, last_error, created_at`
+
+// ListWebhookDeadLetters implements LinkStore
+func (s *SQLiteLinkStore) ListWebhookDeadLetters(ctx context.Context, filter DeadLetterFilter) ([]*WebhookDeadLetter, error) {
+	query := `SELECT ` + deadLetterColumns + ` FROM webhook_dead_letters WHERE 1 = 1`
+	var args []interface{}
+	if filter.EventType != "" {
+		query += ` AND event_type = ?`
+		args = append(args, filter.EventType)
+	}
+	if filter.URL != "" {
+		query += ` AND url = ?`
+		args = append(args, filter.URL)
+	}
+	if filter.BeforeID > 0 {
+		query += ` AND id < ?`
+		args = append(args, filter.BeforeID)
+	}
+	query += ` ORDER BY id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	letters := []*WebhookDeadLetter{}
+	for rows.Next() {
+		letter, err := s.scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read webhook dead letter: %w", err)
+		}
+		letters = append(letters, letter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list webhook dead letters: %w", err)
+	}
+	return letters, nil
+}
+
+// GetWebhookDeadLetter implements LinkStore
+func (s *SQLiteLinkStore) GetWebhookDeadLetter(ctx context.Context, id int64) (*WebhookDeadLetter, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+deadLetterColumns+` FROM webhook_dead_letters WHERE id = ?`, id)
+	letter, err := s.scanDeadLetter(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook dead letter: %w", err)
+	}
+	return letter, nil
+}
+
+// RecordWebhookDeadLetterRetry implements LinkStore
+func (s *SQLiteLinkStore) RecordWebhookDeadLetterRetry(ctx context.Context, id int64, lastError string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE webhook_dead_letters SET attempts = attempts + 1, last_error = ? WHERE id = ?`, lastError, id)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook dead letter retry: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+// DeleteWebhookDeadLetter implements LinkStore
+func (s *SQLiteLinkStore) DeleteWebhookDeadLetter(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM webhook_dead_letters WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook dead letter: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+// scanDeadLetter reads a webhook_dead_letters row selected with deadLetterColumns,
+// decrypting its payload
+func (s *SQLiteLinkStore) scanDeadLetter(row rowScanner) (*WebhookDeadLetter, error) {
+	var letter WebhookDeadLetter
+	var createdAt string
+	err := row.Scan(&letter.ID, &letter.EventID, &letter.EventType, &letter.URL, &letter.Payload,
+		&letter.Attempts, &letter.LastError, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if letter.Payload, err = s.keys.decrypt(columnDeadLetterPayload, letter.Payload); err != nil {
+		return nil, err
+	}
+	letter.CreatedAt = parseSQLiteTime(createdAt)
+	return &letter, nil
+}
+
+// insertOutboxEvent records event for the link with id, as the transaction has left it.
+// It does nothing when event is nil.
+func (s *SQLiteLinkStore) insertOutboxEvent(ctx context.Context, tx *sql.Tx, id string, event *OutboxEvent) error {
+	if event == nil {
+		return nil
+	}
+	// The transaction holds the only connection, so the link is read through it
+	link, err := s.scanLink(tx.QueryRowContext(ctx, `SELECT `+linkColumns+` FROM payment_links WHERE id = ?`, id))
+	if err != nil {
+		return fmt.Errorf("failed to get payment link for outbox event: %w", err)
+	}
+	return s.writeOutboxEvent(ctx, tx, link, event)
+}
+
+// writeOutboxEvent inserts event about link into the outbox, encrypting the link as it
+// holds the customer's personal data
+func (s *SQLiteLinkStore) writeOutboxEvent(ctx context.Context, tx *sql.Tx, link *Link, event *OutboxEvent) error {
+	event.LinkID = link.ID
+	event.Link = link
+	payload, err := json.Marshal(link)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox event: %w", err)
+	}
+	sealed, err := s.keys.encrypt(columnOutboxPayload, string(payload))
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO link_outbox (event_id, event_type, link_id, payload, created_at) VALUES (?, ?, ?, ?, ?)`,
+		event.EventID, event.EventType, event.LinkID, sealed, formatSQLiteTime(event.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert outbox event: %w", err)
+	}
+	event.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// ClaimOutboxEvents implements LinkStore
+func (s *SQLiteLinkStore) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]*OutboxEvent, error) {
+	now := time.Now().UTC()
+	rows, err := s.db.QueryContext(ctx,
+		`UPDATE link_outbox SET locked_until = ?, attempts = attempts + 1
+		 WHERE id IN (SELECT id FROM link_outbox WHERE locked_until < ? ORDER BY id LIMIT ?)
+		 RETURNING id, event_id, event_type, link_id, payload, created_at, attempts`,
+		formatSQLiteTime(now.Add(lease)), formatSQLiteTime(now), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*OutboxEvent
+	for rows.Next() {
+		var event OutboxEvent
+		var payload, createdAt string
+		if err := rows.Scan(&event.ID, &event.EventID, &event.EventType, &event.LinkID, &payload, &createdAt, &event.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		if event.Link, err = s.keys.decryptOutboxPayload(payload); err != nil {
+			return nil, fmt.Errorf("outbox event %d: %w", event.ID, err)
+		}
+		event.CreatedAt = parseSQLiteTime(createdAt)
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	// RETURNING gives no guarantee of order
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+// DeleteOutboxEvent implements LinkStore
+func (s *SQLiteLinkStore) DeleteOutboxEvent(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM link_outbox WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete outbox event: %w", err)
+	}
+	return nil
+}
+
+// PruneWebhookDeadLetters implements LinkStore
+func (s *SQLiteLinkStore) PruneWebhookDeadLetters(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM webhook_dead_letters WHERE created_at < ?`, formatSQLiteTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune webhook dead letters: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// AnonymizeWebhookDeadLetters implements LinkStore
+func (s *SQLiteLinkStore) AnonymizeWebhookDeadLetters(ctx context.Context, before time.Time) (int64, error) {
+	anonymized, err := s.keys.anonymizeDeadLetters(ctx, s.db, sqliteDialect, formatSQLiteTime(before))
+	if err != nil {
+		return anonymized, fmt.Errorf("failed to anonymize webhook dead letters: %w", err)
+	}
+	return anonymized, nil
+}
+
+// CreateCustomer implements LinkStore
+func (s *SQLiteLinkStore) CreateCustomer(ctx context.Context, customer *Customer) error {
+	now := time.Now().UTC()
+	customer.CreatedAt = now
+	customer.UpdatedAt = now
+
+	email, err := s.keys.encrypt(columnCustomerEmail, customer.Email)
+	if err != nil {
+		return err
+	}
+	phone, err := s.keys.encrypt(columnCustomerPhone, customer.Phone)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO customers (id, name, email, phone, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		customer.ID, customer.Name, email, phone, formatSQLiteTime(now), formatSQLiteTime(now),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert customer: %w", err)
+	}
+	return nil
+}
+
+// GetCustomer implements LinkStore
+func (s *SQLiteLinkStore) GetCustomer(ctx context.Context, id string) (*Customer, error) {
+	var customer Customer
+	var createdAt, updatedAt string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, name, email, phone, created_at, updated_at FROM customers WHERE id = ?`, id,
+	).Scan(&customer.ID, &customer.Name, &customer.Email, &customer.Phone, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read customer: %w", err)
+	}
+	if customer.Email, err = s.keys.decrypt(columnCustomerEmail, customer.Email); err != nil {
+		return nil, err
+	}
+	if customer.Phone, err = s.keys.decrypt(columnCustomerPhone, customer.Phone); err != nil {
+		return nil, err
+	}
+	customer.CreatedAt = parseSQLiteTime(createdAt)
+	customer.UpdatedAt = parseSQLiteTime(updatedAt)
+	return &customer, nil
+}
+
+// EraseCustomer implements LinkStore
+func (s *SQLiteLinkStore) EraseCustomer(ctx context.Context, id string) (*CustomerErasure, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start erasing customer: %w", err)
+	}
+	defer tx.Rollback()
+
+	var email string
+	err = tx.QueryRowContext(ctx, `SELECT email FROM customers WHERE id = ?`, id).Scan(&email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read customer: %w", err)
+	}
+	if email, err = s.keys.decrypt(columnCustomerEmail, email); err != nil {
+		return nil, err
+	}
+
+	var erasure CustomerErasure
+	// Velocity entries are keyed by the customer's email and their links' references, so
+	// they are deleted before the references are cleared
+	result, err := tx.ExecContext(ctx,
+		`DELETE FROM velocity_entries WHERE (? <> '' AND key = ?)
+			OR key IN (SELECT reference FROM payment_links WHERE customer_id = ? AND reference <> '')`,
+		email, strings.ToLower(email), id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase velocity entries: %w", err)
+	}
+	erasure.Velocity, _ = result.RowsAffected()
+
+	result, err = tx.ExecContext(ctx,
+		`DELETE FROM webhook_dead_letters WHERE customer_id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase webhook dead letters: %w", err)
+	}
+	erasure.DeadLetters, _ = result.RowsAffected()
+
+	result, err = tx.ExecContext(ctx,
+		`UPDATE link_deliveries SET recipient = ''
+		 WHERE recipient <> '' AND link_id IN (SELECT id FROM payment_links WHERE customer_id = ?)`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase delivery recipients: %w", err)
+	}
+	erasure.Deliveries, _ = result.RowsAffected()
+
+	result, err = tx.ExecContext(ctx,
+		`UPDATE link_views SET user_agent = '', referrer = ''
+		 WHERE (user_agent <> '' OR referrer <> '') AND link_id IN (SELECT id FROM payment_links WHERE customer_id = ?)`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase link views: %w", err)
+	}
+	erasure.Views, _ = result.RowsAffected()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, template FROM link_series WHERE customer_id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find customer's link series: %w", err)
+	}
+	templates := make(map[string]string)
+	for rows.Next() {
+		var seriesID, template string
+		if err := rows.Scan(&seriesID, &template); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan link series: %w", err)
+		}
+		templates[seriesID] = template
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read link series: %w", err)
+	}
+	for seriesID, template := range templates {
+		scrubbed, err := s.keys.scrubSeriesTemplate(template)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE link_series SET reference = '', template = ?, customer_id = '' WHERE id = ?`, scrubbed, seriesID); err != nil {
+			return nil, fmt.Errorf("failed to erase link series: %w", err)
+		}
+		// Installments not yet created would otherwise go on being sent to the customer
+		if _, err := tx.ExecContext(ctx, `DELETE FROM series_installments WHERE series_id = ? AND link_id = ''`, seriesID); err != nil {
+			return nil, fmt.Errorf("failed to delete scheduled installments: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE series_installments SET reference = '' WHERE series_id = ?`, seriesID); err != nil {
+			return nil, fmt.Errorf("failed to erase installment references: %w", err)
+		}
+		erasure.Series++
+	}
+
+	result, err = tx.ExecContext(ctx,
+		`UPDATE payment_links SET reference = '', customer_phone = '', metadata = '{}', metadata_index = '[]', customer_id = ''
+		 WHERE customer_id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase customer's payment links: %w", err)
+	}
+	erasure.Links, _ = result.RowsAffected()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM customers WHERE id = ?`, id); err != nil {
+		return nil, fmt.Errorf("failed to delete customer: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit customer erasure: %w", err)
+	}
+	return &erasure, nil
+}
+
+// sqliteIdleCustomer selects customers last updated before the first parameter with no
+// links that are active, given as the second, or updated since the third
+const sqliteIdleCustomer = `updated_at < ? AND NOT EXISTS (
+	SELECT 1 FROM payment_links WHERE customer_id = customers.id AND (status = ? OR updated_at >= ?))`
+
+// ListIdleCustomers implements LinkStore
+func (s *SQLiteLinkStore) ListIdleCustomers(ctx context.Context, idleSince time.Time, limit int) ([]string, error) {
+	cutoff := formatSQLiteTime(idleSince)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id FROM customers WHERE `+sqliteIdleCustomer+` ORDER BY id LIMIT ?`,
+		cutoff, LinkStatusActive, cutoff, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query idle customers: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan customer: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read idle customers: %w", err)
+	}
+	return ids, nil
+}
+
+// AnonymizeCustomers implements LinkStore
+func (s *SQLiteLinkStore) AnonymizeCustomers(ctx context.Context, idleSince time.Time) (int64, error) {
+	cutoff := formatSQLiteTime(idleSince)
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE customers SET name = '', email = '', phone = ''
+		 WHERE (name <> '' OR email <> '' OR phone <> '') AND `+sqliteIdleCustomer,
+		cutoff, LinkStatusActive, cutoff,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to anonymize customers: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// templateColumns lists the link_templates columns in the order scanTemplate expects
+const templateColumns = `id, name, amount, currency, description, usage_mode, usage_limit, expiration_days, created_at, updated_at`
+
+// CreateTemplate implements LinkStore
+func (s *SQLiteLinkStore) CreateTemplate(ctx context.Context, template *LinkTemplate) error {
+	now := time.Now().UTC()
+	template.CreatedAt = now
+	template.UpdatedAt = now
+
+	description, err := s.keys.encrypt(columnTemplateDescription, template.Description)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO link_templates (`+templateColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		template.ID, template.Name, template.Amount, template.Currency, description,
+		template.UsageMode, template.UsageLimit, template.ExpirationDays, formatSQLiteTime(now), formatSQLiteTime(now),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert link template: %w", err)
+	}
+	return nil
+}
+
+// GetTemplate implements LinkStore
+func (s *SQLiteLinkStore) GetTemplate(ctx context.Context, id string) (*LinkTemplate, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+templateColumns+` FROM link_templates WHERE id = ?`, id)
+	template, err := s.scanTemplate(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read link template: %w", err)
+	}
+	return template, nil
+}
+
+// ListTemplates implements LinkStore
+func (s *SQLiteLinkStore) ListTemplates(ctx context.Context) ([]*LinkTemplate, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+templateColumns+` FROM link_templates ORDER BY name, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list link templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []*LinkTemplate{}
+	for rows.Next() {
+		template, err := s.scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read link template: %w", err)
+		}
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list link templates: %w", err)
+	}
+	return templates, nil
+}
+
+// UpdateTemplate implements LinkStore
+func (s *SQLiteLinkStore) UpdateTemplate(ctx context.Context, template *LinkTemplate) error {
+	template.UpdatedAt = time.Now().UTC()
+	description, err := s.keys.encrypt(columnTemplateDescription, template.Description)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE link_templates SET name = ?, amount = ?, currency = ?, description = ?, usage_mode = ?,
+		 usage_limit = ?, expiration_days = ?, updated_at = ? WHERE id = ?`,
+		template.Name, template.Amount, template.Currency, description, template.UsageMode,
+		template.UsageLimit, template.ExpirationDays, formatSQLiteTime(template.UpdatedAt), template.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update link template: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+// DeleteTemplate implements LinkStore
+func (s *SQLiteLinkStore) DeleteTemplate(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM link_templates WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete link template: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+// productColumns lists the products columns in the order scanProduct expects
+const productColumns = `sku, name, unit_price, currency, created_at, updated_at`
+
+// SaveProduct implements LinkStore
+func (s *SQLiteLinkStore) SaveProduct(ctx context.Context, product *Product) error {
+	now := time.Now().UTC()
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO products (`+productColumns+`) VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (sku) DO UPDATE SET name = excluded.name, unit_price = excluded.unit_price,
+		 currency = excluded.currency, updated_at = excluded.updated_at`,
+		product.SKU, product.Name, product.UnitPrice, product.Currency, formatSQLiteTime(now), formatSQLiteTime(now),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save product: %w", err)
+	}
+	product.UpdatedAt = now
+	return nil
+}
+
+// GetProduct implements LinkStore
+func (s *SQLiteLinkStore) GetProduct(ctx context.Context, sku string) (*Product, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+productColumns+` FROM products WHERE sku = ?`, sku)
+	product, err := scanProduct(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read product: %w", err)
+	}
+	return product, nil
+}
+
+// ListProducts implements LinkStore
+func (s *SQLiteLinkStore) ListProducts(ctx context.Context) ([]*Product, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+productColumns+` FROM products ORDER BY sku`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []*Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read product: %w", err)
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+	return products, nil
+}
+
+// DeleteProduct implements LinkStore
+func (s *SQLiteLinkStore) DeleteProduct(ctx context.Context, sku string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM products WHERE sku = ?`, sku)
+	if err != nil {
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// ClaimPromoCode implements LinkStore
+func (s *SQLiteLinkStore) ClaimPromoCode(ctx context.Context, code string, maxUses int) error {
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO promo_code_uses (code) VALUES (?) ON CONFLICT (code) DO NOTHING`, code); err != nil {
+		return fmt.Errorf("failed to record promo code: %w", err)
+	}
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE promo_code_uses SET uses = uses + 1 WHERE code = ? AND (? = 0 OR uses < ?)`,
+		code, maxUses, maxUses,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to claim promo code: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrPromoCodeUsedUp
+	}
+	return nil
+}
+
+// ReleasePromoCode implements LinkStore
+func (s *SQLiteLinkStore) ReleasePromoCode(ctx context.Context, code string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE promo_code_uses SET uses = uses - 1 WHERE code = ? AND uses > 0`, code)
+	if err != nil {
+		return fmt.Errorf("failed to release promo code: %w", err)
+	}
+	return nil
+}
+
+// NextReferenceSequence implements LinkStore
+func (s *SQLiteLinkStore) NextReferenceSequence(ctx context.Context, scope string) (int64, error) {
+	var value int64
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO reference_sequences (scope, value) VALUES (?, 1)
+		 ON CONFLICT (scope) DO UPDATE SET value = value + 1 RETURNING value`,
+		scope,
+	).Scan(&value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to advance reference sequence: %w", err)
+	}
+	return value, nil
+}
+
+// ReserveVelocity implements LinkStore. The store has a single connection, so nothing
+// else runs between the transaction's reads and writes.
+func (s *SQLiteLinkStore) ReserveVelocity(ctx context.Context, entries []VelocityEntry, limits []VelocityLimit) (string, error) {
+	reservation, err := newReservation()
+	if err != nil {
+		return "", err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to start reserving velocity: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i, limit := range limits {
+		var usage VelocityUsage
+		err := tx.QueryRowContext(ctx,
+			`SELECT COUNT(*), COALESCE(SUM(CASE WHEN currency = ? THEN amount ELSE 0 END), 0)
+			 FROM velocity_entries WHERE scope = ? AND key = ? AND created_at > ?`,
+			limit.Currency, limit.Scope, limit.Key, formatSQLiteTime(limit.Since),
+		).Scan(&usage.Links, &usage.Amount)
+		if err != nil {
+			return "", fmt.Errorf("failed to read velocity: %w", err)
+		}
+		if limit.exceededBy(usage, velocityAmount(entries, limit)) {
+			return "", &VelocityLimitError{Index: i, Usage: usage}
+		}
+	}
+	for _, entry := range entries {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO velocity_entries (scope, key, amount, currency, created_at, reservation) VALUES (?, ?, ?, ?, ?, ?)`,
+			entry.Scope, entry.Key, entry.Amount, entry.Currency, formatSQLiteTime(entry.CreatedAt), reservation,
+		); err != nil {
+			return "", fmt.Errorf("failed to record velocity: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit velocity: %w", err)
+	}
+	return reservation, nil
+}
+
+// ReleaseVelocity implements LinkStore
+func (s *SQLiteLinkStore) ReleaseVelocity(ctx context.Context, reservation string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM velocity_entries WHERE reservation = ?`, reservation); err != nil {
+		return fmt.Errorf("failed to release velocity: %w", err)
+	}
+	return nil
+}
+
+// PruneVelocity implements LinkStore
+func (s *SQLiteLinkStore) PruneVelocity(ctx context.Context, before time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM velocity_entries WHERE created_at < ?`, formatSQLiteTime(before)); err != nil {
+		return fmt.Errorf("failed to prune velocity: %w", err)
+	}
+	return nil
+}
+
+// ReserveRefund implements LinkStore
+func (s *SQLiteLinkStore) ReserveRefund(ctx context.Context, refund *Refund, captured int64) error {
+	reservation, err := newReservation()
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start reserving refund: %w", err)
+	}
+	defer tx.Rollback()
+
+	var refunded int64
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE transaction_id = ?`,
+		refund.TransactionID).Scan(&refunded); err != nil {
+		return fmt.Errorf("failed to read refunds: %w", err)
+	}
+	amount, err := refundAmount(refund, captured, refunded)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO refunds (reservation, transaction_id, amount, currency, created_at) VALUES (?, ?, ?, ?, ?)`,
+		reservation, refund.TransactionID, amount, refund.Currency, formatSQLiteTime(refund.CreatedAt),
+	); err != nil {
+		return fmt.Errorf("failed to record refund: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit refund: %w", err)
+	}
+	refund.Reservation, refund.Amount = reservation, amount
+	return nil
+}
+
+// ReleaseRefund implements LinkStore
+func (s *SQLiteLinkStore) ReleaseRefund(ctx context.Context, reservation string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM refunds WHERE reservation = ?`, reservation); err != nil {
+		return fmt.Errorf("failed to release refund: %w", err)
+	}
+	return nil
+}
+
+// AppendAudit implements LinkStore
+func (s *SQLiteLinkStore) AppendAudit(ctx context.Context, entry *AuditEntry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start appending audit entry: %w", err)
+	}
+	defer tx.Rollback()
+
+	var lastID int64
+	var lastHash string
+	err = tx.QueryRowContext(ctx, `SELECT id, hash FROM audit_log ORDER BY id DESC LIMIT 1`).Scan(&lastID, &lastHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to read last audit entry: %w", err)
+	}
+	chainAuditEntry(entry, lastID, lastHash)
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO audit_log (`+auditColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID, entry.Action, entry.Actor, entry.RequestID, entry.Resource, entry.LinkID, entry.TransactionID,
+		string(entry.Changes), formatSQLiteTime(entry.CreatedAt), entry.PrevHash, entry.Hash,
+	); err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListAudit implements LinkStore
+func (s *SQLiteLinkStore) ListAudit(ctx context.Context, filter AuditFilter) ([]*AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM audit_log WHERE 1 = 1`
+	var args []interface{}
+
+	for _, condition := range []struct {
+		column, value string
+	}{
+		{"action", filter.Action},
+		{"actor", filter.Actor},
+		{"resource", filter.Resource},
+		{"link_id", filter.LinkID},
+		{"transaction_id", filter.TransactionID},
+	} {
+		if condition.value != "" {
+			query += ` AND ` + condition.column + ` = ?`
+			args = append(args, condition.value)
+		}
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query += ` AND created_at >= ?`
+		args = append(args, formatSQLiteTime(filter.CreatedFrom))
+	}
+	if !filter.CreatedTo.IsZero() {
+		query += ` AND created_at <= ?`
+		args = append(args, formatSQLiteTime(filter.CreatedTo))
+	}
+	if filter.BeforeID > 0 {
+		query += ` AND id < ?`
+		args = append(args, filter.BeforeID)
+	}
+
+	query += ` ORDER BY id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*AuditEntry{}
+	for rows.Next() {
+		var entry AuditEntry
+		var changes, createdAt string
+		if err := rows.Scan(&entry.ID, &entry.Action, &entry.Actor, &entry.RequestID, &entry.Resource, &entry.LinkID,
+			&entry.TransactionID, &changes, &createdAt, &entry.PrevHash, &entry.Hash); err != nil {
+			return nil, fmt.Errorf("failed to read audit entry: %w", err)
+		}
+		entry.Changes = json.RawMessage(changes)
+		entry.CreatedAt = parseSQLiteTime(createdAt)
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+// CreateSeries implements LinkStore
+func (s *SQLiteLinkStore) CreateSeries(ctx context.Context, series *LinkSeries, installments []*Installment) error {
+	now := time.Now().UTC()
+	series.CreatedAt = now
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start recording series: %w", err)
+	}
+	defer tx.Rollback()
+
+	template, err := s.keys.encrypt(columnSeriesTemplate, series.Template)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO link_series (id, reference, cadence, occurrences, template, customer_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		series.ID, series.Reference, series.Cadence, series.Occurrences, template, seriesCustomerID(series.Template), formatSQLiteTime(now),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert series: %w", err)
+	}
+	for _, installment := range installments {
+		installment.SeriesID = series.ID
+		installment.UpdatedAt = now
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO series_installments (series_id, number, reference, due_at, link_id, attempts, last_error, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			installment.SeriesID, installment.Number, installment.Reference, formatSQLiteTime(installment.DueAt),
+			installment.LinkID, installment.Attempts, installment.LastError, formatSQLiteTime(now),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert installment: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit series: %w", err)
+	}
+	return nil
+}
+
+// GetSeries implements LinkStore
+func (s *SQLiteLinkStore) GetSeries(ctx context.Context, id string) (*LinkSeries, error) {
+	var series LinkSeries
+	var createdAt string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, reference, cadence, occurrences, template, created_at FROM link_series WHERE id = ?`, id,
+	).Scan(&series.ID, &series.Reference, &series.Cadence, &series.Occurrences, &series.Template, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSeriesNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read series: %w", err)
+	}
+	if series.Template, err = s.keys.decrypt(columnSeriesTemplate, series.Template); err != nil {
+		return nil, err
+	}
+	series.CreatedAt = parseSQLiteTime(createdAt)
+	return &series, nil
+}
+
+// ListInstallments implements LinkStore
+func (s *SQLiteLinkStore) ListInstallments(ctx context.Context, seriesID string) ([]*Installment, error) {
+	return s.queryInstallments(ctx, `WHERE i.series_id = ? ORDER BY i.number`, seriesID)
+}
+
+// ListDueInstallments implements LinkStore
+func (s *SQLiteLinkStore) ListDueInstallments(ctx context.Context, dueBy time.Time, maxAttempts, limit int) ([]*Installment, error) {
+	return s.queryInstallments(ctx,
+		`WHERE i.link_id = '' AND i.due_at <= ? AND i.attempts < ? ORDER BY i.due_at, i.series_id, i.number LIMIT ?`,
+		formatSQLiteTime(dueBy), maxAttempts, limit,
+	)
+}
+
+// queryInstallments selects installments joined with their links, filtered and ordered by clause
+func (s *SQLiteLinkStore) queryInstallments(ctx context.Context, clause string, args ...interface{}) ([]*Installment, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT i.series_id, i.number, i.reference, i.due_at, i.link_id, i.attempts, i.last_error, i.updated_at,
+		        COALESCE(l.url, ''), COALESCE(l.status, '')
+		 FROM series_installments i LEFT JOIN payment_links l ON l.id = i.link_id AND i.link_id <> '' `+clause,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list installments: %w", err)
+	}
+	defer rows.Close()
+
+	installments := []*Installment{}
+	for rows.Next() {
+		var installment Installment
+		var dueAt, updatedAt string
+		err := rows.Scan(&installment.SeriesID, &installment.Number, &installment.Reference, &dueAt,
+			&installment.LinkID, &installment.Attempts, &installment.LastError, &updatedAt,
+			&installment.LinkURL, &installment.LinkStatus)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read installment: %w", err)
+		}
+		installment.DueAt = parseSQLiteTime(dueAt)
+		installment.UpdatedAt = parseSQLiteTime(updatedAt)
+		installments = append(installments, &installment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list installments: %w", err)
+	}
+	return installments, nil
+}
+
+// RecordInstallmentAttempt implements LinkStore
+func (s *SQLiteLinkStore) RecordInstallmentAttempt(ctx context.Context, seriesID string, number int, linkID, attemptError string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE series_installments SET link_id = ?, attempts = attempts + 1, last_error = ?, updated_at = ?
+		 WHERE series_id = ? AND number = ?`,
+		linkID, attemptError, formatSQLiteTime(time.Now()), seriesID, number,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update installment: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrSeriesNotFound
+	}
+	return nil
+}
+
+// Reencrypt implements LinkStore
+func (s *SQLiteLinkStore) Reencrypt(ctx context.Context) (*Reencryption, error) {
+	return s.keys.reencrypt(ctx, s.db, sqliteDialect)
+}
+
+// Ping implements LinkStore
+func (s *SQLiteLinkStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close implements LinkStore
+func (s *SQLiteLinkStore) Close() error {
+	return s.db.Close()
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanLink reads a payment_links row selected with linkColumns, decrypting its personal data
+func (s *SQLiteLinkStore) scanLink(row rowScanner) (*Link, error) {
+	var link Link
+	var expiresAt, createdAt, updatedAt, lastReminderAt, metadata string
+	err := row.Scan(
+		&link.ID, &link.URL, &link.Reference, &link.Amount, &link.Currency, &link.Status,
+		&link.TransactionID, &link.TransactionStatus, &link.CustomerPhone, &expiresAt, &createdAt, &updatedAt,
+		&link.RemindersOptOut, &link.RemindersSent, &lastReminderAt, &link.CustomerID,
+		&link.PromoCode, &link.DiscountAmount, &link.TaxAmount, &link.TaxRate, &link.MinAmount, &link.MaxAmount,
+		&metadata, &link.ShortCode, &link.Itemized,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if link.CustomerPhone, err = s.keys.decrypt(columnLinkPhone, link.CustomerPhone); err != nil {
+		return nil, err
+	}
+	if link.Metadata, err = s.keys.decryptMetadata([]byte(metadata)); err != nil {
+		return nil, err
+	}
+	link.ExpiresAt = parseSQLiteTime(expiresAt)
+	link.CreatedAt = parseSQLiteTime(createdAt)
+	link.UpdatedAt = parseSQLiteTime(updatedAt)
+	if lastReminderAt != "" {
+		t := parseSQLiteTime(lastReminderAt)
+		link.LastReminderAt = &t
+	}
+	return &link, nil
+}
+
+// scanTemplate reads a link_templates row selected with templateColumns, decrypting its description
+func (s *SQLiteLinkStore) scanTemplate(row rowScanner) (*LinkTemplate, error) {
+	var template LinkTemplate
+	var createdAt, updatedAt string
+	err := row.Scan(
+		&template.ID, &template.Name, &template.Amount, &template.Currency, &template.Description,
+		&template.UsageMode, &template.UsageLimit, &template.ExpirationDays, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if template.Description, err = s.keys.decrypt(columnTemplateDescription, template.Description); err != nil {
+		return nil, err
+	}
+	template.CreatedAt = parseSQLiteTime(createdAt)
+	template.UpdatedAt = parseSQLiteTime(updatedAt)
+	return &template, nil
+}
+
+// formatSQLiteTime converts t to the stored timestamp format
+func formatSQLiteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// formatOptionalSQLiteTime converts t to the stored timestamp format, or "" when t is nil
+func formatOptionalSQLiteTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatSQLiteTime(*t)
+}
+
+// parseSQLiteTime converts a stored timestamp back to a time.Time
+func parseSQLiteTime(value string) time.Time {
+	t, _ := time.Parse(sqliteTimeLayout, value)
+	return t
+}
+
+// scanProduct reads a products row selected with productColumns
+func scanProduct(row rowScanner) (*Product, error) {
+	var product Product
+	var createdAt, updatedAt string
+	err := row.Scan(&product.SKU, &product.Name, &product.UnitPrice, &product.Currency, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	product.CreatedAt = parseSQLiteTime(createdAt)
+	product.UpdatedAt = parseSQLiteTime(updatedAt)
+	return &product, nil
+}
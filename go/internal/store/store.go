@@ -0,0 +1,774 @@
+// Package store persists the payment links and delivery attempts created by the server.
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Local payment link statuses
+const (
+	LinkStatusActive   = "ACTIVE"
+	LinkStatusPaid     = "PAID"
+	LinkStatusInactive = "INACTIVE"
+	LinkStatusExpired  = "EXPIRED"
+)
+
+// Recurring link series cadences
+const (
+	CadenceWeekly  = "WEEKLY"
+	CadenceMonthly = "MONTHLY"
+)
+
+// Errors returned by LinkStore implementations
+var (
+	ErrLinkNotFound       = errors.New("payment link not found")
+	ErrDeliveryNotFound   = errors.New("delivery not found")
+	ErrSeriesNotFound     = errors.New("recurring link series not found")
+	ErrCustomerNotFound   = errors.New("customer not found")
+	ErrTemplateNotFound   = errors.New("link template not found")
+	ErrProductNotFound    = errors.New("product not found")
+	ErrReceiptNotFound    = errors.New("receipt not found")
+	ErrDeadLetterNotFound = errors.New("webhook dead letter not found")
+	ErrPromoCodeUsedUp    = errors.New("promo code has reached its maximum uses")
+	// ErrLinkUnchanged reports that a transaction was not recorded because the link is already paid
+	ErrLinkUnchanged = errors.New("payment link unchanged")
+	// ErrTransactionRecorded reports that a link already records a transaction with the same status
+	ErrTransactionRecorded = errors.New("transaction already recorded")
+	// ErrAdminSessionNotFound is returned for dashboard sessions that are unknown, expired, or revoked
+	ErrAdminSessionNotFound = errors.New("admin session not found")
+)
+
+// Link holds the locally known state of a payment link
+type Link struct {
+	ID                string    `json:"linkId"`
+	URL               string    `json:"paymentLink"`
+	Reference         string    `json:"reference"`
+	Amount            int64     `json:"amount"`
+	Currency          string    `json:"currency"`
+	Status            string    `json:"status"`
+	TransactionID     string    `json:"transactionId,omitempty"`
+	TransactionStatus string    `json:"transactionStatus,omitempty"`
+	CustomerPhone     string    `json:"customerPhone,omitempty"`
+	CustomerID        string    `json:"customerId,omitempty"`
+	ExpiresAt         time.Time `json:"expiresAt"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+	// RemindersOptOut stops payment reminders being sent for the link
+	RemindersOptOut bool       `json:"remindersOptOut,omitempty"`
+	RemindersSent   int        `json:"remindersSent,omitempty"`
+	LastReminderAt  *time.Time `json:"lastReminderAt,omitempty"`
+	// PromoCode is the promo code applied to the link, and DiscountAmount the minor units
+	// it took off; Amount is what the customer pays after the discount
+	PromoCode      string `json:"promoCode,omitempty"`
+	DiscountAmount int64  `json:"discountAmount,omitempty"`
+	// TaxAmount is the tax included in Amount, in minor units, charged at TaxRate percent
+	TaxAmount int64  `json:"taxAmount,omitempty"`
+	TaxRate   string `json:"taxRate,omitempty"`
+	// MinAmount and MaxAmount bound what the customer may pay on an open-amount link, in
+	// minor units, where either may be zero for no bound; Amount is zero on such links
+	MinAmount int64 `json:"minAmount,omitempty"`
+	MaxAmount int64 `json:"maxAmount,omitempty"`
+	// Metadata holds the merchant's own key-value pairs, such as order or campaign identifiers
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// ShortCode identifies the link's /l/{code} shortlink, which records each view before
+	// redirecting to URL. Links created before shortlinks were added have none.
+	ShortCode string `json:"shortCode,omitempty"`
+	// Itemized records that Amount was priced from catalog line items listed in the description
+	Itemized bool `json:"itemized,omitempty"`
+}
+
+// Customer is a customer in the merchant's local directory, to which links can be associated
+type Customer struct {
+	ID        string    `json:"customerId"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	Phone     string    `json:"phone,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CustomerErasure counts what was erased along with a customer. Links, deliveries, views,
+// and series are kept for the merchant's records with the personal data removed; velocity
+// entries and dead-lettered webhook events are deleted.
+type CustomerErasure struct {
+	Links       int64 `json:"links"`
+	Deliveries  int64 `json:"deliveries"`
+	Views       int64 `json:"views"`
+	Series      int64 `json:"series"`
+	Velocity    int64 `json:"velocityEntries"`
+	DeadLetters int64 `json:"deadLetters"`
+}
+
+// seriesPersonalFields are the fields of a series' link creation request that identify the customer
+var seriesPersonalFields = []string{"customerId", "customerPhone", "reference", "metadata"}
+
+// scrubSeriesTemplate returns a series' link creation request with the fields that
+// identify the customer removed
+func scrubSeriesTemplate(template string) (string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(template), &fields); err != nil {
+		return "", fmt.Errorf("failed to decode series template: %w", err)
+	}
+	for _, name := range seriesPersonalFields {
+		delete(fields, name)
+	}
+	// A map of raw JSON values read from valid JSON always encodes
+	scrubbed, _ := json.Marshal(fields)
+	return string(scrubbed), nil
+}
+
+// seriesCustomerID returns the customer a series' link creation request is for, kept in
+// a column of its own so erasure can find the series without decrypting every template
+func seriesCustomerID(template string) string {
+	var request struct {
+		CustomerID string `json:"customerId"`
+	}
+	// A template that does not decode is for no known customer
+	_ = json.Unmarshal([]byte(template), &request)
+	return request.CustomerID
+}
+
+// deadLetterPersonalFields are the link fields of a dead letter's payload that identify the customer
+var deadLetterPersonalFields = []string{"reference", "customerPhone", "customerId", "metadata"}
+
+// deadLetterCustomerID returns the customer whose link a dead letter's payload describes
+func deadLetterCustomerID(payload string) string {
+	var event struct {
+		Data struct {
+			CustomerID string `json:"customerId"`
+		} `json:"data"`
+	}
+	// Payloads are always written as JSON, but one that does not decode names no customer
+	_ = json.Unmarshal([]byte(payload), &event)
+	return event.Data.CustomerID
+}
+
+// anonymizeDeadLetterPayload returns a dead letter's payload with the link fields that
+// identify the customer removed, and whether there were any to remove
+func anonymizeDeadLetterPayload(payload string) (string, bool, error) {
+	var event map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		return "", false, fmt.Errorf("failed to decode dead letter payload: %w", err)
+	}
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(event["data"], &data); err != nil || data == nil {
+		return payload, false, nil
+	}
+	changed := false
+	for _, name := range deadLetterPersonalFields {
+		if _, ok := data[name]; ok {
+			delete(data, name)
+			changed = true
+		}
+	}
+	if !changed {
+		return payload, false, nil
+	}
+	// Maps of raw JSON values read from valid JSON always encode
+	event["data"], _ = json.Marshal(data)
+	anonymized, _ := json.Marshal(event)
+	return string(anonymized), true, nil
+}
+
+// LinkTemplate is a named preset of link fields that link requests can start from. The
+// fields hold request values, such as the amount in major units; empty fields are not preset.
+type LinkTemplate struct {
+	ID             string    `json:"templateId"`
+	Name           string    `json:"name"`
+	Amount         string    `json:"amount,omitempty"`
+	Currency       string    `json:"currency,omitempty"`
+	Description    string    `json:"description,omitempty"`
+	UsageMode      string    `json:"usageMode,omitempty"`
+	UsageLimit     string    `json:"usageLimit,omitempty"`
+	ExpirationDays string    `json:"expirationDays,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// Product is an item in the local catalog that link line items are priced from
+type Product struct {
+	SKU  string `json:"sku"`
+	Name string `json:"name"`
+	// UnitPrice is in the currency's minor units
+	UnitPrice int64     `json:"unitPrice"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Delivery records an attempt to send a payment link to a customer
+type Delivery struct {
+	ID         int64     `json:"id"`
+	LinkID     string    `json:"linkId"`
+	Channel    string    `json:"channel"`
+	Recipient  string    `json:"recipient"`
+	ProviderID string    `json:"providerId,omitempty"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// LinkView records a visit to a link's shortlink, made just before the visitor was
+// redirected to the payment page
+type LinkView struct {
+	ID        int64     `json:"id"`
+	LinkID    string    `json:"linkId"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	Referrer  string    `json:"referrer,omitempty"`
+	ViewedAt  time.Time `json:"viewedAt"`
+}
+
+// Receipt records the payment that settled a link, from GP's status notification, for the
+// receipt sent to the payer
+type Receipt struct {
+	LinkID        string `json:"linkId"`
+	TransactionID string `json:"transactionId"`
+	// Amount is what was paid, in minor units
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	// CardBrand and CardLast4 identify the card paid with, such as VISA and 1111, when
+	// the payment was made by card
+	CardBrand string    `json:"cardBrand,omitempty"`
+	CardLast4 string    `json:"cardLast4,omitempty"`
+	PaidAt    time.Time `json:"paidAt"`
+}
+
+// AdminSession is a browser signed in to the dashboard with an API key. The browser holds a
+// random token, and only hashes of it and of the API key are stored, so the session ends
+// when the key is removed or changed.
+type AdminSession struct {
+	TokenHash  string
+	APIKeyHash string
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+}
+
+// WebhookDeadLetter records an outbound webhook event that could not be delivered
+// to a merchant endpoint after every retry, so it can be inspected and replayed
+type WebhookDeadLetter struct {
+	ID        int64     `json:"id"`
+	EventID   string    `json:"eventId"`
+	EventType string    `json:"eventType"`
+	URL       string    `json:"url"`
+	Payload   string    `json:"payload"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// OutboxEvent is a link event written to the outbox in the same transaction as the change
+// it announces, so it is delivered even when the process stops before publishing it
+type OutboxEvent struct {
+	ID int64
+	// EventID and EventType identify the event to its receivers
+	EventID   string
+	EventType string
+	LinkID    string
+	// Link is the link as the change left it, filled in by the store
+	Link      *Link
+	CreatedAt time.Time
+	// Attempts counts the times the event has been claimed for delivery
+	Attempts int
+}
+
+// LinkSeries is a recurring series of single-use links, one for each installment
+type LinkSeries struct {
+	ID          string
+	Reference   string
+	Cadence     string
+	Occurrences int
+	// Template is the JSON link creation request each installment's link is created from
+	Template  string
+	CreatedAt time.Time
+}
+
+// Installment is one scheduled payment of a LinkSeries
+type Installment struct {
+	SeriesID  string
+	Number    int
+	Reference string
+	DueAt     time.Time
+	// LinkID is set once the installment's link has been created
+	LinkID    string
+	Attempts  int
+	LastError string
+	UpdatedAt time.Time
+	// LinkURL and LinkStatus are read from the installment's stored link, when there is one
+	LinkURL    string
+	LinkStatus string
+}
+
+// VelocityEntry counts a created link towards a velocity key, such as its reference or the
+// API key that created it, so the links created for that key in a window can be limited
+type VelocityEntry struct {
+	// Scope names what Key is, such as reference, customer, or apikey
+	Scope string
+	Key   string
+	// Amount is the link amount in Currency's minor units
+	Amount    int64
+	Currency  string
+	CreatedAt time.Time
+}
+
+// VelocityUsage is how many links were counted towards a velocity key in a window, and
+// the total of their amounts in one currency
+type VelocityUsage struct {
+	Links  int
+	Amount int64
+}
+
+// VelocityLimit is a limit a velocity reservation must stay within: at most MaxLinks links,
+// and at most MaxAmount minor units of Currency, counted towards a velocity key since Since.
+// A zero maximum is not enforced.
+type VelocityLimit struct {
+	Scope     string
+	Key       string
+	Since     time.Time
+	MaxLinks  int
+	MaxAmount int64
+	Currency  string
+}
+
+// VelocityLimitError reports the velocity limit a reservation would have exceeded
+type VelocityLimitError struct {
+	// Index is the position of the limit in those passed to ReserveVelocity
+	Index int
+	// Usage is what was already counted towards the limit's key
+	Usage VelocityUsage
+}
+
+func (e *VelocityLimitError) Error() string {
+	return fmt.Sprintf("velocity limit %d exceeded", e.Index)
+}
+
+// exceededBy reports whether counting a link for amount minor units on top of usage would
+// take the limit over
+func (l VelocityLimit) exceededBy(usage VelocityUsage, amount int64) bool {
+	return (l.MaxLinks > 0 && usage.Links >= l.MaxLinks) || (l.MaxAmount > 0 && usage.Amount+amount > l.MaxAmount)
+}
+
+// velocityAmount returns the amount of the entry counted towards the limit's key
+func velocityAmount(entries []VelocityEntry, limit VelocityLimit) int64 {
+	for _, entry := range entries {
+		if entry.Scope == limit.Scope && entry.Key == limit.Key {
+			return entry.Amount
+		}
+	}
+	return 0
+}
+
+// Refund is a refund of a captured transaction, recorded before GP is asked to make it so
+// the refunds of a transaction cannot add up to more than was captured
+type Refund struct {
+	// Reservation identifies the refund until GP has made it; ReserveRefund sets it
+	Reservation   string
+	TransactionID string
+	// Amount is in Currency's minor units. A refund reserved without one is for what
+	// remains of the transaction, and ReserveRefund sets it.
+	Amount    int64
+	Currency  string
+	CreatedAt time.Time
+}
+
+// RefundLimitError reports that a refund would take a transaction's refunds past the
+// amount captured
+type RefundLimitError struct {
+	// Refunded is the amount already refunded, in minor units
+	Refunded int64
+}
+
+func (e *RefundLimitError) Error() string {
+	return fmt.Sprintf("refund exceeds what remains after %d refunded", e.Refunded)
+}
+
+// refundAmount returns the amount refund is for, given what was captured and what has
+// been refunded, or a *RefundLimitError when that is more than remains
+func refundAmount(refund *Refund, captured, refunded int64) (int64, error) {
+	amount := refund.Amount
+	if amount <= 0 {
+		amount = captured - refunded
+	}
+	if amount <= 0 || refunded+amount > captured {
+		return 0, &RefundLimitError{Refunded: refunded}
+	}
+	return amount, nil
+}
+
+// newReservation returns a random ID for the entries of one velocity reservation, or for
+// a refund reservation
+func newReservation() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate reservation: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// AuditGenesisHash is the previous hash of the first entry in the audit log
+var AuditGenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// AuditEntry records one change made through the server. Entries are only ever appended,
+// and each carries the hash of the one before it, so removing or editing an entry breaks
+// the chain from that point on.
+type AuditEntry struct {
+	ID int64 `json:"id"`
+	// Action names what was done, such as link.create or transaction.refund
+	Action string `json:"action"`
+	// Actor is who did it: apikey:<name>, user:<name> for single sign-on, anonymous when
+	// authentication is disabled, or system for background jobs
+	Actor     string `json:"actor"`
+	RequestID string `json:"requestId,omitempty"`
+	// Resource identifies what was changed: a link, customer, or template ID, a product
+	// SKU, or a transaction ID
+	Resource string `json:"resource"`
+	// LinkID and TransactionID are the GP API identifiers involved, when there are any
+	LinkID        string `json:"linkId,omitempty"`
+	TransactionID string `json:"transactionId,omitempty"`
+	// Changes is a JSON object describing what changed
+	Changes   json.RawMessage `json:"changes"`
+	CreatedAt time.Time       `json:"createdAt"`
+	PrevHash  string          `json:"prevHash"`
+	Hash      string          `json:"hash"`
+}
+
+// ComputeHash returns the hex SHA-256 of the entry's contents, including PrevHash, which
+// Hash must equal
+func (e *AuditEntry) ComputeHash() string {
+	// Field order is fixed by the struct, so the encoding is the same every time
+	payload, _ := json.Marshal(struct {
+		ID            int64           `json:"id"`
+		PrevHash      string          `json:"prevHash"`
+		CreatedAt     string          `json:"createdAt"`
+		Action        string          `json:"action"`
+		Actor         string          `json:"actor"`
+		RequestID     string          `json:"requestId"`
+		Resource      string          `json:"resource"`
+		LinkID        string          `json:"linkId"`
+		TransactionID string          `json:"transactionId"`
+		Changes       json.RawMessage `json:"changes"`
+	}{e.ID, e.PrevHash, e.CreatedAt.UTC().Format(time.RFC3339Nano), e.Action, e.Actor, e.RequestID, e.Resource, e.LinkID, e.TransactionID, e.Changes})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// chainAuditEntry fills in the ID, time, and hashes of an entry appended after the entry
+// with lastID and lastHash, or after none when lastID is 0
+func chainAuditEntry(entry *AuditEntry, lastID int64, lastHash string) {
+	entry.ID = lastID + 1
+	entry.PrevHash = lastHash
+	if lastID == 0 {
+		entry.PrevHash = AuditGenesisHash
+	}
+	// Stored timestamps keep microseconds, so the hash is computed over what is read back
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	if len(entry.Changes) == 0 {
+		entry.Changes = json.RawMessage("{}")
+	}
+	entry.Hash = entry.ComputeHash()
+}
+
+// AuditFilter selects and paginates audit entries, newest first. Zero-valued fields are not
+// filtered on.
+type AuditFilter struct {
+	Action        string
+	Actor         string
+	Resource      string
+	LinkID        string
+	TransactionID string
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+	// BeforeID continues a listing from the entry before this one
+	BeforeID int64
+	Limit    int
+}
+
+// DeadLetterFilter selects and paginates webhook dead letters, newest first. Zero-valued
+// fields are not filtered on.
+type DeadLetterFilter struct {
+	EventType string
+	URL       string
+	// BeforeID continues a listing from the dead letter before this one
+	BeforeID int64
+	Limit    int
+}
+
+// LinkFilter selects and paginates stored links. Zero-valued fields are not filtered on.
+type LinkFilter struct {
+	Reference   string
+	Status      string
+	Currency    string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// TransactionID selects the link whose most recent transaction has this ID
+	TransactionID string
+	// CustomerID selects the links associated with a customer
+	CustomerID string
+	// ExpiresBefore selects links whose expiry date is earlier than this time
+	ExpiresBefore time.Time
+	// Metadata selects links whose metadata has every one of these keys with the same value
+	Metadata map[string]string
+	// Limit is the maximum number of links to return
+	Limit int
+	// After continues a listing from the position encoded in a previous page's cursor
+	After *LinkCursor
+}
+
+// LinkCursor identifies a position in a listing ordered by creation time, newest first
+type LinkCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// Encode returns the opaque string form of the cursor used in API responses
+func (c LinkCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeLinkCursor parses a cursor produced by LinkCursor.Encode
+func DecodeLinkCursor(encoded string) (*LinkCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, errors.New("invalid cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &LinkCursor{CreatedAt: t, ID: id}, nil
+}
+
+// encodeMetadata returns the JSON stored for a link's metadata, {} when it has none
+func encodeMetadata(metadata map[string]string) string {
+	if len(metadata) == 0 {
+		return "{}"
+	}
+	// A map of strings always encodes
+	encoded, _ := json.Marshal(metadata)
+	return string(encoded)
+}
+
+// decodeMetadata reads a link's stored metadata, returning nil when it has none
+func decodeMetadata(encoded []byte) (map[string]string, error) {
+	var metadata map[string]string
+	if err := json.Unmarshal(encoded, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode link metadata: %w", err)
+	}
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	return metadata, nil
+}
+
+// transactionNotRecorded explains why RecordTransaction updated no link, given the error
+// reading the link back
+func transactionNotRecorded(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrLinkNotFound
+	case err != nil:
+		return fmt.Errorf("failed to get payment link: %w", err)
+	default:
+		return ErrLinkUnchanged
+	}
+}
+
+// sortedKeys returns the keys of a metadata filter in order, so queries are built the same way each time
+func sortedKeys(metadata map[string]string) []string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LinkUpdate describes changes to a stored link's details. Nil fields are left unchanged.
+type LinkUpdate struct {
+	Amount    *int64
+	ExpiresAt *time.Time
+}
+
+// LinkStore persists payment links created by this server
+type LinkStore interface {
+	// CreateLink records a newly created link, and event in the outbox unless it is nil
+	CreateLink(ctx context.Context, link *Link, event *OutboxEvent) error
+	// GetLink returns the link with the given ID or ErrLinkNotFound
+	GetLink(ctx context.Context, id string) (*Link, error)
+	// GetLinkByShortCode returns the link with the given shortlink code or ErrLinkNotFound
+	GetLinkByShortCode(ctx context.Context, code string) (*Link, error)
+	// ListLinks returns links matching filter, newest first
+	ListLinks(ctx context.Context, filter LinkFilter) ([]*Link, error)
+	// UpdateStatus sets a link's status, and records event in the outbox unless it is nil,
+	// returning ErrLinkNotFound for unknown links
+	UpdateStatus(ctx context.Context, id, status string, event *OutboxEvent) error
+	// UpdateLink applies update to a link, returning ErrLinkNotFound for unknown links
+	UpdateLink(ctx context.Context, id string, update LinkUpdate) error
+	// RecordTransaction records a transaction against a link that is not yet paid, marking
+	// the link PAID when paid is set and otherwise leaving its status as it is, records event
+	// in the outbox unless it is nil, and returns the updated link. Without recording the
+	// event, it returns ErrTransactionRecorded when transactionID with transactionStatus was
+	// recorded before, even if the link has since moved on, so a repeated or replayed
+	// notification is applied once whichever instance receives it, and ErrLinkUnchanged for
+	// links already paid, so a late or out-of-order notification cannot undo a payment. It
+	// returns ErrLinkNotFound for unknown links.
+	RecordTransaction(ctx context.Context, id string, paid bool, transactionID, transactionStatus string, event *OutboxEvent) (*Link, error)
+	// UpdateTransactionStatus sets the status of the transaction a link already records, such
+	// as once it is captured or refunded, returning ErrLinkNotFound unless the link took
+	// transactionID and, when from is given, the transaction's status is one of from
+	UpdateTransactionStatus(ctx context.Context, id, transactionID, transactionStatus string, from ...string) error
+	// RecordReminder counts a payment reminder sent for a link, returning ErrLinkNotFound for unknown links
+	RecordReminder(ctx context.Context, id string) error
+	// SetRemindersOptOut turns payment reminders off or back on for a link
+	// and returns the updated link, or ErrLinkNotFound
+	SetRemindersOptOut(ctx context.Context, id string, optOut bool) (*Link, error)
+	// PruneLinks deletes links that are no longer active and were last updated before
+	// the given time, along with their deliveries and views, and returns how many links were deleted
+	PruneLinks(ctx context.Context, updatedBefore time.Time) (int64, error)
+	// AnonymizeLinks clears the reference, phone number, metadata, and customer of links that
+	// are no longer active and were last updated before the given time, the recipients of
+	// their deliveries, and the user agents and referrers of their views, and returns how many
+	// links were anonymized
+	AnonymizeLinks(ctx context.Context, updatedBefore time.Time) (int64, error)
+	// CreateCustomer records a new customer
+	CreateCustomer(ctx context.Context, customer *Customer) error
+	// GetCustomer returns the customer with the given ID or ErrCustomerNotFound
+	GetCustomer(ctx context.Context, id string) (*Customer, error)
+	// EraseCustomer deletes a customer and the personal data tied to them: the reference,
+	// phone number, and metadata of their links, the recipients of those links' deliveries,
+	// the customer fields and scheduled installments of their recurring series, and velocity
+	// entries and dead-lettered events naming them. It returns ErrCustomerNotFound for
+	// unknown customers.
+	EraseCustomer(ctx context.Context, id string) (*CustomerErasure, error)
+	// ListIdleCustomers returns the IDs of up to limit customers last updated before the
+	// given time who have no active links and no links updated since
+	ListIdleCustomers(ctx context.Context, idleSince time.Time, limit int) ([]string, error)
+	// AnonymizeCustomers clears the name, email, and phone number of the customers
+	// ListIdleCustomers would return, and returns how many were anonymized
+	AnonymizeCustomers(ctx context.Context, idleSince time.Time) (int64, error)
+	// CreateTemplate records a new link template
+	CreateTemplate(ctx context.Context, template *LinkTemplate) error
+	// GetTemplate returns the link template with the given ID or ErrTemplateNotFound
+	GetTemplate(ctx context.Context, id string) (*LinkTemplate, error)
+	// ListTemplates returns every link template, ordered by name
+	ListTemplates(ctx context.Context) ([]*LinkTemplate, error)
+	// UpdateTemplate replaces the fields of a link template, returning ErrTemplateNotFound for unknown templates
+	UpdateTemplate(ctx context.Context, template *LinkTemplate) error
+	// DeleteTemplate deletes a link template, returning ErrTemplateNotFound for unknown templates
+	DeleteTemplate(ctx context.Context, id string) error
+	// SaveProduct records a catalog product, replacing the product with the same SKU if there is one
+	SaveProduct(ctx context.Context, product *Product) error
+	// GetProduct returns the catalog product with the given SKU or ErrProductNotFound
+	GetProduct(ctx context.Context, sku string) (*Product, error)
+	// ListProducts returns every catalog product, ordered by SKU
+	ListProducts(ctx context.Context) ([]*Product, error)
+	// DeleteProduct deletes a catalog product, returning ErrProductNotFound for unknown SKUs
+	DeleteProduct(ctx context.Context, sku string) error
+	// ClaimPromoCode counts a use of a promo code, returning ErrPromoCodeUsedUp when
+	// maxUses have already been claimed. A maxUses of 0 means no limit.
+	ClaimPromoCode(ctx context.Context, code string, maxUses int) error
+	// ReleasePromoCode gives back a use claimed for a link that could not be created
+	ReleasePromoCode(ctx context.Context, code string) error
+	// NextReferenceSequence returns the next number, starting at 1, of the sequence named
+	// scope that generated references are numbered from
+	NextReferenceSequence(ctx context.Context, scope string) (int64, error)
+	// ReserveVelocity counts a link towards each of the velocity keys in entries, returning a
+	// *VelocityLimitError instead when that would take one over any of limits. Links are
+	// counted in any currency and amounts in the limit's. Limits are checked and entries
+	// recorded in one step, so concurrent reservations cannot go over a limit together.
+	// The returned reservation is what ReleaseVelocity takes.
+	ReserveVelocity(ctx context.Context, entries []VelocityEntry, limits []VelocityLimit) (string, error)
+	// ReleaseVelocity deletes the entries of a reservation made for a link that could not be created
+	ReleaseVelocity(ctx context.Context, reservation string) error
+	// PruneVelocity deletes velocity entries recorded before the given time
+	PruneVelocity(ctx context.Context, before time.Time) error
+	// ReserveRefund records refund against its transaction, returning a *RefundLimitError
+	// instead when that would take the transaction's refunds past captured. Refunds are
+	// checked and recorded in one step, so concurrent refunds cannot go over together.
+	ReserveRefund(ctx context.Context, refund *Refund, captured int64) error
+	// ReleaseRefund deletes the reservation of a refund GP did not make
+	ReleaseRefund(ctx context.Context, reservation string) error
+	// CreateSeries records a recurring link series and its installments
+	CreateSeries(ctx context.Context, series *LinkSeries, installments []*Installment) error
+	// GetSeries returns the series with the given ID or ErrSeriesNotFound
+	GetSeries(ctx context.Context, id string) (*LinkSeries, error)
+	// ListInstallments returns the installments of a series in order, with their links' state
+	ListInstallments(ctx context.Context, seriesID string) ([]*Installment, error)
+	// ListDueInstallments returns up to limit installments due by the given time that have
+	// no link yet and fewer than maxAttempts failed attempts, earliest first
+	ListDueInstallments(ctx context.Context, dueBy time.Time, maxAttempts, limit int) ([]*Installment, error)
+	// RecordInstallmentAttempt records an attempt to create an installment's link: the link's
+	// ID when it was created, or the error when it was not
+	RecordInstallmentAttempt(ctx context.Context, seriesID string, number int, linkID, attemptError string) error
+	// CreateDelivery records a delivery attempt and assigns its ID
+	CreateDelivery(ctx context.Context, delivery *Delivery) error
+	// UpdateDeliveryStatus updates the delivery with the given provider ID, or returns ErrDeliveryNotFound
+	UpdateDeliveryStatus(ctx context.Context, providerID, status, deliveryError string) error
+	// ListDeliveries returns the delivery attempts for a link, oldest first
+	ListDeliveries(ctx context.Context, linkID string) ([]*Delivery, error)
+	// RecordLinkView records a visit to a link's shortlink and assigns its ID
+	RecordLinkView(ctx context.Context, view *LinkView) error
+	// ListLinkViews returns up to limit of the visits to a link's shortlink, newest first
+	ListLinkViews(ctx context.Context, linkID string, limit int) ([]*LinkView, error)
+	// SaveReceipt records the payment that settled a link, replacing any receipt saved for it before
+	SaveReceipt(ctx context.Context, receipt *Receipt) error
+	// GetReceipt returns the receipt saved for a link, or ErrReceiptNotFound
+	GetReceipt(ctx context.Context, linkID string) (*Receipt, error)
+	// AppendAudit adds entry to the end of the audit log, setting its ID, time, and hashes
+	AppendAudit(ctx context.Context, entry *AuditEntry) error
+	// ListAudit returns the audit entries matching filter, newest first
+	ListAudit(ctx context.Context, filter AuditFilter) ([]*AuditEntry, error)
+	// CreateAdminSession records a dashboard sign-in, deleting sessions that have expired
+	CreateAdminSession(ctx context.Context, session *AdminSession) error
+	// GetAdminSession returns the unexpired session with the given token hash, or ErrAdminSessionNotFound
+	GetAdminSession(ctx context.Context, tokenHash string) (*AdminSession, error)
+	// DeleteAdminSession revokes a session; revoking one that does not exist is not an error
+	DeleteAdminSession(ctx context.Context, tokenHash string) error
+	// CreateWebhookDeadLetter records a webhook event that could not be delivered and assigns its ID
+	CreateWebhookDeadLetter(ctx context.Context, letter *WebhookDeadLetter) error
+	// ListWebhookDeadLetters returns the dead letters matching filter, newest first
+	ListWebhookDeadLetters(ctx context.Context, filter DeadLetterFilter) ([]*WebhookDeadLetter, error)
+	// GetWebhookDeadLetter returns a dead letter by ID, or ErrDeadLetterNotFound
+	GetWebhookDeadLetter(ctx context.Context, id int64) (*WebhookDeadLetter, error)
+	// RecordWebhookDeadLetterRetry counts a failed redelivery of a dead letter and its error,
+	// returning ErrDeadLetterNotFound for unknown dead letters
+	RecordWebhookDeadLetterRetry(ctx context.Context, id int64, lastError string) error
+	// DeleteWebhookDeadLetter removes a dead letter once it has been redelivered, returning
+	// ErrDeadLetterNotFound for unknown dead letters
+	DeleteWebhookDeadLetter(ctx context.Context, id int64) error
+	// ClaimOutboxEvents leases up to limit undelivered events, oldest first, so no other
+	// relay delivers them until lease has passed
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]*OutboxEvent, error)
+	// DeleteOutboxEvent removes a delivered event from the outbox
+	DeleteOutboxEvent(ctx context.Context, id int64) error
+	// PruneWebhookDeadLetters deletes webhook dead letters recorded before the given time
+	// and returns how many were deleted
+	PruneWebhookDeadLetters(ctx context.Context, before time.Time) (int64, error)
+	// AnonymizeWebhookDeadLetters removes the reference, phone number, customer, and metadata
+	// of the link in the payloads of webhook dead letters recorded before the given time, and
+	// returns how many were anonymized
+	AnonymizeWebhookDeadLetters(ctx context.Context, before time.Time) (int64, error)
+	// Reencrypt encrypts the personal data still in plain text or encrypted with an older
+	// key with the current key, returning ErrEncryptionDisabled when there are no keys
+	Reencrypt(ctx context.Context) (*Reencryption, error)
+	// Ping checks that the store is reachable
+	Ping(ctx context.Context) error
+	// Close releases the store's resources
+	Close() error
+}
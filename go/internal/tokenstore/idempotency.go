@@ -0,0 +1,80 @@
+package tokenstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// idempotencyPrefix namespaces the idempotency records written by this package
+const idempotencyPrefix = "paybylink:idempotency:"
+
+// pendingResponse is the value of a key reserved by a request that has not finished. A
+// recorded response is never empty.
+const pendingResponse = ""
+
+// releaseIdempotencyScript deletes a reservation only while it is still pending, so a
+// response recorded by another instance is left alone
+var releaseIdempotencyScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Idempotency records the responses to requests made with an idempotency key in Redis, so
+// a request retried against any instance gets the first response
+type Idempotency struct {
+	client *redis.Client
+}
+
+// NewIdempotency returns an idempotency store keeping records in Redis through client.
+// Closing client is left to the caller.
+func NewIdempotency(client *redis.Client) *Idempotency {
+	return &Idempotency{client: client}
+}
+
+// Reserve claims key for lease with SETNX. When another request already holds it, Reserve
+// returns false with the response recorded for it, or a nil response while that request
+// is still in progress.
+func (i *Idempotency) Reserve(ctx context.Context, key string, lease time.Duration) ([]byte, bool, error) {
+	reserved, err := i.client.SetNX(ctx, idempotencyPrefix+key, pendingResponse, lease).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if reserved {
+		return nil, true, nil
+	}
+
+	response, err := i.client.Get(ctx, idempotencyPrefix+key).Bytes()
+	// A reservation that expired since SETNX belonged to a request that stopped, which
+	// the caller treats as still in progress
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read idempotency key: %w", err)
+	}
+	if len(response) == 0 {
+		return nil, false, nil
+	}
+	return response, false, nil
+}
+
+// Complete records response for a reserved key and keeps it for ttl
+func (i *Idempotency) Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error {
+	if err := i.client.Set(ctx, idempotencyPrefix+key, response, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to record idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release gives up a reservation without recording a response, so the request can be retried
+func (i *Idempotency) Release(ctx context.Context, key string) error {
+	if err := releaseIdempotencyScript.Run(ctx, i.client, []string{idempotencyPrefix + key}, pendingResponse).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Package tokenstore shares state between server instances through Redis: GP API access
+// tokens, so replicas reuse one token instead of each requesting their own from
+// /accesstoken, and the responses recorded for idempotency keys.
+package tokenstore
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+)
+
+// keyPrefix namespaces the keys written by this package
+const keyPrefix = "paybylink:gp-token:"
+
+// releaseScript deletes the lock only if it is still held by the caller,
+// so a lock that expired and was claimed by another instance is left alone
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// storedToken is the value saved in Redis
+type storedToken struct {
+	Token     *gpapi.TokenResponse `json:"token"`
+	ExpiresAt time.Time            `json:"expires_at"`
+}
+
+// Redis is a gpapi.TokenStore backed by Redis
+type Redis struct {
+	client  *redis.Client
+	key     string
+	lockKey string
+}
+
+// Connect connects to the Redis server at redisURL, e.g. redis://localhost:6379/0
+func Connect(ctx context.Context, redisURL string) (*redis.Client, error) {
+	options, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	client := redis.NewClient(options)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return client, nil
+}
+
+// NewRedis returns a token store keeping tokens in Redis through client. Tokens are stored
+// under a key derived from scope, such as the GP API base URL and credentials, so
+// instances using different credentials never share a token. Only a hash of scope is
+// stored. Closing client is left to the caller.
+func NewRedis(client *redis.Client, scope string) *Redis {
+	hash := sha256.Sum256([]byte(scope))
+	key := keyPrefix + hex.EncodeToString(hash[:8])
+	return &Redis{client: client, key: key, lockKey: key + ":lock"}
+}
+
+// Load returns the stored token and when it expires, or a nil token when none is stored
+func (r *Redis) Load(ctx context.Context) (*gpapi.TokenResponse, time.Time, error) {
+	value, err := r.client.Get(ctx, r.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to load token: %w", err)
+	}
+
+	var stored storedToken
+	if err := json.Unmarshal(value, &stored); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to decode stored token: %w", err)
+	}
+	return stored.Token, stored.ExpiresAt, nil
+}
+
+// Save stores token until expiresAt, after which Redis removes it
+func (r *Redis) Save(ctx context.Context, token *gpapi.TokenResponse, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	value, err := json.Marshal(storedToken{Token: token, ExpiresAt: expiresAt})
+	if err != nil {
+		return fmt.Errorf("failed to encode token: %w", err)
+	}
+	if err := r.client.Set(ctx, r.key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+	return nil
+}
+
+// TryLock claims the right to fetch a new token for up to ttl
+func (r *Redis) TryLock(ctx context.Context, ttl time.Duration) (func(), bool, error) {
+	b := make([]byte, 8)
+	rand.Read(b)
+	owner := hex.EncodeToString(b)
+
+	ok, err := r.client.SetNX(ctx, r.lockKey, owner, ttl).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to lock token refresh: %w", err)
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	release := func() {
+		releaseScript.Run(context.WithoutCancel(ctx), r.client, []string{r.lockKey}, owner)
+	}
+	return release, true, nil
+}
@@ -0,0 +1,166 @@
+package wallet
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// PKPassContentType is the media type of an Apple Wallet pass
+const PKPassContentType = "application/vnd.apple.pkpass"
+
+// AppleSigner signs Apple Wallet passes with a Pass Type ID certificate
+type AppleSigner struct {
+	passTypeID string
+	teamID     string
+	cert       *x509.Certificate
+	key        crypto.Signer
+	// wwdr is Apple's Worldwide Developer Relations intermediate, which issued cert
+	wwdr *x509.Certificate
+}
+
+// LoadAppleSigner reads the Pass Type ID certificate and key from PEM files, and Apple's
+// WWDR intermediate certificate from a PEM or DER file
+func LoadAppleSigner(passTypeID, teamID, certFile, keyFile, wwdrFile string) (*AppleSigner, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pass certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pass certificate: %w", err)
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("pass certificate key cannot sign")
+	}
+
+	data, err := os.ReadFile(wwdrFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read WWDR certificate: %w", err)
+	}
+	// Apple publishes the WWDR certificate in DER form; a PEM copy is accepted too
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	wwdr, err := x509.ParseCertificate(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse WWDR certificate: %w", err)
+	}
+	return &AppleSigner{passTypeID: passTypeID, teamID: teamID, cert: cert, key: key, wwdr: wwdr}, nil
+}
+
+// passField is a label and value shown on an Apple Wallet pass
+type passField struct {
+	Key       string `json:"key"`
+	Label     string `json:"label,omitempty"`
+	Value     string `json:"value"`
+	DateStyle string `json:"dateStyle,omitempty"`
+	TimeStyle string `json:"timeStyle,omitempty"`
+}
+
+// passJSON builds the pass.json of a generic Apple Wallet pass
+func (a *AppleSigner) passJSON(pass Pass) ([]byte, error) {
+	foreground, label := "rgb(0, 0, 0)", "rgb(70, 70, 70)"
+	if pass.darkBackground() {
+		foreground, label = "rgb(255, 255, 255)", "rgb(220, 220, 220)"
+	}
+	r, g, b := pass.rgb()
+	fields := map[string][]passField{
+		"primaryFields":   {{Key: "amount", Label: "AMOUNT DUE", Value: pass.Amount}},
+		"secondaryFields": {{Key: "reference", Label: "REFERENCE", Value: pass.Reference}},
+		"auxiliaryFields": {{Key: "expires", Label: "PAY BY", Value: pass.ExpiresAt.UTC().Format(time.RFC3339),
+			DateStyle: "PKDateStyleMedium", TimeStyle: "PKDateStyleShort"}},
+		// Wallet makes the URL on the back of the pass a link that opens the payment page
+		"backFields": {{Key: "link", Label: "Pay online", Value: pass.URL}},
+	}
+	return json.Marshal(map[string]interface{}{
+		"formatVersion":      1,
+		"passTypeIdentifier": a.passTypeID,
+		"teamIdentifier":     a.teamID,
+		"serialNumber":       pass.SerialNumber,
+		"organizationName":   pass.Organization,
+		"description":        pass.Description,
+		"logoText":           pass.Organization,
+		"expirationDate":     pass.ExpiresAt.UTC().Format(time.RFC3339),
+		"backgroundColor":    fmt.Sprintf("rgb(%d, %d, %d)", r, g, b),
+		"foregroundColor":    foreground,
+		"labelColor":         label,
+		"barcodes": []map[string]string{{
+			"format":          "PKBarcodeFormatQR",
+			"message":         pass.URL,
+			"messageEncoding": "iso-8859-1",
+			"altText":         "Scan to pay",
+		}},
+		"generic": fields,
+	})
+}
+
+// passFile is a file in a .pkpass archive
+type passFile struct {
+	name    string
+	content []byte
+}
+
+// Build returns the signed .pkpass file of pass: a zip of pass.json, the icons, a manifest
+// of their SHA-1 hashes, and a PKCS #7 signature of the manifest
+func (a *AppleSigner) Build(pass Pass) ([]byte, error) {
+	passJSON, err := a.passJSON(pass)
+	if err != nil {
+		return nil, err
+	}
+	icon, err := pass.icon(29)
+	if err != nil {
+		return nil, err
+	}
+	icon2x, err := pass.icon(58)
+	if err != nil {
+		return nil, err
+	}
+	files := []passFile{
+		{"pass.json", passJSON},
+		{"icon.png", icon},
+		{"icon@2x.png", icon2x},
+	}
+
+	manifest := make(map[string]string, len(files))
+	for _, file := range files {
+		sum := sha1.Sum(file.content)
+		manifest[file.name] = hex.EncodeToString(sum[:])
+	}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := signDetached(manifestJSON, a.cert, a.key, []*x509.Certificate{a.wwdr}, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign pass: %w", err)
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files = append(files, passFile{"manifest.json", manifestJSON}, passFile{"signature", signature})
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(file.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
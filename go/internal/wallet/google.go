@@ -0,0 +1,168 @@
+package wallet
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+)
+
+// googleSaveURL is where a signed pass is opened to add it to Google Wallet
+const googleSaveURL = "https://pay.google.com/gp/v/save/"
+
+// googleClassSuffix names the pass class every payment link pass belongs to, under the issuer
+const googleClassSuffix = "pay_by_link"
+
+// googleIDPattern matches the characters Google Wallet allows in class and object IDs
+var googleIDPattern = regexp.MustCompile(`^[A-Za-z0-9._\-]+$`)
+
+// issuerIDPattern matches a Google Wallet issuer ID
+var issuerIDPattern = regexp.MustCompile(`^[0-9]+$`)
+
+// GoogleIssuer signs Google Wallet passes with a service account of a Wallet issuer
+type GoogleIssuer struct {
+	issuerID    string
+	clientEmail string
+	key         *rsa.PrivateKey
+}
+
+// serviceAccountKey holds the fields used from a Google Cloud service account key file
+type serviceAccountKey struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+}
+
+// LoadGoogleIssuer reads the JSON key of a service account authorized for the Google
+// Wallet issuer issuerID
+func LoadGoogleIssuer(issuerID, serviceAccountFile string) (*GoogleIssuer, error) {
+	if !issuerIDPattern.MatchString(issuerID) {
+		return nil, errors.New("issuer ID must be the number shown in the Google Pay & Wallet Console")
+	}
+	data, err := os.ReadFile(serviceAccountFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account key: %w", err)
+	}
+	var account serviceAccountKey
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("failed to parse service account key: %w", err)
+	}
+	if account.ClientEmail == "" {
+		return nil, errors.New("service account key has no client_email")
+	}
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, errors.New("service account key has no PEM private_key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse service account private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("service account private key must be an RSA key")
+	}
+	return &GoogleIssuer{issuerID: issuerID, clientEmail: account.ClientEmail, key: key}, nil
+}
+
+// localizedString is a Google Wallet text in its default language
+type localizedString struct {
+	DefaultValue translatedString `json:"defaultValue"`
+}
+
+type translatedString struct {
+	Language string `json:"language"`
+	Value    string `json:"value"`
+}
+
+// localized returns value as a Google Wallet text in English
+func localized(value string) localizedString {
+	return localizedString{DefaultValue: translatedString{Language: "en", Value: value}}
+}
+
+// genericObject builds the Google Wallet generic pass object of pass
+func (g *GoogleIssuer) genericObject(pass Pass) map[string]interface{} {
+	object := map[string]interface{}{
+		"id":                 g.issuerID + "." + pass.SerialNumber,
+		"classId":            g.issuerID + "." + googleClassSuffix,
+		"state":              "ACTIVE",
+		"cardTitle":          localized(pass.Organization),
+		"subheader":          localized(pass.Description),
+		"header":             localized(pass.Amount),
+		"hexBackgroundColor": pass.backgroundColor(),
+		"barcode": map[string]string{
+			"type":          "QR_CODE",
+			"value":         pass.URL,
+			"alternateText": "Scan to pay",
+		},
+		"textModulesData": []map[string]string{
+			{"id": "reference", "header": "Reference", "body": pass.Reference},
+			{"id": "expires", "header": "Pay by", "body": pass.ExpiresAt.UTC().Format("2 Jan 2006 15:04 MST")},
+		},
+		"linksModuleData": map[string]interface{}{
+			"uris": []map[string]string{{"id": "pay", "uri": pass.URL, "description": "Pay online"}},
+		},
+		// Wallet shows the pass as expired once the link can no longer be paid
+		"validTimeInterval": map[string]interface{}{
+			"end": map[string]string{"date": pass.ExpiresAt.UTC().Format(time.RFC3339)},
+		},
+	}
+	if pass.LogoURL != "" {
+		object["logo"] = map[string]interface{}{
+			"sourceUri":          map[string]string{"uri": pass.LogoURL},
+			"contentDescription": localized(pass.Organization),
+		}
+	}
+	return object
+}
+
+// SaveURL returns the Add to Google Wallet URL of pass. The URL carries the pass and its
+// class in a JWT signed by the service account, so Google creates both when it is opened
+// and nothing is sent to the Google Wallet API beforehand.
+func (g *GoogleIssuer) SaveURL(pass Pass) (string, error) {
+	if !googleIDPattern.MatchString(pass.SerialNumber) {
+		return "", fmt.Errorf("invalid pass serial number %q", pass.SerialNumber)
+	}
+	claims := map[string]interface{}{
+		"iss": g.clientEmail,
+		"aud": "google",
+		"typ": "savetowallet",
+		"iat": time.Now().Unix(),
+		"payload": map[string]interface{}{
+			"genericClasses": []map[string]string{{"id": g.issuerID + "." + googleClassSuffix}},
+			"genericObjects": []map[string]interface{}{g.genericObject(pass)},
+		},
+	}
+	token, err := signJWT(claims, g.key)
+	if err != nil {
+		return "", err
+	}
+	return googleSaveURL + token, nil
+}
+
+// signJWT encodes claims as a JWT signed with RS256
+func signJWT(claims interface{}, key *rsa.PrivateKey) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign pass: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,150 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// Object identifiers of the PKCS #7 signature Apple Wallet checks
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSASHA256   = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// contentInfo wraps the signed data, or names the type of the detached content
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      contentInfo
+	Certificates     asn1.RawValue
+	SignerInfos      []signerInfo `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version                   int
+	IssuerAndSerialNumber     issuerAndSerialNumber
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   asn1.RawValue
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+// signDetached returns a DER-encoded PKCS #7 signature of content by key, whose certificate
+// is cert, carrying cert and the intermediates that chain it to a root. The content itself
+// is left out of the signature, as Apple Wallet expects of a pass's manifest.
+func signDetached(content []byte, cert *x509.Certificate, key crypto.Signer, intermediates []*x509.Certificate, now time.Time) ([]byte, error) {
+	digest := sha256.Sum256(content)
+	attributes, err := signedAttributes(digest[:], now)
+	if err != nil {
+		return nil, err
+	}
+	// The signature covers the attributes encoded as a SET, not as the [0] they are sent in
+	set, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: attributes})
+	if err != nil {
+		return nil, err
+	}
+	setDigest := sha256.Sum256(set)
+
+	var signatureAlgorithm pkix.AlgorithmIdentifier
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		signatureAlgorithm = pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue}
+	case *ecdsa.PublicKey:
+		signatureAlgorithm = pkix.AlgorithmIdentifier{Algorithm: oidECDSASHA256}
+	default:
+		return nil, errors.New("unsupported signing key type: must be RSA or ECDSA")
+	}
+	signature, err := key.Sign(rand.Reader, setDigest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	var certificates []byte
+	for _, c := range append([]*x509.Certificate{cert}, intermediates...) {
+		certificates = append(certificates, c.Raw...)
+	}
+	sha256Algorithm := pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
+	signed, err := asn1.Marshal(signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Algorithm},
+		ContentInfo:      contentInfo{ContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certificates},
+		SignerInfos: []signerInfo{{
+			Version:                   1,
+			IssuerAndSerialNumber:     issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, SerialNumber: cert.SerialNumber},
+			DigestAlgorithm:           sha256Algorithm,
+			AuthenticatedAttributes:   asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attributes},
+			DigestEncryptionAlgorithm: signatureAlgorithm,
+			EncryptedDigest:           signature,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signed},
+	})
+}
+
+// signedAttributes returns the content type, signing time, and message digest attributes
+// of a signature, concatenated in the sorted order DER requires of a SET
+func signedAttributes(digest []byte, now time.Time) ([]byte, error) {
+	values := []struct {
+		oid   asn1.ObjectIdentifier
+		value interface{}
+	}{
+		{oidContentType, oidData},
+		{oidSigningTime, now.UTC()},
+		{oidMessageDigest, digest},
+	}
+	encoded := make([][]byte, 0, len(values))
+	for _, v := range values {
+		value, err := asn1.Marshal(v.value)
+		if err != nil {
+			return nil, err
+		}
+		attr, err := asn1.Marshal(attribute{
+			Type:   v.oid,
+			Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, attr)
+	}
+	sort.Slice(encoded, func(i, j int) bool { return bytes.Compare(encoded[i], encoded[j]) < 0 })
+	return bytes.Join(encoded, nil), nil
+}
@@ -0,0 +1,82 @@
+// Package wallet builds Apple Wallet and Google Wallet passes for payment links. A pass
+// shows the amount, reference, and expiry of a link, with a QR code of the link's URL, so a
+// customer can keep it in their wallet app and open the payment page from it.
+package wallet
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"time"
+)
+
+// DefaultColor is the background color of passes when none is configured
+const DefaultColor = "#003A70"
+
+// Pass is the content of a wallet pass for a payment link
+type Pass struct {
+	// SerialNumber identifies the pass among those of the same type, such as the link ID
+	SerialNumber string
+	// Organization names the merchant on the pass
+	Organization string
+	// Description is a short summary of the pass, read by VoiceOver and shown in lists
+	Description string
+	// Amount is the amount due as shown to the customer, such as 25.00 EUR
+	Amount    string
+	Reference string
+	// ExpiresAt is when the link expires, after which the pass is shown as expired
+	ExpiresAt time.Time
+	// URL opens the payment page. It is encoded in the pass's QR code and offered as a link.
+	URL string
+	// Color is the background color as #RRGGBB, or empty for DefaultColor
+	Color string
+	// LogoURL is the HTTPS address of the merchant's logo, shown on Google Wallet passes
+	LogoURL string
+}
+
+// rgb returns the red, green, and blue components of the pass's color
+func (p Pass) rgb() (r, g, b uint8) {
+	hex := p.Color
+	if len(hex) != 7 || hex[0] != '#' {
+		hex = DefaultColor
+	}
+	value, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		value, _ = strconv.ParseUint(DefaultColor[1:], 16, 32)
+	}
+	return uint8(value >> 16), uint8(value >> 8), uint8(value)
+}
+
+// backgroundColor returns the pass's color as #RRGGBB
+func (p Pass) backgroundColor() string {
+	r, g, b := p.rgb()
+	return fmt.Sprintf("#%02X%02X%02X", r, g, b)
+}
+
+// darkBackground reports whether text on the pass's color should be light, by its
+// relative luminance
+func (p Pass) darkBackground() bool {
+	r, g, b := p.rgb()
+	return 0.2126*float64(r)+0.7152*float64(g)+0.0722*float64(b) < 140
+}
+
+// icon draws a square PNG of the pass's color, size pixels across, for the notifications
+// and lock screen of Apple Wallet, which require an icon in every pass
+func (p Pass) icon(size int) ([]byte, error) {
+	r, g, b := p.rgb()
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	fill := color.RGBA{R: r, G: g, B: b, A: 0xFF}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.SetRGBA(x, y, fill)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,248 @@
+package wallet
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testPass = Pass{
+	SerialNumber: "LNK_1",
+	Organization: "Example Shop",
+	Description:  "Payment request INV-1",
+	Amount:       "10.50 USD",
+	Reference:    "INV-1",
+	ExpiresAt:    time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC),
+	URL:          "https://pay.example/s/abc123",
+	Color:        "#FFFFFF",
+}
+
+// writePEM writes a PEM block of type kind holding der to a file in dir
+func writePEM(t *testing.T, dir, name, kind string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+// newCertificate issues a certificate for key from template, signed by parent and its key,
+// or self-signed when parent is nil
+func newCertificate(t *testing.T, template *x509.Certificate, key *rsa.PrivateKey, parent *x509.Certificate, parentKey *rsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	return cert
+}
+
+func TestAppleBuild(t *testing.T) {
+	dir := t.TempDir()
+	wwdrKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	wwdr := newCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "Test WWDR"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour),
+		IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign,
+	}, wwdrKey, nil, nil)
+	passKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	passCert := newCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "Pass Type ID: pass.com.example.paybylink"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour), KeyUsage: x509.KeyUsageDigitalSignature,
+	}, passKey, wwdr, wwdrKey)
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(passKey)
+	// Apple publishes the WWDR certificate in DER form
+	wwdrFile := filepath.Join(dir, "wwdr.cer")
+	if err := os.WriteFile(wwdrFile, wwdr.Raw, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	signer, err := LoadAppleSigner("pass.com.example.paybylink", "TEAM123456",
+		writePEM(t, dir, "pass.pem", "CERTIFICATE", passCert.Raw), writePEM(t, dir, "pass-key.pem", "PRIVATE KEY", keyDER), wwdrFile)
+	if err != nil {
+		t.Fatalf("LoadAppleSigner: %v", err)
+	}
+	pkpass, err := signer.Build(testPass)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(pkpass), int64(len(pkpass)))
+	if err != nil {
+		t.Fatalf("opening pkpass: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", f.Name, err)
+		}
+		files[f.Name], _ = io.ReadAll(r)
+		r.Close()
+	}
+
+	var manifest map[string]string
+	if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+		t.Fatalf("decoding manifest: %v", err)
+	}
+	for _, name := range []string{"pass.json", "icon.png", "icon@2x.png"} {
+		sum := sha1.Sum(files[name])
+		if manifest[name] != hex.EncodeToString(sum[:]) {
+			t.Errorf("manifest hash of %s = %q, want %x", name, manifest[name], sum)
+		}
+	}
+
+	var pass struct {
+		PassTypeIdentifier string `json:"passTypeIdentifier"`
+		SerialNumber       string `json:"serialNumber"`
+		ExpirationDate     string `json:"expirationDate"`
+		ForegroundColor    string `json:"foregroundColor"`
+		Barcodes           []struct {
+			Message string `json:"message"`
+		} `json:"barcodes"`
+	}
+	if err := json.Unmarshal(files["pass.json"], &pass); err != nil {
+		t.Fatalf("decoding pass.json: %v", err)
+	}
+	if pass.PassTypeIdentifier != "pass.com.example.paybylink" || pass.SerialNumber != "LNK_1" ||
+		pass.ExpirationDate != "2030-01-02T15:04:05Z" || len(pass.Barcodes) != 1 || pass.Barcodes[0].Message != testPass.URL {
+		t.Errorf("pass.json = %s, want the link's pass", files["pass.json"])
+	}
+	// Text on a white pass is dark
+	if pass.ForegroundColor != "rgb(0, 0, 0)" {
+		t.Errorf("foregroundColor = %q, want black", pass.ForegroundColor)
+	}
+
+	// The signature is a detached PKCS #7 signature of the manifest by the pass certificate,
+	// carrying the chain to the WWDR certificate
+	var outer contentInfo
+	if _, err := asn1.Unmarshal(files["signature"], &outer); err != nil || !outer.ContentType.Equal(oidSignedData) {
+		t.Fatalf("signature is not signed data: %v", err)
+	}
+	var signed signedData
+	if _, err := asn1.Unmarshal(outer.Content.Bytes, &signed); err != nil {
+		t.Fatalf("decoding signed data: %v", err)
+	}
+	certs, err := x509.ParseCertificates(signed.Certificates.Bytes)
+	if err != nil || len(certs) != 2 || !certs[0].Equal(passCert) || !certs[1].Equal(wwdr) {
+		t.Fatalf("signature certificates = %d (%v), want the pass and WWDR certificates", len(certs), err)
+	}
+	info := signed.SignerInfos[0]
+	set, _ := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: info.AuthenticatedAttributes.Bytes})
+	setDigest := sha256.Sum256(set)
+	if err := rsa.VerifyPKCS1v15(&passKey.PublicKey, crypto.SHA256, setDigest[:], info.EncryptedDigest); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+	manifestDigest := sha256.Sum256(files["manifest.json"])
+	var digest []byte
+	for rest := info.AuthenticatedAttributes.Bytes; len(rest) > 0; {
+		var attr attribute
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			t.Fatalf("decoding signed attributes: %v", err)
+		}
+		if attr.Type.Equal(oidMessageDigest) {
+			asn1.Unmarshal(attr.Values.Bytes, &digest)
+		}
+	}
+	if !bytes.Equal(digest, manifestDigest[:]) {
+		t.Errorf("signed message digest = %x, want the manifest's %x", digest, manifestDigest)
+	}
+}
+
+func TestGoogleSaveURL(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(key)
+	account, _ := json.Marshal(serviceAccountKey{
+		ClientEmail: "wallet@example.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})),
+	})
+	accountFile := filepath.Join(t.TempDir(), "service-account.json")
+	if err := os.WriteFile(accountFile, account, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if _, err := LoadGoogleIssuer("example-issuer", accountFile); err == nil {
+		t.Error("LoadGoogleIssuer accepted an issuer ID that is not a number")
+	}
+	issuer, err := LoadGoogleIssuer("3388000000012345678", accountFile)
+	if err != nil {
+		t.Fatalf("LoadGoogleIssuer: %v", err)
+	}
+
+	pass := testPass
+	pass.Color = ""
+	pass.LogoURL = "https://shop.example/logo.png"
+	saveURL, err := issuer.SaveURL(pass)
+	if err != nil {
+		t.Fatalf("SaveURL: %v", err)
+	}
+	token, ok := strings.CutPrefix(saveURL, googleSaveURL)
+	parts := strings.Split(token, ".")
+	if !ok || len(parts) != 3 {
+		t.Fatalf("SaveURL = %q, want a JWT under %s", saveURL, googleSaveURL)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("JWT signature does not verify: %v", err)
+	}
+
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		Iss     string `json:"iss"`
+		Aud     string `json:"aud"`
+		Typ     string `json:"typ"`
+		Payload struct {
+			GenericObjects []struct {
+				ID                 string            `json:"id"`
+				ClassID            string            `json:"classId"`
+				HexBackgroundColor string            `json:"hexBackgroundColor"`
+				Barcode            map[string]string `json:"barcode"`
+				Logo               json.RawMessage   `json:"logo"`
+			} `json:"genericObjects"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("decoding JWT claims: %v", err)
+	}
+	if claims.Iss != "wallet@example.iam.gserviceaccount.com" || claims.Aud != "google" || claims.Typ != "savetowallet" ||
+		len(claims.Payload.GenericObjects) != 1 {
+		t.Fatalf("claims = %s, want a save to wallet JWT of one pass", payload)
+	}
+	object := claims.Payload.GenericObjects[0]
+	if object.ID != "3388000000012345678.LNK_1" || object.ClassID != "3388000000012345678.pay_by_link" ||
+		object.HexBackgroundColor != DefaultColor || object.Barcode["value"] != pass.URL || object.Logo == nil {
+		t.Errorf("pass object = %s, want the link's pass in the default color with a logo", payload)
+	}
+
+	pass.SerialNumber = "LNK 1"
+	if _, err := issuer.SaveURL(pass); err == nil {
+		t.Error("SaveURL accepted a serial number Google Wallet does not allow")
+	}
+}
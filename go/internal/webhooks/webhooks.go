@@ -0,0 +1,262 @@
+// Package webhooks posts signed link events to merchant-configured endpoints, retrying
+// failed deliveries with exponential backoff and dead-lettering those that never succeed.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// Link event types
+const (
+	EventLinkCreated   = "link.created"
+	EventLinkPaid      = "link.paid"
+	EventLinkExpired   = "link.expired"
+	EventLinkCancelled = "link.cancelled"
+	// EventLinkViewed is published to the event publisher alone, when a link's shortlink
+	// is opened; merchant webhooks announce changes to links
+	EventLinkViewed = "link.viewed"
+)
+
+// Headers sent with each event
+const (
+	SignatureHeader = "X-PayByLink-Signature"
+	EventIDHeader   = "X-PayByLink-Event-Id"
+	EventTypeHeader = "X-PayByLink-Event-Type"
+)
+
+// Delivery limits
+const (
+	deliveryTimeout = 10 * time.Second
+	maxRetryBackoff = 5 * time.Minute
+	userAgent       = "PayByLink-Go-Webhooks/1.0"
+)
+
+// ErrEndpointNotConfigured is returned when redelivering to an endpoint that is no longer
+// one of the configured webhook URLs
+var ErrEndpointNotConfigured = errors.New("endpoint is not a configured webhook URL")
+
+// Event is the JSON body posted to merchant endpoints
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      *store.Link `json:"data"`
+}
+
+// DeadLetterStore records events that could not be delivered
+type DeadLetterStore interface {
+	CreateWebhookDeadLetter(ctx context.Context, letter *store.WebhookDeadLetter) error
+}
+
+// Dispatcher delivers events to every configured endpoint in the background
+type Dispatcher struct {
+	urls         []string
+	secret       []byte
+	maxAttempts  int
+	retryBackoff time.Duration
+	deadLetters  DeadLetterStore
+	http         *http.Client
+
+	wg       sync.WaitGroup
+	stopping chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDispatcher creates a Dispatcher that posts to the endpoints in cfg with client.
+// Deliveries that fail every attempt are recorded in deadLetters.
+func NewDispatcher(cfg config.Webhooks, deadLetters DeadLetterStore, client *http.Client) *Dispatcher {
+	return &Dispatcher{
+		urls:         cfg.URLs,
+		secret:       []byte(cfg.Secret),
+		maxAttempts:  cfg.MaxAttempts,
+		retryBackoff: cfg.RetryBackoff,
+		deadLetters:  deadLetters,
+		http:         client,
+		stopping:     make(chan struct{}),
+	}
+}
+
+// NewEvent creates an event of the given type about link with a new event ID
+func NewEvent(eventType string, link *store.Link) Event {
+	return Event{ID: newEventID(), Type: eventType, CreatedAt: time.Now().UTC(), Data: link}
+}
+
+// Publish sends event to every endpoint without blocking the caller. Once every endpoint
+// has the event or has it dead-lettered, done is called, unless it is nil, with false when
+// the event could not be encoded or a dead letter could not be recorded, so the caller
+// can keep the event to send again. A nil Dispatcher calls done with true straight away.
+func (d *Dispatcher) Publish(event Event, done func(recorded bool)) {
+	if done == nil {
+		done = func(bool) {}
+	}
+	if d == nil || len(d.urls) == 0 {
+		done(true)
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("Error encoding webhook event", "event_type", event.Type, "error", err)
+		done(false)
+		return
+	}
+
+	// The last delivery to finish reports whether every endpoint's was recorded
+	var mu sync.Mutex
+	pending, recorded := len(d.urls), true
+	for _, url := range d.urls {
+		d.wg.Add(1)
+		go func(url string) {
+			defer d.wg.Done()
+			ok := d.deliver(event, url, payload)
+
+			mu.Lock()
+			pending--
+			recorded = recorded && ok
+			last := pending == 0
+			mu.Unlock()
+			if last {
+				done(recorded)
+			}
+		}(url)
+	}
+}
+
+// Close stops retrying and waits up to ctx's deadline for deliveries in progress.
+// Events still waiting for a retry are dead-lettered so they are not lost.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	d.stopOnce.Do(func() { close(d.stopping) })
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhook deliveries still in progress: %w", ctx.Err())
+	}
+}
+
+// Redeliver posts a dead letter's payload to its endpoint once, under its original event
+// ID with a new signature, so an operator can resend it after fixing the endpoint. Only
+// endpoints that are still configured are sent to.
+func (d *Dispatcher) Redeliver(letter *store.WebhookDeadLetter) error {
+	if d == nil || !slices.Contains(d.urls, letter.URL) {
+		return ErrEndpointNotConfigured
+	}
+	return d.post(Event{ID: letter.EventID, Type: letter.EventType}, letter.URL, []byte(letter.Payload))
+}
+
+// deliver posts payload to url, retrying with exponential backoff, and dead-letters the
+// event once every attempt has failed or the dispatcher is closing. It returns false when
+// the event was neither delivered nor dead-lettered.
+func (d *Dispatcher) deliver(event Event, url string, payload []byte) bool {
+	logger := slog.With("event_id", event.ID, "event_type", event.Type, "url", url)
+	backoff := d.retryBackoff
+
+	var lastErr error
+	attempts := 0
+	for attempts < d.maxAttempts {
+		attempts++
+		if lastErr = d.post(event, url, payload); lastErr == nil {
+			logger.Info("Webhook event delivered", "attempts", attempts)
+			return true
+		}
+		logger.Warn("Webhook delivery failed", "attempt", attempts, "error", lastErr)
+		if attempts == d.maxAttempts {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-d.stopping:
+			lastErr = fmt.Errorf("server shut down before retrying: %w", lastErr)
+			attempts = d.maxAttempts
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+
+	letter := &store.WebhookDeadLetter{
+		EventID:   event.ID,
+		EventType: event.Type,
+		URL:       url,
+		Payload:   string(payload),
+		Attempts:  attempts,
+		LastError: lastErr.Error(),
+	}
+	if err := d.deadLetters.CreateWebhookDeadLetter(context.Background(), letter); err != nil {
+		logger.Error("Error recording webhook dead letter", "error", err)
+		return false
+	}
+	logger.Error("Webhook event dead-lettered", "attempts", attempts, "dead_letter_id", letter.ID, "error", lastErr)
+	return true
+}
+
+// post sends one delivery attempt, allowing it deliveryTimeout. Any 2xx response counts as delivered.
+func (d *Dispatcher) post(event Event, url string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(EventIDHeader, event.ID)
+	req.Header.Set(EventTypeHeader, event.Type)
+	req.Header.Set(SignatureHeader, Sign(d.secret, time.Now(), payload))
+
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the signature header value for payload sent at timestamp, in the form
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<unix seconds>.<payload>">". Including the
+// timestamp lets receivers reject replayed events.
+func Sign(secret []byte, timestamp time.Time, payload []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(t + "."))
+	mac.Write(payload)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newEventID generates a random event identifier
+func newEventID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "evt_" + hex.EncodeToString(b)
+}
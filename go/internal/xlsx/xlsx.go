@@ -0,0 +1,273 @@
+// Package xlsx writes Excel workbooks holding a single worksheet. Rows are written
+// straight through to the underlying writer as they are added, so a sheet of any size
+// is never held in memory.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of an Excel workbook
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// maxCellLength is the most characters Excel holds in a cell; longer text is truncated
+const maxCellLength = 32767
+
+// maxSheetNameLength is the longest worksheet name Excel accepts
+const maxSheetNameLength = 31
+
+// Cell styles, indexes into cellXfs in styles.xml
+const (
+	styleDefault = 0
+	styleTime    = 1
+	styleHeader  = 2
+)
+
+// excelEpoch is day zero of Excel's date serial numbers
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// numberPattern matches the decimal numbers a Number may hold
+var numberPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// ErrClosed is returned when writing to a Writer that has been closed
+var ErrClosed = errors.New("xlsx: writer is closed")
+
+// Number is a decimal number written as a numeric cell exactly as given, such as an
+// amount formatted in major currency units, avoiding the rounding of a float64. One that
+// is not a plain decimal number is written as text.
+type Number string
+
+// Writer streams a workbook's single worksheet row by row. Close must be called to
+// finish the workbook; until then the output is not a valid file.
+type Writer struct {
+	zip    *zip.Writer
+	sheet  *bufio.Writer
+	err    error
+	closed bool
+}
+
+// NewWriter starts a workbook on w with one worksheet named sheetName
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	sheetName = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, sheetName)
+	if sheetName == "" {
+		sheetName = "Sheet1"
+	}
+	if utf8.RuneCountInString(sheetName) > maxSheetNameLength {
+		sheetName = string([]rune(sheetName)[:maxSheetNameLength])
+	}
+
+	zw := zip.NewWriter(w)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", fmt.Sprintf(workbook, escape(sheetName))},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+		{"xl/styles.xml", styles},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("xlsx: failed to write %s: %w", part.name, err)
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, fmt.Errorf("xlsx: failed to write %s: %w", part.name, err)
+		}
+	}
+
+	// The worksheet is written last, so it can stay open while rows are added
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: failed to write the worksheet: %w", err)
+	}
+	sheet := bufio.NewWriter(f)
+	sheet.WriteString(sheetStart)
+	return &Writer{zip: zw, sheet: sheet}, nil
+}
+
+// WriteHeader adds a row of column names in bold
+func (w *Writer) WriteHeader(names ...string) error {
+	cells := make([]interface{}, len(names))
+	for i, name := range names {
+		cells[i] = name
+	}
+	return w.writeRow(styleHeader, cells)
+}
+
+// WriteRow adds a row of cells. Each cell may be a string, Number, int, int64, float64,
+// bool, or time.Time, which is written in UTC; nil leaves the cell empty.
+func (w *Writer) WriteRow(cells ...interface{}) error {
+	return w.writeRow(styleDefault, cells)
+}
+
+// writeRow adds a row whose text cells have the given style
+func (w *Writer) writeRow(style int, cells []interface{}) error {
+	if w.closed {
+		return ErrClosed
+	}
+	if w.err != nil {
+		return w.err
+	}
+
+	b := w.sheet
+	b.WriteString("<row>")
+	for i, cell := range cells {
+		switch v := cell.(type) {
+		case nil:
+			b.WriteString("<c/>")
+		case string:
+			if v == "" {
+				b.WriteString("<c/>")
+				continue
+			}
+			writeText(b, style, v)
+		case Number:
+			if !numberPattern.MatchString(string(v)) {
+				writeText(b, style, string(v))
+				continue
+			}
+			fmt.Fprintf(b, "<c><v>%s</v></c>", v)
+		case int:
+			fmt.Fprintf(b, "<c><v>%d</v></c>", v)
+		case int64:
+			fmt.Fprintf(b, "<c><v>%d</v></c>", v)
+		case float64:
+			fmt.Fprintf(b, "<c><v>%s</v></c>", strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			value := 0
+			if v {
+				value = 1
+			}
+			fmt.Fprintf(b, `<c t="b"><v>%d</v></c>`, value)
+		case time.Time:
+			if v.IsZero() {
+				b.WriteString("<c/>")
+				continue
+			}
+			days := float64(v.UTC().Sub(excelEpoch)) / float64(24*time.Hour)
+			fmt.Fprintf(b, `<c s="%d"><v>%s</v></c>`, styleTime, strconv.FormatFloat(days, 'f', -1, 64))
+		default:
+			w.err = fmt.Errorf("xlsx: unsupported type %T in column %d", cell, i+1)
+			return w.err
+		}
+	}
+	if _, err := b.WriteString("</row>"); err != nil {
+		w.err = fmt.Errorf("xlsx: failed to write a row: %w", err)
+	}
+	return w.err
+}
+
+// Flush writes rows buffered so far to the underlying writer
+func (w *Writer) Flush() error {
+	if w.err != nil || w.closed {
+		return w.err
+	}
+	if err := w.sheet.Flush(); err != nil {
+		w.err = fmt.Errorf("xlsx: failed to write rows: %w", err)
+	}
+	return w.err
+}
+
+// Close finishes the worksheet and the workbook. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrClosed
+	}
+	w.closed = true
+	if w.err != nil {
+		return w.err
+	}
+	w.sheet.WriteString(sheetEnd)
+	if err := w.sheet.Flush(); err != nil {
+		return fmt.Errorf("xlsx: failed to finish the worksheet: %w", err)
+	}
+	if err := w.zip.Close(); err != nil {
+		return fmt.Errorf("xlsx: failed to finish the workbook: %w", err)
+	}
+	return nil
+}
+
+// writeText writes an inline string cell, which Excel never evaluates as a formula
+func writeText(b *bufio.Writer, style int, text string) {
+	if utf8.RuneCountInString(text) > maxCellLength {
+		text = string([]rune(text)[:maxCellLength])
+	}
+	if style == styleDefault {
+		b.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+	} else {
+		fmt.Fprintf(b, `<c t="inlineStr" s="%d"><is><t xml:space="preserve">`, style)
+	}
+	xml.EscapeText(b, []byte(text))
+	b.WriteString("</t></is></c>")
+}
+
+// escape returns text escaped for an XML attribute
+func escape(text string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(text))
+	return sb.String()
+}
+
+const contentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const rootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// workbook is formatted with the escaped sheet name
+const workbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const workbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// styles defines the cell styles: the default, a date and time, and bold header text
+const styles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`</cellXfs>` +
+	`</styleSheet>`
+
+// sheetStart and sheetEnd enclose the worksheet's rows. The header row stays in view
+// while scrolling.
+const sheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>` +
+	`<sheetData>`
+
+const sheetEnd = `</sheetData></worksheet>`
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/httpclient"
+	"github.com/globalpayments/pay-by-link-go/internal/money"
+	"github.com/globalpayments/pay-by-link-go/internal/server"
+)
+
+// Link listing limits for the list subcommand
+const (
+	defaultListDays  = 10
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// setupCommand loads the configuration for a link subcommand, keeping informational logs
+// off the terminal so the command's output can be read by scripts
+func setupCommand() (*config.Config, error) {
+	cfg, err := loadConfig(os.Stderr, slog.LevelWarn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// printJSON writes v to w as indented JSON
+func printJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// newCreateCommand creates the create subcommand, which validates and records links exactly
+// as POST /create-payment-link does
+func newCreateCommand() *cobra.Command {
+	var (
+		amount, shippingAmount int64
+		usageLimit             int
+		expirationDays         int
+		req                    server.PaymentLinkRequest
+		shippable, jsonOutput  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a payment link",
+		Example: `  paybylink create --amount 1099 --currency EUR --reference INV-1001 \
+    --name "Invoice 1001" --description "Consulting, March"`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Amounts are given in minor units on the command line and in major units to the server
+			req.Amount = money.FormatMinorUnits(amount, req.Currency)
+			if cmd.Flags().Changed("usage-limit") {
+				req.UsageLimit = fmt.Sprint(usageLimit)
+			}
+			if cmd.Flags().Changed("expiration-days") {
+				req.ExpirationDays = fmt.Sprint(expirationDays)
+			}
+			if cmd.Flags().Changed("shipping-amount") {
+				req.ShippingAmount = money.FormatMinorUnits(shippingAmount, req.Currency)
+			}
+			if cmd.Flags().Changed("shippable") {
+				req.Shippable = "false"
+				if shippable {
+					req.Shippable = "true"
+				}
+			}
+
+			cfg, err := setupCommand()
+			if err != nil {
+				return err
+			}
+			client, err := httpclient.New(cfg.HTTPClient)
+			if err != nil {
+				return fmt.Errorf("error setting up HTTP client: %w", err)
+			}
+			gp, closeTokens, err := newGPClient(cfg, client, nil)
+			if err != nil {
+				return err
+			}
+			defer closeTokens()
+
+			links, err := openLinkStore(cfg.Store)
+			if err != nil {
+				return fmt.Errorf("error opening link store: %w", err)
+			}
+			defer links.Close()
+
+			srv := server.New(cfg, gp, links, nil, client)
+			if err := setEventPublisher(srv, cfg.EventPublisher); err != nil {
+				return fmt.Errorf("error connecting to the event publisher: %w", err)
+			}
+			link, err := srv.CreateLink(cmd.Context(), req)
+
+			// Give the link.created event a chance to reach merchant webhooks and the event
+			// publisher before exiting; events left in the outbox are sent by the server
+			ctx, cancel := context.WithTimeout(context.Background(), eventFlushTimeout)
+			defer cancel()
+			srv.RelayOutbox(ctx)
+			if closeErr := srv.Close(ctx); closeErr != nil {
+				slog.Warn("Link events were not all delivered before exit", "error", closeErr)
+			}
+
+			if err != nil {
+				return linkRequestError(err)
+			}
+			if jsonOutput {
+				return printJSON(cmd.OutOrStdout(), link)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintf(w, "Link ID:\t%s\n", link.LinkID)
+			fmt.Fprintf(w, "Payment link:\t%s\n", link.PaymentLink)
+			fmt.Fprintf(w, "Reference:\t%s\n", link.Reference)
+			fmt.Fprintf(w, "Amount:\t%s %s\n", link.DisplayAmount, link.Currency)
+			fmt.Fprintf(w, "Usage:\t%s, limit %d\n", link.UsageMode, link.UsageLimit)
+			fmt.Fprintf(w, "Capture:\t%s\n", link.CaptureMode)
+			fmt.Fprintf(w, "Expires:\t%s\n", link.ExpiresAt)
+			fmt.Fprintf(w, "Payment methods:\t%s\n", strings.Join(link.PaymentMethods, ", "))
+			if len(link.DigitalWallets) > 0 {
+				fmt.Fprintf(w, "Digital wallets:\t%s\n", strings.Join(link.DigitalWallets, ", "))
+			}
+			return w.Flush()
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.Int64Var(&amount, "amount", 0, "amount in minor units, e.g. 1099 for 10.99 EUR")
+	flags.StringVar(&req.Currency, "currency", "", "ISO 4217 currency code, such as EUR")
+	flags.StringVar(&req.Reference, "reference", "", "merchant reference, such as an invoice number (default generated from REFERENCE_FORMAT)")
+	flags.StringVar(&req.Name, "name", "", "name shown on the payment page")
+	flags.StringVar(&req.Description, "description", "", "description shown on the payment page")
+	flags.StringVar(&req.UsageMode, "usage-mode", "", "SINGLE or MULTIPLE (default SINGLE)")
+	flags.IntVar(&usageLimit, "usage-limit", 1, "payments allowed on a MULTIPLE usage link")
+	flags.IntVar(&expirationDays, "expiration-days", 0, "days until the link expires (default 10)")
+	flags.StringVar(&req.ExpirationDate, "expiration-date", "", "RFC3339 time the link expires, instead of --expiration-days")
+	flags.StringVar(&req.PaymentMethods, "payment-methods", "", "comma-separated payment methods (default GP_API_PAYMENT_METHODS)")
+	flags.StringVar(&req.DigitalWallets, "digital-wallets", "", "comma-separated wallets offered under DIGITAL_WALLET (default GP_API_DIGITAL_WALLETS)")
+	flags.BoolVar(&shippable, "shippable", false, "collect a shipping address (default GP_API_SHIPPABLE)")
+	flags.Int64Var(&shippingAmount, "shipping-amount", 0, "shipping charge in minor units")
+	flags.StringVar(&req.Country, "country", "", "merchant country code (default GP_API_COUNTRY)")
+	flags.StringVar(&req.CaptureMode, "capture-mode", "", "AUTO, or LATER to only authorize payments (default GP_API_CAPTURE_MODE)")
+	flags.StringToStringVar(&req.Metadata, "metadata", nil, "key=value pairs stored with the link, such as order=1234,campaign=spring")
+	flags.StringVar(&req.MerchantID, "merchant-id", "", "merchant to create the link for, from MERCHANT_ACCOUNTS")
+	flags.StringVar(&req.AccountName, "account-name", "", "account to create the link under, from MERCHANT_ACCOUNTS")
+	flags.StringVar(&req.DynamicDescriptor, "dynamic-descriptor", "", "descriptor shown on the payer's card statement, up to 22 characters")
+	flags.StringVar(&req.PayerEmail, "payer-email", "", "payer email address, sent to GP for fraud screening")
+	flags.StringVar(&req.BillingCountry, "billing-country", "", "payer billing country code, sent to GP for fraud screening")
+	flags.StringVar(&req.PayerIP, "payer-ip", "", "payer IP address, sent to GP for fraud screening")
+	flags.StringVar(&req.Language, "language", "", "language tag of the hosted payment page, such as es or fr-CA")
+	flags.StringVar(&req.BrandDisplayName, "brand-display-name", "", "merchant name shown on the hosted payment page (default BRAND_DISPLAY_NAME)")
+	flags.StringVar(&req.BrandLogoURL, "brand-logo-url", "", "HTTPS URL of the logo shown on the hosted payment page (default BRAND_LOGO_URL)")
+	flags.StringArrayVar(&req.Images, "image", nil, "HTTPS URL of a product image shown on the hosted payment page; repeat for several")
+	flags.StringVar(&req.BrandColor, "brand-color", "", "hex color of the hosted payment page, such as #1A73E8 (default BRAND_COLOR)")
+	flags.BoolVar(&jsonOutput, "json", false, "print the link as JSON")
+	for _, name := range []string{"amount", "currency", "name", "description"} {
+		cmd.MarkFlagRequired(name)
+	}
+	return cmd
+}
+
+// linkRequestError adds the invalid fields of a failed link request to its message
+func linkRequestError(err error) error {
+	var linkErr *server.LinkRequestError
+	if !errors.As(err, &linkErr) || len(linkErr.Fields) == 0 {
+		return err
+	}
+	messages := make([]string, len(linkErr.Fields))
+	for i, field := range linkErr.Fields {
+		messages[i] = field.Message
+	}
+	return fmt.Errorf("%s: %s", linkErr.Code, strings.Join(messages, "; "))
+}
+
+// newListCommand creates the list subcommand, which searches GP API for recent links
+func newListCommand() *cobra.Command {
+	var (
+		days, limit int
+		jsonOutput  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List recently created payment links, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if days < 1 {
+				return fmt.Errorf("--days must be at least 1")
+			}
+			if limit < 1 || limit > maxListLimit {
+				return fmt.Errorf("--limit must be between 1 and %d", maxListLimit)
+			}
+
+			cfg, err := setupCommand()
+			if err != nil {
+				return err
+			}
+			client, err := httpclient.New(cfg.HTTPClient)
+			if err != nil {
+				return fmt.Errorf("error setting up HTTP client: %w", err)
+			}
+			gp, closeTokens, err := newGPClient(cfg, client, nil)
+			if err != nil {
+				return err
+			}
+			defer closeTokens()
+
+			to := time.Now()
+			links, err := gp.SearchLinks(cmd.Context(), to.AddDate(0, 0, -days), to, limit)
+			if err != nil {
+				return fmt.Errorf("error searching payment links: %w", err)
+			}
+			if jsonOutput {
+				return printJSON(cmd.OutOrStdout(), links)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tSTATUS\tAMOUNT\tREFERENCE\tEXPIRES")
+			for _, link := range links {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", link.ID, link.Status,
+					displayAmount(link.Transactions.Amount, link.Transactions.Currency), link.Reference, link.ExpirationDate)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().IntVar(&days, "days", defaultListDays, "list links created in this many past days")
+	cmd.Flags().IntVar(&limit, "limit", defaultListLimit, fmt.Sprintf("maximum number of links to list, up to %d", maxListLimit))
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print the links as JSON")
+	return cmd
+}
+
+// newStatusCommand creates the status subcommand, which looks a link up in GP API
+func newStatusCommand() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "status <link-id>",
+		Short: "Show a payment link's status and transactions",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := setupCommand()
+			if err != nil {
+				return err
+			}
+			client, err := httpclient.New(cfg.HTTPClient)
+			if err != nil {
+				return fmt.Errorf("error setting up HTTP client: %w", err)
+			}
+			gp, closeTokens, err := newGPClient(cfg, client, nil)
+			if err != nil {
+				return err
+			}
+			defer closeTokens()
+
+			link, err := gp.GetLink(cmd.Context(), args[0])
+			if err != nil {
+				return fmt.Errorf("error looking up payment link: %w", err)
+			}
+			if jsonOutput {
+				return printJSON(cmd.OutOrStdout(), link)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintf(w, "Link ID:\t%s\n", link.ID)
+			fmt.Fprintf(w, "Payment link:\t%s\n", link.URL)
+			fmt.Fprintf(w, "Status:\t%s\n", link.Status)
+			fmt.Fprintf(w, "Reference:\t%s\n", link.Reference)
+			fmt.Fprintf(w, "Name:\t%s\n", link.Name)
+			fmt.Fprintf(w, "Amount:\t%s\n", displayAmount(link.Transactions.Amount, link.Transactions.Currency))
+			fmt.Fprintf(w, "Usage:\t%s, %s of %s used\n", link.UsageMode, link.UsageCount, link.UsageLimit)
+			fmt.Fprintf(w, "Viewed:\t%s times\n", link.ViewedCount)
+			fmt.Fprintf(w, "Expires:\t%s\n", link.ExpirationDate)
+			if len(link.Transactions.TransactionList) > 0 {
+				fmt.Fprintln(w)
+				fmt.Fprintln(w, "TRANSACTION\tSTATUS\tAMOUNT\tCREATED")
+				for _, transaction := range link.Transactions.TransactionList {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", transaction.ID, transaction.Status,
+						displayAmount(transaction.Amount, transaction.Currency), transaction.TimeCreated)
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print the link as GP API returned it, as JSON")
+	return cmd
+}
+
+// displayAmount formats a GP API amount in minor units as a decimal amount and currency
+func displayAmount(amount json.Number, currency string) string {
+	minor, err := amount.Int64()
+	if err != nil {
+		return amount.String() + " " + currency
+	}
+	return money.FormatMinorUnits(minor, currency) + " " + currency
+}
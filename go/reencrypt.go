@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/globalpayments/pay-by-link-go/internal/store"
+)
+
+// newReencryptCommand creates the reencrypt subcommand, which moves the personal data in
+// the link store to the current encryption key after a key rotation, or encrypts data
+// stored before encryption was turned on
+func newReencryptCommand() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "reencrypt",
+		Short: "Encrypt stored personal data with the current encryption key",
+		Long: `Encrypt stored personal data with the current encryption key.
+
+Values still in plain text, or encrypted with an older key version, are rewritten with
+the key named by ENCRYPTION_KEY_VERSION. Keep the older keys in ENCRYPTION_KEYS until
+this has finished. It can run while the server is serving, and can be run again if it
+is interrupted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := setupCommand()
+			if err != nil {
+				return err
+			}
+			links, err := openLinkStore(cfg.Store)
+			if err != nil {
+				return fmt.Errorf("error opening link store: %w", err)
+			}
+			defer links.Close()
+
+			result, err := links.Reencrypt(cmd.Context())
+			if errors.Is(err, store.ErrEncryptionDisabled) {
+				return errors.New("set ENCRYPTION_KEYS or ENCRYPTION_KEYS_FILE to re-encrypt the link store")
+			}
+			if err != nil {
+				if result != nil {
+					printReencryption(cmd, result)
+				}
+				return fmt.Errorf("error re-encrypting the link store: %w", err)
+			}
+			if jsonOutput {
+				return printJSON(cmd.OutOrStdout(), result)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Re-encrypted with key version %d:\n", cfg.Store.Encryption.Version)
+			return printReencryption(cmd, result)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print the rows re-encrypted in each table as JSON")
+	return cmd
+}
+
+// printReencryption lists the rows re-encrypted in each table
+func printReencryption(cmd *cobra.Command, result *store.Reencryption) error {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Links:\t%d\n", result.Links)
+	fmt.Fprintf(w, "Deliveries:\t%d\n", result.Deliveries)
+	fmt.Fprintf(w, "Customers:\t%d\n", result.Customers)
+	fmt.Fprintf(w, "Templates:\t%d\n", result.Templates)
+	fmt.Fprintf(w, "Outbox events:\t%d\n", result.Outbox)
+	fmt.Fprintf(w, "Series:\t%d\n", result.Series)
+	fmt.Fprintf(w, "Dead letters:\t%d\n", result.DeadLetters)
+	return w.Flush()
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/cobra"
+
+	"github.com/globalpayments/pay-by-link-go/internal/config"
+	"github.com/globalpayments/pay-by-link-go/internal/gpapi"
+	"github.com/globalpayments/pay-by-link-go/internal/httpclient"
+	"github.com/globalpayments/pay-by-link-go/internal/notify"
+	"github.com/globalpayments/pay-by-link-go/internal/server"
+	"github.com/globalpayments/pay-by-link-go/internal/tokenstore"
+	"github.com/globalpayments/pay-by-link-go/internal/tracing"
+)
+
+// newServeCommand creates the serve subcommand
+func newServeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the Pay by Link API and browser client (the default)",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			serve()
+		},
+	}
+}
+
+// serve runs the HTTP server until it is stopped, exiting the process on startup errors
+func serve() {
+	cfg, err := loadConfig(os.Stdout, slog.LevelDebug)
+	if err != nil {
+		fatal("Invalid configuration", err)
+	}
+
+	// Export traces over OTLP when an endpoint is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Error setting up tracing", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Warn("Error flushing traces", "error", err)
+		}
+	}()
+	if cfg.Tracing.Enabled {
+		slog.Info("Tracing enabled", "service_name", cfg.Tracing.ServiceName)
+	}
+
+	// One HTTP client, and so one connection pool, serves GP API, Twilio, webhook, and Slack calls
+	client, err := httpclient.New(cfg.HTTPClient)
+	if err != nil {
+		fatal("Error setting up HTTP client", err)
+	}
+	if cfg.HTTPClient.CABundle != "" {
+		slog.Info("Trusting additional certificate authorities", "ca_bundle", cfg.HTTPClient.CABundle)
+	}
+
+	// One Redis connection shares access tokens and idempotency records with other instances
+	var rdb *redis.Client
+	if cfg.RedisURL != "" {
+		if rdb, err = connectRedis(cfg.RedisURL); err != nil {
+			fatal("Error setting up GP API client", err)
+		}
+		defer rdb.Close()
+	}
+	gp, _, err := newGPClient(cfg, client, rdb)
+	if err != nil {
+		fatal("Error setting up GP API client", err)
+	}
+	// Calls go through a client that reloading the configuration can replace
+	swappable := gpapi.NewSwappableClient(gp)
+
+	switch {
+	case len(cfg.APIKeys.Keys) > 0:
+		slog.Info("API key authentication enabled", "keys", len(cfg.APIKeys.Keys), "public_config", cfg.APIKeys.PublicConfig)
+	case cfg.APIKeys.AllowUnauthenticated:
+		slog.Warn("API_KEYS is not set and ALLOW_UNAUTHENTICATED is true; every endpoint is open to anyone who can reach this server")
+	default:
+		slog.Warn("API_KEYS is not set; only link creation is served, and endpoints that read or change links and customer data are disabled until API_KEYS or ALLOW_UNAUTHENTICATED=true is set")
+	}
+
+	// Open the link store
+	links, err := openLinkStore(cfg.Store)
+	if err != nil {
+		fatal("Error opening link store", err)
+	}
+	defer links.Close()
+	slog.Info("Link store opened", "driver", cfg.Store.Driver)
+	if len(cfg.Store.Encryption.Keys) > 0 {
+		slog.Info("Encrypting personal data in the link store", "key_version", cfg.Store.Encryption.Version, "key_versions", len(cfg.Store.Encryption.Keys))
+	}
+
+	// Configure SMS delivery of payment links (optional)
+	var sms notify.Notifier
+	if cfg.SMS.Provider == "twilio" {
+		sms, err = notify.NewTwilioNotifier(
+			cfg.SMS.TwilioAccountSID,
+			cfg.SMS.TwilioAuthToken,
+			cfg.SMS.TwilioFromNumber,
+			cfg.SMS.TwilioStatusCallbackURL,
+			client,
+		)
+		if err != nil {
+			links.Close()
+			fatal("Invalid SMS configuration", err)
+		}
+		slog.Info("SMS delivery enabled", "channel", sms.Channel())
+	}
+	if cfg.Slack.WebhookURL != "" {
+		slog.Info("Slack notifications enabled", "events", cfg.Slack.Events)
+	}
+
+	srv := server.New(cfg, swappable, links, sms, client)
+	srv.SetReloader(reloader(cfg, swappable, client, rdb))
+	if rdb != nil {
+		srv.SetIdempotencyStore(tokenstore.NewIdempotency(rdb))
+		slog.Info("Sharing idempotency records through Redis")
+	}
+	if err := setEventPublisher(srv, cfg.EventPublisher); err != nil {
+		links.Close()
+		fatal("Invalid event publisher configuration", err)
+	}
+	if cfg.StaticDir != "" {
+		slog.Info("Serving the browser client from disk", "dir", cfg.StaticDir)
+	}
+	srv.LoadCapabilities(context.Background())
+
+	scheme := "http"
+	if cfg.TLS.Enabled() {
+		scheme = "https"
+	}
+	slog.Info("Server starting",
+		"url", scheme+"://localhost:"+cfg.Port,
+		"endpoints", []string{
+			"GET /config",
+			"GET /healthz",
+			"GET /readyz",
+			"GET /admin",
+			"GET /admin/links/{id}",
+			"POST /admin/links/{id}/cancel",
+			"POST /admin/links/{id}/resend",
+			"GET /admin/login",
+			"POST /admin/login",
+			"POST /admin/logout",
+			"GET /admin/oidc/login",
+			"GET /admin/oidc/callback",
+			"GET /admin/config",
+			"POST /admin/reload",
+			"GET /admin/audit",
+			"GET /admin/audit/verify",
+			"GET /admin/retention",
+			"GET /admin/deadletters",
+			"POST /admin/deadletters/{id}/retry",
+			"GET /openapi.json",
+			"GET /docs",
+			"POST /create-payment-link",
+			"POST /create-payment-links",
+			"GET /payment-links",
+			"GET /payment-links/export",
+			"POST /customers",
+			"GET /customers/{id}",
+			"GET /customers/{id}/payment-links",
+			"GET /customers/{id}/export",
+			"DELETE /customers/{id}/data",
+			"GET /link-templates",
+			"POST /link-templates",
+			"GET /link-templates/{id}",
+			"PUT /link-templates/{id}",
+			"DELETE /link-templates/{id}",
+			"GET /products",
+			"GET /products/{sku}",
+			"PUT /products/{sku}",
+			"DELETE /products/{sku}",
+			"POST /recurring-links",
+			"GET /recurring-links/{id}",
+			"POST /graphql",
+			"GET /events",
+			"GET /ws",
+			"GET /payment-link/{id}",
+			"PATCH /payment-link/{id}",
+			"POST /payment-link/{id}/cancel",
+			"POST /payment-link/{id}/send-sms",
+			"POST /payment-link/{id}/reminders",
+			"GET /payment-link/{id}/deliveries",
+			"GET /payment-link/{id}/views",
+			"GET /payment-link/{id}/receipt",
+			"GET /payment-link/{id}/wallet-pass",
+			"GET /payment-link/{id}/transactions",
+			"GET /transactions",
+			"POST /transactions/{id}/capture",
+			"POST /transactions/{id}/refund",
+			"GET /payment-result",
+			"GET /l/{code}",
+			"POST /webhooks/status",
+			"POST /webhooks/sms/status",
+		},
+	)
+	if err := srv.ListenAndServe("0.0.0.0:"+cfg.Port, cfg.ShutdownTimeout); err != nil {
+		links.Close()
+		fatal("Server stopped", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// reloadableSettings are the settings a reload applies. The rest only change on restart.
+var reloadableSettings = []string{
+	"GP_API_APP_ID", "GP_API_APP_KEY", "GP_API_BASE_URL", "GP_API_ENVIRONMENT", "GP_API_VERSION",
+	"IP_ALLOWLIST_ADMIN", "IP_ALLOWLIST_PUBLIC", "IP_DENYLIST_ADMIN", "IP_DENYLIST_PUBLIC",
+}
+
+// reloader returns the function the server calls to reload the configuration. It builds a
+// GP API client from the new GP API settings, which starts without a cached access token,
+// and swaps gp over to it. The new client shares its token through rdb, when it is not
+// nil. Changes to other settings are logged as needing a restart.
+func reloader(cfg *config.Config, gp *gpapi.SwappableClient, client *http.Client, rdb *redis.Client) server.Reloader {
+	current := cfg
+	return func(context.Context) (*config.Config, error) {
+		next, err := reloadConfig()
+		if err != nil {
+			return nil, err
+		}
+		if next.GP.Mock.Enabled != current.GP.Mock.Enabled {
+			return nil, errors.New("GP_API_MOCK cannot be changed without a restart")
+		}
+		// REDIS_URL takes effect on restart, so the new client keeps the connection opened at startup
+		next.RedisURL = cfg.RedisURL
+		nextGP, _, err := newGPClient(next, client, rdb)
+		if err != nil {
+			return nil, err
+		}
+		gp.Swap(nextGP)
+
+		var restart []string
+		for _, name := range config.ChangedSettings(current.Settings, next.Settings) {
+			if !slices.Contains(reloadableSettings, name) {
+				restart = append(restart, name)
+			}
+		}
+		if len(restart) > 0 {
+			slog.Warn("Changed settings take effect on restart", "settings", restart)
+		}
+		current = next
+		return next, nil
+	}
+}
@@ -0,0 +1,139 @@
+// Single-use links always accept exactly one payment
+document.getElementById('usageMode').addEventListener('change', function() {
+    const usageLimit = document.getElementById('usageLimit');
+    if (this.value === 'SINGLE') {
+        usageLimit.value = 1;
+    }
+    usageLimit.disabled = this.value === 'SINGLE';
+});
+document.getElementById('usageLimit').disabled = true;
+
+// The CAPTCHA widget, when the server requires one to create links
+const captchaScripts = {
+    recaptcha: 'https://www.google.com/recaptcha/api.js?onload=renderCaptcha&render=explicit',
+    turnstile: 'https://challenges.cloudflare.com/turnstile/v0/api.js?onload=renderCaptcha&render=explicit'
+};
+let captcha = null;
+let captchaWidget = null;
+// Echoed with each form post, unless the server has CSRF protection off
+let csrfToken = '';
+
+function captchaApi() {
+    return captcha.provider === 'turnstile' ? window.turnstile : window.grecaptcha;
+}
+
+window.renderCaptcha = function() {
+    const container = document.getElementById('captcha');
+    container.classList.remove('gp-hidden');
+    captchaWidget = captchaApi().render(container, { sitekey: captcha.siteKey });
+};
+
+fetch('config')
+    .then(response => response.json())
+    .then(result => {
+        if (result.success && result.data.csrfToken) {
+            csrfToken = result.data.csrfToken;
+        }
+        if (result.success && result.data.captcha && captchaScripts[result.data.captcha.provider]) {
+            captcha = result.data.captcha;
+            const script = document.createElement('script');
+            script.src = captchaScripts[captcha.provider];
+            script.async = true;
+            document.head.appendChild(script);
+        }
+    })
+    .catch(error => console.error('Failed to load configuration:', error));
+
+document.getElementById('payment-link-form').addEventListener('submit', async function(e) {
+    e.preventDefault();
+
+    // Hide previous results/errors
+    document.getElementById('result').classList.add('gp-hidden');
+    document.getElementById('error').classList.add('gp-hidden');
+
+    // Show loading state
+    const submitButton = document.querySelector('button[type="submit"]');
+    const originalText = submitButton.textContent;
+    submitButton.textContent = 'Creating Link...';
+    submitButton.disabled = true;
+
+    // Get form values
+    const formValues = {
+        amount: document.getElementById('amount').value,
+        currency: document.getElementById('currency').value,
+        reference: document.getElementById('reference').value,
+        name: document.getElementById('name').value,
+        description: document.getElementById('description').value,
+        usageMode: document.getElementById('usageMode').value,
+        usageLimit: document.getElementById('usageLimit').value,
+        expirationDays: document.getElementById('expirationDays').value,
+        language: document.getElementById('language').value
+    };
+
+    // Debug: Log the values being sent
+    console.log('Form data being sent:', formValues);
+
+    // Use URLSearchParams for better compatibility
+    const formData = new URLSearchParams();
+    Object.keys(formValues).forEach(key => {
+        formData.append(key, formValues[key]);
+    });
+
+    const headers = {
+        'Content-Type': 'application/x-www-form-urlencoded',
+    };
+    if (csrfToken) {
+        headers['X-CSRF-Token'] = csrfToken;
+    }
+    if (captcha && captchaWidget !== null) {
+        headers['X-Captcha-Token'] = captchaApi().getResponse(captchaWidget) || '';
+    }
+
+    try {
+        // Submit to our API with proper headers
+        const response = await fetch('create-payment-link', {
+            method: 'POST',
+            headers: headers,
+            body: formData
+        });
+
+        const result = await response.json();
+
+        if (result.success) {
+            // Display success result
+            document.getElementById('result-content').innerHTML = `
+                <p><strong>Payment Link:</strong></p>
+                <p><a href="${result.data.paymentLink}" target="_blank" class="gp-button gp-button-secondary">${result.data.paymentLink}</a></p>
+                <p><strong>Link ID:</strong> ${result.data.linkId}</p>
+                <p><strong>Reference:</strong> ${result.data.reference}</p>
+                <p><strong>Amount:</strong> ${result.data.displayAmount} ${result.data.currency}</p>
+                <p><strong>Expires:</strong> ${new Date(result.data.expiresAt).toLocaleString()}</p>
+                <p><strong>Usage:</strong> ${result.data.usageMode} (limit ${result.data.usageLimit})</p>
+            `;
+            document.getElementById('result').classList.remove('gp-hidden');
+        } else {
+            // Display error with details if available
+            let errorMessage = result.message || 'Unknown error occurred';
+            if (result.error && result.error.fields) {
+                errorMessage += ': ' + result.error.fields.map(field => field.message).join('; ');
+            } else if (result.error && result.error.details) {
+                errorMessage += ': ' + result.error.details;
+            }
+            document.getElementById('error-content').textContent = errorMessage;
+            document.getElementById('error').classList.remove('gp-hidden');
+        }
+    } catch (error) {
+        // Display network/parsing error
+        console.error('Request failed:', error);
+        document.getElementById('error-content').textContent = 'Network error: ' + error.message;
+        document.getElementById('error').classList.remove('gp-hidden');
+    } finally {
+        // Reset button state
+        submitButton.textContent = originalText;
+        submitButton.disabled = false;
+        // Each CAPTCHA token can only be verified once
+        if (captcha && captchaWidget !== null) {
+            captchaApi().reset(captchaWidget);
+        }
+    }
+});
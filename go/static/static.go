@@ -0,0 +1,10 @@
+// Package static embeds the browser client served at /, so the compiled binary can be
+// deployed without the static directory beside it.
+package static
+
+import "embed"
+
+// Files holds the browser client. Add a pattern here for any new kind of asset.
+//
+//go:embed *.html *.js
+var Files embed.FS